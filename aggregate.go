@@ -0,0 +1,96 @@
+package apidiags
+
+import (
+	"strings"
+	"sync"
+)
+
+// Aggregator accumulates the warnings in many responses, like each page of
+// a list endpoint, so clients can report them once at the end instead of
+// once per response. Warnings with the same Code and Paths are only kept
+// once; the first one seen is kept, and the rest are counted.
+//
+// The zero value is an empty Aggregator, ready to use. An Aggregator is
+// safe for concurrent use.
+type Aggregator struct {
+	mu      sync.Mutex
+	entries []AggregatedDiagnostic
+	index   map[string]int
+}
+
+// WithAggregator makes ParseResponse add the warnings of every response it
+// parses to aggregator.
+func WithAggregator(aggregator *Aggregator) ResponseOption {
+	return func(c *responseConfig) {
+		c.aggregator = aggregator
+	}
+}
+
+// AggregatedDiagnostic is a warning accumulated by an Aggregator, along with
+// the number of times it was seen.
+type AggregatedDiagnostic struct {
+	Diagnostic Diagnostic
+	Count      int
+}
+
+// Add adds the Diagnostics in diags with a Severity of DiagnosticWarning to
+// the Aggregator. Errors are ignored, as they usually stop a client from
+// continuing, and should be reported right away.
+func (a *Aggregator) Add(diags Diagnostics) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, diag := range diags {
+		if diag.Severity != DiagnosticWarning {
+			continue
+		}
+		key := aggregateKey(diag)
+		if pos, ok := a.index[key]; ok {
+			a.entries[pos].Count++
+			continue
+		}
+		if a.index == nil {
+			a.index = map[string]int{}
+		}
+		a.index[key] = len(a.entries)
+		a.entries = append(a.entries, AggregatedDiagnostic{Diagnostic: diag, Count: 1})
+	}
+}
+
+// Summary returns every warning the Aggregator has accumulated, once per
+// unique Code and Paths, with the number of times each was seen, in the
+// order they were first seen.
+func (a *Aggregator) Summary() []AggregatedDiagnostic {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.entries) < 1 {
+		return nil
+	}
+	return append([]AggregatedDiagnostic(nil), a.entries...)
+}
+
+// Diagnostics returns every warning the Aggregator has accumulated, once per
+// unique Code and Paths, in the order they were first seen.
+func (a *Aggregator) Diagnostics() Diagnostics {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.entries) < 1 {
+		return nil
+	}
+	results := make(Diagnostics, 0, len(a.entries))
+	for _, entry := range a.entries {
+		results = append(results, entry.Diagnostic)
+	}
+	return results
+}
+
+// aggregateKey returns the key an Aggregator deduplicates diag by: its Code
+// and Paths.
+func aggregateKey(diag Diagnostic) string {
+	var key strings.Builder
+	key.WriteString(string(diag.Code))
+	for _, path := range diag.Paths {
+		key.WriteByte(0)
+		key.WriteString(path.String())
+	}
+	return key.String()
+}
@@ -0,0 +1,55 @@
+package apidiags
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAggregator(t *testing.T) {
+	t.Parallel()
+
+	var aggregator Aggregator
+	if summary := aggregator.Summary(); summary != nil {
+		t.Errorf("expected no summary, got %+v", summary)
+	}
+
+	name := BodyPath().AddStep(ObjectPropertyStep("name"))
+	legacy := Diagnostic{Severity: DiagnosticWarning, Code: CodeDeprecated, Paths: []Steps{name}, Summary: "Use display_name."}
+	pages := []Diagnostics{
+		{legacy, {Severity: DiagnosticWarning, Code: CodeInsufficient}},
+		{{Severity: DiagnosticWarning, Code: CodeDeprecated, Paths: []Steps{name}, Summary: "Use display_name instead."}},
+		{{Severity: DiagnosticError, Code: CodeActOfGod}},
+		{{Severity: DiagnosticWarning, Code: CodeDeprecated, Paths: []Steps{HeaderPath("X-Legacy")}}},
+	}
+	for _, page := range pages[:3] {
+		aggregator.Add(page)
+	}
+	resp := &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{},
+		Body:          io.NopCloser(strings.NewReader(`{"items":[]}`)),
+		ContentLength: -1,
+	}
+	if err := SetWarningsHeader(resp.Header, pages[3]); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := ParseResponse(resp, WithAggregator(&aggregator)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []AggregatedDiagnostic{
+		{Diagnostic: legacy, Count: 2},
+		{Diagnostic: Diagnostic{Severity: DiagnosticWarning, Code: CodeInsufficient}, Count: 1},
+		{Diagnostic: Diagnostic{Severity: DiagnosticWarning, Code: CodeDeprecated, Paths: []Steps{HeaderPath("X-Legacy")}}, Count: 1},
+	}
+	if diff := cmp.Diff(expected, aggregator.Summary()); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	if diff := cmp.Diff(Diagnostics{expected[0].Diagnostic, expected[1].Diagnostic, expected[2].Diagnostic}, aggregator.Diagnostics()); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+}
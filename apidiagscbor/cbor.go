@@ -0,0 +1,208 @@
+// Package apidiagscbor encodes apidiags Diagnostics and Steps as CBOR, so
+// constrained clients that speak CBOR rather than JSON still get structured
+// diagnostics.
+//
+// The encoding mirrors the JSON encoding: Diagnostics are arrays of maps
+// with the same keys as their JSON objects, and each Step is a map with a
+// "kind" key and, for Steps that have one, a "value" key. Integer values are
+// encoded as CBOR integers, and everything else as CBOR text strings.
+package apidiagscbor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"impractical.co/apidiags"
+)
+
+var encMode = mustEncMode()
+
+func mustEncMode() cbor.EncMode {
+	mode, err := cbor.CoreDetEncOptions().EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}
+
+type diagnostic struct {
+	Severity apidiags.Severity `cbor:"severity"`
+	Code     apidiags.Code     `cbor:"code"`
+	Paths    [][]step          `cbor:"path,omitempty"`
+	Summary  string            `cbor:"summary,omitempty"`
+	Detail   string            `cbor:"detail,omitempty"`
+	DocURL   string            `cbor:"doc_url,omitempty"`
+}
+
+type step struct {
+	Kind  string `cbor:"kind"`
+	Value any    `cbor:"value,omitempty"`
+}
+
+// Marshal encodes diags as CBOR. The output uses the Core Deterministic
+// Encoding Requirements of RFC 8949, so the same Diagnostics always encode to
+// the same bytes.
+func Marshal(diags apidiags.Diagnostics) ([]byte, error) {
+	encoded := make([]diagnostic, 0, len(diags))
+	for pos, diag := range diags {
+		diag = diag.RenderMessage(nil)
+		result := diagnostic{
+			Severity: diag.Severity,
+			Code:     diag.Code,
+			Summary:  diag.Summary,
+			Detail:   diag.Detail,
+			DocURL:   diag.DocURL,
+		}
+		for _, path := range diag.Paths {
+			steps, err := fromSteps(path)
+			if err != nil {
+				return nil, fmt.Errorf("error encoding diagnostic %d: %w", pos, err)
+			}
+			result.Paths = append(result.Paths, steps)
+		}
+		encoded = append(encoded, result)
+	}
+	return encMode.Marshal(encoded)
+}
+
+// Unmarshal decodes Diagnostics encoded by Marshal.
+func Unmarshal(data []byte) (apidiags.Diagnostics, error) {
+	var encoded []diagnostic
+	err := cbor.Unmarshal(data, &encoded)
+	if err != nil {
+		return nil, err
+	}
+	results := make(apidiags.Diagnostics, 0, len(encoded))
+	for pos, diag := range encoded {
+		result := apidiags.Diagnostic{
+			Severity: diag.Severity,
+			Code:     diag.Code,
+			Summary:  diag.Summary,
+			Detail:   diag.Detail,
+			DocURL:   diag.DocURL,
+		}
+		for _, path := range diag.Paths {
+			steps, err := toSteps(path)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing diagnostic %d: %w", pos, err)
+			}
+			result.Paths = append(result.Paths, steps)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// MarshalSteps encodes steps as CBOR, using the same encoding as the Paths of
+// Diagnostics encoded by Marshal.
+func MarshalSteps(steps apidiags.Steps) ([]byte, error) {
+	encoded, err := fromSteps(steps)
+	if err != nil {
+		return nil, err
+	}
+	return encMode.Marshal(encoded)
+}
+
+// UnmarshalSteps decodes Steps encoded by MarshalSteps.
+func UnmarshalSteps(data []byte) (apidiags.Steps, error) {
+	var encoded []step
+	err := cbor.Unmarshal(data, &encoded)
+	if err != nil {
+		return nil, err
+	}
+	return toSteps(encoded)
+}
+
+func fromSteps(steps apidiags.Steps) ([]step, error) {
+	results := make([]step, 0, len(steps))
+	for pos, value := range steps {
+		switch value := value.(type) {
+		case apidiags.BodyStep:
+			results = append(results, step{Kind: "body"})
+		case apidiags.HeaderStep:
+			results = append(results, step{Kind: "header", Value: string(value)})
+		case apidiags.URLParamStep:
+			results = append(results, step{Kind: "url_param", Value: string(value)})
+		case apidiags.ArrayIndexStep:
+			results = append(results, step{Kind: "array_index", Value: int64(value)})
+		case apidiags.ObjectPropertyStep:
+			results = append(results, step{Kind: "object_property", Value: string(value)})
+		case apidiags.StringIndexStep:
+			results = append(results, step{Kind: "string_index", Value: int64(value)})
+		case apidiags.RuneIndexStep:
+			results = append(results, step{Kind: "rune_index", Value: int64(value)})
+		default:
+			return nil, fmt.Errorf("unknown step type %T for step %d", value, pos)
+		}
+	}
+	return results, nil
+}
+
+func toSteps(encoded []step) (apidiags.Steps, error) {
+	results := make(apidiags.Steps, 0, len(encoded))
+	for pos, value := range encoded {
+		result, err := value.toStep()
+		if err != nil {
+			return nil, fmt.Errorf("error parsing step %d: %w", pos, err)
+		}
+		results = results.AddStep(result)
+	}
+	return results, nil
+}
+
+func (s step) toStep() (apidiags.Step, error) {
+	switch s.Kind {
+	case "body":
+		return apidiags.BodyStep{}, nil
+	case "header":
+		header, err := s.stringValue()
+		return apidiags.HeaderStep(header), err
+	case "url_param":
+		param, err := s.stringValue()
+		return apidiags.URLParamStep(param), err
+	case "array_index":
+		idx, err := s.intValue()
+		return apidiags.ArrayIndexStep(idx), err
+	case "object_property":
+		property, err := s.stringValue()
+		return apidiags.ObjectPropertyStep(property), err
+	case "string_index":
+		idx, err := s.intValue()
+		return apidiags.StringIndexStep(idx), err
+	case "rune_index":
+		idx, err := s.intValue()
+		return apidiags.RuneIndexStep(idx), err
+	default:
+		return nil, fmt.Errorf("unexpected step kind %q with value type %T", s.Kind, s.Value)
+	}
+}
+
+func (s step) stringValue() (string, error) {
+	if s.Value == nil {
+		return "", errors.New("no value")
+	}
+	val, ok := s.Value.(string)
+	if !ok {
+		return "", fmt.Errorf("wanted string, got %T", s.Value)
+	}
+	return val, nil
+}
+
+func (s step) intValue() (int64, error) {
+	switch val := s.Value.(type) {
+	case nil:
+		return 0, errors.New("no value")
+	case int64:
+		return val, nil
+	case uint64:
+		if val > math.MaxInt64 {
+			return 0, fmt.Errorf("%d overflows int64", val)
+		}
+		return int64(val), nil
+	default:
+		return 0, fmt.Errorf("wanted integer, got %T", s.Value)
+	}
+}
@@ -0,0 +1,118 @@
+package apidiagscbor
+
+import (
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/google/go-cmp/cmp"
+
+	"impractical.co/apidiags"
+)
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags    apidiags.Diagnostics
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"empty": {
+			diags:    apidiags.Diagnostics{},
+			expected: `[]`,
+		},
+		"all-fields": {
+			diags: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Summary:  "Name is required.",
+					Detail:   "Every item needs a name.",
+					DocURL:   "https://example.com/errors/missing",
+					Paths: []apidiags.Steps{apidiags.BodyPath().AddSteps(
+						apidiags.ObjectPropertyStep("items"),
+						apidiags.ArrayIndexStep(3),
+					)},
+				},
+			},
+			expected: `[{"code": "missing", "path": [[{"kind": "body"}, {"kind": "object_property", "value": "items"}, {"kind": "array_index", "value": 3}]], ` +
+				`"detail": "Every item needs a name.", "doc_url": "https://example.com/errors/missing", "summary": "Name is required.", "severity": "error"}]`,
+		},
+		"every-step-kind": {
+			diags: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticWarning,
+					Code:     apidiags.CodeDeprecated,
+					Paths: []apidiags.Steps{
+						apidiags.HeaderPath("X-Legacy"),
+						apidiags.URLParamPath("id"),
+						apidiags.BodyPath().AddSteps(apidiags.ObjectPropertyStep("name"), apidiags.StringIndexStep(-1)),
+						apidiags.BodyPath().AddSteps(apidiags.ObjectPropertyStep("name"), apidiags.RuneIndexStep(4)),
+					},
+				},
+			},
+			expected: `[{"code": "deprecated", "path": [` +
+				`[{"kind": "header", "value": "X-Legacy"}], ` +
+				`[{"kind": "url_param", "value": "id"}], ` +
+				`[{"kind": "body"}, {"kind": "object_property", "value": "name"}, {"kind": "string_index", "value": -1}], ` +
+				`[{"kind": "body"}, {"kind": "object_property", "value": "name"}, {"kind": "rune_index", "value": 4}]` +
+				`], "severity": "warning"}]`,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			encoded, err := Marshal(tc.diags)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			notation, err := cbor.Diagnose(encoded)
+			if err != nil {
+				t.Fatalf("unexpected error rendering CBOR: %s", err)
+			}
+			if diff := cmp.Diff(tc.expected, notation); diff != "" {
+				t.Errorf("unexpected CBOR (-wanted, +got): %s", diff)
+			}
+			decoded, err := Unmarshal(encoded)
+			if err != nil {
+				t.Fatalf("unexpected error decoding: %s", err)
+			}
+			if diff := cmp.Diff(tc.diags, decoded); diff != "" {
+				t.Errorf("unexpected round trip results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestUnmarshalStepsErrors(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string][]map[string]any{
+		"unknown-kind": {{"kind": "cookie", "value": "session"}},
+		"wrong-type":   {{"kind": "body"}, {"kind": "array_index", "value": "three"}},
+		"no-value":     {{"kind": "header"}},
+		"overflow":     {{"kind": "body"}, {"kind": "string_index", "value": uint64(1 << 63)}},
+	}
+
+	for name, input := range cases {
+		name, input := name, input
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			encoded, err := cbor.Marshal(input)
+			if err != nil {
+				t.Fatalf("unexpected error encoding input: %s", err)
+			}
+			steps, err := UnmarshalSteps(encoded)
+			if err == nil {
+				t.Fatalf("expected error, got %+v", steps)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+module impractical.co/apidiags/apidiagscbor
+
+go 1.19
+
+require (
+	github.com/fxamacker/cbor/v2 v2.9.1
+	github.com/google/go-cmp v0.5.9
+	impractical.co/apidiags v0.0.0
+)
+
+require github.com/x448/float16 v0.8.4 // indirect
+
+replace impractical.co/apidiags => ../
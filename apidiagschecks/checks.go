@@ -0,0 +1,220 @@
+// Package apidiagschecks provides composable validation checks that return
+// apidiags Diagnostics, so services describe invalid requests with the same
+// Codes, Paths, and bounds no matter who wrote the validation.
+//
+//	diags := apidiagschecks.String(apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name")), req.Name,
+//		apidiagschecks.Required[string](),
+//		apidiagschecks.MaxLen(80),
+//	)
+package apidiagschecks
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+
+	"impractical.co/apidiags"
+)
+
+// The extension members checks use to describe the bounds a value had to
+// stay within, which clients can read with apidiags.BoundsFrom.
+const (
+	// MinMember holds the smallest value or length allowed, inclusive.
+	MinMember = apidiags.MinMember
+
+	// MaxMember holds the largest value or length allowed, inclusive.
+	MaxMember = apidiags.MaxMember
+
+	// AllowedMember holds the list of allowed values.
+	AllowedMember = apidiags.AllowedMember
+
+	// PatternMember holds the regular expression a value had to match.
+	PatternMember = apidiags.PatternMember
+)
+
+// Check validates the value found at path, returning Diagnostics describing
+// why it's invalid, or nil if it's valid.
+type Check[T any] func(path apidiags.Steps, value T) apidiags.Diagnostics
+
+// Number is the set of types Min and Max can check.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Value runs each of checks against value, found at path, and returns all of
+// their Diagnostics.
+func Value[T any](path apidiags.Steps, value T, checks ...Check[T]) apidiags.Diagnostics {
+	var results apidiags.Diagnostics
+	for _, check := range checks {
+		results = append(results, check(path, value)...)
+	}
+	return results
+}
+
+// String runs each of checks against value, found at path, and returns all
+// of their Diagnostics. It's Value, without the need to spell out the type
+// for untyped string constants.
+func String(path apidiags.Steps, value string, checks ...Check[string]) apidiags.Diagnostics {
+	return Value(path, value, checks...)
+}
+
+// All combines checks into a single Check, which returns the Diagnostics of
+// all of them.
+func All[T any](checks ...Check[T]) Check[T] {
+	return func(path apidiags.Steps, value T) apidiags.Diagnostics {
+		return Value(path, value, checks...)
+	}
+}
+
+// Optional combines checks into a single Check that only runs them when the
+// value isn't its type's zero value, for fields that may be left out but
+// must be valid when they're set.
+func Optional[T comparable](checks ...Check[T]) Check[T] {
+	return func(path apidiags.Steps, value T) apidiags.Diagnostics {
+		var zero T
+		if value == zero {
+			return nil
+		}
+		return Value(path, value, checks...)
+	}
+}
+
+// Each returns a Check that runs checks against every element of a slice,
+// with Paths pointing to the element using an apidiags.ArrayIndexStep.
+func Each[T any](checks ...Check[T]) Check[[]T] {
+	return func(path apidiags.Steps, values []T) apidiags.Diagnostics {
+		var results apidiags.Diagnostics
+		for pos, value := range values {
+			elem := append(path[:len(path):len(path)], apidiags.ArrayIndexStep(pos))
+			results = append(results, Value(elem, value, checks...)...)
+		}
+		return results
+	}
+}
+
+// Required returns a Check that fails with apidiags.CodeMissing when the
+// value is its type's zero value.
+func Required[T comparable]() Check[T] {
+	return func(path apidiags.Steps, value T) apidiags.Diagnostics {
+		var zero T
+		if value != zero {
+			return nil
+		}
+		return apidiags.Diagnostics{{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeMissing,
+			Paths:    []apidiags.Steps{path},
+		}}
+	}
+}
+
+// MinLen returns a Check that fails with apidiags.CodeInsufficient when a
+// string has fewer than n characters. Characters are counted as runes, not
+// bytes. The bound is recorded in MinMember.
+func MinLen[T ~string](n int) Check[T] {
+	return func(path apidiags.Steps, value T) apidiags.Diagnostics {
+		if utf8.RuneCountInString(string(value)) >= n {
+			return nil
+		}
+		return bounded(path, apidiags.CodeInsufficient, MinMember, n,
+			"Must be at least "+characters(n)+" long.")
+	}
+}
+
+// MaxLen returns a Check that fails with apidiags.CodeOverflow when a
+// string has more than n characters. Characters are counted as runes, not
+// bytes. The bound is recorded in MaxMember.
+func MaxLen[T ~string](n int) Check[T] {
+	return func(path apidiags.Steps, value T) apidiags.Diagnostics {
+		if utf8.RuneCountInString(string(value)) <= n {
+			return nil
+		}
+		return bounded(path, apidiags.CodeOverflow, MaxMember, n,
+			"Must be at most "+characters(n)+" long.")
+	}
+}
+
+// Min returns a Check that fails with apidiags.CodeInsufficient when a
+// number is less than min. The bound is recorded in MinMember.
+func Min[T Number](min T) Check[T] {
+	return func(path apidiags.Steps, value T) apidiags.Diagnostics {
+		if value >= min {
+			return nil
+		}
+		return bounded(path, apidiags.CodeInsufficient, MinMember, min,
+			"Must be at least "+formatNumber(min)+".")
+	}
+}
+
+// Max returns a Check that fails with apidiags.CodeOverflow when a number
+// is greater than max. The bound is recorded in MaxMember.
+func Max[T Number](max T) Check[T] {
+	return func(path apidiags.Steps, value T) apidiags.Diagnostics {
+		if value <= max {
+			return nil
+		}
+		return bounded(path, apidiags.CodeOverflow, MaxMember, max,
+			"Must be at most "+formatNumber(max)+".")
+	}
+}
+
+// OneOf returns a Check that fails with apidiags.CodeInvalidValue when the
+// value isn't one of allowed. The allowed values are recorded in
+// AllowedMember.
+func OneOf[T comparable](allowed ...T) Check[T] {
+	return func(path apidiags.Steps, value T) apidiags.Diagnostics {
+		for _, candidate := range allowed {
+			if value == candidate {
+				return nil
+			}
+		}
+		return bounded(path, apidiags.CodeInvalidValue, AllowedMember, allowed, "Must be one of the allowed values.")
+	}
+}
+
+// MatchesRegexp returns a Check that fails with apidiags.CodeInvalidFormat
+// when a string doesn't match re. The expression is recorded in
+// PatternMember.
+func MatchesRegexp[T ~string](re *regexp.Regexp) Check[T] {
+	return func(path apidiags.Steps, value T) apidiags.Diagnostics {
+		if re.MatchString(string(value)) {
+			return nil
+		}
+		return bounded(path, apidiags.CodeInvalidFormat, PatternMember, re.String(),
+			"Must match the pattern "+strconv.Quote(re.String())+".")
+	}
+}
+
+// bounded returns a single Diagnostic with a Severity of
+// apidiags.DiagnosticError for a value that broke the bound recorded in
+// member.
+func bounded(path apidiags.Steps, code apidiags.Code, member string, bound any, detail string) apidiags.Diagnostics {
+	diag := apidiags.Diagnostic{
+		Severity: apidiags.DiagnosticError,
+		Code:     code,
+		Paths:    []apidiags.Steps{path},
+		Detail:   detail,
+	}
+	if encoded, err := json.Marshal(bound); err == nil {
+		diag.Extensions = map[string]json.RawMessage{member: encoded}
+	}
+	return apidiags.Diagnostics{diag}
+}
+
+func characters(n int) string {
+	if n == 1 {
+		return "1 character"
+	}
+	return strconv.Itoa(n) + " characters"
+}
+
+func formatNumber[T Number](n T) string {
+	encoded, err := json.Marshal(n)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
@@ -0,0 +1,149 @@
+package apidiagschecks
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"impractical.co/apidiags"
+)
+
+func TestChecks(t *testing.T) {
+	t.Parallel()
+
+	path := apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("field"))
+
+	type testCase struct {
+		check    func(apidiags.Steps) apidiags.Diagnostics
+		expected apidiags.Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"required-valid": {
+			check: func(p apidiags.Steps) apidiags.Diagnostics {
+				return String(p, "foo", Required[string]())
+			},
+		},
+		"required": {
+			check: func(p apidiags.Steps) apidiags.Diagnostics {
+				return Value(p, 0, Required[int]())
+			},
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeMissing,
+				Paths:    []apidiags.Steps{path},
+			}},
+		},
+		"min-len": {
+			check: func(p apidiags.Steps) apidiags.Diagnostics {
+				return String(p, "héllo", MinLen[string](6), MinLen[string](5))
+			},
+			expected: apidiags.Diagnostics{{
+				Severity:   apidiags.DiagnosticError,
+				Code:       apidiags.CodeInsufficient,
+				Paths:      []apidiags.Steps{path},
+				Detail:     "Must be at least 6 characters long.",
+				Extensions: map[string]json.RawMessage{MinMember: json.RawMessage(`6`)},
+			}},
+		},
+		"max-len": {
+			check: func(p apidiags.Steps) apidiags.Diagnostics {
+				return String(p, "ab", MaxLen[string](1), MaxLen[string](2))
+			},
+			expected: apidiags.Diagnostics{{
+				Severity:   apidiags.DiagnosticError,
+				Code:       apidiags.CodeOverflow,
+				Paths:      []apidiags.Steps{path},
+				Detail:     "Must be at most 1 character long.",
+				Extensions: map[string]json.RawMessage{MaxMember: json.RawMessage(`1`)},
+			}},
+		},
+		"min-max": {
+			check: func(p apidiags.Steps) apidiags.Diagnostics {
+				return Value(p, 1.5, Min(2.5), Max(1.0), Min(1.5), Max(1.5))
+			},
+			expected: apidiags.Diagnostics{
+				{
+					Severity:   apidiags.DiagnosticError,
+					Code:       apidiags.CodeInsufficient,
+					Paths:      []apidiags.Steps{path},
+					Detail:     "Must be at least 2.5.",
+					Extensions: map[string]json.RawMessage{MinMember: json.RawMessage(`2.5`)},
+				},
+				{
+					Severity:   apidiags.DiagnosticError,
+					Code:       apidiags.CodeOverflow,
+					Paths:      []apidiags.Steps{path},
+					Detail:     "Must be at most 1.",
+					Extensions: map[string]json.RawMessage{MaxMember: json.RawMessage(`1`)},
+				},
+			},
+		},
+		"one-of": {
+			check: func(p apidiags.Steps) apidiags.Diagnostics {
+				return String(p, "green", OneOf("red", "blue"))
+			},
+			expected: apidiags.Diagnostics{{
+				Severity:   apidiags.DiagnosticError,
+				Code:       apidiags.CodeInvalidValue,
+				Paths:      []apidiags.Steps{path},
+				Detail:     "Must be one of the allowed values.",
+				Extensions: map[string]json.RawMessage{AllowedMember: json.RawMessage(`["red","blue"]`)},
+			}},
+		},
+		"matches-regexp": {
+			check: func(p apidiags.Steps) apidiags.Diagnostics {
+				return String(p, "ABC", MatchesRegexp[string](regexp.MustCompile(`^[a-z]+$`)))
+			},
+			expected: apidiags.Diagnostics{{
+				Severity:   apidiags.DiagnosticError,
+				Code:       apidiags.CodeInvalidFormat,
+				Paths:      []apidiags.Steps{path},
+				Detail:     `Must match the pattern "^[a-z]+$".`,
+				Extensions: map[string]json.RawMessage{PatternMember: json.RawMessage(`"^[a-z]+$"`)},
+			}},
+		},
+		"optional": {
+			check: func(p apidiags.Steps) apidiags.Diagnostics {
+				return String(p, "", Optional(MinLen[string](3)))
+			},
+		},
+		"each": {
+			check: func(p apidiags.Steps) apidiags.Diagnostics {
+				return Value(p, []string{"ok", "", "toolong"}, Each(All(Required[string](), MaxLen[string](3))))
+			},
+			expected: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Paths:    []apidiags.Steps{path.AddStep(apidiags.ArrayIndexStep(1))},
+				},
+				{
+					Severity:   apidiags.DiagnosticError,
+					Code:       apidiags.CodeOverflow,
+					Paths:      []apidiags.Steps{path.AddStep(apidiags.ArrayIndexStep(2))},
+					Detail:     "Must be at most 3 characters long.",
+					Extensions: map[string]json.RawMessage{MaxMember: json.RawMessage(`3`)},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// give the path spare capacity, so Checks that append to it
+			// would clobber each other's Paths
+			p := make(apidiags.Steps, len(path), len(path)+4)
+			copy(p, path)
+			got := tc.check(p)
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
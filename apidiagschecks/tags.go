@@ -0,0 +1,315 @@
+package apidiagschecks
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"impractical.co/apidiags"
+)
+
+// TagName is the struct tag ValidateStruct reads rules from.
+const TagName = "apidiags"
+
+// ValidateStruct validates value, a struct or a pointer to one, using the
+// rules in the `apidiags` struct tags of its fields, like
+//
+//	type CreateWidgetRequest struct {
+//		Name  string   `json:"name" apidiags:"required,max=80"`
+//		Color string   `json:"color" apidiags:"omitempty,oneof=red|green|blue"`
+//		Tags  []string `json:"tags" apidiags:"max=10"`
+//	}
+//
+// The rules are:
+//
+//   - required: the field can't be its type's zero value, or empty, for
+//     strings, slices, and maps. Fails with apidiags.CodeMissing.
+//   - omitempty: skip the field's other rules if it's its type's zero
+//     value, or empty.
+//   - min=n and max=n: numbers must be at least or at most n, and strings,
+//     slices, and maps must have at least or at most n characters,
+//     elements, or entries. Characters are counted as runes. Fail with
+//     apidiags.CodeInsufficient or apidiags.CodeOverflow, recording n in
+//     MinMember or MaxMember.
+//   - oneof=a|b: the field, a string or number, must be one of the values
+//     separated by "|". Fails with apidiags.CodeInvalidValue, recording the
+//     values in AllowedMember.
+//
+// Rules are applied to the values pointers point to, and a nil pointer only
+// fails the required rule. ValidateStruct descends into struct fields,
+// including through pointers, and into the elements of slices, arrays, and
+// maps with string keys, so nested structs are validated too. Paths point
+// into the request body, and are derived from the fields' `json` struct
+// tags, like Validator's.
+//
+// ValidateStruct panics if a tag has a rule it doesn't recognize, or a rule
+// that can't apply to its field's type, as that's a programming error
+// rather than a problem with the request.
+func ValidateStruct(value any) apidiags.Diagnostics {
+	return ValidateStructAt(apidiags.BodyPath(), value)
+}
+
+// ValidateStructAt is like ValidateStruct, but the Paths of the Diagnostics
+// start with path instead of pointing into the request body.
+func ValidateStructAt(path apidiags.Steps, value any) apidiags.Diagnostics {
+	return walkValue(path, reflect.ValueOf(value), nil)
+}
+
+// tagRules are the parsed rules from a field's `apidiags` struct tag.
+type tagRules struct {
+	required  bool
+	omitEmpty bool
+	min, max  *float64
+	oneOf     []string
+}
+
+// structField is a field of a struct, along with its parsed rules.
+type structField struct {
+	index    int
+	name     string
+	promoted bool
+	rules    tagRules
+}
+
+// structFields caches the []structField for each struct type
+// ValidateStruct has seen.
+var structFields sync.Map
+
+// fieldsOf returns the exported fields of typ, a struct type, with their
+// parsed rules.
+func fieldsOf(typ reflect.Type) []structField {
+	if cached, ok := structFields.Load(typ); ok {
+		return cached.([]structField)
+	}
+	var fields []structField
+	for pos := 0; pos < typ.NumField(); pos++ {
+		field := typ.Field(pos)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		name, promoted := jsonName(field)
+		fields = append(fields, structField{
+			index:    pos,
+			name:     name,
+			promoted: promoted,
+			rules:    parseRules(typ, field),
+		})
+	}
+	structFields.Store(typ, fields)
+	return fields
+}
+
+// parseRules parses the `apidiags` struct tag of field, a field of typ.
+func parseRules(typ reflect.Type, field reflect.StructField) tagRules {
+	var rules tagRules
+	tag := field.Tag.Get(TagName)
+	if tag == "" {
+		return rules
+	}
+	elem := field.Type
+	for elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			rules.required = true
+		case "omitempty":
+			rules.omitEmpty = true
+		case "min", "max":
+			if !isNumber(elem) && !hasLen(elem) {
+				panic(fmt.Sprintf("apidiagschecks: can't apply %q to %s.%s, a %s", rule, typ, field.Name, field.Type))
+			}
+			bound, err := strconv.ParseFloat(param, 64)
+			if err != nil || math.IsInf(bound, 0) || math.IsNaN(bound) {
+				panic(fmt.Sprintf("apidiagschecks: invalid bound in %q on %s.%s", rule, typ, field.Name))
+			}
+			if name == "min" {
+				rules.min = &bound
+			} else {
+				rules.max = &bound
+			}
+		case "oneof":
+			if !isNumber(elem) && elem.Kind() != reflect.String {
+				panic(fmt.Sprintf("apidiagschecks: can't apply %q to %s.%s, a %s", rule, typ, field.Name, field.Type))
+			}
+			rules.oneOf = strings.Split(param, "|")
+		default:
+			panic(fmt.Sprintf("apidiagschecks: unknown rule %q on %s.%s", rule, typ, field.Name))
+		}
+	}
+	return rules
+}
+
+// walkValue validates value, found at path, using rules, then descends into
+// it.
+func walkValue(path apidiags.Steps, value reflect.Value, rules *tagRules) apidiags.Diagnostics {
+	var results apidiags.Diagnostics
+	if rules != nil {
+		results = applyRules(path, value, *rules)
+	}
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return results
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		for _, field := range fieldsOf(value.Type()) {
+			field := field
+			fieldPath := path
+			if !field.promoted {
+				fieldPath = join(path, apidiags.Steps{apidiags.ObjectPropertyStep(field.name)})
+			}
+			results = append(results, walkValue(fieldPath, value.Field(field.index), &field.rules)...)
+		}
+	case reflect.Slice, reflect.Array:
+		for pos := 0; pos < value.Len(); pos++ {
+			elemPath := join(path, apidiags.Steps{apidiags.ArrayIndexStep(pos)})
+			results = append(results, walkValue(elemPath, value.Index(pos), nil)...)
+		}
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return results
+		}
+		keys := value.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			elemPath := join(path, apidiags.Steps{apidiags.ObjectPropertyStep(key.String())})
+			results = append(results, walkValue(elemPath, value.MapIndex(key), nil)...)
+		}
+	}
+	return results
+}
+
+// applyRules returns the Diagnostics for value, found at path, breaking
+// rules.
+func applyRules(path apidiags.Steps, value reflect.Value, rules tagRules) apidiags.Diagnostics {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			if rules.required {
+				return missing(path)
+			}
+			return nil
+		}
+		value = value.Elem()
+	}
+	if isEmpty(value) {
+		if rules.required {
+			return missing(path)
+		}
+		if rules.omitEmpty {
+			return nil
+		}
+	}
+	var results apidiags.Diagnostics
+	if rules.min != nil {
+		if size, isLen := measure(value); size < *rules.min {
+			results = append(results, bounded(path, apidiags.CodeInsufficient, MinMember, *rules.min,
+				"Must be at least "+describeBound(*rules.min, value, isLen)+".")...)
+		}
+	}
+	if rules.max != nil {
+		if size, isLen := measure(value); size > *rules.max {
+			results = append(results, bounded(path, apidiags.CodeOverflow, MaxMember, *rules.max,
+				"Must be at most "+describeBound(*rules.max, value, isLen)+".")...)
+		}
+	}
+	if rules.oneOf != nil && !containsValue(rules.oneOf, value) {
+		results = append(results, bounded(path, apidiags.CodeInvalidValue, AllowedMember, rules.oneOf,
+			"Must be one of the allowed values.")...)
+	}
+	return results
+}
+
+func missing(path apidiags.Steps) apidiags.Diagnostics {
+	return apidiags.Diagnostics{{
+		Severity: apidiags.DiagnosticError,
+		Code:     apidiags.CodeMissing,
+		Paths:    []apidiags.Steps{path},
+	}}
+}
+
+// measure returns the number min and max compare against: the value of
+// numbers, and the length of everything else.
+func measure(value reflect.Value) (float64, bool) {
+	switch {
+	case value.CanInt():
+		return float64(value.Int()), false
+	case value.CanUint():
+		return float64(value.Uint()), false
+	case value.CanFloat():
+		return value.Float(), false
+	case value.Kind() == reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), true
+	}
+	return float64(value.Len()), true
+}
+
+// describeBound describes bound, as it applies to value, for the Detail of
+// a Diagnostic.
+func describeBound(bound float64, value reflect.Value, isLen bool) string {
+	formatted := strconv.FormatFloat(bound, 'f', -1, 64)
+	switch {
+	case !isLen:
+		return formatted
+	case value.Kind() == reflect.String:
+		return characters(int(bound)) + " long"
+	case formatted == "1":
+		return "1 item"
+	default:
+		return formatted + " items"
+	}
+}
+
+// containsValue reports whether value, a string or number, is one of
+// allowed.
+func containsValue(allowed []string, value reflect.Value) bool {
+	for _, candidate := range allowed {
+		switch {
+		case value.Kind() == reflect.String:
+			if value.String() == candidate {
+				return true
+			}
+		default:
+			parsed, err := strconv.ParseFloat(candidate, 64)
+			if size, _ := measure(value); err == nil && size == parsed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
+
+func isNumber(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func hasLen(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package apidiagschecks
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"impractical.co/apidiags"
+)
+
+type taggedLabel struct {
+	Key string `json:"key" apidiags:"required"`
+}
+
+type taggedAudit struct {
+	Reason string `json:"reason" apidiags:"omitempty,min=3"`
+}
+
+type taggedWidget struct {
+	taggedAudit
+	Name     string                 `json:"name" apidiags:"required,max=5"`
+	Color    string                 `json:"color,omitempty" apidiags:"omitempty,oneof=red|blue"`
+	Count    *int                   `json:"count" apidiags:"min=1,max=10"`
+	Priority int                    `json:"priority" apidiags:"oneof=1|2|3"`
+	Labels   []taggedLabel          `json:"labels" apidiags:"max=2"`
+	Owner    *taggedLabel           `json:"owner" apidiags:"required"`
+	Extra    map[string]taggedLabel `json:"extra"`
+}
+
+func TestValidateStruct(t *testing.T) {
+	t.Parallel()
+
+	body := func(steps ...apidiags.Step) apidiags.Steps {
+		return apidiags.BodyPath().AddSteps(steps...)
+	}
+	count := func(n int) *int { return &n }
+
+	type testCase struct {
+		value    any
+		expected apidiags.Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"valid": {
+			value: &taggedWidget{
+				Name:     "foo",
+				Count:    count(3),
+				Priority: 2,
+				Owner:    &taggedLabel{Key: "me"},
+			},
+		},
+		"invalid": {
+			value: taggedWidget{
+				taggedAudit: taggedAudit{Reason: "no"},
+				Color:       "green",
+				Count:       count(11),
+				Priority:    4,
+				Labels:      []taggedLabel{{Key: "a"}, {}, {Key: "c"}},
+				Extra:       map[string]taggedLabel{"b": {}, "a": {Key: "a"}},
+			},
+			expected: apidiags.Diagnostics{
+				{
+					Severity:   apidiags.DiagnosticError,
+					Code:       apidiags.CodeInsufficient,
+					Paths:      []apidiags.Steps{body(apidiags.ObjectPropertyStep("reason"))},
+					Detail:     "Must be at least 3 characters long.",
+					Extensions: map[string]json.RawMessage{MinMember: json.RawMessage(`3`)},
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Paths:    []apidiags.Steps{body(apidiags.ObjectPropertyStep("name"))},
+				},
+				{
+					Severity:   apidiags.DiagnosticError,
+					Code:       apidiags.CodeInvalidValue,
+					Paths:      []apidiags.Steps{body(apidiags.ObjectPropertyStep("color"))},
+					Detail:     "Must be one of the allowed values.",
+					Extensions: map[string]json.RawMessage{AllowedMember: json.RawMessage(`["red","blue"]`)},
+				},
+				{
+					Severity:   apidiags.DiagnosticError,
+					Code:       apidiags.CodeOverflow,
+					Paths:      []apidiags.Steps{body(apidiags.ObjectPropertyStep("count"))},
+					Detail:     "Must be at most 10.",
+					Extensions: map[string]json.RawMessage{MaxMember: json.RawMessage(`10`)},
+				},
+				{
+					Severity:   apidiags.DiagnosticError,
+					Code:       apidiags.CodeInvalidValue,
+					Paths:      []apidiags.Steps{body(apidiags.ObjectPropertyStep("priority"))},
+					Detail:     "Must be one of the allowed values.",
+					Extensions: map[string]json.RawMessage{AllowedMember: json.RawMessage(`["1","2","3"]`)},
+				},
+				{
+					Severity:   apidiags.DiagnosticError,
+					Code:       apidiags.CodeOverflow,
+					Paths:      []apidiags.Steps{body(apidiags.ObjectPropertyStep("labels"))},
+					Detail:     "Must be at most 2 items.",
+					Extensions: map[string]json.RawMessage{MaxMember: json.RawMessage(`2`)},
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Paths:    []apidiags.Steps{body(apidiags.ObjectPropertyStep("labels"), apidiags.ArrayIndexStep(1), apidiags.ObjectPropertyStep("key"))},
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Paths:    []apidiags.Steps{body(apidiags.ObjectPropertyStep("owner"))},
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Paths:    []apidiags.Steps{body(apidiags.ObjectPropertyStep("extra"), apidiags.ObjectPropertyStep("b"), apidiags.ObjectPropertyStep("key"))},
+				},
+			},
+		},
+		"nil-pointer": {
+			value: (*taggedWidget)(nil),
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := ValidateStruct(tc.value)
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateStructPanics(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		value any
+	}
+
+	cases := map[string]testCase{
+		"unknown-rule": {
+			value: struct {
+				Name string `apidiags:"email"`
+			}{},
+		},
+		"bad-bound": {
+			value: struct {
+				Name string `apidiags:"max=lots"`
+			}{},
+		},
+		"wrong-type": {
+			value: struct {
+				Enabled bool `apidiags:"min=1"`
+			}{},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			ValidateStruct(tc.value)
+		})
+	}
+}
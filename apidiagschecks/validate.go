@@ -0,0 +1,162 @@
+package apidiagschecks
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unsafe"
+
+	"impractical.co/apidiags"
+)
+
+// Validator validates values of the struct type T, running the Checks
+// registered for each of its fields with Field, Nested, and Elements.
+//
+// The Paths of the Diagnostics it returns are derived from the fields'
+// `json` struct tags, so they match the request body the struct was
+// decoded from:
+//
+//	widgets := apidiagschecks.NewValidator[CreateWidgetRequest]()
+//	apidiagschecks.Field(widgets, func(r *CreateWidgetRequest) *string { return &r.Name },
+//		apidiagschecks.Required[string](), apidiagschecks.MaxLen[string](80))
+//	apidiagschecks.Elements(widgets, func(r *CreateWidgetRequest) *[]Label { return &r.Labels }, labels)
+//
+//	diags := widgets.Validate(req)
+//
+// Validators should be built once, when a program starts; they're safe for
+// concurrent use once all their rules are registered.
+type Validator[T any] struct {
+	rules []func(path apidiags.Steps, value *T) apidiags.Diagnostics
+}
+
+// NewValidator returns a Validator for T with no rules. It panics if T isn't
+// a struct.
+func NewValidator[T any]() *Validator[T] {
+	if typ := reflect.TypeOf((*T)(nil)).Elem(); typ.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("apidiagschecks: can't validate %s, only structs", typ))
+	}
+	return &Validator[T]{}
+}
+
+// Validate runs every rule registered with v against value, and returns
+// all of their Diagnostics, with Paths pointing into the request body.
+func (v *Validator[T]) Validate(value T) apidiags.Diagnostics {
+	return v.ValidateAt(apidiags.BodyPath(), value)
+}
+
+// ValidateAt is like Validate, but the Paths of the Diagnostics start with
+// path instead of pointing into the request body.
+func (v *Validator[T]) ValidateAt(path apidiags.Steps, value T) apidiags.Diagnostics {
+	var results apidiags.Diagnostics
+	for _, rule := range v.rules {
+		results = append(results, rule(path, &value)...)
+	}
+	return results
+}
+
+// Field registers checks to run against the field of T that field returns
+// a pointer to, like
+//
+//	func(r *Request) *string { return &r.Name }
+//
+// field is called once with a pointer to T's zero value to work out which
+// field it points to, so it must return a pointer to a field of T, or of a
+// struct T contains directly, and not follow pointers or index into maps
+// or slices. Field panics if it doesn't.
+func Field[T, F any](v *Validator[T], field func(*T) *F, checks ...Check[F]) {
+	steps := fieldSteps(field)
+	v.rules = append(v.rules, func(path apidiags.Steps, value *T) apidiags.Diagnostics {
+		return Value(join(path, steps), *field(value), checks...)
+	})
+}
+
+// Nested registers nested to validate the struct field of T that field
+// returns a pointer to, as Field does.
+func Nested[T, F any](v *Validator[T], field func(*T) *F, nested *Validator[F]) {
+	steps := fieldSteps(field)
+	v.rules = append(v.rules, func(path apidiags.Steps, value *T) apidiags.Diagnostics {
+		return nested.ValidateAt(join(path, steps), *field(value))
+	})
+}
+
+// Elements registers elems to validate each element of the slice field of T
+// that field returns a pointer to, as Field does. The Paths of the
+// element's Diagnostics include an apidiags.ArrayIndexStep for its
+// position.
+func Elements[T, F any](v *Validator[T], field func(*T) *[]F, elems *Validator[F]) {
+	steps := fieldSteps(field)
+	v.rules = append(v.rules, func(path apidiags.Steps, value *T) apidiags.Diagnostics {
+		var results apidiags.Diagnostics
+		for pos, elem := range *field(value) {
+			elemPath := join(path, steps).AddStep(apidiags.ArrayIndexStep(pos))
+			results = append(results, elems.ValidateAt(elemPath, elem)...)
+		}
+		return results
+	})
+}
+
+// fieldSteps returns the Steps pointing to the field that field returns a
+// pointer to, relative to the start of T.
+func fieldSteps[T, F any](field func(*T) *F) apidiags.Steps {
+	base := new(T)
+	ptr := field(base)
+	start := uintptr(unsafe.Pointer(base))
+	offset := uintptr(unsafe.Pointer(ptr)) - start
+	typ := reflect.TypeOf(base).Elem()
+	target := reflect.TypeOf(ptr).Elem()
+	if ptr == nil || uintptr(unsafe.Pointer(ptr)) < start || offset >= typ.Size() {
+		panic(fmt.Sprintf("apidiagschecks: field doesn't point to a field of %s", typ))
+	}
+	steps, ok := findField(typ, offset, target)
+	if !ok {
+		panic(fmt.Sprintf("apidiagschecks: field doesn't point to a field of %s", typ))
+	}
+	return steps
+}
+
+// findField returns the Steps pointing to the field of typ of type target
+// found offset bytes from the start of typ, descending into the struct
+// fields of typ if necessary.
+func findField(typ reflect.Type, offset uintptr, target reflect.Type) (apidiags.Steps, bool) {
+	for pos := 0; pos < typ.NumField(); pos++ {
+		field := typ.Field(pos)
+		if offset < field.Offset || offset >= field.Offset+field.Type.Size() {
+			continue
+		}
+		var steps apidiags.Steps
+		if name, promoted := jsonName(field); !promoted {
+			steps = apidiags.Steps{apidiags.ObjectPropertyStep(name)}
+		}
+		if offset == field.Offset && field.Type == target {
+			return steps, true
+		}
+		if field.Type.Kind() != reflect.Struct {
+			continue
+		}
+		if nested, ok := findField(field.Type, offset-field.Offset, target); ok {
+			return append(steps, nested...), true
+		}
+	}
+	return nil, false
+}
+
+// jsonName returns the name encoding/json uses for field, or reports that
+// field is an embedded struct whose fields are promoted into its parent.
+func jsonName(field reflect.StructField) (string, bool) {
+	tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+		return "", true
+	}
+	if tag == "" || tag == "-" {
+		return field.Name, false
+	}
+	return tag, false
+}
+
+// join returns a copy of path with steps appended, so Paths built from the
+// same path don't share memory.
+func join(path, steps apidiags.Steps) apidiags.Steps {
+	results := make(apidiags.Steps, 0, len(path)+len(steps)+1)
+	results = append(results, path...)
+	return append(results, steps...)
+}
@@ -0,0 +1,163 @@
+package apidiagschecks
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"impractical.co/apidiags"
+)
+
+type testMetadata struct {
+	CreatedBy string `json:"created_by"`
+}
+
+type testOwner struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+}
+
+type testLabel struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type testWidget struct {
+	testMetadata
+	ID     string      `json:"-"`
+	Name   string      `json:"name"`
+	Count  int         `json:"count"`
+	Owner  testOwner   `json:"owner"`
+	Labels []testLabel `json:"labels"`
+	Tags   []string    `json:"tags"`
+	Color  string
+}
+
+func testWidgetValidator() *Validator[testWidget] {
+	labels := NewValidator[testLabel]()
+	Field(labels, func(l *testLabel) *string { return &l.Key }, Required[string]())
+
+	owners := NewValidator[testOwner]()
+	Field(owners, func(o *testOwner) *string { return &o.Email }, Required[string]())
+
+	widgets := NewValidator[testWidget]()
+	Field(widgets, func(w *testWidget) *string { return &w.CreatedBy }, Required[string]())
+	Field(widgets, func(w *testWidget) *string { return &w.Name }, Required[string](), MaxLen[string](5))
+	Field(widgets, func(w *testWidget) *int { return &w.Count }, Min(1))
+	Field(widgets, func(w *testWidget) *string { return &w.Owner.Name }, Required[string]())
+	Field(widgets, func(w *testWidget) *[]string { return &w.Tags }, Each(MinLen[string](2)))
+	Field(widgets, func(w *testWidget) *string { return &w.Color }, Optional(OneOf("red")))
+	Nested(widgets, func(w *testWidget) *testOwner { return &w.Owner }, owners)
+	Elements(widgets, func(w *testWidget) *[]testLabel { return &w.Labels }, labels)
+	return widgets
+}
+
+func TestValidator(t *testing.T) {
+	t.Parallel()
+
+	widgets := testWidgetValidator()
+	body := func(steps ...apidiags.Step) apidiags.Steps {
+		return apidiags.BodyPath().AddSteps(steps...)
+	}
+
+	type testCase struct {
+		widget   testWidget
+		expected apidiags.Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"valid": {
+			widget: testWidget{
+				testMetadata: testMetadata{CreatedBy: "me"},
+				Name:         "foo",
+				Count:        1,
+				Owner:        testOwner{Name: "me", Email: "me@example.com"},
+				Labels:       []testLabel{{Key: "app"}},
+				Tags:         []string{"ok"},
+			},
+		},
+		"invalid": {
+			widget: testWidget{
+				Name:   "too long",
+				Labels: []testLabel{{Key: "app"}, {Value: "blue"}},
+				Tags:   []string{"ok", "x"},
+				Color:  "green",
+			},
+			expected: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Paths:    []apidiags.Steps{body(apidiags.ObjectPropertyStep("created_by"))},
+				},
+				{
+					Severity:   apidiags.DiagnosticError,
+					Code:       apidiags.CodeOverflow,
+					Paths:      []apidiags.Steps{body(apidiags.ObjectPropertyStep("name"))},
+					Detail:     "Must be at most 5 characters long.",
+					Extensions: map[string]json.RawMessage{MaxMember: json.RawMessage(`5`)},
+				},
+				{
+					Severity:   apidiags.DiagnosticError,
+					Code:       apidiags.CodeInsufficient,
+					Paths:      []apidiags.Steps{body(apidiags.ObjectPropertyStep("count"))},
+					Detail:     "Must be at least 1.",
+					Extensions: map[string]json.RawMessage{MinMember: json.RawMessage(`1`)},
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Paths:    []apidiags.Steps{body(apidiags.ObjectPropertyStep("owner"), apidiags.ObjectPropertyStep("name"))},
+				},
+				{
+					Severity:   apidiags.DiagnosticError,
+					Code:       apidiags.CodeInsufficient,
+					Paths:      []apidiags.Steps{body(apidiags.ObjectPropertyStep("tags"), apidiags.ArrayIndexStep(1))},
+					Detail:     "Must be at least 2 characters long.",
+					Extensions: map[string]json.RawMessage{MinMember: json.RawMessage(`2`)},
+				},
+				{
+					Severity:   apidiags.DiagnosticError,
+					Code:       apidiags.CodeInvalidValue,
+					Paths:      []apidiags.Steps{body(apidiags.ObjectPropertyStep("Color"))},
+					Detail:     "Must be one of the allowed values.",
+					Extensions: map[string]json.RawMessage{AllowedMember: json.RawMessage(`["red"]`)},
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Paths:    []apidiags.Steps{body(apidiags.ObjectPropertyStep("owner"), apidiags.ObjectPropertyStep("email"))},
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Paths:    []apidiags.Steps{body(apidiags.ObjectPropertyStep("labels"), apidiags.ArrayIndexStep(1), apidiags.ObjectPropertyStep("key"))},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := widgets.Validate(tc.widget)
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestFieldPanics(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a field outside the struct")
+		}
+	}()
+	other := "elsewhere"
+	Field(NewValidator[testWidget](), func(*testWidget) *string { return &other })
+}
@@ -0,0 +1,83 @@
+// Package apidiagschi integrates apidiags with the chi router, so chi
+// services can respond with Diagnostics in one call, build Paths from their
+// URL parameters, and have chi's request IDs show up in their error
+// responses.
+package apidiagschi
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"impractical.co/apidiags"
+)
+
+// URLParam returns the value of the chi URL parameter key for r, along with
+// Steps pointing to it, for use in Diagnostics about the parameter.
+func URLParam(r *http.Request, key string) (string, apidiags.Steps) {
+	return chi.URLParam(r, key), apidiags.URLParamPath(key)
+}
+
+// Render writes diags to w using apidiags.WriteHTTP. If chi's
+// middleware.RequestID has assigned r a request ID, it's set in the
+// middleware.RequestIDHeader of the response, so clients can report it.
+func Render(w http.ResponseWriter, r *http.Request, diags apidiags.Diagnostics, opts ...apidiags.HTTPOption) error {
+	if id := middleware.GetReqID(r.Context()); id != "" {
+		w.Header().Set(middleware.RequestIDHeader, id)
+	}
+	return apidiags.WriteHTTP(w, diags, opts...)
+}
+
+// RenderError writes the Diagnostics describing err to w using Render. The
+// Diagnostics are found using apidiags.FromError.
+func RenderError(w http.ResponseWriter, r *http.Request, err error, opts ...apidiags.HTTPOption) error {
+	return Render(w, r, apidiags.FromError(err), opts...)
+}
+
+// Handler returns an http.HandlerFunc that calls fn, writing the error it
+// returns, if any, using RenderError. fn must not write a response if it
+// returns an error.
+func Handler(fn func(http.ResponseWriter, *http.Request) error, opts ...apidiags.HTTPOption) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := fn(w, r); err != nil {
+			_ = RenderError(w, r, err, opts...)
+		}
+	}
+}
+
+// NotFound is an http.HandlerFunc for chi.Mux.NotFound, responding with a
+// Diagnostic with a Code of apidiags.CodeNotFound.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	_ = Render(w, r, apidiags.Diagnostics{{
+		Severity: apidiags.DiagnosticError,
+		Code:     apidiags.CodeNotFound,
+	}})
+}
+
+// MethodNotAllowed is an http.HandlerFunc for chi.Mux.MethodNotAllowed,
+// responding with http.StatusMethodNotAllowed and a Diagnostic with a Code
+// of apidiags.CodeInvalidValue.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	_ = Render(w, r, apidiags.Diagnostics{{
+		Severity: apidiags.DiagnosticError,
+		Code:     apidiags.CodeInvalidValue,
+		Summary:  "The " + r.Method + " method isn't supported.",
+	}}, apidiags.WithStatus(http.StatusMethodNotAllowed))
+}
+
+// Recoverer is a replacement for chi's middleware.Recoverer, using
+// apidiags.RecoverPanics to respond to panics with Diagnostics. The request
+// ID assigned by middleware.RequestID, if any, is used as the correlation
+// ID, so it should come before Recoverer in the middleware stack.
+func Recoverer(opts ...apidiags.RecoverOption) func(http.Handler) http.Handler {
+	opts = append([]apidiags.RecoverOption{
+		apidiags.WithCorrelationIDHeader(middleware.RequestIDHeader),
+		apidiags.WithCorrelationIDFunc(func(r *http.Request) string {
+			return middleware.GetReqID(r.Context())
+		}),
+	}, opts...)
+	return func(next http.Handler) http.Handler {
+		return apidiags.RecoverPanics(next, opts...)
+	}
+}
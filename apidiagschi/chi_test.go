@@ -0,0 +1,140 @@
+package apidiagschi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/go-cmp/cmp"
+
+	"impractical.co/apidiags"
+)
+
+type diagsError apidiags.Diagnostics
+
+func (e diagsError) Error() string { return "diagnostics" }
+
+func (e diagsError) Diagnostics() apidiags.Diagnostics { return apidiags.Diagnostics(e) }
+
+func newRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(Recoverer())
+	r.NotFound(NotFound)
+	r.MethodNotAllowed(MethodNotAllowed)
+	r.Get("/widgets/{id}", Handler(func(w http.ResponseWriter, r *http.Request) error {
+		id, path := URLParam(r, "id")
+		if id != "123" {
+			return diagsError{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeNotFound,
+				Paths:    []apidiags.Steps{path},
+			}}
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}))
+	r.Get("/panic", func(http.ResponseWriter, *http.Request) {
+		panic("oops")
+	})
+	return r
+}
+
+func TestRouter(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		method    string
+		target    string
+		requestID string
+		status    int
+		expected  apidiags.Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"found": {
+			method: http.MethodGet,
+			target: "/widgets/123",
+			status: http.StatusNoContent,
+		},
+		"url-param": {
+			method:    http.MethodGet,
+			target:    "/widgets/456",
+			requestID: "req-1",
+			status:    http.StatusNotFound,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeNotFound,
+				Paths:    []apidiags.Steps{apidiags.URLParamPath("id")},
+			}},
+		},
+		"not-found": {
+			method: http.MethodGet,
+			target: "/gadgets",
+			status: http.StatusNotFound,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeNotFound,
+			}},
+		},
+		"method-not-allowed": {
+			method: http.MethodPut,
+			target: "/widgets/123",
+			status: http.StatusMethodNotAllowed,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidValue,
+				Summary:  "The PUT method isn't supported.",
+			}},
+		},
+		"panic": {
+			method:    http.MethodGet,
+			target:    "/panic",
+			requestID: "req-2",
+			status:    http.StatusServiceUnavailable,
+			expected: apidiags.Diagnostics{{
+				Severity:   apidiags.DiagnosticError,
+				Code:       apidiags.CodeActOfGod,
+				Extensions: map[string]json.RawMessage{apidiags.CorrelationIDMember: json.RawMessage(`"req-2"`)},
+			}},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(tc.method, tc.target, nil)
+			if tc.requestID != "" {
+				r.Header.Set(middleware.RequestIDHeader, tc.requestID)
+			}
+			w := httptest.NewRecorder()
+			newRouter().ServeHTTP(w, r)
+
+			if w.Code != tc.status {
+				t.Errorf("expected status %d, got %d", tc.status, w.Code)
+			}
+			if tc.requestID != "" && w.Header().Get(middleware.RequestIDHeader) != tc.requestID {
+				t.Errorf("expected request ID %q, got %q", tc.requestID, w.Header().Get(middleware.RequestIDHeader))
+			}
+			if tc.expected == nil {
+				return
+			}
+			if w.Header().Get(middleware.RequestIDHeader) == "" {
+				t.Error("expected a request ID header")
+			}
+			diags, err := apidiags.ParseResponse(w.Result())
+			if err != nil {
+				t.Fatalf("unexpected error parsing response: %s", err)
+			}
+			if diff := cmp.Diff(tc.expected, diags); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
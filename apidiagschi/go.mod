@@ -0,0 +1,11 @@
+module impractical.co/apidiags/apidiagschi
+
+go 1.23
+
+require (
+	github.com/go-chi/chi/v5 v5.3.2
+	github.com/google/go-cmp v0.5.9
+	impractical.co/apidiags v0.0.0
+)
+
+replace impractical.co/apidiags => ../
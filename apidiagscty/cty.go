@@ -0,0 +1,77 @@
+// Package apidiagscty converts between apidiags Steps and go-cty's cty.Path,
+// so diagnostics produced by cty-based validation can be surfaced as
+// apidiags Diagnostics without manual translation.
+package apidiagscty
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"impractical.co/apidiags"
+)
+
+// FromPath converts a cty.Path into Steps, appending them to a copy of
+// root. It's usually called with apidiags.BodyPath() as root, so the
+// resulting Steps point into the body of the request.
+//
+// cty.GetAttrStep and cty.IndexStep with a string key both become
+// apidiags.ObjectPropertySteps; cty.IndexStep with a whole number key becomes
+// an apidiags.ArrayIndexStep. Any other key type, including the unknown
+// values used to index into sets, results in an error.
+func FromPath(root apidiags.Steps, path cty.Path) (apidiags.Steps, error) {
+	results := make(apidiags.Steps, 0, len(root)+len(path))
+	results = append(results, root...)
+	for pos, step := range path {
+		switch value := step.(type) {
+		case cty.GetAttrStep:
+			results = results.AddStep(apidiags.ObjectPropertyStep(value.Name))
+		case cty.IndexStep:
+			if !value.Key.IsKnown() || value.Key.IsNull() {
+				return nil, fmt.Errorf("error converting step %d: key must be known and non-null", pos)
+			}
+			switch value.Key.Type() {
+			case cty.String:
+				results = results.AddStep(apidiags.ObjectPropertyStep(value.Key.AsString()))
+			case cty.Number:
+				idx, accuracy := value.Key.AsBigFloat().Int64()
+				if accuracy != big.Exact {
+					return nil, fmt.Errorf("error converting step %d: key %s is not a whole number", pos, value.Key.AsBigFloat())
+				}
+				results = results.AddStep(apidiags.ArrayIndexStep(idx))
+			default:
+				return nil, fmt.Errorf("error converting step %d: unsupported key type %s", pos, value.Key.Type().FriendlyName())
+			}
+		default:
+			return nil, fmt.Errorf("unknown step type %T for step %d", step, pos)
+		}
+	}
+	return results, nil
+}
+
+// ToPath converts Steps into a cty.Path. A leading apidiags.BodyStep is
+// dropped, as cty.Paths are always relative to the value being validated.
+//
+// Because apidiags doesn't distinguish between object attributes and map
+// keys, apidiags.ObjectPropertySteps always become cty.GetAttrSteps.
+// apidiags.ArrayIndexSteps become cty.IndexSteps with a number key. Any other
+// Step has no cty equivalent and results in an error.
+func ToPath(steps apidiags.Steps) (cty.Path, error) {
+	results := make(cty.Path, 0, len(steps))
+	for pos, step := range steps {
+		switch value := step.(type) {
+		case apidiags.BodyStep:
+			if pos != 0 {
+				return nil, fmt.Errorf("step %d: %T is only supported as the first step", pos, step)
+			}
+		case apidiags.ObjectPropertyStep:
+			results = results.GetAttr(string(value))
+		case apidiags.ArrayIndexStep:
+			results = results.Index(cty.NumberIntVal(int64(value)))
+		default:
+			return nil, fmt.Errorf("step %d: %T has no cty equivalent", pos, step)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,140 @@
+package apidiagscty
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zclconf/go-cty/cty"
+
+	"impractical.co/apidiags"
+)
+
+func TestFromPath(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		root     apidiags.Steps
+		path     cty.Path
+		expected apidiags.Steps
+		wantErr  bool
+	}
+
+	cases := map[string]testCase{
+		"empty": {
+			root:     apidiags.BodyPath(),
+			expected: apidiags.BodyPath(),
+		},
+		"attr": {
+			root:     apidiags.BodyPath(),
+			path:     cty.GetAttrPath("foo"),
+			expected: apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("foo")),
+		},
+		"attr-index-mapKey": {
+			root: apidiags.BodyPath(),
+			path: cty.GetAttrPath("foo").IndexInt(2).IndexString("bar"),
+			expected: apidiags.BodyPath().
+				AddStep(apidiags.ObjectPropertyStep("foo")).
+				AddStep(apidiags.ArrayIndexStep(2)).
+				AddStep(apidiags.ObjectPropertyStep("bar")),
+		},
+		"no-root": {
+			path:     cty.IndexIntPath(1),
+			expected: apidiags.Steps{apidiags.ArrayIndexStep(1)},
+		},
+		"fractional-index": {
+			root:    apidiags.BodyPath(),
+			path:    cty.IndexPath(cty.NumberFloatVal(1.5)),
+			wantErr: true,
+		},
+		"unknown-index": {
+			root:    apidiags.BodyPath(),
+			path:    cty.IndexPath(cty.UnknownVal(cty.String)),
+			wantErr: true,
+		},
+		"bool-index": {
+			root:    apidiags.BodyPath(),
+			path:    cty.IndexPath(cty.True),
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := FromPath(tc.root, tc.path)
+			if err != nil && !tc.wantErr {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && tc.wantErr {
+				t.Fatalf("expected error, got %v", result)
+			}
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestToPath(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		steps    apidiags.Steps
+		expected cty.Path
+		wantErr  bool
+	}
+
+	cases := map[string]testCase{
+		"body": {
+			steps:    apidiags.BodyPath(),
+			expected: cty.Path{},
+		},
+		"body-prop-arrayIndex": {
+			steps: apidiags.BodyPath().
+				AddStep(apidiags.ObjectPropertyStep("foo")).
+				AddStep(apidiags.ArrayIndexStep(3)),
+			expected: cty.GetAttrPath("foo").IndexInt(3),
+		},
+		"relative": {
+			steps:    apidiags.Steps{apidiags.ObjectPropertyStep("foo")},
+			expected: cty.GetAttrPath("foo"),
+		},
+		"header": {
+			steps:   apidiags.HeaderPath("foo"),
+			wantErr: true,
+		},
+		"body-stringIndex": {
+			steps:   apidiags.BodyPath().AddStep(apidiags.StringIndexStep(1)),
+			wantErr: true,
+		},
+		"nested-body": {
+			steps:   apidiags.BodyPath().AddStep(apidiags.BodyStep{}),
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := ToPath(tc.steps)
+			if err != nil && !tc.wantErr {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && tc.wantErr {
+				t.Fatalf("expected error, got %#v", result)
+			}
+			if err != nil {
+				return
+			}
+			if !result.Equals(tc.expected) {
+				t.Fatalf("expected %#v, got %#v", tc.expected, result)
+			}
+		})
+	}
+}
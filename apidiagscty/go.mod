@@ -0,0 +1,17 @@
+module impractical.co/apidiags/apidiagscty
+
+go 1.25
+
+require (
+	github.com/google/go-cmp v0.5.9
+	github.com/zclconf/go-cty v1.19.0
+	impractical.co/apidiags v0.0.0
+)
+
+require (
+	github.com/apparentlymart/go-textseg/v15 v15.0.0 // indirect
+	github.com/apparentlymart/go-textseg/v17 v17.0.1 // indirect
+	golang.org/x/text v0.11.0 // indirect
+)
+
+replace impractical.co/apidiags => ../
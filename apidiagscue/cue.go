@@ -0,0 +1,176 @@
+// Package apidiagscue converts the errors CUE reports while validating a
+// payload against a CUE schema into apidiags Diagnostics, pointing to the
+// fields that failed validation.
+//
+// Errors about a field of the payload point to it, using
+// apidiags.ObjectPropertyStep and apidiags.ArrayIndexStep. Errors that
+// aren't about a field, like syntax errors, point to the position in the
+// payload CUE reports instead, represented the same way apidiagshcl
+// represents source positions: an apidiags.StringIndexStep holding the
+// byte offset into the payload. The line and column of the position are
+// kept in the PositionMember extension member.
+package apidiagscue
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue/errors"
+
+	"impractical.co/apidiags"
+)
+
+// PositionMember is the extension member FromError records the position in
+// the payload of an error that isn't about a field in, as an object with
+// the line, column, and byte offset of the position:
+//
+//	{"line": 3, "column": 12, "byte": 27}
+const PositionMember = "cue_position"
+
+// sourcePos is the JSON encoding of a position in the payload.
+type sourcePos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Byte   int `json:"byte"`
+}
+
+// FromError converts err, returned by CUE while extracting or validating a
+// payload, into Diagnostics, one per error CUE reports, each with a
+// Severity of apidiags.DiagnosticError. root points to the payload within
+// the request, like apidiags.BodyPath() if the request body is the
+// payload, and filename is the name the payload was given when it was
+// loaded, like the filename passed to cuelang.org/go/encoding/json.Extract.
+//
+// Each Diagnostic's Path is root, followed by the path of the field the
+// error is about. Definitions and hidden fields, like the `#Widget` in
+// `#Widget.name` when validating against a definition, aren't part of the
+// payload, and are left out of the Path. Errors without a field that have
+// a position within filename point to it using an apidiags.StringIndexStep
+// holding its byte offset, and have its line and column recorded in
+// PositionMember; errors with neither have no Path.
+//
+// Codes are chosen based on the kind of error: apidiags.CodeMissing for
+// required fields that aren't present, apidiags.CodeInvalidFormat for
+// values of the wrong type and payloads that can't be parsed,
+// apidiags.CodeOverflow and apidiags.CodeInsufficient for values that are
+// out of bounds, and apidiags.CodeInvalidValue for everything else. The
+// errors for each branch of a disjunction that nothing matched are
+// combined into a single Diagnostic.
+func FromError(root apidiags.Steps, filename string, err error) apidiags.Diagnostics {
+	errs := errors.Errors(err)
+	if len(errs) < 1 {
+		return nil
+	}
+	results := make(apidiags.Diagnostics, 0, len(errs))
+	var disjunction *apidiags.Diagnostic
+	var disjunctionPath []string
+	var branches []string
+	finishDisjunction := func() {
+		if disjunction == nil {
+			return
+		}
+		disjunction.Detail = strings.Join(branches, "; ")
+		results = append(results, *disjunction)
+		disjunction, disjunctionPath, branches = nil, nil, nil
+	}
+	for _, cueErr := range errs {
+		format, args := cueErr.Msg()
+		if disjunction != nil && equalPaths(cueErr.Path(), disjunctionPath) {
+			branches = append(branches, fmt.Sprintf(format, args...))
+			continue
+		}
+		finishDisjunction()
+		diag := apidiags.Diagnostic{
+			Severity: apidiags.DiagnosticError,
+			Code:     code(format, args),
+			Detail:   fmt.Sprintf(format, args...),
+		}
+		if path, ok := fieldPath(root, cueErr.Path()); ok {
+			diag.Paths = []apidiags.Steps{path}
+		} else if pos := cueErr.Position(); pos.IsValid() && pos.Filename() == filename {
+			path := make(apidiags.Steps, 0, len(root)+1)
+			path = append(path, root...)
+			diag.Paths = []apidiags.Steps{path.AddStep(apidiags.StringIndexStep(pos.Offset()))}
+			encoded, err := json.Marshal(sourcePos{Line: pos.Line(), Column: pos.Column(), Byte: pos.Offset()})
+			if err == nil {
+				diag.Extensions = map[string]json.RawMessage{PositionMember: encoded}
+			}
+		}
+		if strings.HasSuffix(format, "errors in empty disjunction:") {
+			diag.Code = apidiags.CodeInvalidValue
+			disjunction = &diag
+			disjunctionPath = cueErr.Path()
+			continue
+		}
+		results = append(results, diag)
+	}
+	finishDisjunction()
+	return results
+}
+
+// code returns the apidiags.Code describing a CUE error with the message
+// format and args.
+func code(format string, args []any) apidiags.Code {
+	switch {
+	case format == "field is required but not present", strings.HasPrefix(format, "incomplete value"):
+		return apidiags.CodeMissing
+	case strings.Contains(format, "(mismatched types"), strings.HasPrefix(format, "invalid JSON"):
+		return apidiags.CodeInvalidFormat
+	case strings.Contains(format, "(out of bound") && len(args) > 1:
+		bound := fmt.Sprint(args[1])
+		if strings.HasPrefix(bound, "<") {
+			return apidiags.CodeOverflow
+		}
+		if strings.HasPrefix(bound, ">") {
+			return apidiags.CodeInsufficient
+		}
+	case strings.Contains(format, "(does not satisfy") && len(args) > 1:
+		validator := fmt.Sprint(args[1])
+		if strings.Contains(validator, ".Max") {
+			return apidiags.CodeOverflow
+		}
+		if strings.Contains(validator, ".Min") {
+			return apidiags.CodeInsufficient
+		}
+	}
+	return apidiags.CodeInvalidValue
+}
+
+// fieldPath appends the labels of a CUE path to root, skipping
+// definitions and hidden fields. If there are no labels left, false is
+// returned.
+func fieldPath(root apidiags.Steps, labels []string) (apidiags.Steps, bool) {
+	path := make(apidiags.Steps, 0, len(root)+len(labels))
+	path = append(path, root...)
+	found := false
+	for _, label := range labels {
+		if strings.HasPrefix(label, "#") || strings.HasPrefix(label, "_") {
+			continue
+		}
+		found = true
+		if unquoted, err := strconv.Unquote(label); err == nil {
+			path = path.AddStep(apidiags.ObjectPropertyStep(unquoted))
+			continue
+		}
+		if idx, err := strconv.ParseInt(label, 10, 64); err == nil && idx >= 0 {
+			path = path.AddStep(apidiags.ArrayIndexStep(idx))
+			continue
+		}
+		path = path.AddStep(apidiags.ObjectPropertyStep(label))
+	}
+	return path, found
+}
+
+func equalPaths(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for pos := range a {
+		if a[pos] != b[pos] {
+			return false
+		}
+	}
+	return true
+}
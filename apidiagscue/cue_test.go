@@ -0,0 +1,157 @@
+package apidiagscue
+
+import (
+	stdjson "encoding/json"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/encoding/json"
+	"github.com/google/go-cmp/cmp"
+
+	"impractical.co/apidiags"
+)
+
+const schema = `
+import "strings"
+
+#Widget: {
+	name!:      string & strings.MaxRunes(5)
+	count:      int & >=1 & <=10
+	mode?:      "fast" | "slow"
+	tags?:      [...string]
+	"label-id"?: int
+}
+`
+
+func validate(t *testing.T, payload string) error {
+	t.Helper()
+
+	ctx := cuecontext.New()
+	widget := ctx.CompileString(schema, cue.Filename("schema.cue")).LookupPath(cue.ParsePath("#Widget"))
+	if widget.Err() != nil {
+		t.Fatalf("unexpected error compiling schema: %s", widget.Err())
+	}
+	expr, err := json.Extract("body.json", []byte(payload))
+	if err != nil {
+		return err
+	}
+	return widget.Unify(ctx.BuildExpr(expr)).Validate(cue.Concrete(true))
+}
+
+func TestFromError(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		payload  string
+		expected apidiags.Diagnostics
+	}
+
+	body := func(steps ...apidiags.Step) apidiags.Steps {
+		return apidiags.PathOf(append([]apidiags.Step{apidiags.BodyStep{}}, steps...)...)
+	}
+
+	cases := map[string]testCase{
+		"valid": {
+			payload: `{"name": "foo", "count": 1}`,
+		},
+		"missing": {
+			payload: `{"count": 1}`,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeMissing,
+				Paths:    []apidiags.Steps{body(apidiags.ObjectPropertyStep("name"))},
+				Detail:   "field is required but not present",
+			}},
+		},
+		"bounds": {
+			payload: `{"name": "foobarbaz", "count": 11}`,
+			expected: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeOverflow,
+					Paths:    []apidiags.Steps{body(apidiags.ObjectPropertyStep("name"))},
+					Detail:   `invalid value "foobarbaz" (does not satisfy strings.MaxRunes(5))`,
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeOverflow,
+					Paths:    []apidiags.Steps{body(apidiags.ObjectPropertyStep("count"))},
+					Detail:   "invalid value 11 (out of bound <=10)",
+				},
+			},
+		},
+		"types": {
+			payload: `{"name": "foo", "count": 1, "tags": ["a", 2], "label-id": "x"}`,
+			expected: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeInvalidFormat,
+					Paths:    []apidiags.Steps{body(apidiags.ObjectPropertyStep("tags"), apidiags.ArrayIndexStep(1))},
+					Detail:   "conflicting values 2 and string (mismatched types int and string)",
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeInvalidFormat,
+					Paths:    []apidiags.Steps{body(apidiags.ObjectPropertyStep("label-id"))},
+					Detail:   `conflicting values int and "x" (mismatched types int and string)`,
+				},
+			},
+		},
+		"disjunction": {
+			payload: `{"name": "foo", "count": 1, "mode": "medium"}`,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidValue,
+				Paths:    []apidiags.Steps{body(apidiags.ObjectPropertyStep("mode"))},
+				Detail:   `conflicting values "fast" and "medium"; conflicting values "slow" and "medium"`,
+			}},
+		},
+		"not-allowed": {
+			payload: `{"name": "foo", "count": 1, "extra": true}`,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidValue,
+				Paths:    []apidiags.Steps{body(apidiags.ObjectPropertyStep("extra"))},
+				Detail:   "field not allowed",
+			}},
+		},
+		"syntax": {
+			payload: `{"name": `,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidFormat,
+				Paths:    []apidiags.Steps{body(apidiags.StringIndexStep(8))},
+				Detail:   `invalid JSON for file "body.json"`,
+				Extensions: map[string]stdjson.RawMessage{
+					PositionMember: stdjson.RawMessage(`{"line":1,"column":9,"byte":8}`),
+				},
+			}},
+		},
+		"syntax-multiline": {
+			payload: "{\n  \"name\": \"foo\",\n  \"count\": ]\n}",
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidFormat,
+				Paths:    []apidiags.Steps{body(apidiags.StringIndexStep(30))},
+				Detail:   `invalid JSON for file "body.json"`,
+				Extensions: map[string]stdjson.RawMessage{
+					PositionMember: stdjson.RawMessage(`{"line":3,"column":12,"byte":30}`),
+				},
+			}},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result := FromError(apidiags.BodyPath(), "body.json", validate(t, tc.payload))
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// Package apidiagsecho integrates apidiags with the echo web framework, so
+// echo handlers can return Diagnostics as errors, and echo's binding
+// failures are described by Diagnostics pointing to the offending parts of
+// the request.
+package apidiagsecho
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"impractical.co/apidiags"
+)
+
+// diagnosticsError is an error carrying Diagnostics, returned by Error.
+type diagnosticsError apidiags.Diagnostics
+
+func (e diagnosticsError) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, diag := range e {
+		messages = append(messages, diag.Message())
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e diagnosticsError) Diagnostics() apidiags.Diagnostics {
+	return apidiags.Diagnostics(e)
+}
+
+// Error returns an error carrying diags, for echo handlers to return. It
+// implements apidiags.DiagnosticsError, so HTTPErrorHandler writes diags as
+// the response.
+func Error(diags apidiags.Diagnostics) error {
+	return diagnosticsError(diags)
+}
+
+// HTTPErrorHandler returns an echo.HTTPErrorHandler that writes the
+// Diagnostics describing each error, found using FromError, with
+// apidiags.WriteHTTP. Errors FromError describes by the status code of an
+// *echo.HTTPError are written with that status code, unless opts set
+// another with apidiags.WithStatus. Responses that have already been
+// committed are left alone.
+//
+//	e := echo.New()
+//	e.HTTPErrorHandler = apidiagsecho.HTTPErrorHandler()
+func HTTPErrorHandler(opts ...apidiags.HTTPOption) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+		diags, status := fromError(err)
+		if status != 0 {
+			opts = append([]apidiags.HTTPOption{apidiags.WithStatus(status)}, opts...)
+		}
+		_ = apidiags.WriteHTTP(c.Response(), diags, opts...)
+	}
+}
+
+// FromError converts err, as returned by an echo handler, into Diagnostics.
+//
+// Errors implementing apidiags.DiagnosticsError, like those returned by
+// Error, are converted using apidiags.FromError, even when wrapped by an
+// *echo.HTTPError. *echo.BindingErrors, as returned by echo's fluent
+// binders like echo.QueryParamsBinder, point to the parameter that couldn't
+// be bound. JSON syntax and type errors encountered while binding a body point
+// into the body. Other *echo.HTTPErrors, like those echo's router returns
+// for unknown routes, are described by a Diagnostic with a Code chosen by
+// apidiags.CodeForStatus.
+func FromError(err error) apidiags.Diagnostics {
+	diags, _ := fromError(err)
+	return diags
+}
+
+// fromError converts err into Diagnostics like FromError, also returning
+// the status code of the *echo.HTTPError they describe, if that's how
+// they were chosen, or 0 if WriteHTTP should choose the status code.
+func fromError(err error) (apidiags.Diagnostics, int) {
+	var bindingErr *echo.BindingError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var httpErr *echo.HTTPError
+	var diagsErr apidiags.DiagnosticsError
+	switch {
+	case errors.As(err, &diagsErr):
+		return apidiags.FromError(err), 0
+	case errors.As(err, &bindingErr):
+		return apidiags.Diagnostics{{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeInvalidFormat,
+			Paths:    []apidiags.Steps{apidiags.URLParamPath(bindingErr.Field)},
+		}}, 0
+	case errors.As(err, &syntaxErr):
+		return apidiags.Diagnostics{{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeInvalidFormat,
+			Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.StringIndexStep(syntaxErr.Offset))},
+			Detail:   syntaxErr.Error(),
+		}}, 0
+	case errors.As(err, &typeErr):
+		path := apidiags.BodyPath()
+		if typeErr.Field != "" {
+			for _, field := range strings.Split(typeErr.Field, ".") {
+				path = path.AddStep(apidiags.ObjectPropertyStep(field))
+			}
+		}
+		return apidiags.Diagnostics{{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeInvalidFormat,
+			Paths:    []apidiags.Steps{path},
+			Detail:   "Expected " + typeErr.Type.String() + ", got " + typeErr.Value + ".",
+		}}, 0
+	case errors.As(err, &httpErr):
+		diag := apidiags.Diagnostic{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeForStatus(httpErr.Code),
+		}
+		if message, ok := httpErr.Message.(string); ok && message != http.StatusText(httpErr.Code) {
+			diag.Summary = message
+		}
+		return apidiags.Diagnostics{diag}, httpErr.Code
+	}
+	return apidiags.FromError(err), 0
+}
+
+// ValidatorFunc is an echo.Validator that validates using a function
+// returning Diagnostics, so c.Validate returns an error carrying the
+// Diagnostics when any of them are errors.
+//
+//	e.Validator = apidiagsecho.ValidatorFunc(func(i any) apidiags.Diagnostics {
+//		return i.(interface{ Validate() apidiags.Diagnostics }).Validate()
+//	})
+type ValidatorFunc func(i any) apidiags.Diagnostics
+
+// Validate calls fn, returning an error carrying its Diagnostics if any of
+// them are errors.
+func (fn ValidatorFunc) Validate(i any) error {
+	diags := fn(i)
+	if !diags.HasErrors() {
+		return nil
+	}
+	return Error(diags)
+}
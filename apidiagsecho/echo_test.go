@@ -0,0 +1,214 @@
+package apidiagsecho
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/labstack/echo/v4"
+
+	"impractical.co/apidiags"
+)
+
+type widget struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func newEcho() *echo.Echo {
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler()
+	e.Validator = ValidatorFunc(func(i any) apidiags.Diagnostics {
+		w, ok := i.(*widget)
+		if !ok || w.Name != "" {
+			return nil
+		}
+		return apidiags.Diagnostics{{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeMissing,
+			Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name"))},
+		}}
+	})
+	e.POST("/widgets", func(c echo.Context) error {
+		var body widget
+		if err := c.Bind(&body); err != nil {
+			return err
+		}
+		if err := c.Validate(&body); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusCreated)
+	})
+	e.GET("/widgets", func(c echo.Context) error {
+		var limit int
+		if err := echo.QueryParamsBinder(c).Int("limit", &limit).BindError(); err != nil {
+			return err
+		}
+		return echo.NewHTTPError(http.StatusForbidden, "Your plan doesn't include widgets.")
+	})
+	e.GET("/account", func(c echo.Context) error {
+		return echo.ErrUnauthorized
+	})
+	e.GET("/quota", func(c echo.Context) error {
+		return echo.ErrTooManyRequests
+	})
+	e.GET("/widgets/:id", func(c echo.Context) error {
+		return Error(apidiags.Diagnostics{{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeNotFound,
+			Paths:    []apidiags.Steps{apidiags.URLParamPath("id")},
+		}})
+	})
+	return e
+}
+
+func TestHTTPErrorHandler(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		method   string
+		target   string
+		body     string
+		status   int
+		expected apidiags.Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"valid": {
+			method: http.MethodPost,
+			target: "/widgets",
+			body:   `{"name":"foo","count":3}`,
+			status: http.StatusCreated,
+		},
+		"validation": {
+			method: http.MethodPost,
+			target: "/widgets",
+			body:   `{"count":3}`,
+			status: http.StatusBadRequest,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeMissing,
+				Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name"))},
+			}},
+		},
+		"syntax": {
+			method: http.MethodPost,
+			target: "/widgets",
+			body:   `{"name":}`,
+			status: http.StatusBadRequest,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidFormat,
+				Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.StringIndexStep(9))},
+				Detail:   "invalid character '}' looking for beginning of value",
+			}},
+		},
+		"type": {
+			method: http.MethodPost,
+			target: "/widgets",
+			body:   `{"name":"foo","count":"three"}`,
+			status: http.StatusBadRequest,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidFormat,
+				Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("count"))},
+				Detail:   "Expected int, got string.",
+			}},
+		},
+		"query-binding": {
+			method: http.MethodGet,
+			target: "/widgets?limit=lots",
+			status: http.StatusBadRequest,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidFormat,
+				Paths:    []apidiags.Steps{apidiags.URLParamPath("limit")},
+			}},
+		},
+		"http-error": {
+			method: http.MethodGet,
+			target: "/widgets?limit=3",
+			status: http.StatusForbidden,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeAccessDenied,
+				Summary:  "Your plan doesn't include widgets.",
+			}},
+		},
+		"diagnostics-error": {
+			method: http.MethodGet,
+			target: "/widgets/123",
+			status: http.StatusNotFound,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeNotFound,
+				Paths:    []apidiags.Steps{apidiags.URLParamPath("id")},
+			}},
+		},
+		"unauthorized": {
+			method: http.MethodGet,
+			target: "/account",
+			status: http.StatusUnauthorized,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeAccessDenied,
+			}},
+		},
+		"method-not-allowed": {
+			method: http.MethodDelete,
+			target: "/widgets",
+			status: http.StatusMethodNotAllowed,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidValue,
+			}},
+		},
+		"too-many-requests": {
+			method: http.MethodGet,
+			target: "/quota",
+			status: http.StatusTooManyRequests,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeActOfGod,
+			}},
+		},
+		"unknown-route": {
+			method: http.MethodGet,
+			target: "/gadgets",
+			status: http.StatusNotFound,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeNotFound,
+			}},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			newEcho().ServeHTTP(w, r)
+
+			if w.Code != tc.status {
+				t.Errorf("expected status %d, got %d", tc.status, w.Code)
+			}
+			if tc.expected == nil {
+				return
+			}
+			diags, err := apidiags.ParseResponse(w.Result())
+			if err != nil {
+				t.Fatalf("unexpected error parsing response: %s", err)
+			}
+			if diff := cmp.Diff(tc.expected, diags); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+// Package apidiagsfiber integrates apidiags with the fiber web framework, so
+// fasthttp-based services can respond with the same Diagnostics as net/http
+// services, and fiber's body-parsing failures are described by Diagnostics
+// pointing into the body.
+package apidiagsfiber
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+
+	"impractical.co/apidiags"
+)
+
+// diagnosticsError is an error carrying Diagnostics, returned by Error.
+type diagnosticsError apidiags.Diagnostics
+
+func (e diagnosticsError) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, diag := range e {
+		messages = append(messages, diag.Message())
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e diagnosticsError) Diagnostics() apidiags.Diagnostics {
+	return apidiags.Diagnostics(e)
+}
+
+// Error returns an error carrying diags, for fiber handlers to return. It
+// implements apidiags.DiagnosticsError, so ErrorHandler writes diags as the
+// response.
+func Error(diags apidiags.Diagnostics) error {
+	return diagnosticsError(diags)
+}
+
+// Render writes diags to c using apidiags.WriteHTTP, so the response has
+// the same status code, headers, and body a net/http service would send.
+func Render(c fiber.Ctx, diags apidiags.Diagnostics, opts ...apidiags.HTTPOption) error {
+	return apidiags.WriteHTTP(&responseWriter{ctx: c, header: http.Header{}}, diags, opts...)
+}
+
+// ErrorHandler returns a fiber.ErrorHandler that writes the Diagnostics
+// describing each error, found using FromError, with Render. Errors
+// FromError describes by the status code of a *fiber.Error are written with
+// that status code, unless opts set another with apidiags.WithStatus.
+//
+//	app := fiber.New(fiber.Config{
+//		ErrorHandler: apidiagsfiber.ErrorHandler(),
+//	})
+func ErrorHandler(opts ...apidiags.HTTPOption) fiber.ErrorHandler {
+	return func(c fiber.Ctx, err error) error {
+		diags, status := fromError(err)
+		if status != 0 {
+			opts = append([]apidiags.HTTPOption{apidiags.WithStatus(status)}, opts...)
+		}
+		return Render(c, diags, opts...)
+	}
+}
+
+// FromError converts err, as returned by a fiber handler, into Diagnostics.
+//
+// Errors implementing apidiags.DiagnosticsError, like those returned by
+// Error, are converted using apidiags.FromError. *fiber.BindErrors, as
+// returned by c.Bind(), point to the field that couldn't be bound: into the
+// body for body binding, with JSON syntax errors pointing to the byte
+// offset of the error, to the header for header binding, and to the URL
+// parameter for URI and query binding. c.Bind().WithAutoHandling() replaces
+// these errors with a *fiber.Error, losing the field, so it shouldn't be
+// used.
+//
+// Any other *fiber.Error, like those fiber's router returns for unknown
+// routes, is described by a Diagnostic with a Code chosen by
+// apidiags.CodeForStatus.
+func FromError(err error) apidiags.Diagnostics {
+	diags, _ := fromError(err)
+	return diags
+}
+
+// fromError converts err into Diagnostics like FromError, also returning
+// the status code of the *fiber.Error they describe, if that's how they
+// were chosen, or 0 if WriteHTTP should choose the status code.
+func fromError(err error) (apidiags.Diagnostics, int) {
+	var diagsErr apidiags.DiagnosticsError
+	var bindErr *fiber.BindError
+	var fiberErr *fiber.Error
+	switch {
+	case errors.As(err, &diagsErr):
+		return apidiags.FromError(err), 0
+	case errors.As(err, &bindErr):
+		return bindDiagnostics(bindErr), 0
+	case errors.As(err, &fiberErr):
+		diag := apidiags.Diagnostic{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeForStatus(fiberErr.Code),
+		}
+		if fiberErr.Message != http.StatusText(fiberErr.Code) {
+			diag.Summary = fiberErr.Message
+		}
+		return apidiags.Diagnostics{diag}, fiberErr.Code
+	}
+	return apidiags.FromError(err), 0
+}
+
+func bindDiagnostics(err *fiber.BindError) apidiags.Diagnostics {
+	diag := apidiags.Diagnostic{
+		Severity: apidiags.DiagnosticError,
+		Code:     apidiags.CodeInvalidFormat,
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err.Source != fiber.BindSourceBody:
+		if err.Field == "" {
+			break
+		}
+		if err.Source == fiber.BindSourceHeader {
+			diag.Paths = []apidiags.Steps{apidiags.HeaderPath(err.Field)}
+		} else {
+			diag.Paths = []apidiags.Steps{apidiags.URLParamPath(err.Field)}
+		}
+	case errors.As(err, &syntaxErr):
+		diag.Paths = []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.StringIndexStep(syntaxErr.Offset))}
+		diag.Detail = syntaxErr.Error()
+	case errors.As(err, &typeErr):
+		path := apidiags.BodyPath()
+		if typeErr.Field != "" {
+			for _, field := range strings.Split(typeErr.Field, ".") {
+				path = path.AddStep(apidiags.ObjectPropertyStep(field))
+			}
+		}
+		diag.Paths = []apidiags.Steps{path}
+		diag.Detail = "Expected " + typeErr.Type.String() + ", got " + typeErr.Value + "."
+	default:
+		path := apidiags.BodyPath()
+		if err.Field != "" {
+			path = path.AddStep(apidiags.ObjectPropertyStep(err.Field))
+		}
+		diag.Paths = []apidiags.Steps{path}
+	}
+	return apidiags.Diagnostics{diag}
+}
+
+// responseWriter adapts a fiber.Ctx to an http.ResponseWriter, so
+// apidiags.WriteHTTP can write to it.
+type responseWriter struct {
+	ctx         fiber.Ctx
+	header      http.Header
+	wroteHeader bool
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	for key, values := range w.header {
+		w.ctx.Response().Header.Del(key)
+		for _, value := range values {
+			w.ctx.Response().Header.Add(key, value)
+		}
+	}
+	w.ctx.Status(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.ctx.Write(b)
+}
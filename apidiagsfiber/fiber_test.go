@@ -0,0 +1,229 @@
+package apidiagsfiber
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/go-cmp/cmp"
+
+	"impractical.co/apidiags"
+)
+
+type widget struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type widgetQuery struct {
+	Limit int `query:"limit"`
+}
+
+type widgetHeaders struct {
+	Version int `header:"X-Version"`
+}
+
+func newApp() *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler()})
+	app.Post("/widgets", func(c fiber.Ctx) error {
+		var body widget
+		if err := c.Bind().Body(&body); err != nil {
+			return err
+		}
+		if body.Name == "" {
+			return Error(apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeMissing,
+				Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name"))},
+			}})
+		}
+		return c.SendStatus(http.StatusCreated)
+	})
+	app.Get("/widgets", func(c fiber.Ctx) error {
+		var query widgetQuery
+		if err := c.Bind().Query(&query); err != nil {
+			return err
+		}
+		var headers widgetHeaders
+		if err := c.Bind().Header(&headers); err != nil {
+			return err
+		}
+		return Render(c, apidiags.Diagnostics{{
+			Severity: apidiags.DiagnosticWarning,
+			Code:     apidiags.CodeDeprecated,
+			DocURL:   "https://example.com/docs/v2",
+		}})
+	})
+	app.Get("/account", func(c fiber.Ctx) error {
+		return fiber.ErrUnauthorized
+	})
+	app.Get("/quota", func(c fiber.Ctx) error {
+		return fiber.ErrTooManyRequests
+	})
+	return app
+}
+
+func TestErrorHandler(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		method   string
+		target   string
+		body     string
+		headers  map[string]string
+		status   int
+		link     string
+		expected apidiags.Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"valid": {
+			method: http.MethodPost,
+			target: "/widgets",
+			body:   `{"name":"foo","count":3}`,
+			status: http.StatusCreated,
+		},
+		"diagnostics-error": {
+			method: http.MethodPost,
+			target: "/widgets",
+			body:   `{"count":3}`,
+			status: http.StatusBadRequest,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeMissing,
+				Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name"))},
+			}},
+		},
+		"syntax": {
+			method: http.MethodPost,
+			target: "/widgets",
+			body:   `{"name":}`,
+			status: http.StatusBadRequest,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidFormat,
+				Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.StringIndexStep(9))},
+				Detail:   "invalid character '}' looking for beginning of value",
+			}},
+		},
+		"type": {
+			method: http.MethodPost,
+			target: "/widgets",
+			body:   `{"name":"foo","count":"three"}`,
+			status: http.StatusBadRequest,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidFormat,
+				Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("count"))},
+				Detail:   "Expected int, got string.",
+			}},
+		},
+		"query": {
+			method: http.MethodGet,
+			target: "/widgets?limit=lots",
+			status: http.StatusBadRequest,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidFormat,
+				Paths:    []apidiags.Steps{apidiags.URLParamPath("limit")},
+			}},
+		},
+		"header": {
+			method:  http.MethodGet,
+			target:  "/widgets",
+			headers: map[string]string{"X-Version": "two"},
+			status:  http.StatusBadRequest,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidFormat,
+				Paths:    []apidiags.Steps{apidiags.HeaderPath("X-Version")},
+			}},
+		},
+		"render": {
+			method: http.MethodGet,
+			target: "/widgets",
+			status: http.StatusOK,
+			link:   `<https://example.com/docs/v2>; rel="deprecation"`,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticWarning,
+				Code:     apidiags.CodeDeprecated,
+				DocURL:   "https://example.com/docs/v2",
+			}},
+		},
+		"unauthorized": {
+			method: http.MethodGet,
+			target: "/account",
+			status: http.StatusUnauthorized,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeAccessDenied,
+			}},
+		},
+		"method-not-allowed": {
+			method: http.MethodDelete,
+			target: "/widgets",
+			status: http.StatusMethodNotAllowed,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidValue,
+			}},
+		},
+		"too-many-requests": {
+			method: http.MethodGet,
+			target: "/quota",
+			status: http.StatusTooManyRequests,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeActOfGod,
+			}},
+		},
+		"unknown-route": {
+			method: http.MethodGet,
+			target: "/gadgets",
+			status: http.StatusNotFound,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeNotFound,
+			}},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			r.Header.Set("Content-Type", "application/json")
+			for key, value := range tc.headers {
+				r.Header.Set(key, value)
+			}
+			resp, err := newApp().Test(r)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if resp.StatusCode != tc.status {
+				t.Errorf("expected status %d, got %d", tc.status, resp.StatusCode)
+			}
+			if link := resp.Header.Get("Link"); link != tc.link {
+				t.Errorf("expected Link header %q, got %q", tc.link, link)
+			}
+			if tc.expected == nil {
+				return
+			}
+			if contentType := resp.Header.Get("Content-Type"); contentType != apidiags.ProblemContentType {
+				t.Errorf("expected Content-Type %q, got %q", apidiags.ProblemContentType, contentType)
+			}
+			diags, err := apidiags.ParseResponse(resp)
+			if err != nil {
+				t.Fatalf("unexpected error parsing response: %s", err)
+			}
+			if diff := cmp.Diff(tc.expected, diags); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
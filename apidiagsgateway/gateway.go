@@ -0,0 +1,127 @@
+// Package apidiagsgateway integrates apidiags with grpc-gateway, so services
+// serving the same API over gRPC and HTTP present the same Diagnostics on
+// both: errors described by google.rpc details, as written by
+// apidiagsgrpc.ToStatus, become application/problem+json responses and
+// back, and warnings sent as gRPC metadata become apidiags.WarningsHeader
+// headers.
+package apidiagsgateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"impractical.co/apidiags"
+	"impractical.co/apidiags/apidiagsgrpc"
+)
+
+// WarningsMetadataKey is the gRPC metadata key SetWarnings sends warnings
+// under, and ForwardWarnings reads them from.
+const WarningsMetadataKey = "x-api-warnings"
+
+// ErrorHandler returns a runtime.ErrorHandlerFunc, for use with
+// runtime.WithErrorHandler, that converts the gRPC status of each error
+// into Diagnostics using apidiagsgrpc.FromStatus, and writes them using
+// apidiags.WriteHTTP. The response's status code is chosen from the
+// Diagnostics, not the gRPC status code, so it matches the status code an
+// HTTP service would use for the same Diagnostics.
+//
+//	mux := runtime.NewServeMux(
+//		runtime.WithErrorHandler(apidiagsgateway.ErrorHandler()),
+//		runtime.WithRoutingErrorHandler(apidiagsgateway.RoutingErrorHandler()),
+//		runtime.WithForwardResponseOption(apidiagsgateway.ForwardWarnings),
+//	)
+func ErrorHandler(opts ...apidiags.HTTPOption) runtime.ErrorHandlerFunc {
+	return func(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, _ *http.Request, err error) {
+		var statusErr *runtime.HTTPStatusError
+		if errors.As(err, &statusErr) {
+			writeStatus(w, statusErr.HTTPStatus, opts)
+			return
+		}
+		_ = apidiags.WriteHTTP(w, apidiagsgrpc.FromStatus(status.Convert(err)), opts...)
+	}
+}
+
+// RoutingErrorHandler returns a runtime.RoutingErrorHandlerFunc, for use
+// with runtime.WithRoutingErrorHandler, that describes requests grpc-gateway
+// can't route, like those for unknown paths or with unsupported methods, by
+// a Diagnostic with a Code chosen by apidiags.CodeForStatus.
+func RoutingErrorHandler(opts ...apidiags.HTTPOption) runtime.RoutingErrorHandlerFunc {
+	return func(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, _ *http.Request, httpStatus int) {
+		writeStatus(w, httpStatus, opts)
+	}
+}
+
+// writeStatus writes a response with the HTTP status code httpStatus and a
+// single Diagnostic describing it.
+func writeStatus(w http.ResponseWriter, httpStatus int, opts []apidiags.HTTPOption) {
+	diags := apidiags.Diagnostics{{
+		Severity: apidiags.DiagnosticError,
+		Code:     apidiags.CodeForStatus(httpStatus),
+	}}
+	opts = append([]apidiags.HTTPOption{apidiags.WithStatus(httpStatus)}, opts...)
+	_ = apidiags.WriteHTTP(w, diags, opts...)
+}
+
+// StatusFromResponse converts an HTTP response carrying Diagnostics, like
+// those written by ErrorHandler, back into a gRPC status, using
+// apidiags.ParseResponse and apidiagsgrpc.ToStatus. It's the reverse of
+// ErrorHandler, for gRPC services fronting HTTP APIs.
+func StatusFromResponse(resp *http.Response, opts ...apidiagsgrpc.Option) (*status.Status, error) {
+	diags, err := apidiags.ParseResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	return apidiagsgrpc.ToStatus(diags, opts...)
+}
+
+// SetWarnings sends the warnings in diags as header metadata of the gRPC
+// call in ctx, under WarningsMetadataKey, using grpc.SetHeader. It's meant
+// for gRPC servers, whose successful responses have nowhere else to carry
+// warnings. The warnings are encoded the same way as
+// apidiags.SetWarningsHeader encodes them.
+func SetWarnings(ctx context.Context, diags apidiags.Diagnostics) error {
+	h := http.Header{}
+	err := apidiags.SetWarningsHeader(h, diags)
+	if err != nil {
+		return err
+	}
+	value := h.Get(apidiags.WarningsHeader)
+	if value == "" {
+		return nil
+	}
+	return grpc.SetHeader(ctx, metadata.Pairs(WarningsMetadataKey, value))
+}
+
+// WarningsFromMetadata decodes the warnings sent by SetWarnings, for gRPC
+// clients to read from the header metadata of a call.
+func WarningsFromMetadata(md metadata.MD) (apidiags.Diagnostics, error) {
+	values := md.Get(WarningsMetadataKey)
+	if len(values) < 1 {
+		return nil, nil
+	}
+	h := http.Header{}
+	h.Set(apidiags.WarningsHeader, values[0])
+	return apidiags.WarningsFromHeader(h)
+}
+
+// ForwardWarnings is a forwarder, for use with
+// runtime.WithForwardResponseOption, that copies the warnings sent by
+// SetWarnings into the apidiags.WarningsHeader of the HTTP response, so
+// HTTP clients see the same warnings as gRPC clients.
+func ForwardWarnings(ctx context.Context, w http.ResponseWriter, _ proto.Message) error {
+	md, ok := runtime.ServerMetadataFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	if values := md.HeaderMD.Get(WarningsMetadataKey); len(values) > 0 {
+		w.Header().Set(apidiags.WarningsHeader, values[0])
+	}
+	return nil
+}
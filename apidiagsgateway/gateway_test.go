@@ -0,0 +1,183 @@
+package apidiagsgateway
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"impractical.co/apidiags"
+	"impractical.co/apidiags/apidiagsgrpc"
+)
+
+func TestErrorHandler(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		err      func(t *testing.T) error
+		status   int
+		expected apidiags.Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"diagnostics": {
+			err: func(t *testing.T) error {
+				st, err := apidiagsgrpc.ToStatus(apidiags.Diagnostics{{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeOverflow,
+					Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name"))},
+				}})
+				if err != nil {
+					t.Fatalf("unexpected error creating status: %s", err)
+				}
+				return st.Err()
+			},
+			status: http.StatusUnprocessableEntity,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeOverflow,
+				Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name"))},
+			}},
+		},
+		"plain-status": {
+			err: func(*testing.T) error {
+				return status.Error(codes.NotFound, "No such widget.")
+			},
+			status: http.StatusNotFound,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeNotFound,
+				Summary:  "No such widget.",
+			}},
+		},
+		"http-status-error": {
+			err: func(*testing.T) error {
+				return &runtime.HTTPStatusError{HTTPStatus: http.StatusMethodNotAllowed, Err: errors.New("nope")}
+			},
+			status: http.StatusMethodNotAllowed,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidValue,
+			}},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/v1/widgets", nil)
+			ErrorHandler()(context.Background(), runtime.NewServeMux(), &runtime.JSONPb{}, w, r, tc.err(t))
+
+			if w.Code != tc.status {
+				t.Errorf("expected status %d, got %d", tc.status, w.Code)
+			}
+			diags, err := apidiags.ParseResponse(w.Result())
+			if err != nil {
+				t.Fatalf("unexpected error parsing response: %s", err)
+			}
+			if diff := cmp.Diff(tc.expected, diags); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+
+			st, err := StatusFromResponse(&http.Response{
+				StatusCode: w.Code,
+				Header:     w.Header(),
+				Body:       io.NopCloser(bytes.NewReader(w.Body.Bytes())),
+			})
+			if err != nil {
+				t.Fatalf("unexpected error converting response: %s", err)
+			}
+			if diff := cmp.Diff(tc.expected, apidiagsgrpc.FromStatus(st)); diff != "" {
+				t.Fatalf("unexpected round trip results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestRoutingErrorHandler(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/v1/gadgets", nil)
+	RoutingErrorHandler()(context.Background(), runtime.NewServeMux(), &runtime.JSONPb{}, w, r, http.StatusNotFound)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+	diags, err := apidiags.ParseResponse(w.Result())
+	if err != nil {
+		t.Fatalf("unexpected error parsing response: %s", err)
+	}
+	expected := apidiags.Diagnostics{{Severity: apidiags.DiagnosticError, Code: apidiags.CodeNotFound}}
+	if diff := cmp.Diff(expected, diags); diff != "" {
+		t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+	}
+}
+
+// serverTransportStream records the header metadata set on it.
+type serverTransportStream struct {
+	header metadata.MD
+}
+
+func (s *serverTransportStream) Method() string { return "/widgets.v1.Widgets/GetWidget" }
+
+func (s *serverTransportStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *serverTransportStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+
+func (s *serverTransportStream) SetTrailer(metadata.MD) error { return nil }
+
+func TestWarnings(t *testing.T) {
+	t.Parallel()
+
+	diags := apidiags.Diagnostics{
+		{Severity: apidiags.DiagnosticError, Code: apidiags.CodeNotFound},
+		{Severity: apidiags.DiagnosticWarning, Code: apidiags.CodeDeprecated, Paths: []apidiags.Steps{apidiags.URLParamPath("mode")}},
+	}
+	expected := diags[1:]
+
+	stream := &serverTransportStream{}
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+	err := SetWarnings(ctx, diags)
+	if err != nil {
+		t.Fatalf("unexpected error setting warnings: %s", err)
+	}
+	fromMetadata, err := WarningsFromMetadata(stream.header)
+	if err != nil {
+		t.Fatalf("unexpected error reading warnings: %s", err)
+	}
+	if diff := cmp.Diff(expected, fromMetadata); diff != "" {
+		t.Errorf("unexpected warnings from metadata (-wanted, +got): %s", diff)
+	}
+
+	w := httptest.NewRecorder()
+	ctx = runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{HeaderMD: stream.header})
+	err = ForwardWarnings(ctx, w, nil)
+	if err != nil {
+		t.Fatalf("unexpected error forwarding warnings: %s", err)
+	}
+	fromHeader, err := apidiags.WarningsFromHeader(w.Header())
+	if err != nil {
+		t.Fatalf("unexpected error reading warnings header: %s", err)
+	}
+	if diff := cmp.Diff(expected, fromHeader); diff != "" {
+		t.Errorf("unexpected warnings from header (-wanted, +got): %s", diff)
+	}
+}
@@ -0,0 +1,25 @@
+module impractical.co/apidiags/apidiagsgateway
+
+go 1.26.0
+
+require (
+	github.com/google/go-cmp v0.7.0
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.31.0
+	google.golang.org/grpc v1.84.0
+	google.golang.org/protobuf v1.36.12
+	impractical.co/apidiags v0.0.0
+	impractical.co/apidiags/apidiagsgrpc v0.0.0
+)
+
+require (
+	golang.org/x/net v0.59.0 // indirect
+	golang.org/x/sys v0.48.0 // indirect
+	golang.org/x/text v0.42.0 // indirect
+	google.golang.org/genproto/googleapis/api v0.0.0-20260921155816-b14227669459 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260921155816-b14227669459 // indirect
+)
+
+replace (
+	impractical.co/apidiags => ../
+	impractical.co/apidiags/apidiagsgrpc => ../apidiagsgrpc
+)
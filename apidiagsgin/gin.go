@@ -0,0 +1,162 @@
+// Package apidiagsgin integrates apidiags with the gin web framework, so gin
+// handlers can report Diagnostics through c.Error, and gin's binding errors
+// are described by Diagnostics pointing to the offending fields.
+package apidiagsgin
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"impractical.co/apidiags"
+	"impractical.co/apidiags/apidiagsvalidator"
+)
+
+// diagnosticsError is an error carrying Diagnostics, so they can be added
+// to a gin.Context's errors.
+type diagnosticsError apidiags.Diagnostics
+
+func (e diagnosticsError) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, diag := range e {
+		messages = append(messages, diag.Message())
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e diagnosticsError) Diagnostics() apidiags.Diagnostics {
+	return apidiags.Diagnostics(e)
+}
+
+// Error adds diags to the errors of c, like c.Error, so Middleware includes
+// them in its response. The returned *gin.Error has a type of
+// gin.ErrorTypePublic.
+func Error(c *gin.Context, diags apidiags.Diagnostics) *gin.Error {
+	return c.Error(diagnosticsError(diags)).SetType(gin.ErrorTypePublic)
+}
+
+// Middleware returns a gin.HandlerFunc that, once the rest of the handlers
+// have run, writes every error added to the gin.Context as a single
+// response using apidiags.WriteHTTP. Each error is converted into
+// Diagnostics using FromError, so errors added by Error, errors
+// implementing apidiags.DiagnosticsError, and binding errors are all
+// described precisely.
+//
+// If a handler has already written a response body, the errors are left
+// alone. gin's c.Bind methods write the response status as soon as binding
+// fails, before Middleware can set the response's Content-Type; use the
+// c.ShouldBind methods and pass their errors to c.Error instead.
+func Middleware(opts ...apidiags.HTTPOption) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) < 1 || c.Writer.Size() > 0 {
+			return
+		}
+		var diags apidiags.Diagnostics
+		for _, ginErr := range c.Errors {
+			diags = append(diags, FromError(ginErr.Err)...)
+		}
+		writeOpts := opts
+		if c.Writer.Written() {
+			writeOpts = append(append([]apidiags.HTTPOption{}, opts...), apidiags.WithStatus(c.Writer.Status()))
+		}
+		_ = apidiags.WriteHTTP(c.Writer, diags, writeOpts...)
+	}
+}
+
+// FromError converts an error returned while binding a request body, like
+// by c.ShouldBindJSON, into Diagnostics pointing into the body.
+//
+// validator.ValidationErrors are converted using
+// apidiagsvalidator.FromValidationErrors, becoming one Diagnostic per failed
+// field, with a Code based on the validation tag that failed and extension
+// members describing the rule and its bounds. JSON syntax errors point
+// to the byte offset of the error, using apidiags.StringIndexStep, and JSON
+// type errors point to the field with the wrong type. An empty body is
+// described with apidiags.CodeMissing.
+//
+// Any other error is converted using apidiags.FromError.
+func FromError(err error) apidiags.Diagnostics {
+	return fromError(err, func(field string) apidiags.Steps {
+		return apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep(field))
+	})
+}
+
+// FromURLError converts an error returned while binding a request's query
+// or URL parameters, like by c.ShouldBindQuery or c.ShouldBindUri, into
+// Diagnostics pointing to the parameters, in the same way as FromError.
+func FromURLError(err error) apidiags.Diagnostics {
+	return fromError(err, apidiags.URLParamPath)
+}
+
+func fromError(err error, root func(field string) apidiags.Steps) apidiags.Diagnostics {
+	var validationErrs validator.ValidationErrors
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &validationErrs):
+		return apidiagsvalidator.FromValidationErrors(validationErrs, apidiagsvalidator.WithRoot(root))
+	case errors.As(err, &syntaxErr):
+		return apidiags.Diagnostics{{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeInvalidFormat,
+			Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.StringIndexStep(syntaxErr.Offset))},
+			Detail:   syntaxErr.Error(),
+		}}
+	case errors.As(err, &typeErr):
+		diag := apidiags.Diagnostic{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeInvalidFormat,
+			Paths:    []apidiags.Steps{apidiags.BodyPath()},
+			Detail:   "Expected " + typeErr.Type.String() + ", got " + typeErr.Value + ".",
+		}
+		if typeErr.Field != "" {
+			path := apidiags.BodyPath()
+			for _, field := range strings.Split(typeErr.Field, ".") {
+				path = path.AddStep(apidiags.ObjectPropertyStep(field))
+			}
+			diag.Paths = []apidiags.Steps{path}
+		}
+		return apidiags.Diagnostics{diag}
+	case errors.Is(err, io.EOF):
+		return apidiags.Diagnostics{{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeMissing,
+			Paths:    []apidiags.Steps{apidiags.BodyPath()},
+		}}
+	}
+	return apidiags.FromError(err)
+}
+
+// UseFieldNames configures gin's default validator to name fields in its
+// errors using their `json` struct tags, or their `form` or `uri` tags if
+// they have no `json` tag, so the Paths of the Diagnostics returned by
+// FromError and FromURLError match the names clients use. Without it,
+// fields are named using their Go names.
+//
+// UseFieldNames modifies the validator shared by every gin.Engine, and
+// should be called once, before any requests are handled.
+func UseFieldNames() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form", "uri"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+}
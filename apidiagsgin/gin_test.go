@@ -0,0 +1,198 @@
+package apidiagsgin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-cmp/cmp"
+
+	"impractical.co/apidiags"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	UseFieldNames()
+	os.Exit(m.Run())
+}
+
+type widget struct {
+	Name   string            `json:"name" binding:"required"`
+	Count  int               `json:"count" binding:"min=1,max=10"`
+	Tags   []string          `json:"tags" binding:"dive,alphanum"`
+	Labels map[string]string `json:"labels" binding:"dive,keys,required,endkeys,max=5"`
+}
+
+type widgetQuery struct {
+	Mode string `form:"mode" binding:"oneof=fast slow"`
+}
+
+func newEngine() *gin.Engine {
+	engine := gin.New()
+	engine.Use(Middleware())
+	engine.POST("/widgets", func(c *gin.Context) {
+		var body widget
+		if err := c.ShouldBindJSON(&body); err != nil {
+			_ = c.Error(err)
+			return
+		}
+		c.Status(http.StatusCreated)
+	})
+	engine.GET("/widgets", func(c *gin.Context) {
+		var query widgetQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			Error(c, FromURLError(err))
+			return
+		}
+		Error(c, apidiags.Diagnostics{{Severity: apidiags.DiagnosticError, Code: apidiags.CodeAccessDenied}})
+		Error(c, apidiags.Diagnostics{{Severity: apidiags.DiagnosticWarning, Code: apidiags.CodeDeprecated}})
+	})
+	return engine
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		method   string
+		target   string
+		body     string
+		status   int
+		expected apidiags.Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"valid": {
+			method: http.MethodPost,
+			target: "/widgets",
+			body:   `{"name":"foo","count":3,"tags":["a1"],"labels":{"app":"web"}}`,
+			status: http.StatusCreated,
+		},
+		"validation": {
+			method: http.MethodPost,
+			target: "/widgets",
+			body:   `{"count":30,"tags":["ok","not ok"],"labels":{"app":"website"}}`,
+			status: http.StatusUnprocessableEntity,
+			expected: apidiags.Diagnostics{
+				{
+					Severity:   apidiags.DiagnosticError,
+					Code:       apidiags.CodeMissing,
+					Paths:      []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name"))},
+					Detail:     `The "required" validation rule failed.`,
+					Extensions: map[string]json.RawMessage{"rule": json.RawMessage(`"required"`)},
+				},
+				{
+					Severity:   apidiags.DiagnosticError,
+					Code:       apidiags.CodeOverflow,
+					Paths:      []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("count"))},
+					Detail:     `The "max=10" validation rule failed.`,
+					Extensions: map[string]json.RawMessage{"rule": json.RawMessage(`"max=10"`), "max": json.RawMessage(`10`)},
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeInvalidFormat,
+					Paths: []apidiags.Steps{apidiags.BodyPath().AddSteps(
+						apidiags.ObjectPropertyStep("tags"), apidiags.ArrayIndexStep(1),
+					)},
+					Detail:     `The "alphanum" validation rule failed.`,
+					Extensions: map[string]json.RawMessage{"rule": json.RawMessage(`"alphanum"`)},
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeOverflow,
+					Paths: []apidiags.Steps{apidiags.BodyPath().AddSteps(
+						apidiags.ObjectPropertyStep("labels"), apidiags.ObjectPropertyStep("app"),
+					)},
+					Detail:     `The "max=5" validation rule failed.`,
+					Extensions: map[string]json.RawMessage{"rule": json.RawMessage(`"max=5"`), "max": json.RawMessage(`5`)},
+				},
+			},
+		},
+		"syntax": {
+			method: http.MethodPost,
+			target: "/widgets",
+			body:   `{"name":}`,
+			status: http.StatusBadRequest,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidFormat,
+				Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.StringIndexStep(9))},
+				Detail:   "invalid character '}' looking for beginning of value",
+			}},
+		},
+		"type": {
+			method: http.MethodPost,
+			target: "/widgets",
+			body:   `{"name":"foo","count":"three"}`,
+			status: http.StatusBadRequest,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidFormat,
+				Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("count"))},
+				Detail:   "Expected int, got string.",
+			}},
+		},
+		"empty-body": {
+			method: http.MethodPost,
+			target: "/widgets",
+			status: http.StatusBadRequest,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeMissing,
+				Paths:    []apidiags.Steps{apidiags.BodyPath()},
+			}},
+		},
+		"query": {
+			method: http.MethodGet,
+			target: "/widgets?mode=medium",
+			status: http.StatusBadRequest,
+			expected: apidiags.Diagnostics{{
+				Severity:   apidiags.DiagnosticError,
+				Code:       apidiags.CodeInvalidValue,
+				Paths:      []apidiags.Steps{apidiags.URLParamPath("mode")},
+				Detail:     `The "oneof=fast slow" validation rule failed.`,
+				Extensions: map[string]json.RawMessage{"rule": json.RawMessage(`"oneof=fast slow"`), "allowed": json.RawMessage(`["fast","slow"]`)},
+			}},
+		},
+		"accumulated": {
+			method: http.MethodGet,
+			target: "/widgets?mode=fast",
+			status: http.StatusForbidden,
+			expected: apidiags.Diagnostics{
+				{Severity: apidiags.DiagnosticError, Code: apidiags.CodeAccessDenied},
+				{Severity: apidiags.DiagnosticWarning, Code: apidiags.CodeDeprecated},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			newEngine().ServeHTTP(w, r)
+
+			if w.Code != tc.status {
+				t.Errorf("expected status %d, got %d", tc.status, w.Code)
+			}
+			if tc.expected == nil {
+				return
+			}
+			diags, err := apidiags.ParseResponse(w.Result())
+			if err != nil {
+				t.Fatalf("unexpected error parsing response: %s", err)
+			}
+			if diff := cmp.Diff(tc.expected, diags); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
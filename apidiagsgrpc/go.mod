@@ -0,0 +1,15 @@
+module impractical.co/apidiags/apidiagsgrpc
+
+go 1.26.0
+
+require (
+	github.com/google/go-cmp v0.7.0
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260921155816-b14227669459
+	google.golang.org/grpc v1.84.0
+	google.golang.org/protobuf v1.36.12
+	impractical.co/apidiags v0.0.0
+)
+
+require golang.org/x/sys v0.47.0 // indirect
+
+replace impractical.co/apidiags => ../
@@ -0,0 +1,301 @@
+// Package apidiagsgrpc converts between apidiags Diagnostics and gRPC
+// statuses carrying google.rpc.BadRequest details, so gRPC and HTTP services
+// can emit equivalent errors.
+package apidiagsgrpc
+
+import (
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+
+	"impractical.co/apidiags"
+)
+
+// Code returns the gRPC status code that best represents code. Codes this
+// package doesn't know about map to codes.Unknown.
+func Code(code apidiags.Code) codes.Code {
+	switch code {
+	case apidiags.CodeAccessDenied:
+		return codes.PermissionDenied
+	case apidiags.CodeInsufficient, apidiags.CodeOverflow, apidiags.CodeInvalidValue,
+		apidiags.CodeInvalidFormat, apidiags.CodeMissing:
+		return codes.InvalidArgument
+	case apidiags.CodeNotFound:
+		return codes.NotFound
+	case apidiags.CodeConflict:
+		return codes.FailedPrecondition
+	case apidiags.CodeActOfGod:
+		return codes.Unavailable
+	case apidiags.CodeDeprecated:
+		return codes.OK
+	default:
+		return codes.Unknown
+	}
+}
+
+// FromCode returns the apidiags.Code that best represents a gRPC status code.
+// Status codes without an equivalent apidiags.Code are returned as their
+// names, like "Internal".
+func FromCode(code codes.Code) apidiags.Code {
+	switch code {
+	case codes.PermissionDenied, codes.Unauthenticated:
+		return apidiags.CodeAccessDenied
+	case codes.InvalidArgument:
+		return apidiags.CodeInvalidValue
+	case codes.OutOfRange:
+		return apidiags.CodeOverflow
+	case codes.NotFound:
+		return apidiags.CodeNotFound
+	case codes.AlreadyExists, codes.FailedPrecondition, codes.Aborted:
+		return apidiags.CodeConflict
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return apidiags.CodeActOfGod
+	default:
+		return apidiags.Code(code.String())
+	}
+}
+
+// CodeForDiagnostics returns the gRPC status code for a response containing
+// diags: the Code of the first Diagnostic with a Severity of
+// apidiags.DiagnosticError whose Code doesn't map to codes.OK, like
+// apidiags.CodeDeprecated does. If every error's Code maps to codes.OK,
+// codes.Unknown is returned, so errors are never reported as successes. If
+// there are no errors, codes.OK is returned.
+func CodeForDiagnostics(diags apidiags.Diagnostics) codes.Code {
+	_, code := primaryDiagnostic(diags)
+	return code
+}
+
+// primaryDiagnostic returns the position in diags of the Diagnostic that
+// determines the status code CodeForDiagnostics returns, along with that
+// code. The position is -1 if there are no errors in diags.
+func primaryDiagnostic(diags apidiags.Diagnostics) (int, codes.Code) {
+	primary := -1
+	for pos, diag := range diags {
+		if diag.Severity != apidiags.DiagnosticError {
+			continue
+		}
+		if code := Code(diag.Code); code != codes.OK {
+			return pos, code
+		}
+		if primary < 0 {
+			primary = pos
+		}
+	}
+	if primary < 0 {
+		return primary, codes.OK
+	}
+	return primary, codes.Unknown
+}
+
+// FieldPath renders steps as a gRPC field path, like `items[3].name`, using
+// apidiags.Steps.FieldPath.
+func FieldPath(steps apidiags.Steps) string {
+	return steps.FieldPath()
+}
+
+// StepsFromFieldPath parses a gRPC field path rendered by FieldPath back into
+// Steps, using apidiags.ParseFieldPath.
+func StepsFromFieldPath(field string) (apidiags.Steps, error) {
+	return apidiags.ParseFieldPath(field)
+}
+
+// Option configures how Diagnostics are converted into a gRPC status.
+type Option func(*config)
+
+type config struct {
+	domain  string
+	catalog *apidiags.Catalog
+	langs   []string
+}
+
+// WithErrorInfoDomain includes a google.rpc.ErrorInfo detail in the status,
+// describing the Diagnostic that determined the status code. Its reason is
+// the Diagnostic's Code in upper snake case, like "NOT_FOUND", and its
+// domain is domain, which should be the service's name, like
+// "widgets.example.com". If the Diagnostic has a Path, it's included in the
+// ErrorInfo's metadata under "field".
+func WithErrorInfoDomain(domain string) Option {
+	return func(c *config) {
+		c.domain = domain
+	}
+}
+
+// WithCatalog includes google.rpc.LocalizedMessage details in the status and
+// its field violations, holding the Diagnostics' messages translated by
+// catalog into the first of langs it can, using apidiags.Catalog.Translation.
+// The details' locale is the language the message was found in, as returned
+// by apidiags.Catalog.Languages. Diagnostics catalog has no message for in
+// any of langs get no LocalizedMessage, as their messages are in the API's
+// own language.
+func WithCatalog(catalog *apidiags.Catalog, langs ...string) Option {
+	return func(c *config) {
+		c.catalog = catalog
+		c.langs = langs
+	}
+}
+
+// ToStatus converts diags into a gRPC status. The status code is chosen by
+// CodeForDiagnostics, and the message is the Message of the Diagnostic that
+// determined it.
+//
+// Every Path of every Diagnostic with a Severity of apidiags.DiagnosticError
+// is included as a google.rpc.BadRequest.FieldViolation, with the Path
+// rendered by FieldPath as the field, the Code as the reason, and the
+// Diagnostic's Message as the description. Warnings have no equivalent in
+// gRPC, and are left out.
+//
+// Errors with a DocURL are included as links in a google.rpc.Help detail,
+// each described by the error's Code. See WithErrorInfoDomain and WithCatalog
+// for the other details that can be included.
+func ToStatus(diags apidiags.Diagnostics, opts ...Option) (*status.Status, error) {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	primaryPos, code := primaryDiagnostic(diags)
+	if code == codes.OK {
+		return status.New(codes.OK, ""), nil
+	}
+	primary := &diags[primaryPos]
+	badRequest := &errdetails.BadRequest{}
+	help := &errdetails.Help{}
+	docURLs := map[string]bool{}
+	for _, diag := range diags {
+		if diag.Severity != apidiags.DiagnosticError {
+			continue
+		}
+		for _, path := range diag.Paths {
+			violation := &errdetails.BadRequest_FieldViolation{
+				Field:       FieldPath(path),
+				Description: diag.Message(),
+				Reason:      string(diag.Code),
+			}
+			if msg, lang, ok := cfg.catalog.Translation(diag, cfg.langs...); ok {
+				violation.LocalizedMessage = &errdetails.LocalizedMessage{
+					Locale:  lang,
+					Message: msg,
+				}
+			}
+			badRequest.FieldViolations = append(badRequest.FieldViolations, violation)
+		}
+		if diag.DocURL != "" && !docURLs[diag.DocURL] {
+			docURLs[diag.DocURL] = true
+			help.Links = append(help.Links, &errdetails.Help_Link{
+				Description: string(diag.Code),
+				Url:         diag.DocURL,
+			})
+		}
+	}
+	var details []protoadapt.MessageV1
+	if cfg.domain != "" {
+		info := &errdetails.ErrorInfo{
+			Reason: strings.ToUpper(string(primary.Code)),
+			Domain: cfg.domain,
+		}
+		if len(primary.Paths) > 0 {
+			info.Metadata = map[string]string{"field": FieldPath(primary.Paths[0])}
+		}
+		details = append(details, info)
+	}
+	if len(badRequest.FieldViolations) > 0 {
+		details = append(details, badRequest)
+	}
+	if len(help.Links) > 0 {
+		details = append(details, help)
+	}
+	if msg, lang, ok := cfg.catalog.Translation(*primary, cfg.langs...); ok {
+		details = append(details, &errdetails.LocalizedMessage{
+			Locale:  lang,
+			Message: msg,
+		})
+	}
+	st := status.New(code, primary.Message())
+	if len(details) < 1 {
+		return st, nil
+	}
+	return st.WithDetails(details...)
+}
+
+// FromStatus converts the google.rpc.BadRequest details of a gRPC status into
+// Diagnostics, one per field violation, each with a Severity of
+// apidiags.DiagnosticError. Field violations without a reason use FromCode to
+// determine their Code. Field violations with a field that can't be parsed by
+// StepsFromFieldPath have no Path. Links in a google.rpc.Help detail are used
+// as the DocURL of the Diagnostics whose Code matches the link's
+// description.
+//
+// If st isn't OK and has no field violations, a single Diagnostic is returned.
+// Its Code comes from the reason of the status's google.rpc.ErrorInfo detail
+// if it has one, and from FromCode otherwise. Its Summary is the message of
+// the status's google.rpc.LocalizedMessage detail if it has one, and the
+// status message otherwise. Its Path comes from the ErrorInfo's "field"
+// metadata, if set.
+func FromStatus(st *status.Status) apidiags.Diagnostics {
+	if st == nil || st.Code() == codes.OK {
+		return nil
+	}
+	var results apidiags.Diagnostics
+	var info *errdetails.ErrorInfo
+	var localized *errdetails.LocalizedMessage
+	docURLs := map[apidiags.Code]string{}
+	for _, detail := range st.Details() {
+		switch detail := detail.(type) {
+		case *errdetails.ErrorInfo:
+			info = detail
+		case *errdetails.LocalizedMessage:
+			localized = detail
+		case *errdetails.Help:
+			for _, link := range detail.GetLinks() {
+				docURLs[apidiags.Code(link.GetDescription())] = link.GetUrl()
+			}
+		case *errdetails.BadRequest:
+			for _, violation := range detail.GetFieldViolations() {
+				diag := apidiags.Diagnostic{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.Code(violation.GetReason()),
+					Summary:  violation.GetDescription(),
+				}
+				if diag.Code == "" {
+					diag.Code = FromCode(st.Code())
+				}
+				if diag.Summary == string(diag.Code) {
+					diag.Summary = ""
+				}
+				if path, err := StepsFromFieldPath(violation.GetField()); err == nil {
+					diag.Paths = []apidiags.Steps{path}
+				}
+				results = append(results, diag)
+			}
+		}
+	}
+	if len(results) < 1 {
+		diag := apidiags.Diagnostic{
+			Severity: apidiags.DiagnosticError,
+			Code:     FromCode(st.Code()),
+			Summary:  st.Message(),
+		}
+		if info != nil && info.GetReason() != "" {
+			diag.Code = apidiags.Code(strings.ToLower(info.GetReason()))
+			if field, ok := info.GetMetadata()["field"]; ok {
+				if path, err := StepsFromFieldPath(field); err == nil {
+					diag.Paths = []apidiags.Steps{path}
+				}
+			}
+		}
+		if localized != nil && localized.GetMessage() != "" {
+			diag.Summary = localized.GetMessage()
+		}
+		if diag.Summary == string(diag.Code) {
+			diag.Summary = ""
+		}
+		results = append(results, diag)
+	}
+	for pos := range results {
+		results[pos].DocURL = docURLs[results[pos].Code]
+	}
+	return results
+}
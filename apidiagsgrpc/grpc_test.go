@@ -0,0 +1,341 @@
+package apidiagsgrpc
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"impractical.co/apidiags"
+)
+
+func TestCodeRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, code := range []apidiags.Code{
+		apidiags.CodeAccessDenied,
+		apidiags.CodeNotFound,
+		apidiags.CodeConflict,
+		apidiags.CodeActOfGod,
+	} {
+		code := code
+
+		t.Run(string(code), func(t *testing.T) {
+			t.Parallel()
+
+			if result := FromCode(Code(code)); result != code {
+				t.Fatalf("expected %q, got %q (via %s)", code, result, Code(code))
+			}
+		})
+	}
+}
+
+func TestFieldPathRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		steps    apidiags.Steps
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"body": {
+			steps:    apidiags.BodyPath(),
+			expected: "",
+		},
+		"body-prop-arrayIndex-prop": {
+			steps:    apidiags.BodyPath().AddSteps(apidiags.ObjectPropertyStep("items"), apidiags.ArrayIndexStep(3), apidiags.ObjectPropertyStep("name")),
+			expected: "items[3].name",
+		},
+		"body-arrayIndex": {
+			steps:    apidiags.BodyPath().AddStep(apidiags.ArrayIndexStep(0)),
+			expected: "[0]",
+		},
+		"header": {
+			steps:    apidiags.HeaderPath("X-Request-Id"),
+			expected: `header("X-Request-Id")`,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result := FieldPath(tc.steps)
+			if result != tc.expected {
+				t.Fatalf("expected %q, got %q", tc.expected, result)
+			}
+			parsed, err := StepsFromFieldPath(result)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(tc.steps, parsed); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestToStatus(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags      apidiags.Diagnostics
+		code       codes.Code
+		message    string
+		violations []*errdetails.BadRequest_FieldViolation
+	}
+
+	cases := map[string]testCase{
+		"no-diags": {
+			code: codes.OK,
+		},
+		"warnings-only": {
+			diags: apidiags.Diagnostics{
+				{Severity: apidiags.DiagnosticWarning, Code: apidiags.CodeDeprecated, Paths: []apidiags.Steps{apidiags.BodyPath()}},
+			},
+			code: codes.OK,
+		},
+		"field-violations": {
+			diags: apidiags.Diagnostics{
+				{Severity: apidiags.DiagnosticWarning, Code: apidiags.CodeDeprecated, Paths: []apidiags.Steps{apidiags.BodyPath()}},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Summary:  "Name is required.",
+					Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name"))},
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeConflict,
+					Paths: []apidiags.Steps{
+						apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("start")),
+						apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("end")),
+					},
+				},
+			},
+			code:    codes.InvalidArgument,
+			message: "Name is required.",
+			violations: []*errdetails.BadRequest_FieldViolation{
+				{Field: "name", Description: "Name is required.", Reason: "missing"},
+				{Field: "start", Description: "conflict", Reason: "conflict"},
+				{Field: "end", Description: "conflict", Reason: "conflict"},
+			},
+		},
+		"no-paths": {
+			diags: apidiags.Diagnostics{
+				{Severity: apidiags.DiagnosticError, Code: apidiags.CodeActOfGod},
+			},
+			code:    codes.Unavailable,
+			message: "act_of_god",
+		},
+		"skips-ok-errors": {
+			diags: apidiags.Diagnostics{
+				{Severity: apidiags.DiagnosticError, Code: apidiags.CodeDeprecated, Summary: "v1 is gone."},
+				{Severity: apidiags.DiagnosticError, Code: apidiags.CodeNotFound, Summary: "No such widget."},
+			},
+			code:    codes.NotFound,
+			message: "No such widget.",
+		},
+		"only-ok-errors": {
+			diags: apidiags.Diagnostics{
+				{Severity: apidiags.DiagnosticWarning, Code: apidiags.CodeNotFound},
+				{Severity: apidiags.DiagnosticError, Code: apidiags.CodeDeprecated, Summary: "v1 is gone."},
+			},
+			code:    codes.Unknown,
+			message: "v1 is gone.",
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			st, err := ToStatus(tc.diags)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if st.Code() != tc.code {
+				t.Errorf("expected code %s, got %s", tc.code, st.Code())
+			}
+			if st.Message() != tc.message {
+				t.Errorf("expected message %q, got %q", tc.message, st.Message())
+			}
+			var violations []*errdetails.BadRequest_FieldViolation
+			for _, detail := range st.Details() {
+				if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+					violations = append(violations, badRequest.GetFieldViolations()...)
+				}
+			}
+			if diff := cmp.Diff(tc.violations, violations, cmp.Comparer(proto.Equal)); diff != "" {
+				t.Errorf("unexpected field violations (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestFromStatus(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		status   *status.Status
+		expected apidiags.Diagnostics
+	}
+
+	withViolations, err := status.New(codes.InvalidArgument, "bad request").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "items[3].name", Description: "Name is required.", Reason: "missing"},
+			{Field: "email", Description: "Not an email address."},
+			{Field: "not a path", Description: "missing", Reason: "missing"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error building status: %s", err)
+	}
+
+	cases := map[string]testCase{
+		"nil": {},
+		"ok": {
+			status: status.New(codes.OK, ""),
+		},
+		"no-details": {
+			status: status.New(codes.NotFound, "no such widget"),
+			expected: apidiags.Diagnostics{
+				{Severity: apidiags.DiagnosticError, Code: apidiags.CodeNotFound, Summary: "no such widget"},
+			},
+		},
+		"field-violations": {
+			status: withViolations,
+			expected: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Summary:  "Name is required.",
+					Paths: []apidiags.Steps{apidiags.BodyPath().AddSteps(
+						apidiags.ObjectPropertyStep("items"),
+						apidiags.ArrayIndexStep(3),
+						apidiags.ObjectPropertyStep("name"),
+					)},
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeInvalidValue,
+					Summary:  "Not an email address.",
+					Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("email"))},
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result := FromStatus(tc.status)
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestToStatusDetails(t *testing.T) {
+	t.Parallel()
+
+	diags := apidiags.Diagnostics{
+		{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeNotFound,
+			Summary:  "No such widget.",
+			DocURL:   "https://example.com/errors/not_found",
+			Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("widget_id"))},
+		},
+		{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeMissing,
+			Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name"))},
+		},
+	}
+	var catalog apidiags.Catalog
+	catalog.Add("fr", map[apidiags.Code]string{apidiags.CodeNotFound: "{path} introuvable."})
+	st, err := ToStatus(diags, WithErrorInfoDomain("widgets.example.com"), WithCatalog(&catalog, "fr-CA", "en"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []any{
+		&errdetails.ErrorInfo{
+			Reason:   "NOT_FOUND",
+			Domain:   "widgets.example.com",
+			Metadata: map[string]string{"field": "widget_id"},
+		},
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+			Field:            "widget_id",
+			Description:      "No such widget.",
+			Reason:           "not_found",
+			LocalizedMessage: &errdetails.LocalizedMessage{Locale: "fr", Message: "widget_id introuvable."},
+		}, {
+			Field:       "name",
+			Description: "missing",
+			Reason:      "missing",
+		}}},
+		&errdetails.Help{Links: []*errdetails.Help_Link{{
+			Description: "not_found",
+			Url:         "https://example.com/errors/not_found",
+		}}},
+		&errdetails.LocalizedMessage{Locale: "fr", Message: "widget_id introuvable."},
+	}
+	if diff := cmp.Diff(expected, st.Details(), cmp.Comparer(proto.Equal)); diff != "" {
+		t.Errorf("unexpected details (-wanted, +got): %s", diff)
+	}
+
+	if diff := cmp.Diff(diags, FromStatus(st)); diff != "" {
+		t.Errorf("unexpected round trip results (-wanted, +got): %s", diff)
+	}
+}
+
+func TestFromStatusErrorInfo(t *testing.T) {
+	t.Parallel()
+
+	st, err := status.New(codes.FailedPrecondition, "precondition failed").WithDetails(
+		&errdetails.ErrorInfo{
+			Reason:   "QUOTA_EXCEEDED",
+			Domain:   "widgets.example.com",
+			Metadata: map[string]string{"field": "items[2]"},
+		},
+		&errdetails.LocalizedMessage{Locale: "fr-FR", Message: "Quota dépassé."},
+		&errdetails.Help{Links: []*errdetails.Help_Link{{
+			Description: "quota_exceeded",
+			Url:         "https://example.com/errors/quota",
+		}}},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error building status: %s", err)
+	}
+	expected := apidiags.Diagnostics{{
+		Severity: apidiags.DiagnosticError,
+		Code:     "quota_exceeded",
+		Summary:  "Quota dépassé.",
+		DocURL:   "https://example.com/errors/quota",
+		Paths: []apidiags.Steps{apidiags.BodyPath().AddSteps(
+			apidiags.ObjectPropertyStep("items"),
+			apidiags.ArrayIndexStep(2),
+		)},
+	}}
+	if diff := cmp.Diff(expected, FromStatus(st)); diff != "" {
+		t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+	}
+}
@@ -0,0 +1,182 @@
+// Package apidiagshcl converts between apidiags Diagnostics and
+// hcl.Diagnostics, so APIs that accept HCL configuration can report the
+// problems HCL finds with it as apidiags Diagnostics.
+//
+// apidiags has no notion of source ranges, so the start of a diagnostic's
+// subject is represented as an apidiags.StringIndexStep holding the byte
+// offset into the HCL source, appended to the Steps pointing to the source
+// within the request. The full subject, with the line, column, and byte
+// offset of its start and end, is kept in the RangeMember extension member.
+// Lines and columns are recomputed from the source when converting back.
+package apidiagshcl
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/apparentlymart/go-textseg/v15/textseg"
+	"github.com/hashicorp/hcl/v2"
+
+	"impractical.co/apidiags"
+)
+
+// RangeMember is the extension member FromDiagnostics records the Subject of
+// a diagnostic in, as an object with start and end members, each with the
+// line, column, and byte of a position in the HCL source:
+//
+//	{"start": {"line": 2, "column": 8, "byte": 14}, "end": {"line": 2, "column": 11, "byte": 17}}
+const RangeMember = "hcl_range"
+
+// sourceRange is the JSON encoding of an hcl.Range, without its Filename.
+type sourceRange struct {
+	Start sourcePos `json:"start"`
+	End   sourcePos `json:"end"`
+}
+
+// sourcePos is the JSON encoding of an hcl.Pos.
+type sourcePos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Byte   int `json:"byte"`
+}
+
+// Coder can be implemented by the Extra of an hcl.Diagnostic to choose the
+// Code of the apidiags.Diagnostic it's converted into.
+type Coder interface {
+	APIDiagsCode() apidiags.Code
+}
+
+type codeExtra apidiags.Code
+
+func (c codeExtra) APIDiagsCode() apidiags.Code {
+	return apidiags.Code(c)
+}
+
+// FromDiagnostics converts diags into apidiags.Diagnostics. root points to
+// the HCL source within the request, like apidiags.BodyPath() if the request
+// body is the HCL source.
+//
+// hcl.DiagWarning becomes apidiags.DiagnosticWarning, and every other
+// severity becomes apidiags.DiagnosticError. The Code comes from the
+// diagnostic's Extra if it implements Coder, and is apidiags.CodeInvalidValue
+// otherwise. Diagnostics with a Subject get a single Path, pointing to the
+// start of the Subject, and have the whole Subject recorded in RangeMember.
+func FromDiagnostics(root apidiags.Steps, diags hcl.Diagnostics) apidiags.Diagnostics {
+	if len(diags) < 1 {
+		return nil
+	}
+	results := make(apidiags.Diagnostics, 0, len(diags))
+	for _, diag := range diags {
+		result := apidiags.Diagnostic{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeInvalidValue,
+			Summary:  diag.Summary,
+			Detail:   diag.Detail,
+		}
+		if diag.Severity == hcl.DiagWarning {
+			result.Severity = apidiags.DiagnosticWarning
+		}
+		if coder, ok := diag.Extra.(Coder); ok {
+			result.Code = coder.APIDiagsCode()
+		}
+		if diag.Subject != nil {
+			path := make(apidiags.Steps, 0, len(root)+1)
+			path = append(path, root...)
+			result.Paths = []apidiags.Steps{path.AddStep(apidiags.StringIndexStep(diag.Subject.Start.Byte))}
+			encoded, err := json.Marshal(sourceRange{
+				Start: sourcePos{Line: diag.Subject.Start.Line, Column: diag.Subject.Start.Column, Byte: diag.Subject.Start.Byte},
+				End:   sourcePos{Line: diag.Subject.End.Line, Column: diag.Subject.End.Column, Byte: diag.Subject.End.Byte},
+			})
+			if err == nil {
+				result.Extensions = map[string]json.RawMessage{RangeMember: encoded}
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// ToDiagnostics converts diags into hcl.Diagnostics, reversing
+// FromDiagnostics. root points to the HCL source within the request, and
+// filename and src are the name and contents of that source, used to
+// compute the Subject of each diagnostic.
+//
+// The first Path of each Diagnostic made up of root followed by a single
+// apidiags.StringIndexStep within src becomes the start of its Subject. The
+// Subject ends where the Diagnostic's RangeMember says it does, if it has
+// one starting at the same byte and ending within src; otherwise, it's
+// zero-length. The Diagnostic's Code is kept in the diagnostic's Extra,
+// which implements Coder.
+func ToDiagnostics(diags apidiags.Diagnostics, root apidiags.Steps, filename string, src []byte) hcl.Diagnostics {
+	if len(diags) < 1 {
+		return nil
+	}
+	results := make(hcl.Diagnostics, 0, len(diags))
+	for _, diag := range diags {
+		diag = diag.RenderMessage(nil)
+		result := &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  diag.Message(),
+			Detail:   diag.Detail,
+			Extra:    codeExtra(diag.Code),
+		}
+		if diag.Severity == apidiags.DiagnosticWarning {
+			result.Severity = hcl.DiagWarning
+		}
+		for _, path := range diag.Paths {
+			offset, ok := sourceOffset(root, path)
+			if !ok || offset > len(src) {
+				continue
+			}
+			start := position(src, offset)
+			end := start
+			if rng, ok := subjectRange(diag); ok && rng.Start.Byte == offset && rng.End.Byte >= offset && rng.End.Byte <= len(src) {
+				end = position(src, rng.End.Byte)
+			}
+			result.Subject = &hcl.Range{Filename: filename, Start: start, End: end}
+			break
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// subjectRange returns the range recorded in diag's RangeMember, if it has
+// one.
+func subjectRange(diag apidiags.Diagnostic) (sourceRange, bool) {
+	encoded, ok := diag.Extensions[RangeMember]
+	if !ok {
+		return sourceRange{}, false
+	}
+	var rng sourceRange
+	if err := json.Unmarshal(encoded, &rng); err != nil {
+		return sourceRange{}, false
+	}
+	return rng, true
+}
+
+// sourceOffset returns the byte offset path points to, if it's made up of
+// root followed by a single StringIndexStep.
+func sourceOffset(root, path apidiags.Steps) (int, bool) {
+	if len(path) != len(root)+1 || !path[:len(root)].Equal(root) {
+		return 0, false
+	}
+	idx, ok := path[len(root)].(apidiags.StringIndexStep)
+	if !ok || idx < 0 {
+		return 0, false
+	}
+	return int(idx), true
+}
+
+// position returns the hcl.Pos of the byte at offset in src. Like the HCL
+// parser, it counts lines from 1, and columns from 1 in grapheme clusters.
+func position(src []byte, offset int) hcl.Pos {
+	before := src[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+	column, err := textseg.TokenCount(before[lineStart:], textseg.ScanGraphemeClusters)
+	if err != nil {
+		column = len(before) - lineStart
+	}
+	return hcl.Pos{Line: line, Column: column + 1, Byte: offset}
+}
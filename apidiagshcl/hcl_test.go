@@ -0,0 +1,157 @@
+package apidiagshcl
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+
+	"impractical.co/apidiags"
+)
+
+func TestFromDiagnosticsParseError(t *testing.T) {
+	t.Parallel()
+
+	src := []byte("name = \"widget\"\nsize = \n")
+	_, diags := hclsyntax.ParseConfig(src, "widget.hcl", hcl.InitialPos)
+	if !diags.HasErrors() {
+		t.Fatal("expected parse errors")
+	}
+
+	root := apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("config"))
+	results := FromDiagnostics(root, diags)
+	if len(results) != len(diags) {
+		t.Fatalf("expected %d diagnostics, got %d", len(diags), len(results))
+	}
+	expected := apidiags.Diagnostic{
+		Severity: apidiags.DiagnosticError,
+		Code:     apidiags.CodeInvalidValue,
+		Summary:  diags[0].Summary,
+		Detail:   diags[0].Detail,
+		Paths:    []apidiags.Steps{root.AddStep(apidiags.StringIndexStep(diags[0].Subject.Start.Byte))},
+		Extensions: map[string]json.RawMessage{
+			RangeMember: mustMarshal(t, sourceRange{
+				Start: sourcePos{Line: diags[0].Subject.Start.Line, Column: diags[0].Subject.Start.Column, Byte: diags[0].Subject.Start.Byte},
+				End:   sourcePos{Line: diags[0].Subject.End.Line, Column: diags[0].Subject.End.Column, Byte: diags[0].Subject.End.Byte},
+			}),
+		},
+	}
+	if diff := cmp.Diff(expected, results[0]); diff != "" {
+		t.Errorf("unexpected result (-wanted, +got): %s", diff)
+	}
+
+	// converting back should recover the range HCL reported
+	back := ToDiagnostics(results, root, "widget.hcl", src)
+	if diff := cmp.Diff(*diags[0].Subject, *back[0].Subject); diff != "" {
+		t.Errorf("unexpected subject (-wanted, +got): %s", diff)
+	}
+}
+
+func mustMarshal(t *testing.T, v any) json.RawMessage {
+	t.Helper()
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return encoded
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	src := []byte("a = 1\nnäme = 2\n")
+	diags := apidiags.Diagnostics{
+		{
+			Severity: apidiags.DiagnosticWarning,
+			Code:     apidiags.CodeDeprecated,
+			Summary:  "Deprecated attribute",
+			Detail:   "Use b instead.",
+			Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.StringIndexStep(0))},
+		},
+		{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeMissing,
+			Summary:  "Missing value",
+			Paths: []apidiags.Steps{
+				apidiags.HeaderPath("X-Config"),
+				apidiags.BodyPath().AddStep(apidiags.StringIndexStep(14)),
+			},
+			Extensions: map[string]json.RawMessage{
+				RangeMember: json.RawMessage(`{"start":{"line":2,"column":8,"byte":14},"end":{"line":2,"column":9,"byte":15}}`),
+			},
+		},
+		{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeInvalidValue,
+			Summary:  "Range out of bounds",
+			Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.StringIndexStep(4))},
+			Extensions: map[string]json.RawMessage{
+				RangeMember: json.RawMessage(`{"start":{"line":1,"column":5,"byte":4},"end":{"line":3,"column":1,"byte":99}}`),
+			},
+		},
+		{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeActOfGod,
+		},
+	}
+
+	hclDiags := ToDiagnostics(diags, apidiags.BodyPath(), "config.hcl", src)
+	expected := hcl.Diagnostics{
+		{
+			Severity: hcl.DiagWarning,
+			Summary:  "Deprecated attribute",
+			Detail:   "Use b instead.",
+			Subject: &hcl.Range{
+				Filename: "config.hcl",
+				Start:    hcl.Pos{Line: 1, Column: 1, Byte: 0},
+				End:      hcl.Pos{Line: 1, Column: 1, Byte: 0},
+			},
+			Extra: codeExtra(apidiags.CodeDeprecated),
+		},
+		{
+			Severity: hcl.DiagError,
+			Summary:  "Missing value",
+			Subject: &hcl.Range{
+				Filename: "config.hcl",
+				Start:    hcl.Pos{Line: 2, Column: 8, Byte: 14},
+				End:      hcl.Pos{Line: 2, Column: 9, Byte: 15},
+			},
+			Extra: codeExtra(apidiags.CodeMissing),
+		},
+		{
+			Severity: hcl.DiagError,
+			Summary:  "Range out of bounds",
+			Subject: &hcl.Range{
+				Filename: "config.hcl",
+				Start:    hcl.Pos{Line: 1, Column: 5, Byte: 4},
+				End:      hcl.Pos{Line: 1, Column: 5, Byte: 4},
+			},
+			Extra: codeExtra(apidiags.CodeInvalidValue),
+		},
+		{
+			Severity: hcl.DiagError,
+			Summary:  "act_of_god",
+			Extra:    codeExtra(apidiags.CodeActOfGod),
+		},
+	}
+	if diff := cmp.Diff(expected, hclDiags); diff != "" {
+		t.Errorf("unexpected HCL diagnostics (-wanted, +got): %s", diff)
+	}
+
+	// only the Path HCL can represent survives the round trip, every
+	// Subject's range is recorded, and a Summary that was just the Code is
+	// kept
+	diags[0].Extensions = map[string]json.RawMessage{
+		RangeMember: json.RawMessage(`{"start":{"line":1,"column":1,"byte":0},"end":{"line":1,"column":1,"byte":0}}`),
+	}
+	diags[1].Paths = diags[1].Paths[1:]
+	diags[2].Extensions = map[string]json.RawMessage{
+		RangeMember: json.RawMessage(`{"start":{"line":1,"column":5,"byte":4},"end":{"line":1,"column":5,"byte":4}}`),
+	}
+	diags[3].Summary = "act_of_god"
+	if diff := cmp.Diff(diags, FromDiagnostics(apidiags.BodyPath(), hclDiags)); diff != "" {
+		t.Errorf("unexpected round trip results (-wanted, +got): %s", diff)
+	}
+}
@@ -0,0 +1,231 @@
+// Package apidiagsk8s converts between apidiags Diagnostics and Kubernetes
+// metav1.Status objects, so controllers and aggregated API servers can use
+// apidiags internally and still speak the Kubernetes API error dialect.
+package apidiagsk8s
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"impractical.co/apidiags"
+)
+
+// CauseType returns the metav1.CauseType that best represents code. Codes
+// without a Kubernetes equivalent are used as the CauseType as-is.
+func CauseType(code apidiags.Code) metav1.CauseType {
+	switch code {
+	case apidiags.CodeMissing:
+		return metav1.CauseTypeFieldValueRequired
+	case apidiags.CodeNotFound:
+		return metav1.CauseTypeFieldValueNotFound
+	case apidiags.CodeInvalidValue:
+		return metav1.CauseTypeFieldValueInvalid
+	case apidiags.CodeInvalidFormat:
+		return metav1.CauseTypeTypeInvalid
+	case apidiags.CodeOverflow:
+		return metav1.CauseTypeTooLong
+	case apidiags.CodeAccessDenied:
+		return metav1.CauseTypeForbidden
+	default:
+		return metav1.CauseType(code)
+	}
+}
+
+// FromCauseType returns the apidiags.Code that best represents a
+// metav1.CauseType. CauseTypes without an apidiags equivalent are used as the
+// Code as-is.
+func FromCauseType(causeType metav1.CauseType) apidiags.Code {
+	switch causeType {
+	case metav1.CauseTypeFieldValueRequired:
+		return apidiags.CodeMissing
+	case metav1.CauseTypeFieldValueNotFound:
+		return apidiags.CodeNotFound
+	case metav1.CauseTypeFieldValueInvalid, metav1.CauseTypeFieldValueNotSupported:
+		return apidiags.CodeInvalidValue
+	case metav1.CauseTypeTypeInvalid:
+		return apidiags.CodeInvalidFormat
+	case metav1.CauseTypeTooLong, metav1.CauseTypeTooMany:
+		return apidiags.CodeOverflow
+	case metav1.CauseTypeForbidden:
+		return apidiags.CodeAccessDenied
+	case metav1.CauseTypeFieldValueDuplicate:
+		return apidiags.CodeConflict
+	default:
+		return apidiags.Code(causeType)
+	}
+}
+
+// Reason returns the metav1.StatusReason and HTTP status code that best
+// represent a response failing because of code.
+func Reason(code apidiags.Code) (metav1.StatusReason, int32) {
+	switch code {
+	case apidiags.CodeAccessDenied:
+		return metav1.StatusReasonForbidden, http.StatusForbidden
+	case apidiags.CodeNotFound:
+		return metav1.StatusReasonNotFound, http.StatusNotFound
+	case apidiags.CodeConflict:
+		return metav1.StatusReasonConflict, http.StatusConflict
+	case apidiags.CodeActOfGod:
+		return metav1.StatusReasonServiceUnavailable, http.StatusServiceUnavailable
+	default:
+		return metav1.StatusReasonInvalid, http.StatusUnprocessableEntity
+	}
+}
+
+// ToStatus converts diags into a metav1.Status. If diags has no Diagnostics
+// with a Severity of apidiags.DiagnosticError, a successful Status is
+// returned. Otherwise, the first error determines the Status's reason, code,
+// and message.
+//
+// Every Path of every error is included as a metav1.StatusCause, with the
+// Path rendered by apidiags.Steps.FieldPath as the field. Errors without
+// Paths are included as a StatusCause without a field. Kubernetes surfaces
+// warnings through Warning headers rather than the Status, so they're left
+// out.
+//
+// Callers should fill in the Name, Group, and Kind of the Status's Details
+// to identify the object the Status is about.
+func ToStatus(diags apidiags.Diagnostics) metav1.Status {
+	result := metav1.Status{
+		TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   metav1.StatusSuccess,
+		Code:     http.StatusOK,
+	}
+	var causes []metav1.StatusCause
+	for _, diag := range diags {
+		if diag.Severity != apidiags.DiagnosticError {
+			continue
+		}
+		if result.Status != metav1.StatusFailure {
+			result.Status = metav1.StatusFailure
+			result.Reason, result.Code = Reason(diag.Code)
+			result.Message = diag.Message()
+		}
+		if len(diag.Paths) < 1 {
+			causes = append(causes, metav1.StatusCause{
+				Type:    CauseType(diag.Code),
+				Message: diag.Message(),
+			})
+		}
+		for _, path := range diag.Paths {
+			causes = append(causes, metav1.StatusCause{
+				Type:    CauseType(diag.Code),
+				Message: diag.Message(),
+				Field:   path.FieldPath(),
+			})
+		}
+	}
+	if len(causes) > 0 {
+		result.Details = &metav1.StatusDetails{Causes: causes}
+	}
+	return result
+}
+
+// FromStatus converts a metav1.Status into Diagnostics, one per cause, each
+// with a Severity of apidiags.DiagnosticError. A failed Status without causes
+// becomes a single Diagnostic, using the Status's reason as its Code. A
+// successful Status has no Diagnostics.
+//
+// Fields are parsed with apidiags.ParseFieldPath, falling back to the
+// Kubernetes convention of unquoted map keys in brackets, like
+// `metadata.labels[app.kubernetes.io/name]`. Causes with a field that can't
+// be parsed either way keep it in their Diagnostic's Detail, and have no
+// Path.
+func FromStatus(status metav1.Status) apidiags.Diagnostics {
+	if status.Status != metav1.StatusFailure {
+		return nil
+	}
+	var causes []metav1.StatusCause
+	if status.Details != nil {
+		causes = status.Details.Causes
+	}
+	if len(causes) < 1 {
+		return apidiags.Diagnostics{{
+			Severity: apidiags.DiagnosticError,
+			Code:     reasonCode(status.Reason),
+			Summary:  status.Message,
+		}}
+	}
+	results := make(apidiags.Diagnostics, 0, len(causes))
+	for _, cause := range causes {
+		diag := apidiags.Diagnostic{
+			Severity: apidiags.DiagnosticError,
+			Code:     FromCauseType(cause.Type),
+		}
+		if cause.Message != string(diag.Code) {
+			diag.Summary = cause.Message
+		}
+		if cause.Field != "" {
+			path, err := parseField(cause.Field)
+			if err != nil {
+				diag.Detail = "field: " + cause.Field
+			} else {
+				diag.Paths = []apidiags.Steps{path}
+			}
+		}
+		results = append(results, diag)
+	}
+	return results
+}
+
+// reasonCode returns the apidiags.Code that best represents a failed Status
+// with the given reason.
+func reasonCode(reason metav1.StatusReason) apidiags.Code {
+	switch reason {
+	case metav1.StatusReasonForbidden, metav1.StatusReasonUnauthorized:
+		return apidiags.CodeAccessDenied
+	case metav1.StatusReasonNotFound, metav1.StatusReasonGone:
+		return apidiags.CodeNotFound
+	case metav1.StatusReasonConflict, metav1.StatusReasonAlreadyExists:
+		return apidiags.CodeConflict
+	case metav1.StatusReasonServiceUnavailable, metav1.StatusReasonServerTimeout,
+		metav1.StatusReasonTimeout, metav1.StatusReasonTooManyRequests:
+		return apidiags.CodeActOfGod
+	case metav1.StatusReasonInvalid, metav1.StatusReasonBadRequest:
+		return apidiags.CodeInvalidValue
+	case metav1.StatusReasonRequestEntityTooLarge:
+		return apidiags.CodeOverflow
+	default:
+		return apidiags.Code(reason)
+	}
+}
+
+func parseField(field string) (apidiags.Steps, error) {
+	if steps, err := apidiags.ParseFieldPath(field); err == nil {
+		return steps, nil
+	}
+	results := apidiags.BodyPath()
+	for field != "" {
+		switch field[0] {
+		case '.':
+			field = field[1:]
+		case '[':
+			end := strings.IndexByte(field, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated %q", field)
+			}
+			key := field[1:end]
+			if idx, err := strconv.ParseInt(key, 10, 64); err == nil {
+				results = results.AddStep(apidiags.ArrayIndexStep(idx))
+			} else {
+				results = results.AddStep(apidiags.ObjectPropertyStep(key))
+			}
+			field = field[end+1:]
+			continue
+		}
+		end := strings.IndexAny(field, ".[")
+		if end < 0 {
+			end = len(field)
+		}
+		if end == 0 {
+			return nil, fmt.Errorf("empty field name before %q", field)
+		}
+		results = results.AddStep(apidiags.ObjectPropertyStep(field[:end]))
+		field = field[end:]
+	}
+	return results, nil
+}
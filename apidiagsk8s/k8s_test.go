@@ -0,0 +1,178 @@
+package apidiagsk8s
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"impractical.co/apidiags"
+)
+
+func TestToStatus(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags    apidiags.Diagnostics
+		expected metav1.Status
+	}
+
+	cases := map[string]testCase{
+		"success": {
+			diags: apidiags.Diagnostics{
+				{Severity: apidiags.DiagnosticWarning, Code: apidiags.CodeDeprecated},
+			},
+			expected: metav1.Status{
+				TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+				Status:   metav1.StatusSuccess,
+				Code:     http.StatusOK,
+			},
+		},
+		"invalid": {
+			diags: apidiags.Diagnostics{
+				{Severity: apidiags.DiagnosticWarning, Code: apidiags.CodeDeprecated},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Summary:  "Required value",
+					Paths: []apidiags.Steps{apidiags.BodyPath().AddSteps(
+						apidiags.ObjectPropertyStep("spec"),
+						apidiags.ObjectPropertyStep("containers"),
+						apidiags.ArrayIndexStep(0),
+						apidiags.ObjectPropertyStep("image"),
+					)},
+				},
+				{Severity: apidiags.DiagnosticError, Code: apidiags.CodeConflict},
+			},
+			expected: metav1.Status{
+				TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+				Status:   metav1.StatusFailure,
+				Message:  "Required value",
+				Reason:   metav1.StatusReasonInvalid,
+				Code:     http.StatusUnprocessableEntity,
+				Details: &metav1.StatusDetails{
+					Causes: []metav1.StatusCause{
+						{Type: metav1.CauseTypeFieldValueRequired, Message: "Required value", Field: "spec.containers[0].image"},
+						{Type: "conflict", Message: "conflict"},
+					},
+				},
+			},
+		},
+		"not-found": {
+			diags: apidiags.Diagnostics{
+				{Severity: apidiags.DiagnosticError, Code: apidiags.CodeNotFound},
+			},
+			expected: metav1.Status{
+				TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+				Status:   metav1.StatusFailure,
+				Message:  "not_found",
+				Reason:   metav1.StatusReasonNotFound,
+				Code:     http.StatusNotFound,
+				Details: &metav1.StatusDetails{
+					Causes: []metav1.StatusCause{
+						{Type: metav1.CauseTypeFieldValueNotFound, Message: "not_found"},
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result := ToStatus(tc.diags)
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestFromStatus(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		status   metav1.Status
+		expected apidiags.Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"success": {
+			status: metav1.Status{Status: metav1.StatusSuccess},
+		},
+		"no-causes": {
+			status: metav1.Status{
+				Status:  metav1.StatusFailure,
+				Reason:  metav1.StatusReasonAlreadyExists,
+				Message: `widgets "foo" already exists`,
+			},
+			expected: apidiags.Diagnostics{
+				{Severity: apidiags.DiagnosticError, Code: apidiags.CodeConflict, Summary: `widgets "foo" already exists`},
+			},
+		},
+		"causes": {
+			status: metav1.Status{
+				Status: metav1.StatusFailure,
+				Reason: metav1.StatusReasonInvalid,
+				Details: &metav1.StatusDetails{
+					Causes: []metav1.StatusCause{
+						{Type: metav1.CauseTypeFieldValueRequired, Message: "Required value", Field: "spec.containers[0].image"},
+						{Type: metav1.CauseTypeFieldValueInvalid, Message: "Invalid value", Field: "metadata.labels[app.kubernetes.io/name]"},
+						{Type: metav1.CauseTypeTooMany, Message: "Too many", Field: "spec..ports"},
+						{Type: "conflict", Message: "conflict"},
+					},
+				},
+			},
+			expected: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Summary:  "Required value",
+					Paths: []apidiags.Steps{apidiags.BodyPath().AddSteps(
+						apidiags.ObjectPropertyStep("spec"),
+						apidiags.ObjectPropertyStep("containers"),
+						apidiags.ArrayIndexStep(0),
+						apidiags.ObjectPropertyStep("image"),
+					)},
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeInvalidValue,
+					Summary:  "Invalid value",
+					Paths: []apidiags.Steps{apidiags.BodyPath().AddSteps(
+						apidiags.ObjectPropertyStep("metadata"),
+						apidiags.ObjectPropertyStep("labels"),
+						apidiags.ObjectPropertyStep("app.kubernetes.io/name"),
+					)},
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeOverflow,
+					Summary:  "Too many",
+					Detail:   "field: spec..ports",
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeConflict,
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result := FromStatus(tc.status)
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
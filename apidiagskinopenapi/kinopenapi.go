@@ -0,0 +1,277 @@
+// Package apidiagskinopenapi validates requests against an OpenAPI
+// specification using kin-openapi, describing everything wrong with them as
+// apidiags Diagnostics pointing to the offending parameters and fields.
+package apidiagskinopenapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+
+	"impractical.co/apidiags"
+)
+
+// ValidateRequest validates input's request using
+// openapi3filter.ValidateRequest, and converts any errors into Diagnostics
+// using FromError. Every problem with the request is reported, not just the
+// first, regardless of whether input's Options set MultiError.
+func ValidateRequest(ctx context.Context, input *openapi3filter.RequestValidationInput) apidiags.Diagnostics {
+	multi := *input
+	options := openapi3filter.Options{}
+	if input.Options != nil {
+		options = *input.Options
+	}
+	options.MultiError = true
+	multi.Options = &options
+	err := openapi3filter.ValidateRequest(ctx, &multi)
+	if err == nil {
+		return nil
+	}
+	return FromError(err)
+}
+
+// FromError converts an error returned by openapi3filter.ValidateRequest
+// into Diagnostics, each with a Severity of apidiags.DiagnosticError.
+//
+// Each openapi3filter.RequestError becomes one Diagnostic per problem it
+// describes. Problems with path and query parameters point to the
+// parameter using apidiags.URLParamPath, problems with headers use
+// apidiags.HeaderPath, problems with cookies point to the Cookie header,
+// and problems with the request body point into the body. When the problem
+// is an openapi3.SchemaError, its JSON Pointer is appended to the Path;
+// reference tokens made up of digits are assumed to be array indexes. An
+// unsupported Content-Type points to the Content-Type header.
+//
+// Codes are chosen by Code, based on the schema keyword that failed, or are
+// apidiags.CodeMissing for missing required values, and
+// apidiags.CodeInvalidFormat for values that couldn't be parsed.
+// openapi3filter.SecurityRequirementsError is apidiags.CodeAccessDenied,
+// routers.ErrPathNotFound is apidiags.CodeNotFound, and any other error is
+// converted using apidiags.FromError.
+func FromError(err error) apidiags.Diagnostics {
+	// openapi3.MultiError matches errors.As whenever any of its errors
+	// do, so it has to be checked for directly, before anything else
+	if multi, ok := err.(openapi3.MultiError); ok {
+		var results apidiags.Diagnostics
+		for _, err := range multi {
+			results = append(results, FromError(err)...)
+		}
+		return results
+	}
+	var requestErr *openapi3filter.RequestError
+	var securityErr *openapi3filter.SecurityRequirementsError
+	switch {
+	case errors.As(err, &requestErr):
+		return fromRequestError(requestErr)
+	case errors.As(err, &securityErr):
+		return apidiags.Diagnostics{{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeAccessDenied,
+		}}
+	case isRouteError(err, routers.ErrPathNotFound):
+		return apidiags.Diagnostics{{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeNotFound,
+		}}
+	}
+	return apidiags.FromError(err)
+}
+
+// isRouteError returns true if err is target, or a routers.RouteError with
+// the same reason, as the routers create new RouteErrors rather than
+// returning routers.ErrPathNotFound and routers.ErrMethodNotAllowed.
+func isRouteError(err, target error) bool {
+	var routeErr *routers.RouteError
+	if errors.As(err, &routeErr) {
+		return routeErr.Reason == target.Error()
+	}
+	return errors.Is(err, target)
+}
+
+func fromRequestError(err *openapi3filter.RequestError) apidiags.Diagnostics {
+	var root apidiags.Steps
+	switch {
+	case err.Parameter != nil:
+		root = parameterPath(err.Parameter)
+	case err.RequestBody != nil && err.Err == nil && strings.Contains(err.Reason, "Content-Type"):
+		return apidiags.Diagnostics{{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeInvalidValue,
+			Paths:    []apidiags.Steps{apidiags.HeaderPath("Content-Type")},
+			Detail:   err.Reason,
+		}}
+	default:
+		root = apidiags.BodyPath()
+	}
+	var schemaErrs []*openapi3.SchemaError
+	collectSchemaErrors(err.Err, &schemaErrs)
+	if len(schemaErrs) > 0 {
+		results := make(apidiags.Diagnostics, 0, len(schemaErrs))
+		for _, schemaErr := range schemaErrs {
+			detail := schemaErr.Reason
+			if detail == "" {
+				detail = `Doesn't match schema "` + schemaErr.SchemaField + `".`
+			}
+			results = append(results, apidiags.Diagnostic{
+				Severity: apidiags.DiagnosticError,
+				Code:     Code(schemaErr.SchemaField),
+				Paths:    []apidiags.Steps{pointerPath(root, schemaErr.JSONPointer())},
+				Detail:   detail,
+			})
+		}
+		return results
+	}
+	diag := apidiags.Diagnostic{
+		Severity: apidiags.DiagnosticError,
+		Code:     apidiags.CodeInvalidValue,
+		Paths:    []apidiags.Steps{root},
+		Detail:   err.Reason,
+	}
+	var parseErr *openapi3filter.ParseError
+	switch {
+	case errors.Is(err.Err, openapi3filter.ErrInvalidRequired):
+		diag.Code = apidiags.CodeMissing
+		diag.Detail = ""
+	case errors.As(err.Err, &parseErr):
+		diag.Code = apidiags.CodeInvalidFormat
+		diag.Detail = parseErr.Error()
+	case err.Err != nil && (diag.Detail == "" || diag.Detail == err.Err.Error()):
+		diag.Detail = err.Err.Error()
+	case err.Err != nil:
+		diag.Detail += ": " + err.Err.Error()
+	}
+	return apidiags.Diagnostics{diag}
+}
+
+// collectSchemaErrors appends every openapi3.SchemaError in err to results,
+// flattening openapi3.MultiErrors.
+func collectSchemaErrors(err error, results *[]*openapi3.SchemaError) {
+	var schemaErr *openapi3.SchemaError
+	if multi, ok := err.(openapi3.MultiError); ok {
+		for _, err := range multi {
+			collectSchemaErrors(err, results)
+		}
+		return
+	}
+	if errors.As(err, &schemaErr) {
+		*results = append(*results, schemaErr)
+	}
+}
+
+// Code returns the apidiags.Code describing a value that failed the
+// schema keyword field, like "maxLength".
+func Code(field string) apidiags.Code {
+	switch field {
+	case "required":
+		return apidiags.CodeMissing
+	case "minimum", "exclusiveMinimum", "minLength", "minItems", "minProperties":
+		return apidiags.CodeInsufficient
+	case "maximum", "exclusiveMaximum", "maxLength", "maxItems", "maxProperties":
+		return apidiags.CodeOverflow
+	case "type", "format", "pattern":
+		return apidiags.CodeInvalidFormat
+	case "uniqueItems":
+		return apidiags.CodeConflict
+	default:
+		return apidiags.CodeInvalidValue
+	}
+}
+
+// parameterPath returns Steps pointing to param.
+func parameterPath(param *openapi3.Parameter) apidiags.Steps {
+	switch param.In {
+	case openapi3.ParameterInHeader:
+		return apidiags.HeaderPath(param.Name)
+	case openapi3.ParameterInCookie:
+		return apidiags.HeaderPath("Cookie")
+	default:
+		return apidiags.URLParamPath(param.Name)
+	}
+}
+
+// pointerPath appends the reference tokens of a JSON Pointer to root.
+func pointerPath(root apidiags.Steps, tokens []string) apidiags.Steps {
+	results := root
+	for _, token := range tokens {
+		if idx, err := strconv.ParseInt(token, 10, 64); err == nil && idx >= 0 && !strings.HasPrefix(token, "+") {
+			results = results.AddStep(apidiags.ArrayIndexStep(idx))
+			continue
+		}
+		results = results.AddStep(apidiags.ObjectPropertyStep(token))
+	}
+	return results
+}
+
+// Option configures Middleware.
+type Option func(*config)
+
+type config struct {
+	options  *openapi3filter.Options
+	httpOpts []apidiags.HTTPOption
+}
+
+// WithFilterOptions sets the openapi3filter.Options requests are validated
+// with, like the AuthenticationFunc used to check security requirements.
+func WithFilterOptions(options *openapi3filter.Options) Option {
+	return func(c *config) {
+		c.options = options
+	}
+}
+
+// WithHTTPOptions sets the apidiags.HTTPOptions used when writing the
+// Diagnostics of requests that fail validation.
+func WithHTTPOptions(opts ...apidiags.HTTPOption) Option {
+	return func(c *config) {
+		c.httpOpts = append(c.httpOpts, opts...)
+	}
+}
+
+// Middleware returns middleware that finds the operation each request is
+// for using router, validates the request with ValidateRequest, and
+// responds with the Diagnostics using apidiags.WriteHTTP if it's invalid.
+// Valid requests are passed on to the next http.Handler, with their bodies
+// intact.
+//
+// Requests for paths the specification doesn't describe are answered with
+// apidiags.CodeNotFound, and requests using methods it doesn't describe are
+// answered with http.StatusMethodNotAllowed.
+func Middleware(router routers.Router, opts ...Option) func(http.Handler) http.Handler {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, pathParams, err := router.FindRoute(r)
+			if isRouteError(err, routers.ErrMethodNotAllowed) {
+				_ = apidiags.WriteHTTP(w, apidiags.Diagnostics{{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeInvalidValue,
+					Summary:  "The " + r.Method + " method isn't supported.",
+				}}, append(append([]apidiags.HTTPOption{}, cfg.httpOpts...), apidiags.WithStatus(http.StatusMethodNotAllowed))...)
+				return
+			}
+			if err != nil {
+				_ = apidiags.WriteHTTP(w, FromError(err), cfg.httpOpts...)
+				return
+			}
+			diags := ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    cfg.options,
+			})
+			if diags.HasErrors() {
+				_ = apidiags.WriteHTTP(w, diags, cfg.httpOpts...)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package apidiagskinopenapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/google/go-cmp/cmp"
+
+	"impractical.co/apidiags"
+)
+
+const spec = `{
+	"openapi": "3.0.3",
+	"info": {"title": "Widgets", "version": "1.0.0"},
+	"paths": {
+		"/widgets/{id}": {
+			"put": {
+				"parameters": [
+					{"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}},
+					{"name": "mode", "in": "query", "schema": {"type": "string", "enum": ["fast", "slow"]}},
+					{"name": "X-Tenant", "in": "header", "required": true, "schema": {"type": "string"}}
+				],
+				"requestBody": {
+					"required": true,
+					"content": {
+						"application/json": {
+							"schema": {
+								"type": "object",
+								"required": ["name"],
+								"properties": {
+									"name": {"type": "string", "maxLength": 5},
+									"tags": {"type": "array", "items": {"type": "string", "minLength": 2}}
+								}
+							}
+						}
+					}
+				},
+				"responses": {"204": {"description": "Updated."}}
+			}
+		}
+	}
+}`
+
+func newRouter(t *testing.T) routers.Router {
+	t.Helper()
+
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(spec))
+	if err != nil {
+		t.Fatalf("unexpected error loading spec: %s", err)
+	}
+	err = doc.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error validating spec: %s", err)
+	}
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		t.Fatalf("unexpected error creating router: %s", err)
+	}
+	return router
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		method         string
+		target         string
+		tenant         string
+		contentType    string
+		body           string
+		expectedStatus int
+		expected       apidiags.Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"valid": {
+			method:         http.MethodPut,
+			target:         "/widgets/123?mode=fast",
+			tenant:         "acme",
+			body:           `{"name": "foo", "tags": ["ab"]}`,
+			expectedStatus: http.StatusNoContent,
+		},
+		"parameters": {
+			method:         http.MethodPut,
+			target:         "/widgets/abc?mode=medium",
+			body:           `{"name": "foo"}`,
+			expectedStatus: http.StatusBadRequest,
+			expected: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeInvalidFormat,
+					Paths:    []apidiags.Steps{apidiags.URLParamPath("id")},
+					Detail:   `value abc: an invalid integer: invalid syntax`,
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeInvalidValue,
+					Paths:    []apidiags.Steps{apidiags.URLParamPath("mode")},
+					Detail:   `value is not one of the allowed values ["fast","slow"]`,
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Paths:    []apidiags.Steps{apidiags.HeaderPath("X-Tenant")},
+				},
+			},
+		},
+		"body": {
+			method:         http.MethodPut,
+			target:         "/widgets/123",
+			tenant:         "acme",
+			body:           `{"name": "foobar", "tags": ["ab", "c"]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expected: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeOverflow,
+					Paths:    []apidiags.Steps{apidiags.PathOf(apidiags.BodyStep{}, apidiags.ObjectPropertyStep("name"))},
+					Detail:   "maximum string length is 5",
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeInsufficient,
+					Paths:    []apidiags.Steps{apidiags.PathOf(apidiags.BodyStep{}, apidiags.ObjectPropertyStep("tags"), apidiags.ArrayIndexStep(1))},
+					Detail:   "minimum string length is 2",
+				},
+			},
+		},
+		"required-property": {
+			method:         http.MethodPut,
+			target:         "/widgets/123",
+			tenant:         "acme",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeMissing,
+				Paths:    []apidiags.Steps{apidiags.PathOf(apidiags.BodyStep{}, apidiags.ObjectPropertyStep("name"))},
+				Detail:   `property "name" is missing`,
+			}},
+		},
+		"missing-body": {
+			method:         http.MethodPut,
+			target:         "/widgets/123",
+			tenant:         "acme",
+			expectedStatus: http.StatusBadRequest,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeMissing,
+				Paths:    []apidiags.Steps{apidiags.BodyPath()},
+			}},
+		},
+		"content-type": {
+			method:         http.MethodPut,
+			target:         "/widgets/123",
+			tenant:         "acme",
+			contentType:    "text/plain",
+			body:           "foo",
+			expectedStatus: http.StatusBadRequest,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidValue,
+				Paths:    []apidiags.Steps{apidiags.HeaderPath("Content-Type")},
+				Detail:   `header Content-Type has unexpected value "text/plain"`,
+			}},
+		},
+		"path-not-found": {
+			method:         http.MethodGet,
+			target:         "/gadgets",
+			expectedStatus: http.StatusNotFound,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeNotFound,
+			}},
+		},
+		"method-not-allowed": {
+			method:         http.MethodDelete,
+			target:         "/widgets/123",
+			expectedStatus: http.StatusMethodNotAllowed,
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidValue,
+				Summary:  "The DELETE method isn't supported.",
+			}},
+		},
+	}
+
+	router := newRouter(t)
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := Middleware(router)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				if err != nil || string(body) != tc.body {
+					t.Errorf("expected body %q to be passed on, got %q (%v)", tc.body, body, err)
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			r := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			r.Header.Set("Content-Type", "application/json")
+			if tc.contentType != "" {
+				r.Header.Set("Content-Type", tc.contentType)
+			}
+			if tc.tenant != "" {
+				r.Header.Set("X-Tenant", tc.tenant)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+			if tc.expected == nil {
+				return
+			}
+			var problem apidiags.Problem
+			err := json.Unmarshal(w.Body.Bytes(), &problem)
+			if err != nil {
+				t.Fatalf("unexpected error decoding response: %s", err)
+			}
+			if diff := cmp.Diff(tc.expected, problem.Diagnostics); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+module impractical.co/apidiags/apidiagsmsgpack
+
+go 1.19
+
+require (
+	github.com/google/go-cmp v0.5.9
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	impractical.co/apidiags v0.0.0
+)
+
+require github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+
+replace impractical.co/apidiags => ../
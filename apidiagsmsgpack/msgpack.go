@@ -0,0 +1,256 @@
+// Package apidiagsmsgpack encodes apidiags Diagnostics and Steps as
+// MessagePack, so they can be carried in MessagePack envelopes.
+//
+// The encoding mirrors the JSON encoding: Diagnostics are arrays of maps
+// with the same keys as their JSON objects, and each Step is a map with a
+// "kind" key and, for Steps that have one, a "value" key. Integer values are
+// encoded as MessagePack integers, and everything else as MessagePack
+// strings.
+package apidiagsmsgpack
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"impractical.co/apidiags"
+)
+
+type diagnostic struct {
+	Severity apidiags.Severity `msgpack:"severity"`
+	Code     apidiags.Code     `msgpack:"code"`
+	Paths    [][]step          `msgpack:"path,omitempty"`
+	Summary  string            `msgpack:"summary,omitempty"`
+	Detail   string            `msgpack:"detail,omitempty"`
+	DocURL   string            `msgpack:"doc_url,omitempty"`
+}
+
+type step struct {
+	Kind  string `msgpack:"kind"`
+	Value any    `msgpack:"value"`
+}
+
+// EncodeMsgpack encodes the step as a map, leaving out the "value" key for
+// steps without a value. The omitempty tag can't be used for this, as it
+// would also leave out values like 0 and "".
+func (s step) EncodeMsgpack(enc *msgpack.Encoder) error {
+	if s.Value == nil {
+		if err := enc.EncodeMapLen(1); err != nil {
+			return err
+		}
+		if err := enc.EncodeString("kind"); err != nil {
+			return err
+		}
+		return enc.EncodeString(s.Kind)
+	}
+	if err := enc.EncodeMapLen(2); err != nil {
+		return err
+	}
+	if err := enc.EncodeString("kind"); err != nil {
+		return err
+	}
+	if err := enc.EncodeString(s.Kind); err != nil {
+		return err
+	}
+	if err := enc.EncodeString("value"); err != nil {
+		return err
+	}
+	return enc.Encode(s.Value)
+}
+
+// Marshal encodes diags as MessagePack.
+func Marshal(diags apidiags.Diagnostics) ([]byte, error) {
+	var buf bytes.Buffer
+	err := Encode(msgpack.NewEncoder(&buf), diags)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Encode writes diags to enc, for embedding Diagnostics in a larger
+// MessagePack document.
+func Encode(enc *msgpack.Encoder, diags apidiags.Diagnostics) error {
+	encoded := make([]diagnostic, 0, len(diags))
+	for pos, diag := range diags {
+		diag = diag.RenderMessage(nil)
+		result := diagnostic{
+			Severity: diag.Severity,
+			Code:     diag.Code,
+			Summary:  diag.Summary,
+			Detail:   diag.Detail,
+			DocURL:   diag.DocURL,
+		}
+		for _, path := range diag.Paths {
+			steps, err := fromSteps(path)
+			if err != nil {
+				return fmt.Errorf("error encoding diagnostic %d: %w", pos, err)
+			}
+			result.Paths = append(result.Paths, steps)
+		}
+		encoded = append(encoded, result)
+	}
+	return enc.Encode(encoded)
+}
+
+// Unmarshal decodes Diagnostics encoded by Marshal.
+func Unmarshal(data []byte) (apidiags.Diagnostics, error) {
+	return Decode(msgpack.NewDecoder(bytes.NewReader(data)))
+}
+
+// Decode reads Diagnostics written by Encode from dec.
+func Decode(dec *msgpack.Decoder) (apidiags.Diagnostics, error) {
+	var encoded []diagnostic
+	err := dec.Decode(&encoded)
+	if err != nil {
+		return nil, err
+	}
+	results := make(apidiags.Diagnostics, 0, len(encoded))
+	for pos, diag := range encoded {
+		result := apidiags.Diagnostic{
+			Severity: diag.Severity,
+			Code:     diag.Code,
+			Summary:  diag.Summary,
+			Detail:   diag.Detail,
+			DocURL:   diag.DocURL,
+		}
+		for _, path := range diag.Paths {
+			steps, err := toSteps(path)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing diagnostic %d: %w", pos, err)
+			}
+			result.Paths = append(result.Paths, steps)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// MarshalSteps encodes steps as MessagePack, using the same encoding as the
+// Paths of Diagnostics encoded by Marshal.
+func MarshalSteps(steps apidiags.Steps) ([]byte, error) {
+	encoded, err := fromSteps(steps)
+	if err != nil {
+		return nil, err
+	}
+	return msgpack.Marshal(encoded)
+}
+
+// UnmarshalSteps decodes Steps encoded by MarshalSteps.
+func UnmarshalSteps(data []byte) (apidiags.Steps, error) {
+	var encoded []step
+	err := msgpack.Unmarshal(data, &encoded)
+	if err != nil {
+		return nil, err
+	}
+	return toSteps(encoded)
+}
+
+func fromSteps(steps apidiags.Steps) ([]step, error) {
+	results := make([]step, 0, len(steps))
+	for pos, value := range steps {
+		switch value := value.(type) {
+		case apidiags.BodyStep:
+			results = append(results, step{Kind: "body"})
+		case apidiags.HeaderStep:
+			results = append(results, step{Kind: "header", Value: string(value)})
+		case apidiags.URLParamStep:
+			results = append(results, step{Kind: "url_param", Value: string(value)})
+		case apidiags.ArrayIndexStep:
+			results = append(results, step{Kind: "array_index", Value: int64(value)})
+		case apidiags.ObjectPropertyStep:
+			results = append(results, step{Kind: "object_property", Value: string(value)})
+		case apidiags.StringIndexStep:
+			results = append(results, step{Kind: "string_index", Value: int64(value)})
+		case apidiags.RuneIndexStep:
+			results = append(results, step{Kind: "rune_index", Value: int64(value)})
+		default:
+			return nil, fmt.Errorf("unknown step type %T for step %d", value, pos)
+		}
+	}
+	return results, nil
+}
+
+func toSteps(encoded []step) (apidiags.Steps, error) {
+	results := make(apidiags.Steps, 0, len(encoded))
+	for pos, value := range encoded {
+		result, err := value.toStep()
+		if err != nil {
+			return nil, fmt.Errorf("error parsing step %d: %w", pos, err)
+		}
+		results = results.AddStep(result)
+	}
+	return results, nil
+}
+
+func (s step) toStep() (apidiags.Step, error) {
+	switch s.Kind {
+	case "body":
+		return apidiags.BodyStep{}, nil
+	case "header":
+		header, err := s.stringValue()
+		return apidiags.HeaderStep(header), err
+	case "url_param":
+		param, err := s.stringValue()
+		return apidiags.URLParamStep(param), err
+	case "array_index":
+		idx, err := s.intValue()
+		return apidiags.ArrayIndexStep(idx), err
+	case "object_property":
+		property, err := s.stringValue()
+		return apidiags.ObjectPropertyStep(property), err
+	case "string_index":
+		idx, err := s.intValue()
+		return apidiags.StringIndexStep(idx), err
+	case "rune_index":
+		idx, err := s.intValue()
+		return apidiags.RuneIndexStep(idx), err
+	default:
+		return nil, fmt.Errorf("unexpected step kind %q with value type %T", s.Kind, s.Value)
+	}
+}
+
+func (s step) stringValue() (string, error) {
+	if s.Value == nil {
+		return "", errors.New("no value")
+	}
+	val, ok := s.Value.(string)
+	if !ok {
+		return "", fmt.Errorf("wanted string, got %T", s.Value)
+	}
+	return val, nil
+}
+
+// intValue returns the step's value as an int64. MessagePack decoders use the
+// smallest integer type that holds the encoded value, so any of them may
+// turn up.
+func (s step) intValue() (int64, error) {
+	switch val := s.Value.(type) {
+	case nil:
+		return 0, errors.New("no value")
+	case int8:
+		return int64(val), nil
+	case int16:
+		return int64(val), nil
+	case int32:
+		return int64(val), nil
+	case int64:
+		return val, nil
+	case uint8:
+		return int64(val), nil
+	case uint16:
+		return int64(val), nil
+	case uint32:
+		return int64(val), nil
+	case uint64:
+		if val > math.MaxInt64 {
+			return 0, fmt.Errorf("%d overflows int64", val)
+		}
+		return int64(val), nil
+	default:
+		return 0, fmt.Errorf("wanted integer, got %T", s.Value)
+	}
+}
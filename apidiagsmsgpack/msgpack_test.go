@@ -0,0 +1,172 @@
+package apidiagsmsgpack
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"impractical.co/apidiags"
+)
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags apidiags.Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"empty": {
+			diags: apidiags.Diagnostics{},
+		},
+		"all-fields": {
+			diags: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Summary:  "Name is required.",
+					Detail:   "Every item needs a name.",
+					DocURL:   "https://example.com/errors/missing",
+					Paths: []apidiags.Steps{apidiags.BodyPath().AddSteps(
+						apidiags.ObjectPropertyStep("items"),
+						apidiags.ArrayIndexStep(3),
+					)},
+				},
+			},
+		},
+		"every-step-kind": {
+			diags: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticWarning,
+					Code:     apidiags.CodeDeprecated,
+					Paths: []apidiags.Steps{
+						apidiags.HeaderPath("X-Legacy"),
+						apidiags.URLParamPath("id"),
+						apidiags.BodyPath().AddSteps(apidiags.ArrayIndexStep(70000), apidiags.StringIndexStep(-1)),
+						apidiags.BodyPath().AddSteps(apidiags.ArrayIndexStep(300), apidiags.RuneIndexStep(4)),
+					},
+				},
+			},
+		},
+		"zero-values": {
+			diags: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeInvalidValue,
+					Paths: []apidiags.Steps{
+						apidiags.BodyPath().AddSteps(apidiags.ArrayIndexStep(0), apidiags.StringIndexStep(0)),
+						apidiags.BodyPath().AddSteps(apidiags.ObjectPropertyStep(""), apidiags.RuneIndexStep(0)),
+						apidiags.HeaderPath(""),
+						apidiags.URLParamPath(""),
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			encoded, err := Marshal(tc.diags)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			decoded, err := Unmarshal(encoded)
+			if err != nil {
+				t.Fatalf("unexpected error decoding: %s", err)
+			}
+			if diff := cmp.Diff(tc.diags, decoded); diff != "" {
+				t.Errorf("unexpected round trip results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	t.Parallel()
+
+	encoded, err := MarshalSteps(apidiags.BodyPath().AddSteps(apidiags.ObjectPropertyStep("items"), apidiags.ArrayIndexStep(3)))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var generic []map[string]any
+	err = msgpack.Unmarshal(encoded, &generic)
+	if err != nil {
+		t.Fatalf("unexpected error decoding: %s", err)
+	}
+	expected := []map[string]any{
+		{"kind": "body"},
+		{"kind": "object_property", "value": "items"},
+		{"kind": "array_index", "value": int64(3)},
+	}
+	if diff := cmp.Diff(expected, generic); diff != "" {
+		t.Errorf("unexpected encoding (-wanted, +got): %s", diff)
+	}
+}
+
+func TestEncodeInEnvelope(t *testing.T) {
+	t.Parallel()
+
+	diags := apidiags.Diagnostics{
+		{Severity: apidiags.DiagnosticError, Code: apidiags.CodeNotFound, Paths: []apidiags.Steps{apidiags.URLParamPath("id")}},
+	}
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	err := enc.EncodeString("envelope")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err = Encode(enc, diags)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	dec := msgpack.NewDecoder(&buf)
+	header, err := dec.DecodeString()
+	if err != nil {
+		t.Fatalf("unexpected error decoding header: %s", err)
+	}
+	if header != "envelope" {
+		t.Errorf("expected header %q, got %q", "envelope", header)
+	}
+	decoded, err := Decode(dec)
+	if err != nil {
+		t.Fatalf("unexpected error decoding: %s", err)
+	}
+	if diff := cmp.Diff(diags, decoded); diff != "" {
+		t.Errorf("unexpected results (-wanted, +got): %s", diff)
+	}
+}
+
+func TestUnmarshalStepsErrors(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string][]map[string]any{
+		"unknown-kind": {{"kind": "cookie", "value": "session"}},
+		"wrong-type":   {{"kind": "body"}, {"kind": "array_index", "value": "three"}},
+		"no-value":     {{"kind": "header"}},
+		"overflow":     {{"kind": "body"}, {"kind": "string_index", "value": uint64(1 << 63)}},
+	}
+
+	for name, input := range cases {
+		name, input := name, input
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			encoded, err := msgpack.Marshal(input)
+			if err != nil {
+				t.Fatalf("unexpected error encoding input: %s", err)
+			}
+			steps, err := UnmarshalSteps(encoded)
+			if err == nil {
+				t.Fatalf("expected error, got %+v", steps)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+module impractical.co/apidiags/apidiagsmultierror
+
+go 1.19
+
+require (
+	github.com/google/go-cmp v0.5.9
+	github.com/hashicorp/go-multierror v1.1.1
+	impractical.co/apidiags v0.0.0
+)
+
+require github.com/hashicorp/errwrap v1.0.0 // indirect
+
+replace impractical.co/apidiags => ../
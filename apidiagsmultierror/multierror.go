@@ -0,0 +1,78 @@
+// Package apidiagsmultierror converts between apidiags Diagnostics and
+// hashicorp/go-multierror errors, so code that collects errors with
+// go-multierror can surface them as structured Diagnostics.
+package apidiagsmultierror
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+
+	"impractical.co/apidiags"
+)
+
+// DiagnosticError is an error describing a single Diagnostic. It implements
+// apidiags.DiagnosticsError, so apidiags.FromError recovers the Diagnostic.
+type DiagnosticError struct {
+	Diagnostic apidiags.Diagnostic
+}
+
+// Error returns the Diagnostic's Message, followed by its Paths, if it has
+// any.
+func (e DiagnosticError) Error() string {
+	if len(e.Diagnostic.Paths) < 1 {
+		return e.Diagnostic.Message()
+	}
+	paths := make([]string, 0, len(e.Diagnostic.Paths))
+	for _, path := range e.Diagnostic.Paths {
+		paths = append(paths, path.String())
+	}
+	return e.Diagnostic.Message() + " at " + strings.Join(paths, ", ")
+}
+
+// Diagnostics returns the Diagnostic the error describes.
+func (e DiagnosticError) Diagnostics() apidiags.Diagnostics {
+	return apidiags.Diagnostics{e.Diagnostic}
+}
+
+// FromError converts err into Diagnostics. If err is or wraps a
+// *multierror.Error, each of its errors is classified by classify, and the
+// results are concatenated; otherwise err itself is classified. If classify
+// is nil, apidiags.FromError is used.
+func FromError(err error, classify func(error) apidiags.Diagnostics) apidiags.Diagnostics {
+	if classify == nil {
+		classify = apidiags.FromError
+	}
+	var multi *multierror.Error
+	if !errors.As(err, &multi) {
+		return classify(err)
+	}
+	var results apidiags.Diagnostics
+	for _, wrapped := range multi.Errors {
+		results = append(results, FromError(wrapped, classify)...)
+	}
+	return results
+}
+
+// ToError converts diags into a *multierror.Error, with a DiagnosticError for
+// each Diagnostic. If none of diags have a Severity of
+// apidiags.DiagnosticError, ToError returns nil, as there's no error to
+// report.
+func ToError(diags apidiags.Diagnostics) error {
+	var hasErrors bool
+	for _, diag := range diags {
+		if diag.Severity == apidiags.DiagnosticError {
+			hasErrors = true
+			break
+		}
+	}
+	if !hasErrors {
+		return nil
+	}
+	result := &multierror.Error{}
+	for _, diag := range diags {
+		result = multierror.Append(result, DiagnosticError{Diagnostic: diag})
+	}
+	return result
+}
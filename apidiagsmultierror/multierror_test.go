@@ -0,0 +1,120 @@
+package apidiagsmultierror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/go-multierror"
+
+	"impractical.co/apidiags"
+)
+
+var errNameRequired = errors.New("name is required")
+
+func classifyLegacy(err error) apidiags.Diagnostics {
+	if errors.Is(err, errNameRequired) {
+		return apidiags.Diagnostics{{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeMissing,
+			Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name"))},
+		}}
+	}
+	return apidiags.FromError(err)
+}
+
+func TestFromError(t *testing.T) {
+	t.Parallel()
+
+	var legacy error
+	legacy = multierror.Append(legacy, fmt.Errorf("validating: %w", errNameRequired))
+	legacy = multierror.Append(legacy, errors.New("database unavailable"))
+
+	type testCase struct {
+		err      error
+		classify func(error) apidiags.Diagnostics
+		expected apidiags.Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"nil": {},
+		"default-classifier": {
+			err: legacy,
+			expected: apidiags.Diagnostics{
+				{Severity: apidiags.DiagnosticError, Code: apidiags.CodeActOfGod},
+				{Severity: apidiags.DiagnosticError, Code: apidiags.CodeActOfGod},
+			},
+		},
+		"custom-classifier": {
+			err:      fmt.Errorf("creating widget: %w", legacy),
+			classify: classifyLegacy,
+			expected: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name"))},
+				},
+				{Severity: apidiags.DiagnosticError, Code: apidiags.CodeActOfGod},
+			},
+		},
+		"not-multierror": {
+			err:      errNameRequired,
+			classify: classifyLegacy,
+			expected: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name"))},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tc.expected, FromError(tc.err, tc.classify)); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestToErrorRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	diags := apidiags.Diagnostics{
+		{Severity: apidiags.DiagnosticWarning, Code: apidiags.CodeDeprecated, Paths: []apidiags.Steps{apidiags.HeaderPath("X-Legacy")}},
+		{Severity: apidiags.DiagnosticError, Code: apidiags.CodeMissing, Summary: "Name is required.", Paths: []apidiags.Steps{
+			apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name")),
+		}},
+	}
+	err := ToError(diags)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	expected := `2 errors occurred:
+	* deprecated at header("X-Legacy")
+	* Name is required. at body.name
+
+`
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+	if diff := cmp.Diff(diags, FromError(err, nil)); diff != "" {
+		t.Errorf("unexpected round trip results (-wanted, +got): %s", diff)
+	}
+}
+
+func TestToErrorWarningsOnly(t *testing.T) {
+	t.Parallel()
+
+	err := ToError(apidiags.Diagnostics{{Severity: apidiags.DiagnosticWarning, Code: apidiags.CodeDeprecated}})
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+}
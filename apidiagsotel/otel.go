@@ -0,0 +1,106 @@
+// Package apidiagsotel records apidiags Diagnostics on OpenTelemetry trace
+// spans, so the errors and warnings a request produced are visible in
+// traces.
+package apidiagsotel
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"impractical.co/apidiags"
+)
+
+// EventName is the name of the span events RecordDiagnostics adds, one per
+// Diagnostic.
+const EventName = "apidiags.diagnostic"
+
+// The attributes RecordDiagnostics sets on spans and their events.
+const (
+	// SeverityKey holds a Diagnostic's Severity, on its event.
+	SeverityKey = attribute.Key("apidiags.severity")
+
+	// CodeKey holds a Diagnostic's Code, on its event.
+	CodeKey = attribute.Key("apidiags.code")
+
+	// PathKey holds a Diagnostic's Paths, rendered using
+	// apidiags.Steps.String, on its event.
+	PathKey = attribute.Key("apidiags.path")
+
+	// SummaryKey holds a Diagnostic's Summary, on its event, if it has one.
+	SummaryKey = attribute.Key("apidiags.summary")
+
+	// CountKey holds the number of Diagnostics, on the span.
+	CountKey = attribute.Key("apidiags.diagnostics.count")
+
+	// ErrorCountKey holds the number of Diagnostics with a Severity of
+	// apidiags.DiagnosticError, on the span.
+	ErrorCountKey = attribute.Key("apidiags.diagnostics.error_count")
+)
+
+// Option configures how Diagnostics are recorded on a span.
+type Option func(*config)
+
+type config struct {
+	status bool
+}
+
+// WithErrorStatus sets the span's status to codes.Error when the
+// Diagnostics include an error, using the Message of the first error as
+// the status's description.
+func WithErrorStatus() Option {
+	return func(c *config) {
+		c.status = true
+	}
+}
+
+// RecordDiagnostics records diags on span: an event named EventName for
+// each Diagnostic, with its Severity, Code, Paths, and Summary as
+// attributes, and the number of Diagnostics and errors as attributes of
+// the span. Details aren't recorded, as they can be long, and may include
+// the values callers sent. If diags is empty, span isn't changed.
+func RecordDiagnostics(span trace.Span, diags apidiags.Diagnostics, opts ...Option) {
+	if len(diags) < 1 || !span.IsRecording() {
+		return
+	}
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	var errs int
+	var primary *apidiags.Diagnostic
+	for pos, diag := range diags {
+		if diag.Severity == apidiags.DiagnosticError {
+			errs++
+			if primary == nil {
+				primary = &diags[pos]
+			}
+		}
+		span.AddEvent(EventName, trace.WithAttributes(Attributes(diag)...))
+	}
+	span.SetAttributes(CountKey.Int(len(diags)), ErrorCountKey.Int(errs))
+	if cfg.status && primary != nil {
+		span.SetStatus(codes.Error, primary.Message())
+	}
+}
+
+// Attributes returns the attributes RecordDiagnostics records on the event
+// for diag.
+func Attributes(diag apidiags.Diagnostic) []attribute.KeyValue {
+	diag = diag.RenderMessage(nil)
+	attrs := []attribute.KeyValue{
+		SeverityKey.String(string(diag.Severity)),
+		CodeKey.String(string(diag.Code)),
+	}
+	if len(diag.Paths) > 0 {
+		paths := make([]string, 0, len(diag.Paths))
+		for _, path := range diag.Paths {
+			paths = append(paths, path.String())
+		}
+		attrs = append(attrs, PathKey.StringSlice(paths))
+	}
+	if diag.Summary != "" {
+		attrs = append(attrs, SummaryKey.String(diag.Summary))
+	}
+	return attrs
+}
@@ -0,0 +1,114 @@
+package apidiagsotel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"impractical.co/apidiags"
+)
+
+func TestRecordDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	type event struct {
+		Name  string
+		Attrs []attribute.KeyValue
+	}
+
+	type testCase struct {
+		diags  apidiags.Diagnostics
+		opts   []Option
+		attrs  []attribute.KeyValue
+		events []event
+		status sdktrace.Status
+	}
+
+	diags := apidiags.Diagnostics{
+		{
+			Severity: apidiags.DiagnosticWarning,
+			Code:     apidiags.CodeDeprecated,
+		},
+		{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeMissing,
+			Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name"))},
+			Summary:  "Name is required.",
+			Detail:   "Set a name.",
+		},
+	}
+	events := []event{
+		{Name: EventName, Attrs: []attribute.KeyValue{
+			SeverityKey.String("warning"),
+			CodeKey.String("deprecated"),
+		}},
+		{Name: EventName, Attrs: []attribute.KeyValue{
+			SeverityKey.String("error"),
+			CodeKey.String("missing"),
+			PathKey.StringSlice([]string{"body.name"}),
+			SummaryKey.String("Name is required."),
+		}},
+	}
+
+	cases := map[string]testCase{
+		"empty": {},
+		"diagnostics": {
+			diags:  diags,
+			attrs:  []attribute.KeyValue{CountKey.Int(2), ErrorCountKey.Int(1)},
+			events: events,
+		},
+		"status": {
+			diags:  diags,
+			opts:   []Option{WithErrorStatus()},
+			attrs:  []attribute.KeyValue{CountKey.Int(2), ErrorCountKey.Int(1)},
+			events: events,
+			status: sdktrace.Status{Code: codes.Error, Description: "Name is required."},
+		},
+		"status-without-errors": {
+			diags:  diags[:1],
+			opts:   []Option{WithErrorStatus()},
+			attrs:  []attribute.KeyValue{CountKey.Int(1), ErrorCountKey.Int(0)},
+			events: events[:1],
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			_, span := provider.Tracer("test").Start(context.Background(), "test")
+			RecordDiagnostics(span, tc.diags, tc.opts...)
+			span.End()
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("expected 1 span, got %d", len(spans))
+			}
+			var gotEvents []event
+			for _, ev := range spans[0].Events() {
+				gotEvents = append(gotEvents, event{Name: ev.Name, Attrs: ev.Attributes})
+			}
+			if diff := cmp.Diff(tc.events, gotEvents, cmp.Comparer(attributeEqual)); diff != "" {
+				t.Errorf("unexpected events diff (-wanted, +got): %s", diff)
+			}
+			if diff := cmp.Diff(tc.attrs, spans[0].Attributes(), cmp.Comparer(attributeEqual)); diff != "" {
+				t.Errorf("unexpected attributes diff (-wanted, +got): %s", diff)
+			}
+			if diff := cmp.Diff(tc.status, spans[0].Status()); diff != "" {
+				t.Errorf("unexpected status diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func attributeEqual(a, b attribute.KeyValue) bool {
+	return a.Key == b.Key && a.Value.Emit() == b.Value.Emit() && a.Value.Type() == b.Value.Type()
+}
@@ -0,0 +1,47 @@
+package apidiagsotel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"impractical.co/apidiags"
+)
+
+// WithSpanContext returns a copy of diag with the IDs of the trace and span
+// in ctx recorded using apidiags.Diagnostic.WithTrace, so a Diagnostic a
+// caller reports can be looked up in a tracing system. If ctx has no valid
+// span context, or diag already has a trace ID, diag is returned
+// unchanged.
+func WithSpanContext(ctx context.Context, diag apidiags.Diagnostic) apidiags.Diagnostic {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.IsValid() {
+		return diag
+	}
+	if traceID, _, err := diag.Trace(); err == nil && traceID != "" {
+		return diag
+	}
+	return diag.WithTrace(spanCtx.TraceID().String(), spanCtx.SpanID().String())
+}
+
+// WithSpanContexts returns a copy of diags with WithSpanContext applied to
+// each Diagnostic.
+func WithSpanContexts(ctx context.Context, diags apidiags.Diagnostics) apidiags.Diagnostics {
+	if len(diags) < 1 {
+		return diags
+	}
+	results := make(apidiags.Diagnostics, 0, len(diags))
+	for _, diag := range diags {
+		results = append(results, WithSpanContext(ctx, diag))
+	}
+	return results
+}
+
+// TraceHook is an apidiags.DiagnosticHook that applies WithSpanContext to
+// every Diagnostic as it's added or written. Register it once, with
+// apidiags.OnDiagnostic, and every Diagnostic added to an instrumented
+// request's context, or written using apidiags.WithContext, records the
+// request's trace.
+func TraceHook(ctx context.Context, _ apidiags.HookEvent, diag apidiags.Diagnostic) apidiags.Diagnostic {
+	return WithSpanContext(ctx, diag)
+}
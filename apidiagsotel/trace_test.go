@@ -0,0 +1,69 @@
+package apidiagsotel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/trace"
+
+	"impractical.co/apidiags"
+)
+
+func TestWithSpanContext(t *testing.T) {
+	t.Parallel()
+
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	if err != nil {
+		t.Fatalf("error parsing trace ID: %s", err)
+	}
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	if err != nil {
+		t.Fatalf("error parsing span ID: %s", err)
+	}
+	spanCtx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+	diag := apidiags.Diagnostic{Severity: apidiags.DiagnosticError, Code: apidiags.CodeActOfGod}
+
+	type testCase struct {
+		ctx      context.Context
+		diag     apidiags.Diagnostic
+		expected apidiags.Diagnostic
+	}
+
+	cases := map[string]testCase{
+		"span": {
+			ctx:      spanCtx,
+			diag:     diag,
+			expected: diag.WithTrace("4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"),
+		},
+		"no-span": {
+			ctx:      context.Background(),
+			diag:     diag,
+			expected: diag,
+		},
+		"already-traced": {
+			ctx:      spanCtx,
+			diag:     diag.WithTrace("0af7651916cd43dd8448eb211c80319c", "b7ad6b7169203331"),
+			expected: diag.WithTrace("0af7651916cd43dd8448eb211c80319c", "b7ad6b7169203331"),
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := TraceHook(tc.ctx, apidiags.HookAdded, tc.diag)
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+			gotAll := WithSpanContexts(tc.ctx, apidiags.Diagnostics{tc.diag})
+			if diff := cmp.Diff(apidiags.Diagnostics{tc.expected}, gotAll); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+module impractical.co/apidiags/apidiagsozzo
+
+go 1.19
+
+require (
+	github.com/go-ozzo/ozzo-validation/v4 v4.4.1
+	github.com/google/go-cmp v0.5.9
+	impractical.co/apidiags v0.0.0
+)
+
+require github.com/asaskevich/govalidator v0.0.0-20210307081110-f21760c49a8d // indirect
+
+replace impractical.co/apidiags => ../
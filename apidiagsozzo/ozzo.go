@@ -0,0 +1,186 @@
+// Package apidiagsozzo converts the errors returned by ozzo-validation into
+// apidiags Diagnostics, pointing to the fields that failed validation.
+package apidiagsozzo
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+
+	"impractical.co/apidiags"
+)
+
+// Option configures how validation errors are converted into Diagnostics.
+type Option func(*config)
+
+type config struct {
+	root    func(field string) apidiags.Steps
+	rootSet bool
+}
+
+// WithRoot makes the Paths of the Diagnostics start with root(field), where
+// field is the name of the top-level field that failed validation, instead
+// of pointing into the request body. Use apidiags.URLParamPath for structs
+// decoded from query parameters, or apidiags.HeaderPath for structs
+// decoded from headers.
+func WithRoot(root func(field string) apidiags.Steps) Option {
+	return func(c *config) {
+		c.root = root
+		c.rootSet = true
+	}
+}
+
+// FromError converts an error returned by ozzo-validation into Diagnostics,
+// each with a Severity of apidiags.DiagnosticError.
+//
+// validation.Errors become one Diagnostic per failed rule, walking nested
+// validation.Errors to build each Diagnostic's Path: field names become
+// apidiags.ObjectPropertyStep, and integer keys, which ozzo-validation uses
+// for slice indexes, become apidiags.ArrayIndexStep. This means maps with
+// integer keys are described as if they were slices. Field names come from
+// the fields' `json` struct tags, as they do in ozzo-validation. Diagnostics
+// are sorted by their Paths, as ozzo-validation doesn't keep its errors in
+// order.
+//
+// Each Diagnostic's Code is chosen by Code, based on the validation.Error's
+// code, and its Detail is the validation.Error's message. Errors that aren't
+// validation.Errors, like those returned by validation.By rules, use
+// apidiags.CodeInvalidValue.
+//
+// A validation.Error that isn't part of a validation.Errors, like one
+// returned by validation.Validate, describes the whole request body; if
+// WithRoot is used, it has no Path. validation.InternalError, and any other
+// error, is converted using apidiags.FromError.
+func FromError(err error, opts ...Option) apidiags.Diagnostics {
+	cfg := config{root: func(field string) apidiags.Steps {
+		return apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep(field))
+	}}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	var internalErr validation.InternalError
+	var errs validation.Errors
+	var validationErr validation.Error
+	switch {
+	case errors.As(err, &internalErr):
+		return apidiags.FromError(err)
+	case errors.As(err, &errs):
+		return fromErrors(errs, nil, cfg)
+	case errors.As(err, &validationErr):
+		diag := fromLeaf(validationErr, nil)
+		if !cfg.rootSet {
+			diag.Paths = []apidiags.Steps{apidiags.BodyPath()}
+		}
+		return apidiags.Diagnostics{diag}
+	}
+	return apidiags.FromError(err)
+}
+
+// fromErrors converts errs, found at path, into Diagnostics. A nil path
+// means errs are the top-level errors, and their keys are passed to the
+// configured root.
+func fromErrors(errs validation.Errors, path apidiags.Steps, cfg config) apidiags.Diagnostics {
+	keys := make([]string, 0, len(errs))
+	for key := range errs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return lessKey(keys[i], keys[j])
+	})
+	var results apidiags.Diagnostics
+	for _, key := range keys {
+		err := errs[key]
+		if err == nil {
+			continue
+		}
+		var keyPath apidiags.Steps
+		switch {
+		case path == nil:
+			keyPath = cfg.root(key)
+		case isIndex(key):
+			idx, _ := strconv.ParseInt(key, 10, 64)
+			keyPath = path.AddStep(apidiags.ArrayIndexStep(idx))
+		default:
+			keyPath = path.AddStep(apidiags.ObjectPropertyStep(key))
+		}
+		var nested validation.Errors
+		if errors.As(err, &nested) {
+			results = append(results, fromErrors(nested, keyPath, cfg)...)
+			continue
+		}
+		results = append(results, fromLeaf(err, keyPath))
+	}
+	return results
+}
+
+// fromLeaf converts an error that isn't a validation.Errors into a
+// Diagnostic pointing to path.
+func fromLeaf(err error, path apidiags.Steps) apidiags.Diagnostic {
+	diag := apidiags.Diagnostic{
+		Severity: apidiags.DiagnosticError,
+		Code:     apidiags.CodeInvalidValue,
+		Detail:   err.Error(),
+	}
+	var validationErr validation.Error
+	if errors.As(err, &validationErr) {
+		diag.Code = Code(validationErr.Code())
+	}
+	if path != nil {
+		diag.Paths = []apidiags.Steps{path}
+	}
+	return diag
+}
+
+// Code returns the apidiags.Code describing a validation.Error with the
+// given code, like "validation_required". Codes this package doesn't know
+// about, including those of custom rules, map to apidiags.CodeInvalidValue.
+func Code(code string) apidiags.Code {
+	switch code {
+	case "validation_required", "validation_nil_or_not_empty_required", "validation_not_nil_required",
+		"validation_key_missing":
+		return apidiags.CodeMissing
+	case "validation_length_too_short", "validation_min_greater_equal_than_required",
+		"validation_min_greater_than_required":
+		return apidiags.CodeInsufficient
+	case "validation_length_too_long", "validation_max_less_equal_than_required",
+		"validation_max_less_than_required":
+		return apidiags.CodeOverflow
+	case "validation_match_invalid", "validation_date_invalid", "validation_key_wrong_type":
+		return apidiags.CodeInvalidFormat
+	}
+	if strings.HasPrefix(code, "validation_is_") {
+		return apidiags.CodeInvalidFormat
+	}
+	return apidiags.CodeInvalidValue
+}
+
+func isIndex(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// lessKey orders integer keys numerically, before any other keys, which are
+// ordered lexically.
+func lessKey(a, b string) bool {
+	aIndex, bIndex := isIndex(a), isIndex(b)
+	switch {
+	case aIndex && bIndex:
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	case aIndex != bIndex:
+		return aIndex
+	}
+	return a < b
+}
@@ -0,0 +1,182 @@
+package apidiagsozzo
+
+import (
+	"errors"
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/google/go-cmp/cmp"
+
+	"impractical.co/apidiags"
+)
+
+type label struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func (l label) Validate() error {
+	return validation.ValidateStruct(&l,
+		validation.Field(&l.Key, validation.Required),
+		validation.Field(&l.Value, validation.Length(1, 5)),
+	)
+}
+
+type widget struct {
+	Name   string  `json:"name"`
+	Count  int     `json:"count"`
+	Owner  string  `json:"owner,omitempty"`
+	Labels []label `json:"labels"`
+	Mode   string
+}
+
+func (w widget) Validate() error {
+	return validation.ValidateStruct(&w,
+		validation.Field(&w.Name, validation.Required),
+		validation.Field(&w.Count, validation.Max(10)),
+		validation.Field(&w.Owner, is.Email),
+		validation.Field(&w.Labels),
+		validation.Field(&w.Mode, validation.By(func(value interface{}) error {
+			if value.(string) != "" {
+				return errors.New("modes aren't supported yet")
+			}
+			return nil
+		})),
+	)
+}
+
+func TestFromError(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		err      error
+		opts     []Option
+		expected apidiags.Diagnostics
+	}
+
+	body := func(steps ...apidiags.Step) apidiags.Steps {
+		path := apidiags.BodyPath()
+		for _, step := range steps {
+			path = path.AddStep(step)
+		}
+		return path
+	}
+
+	cases := map[string]testCase{
+		"valid": {
+			err: widget{Name: "foo"}.Validate(),
+		},
+		"fields": {
+			err: widget{Count: 11, Owner: "foo", Mode: "fast"}.Validate(),
+			expected: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeInvalidValue,
+					Paths:    []apidiags.Steps{body(apidiags.ObjectPropertyStep("Mode"))},
+					Detail:   "modes aren't supported yet",
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeOverflow,
+					Paths:    []apidiags.Steps{body(apidiags.ObjectPropertyStep("count"))},
+					Detail:   "must be no greater than 10",
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Paths:    []apidiags.Steps{body(apidiags.ObjectPropertyStep("name"))},
+					Detail:   "cannot be blank",
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeInvalidFormat,
+					Paths:    []apidiags.Steps{body(apidiags.ObjectPropertyStep("owner"))},
+					Detail:   "must be a valid email address",
+				},
+			},
+		},
+		"nested": {
+			err: widget{Name: "foo", Labels: []label{
+				{Key: "app", Value: "frontend"},
+				{Key: "tier", Value: "web"},
+				{Value: "blue"},
+			}}.Validate(),
+			expected: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeInvalidValue,
+					Paths:    []apidiags.Steps{body(apidiags.ObjectPropertyStep("labels"), apidiags.ArrayIndexStep(0), apidiags.ObjectPropertyStep("value"))},
+					Detail:   "the length must be between 1 and 5",
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Paths:    []apidiags.Steps{body(apidiags.ObjectPropertyStep("labels"), apidiags.ArrayIndexStep(2), apidiags.ObjectPropertyStep("key"))},
+					Detail:   "cannot be blank",
+				},
+			},
+		},
+		"root": {
+			err:  widget{}.Validate(),
+			opts: []Option{WithRoot(apidiags.URLParamPath)},
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeMissing,
+				Paths:    []apidiags.Steps{apidiags.URLParamPath("name")},
+				Detail:   "cannot be blank",
+			}},
+		},
+		"value": {
+			err: validation.Validate("abc", validation.Length(5, 0)),
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInsufficient,
+				Paths:    []apidiags.Steps{apidiags.BodyPath()},
+				Detail:   "the length must be no less than 5",
+			}},
+		},
+		"value-root": {
+			err:  validation.Validate("abc", validation.Length(5, 0)),
+			opts: []Option{WithRoot(apidiags.URLParamPath)},
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInsufficient,
+				Detail:   "the length must be no less than 5",
+			}},
+		},
+		"value-other-option": {
+			err:  validation.Validate("abc", validation.Length(5, 0)),
+			opts: []Option{func(*config) {}},
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInsufficient,
+				Paths:    []apidiags.Steps{apidiags.BodyPath()},
+				Detail:   "the length must be no less than 5",
+			}},
+		},
+		"internal": {
+			err:      validation.NewInternalError(errors.New("database unavailable")),
+			expected: apidiags.FromError(validation.NewInternalError(errors.New("database unavailable"))),
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.err == nil {
+				if tc.expected != nil {
+					t.Fatalf("expected validation to fail")
+				}
+				return
+			}
+			result := FromError(tc.err, tc.opts...)
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,512 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: apidiags.proto
+
+package apidiagspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Diagnostics is a list of Diagnostic messages, suitable for use as a
+// google.protobuf.Any detail.
+type Diagnostics struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Diagnostics   []*Diagnostic          `protobuf:"bytes,1,rep,name=diagnostics,proto3" json:"diagnostics,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Diagnostics) Reset() {
+	*x = Diagnostics{}
+	mi := &file_apidiags_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Diagnostics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Diagnostics) ProtoMessage() {}
+
+func (x *Diagnostics) ProtoReflect() protoreflect.Message {
+	mi := &file_apidiags_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Diagnostics.ProtoReflect.Descriptor instead.
+func (*Diagnostics) Descriptor() ([]byte, []int) {
+	return file_apidiags_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Diagnostics) GetDiagnostics() []*Diagnostic {
+	if x != nil {
+		return x.Diagnostics
+	}
+	return nil
+}
+
+// Diagnostic supplies information about the API and its status to the
+// caller. It mirrors the Diagnostic type of impractical.co/apidiags.
+type Diagnostic struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// severity indicates whether the Diagnostic is advisory or fatal, like
+	// "error" or "warning".
+	Severity string `protobuf:"bytes,1,opt,name=severity,proto3" json:"severity,omitempty"`
+	// code identifies the information the Diagnostic communicates, like
+	// "missing" or "not_found".
+	Code string `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	// paths are the parts of the request that triggered the Diagnostic.
+	Paths []*Steps `protobuf:"bytes,3,rep,name=paths,proto3" json:"paths,omitempty"`
+	// summary is an optional short, human-readable description of the
+	// Diagnostic.
+	Summary string `protobuf:"bytes,4,opt,name=summary,proto3" json:"summary,omitempty"`
+	// detail is an optional longer, human-readable explanation of the
+	// Diagnostic.
+	Detail string `protobuf:"bytes,5,opt,name=detail,proto3" json:"detail,omitempty"`
+	// doc_url is an optional link to documentation about the Diagnostic.
+	DocUrl        string `protobuf:"bytes,6,opt,name=doc_url,json=docUrl,proto3" json:"doc_url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Diagnostic) Reset() {
+	*x = Diagnostic{}
+	mi := &file_apidiags_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Diagnostic) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Diagnostic) ProtoMessage() {}
+
+func (x *Diagnostic) ProtoReflect() protoreflect.Message {
+	mi := &file_apidiags_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Diagnostic.ProtoReflect.Descriptor instead.
+func (*Diagnostic) Descriptor() ([]byte, []int) {
+	return file_apidiags_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Diagnostic) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *Diagnostic) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Diagnostic) GetPaths() []*Steps {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+func (x *Diagnostic) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+func (x *Diagnostic) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+func (x *Diagnostic) GetDocUrl() string {
+	if x != nil {
+		return x.DocUrl
+	}
+	return ""
+}
+
+// Steps is a path to a specific part of a request.
+type Steps struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Steps         []*Step                `protobuf:"bytes,1,rep,name=steps,proto3" json:"steps,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Steps) Reset() {
+	*x = Steps{}
+	mi := &file_apidiags_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Steps) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Steps) ProtoMessage() {}
+
+func (x *Steps) ProtoReflect() protoreflect.Message {
+	mi := &file_apidiags_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Steps.ProtoReflect.Descriptor instead.
+func (*Steps) Descriptor() ([]byte, []int) {
+	return file_apidiags_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Steps) GetSteps() []*Step {
+	if x != nil {
+		return x.Steps
+	}
+	return nil
+}
+
+// Step is a single transform or access that points to a more specific part
+// of the request.
+type Step struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Kind:
+	//
+	//	*Step_Body_
+	//	*Step_Header
+	//	*Step_UrlParam
+	//	*Step_ArrayIndex
+	//	*Step_ObjectProperty
+	//	*Step_StringIndex
+	//	*Step_RuneIndex
+	Kind          isStep_Kind `protobuf_oneof:"kind"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Step) Reset() {
+	*x = Step{}
+	mi := &file_apidiags_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Step) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Step) ProtoMessage() {}
+
+func (x *Step) ProtoReflect() protoreflect.Message {
+	mi := &file_apidiags_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Step.ProtoReflect.Descriptor instead.
+func (*Step) Descriptor() ([]byte, []int) {
+	return file_apidiags_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Step) GetKind() isStep_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return nil
+}
+
+func (x *Step) GetBody() *Step_Body {
+	if x != nil {
+		if x, ok := x.Kind.(*Step_Body_); ok {
+			return x.Body
+		}
+	}
+	return nil
+}
+
+func (x *Step) GetHeader() string {
+	if x != nil {
+		if x, ok := x.Kind.(*Step_Header); ok {
+			return x.Header
+		}
+	}
+	return ""
+}
+
+func (x *Step) GetUrlParam() string {
+	if x != nil {
+		if x, ok := x.Kind.(*Step_UrlParam); ok {
+			return x.UrlParam
+		}
+	}
+	return ""
+}
+
+func (x *Step) GetArrayIndex() int64 {
+	if x != nil {
+		if x, ok := x.Kind.(*Step_ArrayIndex); ok {
+			return x.ArrayIndex
+		}
+	}
+	return 0
+}
+
+func (x *Step) GetObjectProperty() string {
+	if x != nil {
+		if x, ok := x.Kind.(*Step_ObjectProperty); ok {
+			return x.ObjectProperty
+		}
+	}
+	return ""
+}
+
+func (x *Step) GetStringIndex() int64 {
+	if x != nil {
+		if x, ok := x.Kind.(*Step_StringIndex); ok {
+			return x.StringIndex
+		}
+	}
+	return 0
+}
+
+func (x *Step) GetRuneIndex() int64 {
+	if x != nil {
+		if x, ok := x.Kind.(*Step_RuneIndex); ok {
+			return x.RuneIndex
+		}
+	}
+	return 0
+}
+
+type isStep_Kind interface {
+	isStep_Kind()
+}
+
+type Step_Body_ struct {
+	// body selects the body of a request.
+	Body *Step_Body `protobuf:"bytes,1,opt,name=body,proto3,oneof"`
+}
+
+type Step_Header struct {
+	// header selects a single header of a request.
+	Header string `protobuf:"bytes,2,opt,name=header,proto3,oneof"`
+}
+
+type Step_UrlParam struct {
+	// url_param selects a single URL query parameter of a request.
+	UrlParam string `protobuf:"bytes,3,opt,name=url_param,json=urlParam,proto3,oneof"`
+}
+
+type Step_ArrayIndex struct {
+	// array_index selects an item in an array.
+	ArrayIndex int64 `protobuf:"varint,4,opt,name=array_index,json=arrayIndex,proto3,oneof"`
+}
+
+type Step_ObjectProperty struct {
+	// object_property selects a property of an object.
+	ObjectProperty string `protobuf:"bytes,5,opt,name=object_property,json=objectProperty,proto3,oneof"`
+}
+
+type Step_StringIndex struct {
+	// string_index selects a UTF-8 byte offset in a string.
+	StringIndex int64 `protobuf:"varint,6,opt,name=string_index,json=stringIndex,proto3,oneof"`
+}
+
+type Step_RuneIndex struct {
+	// rune_index selects a Unicode code point in a string.
+	RuneIndex int64 `protobuf:"varint,7,opt,name=rune_index,json=runeIndex,proto3,oneof"`
+}
+
+func (*Step_Body_) isStep_Kind() {}
+
+func (*Step_Header) isStep_Kind() {}
+
+func (*Step_UrlParam) isStep_Kind() {}
+
+func (*Step_ArrayIndex) isStep_Kind() {}
+
+func (*Step_ObjectProperty) isStep_Kind() {}
+
+func (*Step_StringIndex) isStep_Kind() {}
+
+func (*Step_RuneIndex) isStep_Kind() {}
+
+// Body selects the body of a request.
+type Step_Body struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Step_Body) Reset() {
+	*x = Step_Body{}
+	mi := &file_apidiags_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Step_Body) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Step_Body) ProtoMessage() {}
+
+func (x *Step_Body) ProtoReflect() protoreflect.Message {
+	mi := &file_apidiags_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Step_Body.ProtoReflect.Descriptor instead.
+func (*Step_Body) Descriptor() ([]byte, []int) {
+	return file_apidiags_proto_rawDescGZIP(), []int{3, 0}
+}
+
+var File_apidiags_proto protoreflect.FileDescriptor
+
+const file_apidiags_proto_rawDesc = "" +
+	"\n" +
+	"\x0eapidiags.proto\x12\x17impractical.apidiags.v1\"T\n" +
+	"\vDiagnostics\x12E\n" +
+	"\vdiagnostics\x18\x01 \x03(\v2#.impractical.apidiags.v1.DiagnosticR\vdiagnostics\"\xbd\x01\n" +
+	"\n" +
+	"Diagnostic\x12\x1a\n" +
+	"\bseverity\x18\x01 \x01(\tR\bseverity\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\x124\n" +
+	"\x05paths\x18\x03 \x03(\v2\x1e.impractical.apidiags.v1.StepsR\x05paths\x12\x18\n" +
+	"\asummary\x18\x04 \x01(\tR\asummary\x12\x16\n" +
+	"\x06detail\x18\x05 \x01(\tR\x06detail\x12\x17\n" +
+	"\adoc_url\x18\x06 \x01(\tR\x06docUrl\"<\n" +
+	"\x05Steps\x123\n" +
+	"\x05steps\x18\x01 \x03(\v2\x1d.impractical.apidiags.v1.StepR\x05steps\"\x9d\x02\n" +
+	"\x04Step\x128\n" +
+	"\x04body\x18\x01 \x01(\v2\".impractical.apidiags.v1.Step.BodyH\x00R\x04body\x12\x18\n" +
+	"\x06header\x18\x02 \x01(\tH\x00R\x06header\x12\x1d\n" +
+	"\turl_param\x18\x03 \x01(\tH\x00R\burlParam\x12!\n" +
+	"\varray_index\x18\x04 \x01(\x03H\x00R\n" +
+	"arrayIndex\x12)\n" +
+	"\x0fobject_property\x18\x05 \x01(\tH\x00R\x0eobjectProperty\x12#\n" +
+	"\fstring_index\x18\x06 \x01(\x03H\x00R\vstringIndex\x12\x1f\n" +
+	"\n" +
+	"rune_index\x18\a \x01(\x03H\x00R\truneIndex\x1a\x06\n" +
+	"\x04BodyB\x06\n" +
+	"\x04kindB$Z\"impractical.co/apidiags/apidiagspbb\x06proto3"
+
+var (
+	file_apidiags_proto_rawDescOnce sync.Once
+	file_apidiags_proto_rawDescData []byte
+)
+
+func file_apidiags_proto_rawDescGZIP() []byte {
+	file_apidiags_proto_rawDescOnce.Do(func() {
+		file_apidiags_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_apidiags_proto_rawDesc), len(file_apidiags_proto_rawDesc)))
+	})
+	return file_apidiags_proto_rawDescData
+}
+
+var file_apidiags_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_apidiags_proto_goTypes = []any{
+	(*Diagnostics)(nil), // 0: impractical.apidiags.v1.Diagnostics
+	(*Diagnostic)(nil),  // 1: impractical.apidiags.v1.Diagnostic
+	(*Steps)(nil),       // 2: impractical.apidiags.v1.Steps
+	(*Step)(nil),        // 3: impractical.apidiags.v1.Step
+	(*Step_Body)(nil),   // 4: impractical.apidiags.v1.Step.Body
+}
+var file_apidiags_proto_depIdxs = []int32{
+	1, // 0: impractical.apidiags.v1.Diagnostics.diagnostics:type_name -> impractical.apidiags.v1.Diagnostic
+	2, // 1: impractical.apidiags.v1.Diagnostic.paths:type_name -> impractical.apidiags.v1.Steps
+	3, // 2: impractical.apidiags.v1.Steps.steps:type_name -> impractical.apidiags.v1.Step
+	4, // 3: impractical.apidiags.v1.Step.body:type_name -> impractical.apidiags.v1.Step.Body
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_apidiags_proto_init() }
+func file_apidiags_proto_init() {
+	if File_apidiags_proto != nil {
+		return
+	}
+	file_apidiags_proto_msgTypes[3].OneofWrappers = []any{
+		(*Step_Body_)(nil),
+		(*Step_Header)(nil),
+		(*Step_UrlParam)(nil),
+		(*Step_ArrayIndex)(nil),
+		(*Step_ObjectProperty)(nil),
+		(*Step_StringIndex)(nil),
+		(*Step_RuneIndex)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_apidiags_proto_rawDesc), len(file_apidiags_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_apidiags_proto_goTypes,
+		DependencyIndexes: file_apidiags_proto_depIdxs,
+		MessageInfos:      file_apidiags_proto_msgTypes,
+	}.Build()
+	File_apidiags_proto = out.File
+	file_apidiags_proto_goTypes = nil
+	file_apidiags_proto_depIdxs = nil
+}
@@ -0,0 +1,145 @@
+// Package apidiagspb contains protobuf messages mirroring apidiags
+// Diagnostics and Steps, and functions to convert between the two, so
+// Diagnostics can be carried in protobuf envelopes and google.protobuf.Any
+// details without being re-encoded as JSON.
+//
+// The messages are defined in apidiags.proto, under the
+// impractical.apidiags.v1 package.
+package apidiagspb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative apidiags.proto
+
+import (
+	"errors"
+	"fmt"
+
+	"impractical.co/apidiags"
+)
+
+// FromDiagnostics converts diags into a Diagnostics message.
+func FromDiagnostics(diags apidiags.Diagnostics) (*Diagnostics, error) {
+	results := &Diagnostics{Diagnostics: make([]*Diagnostic, 0, len(diags))}
+	for pos, diag := range diags {
+		result, err := FromDiagnostic(diag)
+		if err != nil {
+			return nil, fmt.Errorf("error converting diagnostic %d: %w", pos, err)
+		}
+		results.Diagnostics = append(results.Diagnostics, result)
+	}
+	return results, nil
+}
+
+// FromDiagnostic converts a single apidiags.Diagnostic into a Diagnostic
+// message.
+func FromDiagnostic(diag apidiags.Diagnostic) (*Diagnostic, error) {
+	diag = diag.RenderMessage(nil)
+	result := &Diagnostic{
+		Severity: string(diag.Severity),
+		Code:     string(diag.Code),
+		Summary:  diag.Summary,
+		Detail:   diag.Detail,
+		DocUrl:   diag.DocURL,
+	}
+	for pos, path := range diag.Paths {
+		steps, err := FromSteps(path)
+		if err != nil {
+			return nil, fmt.Errorf("error converting path %d: %w", pos, err)
+		}
+		result.Paths = append(result.Paths, steps)
+	}
+	return result, nil
+}
+
+// FromSteps converts steps into a Steps message.
+func FromSteps(steps apidiags.Steps) (*Steps, error) {
+	results := &Steps{Steps: make([]*Step, 0, len(steps))}
+	for pos, step := range steps {
+		result := &Step{}
+		switch value := step.(type) {
+		case apidiags.BodyStep:
+			result.Kind = &Step_Body_{Body: &Step_Body{}}
+		case apidiags.HeaderStep:
+			result.Kind = &Step_Header{Header: string(value)}
+		case apidiags.URLParamStep:
+			result.Kind = &Step_UrlParam{UrlParam: string(value)}
+		case apidiags.ArrayIndexStep:
+			result.Kind = &Step_ArrayIndex{ArrayIndex: int64(value)}
+		case apidiags.ObjectPropertyStep:
+			result.Kind = &Step_ObjectProperty{ObjectProperty: string(value)}
+		case apidiags.StringIndexStep:
+			result.Kind = &Step_StringIndex{StringIndex: int64(value)}
+		case apidiags.RuneIndexStep:
+			result.Kind = &Step_RuneIndex{RuneIndex: int64(value)}
+		default:
+			return nil, fmt.Errorf("unknown step type %T for step %d", step, pos)
+		}
+		results.Steps = append(results.Steps, result)
+	}
+	return results, nil
+}
+
+// ToDiagnostics converts a Diagnostics message into apidiags.Diagnostics,
+// reversing FromDiagnostics.
+func ToDiagnostics(msg *Diagnostics) (apidiags.Diagnostics, error) {
+	results := make(apidiags.Diagnostics, 0, len(msg.GetDiagnostics()))
+	for pos, diag := range msg.GetDiagnostics() {
+		result, err := ToDiagnostic(diag)
+		if err != nil {
+			return nil, fmt.Errorf("error converting diagnostic %d: %w", pos, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// ToDiagnostic converts a Diagnostic message into an apidiags.Diagnostic,
+// reversing FromDiagnostic.
+func ToDiagnostic(msg *Diagnostic) (apidiags.Diagnostic, error) {
+	result := apidiags.Diagnostic{
+		Severity: apidiags.Severity(msg.GetSeverity()),
+		Code:     apidiags.Code(msg.GetCode()),
+		Summary:  msg.GetSummary(),
+		Detail:   msg.GetDetail(),
+		DocURL:   msg.GetDocUrl(),
+	}
+	for pos, path := range msg.GetPaths() {
+		steps, err := ToSteps(path)
+		if err != nil {
+			return apidiags.Diagnostic{}, fmt.Errorf("error converting path %d: %w", pos, err)
+		}
+		result.Paths = append(result.Paths, steps)
+	}
+	return result, nil
+}
+
+// ToSteps converts a Steps message into apidiags.Steps, reversing FromSteps.
+// Steps without a kind set, usually because they were encoded by a newer
+// version of the schema, result in an error.
+func ToSteps(msg *Steps) (apidiags.Steps, error) {
+	results := make(apidiags.Steps, 0, len(msg.GetSteps()))
+	for pos, step := range msg.GetSteps() {
+		switch value := step.GetKind().(type) {
+		case *Step_Body_:
+			results = results.AddStep(apidiags.BodyStep{})
+		case *Step_Header:
+			results = results.AddStep(apidiags.HeaderStep(value.Header))
+		case *Step_UrlParam:
+			results = results.AddStep(apidiags.URLParamStep(value.UrlParam))
+		case *Step_ArrayIndex:
+			results = results.AddStep(apidiags.ArrayIndexStep(value.ArrayIndex))
+		case *Step_ObjectProperty:
+			results = results.AddStep(apidiags.ObjectPropertyStep(value.ObjectProperty))
+		case *Step_StringIndex:
+			results = results.AddStep(apidiags.StringIndexStep(value.StringIndex))
+		case *Step_RuneIndex:
+			results = results.AddStep(apidiags.RuneIndexStep(value.RuneIndex))
+		case nil:
+			return nil, fmt.Errorf("error converting step %d: %w", pos, errNoKind)
+		default:
+			return nil, fmt.Errorf("error converting step %d: unknown kind %T", pos, value)
+		}
+	}
+	return results, nil
+}
+
+var errNoKind = errors.New("no kind set")
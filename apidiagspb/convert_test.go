@@ -0,0 +1,101 @@
+package apidiagspb
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"impractical.co/apidiags"
+)
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags apidiags.Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"empty": {
+			diags: apidiags.Diagnostics{},
+		},
+		"all-fields": {
+			diags: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Summary:  "Name is required.",
+					Detail:   "Every item needs a name.",
+					DocURL:   "https://example.com/errors/missing",
+					Paths: []apidiags.Steps{apidiags.BodyPath().AddSteps(
+						apidiags.ObjectPropertyStep("items"),
+						apidiags.ArrayIndexStep(3),
+					)},
+				},
+			},
+		},
+		"every-step-kind": {
+			diags: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticWarning,
+					Code:     apidiags.CodeDeprecated,
+					Paths: []apidiags.Steps{
+						apidiags.HeaderPath("X-Legacy"),
+						apidiags.URLParamPath("id"),
+						apidiags.BodyPath().AddSteps(apidiags.ObjectPropertyStep("name"), apidiags.StringIndexStep(2)),
+						apidiags.BodyPath().AddSteps(apidiags.ObjectPropertyStep("name"), apidiags.RuneIndexStep(4)),
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			msg, err := FromDiagnostics(tc.diags)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			detail, err := anypb.New(msg)
+			if err != nil {
+				t.Fatalf("unexpected error wrapping in Any: %s", err)
+			}
+			encoded, err := proto.Marshal(detail)
+			if err != nil {
+				t.Fatalf("unexpected error encoding: %s", err)
+			}
+			var decodedAny anypb.Any
+			err = proto.Unmarshal(encoded, &decodedAny)
+			if err != nil {
+				t.Fatalf("unexpected error decoding: %s", err)
+			}
+			var decoded Diagnostics
+			err = decodedAny.UnmarshalTo(&decoded)
+			if err != nil {
+				t.Fatalf("unexpected error unwrapping Any: %s", err)
+			}
+			result, err := ToDiagnostics(&decoded)
+			if err != nil {
+				t.Fatalf("unexpected error converting: %s", err)
+			}
+			if diff := cmp.Diff(tc.diags, result); diff != "" {
+				t.Errorf("unexpected round trip results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestToStepsNoKind(t *testing.T) {
+	t.Parallel()
+
+	steps, err := ToSteps(&Steps{Steps: []*Step{{Kind: &Step_Body_{Body: &Step_Body{}}}, {}}})
+	if err == nil {
+		t.Fatalf("expected error, got %+v", steps)
+	}
+}
@@ -0,0 +1,11 @@
+module impractical.co/apidiags/apidiagspb
+
+go 1.23
+
+require (
+	github.com/google/go-cmp v0.7.0
+	google.golang.org/protobuf v1.36.12
+	impractical.co/apidiags v0.0.0
+)
+
+replace impractical.co/apidiags => ../
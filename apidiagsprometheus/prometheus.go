@@ -0,0 +1,86 @@
+// Package apidiagsprometheus implements apidiags.Metrics, counting the
+// Diagnostics a service sends as Prometheus metrics, so spikes of specific
+// Codes can be alerted on.
+//
+//	metrics := apidiagsprometheus.New()
+//	prometheus.MustRegister(metrics)
+//
+//	apidiags.WriteHTTP(w, diags, apidiags.WithMetrics(metrics, "POST /widgets"))
+package apidiagsprometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"impractical.co/apidiags"
+)
+
+// The labels of the counter Metrics exports.
+const (
+	CodeLabel     = "code"
+	SeverityLabel = "severity"
+	EndpointLabel = "endpoint"
+)
+
+// Option configures the counter Metrics exports.
+type Option func(*prometheus.CounterOpts)
+
+// WithNamespace sets the namespace of the counter's name.
+func WithNamespace(namespace string) Option {
+	return func(opts *prometheus.CounterOpts) {
+		opts.Namespace = namespace
+	}
+}
+
+// WithSubsystem sets the subsystem of the counter's name.
+func WithSubsystem(subsystem string) Option {
+	return func(opts *prometheus.CounterOpts) {
+		opts.Subsystem = subsystem
+	}
+}
+
+// WithConstLabels sets labels with fixed values on the counter, like the
+// name of the service.
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(opts *prometheus.CounterOpts) {
+		opts.ConstLabels = labels
+	}
+}
+
+// Metrics is an apidiags.Metrics that counts Diagnostics in a counter
+// named apidiags_diagnostics_total, labeled with each Diagnostic's Code and
+// Severity, and the endpoint that sent it. It's a prometheus.Collector, and
+// must be registered to be exported.
+type Metrics struct {
+	diagnostics *prometheus.CounterVec
+}
+
+// New returns a Metrics with no Diagnostics counted yet.
+func New(opts ...Option) *Metrics {
+	counterOpts := prometheus.CounterOpts{
+		Name: "apidiags_diagnostics_total",
+		Help: "The number of Diagnostics sent in responses, by code, severity, and endpoint.",
+	}
+	for _, opt := range opts {
+		opt(&counterOpts)
+	}
+	return &Metrics{
+		diagnostics: prometheus.NewCounterVec(counterOpts, []string{CodeLabel, SeverityLabel, EndpointLabel}),
+	}
+}
+
+// ObserveDiagnostics implements apidiags.Metrics, counting each of diags.
+func (m *Metrics) ObserveDiagnostics(endpoint string, diags apidiags.Diagnostics) {
+	for _, diag := range diags {
+		m.diagnostics.WithLabelValues(string(diag.Code), string(diag.Severity), endpoint).Inc()
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.diagnostics.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.diagnostics.Collect(ch)
+}
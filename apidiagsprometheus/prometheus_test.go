@@ -0,0 +1,42 @@
+package apidiagsprometheus
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"impractical.co/apidiags"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	metrics := New(WithNamespace("widgets"))
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(metrics)
+
+	diags := apidiags.Diagnostics{
+		{Severity: apidiags.DiagnosticError, Code: apidiags.CodeMissing},
+		{Severity: apidiags.DiagnosticError, Code: apidiags.CodeMissing},
+		{Severity: apidiags.DiagnosticWarning, Code: apidiags.CodeDeprecated},
+	}
+	err := apidiags.WriteHTTP(httptest.NewRecorder(), diags, apidiags.WithMetrics(metrics, "POST /widgets"))
+	if err != nil {
+		t.Fatalf("error writing response: %s", err)
+	}
+	metrics.ObserveDiagnostics("GET /widgets", diags[2:])
+
+	expected := `# HELP widgets_apidiags_diagnostics_total The number of Diagnostics sent in responses, by code, severity, and endpoint.
+# TYPE widgets_apidiags_diagnostics_total counter
+widgets_apidiags_diagnostics_total{code="deprecated",endpoint="GET /widgets",severity="warning"} 1
+widgets_apidiags_diagnostics_total{code="deprecated",endpoint="POST /widgets",severity="warning"} 1
+widgets_apidiags_diagnostics_total{code="missing",endpoint="POST /widgets",severity="error"} 2
+`
+	err = testutil.GatherAndCompare(registry, strings.NewReader(expected))
+	if err != nil {
+		t.Error(err)
+	}
+}
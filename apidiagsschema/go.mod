@@ -0,0 +1,11 @@
+module impractical.co/apidiags/apidiagsschema
+
+go 1.20
+
+require (
+	github.com/google/go-cmp v0.5.9
+	github.com/gorilla/schema v1.4.1
+	impractical.co/apidiags v0.0.0
+)
+
+replace impractical.co/apidiags => ../
@@ -0,0 +1,119 @@
+// Package apidiagsschema converts the errors returned by gorilla/schema when
+// decoding query strings and forms into apidiags Diagnostics, pointing to
+// the parameters or fields that couldn't be decoded.
+package apidiagsschema
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/gorilla/schema"
+
+	"impractical.co/apidiags"
+)
+
+// Option configures how decoding errors are converted into Diagnostics.
+type Option func(*config)
+
+type config struct {
+	root func(key string) apidiags.Steps
+}
+
+// WithRoot makes the Paths of the Diagnostics start with root(key), where
+// key is the key of the value that couldn't be decoded, like "items.0.name",
+// instead of pointing to the URL parameter named key. Use
+// apidiags.FormFieldPath for structs decoded from a request body's form.
+func WithRoot(root func(key string) apidiags.Steps) Option {
+	return func(c *config) {
+		c.root = root
+	}
+}
+
+// Decode decodes src into dst using decoder, converting any error it
+// returns with FromError, for use like
+//
+//	if diags := apidiagsschema.Decode(decoder, &params, r.URL.Query()); diags.HasErrors() {
+func Decode(decoder *schema.Decoder, dst any, src map[string][]string, opts ...Option) apidiags.Diagnostics {
+	err := decoder.Decode(dst, src)
+	if err == nil {
+		return nil
+	}
+	return FromError(err, opts...)
+}
+
+// FromError converts an error returned by a gorilla/schema Decoder into
+// Diagnostics, each with a Severity of apidiags.DiagnosticError.
+//
+// A schema.MultiError becomes one Diagnostic per key, sorted by key, as
+// gorilla/schema doesn't keep its errors in order. Each Diagnostic's Path is
+// apidiags.URLParamPath(key), unless WithRoot is used; keys like
+// "items.0.name" are kept whole, as that's how they appear in the query
+// string. When a parameter has more than one value, and it's the value at a
+// specific position that can't be decoded, the Path ends with an
+// apidiags.ArrayIndexStep for that position.
+//
+// schema.ConversionError uses apidiags.CodeInvalidFormat,
+// schema.UnknownKeyError uses apidiags.CodeInvalidValue, and
+// schema.EmptyFieldError uses apidiags.CodeMissing. Any other error, like
+// those gorilla/schema returns when dst isn't a valid struct, is converted
+// using apidiags.FromError.
+func FromError(err error, opts ...Option) apidiags.Diagnostics {
+	cfg := config{root: apidiags.URLParamPath}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	var multi schema.MultiError
+	if !errors.As(err, &multi) {
+		return fromKeyError(err, cfg)
+	}
+	keys := make([]string, 0, len(multi))
+	for key := range multi {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	results := make(apidiags.Diagnostics, 0, len(keys))
+	for _, key := range keys {
+		results = append(results, fromKeyError(multi[key], cfg)...)
+	}
+	return results
+}
+
+// fromKeyError converts err, one of the errors in a schema.MultiError, into
+// Diagnostics.
+func fromKeyError(err error, cfg config) apidiags.Diagnostics {
+	var conversionErr schema.ConversionError
+	var unknownErr schema.UnknownKeyError
+	var emptyErr schema.EmptyFieldError
+	switch {
+	case errors.As(err, &conversionErr):
+		path := cfg.root(conversionErr.Key)
+		if conversionErr.Index >= 0 {
+			path = path.AddStep(apidiags.ArrayIndexStep(conversionErr.Index))
+		}
+		diag := apidiags.Diagnostic{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeInvalidFormat,
+			Paths:    []apidiags.Steps{path},
+		}
+		if conversionErr.Err != nil {
+			diag.Detail = conversionErr.Err.Error()
+		} else if conversionErr.Type != nil {
+			diag.Detail = "Expected " + conversionErr.Type.String() + "."
+		}
+		return apidiags.Diagnostics{diag}
+	case errors.As(err, &unknownErr):
+		return apidiags.Diagnostics{{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeInvalidValue,
+			Paths:    []apidiags.Steps{cfg.root(unknownErr.Key)},
+			Detail:   "Unknown parameter.",
+		}}
+	case errors.As(err, &emptyErr):
+		return apidiags.Diagnostics{{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeMissing,
+			Paths:    []apidiags.Steps{cfg.root(emptyErr.Key)},
+		}}
+	}
+	return apidiags.FromError(err)
+}
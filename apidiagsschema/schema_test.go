@@ -0,0 +1,134 @@
+package apidiagsschema
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/schema"
+
+	"impractical.co/apidiags"
+)
+
+type filter struct {
+	Name  string `schema:"name"`
+	Value string `schema:"value"`
+}
+
+type listParams struct {
+	Limit   int       `schema:"limit,required"`
+	IDs     []int     `schema:"id"`
+	Before  time.Time `schema:"before"`
+	Filters []filter  `schema:"filter"`
+}
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		query    string
+		opts     []Option
+		expected apidiags.Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"valid": {
+			query: "limit=10&id=1&id=2&before=2023-01-02T03:04:05Z&filter.0.name=color",
+		},
+		"invalid-value": {
+			query: "limit=ten",
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidFormat,
+				Paths:    []apidiags.Steps{apidiags.URLParamPath("limit")},
+				Detail:   "Expected int.",
+			}},
+		},
+		"invalid-repeated-value": {
+			query: "limit=10&id=1&id=two",
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidFormat,
+				Paths:    []apidiags.Steps{apidiags.URLParamPath("id").AddStep(apidiags.ArrayIndexStep(1))},
+				Detail:   "Expected int.",
+			}},
+		},
+		"unmarshal-text": {
+			query: "limit=10&before=yesterday",
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidFormat,
+				Paths:    []apidiags.Steps{apidiags.URLParamPath("before")},
+				Detail:   `parsing time "yesterday" as "2006-01-02T15:04:05Z07:00": cannot parse "yesterday" as "2006"`,
+			}},
+		},
+		"many": {
+			query: "id=one&sort=name&filter.0.value=blue",
+			expected: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeInvalidFormat,
+					Paths:    []apidiags.Steps{apidiags.URLParamPath("id").AddStep(apidiags.ArrayIndexStep(0))},
+					Detail:   "Expected int.",
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Paths:    []apidiags.Steps{apidiags.URLParamPath("limit")},
+				},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeInvalidValue,
+					Paths:    []apidiags.Steps{apidiags.URLParamPath("sort")},
+					Detail:   "Unknown parameter.",
+				},
+			},
+		},
+		"form": {
+			query: "limit=ten",
+			opts:  []Option{WithRoot(apidiags.FormFieldPath)},
+			expected: apidiags.Diagnostics{{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeInvalidFormat,
+				Paths:    []apidiags.Steps{apidiags.FormFieldPath("limit")},
+				Detail:   "Expected int.",
+			}},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			query, err := url.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatalf("error parsing query: %s", err)
+			}
+			var params listParams
+			got := Decode(schema.NewDecoder(), &params, query, tc.opts...)
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestFromErrorOther(t *testing.T) {
+	t.Parallel()
+
+	var params listParams
+	got := FromError(schema.NewDecoder().Decode(params, url.Values{}))
+	expected := apidiags.Diagnostics{{
+		Severity: apidiags.DiagnosticError,
+		Code:     apidiags.CodeActOfGod,
+	}}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	if got := FromError(errors.New("oops")); len(got) != 1 {
+		t.Errorf("expected 1 Diagnostic, got %d", len(got))
+	}
+}
@@ -0,0 +1,16 @@
+module impractical.co/apidiags/apidiagssentry
+
+go 1.25.0
+
+require (
+	github.com/getsentry/sentry-go v0.49.0
+	github.com/google/go-cmp v0.7.0
+	impractical.co/apidiags v0.0.0
+)
+
+require (
+	golang.org/x/sys v0.46.0 // indirect
+	golang.org/x/text v0.39.0 // indirect
+)
+
+replace impractical.co/apidiags => ../
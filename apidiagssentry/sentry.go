@@ -0,0 +1,162 @@
+// Package apidiagssentry reports apidiags Diagnostics to Sentry, so the
+// errors a service returns are aggregated alongside its other failures.
+package apidiagssentry
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+
+	"impractical.co/apidiags"
+)
+
+// The keys of the contexts and tags Event sets.
+const (
+	// ContextKey is the key of the context holding the Diagnostic's
+	// Severity, Code, Paths, Summary, Detail, DocURL, and trace.
+	ContextKey = "apidiags"
+
+	// DebugContextKey is the key of the context holding the stack and
+	// details of the Diagnostic's Debug, if it has one.
+	DebugContextKey = "apidiags.debug"
+
+	// CodeTag is the tag holding the Diagnostic's Code.
+	CodeTag = "apidiags.code"
+
+	// SeverityTag is the tag holding the Diagnostic's Severity.
+	SeverityTag = "apidiags.severity"
+)
+
+// Fingerprint returns the fingerprint Sentry should group diag's events by:
+// its Code, followed by each of its Paths rendered using
+// apidiags.Steps.String. Diagnostics with the same Code at the same Paths
+// are grouped as the same issue, no matter what their Summary or Detail say.
+func Fingerprint(diag apidiags.Diagnostic) []string {
+	results := make([]string, 0, len(diag.Paths)+1)
+	results = append(results, string(diag.Code))
+	for _, path := range diag.Paths {
+		results = append(results, path.String())
+	}
+	return results
+}
+
+// Event converts diag into a Sentry event, with diag's Message as its
+// message, Fingerprint as its fingerprint, and its Code and Severity as
+// tags. The rest of diag is included in a context under ContextKey.
+//
+// If diag has a Debug, its Error is included as the event's exception, and
+// its Stack and Details are included in a context under DebugContextKey.
+// Sentry events aren't shown to API callers, so they always include the
+// Debug, whether or not the response did.
+func Event(diag apidiags.Diagnostic) *sentry.Event {
+	diag = diag.RenderMessage(nil)
+	event := sentry.NewEvent()
+	event.Level = sentry.LevelError
+	if diag.Severity == apidiags.DiagnosticWarning {
+		event.Level = sentry.LevelWarning
+	}
+	event.Message = diag.Message()
+	event.Fingerprint = Fingerprint(diag)
+	event.Tags[CodeTag] = string(diag.Code)
+	event.Tags[SeverityTag] = string(diag.Severity)
+
+	diagContext := sentry.Context{
+		"severity": string(diag.Severity),
+		"code":     string(diag.Code),
+	}
+	if len(diag.Paths) > 0 {
+		paths := make([]string, 0, len(diag.Paths))
+		for _, path := range diag.Paths {
+			paths = append(paths, path.String())
+		}
+		diagContext["paths"] = paths
+	}
+	if diag.Summary != "" {
+		diagContext["summary"] = diag.Summary
+	}
+	if diag.Detail != "" {
+		diagContext["detail"] = diag.Detail
+	}
+	if diag.DocURL != "" {
+		diagContext["doc_url"] = diag.DocURL
+	}
+	if traceID, spanID, err := diag.Trace(); err == nil {
+		if traceID != "" {
+			diagContext["trace_id"] = traceID
+		}
+		if spanID != "" {
+			diagContext["span_id"] = spanID
+		}
+	}
+	event.Contexts[ContextKey] = diagContext
+
+	if diag.Debug != nil {
+		if diag.Debug.Error != "" {
+			event.Exception = []sentry.Exception{{
+				Type:  string(diag.Code),
+				Value: diag.Debug.Error,
+			}}
+		}
+		debugContext := sentry.Context{}
+		if diag.Debug.Stack != "" {
+			debugContext["stack"] = diag.Debug.Stack
+		}
+		for key, value := range diag.Debug.Details {
+			debugContext[key] = value
+		}
+		if len(debugContext) > 0 {
+			event.Contexts[DebugContextKey] = debugContext
+		}
+	}
+	return event
+}
+
+// Events converts the Diagnostics in diags with a Severity of
+// apidiags.DiagnosticError into Sentry events, using Event. Warnings are
+// left out; they describe requests the service handled, not failures.
+func Events(diags apidiags.Diagnostics) []*sentry.Event {
+	var results []*sentry.Event
+	for _, diag := range diags {
+		if diag.Severity != apidiags.DiagnosticError {
+			continue
+		}
+		results = append(results, Event(diag))
+	}
+	return results
+}
+
+// Capture sends the events Events returns for diags to hub, and returns the
+// IDs of the events hub accepted. If hub is nil, sentry.CurrentHub is used.
+func Capture(hub *sentry.Hub, diags apidiags.Diagnostics) []sentry.EventID {
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	var results []sentry.EventID
+	for _, event := range Events(diags) {
+		if id := hub.CaptureEvent(event); id != nil {
+			results = append(results, *id)
+		}
+	}
+	return results
+}
+
+// Hook returns an apidiags.DiagnosticHook that captures every error as it's
+// written, using the hub sentry.GetHubFromContext returns for the hook's
+// context, or hub if the context doesn't have one. If hub is nil,
+// sentry.CurrentHub is used. Register it with apidiags.OnDiagnostic.
+//
+// Hooks run before the Debug is removed from Diagnostics that won't include
+// it, so the events include the Debug even when the response doesn't.
+func Hook(hub *sentry.Hub) apidiags.DiagnosticHook {
+	return func(ctx context.Context, event apidiags.HookEvent, diag apidiags.Diagnostic) apidiags.Diagnostic {
+		if event != apidiags.HookWritten || diag.Severity != apidiags.DiagnosticError {
+			return diag
+		}
+		target := sentry.GetHubFromContext(ctx)
+		if target == nil {
+			target = hub
+		}
+		Capture(target, apidiags.Diagnostics{diag})
+		return diag
+	}
+}
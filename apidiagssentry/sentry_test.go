@@ -0,0 +1,216 @@
+package apidiagssentry
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"impractical.co/apidiags"
+)
+
+func TestEvent(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diag     apidiags.Diagnostic
+		expected *sentry.Event
+	}
+
+	name := apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name"))
+	id := apidiags.URLParamPath("id")
+
+	cases := map[string]testCase{
+		"minimal": {
+			diag: apidiags.Diagnostic{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeActOfGod,
+			},
+			expected: &sentry.Event{
+				Level:       sentry.LevelError,
+				Message:     "act_of_god",
+				Fingerprint: []string{"act_of_god"},
+				Tags: map[string]string{
+					CodeTag:     "act_of_god",
+					SeverityTag: "error",
+				},
+				Contexts: map[string]sentry.Context{
+					ContextKey: {
+						"severity": "error",
+						"code":     "act_of_god",
+					},
+				},
+			},
+		},
+		"full": {
+			diag: apidiags.Diagnostic{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeConflict,
+				Paths:    []apidiags.Steps{name, id},
+				Summary:  "That name is taken.",
+				Detail:   "Choose another name.",
+				DocURL:   "https://example.com/docs/conflict",
+			}.WithTrace("4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"),
+			expected: &sentry.Event{
+				Level:       sentry.LevelError,
+				Message:     "That name is taken.",
+				Fingerprint: []string{"conflict", "body.name", `url_param("id")`},
+				Tags: map[string]string{
+					CodeTag:     "conflict",
+					SeverityTag: "error",
+				},
+				Contexts: map[string]sentry.Context{
+					ContextKey: {
+						"severity": "error",
+						"code":     "conflict",
+						"paths":    []string{"body.name", `url_param("id")`},
+						"summary":  "That name is taken.",
+						"detail":   "Choose another name.",
+						"doc_url":  "https://example.com/docs/conflict",
+						"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+						"span_id":  "00f067aa0ba902b7",
+					},
+				},
+			},
+		},
+		"debug": {
+			diag: apidiags.Diagnostic{
+				Severity: apidiags.DiagnosticError,
+				Code:     apidiags.CodeActOfGod,
+				Debug: &apidiags.DebugInfo{
+					Error:   "dial tcp: connection refused",
+					Stack:   "goroutine 1 [running]:",
+					Details: map[string]string{"upstream": "db-1"},
+				},
+			},
+			expected: &sentry.Event{
+				Level:       sentry.LevelError,
+				Message:     "act_of_god",
+				Fingerprint: []string{"act_of_god"},
+				Tags: map[string]string{
+					CodeTag:     "act_of_god",
+					SeverityTag: "error",
+				},
+				Contexts: map[string]sentry.Context{
+					ContextKey: {
+						"severity": "error",
+						"code":     "act_of_god",
+					},
+					DebugContextKey: {
+						"stack":    "goroutine 1 [running]:",
+						"upstream": "db-1",
+					},
+				},
+				Exception: []sentry.Exception{{
+					Type:  "act_of_god",
+					Value: "dial tcp: connection refused",
+				}},
+			},
+		},
+		"warning": {
+			diag: apidiags.Diagnostic{
+				Severity: apidiags.DiagnosticWarning,
+				Code:     apidiags.CodeDeprecated,
+			},
+			expected: &sentry.Event{
+				Level:       sentry.LevelWarning,
+				Message:     "deprecated",
+				Fingerprint: []string{"deprecated"},
+				Tags: map[string]string{
+					CodeTag:     "deprecated",
+					SeverityTag: "warning",
+				},
+				Contexts: map[string]sentry.Context{
+					ContextKey: {
+						"severity": "warning",
+						"code":     "deprecated",
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := Event(tc.diag)
+			if diff := cmp.Diff(tc.expected, got, cmpopts.EquateEmpty(), cmpopts.IgnoreUnexported(sentry.Event{})); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func newHub(t *testing.T) (*sentry.Hub, *sentry.MockTransport) {
+	t.Helper()
+	transport := &sentry.MockTransport{}
+	client, err := sentry.NewClient(sentry.ClientOptions{Transport: transport})
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	return sentry.NewHub(client, sentry.NewScope()), transport
+}
+
+func fingerprints(events []*sentry.Event) [][]string {
+	results := make([][]string, 0, len(events))
+	for _, event := range events {
+		results = append(results, event.Fingerprint)
+	}
+	return results
+}
+
+func TestCapture(t *testing.T) {
+	t.Parallel()
+
+	hub, transport := newHub(t)
+	ids := Capture(hub, apidiags.Diagnostics{
+		{Severity: apidiags.DiagnosticWarning, Code: apidiags.CodeDeprecated},
+		{Severity: apidiags.DiagnosticError, Code: apidiags.CodeMissing, Paths: []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name"))}},
+		{Severity: apidiags.DiagnosticError, Code: apidiags.CodeActOfGod},
+	})
+	if len(ids) != 2 {
+		t.Errorf("expected 2 event IDs, got %d", len(ids))
+	}
+	expected := [][]string{{"missing", "body.name"}, {"act_of_god"}}
+	if diff := cmp.Diff(expected, fingerprints(transport.Events())); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+}
+
+func TestHook(t *testing.T) {
+	t.Parallel()
+
+	fallback, fallbackTransport := newHub(t)
+	hub, transport := newHub(t)
+	unregister := apidiags.OnDiagnostic(Hook(fallback))
+	defer unregister()
+
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	diags := apidiags.Diagnostics{
+		{Severity: apidiags.DiagnosticWarning, Code: apidiags.CodeDeprecated},
+		{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeActOfGod,
+			Debug:    &apidiags.DebugInfo{Error: "connection refused"},
+		},
+	}
+	if err := apidiags.WriteHTTP(httptest.NewRecorder(), diags, apidiags.WithContext(ctx)); err != nil {
+		t.Fatalf("error writing diagnostics: %s", err)
+	}
+
+	if diff := cmp.Diff([][]string{{"act_of_god"}}, fingerprints(transport.Events())); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	events := transport.Events()
+	if len(events) == 1 && len(events[0].Exception) != 1 {
+		t.Errorf("expected the event to include the debug error, got %+v", events[0].Exception)
+	}
+	if got := fallbackTransport.Events(); len(got) != 0 {
+		t.Errorf("expected no events to be sent to the fallback hub, got %d", len(got))
+	}
+}
@@ -0,0 +1,202 @@
+// Package apidiagstest provides helpers for testing HTTP handlers that
+// respond with apidiags Diagnostics, running them with net/http/httptest and
+// asserting on the Diagnostics in their responses.
+//
+//	apidiagstest.Do(t, handler, httptest.NewRequest(http.MethodPost, "/widgets", body)).
+//		Status(http.StatusBadRequest).
+//		Errors(1).
+//		Has(apidiags.CodeMissing, "body.name")
+package apidiagstest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"impractical.co/apidiags"
+)
+
+// Result is the response of a handler run by Do, with methods asserting on
+// it. Failed assertions are reported with t.Errorf, listing the Diagnostics
+// in the response, and each assertion returns the Result, so they can be
+// chained.
+type Result struct {
+	t testing.TB
+
+	// Response is the response written by the handler. Its body has
+	// already been read.
+	Response *http.Response
+
+	// Diagnostics are the Diagnostics in the response, as parsed by
+	// apidiags.ParseResponse.
+	Diagnostics apidiags.Diagnostics
+}
+
+// Do runs handler with r using an httptest.ResponseRecorder, and parses the
+// Diagnostics in its response with apidiags.ParseResponse. If the response
+// can't be parsed, the test fails immediately.
+func Do(t testing.TB, handler http.Handler, r *http.Request) *Result {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	resp := w.Result()
+	diags, err := apidiags.ParseResponse(resp)
+	if err != nil {
+		t.Fatalf("error parsing Diagnostics from response: %s", err)
+		return &Result{t: t, Response: resp}
+	}
+	return &Result{t: t, Response: resp, Diagnostics: diags}
+}
+
+// Status asserts that the response has the status code status.
+func (r *Result) Status(status int) *Result {
+	r.t.Helper()
+	if r.Response.StatusCode != status {
+		r.t.Errorf("expected status %d, got %d%s", status, r.Response.StatusCode, r.describe())
+	}
+	return r
+}
+
+// Errors asserts that the response has n Diagnostics with a Severity of
+// apidiags.DiagnosticError.
+func (r *Result) Errors(n int) *Result {
+	r.t.Helper()
+	if count := r.count(apidiags.DiagnosticError); count != n {
+		r.t.Errorf("expected %d errors, got %d%s", n, count, r.describe())
+	}
+	return r
+}
+
+// Warnings asserts that the response has n Diagnostics with a Severity of
+// apidiags.DiagnosticWarning.
+func (r *Result) Warnings(n int) *Result {
+	r.t.Helper()
+	if count := r.count(apidiags.DiagnosticWarning); count != n {
+		r.t.Errorf("expected %d warnings, got %d%s", n, count, r.describe())
+	}
+	return r
+}
+
+// None asserts that the response has no Diagnostics.
+func (r *Result) None() *Result {
+	r.t.Helper()
+	if len(r.Diagnostics) > 0 {
+		r.t.Errorf("expected no Diagnostics, got %d%s", len(r.Diagnostics), r.describe())
+	}
+	return r
+}
+
+// Has asserts that the response has a Diagnostic with a Code of code. If
+// any patterns are passed, the Diagnostic must also have a Path matching
+// each of them; see apidiags.Pattern for their syntax. Patterns that can't
+// be parsed fail the test immediately.
+func (r *Result) Has(code apidiags.Code, patterns ...string) *Result {
+	r.t.Helper()
+	compiled := make([]apidiags.Pattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		p, err := apidiags.CompilePattern(pattern)
+		if err != nil {
+			r.t.Fatalf("error compiling pattern %q: %s", pattern, err)
+			return r
+		}
+		compiled = append(compiled, p)
+	}
+	for _, diag := range r.Diagnostics {
+		if diag.Code == code && matchesAll(diag, compiled) {
+			return r
+		}
+	}
+	if len(patterns) < 1 {
+		r.t.Errorf("expected a Diagnostic with code %q%s", code, r.describe())
+	} else {
+		r.t.Errorf("expected a Diagnostic with code %q at %s%s", code, strings.Join(patterns, ", "), r.describe())
+	}
+	return r
+}
+
+// Lacks asserts that the response has no Diagnostic with a Code of code.
+func (r *Result) Lacks(code apidiags.Code) *Result {
+	r.t.Helper()
+	for _, diag := range r.Diagnostics {
+		if diag.Code == code {
+			r.t.Errorf("expected no Diagnostics with code %q%s", code, r.describe())
+			break
+		}
+	}
+	return r
+}
+
+// Equal asserts that the response's Diagnostics are exactly expected, in
+// order.
+func (r *Result) Equal(expected apidiags.Diagnostics) *Result {
+	r.t.Helper()
+	if len(expected) == 0 && len(r.Diagnostics) == 0 {
+		return r
+	}
+	if !reflect.DeepEqual(expected, r.Diagnostics) {
+		r.t.Errorf("expected Diagnostics:\n%s\ngot:\n%s", Format(expected), Format(r.Diagnostics))
+	}
+	return r
+}
+
+func (r *Result) count(severity apidiags.Severity) int {
+	var count int
+	for _, diag := range r.Diagnostics {
+		if diag.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// describe lists the response's Diagnostics, for failure messages.
+func (r *Result) describe() string {
+	if len(r.Diagnostics) < 1 {
+		return "; the response had no Diagnostics"
+	}
+	return "; the response's Diagnostics were:\n" + Format(r.Diagnostics)
+}
+
+func matchesAll(diag apidiags.Diagnostic, patterns []apidiags.Pattern) bool {
+	for _, pattern := range patterns {
+		var matched bool
+		for _, path := range diag.Paths {
+			if pattern.Match(path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// Format renders diags as an indented list, one Diagnostic per line, like
+// `  error missing at body.name: The name is required.`, for readable test
+// failures.
+func Format(diags apidiags.Diagnostics) string {
+	if len(diags) < 1 {
+		return "  (none)"
+	}
+	lines := make([]string, 0, len(diags))
+	for _, diag := range diags {
+		line := fmt.Sprintf("  %s %s", diag.Severity, diag.Code)
+		if len(diag.Paths) > 0 {
+			paths := make([]string, 0, len(diag.Paths))
+			for _, path := range diag.Paths {
+				paths = append(paths, path.String())
+			}
+			line += " at " + strings.Join(paths, ", ")
+		}
+		if diag.Summary != "" {
+			line += ": " + diag.Summary
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
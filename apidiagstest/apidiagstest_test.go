@@ -0,0 +1,127 @@
+package apidiagstest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"impractical.co/apidiags"
+)
+
+// recordingT is a testing.TB that records failures instead of reporting
+// them.
+type recordingT struct {
+	testing.TB
+	failures []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...any) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+func (t *recordingT) Fatalf(format string, args ...any) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+var testHandler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+	_ = apidiags.WriteHTTP(w, apidiags.Diagnostics{
+		{
+			Severity: apidiags.DiagnosticError,
+			Code:     apidiags.CodeMissing,
+			Paths:    []apidiags.Steps{apidiags.BodyPath().AddSteps(apidiags.ObjectPropertyStep("items"), apidiags.ArrayIndexStep(2), apidiags.ObjectPropertyStep("name"))},
+			Summary:  "The name is required.",
+		},
+		{
+			Severity: apidiags.DiagnosticWarning,
+			Code:     apidiags.CodeDeprecated,
+			Paths:    []apidiags.Steps{apidiags.URLParamPath("mode")},
+		},
+	})
+})
+
+func TestResult(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		assert   func(*Result)
+		failures []string
+	}
+
+	cases := map[string]testCase{
+		"passing": {
+			assert: func(r *Result) {
+				r.Status(http.StatusBadRequest).
+					Errors(1).
+					Warnings(1).
+					Has(apidiags.CodeMissing).
+					Has(apidiags.CodeMissing, "body.items[*].name").
+					Has(apidiags.CodeDeprecated, `url_param("mode")`).
+					Lacks(apidiags.CodeNotFound)
+			},
+		},
+		"status": {
+			assert: func(r *Result) { r.Status(http.StatusOK) },
+			failures: []string{"expected status 200, got 400; the response's Diagnostics were:\n" +
+				"  error missing at body.items[2].name: The name is required.\n" +
+				`  warning deprecated at url_param("mode")`},
+		},
+		"counts": {
+			assert: func(r *Result) { r.Errors(2).Warnings(0) },
+			failures: []string{
+				"expected 2 errors, got 1",
+				"expected 0 warnings, got 1",
+			},
+		},
+		"has-path": {
+			assert:   func(r *Result) { r.Has(apidiags.CodeMissing, "body.items[*].id") },
+			failures: []string{`expected a Diagnostic with code "missing" at body.items[*].id`},
+		},
+		"has-code": {
+			assert:   func(r *Result) { r.Has(apidiags.CodeConflict) },
+			failures: []string{`expected a Diagnostic with code "conflict"`},
+		},
+		"lacks": {
+			assert:   func(r *Result) { r.Lacks(apidiags.CodeDeprecated) },
+			failures: []string{`expected no Diagnostics with code "deprecated"`},
+		},
+		"none": {
+			assert:   func(r *Result) { r.None() },
+			failures: []string{"expected no Diagnostics, got 2"},
+		},
+		"equal": {
+			assert: func(r *Result) {
+				r.Equal(apidiags.Diagnostics{{Severity: apidiags.DiagnosticError, Code: apidiags.CodeMissing}})
+			},
+			failures: []string{"expected Diagnostics:\n  error missing\ngot:\n" +
+				"  error missing at body.items[2].name: The name is required.\n" +
+				`  warning deprecated at url_param("mode")`},
+		},
+		"invalid-pattern": {
+			assert:   func(r *Result) { r.Has(apidiags.CodeMissing, "body[") },
+			failures: []string{`error compiling pattern "body["`},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rt := &recordingT{TB: t}
+			tc.assert(Do(rt, testHandler, httptest.NewRequest(http.MethodPost, "/widgets", nil)))
+			if len(rt.failures) != len(tc.failures) {
+				t.Fatalf("expected %d failures, got %d: %q", len(tc.failures), len(rt.failures), rt.failures)
+			}
+			for pos, failure := range rt.failures {
+				if !strings.HasPrefix(failure, tc.failures[pos]) {
+					t.Errorf("expected failure %d to start with %q, got %q", pos, tc.failures[pos], failure)
+				}
+			}
+		})
+	}
+}
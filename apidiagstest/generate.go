@@ -0,0 +1,279 @@
+package apidiagstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"impractical.co/apidiags"
+)
+
+// The names Generator uses for the parts of requests it generates Paths
+// to.
+var (
+	generatedProperties = []string{"name", "email", "items", "address", "zip", "tags", "labels", "quantity", "owner", "description"}
+	generatedHeaders    = []string{"Authorization", "Idempotency-Key", "If-Match", "X-Request-ID", "Content-Type", "Accept-Language"}
+	generatedParams     = []string{"page", "limit", "sort", "filter", "cursor", "id"}
+)
+
+// adversarialText are strings that commonly break renderers: markup, quotes,
+// control characters, combining characters, right-to-left text, emoji, and
+// text that's long or empty.
+var adversarialText = []string{
+	`<script>alert("x")</script>`,
+	`"quoted" 'name'`,
+	"tab\tnew\nline",
+	"é́́",
+	"‮right-to-left‬",
+	"שם",
+	"名前",
+	"🧑‍🚀🏳️‍🌈",
+	"{{.Name}} ${name} %s %!",
+	strings.Repeat("long", 64),
+	"",
+	" ",
+}
+
+// GeneratorOption configures a Generator.
+type GeneratorOption func(*Generator)
+
+// WithCodes makes a Generator generate Diagnostics with the Codes described
+// by infos, instead of apidiags.BuiltinCodes. Codes without a Status are
+// used for warnings, and the rest for errors, like
+// apidiags.CodeInfo.ExampleDiagnostic.
+func WithCodes(infos ...apidiags.CodeInfo) GeneratorOption {
+	return func(g *Generator) {
+		g.codes = infos
+	}
+}
+
+// WithAdversarialText makes a Generator use text that commonly breaks
+// renderers, like markup, control characters, right-to-left text, and
+// emoji, for the object properties, headers, URL parameters, and messages
+// of the Diagnostics it generates.
+func WithAdversarialText() GeneratorOption {
+	return func(g *Generator) {
+		g.adversarial = true
+	}
+}
+
+// Generator generates realistic Diagnostics, for fuzzing code that renders
+// them and exercising UI error states without hand-writing fixtures. Its
+// output is determined entirely by its seed, so tests using it are
+// reproducible. A Generator isn't safe for concurrent use.
+type Generator struct {
+	rand        *rand.Rand
+	codes       []apidiags.CodeInfo
+	adversarial bool
+}
+
+// NewGenerator returns a Generator whose output is determined by seed and
+// opts.
+func NewGenerator(seed int64, opts ...GeneratorOption) *Generator {
+	g := &Generator{
+		rand:  rand.New(rand.NewSource(seed)),
+		codes: apidiags.BuiltinCodes(),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Diagnostics returns n generated Diagnostics.
+func (g *Generator) Diagnostics(n int) apidiags.Diagnostics {
+	results := make(apidiags.Diagnostics, 0, n)
+	for i := 0; i < n; i++ {
+		results = append(results, g.Diagnostic())
+	}
+	return results
+}
+
+// Diagnostic returns a generated Diagnostic, with a random Code.
+func (g *Generator) Diagnostic() apidiags.Diagnostic {
+	if len(g.codes) < 1 {
+		return g.diagnostic(apidiags.CodeInfo{Code: apidiags.CodeInvalidValue, Status: 400})
+	}
+	return g.diagnostic(g.codes[g.rand.Intn(len(g.codes))])
+}
+
+// Exhaustive returns a generated Diagnostic with each of the Generator's
+// Codes, in order, whose Paths between them use every kind of Step.
+func (g *Generator) Exhaustive() apidiags.Diagnostics {
+	results := make(apidiags.Diagnostics, 0, len(g.codes))
+	for _, info := range g.codes {
+		results = append(results, g.diagnostic(info))
+	}
+	if len(results) < 1 {
+		results = append(results, g.diagnostic(apidiags.CodeInfo{Code: apidiags.CodeInvalidValue, Status: 400}))
+	}
+	// make sure every kind of Step is used at least once
+	results[0].Paths = append(results[0].Paths,
+		apidiags.BodyPath().AddSteps(
+			apidiags.ObjectPropertyStep(g.property()),
+			apidiags.ArrayIndexStep(g.rand.Intn(10)),
+			apidiags.ObjectPropertyStep(g.property()),
+			apidiags.StringIndexStep(g.rand.Intn(20)),
+		),
+		apidiags.HeaderPath(g.header()).AddStep(apidiags.RuneIndexStep(g.rand.Intn(20))),
+		apidiags.URLParamPath(g.param()),
+	)
+	return results
+}
+
+// Path returns a generated Path. Most point into the body, through object
+// properties and array elements, and the rest to headers or URL
+// parameters. Some end by pointing to a single character.
+func (g *Generator) Path() apidiags.Steps {
+	var path apidiags.Steps
+	switch roll := g.rand.Intn(20); {
+	case roll < 14:
+		path = apidiags.BodyPath()
+		for depth := 1 + g.rand.Intn(4); depth > 0; depth-- {
+			if len(path) > 1 && g.rand.Intn(3) == 0 {
+				path = path.AddStep(apidiags.ArrayIndexStep(g.rand.Intn(10)))
+				continue
+			}
+			path = path.AddStep(apidiags.ObjectPropertyStep(g.property()))
+		}
+	case roll < 17:
+		path = apidiags.HeaderPath(g.header())
+	default:
+		path = apidiags.URLParamPath(g.param())
+	}
+	switch g.rand.Intn(10) {
+	case 0:
+		path = path.AddStep(apidiags.StringIndexStep(g.rand.Intn(40)))
+	case 1:
+		path = path.AddStep(apidiags.RuneIndexStep(g.rand.Intn(40)))
+	}
+	return path
+}
+
+// diagnostic returns a generated Diagnostic with the Code info describes.
+func (g *Generator) diagnostic(info apidiags.CodeInfo) apidiags.Diagnostic {
+	diag := apidiags.Diagnostic{
+		Severity: apidiags.DiagnosticError,
+		Code:     info.Code,
+		DocURL:   info.DocURL,
+	}
+	if info.Status == 0 {
+		diag.Severity = apidiags.DiagnosticWarning
+	}
+	if info.Code != apidiags.CodeActOfGod && info.Code != apidiags.CodeAccessDenied {
+		for n := 1 + g.rand.Intn(3)/2; n > 0; n-- {
+			diag.Paths = append(diag.Paths, g.Path())
+		}
+	}
+	field := "The request"
+	if len(diag.Paths) > 0 {
+		field = fieldName(diag.Paths[0])
+	}
+	diag.Summary = g.summary(info, field)
+	if g.rand.Intn(2) == 0 {
+		diag.Detail = info.Description
+		if g.adversarial {
+			diag.Detail = g.text()
+		}
+	}
+	if diag.DocURL == "" && g.rand.Intn(3) == 0 {
+		diag.DocURL = "https://example.com/docs/errors/" + string(info.Code)
+	}
+	switch info.Code {
+	case apidiags.CodeOverflow:
+		diag.Extensions = map[string]json.RawMessage{apidiags.MaxMember: json.RawMessage(strconv.Itoa(10 * (1 + g.rand.Intn(10))))}
+	case apidiags.CodeInsufficient:
+		diag.Extensions = map[string]json.RawMessage{apidiags.MinMember: json.RawMessage(strconv.Itoa(1 + g.rand.Intn(10)))}
+	case apidiags.CodeActOfGod:
+		diag = diag.WithRetryAfter(time.Duration(1+g.rand.Intn(30)) * time.Second)
+	}
+	return diag
+}
+
+// summary returns a generated Summary for a Diagnostic with the Code info
+// describes, about field.
+func (g *Generator) summary(info apidiags.CodeInfo, field string) string {
+	if g.adversarial {
+		return g.text()
+	}
+	switch info.Code {
+	case apidiags.CodeAccessDenied:
+		return "You don't have permission to do that."
+	case apidiags.CodeInsufficient:
+		return field + " is too short."
+	case apidiags.CodeOverflow:
+		return field + " is too long."
+	case apidiags.CodeInvalidValue:
+		return field + " isn't valid."
+	case apidiags.CodeInvalidFormat:
+		return field + " is in the wrong format."
+	case apidiags.CodeMissing:
+		return field + " is required."
+	case apidiags.CodeNotFound:
+		return field + " wasn't found."
+	case apidiags.CodeConflict:
+		return field + " conflicts with another value."
+	case apidiags.CodeActOfGod:
+		return "Something went wrong. Try again."
+	case apidiags.CodeDeprecated:
+		return field + " is deprecated."
+	}
+	if info.Description != "" {
+		return info.Description
+	}
+	return fmt.Sprintf("%s is invalid (%s).", field, info.Code)
+}
+
+// fieldName returns a human-readable name for the part of the request path
+// points to, like "Name" or "Idempotency-Key".
+func fieldName(path apidiags.Steps) string {
+	for pos := len(path) - 1; pos >= 0; pos-- {
+		var name string
+		switch step := path[pos].(type) {
+		case apidiags.ObjectPropertyStep:
+			name = string(step)
+		case apidiags.HeaderStep:
+			name = string(step)
+		case apidiags.URLParamStep:
+			name = string(step)
+		default:
+			continue
+		}
+		if name == "" {
+			break
+		}
+		first, size := utf8.DecodeRuneInString(name)
+		return string(unicode.ToUpper(first)) + name[size:]
+	}
+	return "The request"
+}
+
+func (g *Generator) property() string {
+	if g.adversarial && g.rand.Intn(2) == 0 {
+		return g.text()
+	}
+	return generatedProperties[g.rand.Intn(len(generatedProperties))]
+}
+
+func (g *Generator) header() string {
+	if g.adversarial && g.rand.Intn(2) == 0 {
+		return g.text()
+	}
+	return generatedHeaders[g.rand.Intn(len(generatedHeaders))]
+}
+
+func (g *Generator) param() string {
+	if g.adversarial && g.rand.Intn(2) == 0 {
+		return g.text()
+	}
+	return generatedParams[g.rand.Intn(len(generatedParams))]
+}
+
+func (g *Generator) text() string {
+	return adversarialText[g.rand.Intn(len(adversarialText))]
+}
@@ -0,0 +1,121 @@
+package apidiagstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"impractical.co/apidiags"
+)
+
+func TestGeneratorDeterministic(t *testing.T) {
+	t.Parallel()
+
+	for name, opts := range map[string][]GeneratorOption{
+		"default":     nil,
+		"adversarial": {WithAdversarialText()},
+	} {
+		name, opts := name, opts
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			first := NewGenerator(42, opts...).Diagnostics(50)
+			second := NewGenerator(42, opts...).Diagnostics(50)
+			if diff := cmp.Diff(first, second); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+			other := NewGenerator(43, opts...).Diagnostics(50)
+			if cmp.Equal(first, other) {
+				t.Error("expected different seeds to generate different Diagnostics")
+			}
+		})
+	}
+}
+
+func TestGeneratorExhaustive(t *testing.T) {
+	t.Parallel()
+
+	diags := NewGenerator(1).Exhaustive()
+	codes := map[apidiags.Code]apidiags.Severity{}
+	kinds := map[string]bool{}
+	for _, diag := range diags {
+		codes[diag.Code] = diag.Severity
+		for _, path := range diag.Paths {
+			for _, step := range path {
+				kinds[fmt.Sprintf("%T", step)] = true
+			}
+		}
+	}
+	for _, info := range apidiags.BuiltinCodes() {
+		severity, ok := codes[info.Code]
+		if !ok {
+			t.Errorf("expected a Diagnostic with code %s", info.Code)
+			continue
+		}
+		if info.Status == 0 && severity != apidiags.DiagnosticWarning {
+			t.Errorf("expected %s to be a warning, got %s", info.Code, severity)
+		}
+		if info.Status != 0 && severity != apidiags.DiagnosticError {
+			t.Errorf("expected %s to be an error, got %s", info.Code, severity)
+		}
+	}
+	for _, step := range []apidiags.Step{
+		apidiags.BodyStep{},
+		apidiags.HeaderStep(""),
+		apidiags.URLParamStep(""),
+		apidiags.ArrayIndexStep(0),
+		apidiags.ObjectPropertyStep(""),
+		apidiags.StringIndexStep(0),
+		apidiags.RuneIndexStep(0),
+	} {
+		if !kinds[fmt.Sprintf("%T", step)] {
+			t.Errorf("expected a path with a %T", step)
+		}
+	}
+}
+
+func TestGeneratorWithCodes(t *testing.T) {
+	t.Parallel()
+
+	gen := NewGenerator(7, WithCodes(apidiags.CodeInfo{Code: "quota_exceeded", Description: "The account is out of quota.", Status: 429}))
+	for _, diag := range gen.Diagnostics(10) {
+		if diag.Code != "quota_exceeded" {
+			t.Errorf("expected code quota_exceeded, got %s", diag.Code)
+		}
+		if diag.Summary != "The account is out of quota." {
+			t.Errorf("expected the description as the summary, got %q", diag.Summary)
+		}
+	}
+}
+
+func TestGeneratorRoundTrips(t *testing.T) {
+	t.Parallel()
+
+	for name, opts := range map[string][]GeneratorOption{
+		"default":     nil,
+		"adversarial": {WithAdversarialText()},
+	} {
+		name, opts := name, opts
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			gen := NewGenerator(99, opts...)
+			diags := append(gen.Exhaustive(), gen.Diagnostics(100)...)
+			encoded, err := json.Marshal(diags)
+			if err != nil {
+				t.Fatalf("error encoding Diagnostics: %s", err)
+			}
+			decoded, err := apidiags.NewStrictDecoder().DecodeDiagnostics(encoded)
+			if err != nil {
+				t.Fatalf("error decoding Diagnostics: %s", err)
+			}
+			if diff := cmp.Diff(diags, decoded); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
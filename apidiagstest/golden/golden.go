@@ -0,0 +1,290 @@
+// Package golden provides helpers for snapshot testing error payloads
+// against golden files, so a change to the Diagnostics an API responds with
+// shows up as a readable diff in review.
+//
+//	golden.Assert(t, "create-widget-missing-name", result.Diagnostics)
+//
+// Golden files hold canonical, indented JSON, with object members sorted by
+// key, and values that change from run to run, like trace IDs, replaced by
+// placeholders. Run tests with -update-golden to write the golden files
+// instead of comparing against them.
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"impractical.co/apidiags"
+)
+
+var update = flag.Bool("update-golden", false, "write apidiags golden files instead of comparing against them")
+
+// DefaultDir is the directory golden files are read from and written to,
+// relative to the package being tested, unless another is set using
+// WithDir.
+const DefaultDir = "testdata"
+
+// DefaultVolatileMembers are the object members whose values are replaced
+// with placeholders by default, because they're expected to be different
+// every time a test runs.
+var DefaultVolatileMembers = []string{
+	apidiags.TraceIDMember,
+	apidiags.SpanIDMember,
+	apidiags.CorrelationIDMember,
+	apidiags.DebugMember,
+}
+
+var (
+	uuidPattern      = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	timestampPattern = regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}[Tt ]\d{2}:\d{2}:\d{2}(\.\d+)?([Zz]|[+-]\d{2}:\d{2})`)
+)
+
+// Option configures how payloads are normalized and compared.
+type Option func(*config)
+
+type config struct {
+	dir          string
+	volatile     map[string]bool
+	replacements []replacement
+}
+
+type replacement struct {
+	pattern *regexp.Regexp
+	with    string
+}
+
+// WithDir sets the directory golden files are read from and written to.
+func WithDir(dir string) Option {
+	return func(c *config) {
+		c.dir = dir
+	}
+}
+
+// WithVolatileMembers replaces the values of object members named members,
+// anywhere in a payload, with a placeholder like "<request_id>", on top of
+// DefaultVolatileMembers.
+func WithVolatileMembers(members ...string) Option {
+	return func(c *config) {
+		for _, member := range members {
+			c.volatile[member] = true
+		}
+	}
+}
+
+// WithReplacement replaces every match of pattern in the strings of a
+// payload, including object member names, with repl, which can refer to
+// submatches like regexp.Regexp.ReplaceAllString.
+func WithReplacement(pattern *regexp.Regexp, repl string) Option {
+	return func(c *config) {
+		c.replacements = append(c.replacements, replacement{pattern: pattern, with: repl})
+	}
+}
+
+// WithNormalizedUUIDs replaces UUIDs in the strings of a payload with
+// "<uuid>".
+func WithNormalizedUUIDs() Option {
+	return WithReplacement(uuidPattern, "<uuid>")
+}
+
+// WithNormalizedTimestamps replaces RFC 3339 timestamps in the strings of a
+// payload with "<timestamp>".
+func WithNormalizedTimestamps() Option {
+	return WithReplacement(timestampPattern, "<timestamp>")
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{dir: DefaultDir, volatile: map[string]bool{}}
+	for _, member := range DefaultVolatileMembers {
+		cfg.volatile[member] = true
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// Marshal returns diags encoded the way they're written to golden files.
+func Marshal(diags apidiags.Diagnostics, opts ...Option) ([]byte, error) {
+	if diags == nil {
+		diags = apidiags.Diagnostics{}
+	}
+	encoded, err := json.Marshal(diags)
+	if err != nil {
+		return nil, err
+	}
+	return MarshalJSON(encoded, opts...)
+}
+
+// MarshalJSON returns the JSON document payload, like a whole response
+// body, normalized and encoded the way it's written to golden files.
+func MarshalJSON(payload []byte, opts ...Option) ([]byte, error) {
+	cfg := newConfig(opts)
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var value any
+	err := dec.Decode(&value)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	normalized, err := json.Marshal(cfg.normalize(value))
+	if err != nil {
+		return nil, err
+	}
+	canonical, err := apidiags.CanonicalizeJSON(normalized)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	err = json.Indent(&out, canonical, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+// normalize returns value, decoded from JSON, with its volatile members
+// replaced with placeholders and its strings rewritten by the configured
+// replacements.
+func (c *config) normalize(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(value))
+		for key, member := range value {
+			if c.volatile[key] {
+				member = "<" + key + ">"
+			} else {
+				member = c.normalize(member)
+			}
+			result[c.replace(key)] = member
+		}
+		return result
+	case []any:
+		result := make([]any, 0, len(value))
+		for _, elem := range value {
+			result = append(result, c.normalize(elem))
+		}
+		return result
+	case string:
+		return c.replace(value)
+	}
+	return value
+}
+
+func (c *config) replace(s string) string {
+	for _, r := range c.replacements {
+		s = r.pattern.ReplaceAllString(s, r.with)
+	}
+	return s
+}
+
+// Assert compares diags against the golden file for name, failing the test
+// with a diff if they don't match. name is a path relative to the golden
+// file directory, without the .golden extension, so t.Name() can be used
+// for subtests.
+func Assert(t testing.TB, name string, diags apidiags.Diagnostics, opts ...Option) {
+	t.Helper()
+	got, err := Marshal(diags, opts...)
+	if err != nil {
+		t.Fatalf("error encoding Diagnostics: %s", err)
+		return
+	}
+	compare(t, name, got, newConfig(opts))
+}
+
+// AssertJSON compares the JSON document payload, like a whole response
+// body, against the golden file for name, failing the test with a diff if
+// they don't match. It's like Assert, for payloads that are more than a
+// list of Diagnostics, like problem details or a service's own envelope.
+func AssertJSON(t testing.TB, name string, payload []byte, opts ...Option) {
+	t.Helper()
+	got, err := MarshalJSON(payload, opts...)
+	if err != nil {
+		t.Fatalf("error encoding payload: %s", err)
+		return
+	}
+	compare(t, name, got, newConfig(opts))
+}
+
+func compare(t testing.TB, name string, got []byte, cfg *config) {
+	t.Helper()
+	path := filepath.Join(cfg.dir, filepath.FromSlash(name)+".golden")
+	if *update {
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err == nil {
+			err = os.WriteFile(path, got, 0o644)
+		}
+		if err != nil {
+			t.Fatalf("error writing golden file: %s", err)
+		}
+		return
+	}
+	expected, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("golden file %s doesn't exist; run with -update-golden to create it", path)
+		return
+	}
+	if err != nil {
+		t.Fatalf("error reading golden file: %s", err)
+		return
+	}
+	if bytes.Equal(expected, got) {
+		return
+	}
+	t.Errorf("payload doesn't match golden file %s (-golden, +got):\n%s\nrun with -update-golden to update it", path, diff(string(expected), string(got)))
+}
+
+// diff returns a line-by-line diff of a and b, marking lines only in a with
+// "-", lines only in b with "+", and lines in both with " ".
+func diff(a, b string) string {
+	as := strings.Split(strings.TrimSuffix(a, "\n"), "\n")
+	bs := strings.Split(strings.TrimSuffix(b, "\n"), "\n")
+	// lcs[i][j] is the length of the longest common subsequence of
+	// as[i:] and bs[j:]
+	lcs := make([][]int, len(as)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bs)+1)
+	}
+	for i := len(as) - 1; i >= 0; i-- {
+		for j := len(bs) - 1; j >= 0; j-- {
+			switch {
+			case as[i] == bs[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(as) || j < len(bs) {
+		switch {
+		case i < len(as) && j < len(bs) && as[i] == bs[j]:
+			fmt.Fprintf(&out, "  %s\n", as[i])
+			i++
+			j++
+		case j >= len(bs) || (i < len(as) && lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(&out, "- %s\n", as[i])
+			i++
+		default:
+			fmt.Fprintf(&out, "+ %s\n", bs[j])
+			j++
+		}
+	}
+	return out.String()
+}
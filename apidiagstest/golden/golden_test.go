@@ -0,0 +1,159 @@
+package golden
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"impractical.co/apidiags"
+)
+
+// recordingT is a testing.TB that records failures instead of reporting
+// them.
+type recordingT struct {
+	testing.TB
+	failures []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...any) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+func (t *recordingT) Fatalf(format string, args ...any) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+var testDiags = apidiags.Diagnostics{
+	apidiags.Diagnostic{
+		Severity: apidiags.DiagnosticError,
+		Code:     apidiags.CodeMissing,
+		Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name"))},
+		Summary:  "The name is required.",
+	}.WithTrace("4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"),
+}
+
+func TestMarshal(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    string
+		opts     []Option
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"sorted": {
+			input:    `{"b": 1, "a": [true, null, 1.50], "c": "<tag>"}`,
+			expected: "{\n  \"a\": [\n    true,\n    null,\n    1.5\n  ],\n  \"b\": 1,\n  \"c\": \"<tag>\"\n}\n",
+		},
+		"default-volatile": {
+			input:    `{"trace_id": "abc", "nested": [{"correlation_id": 5, "debug": {"stack": "..."}}]}`,
+			expected: "{\n  \"nested\": [\n    {\n      \"correlation_id\": \"<correlation_id>\",\n      \"debug\": \"<debug>\"\n    }\n  ],\n  \"trace_id\": \"<trace_id>\"\n}\n",
+		},
+		"extra-volatile": {
+			input:    `{"request_id": "r-123", "id": 7}`,
+			opts:     []Option{WithVolatileMembers("request_id")},
+			expected: "{\n  \"id\": 7,\n  \"request_id\": \"<request_id>\"\n}\n",
+		},
+		"uuids-and-timestamps": {
+			input:    `{"detail": "Widget 3F2504E0-4F89-11D3-9A0C-0305E82C3301 was deleted at 2024-05-01T12:30:00.123Z.", "sunset": "2025-01-01T00:00:00+02:00"}`,
+			opts:     []Option{WithNormalizedUUIDs(), WithNormalizedTimestamps()},
+			expected: "{\n  \"detail\": \"Widget <uuid> was deleted at <timestamp>.\",\n  \"sunset\": \"<timestamp>\"\n}\n",
+		},
+		"replacement-keys": {
+			input:    `{"widget-17": "widget-17 is locked"}`,
+			opts:     []Option{WithReplacement(regexp.MustCompile(`widget-\d+`), "widget-N")},
+			expected: "{\n  \"widget-N\": \"widget-N is locked\"\n}\n",
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := MarshalJSON([]byte(tc.input), tc.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(tc.expected, string(result)); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestMarshalJSONTrailingData(t *testing.T) {
+	t.Parallel()
+
+	_, err := MarshalJSON([]byte(`{} {}`))
+	if err == nil {
+		t.Error("expected an error for data after the JSON value")
+	}
+}
+
+func TestAssert(t *testing.T) {
+	t.Parallel()
+
+	Assert(t, "missing-name", testDiags)
+}
+
+func TestAssertMismatch(t *testing.T) {
+	t.Parallel()
+
+	diags := apidiags.Diagnostics{testDiags[0], {Severity: apidiags.DiagnosticWarning, Code: apidiags.CodeDeprecated}}
+	diags[0].Summary = "Name is required."
+	rec := &recordingT{TB: t}
+	Assert(rec, "missing-name", diags)
+	if len(rec.failures) != 1 {
+		t.Fatalf("expected one failure, got %v", rec.failures)
+	}
+	for _, expected := range []string{
+		"payload doesn't match golden file " + filepath.Join("testdata", "missing-name.golden"),
+		`-     "summary": "The name is required.",`,
+		`+     "summary": "Name is required.",`,
+		`      "trace_id": "<trace_id>"`,
+		`+   {`,
+		`+     "code": "deprecated",`,
+		"-update-golden",
+	} {
+		if !strings.Contains(rec.failures[0], expected) {
+			t.Errorf("expected failure to contain %q, got:\n%s", expected, rec.failures[0])
+		}
+	}
+}
+
+func TestAssertMissingFile(t *testing.T) {
+	t.Parallel()
+
+	rec := &recordingT{TB: t}
+	AssertJSON(rec, "nope", []byte(`{}`), WithDir(t.TempDir()))
+	if len(rec.failures) != 1 || !strings.Contains(rec.failures[0], "run with -update-golden to create it") {
+		t.Errorf("expected a missing file failure, got %v", rec.failures)
+	}
+}
+
+func TestAssertUpdate(t *testing.T) {
+	// not parallel, because it sets the -update-golden flag
+	*update = true
+	defer func() { *update = false }()
+
+	dir := t.TempDir()
+	AssertJSON(t, "nested/payload", []byte(`{"diagnostics": [], "status": 400}`), WithDir(dir))
+	result, err := os.ReadFile(filepath.Join(dir, "nested", "payload.golden"))
+	if err != nil {
+		t.Fatalf("error reading golden file: %s", err)
+	}
+	expected := "{\n  \"diagnostics\": [],\n  \"status\": 400\n}\n"
+	if diff := cmp.Diff(expected, string(result)); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+}
@@ -0,0 +1,17 @@
+module impractical.co/apidiags/apidiagstf
+
+go 1.22.0
+
+require (
+	github.com/google/go-cmp v0.6.0
+	github.com/hashicorp/terraform-plugin-framework v1.13.0
+	impractical.co/apidiags v0.0.0
+)
+
+require (
+	github.com/hashicorp/terraform-plugin-go v0.25.0 // indirect
+	github.com/vmihailenco/msgpack/v5 v5.4.1 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+)
+
+replace impractical.co/apidiags => ../
@@ -0,0 +1,71 @@
+// Package apidiagstf converts between apidiags Steps and
+// terraform-plugin-framework's path.Path, so validation code and diagnostic
+// locations can be shared between Terraform providers and HTTP APIs.
+package apidiagstf
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+
+	"impractical.co/apidiags"
+)
+
+// FromPath converts a path.Path into Steps, appending them to a copy of root.
+// It's usually called with apidiags.BodyPath() as root, so the resulting
+// Steps point into the body of the request.
+//
+// Attribute names and string map keys both become
+// apidiags.ObjectPropertySteps, and list indexes become
+// apidiags.ArrayIndexSteps. Set values have no apidiags equivalent and result
+// in an error.
+func FromPath(root apidiags.Steps, p path.Path) (apidiags.Steps, error) {
+	steps := p.Steps()
+	results := make(apidiags.Steps, 0, len(root)+len(steps))
+	results = append(results, root...)
+	for pos, step := range steps {
+		switch value := step.(type) {
+		case path.PathStepAttributeName:
+			results = results.AddStep(apidiags.ObjectPropertyStep(value))
+		case path.PathStepElementKeyString:
+			results = results.AddStep(apidiags.ObjectPropertyStep(value))
+		case path.PathStepElementKeyInt:
+			results = results.AddStep(apidiags.ArrayIndexStep(value))
+		default:
+			return nil, fmt.Errorf("unsupported step type %T for step %d", step, pos)
+		}
+	}
+	return results, nil
+}
+
+// ToPath converts Steps into a path.Path. A leading apidiags.BodyStep is
+// dropped, as path.Paths are always relative to the schema root.
+//
+// Because apidiags doesn't distinguish between attribute names and map keys,
+// apidiags.ObjectPropertySteps always become AtName steps, except when
+// isMapKey is non-nil and returns true for the path built so far, in which
+// case they become AtMapKey steps instead. apidiags.ArrayIndexSteps become
+// AtListIndex steps. Any other Step has no path.Path equivalent and results in
+// an error.
+func ToPath(steps apidiags.Steps, isMapKey func(parent path.Path) bool) (path.Path, error) {
+	result := path.Empty()
+	for pos, step := range steps {
+		switch value := step.(type) {
+		case apidiags.BodyStep:
+			if pos != 0 {
+				return path.Empty(), fmt.Errorf("step %d: %T is only supported as the first step", pos, step)
+			}
+		case apidiags.ObjectPropertyStep:
+			if isMapKey != nil && isMapKey(result) {
+				result = result.AtMapKey(string(value))
+			} else {
+				result = result.AtName(string(value))
+			}
+		case apidiags.ArrayIndexStep:
+			result = result.AtListIndex(int(value))
+		default:
+			return path.Empty(), fmt.Errorf("step %d: %T has no path.Path equivalent", pos, step)
+		}
+	}
+	return result, nil
+}
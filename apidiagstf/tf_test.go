@@ -0,0 +1,116 @@
+package apidiagstf
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+
+	"impractical.co/apidiags"
+)
+
+func TestFromPath(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		root     apidiags.Steps
+		path     path.Path
+		expected apidiags.Steps
+	}
+
+	cases := map[string]testCase{
+		"empty": {
+			root:     apidiags.BodyPath(),
+			path:     path.Empty(),
+			expected: apidiags.BodyPath(),
+		},
+		"name": {
+			root:     apidiags.BodyPath(),
+			path:     path.Root("foo"),
+			expected: apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("foo")),
+		},
+		"name-listIndex-mapKey": {
+			root: apidiags.BodyPath(),
+			path: path.Root("foo").AtListIndex(2).AtMapKey("bar"),
+			expected: apidiags.BodyPath().
+				AddStep(apidiags.ObjectPropertyStep("foo")).
+				AddStep(apidiags.ArrayIndexStep(2)).
+				AddStep(apidiags.ObjectPropertyStep("bar")),
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := FromPath(tc.root, tc.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestToPath(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		steps    apidiags.Steps
+		isMapKey func(path.Path) bool
+		expected path.Path
+		wantErr  bool
+	}
+
+	cases := map[string]testCase{
+		"body": {
+			steps:    apidiags.BodyPath(),
+			expected: path.Empty(),
+		},
+		"body-prop-arrayIndex": {
+			steps: apidiags.BodyPath().
+				AddStep(apidiags.ObjectPropertyStep("foo")).
+				AddStep(apidiags.ArrayIndexStep(3)),
+			expected: path.Root("foo").AtListIndex(3),
+		},
+		"body-prop-mapKey": {
+			steps: apidiags.BodyPath().
+				AddStep(apidiags.ObjectPropertyStep("tags")).
+				AddStep(apidiags.ObjectPropertyStep("env")),
+			isMapKey: func(parent path.Path) bool {
+				return parent.Equal(path.Root("tags"))
+			},
+			expected: path.Root("tags").AtMapKey("env"),
+		},
+		"header": {
+			steps:   apidiags.HeaderPath("foo"),
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := ToPath(tc.steps, tc.isMapKey)
+			if err != nil && !tc.wantErr {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && tc.wantErr {
+				t.Fatalf("expected error, got %s", result)
+			}
+			if err != nil {
+				return
+			}
+			if !result.Equal(tc.expected) {
+				t.Fatalf("expected %s, got %s", tc.expected, result)
+			}
+		})
+	}
+}
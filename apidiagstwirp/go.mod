@@ -0,0 +1,11 @@
+module impractical.co/apidiags/apidiagstwirp
+
+go 1.19
+
+require (
+	github.com/google/go-cmp v0.5.9
+	github.com/twitchtv/twirp v8.1.3+incompatible
+	impractical.co/apidiags v0.0.0
+)
+
+replace impractical.co/apidiags => ../
@@ -0,0 +1,128 @@
+// Package apidiagstwirp converts between apidiags Diagnostics and Twirp
+// errors, carrying the structured Diagnostics in the Twirp error's metadata so
+// they survive the trip between services.
+package apidiagstwirp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/twitchtv/twirp"
+
+	"impractical.co/apidiags"
+)
+
+// MetaKey is the Twirp error metadata key the JSON-encoded Diagnostics are
+// stored under.
+const MetaKey = "apidiags_diagnostics"
+
+// Code returns the Twirp error code that best represents code. Codes this
+// package doesn't know about map to twirp.Unknown.
+func Code(code apidiags.Code) twirp.ErrorCode {
+	switch code {
+	case apidiags.CodeAccessDenied:
+		return twirp.PermissionDenied
+	case apidiags.CodeInsufficient, apidiags.CodeOverflow, apidiags.CodeInvalidValue,
+		apidiags.CodeMissing:
+		return twirp.InvalidArgument
+	case apidiags.CodeInvalidFormat:
+		return twirp.Malformed
+	case apidiags.CodeNotFound:
+		return twirp.NotFound
+	case apidiags.CodeConflict:
+		return twirp.FailedPrecondition
+	case apidiags.CodeActOfGod:
+		return twirp.Unavailable
+	case apidiags.CodeDeprecated:
+		return twirp.NoError
+	default:
+		return twirp.Unknown
+	}
+}
+
+// FromCode returns the apidiags.Code that best represents a Twirp error code.
+// Error codes without an equivalent apidiags.Code are returned as-is.
+func FromCode(code twirp.ErrorCode) apidiags.Code {
+	switch code {
+	case twirp.PermissionDenied, twirp.Unauthenticated:
+		return apidiags.CodeAccessDenied
+	case twirp.InvalidArgument:
+		return apidiags.CodeInvalidValue
+	case twirp.Malformed:
+		return apidiags.CodeInvalidFormat
+	case twirp.OutOfRange:
+		return apidiags.CodeOverflow
+	case twirp.NotFound:
+		return apidiags.CodeNotFound
+	case twirp.AlreadyExists, twirp.FailedPrecondition, twirp.Aborted:
+		return apidiags.CodeConflict
+	case twirp.Unavailable, twirp.DeadlineExceeded:
+		return apidiags.CodeActOfGod
+	default:
+		return apidiags.Code(code)
+	}
+}
+
+// ToError converts diags into a Twirp error. The error code is determined by
+// the first Diagnostic with a Severity of apidiags.DiagnosticError whose
+// Code doesn't map to twirp.NoError, like apidiags.CodeDeprecated does, and
+// the message is that Diagnostic's Message. If every error's Code maps to
+// twirp.NoError, the first error's Message is used with twirp.Unknown, as
+// Twirp serves twirp.NoError as a success. All of diags, including
+// warnings, are JSON-encoded and stored in the error's metadata under
+// MetaKey.
+//
+// If diags has no Diagnostics with a Severity of apidiags.DiagnosticError,
+// ToError returns nil.
+func ToError(diags apidiags.Diagnostics) (twirp.Error, error) {
+	var primary *apidiags.Diagnostic
+	code := twirp.Unknown
+	for pos, diag := range diags {
+		if diag.Severity != apidiags.DiagnosticError {
+			continue
+		}
+		if diagCode := Code(diag.Code); diagCode != twirp.NoError {
+			primary, code = &diags[pos], diagCode
+			break
+		}
+		if primary == nil {
+			primary = &diags[pos]
+		}
+	}
+	if primary == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(diags)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding diagnostics: %w", err)
+	}
+	return twirp.NewError(code, primary.Message()).WithMeta(MetaKey, string(encoded)), nil
+}
+
+// FromError converts a Twirp error into Diagnostics. If the error's metadata
+// has Diagnostics stored under MetaKey, they're returned. Otherwise, a single
+// Diagnostic is returned, with FromCode determining its Code and the error's
+// message as its Summary.
+//
+// If err isn't and doesn't wrap a twirp.Error, FromError returns nil and no
+// error.
+func FromError(err error) (apidiags.Diagnostics, error) {
+	var twerr twirp.Error
+	if !errors.As(err, &twerr) {
+		return nil, nil
+	}
+	if encoded := twerr.Meta(MetaKey); encoded != "" {
+		var diags apidiags.Diagnostics
+		err := json.Unmarshal([]byte(encoded), &diags)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding diagnostics: %w", err)
+		}
+		return diags, nil
+	}
+	return apidiags.Diagnostics{{
+		Severity: apidiags.DiagnosticError,
+		Code:     FromCode(twerr.Code()),
+		Summary:  twerr.Msg(),
+	}}, nil
+}
@@ -0,0 +1,146 @@
+package apidiagstwirp
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/twitchtv/twirp"
+
+	"impractical.co/apidiags"
+)
+
+func TestToErrorRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags   apidiags.Diagnostics
+		code    twirp.ErrorCode
+		message string
+	}
+
+	cases := map[string]testCase{
+		"missing": {
+			diags: apidiags.Diagnostics{
+				{Severity: apidiags.DiagnosticWarning, Code: apidiags.CodeDeprecated, Paths: []apidiags.Steps{apidiags.HeaderPath("X-Legacy")}},
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Summary:  "Name is required.",
+					Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name"))},
+				},
+			},
+			code:    twirp.InvalidArgument,
+			message: "Name is required.",
+		},
+		"not-found": {
+			diags: apidiags.Diagnostics{
+				{Severity: apidiags.DiagnosticError, Code: apidiags.CodeNotFound, Paths: []apidiags.Steps{apidiags.URLParamPath("id")}},
+			},
+			code:    twirp.NotFound,
+			message: "not_found",
+		},
+		"skips-no-error": {
+			diags: apidiags.Diagnostics{
+				{Severity: apidiags.DiagnosticError, Code: apidiags.CodeDeprecated, Summary: "v1 is gone."},
+				{Severity: apidiags.DiagnosticError, Code: apidiags.CodeConflict, Summary: "That name is taken."},
+			},
+			code:    twirp.FailedPrecondition,
+			message: "That name is taken.",
+		},
+		"only-no-error": {
+			diags: apidiags.Diagnostics{
+				{Severity: apidiags.DiagnosticWarning, Code: apidiags.CodeNotFound},
+				{Severity: apidiags.DiagnosticError, Code: apidiags.CodeDeprecated, Summary: "v1 is gone."},
+			},
+			code:    twirp.Unknown,
+			message: "v1 is gone.",
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			twerr, err := ToError(tc.diags)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if twerr.Code() != tc.code {
+				t.Errorf("expected code %q, got %q", tc.code, twerr.Code())
+			}
+			if twerr.Msg() != tc.message {
+				t.Errorf("expected message %q, got %q", tc.message, twerr.Msg())
+			}
+			result, err := FromError(fmt.Errorf("calling service: %w", twerr))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(tc.diags, result); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestToErrorWarningsOnly(t *testing.T) {
+	t.Parallel()
+
+	twerr, err := ToError(apidiags.Diagnostics{
+		{Severity: apidiags.DiagnosticWarning, Code: apidiags.CodeDeprecated},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if twerr != nil {
+		t.Fatalf("expected nil error, got %v", twerr)
+	}
+}
+
+func TestFromError(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		err      error
+		expected apidiags.Diagnostics
+		wantErr  bool
+	}
+
+	cases := map[string]testCase{
+		"nil": {},
+		"not-twirp": {
+			err: fmt.Errorf("oops"),
+		},
+		"no-meta": {
+			err: twirp.NewError(twirp.PermissionDenied, "you can't do that"),
+			expected: apidiags.Diagnostics{
+				{Severity: apidiags.DiagnosticError, Code: apidiags.CodeAccessDenied, Summary: "you can't do that"},
+			},
+		},
+		"invalid-meta": {
+			err:     twirp.NewError(twirp.Internal, "oops").WithMeta(MetaKey, "not json"),
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := FromError(tc.err)
+			if err != nil && !tc.wantErr {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && tc.wantErr {
+				t.Fatalf("expected error, got %+v", result)
+			}
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+// Package apidiagsvalidator converts the errors returned by
+// go-playground/validator into apidiags Diagnostics, pointing to the fields
+// that failed validation and describing the rules they broke.
+package apidiagsvalidator
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"impractical.co/apidiags"
+)
+
+// The extension members FromValidationErrors uses to describe the bounds a
+// field had to stay within. For strings, slices, and maps, the bounds apply
+// to their length, as they do in validator. Clients can read them with
+// apidiags.BoundsFrom.
+const (
+	// RuleMember holds the validation rule that failed, like "max=10".
+	RuleMember = "rule"
+
+	// MinMember holds the smallest value allowed, inclusive.
+	MinMember = apidiags.MinMember
+
+	// MaxMember holds the largest value allowed, inclusive.
+	MaxMember = apidiags.MaxMember
+
+	// ExclusiveMinMember holds the value every allowed value is greater
+	// than.
+	ExclusiveMinMember = apidiags.ExclusiveMinMember
+
+	// ExclusiveMaxMember holds the value every allowed value is less than.
+	ExclusiveMaxMember = apidiags.ExclusiveMaxMember
+
+	// AllowedMember holds the list of allowed values, as strings.
+	AllowedMember = apidiags.AllowedMember
+)
+
+// Option configures how validation errors are converted into Diagnostics.
+type Option func(*config)
+
+type config struct {
+	root func(field string) apidiags.Steps
+}
+
+// WithRoot makes the Paths of the Diagnostics start with root(field), where
+// field is the name of the top-level field that failed validation, instead
+// of pointing into the request body. Use apidiags.URLParamPath for structs
+// decoded from query parameters, or apidiags.HeaderPath for structs
+// decoded from headers.
+func WithRoot(root func(field string) apidiags.Steps) Option {
+	return func(c *config) {
+		c.root = root
+	}
+}
+
+// FromError converts err into Diagnostics. If err wraps
+// validator.ValidationErrors, it's converted using FromValidationErrors;
+// otherwise, it's converted using apidiags.FromError.
+func FromError(err error, opts ...Option) apidiags.Diagnostics {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return FromValidationErrors(validationErrs, opts...)
+	}
+	return apidiags.FromError(err)
+}
+
+// FromValidationErrors converts errs into Diagnostics, one per failed
+// field, each with a Severity of apidiags.DiagnosticError.
+//
+// Each Diagnostic's Code is chosen by Code, based on the validation tag
+// that failed, and its Path is built from the field's namespace, like
+// `Widget.items[0].labels[app]`, leaving out the name of the struct being
+// validated. Use UseJSONNames so the namespace uses the names clients use.
+//
+// The rule that failed is recorded in the RuleMember extension member.
+// When the rule's parameter sets a bound, like the 10 in "max=10", it's
+// also recorded in MinMember, MaxMember, ExclusiveMinMember,
+// ExclusiveMaxMember, or AllowedMember, so clients can tell users what
+// values are allowed.
+func FromValidationErrors(errs validator.ValidationErrors, opts ...Option) apidiags.Diagnostics {
+	cfg := config{root: func(field string) apidiags.Steps {
+		return apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep(field))
+	}}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	results := make(apidiags.Diagnostics, 0, len(errs))
+	for _, fieldErr := range errs {
+		rule := fieldErr.Tag()
+		if fieldErr.Param() != "" {
+			rule += "=" + fieldErr.Param()
+		}
+		diag := apidiags.Diagnostic{
+			Severity:   apidiags.DiagnosticError,
+			Code:       Code(fieldErr.Tag()),
+			Paths:      []apidiags.Steps{namespacePath(fieldErr.Namespace(), cfg.root)},
+			Detail:     "The " + strconv.Quote(rule) + " validation rule failed.",
+			Extensions: boundsMembers(fieldErr.Tag(), fieldErr.Param()),
+		}
+		encoded, err := json.Marshal(rule)
+		if err == nil {
+			if diag.Extensions == nil {
+				diag.Extensions = map[string]json.RawMessage{}
+			}
+			diag.Extensions[RuleMember] = encoded
+		}
+		results = append(results, diag)
+	}
+	return results
+}
+
+// Code returns the apidiags.Code describing a failure of the validation
+// tag: apidiags.CodeMissing for "required" and its variants,
+// apidiags.CodeInsufficient for "min", "gte", and "gt",
+// apidiags.CodeOverflow for "max", "lte", and "lt", apidiags.CodeConflict
+// for "unique" and the "excluded" variants, and apidiags.CodeInvalidFormat
+// for format tags like "email" and "uuid". Every other tag is
+// apidiags.CodeInvalidValue.
+func Code(tag string) apidiags.Code {
+	switch tag {
+	case "required", "required_if", "required_unless", "required_with", "required_with_all",
+		"required_without", "required_without_all":
+		return apidiags.CodeMissing
+	case "min", "gte", "gt":
+		return apidiags.CodeInsufficient
+	case "max", "lte", "lt":
+		return apidiags.CodeOverflow
+	case "excluded_if", "excluded_unless", "excluded_with", "excluded_with_all",
+		"excluded_without", "excluded_without_all", "unique":
+		return apidiags.CodeConflict
+	case "email", "url", "uri", "uuid", "uuid4", "ip", "ipv4", "ipv6", "cidr", "hostname",
+		"datetime", "json", "base64", "hexadecimal", "numeric", "number", "alpha", "alphanum",
+		"e164", "iso3166_1_alpha2", "bcp47_language_tag":
+		return apidiags.CodeInvalidFormat
+	default:
+		return apidiags.CodeInvalidValue
+	}
+}
+
+// boundsMembers returns the extension members describing the bounds set by
+// the validation tag's param, or nil if it doesn't set any.
+func boundsMembers(tag, param string) map[string]json.RawMessage {
+	members := map[string]json.RawMessage{}
+	number := func(member string) {
+		// ParseFloat accepts numbers JSON doesn't, like .5 and 0x1p3, so
+		// the parsed value is encoded rather than param itself
+		parsed, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return
+		}
+		encoded, err := json.Marshal(parsed)
+		if err != nil {
+			return
+		}
+		members[member] = encoded
+	}
+	switch tag {
+	case "min", "gte":
+		number(MinMember)
+	case "max", "lte":
+		number(MaxMember)
+	case "gt":
+		number(ExclusiveMinMember)
+	case "lt":
+		number(ExclusiveMaxMember)
+	case "len", "eq":
+		number(MinMember)
+		number(MaxMember)
+	case "oneof":
+		allowed, err := json.Marshal(strings.Fields(param))
+		if err == nil {
+			members[AllowedMember] = allowed
+		}
+	}
+	if len(members) < 1 {
+		return nil
+	}
+	return members
+}
+
+// namespacePath converts a validator namespace, like
+// `Widget.items[0].labels[app]`, into Steps. The first segment, naming the
+// struct being validated, is dropped, and root converts the second into the
+// start of the Steps.
+func namespacePath(namespace string, root func(field string) apidiags.Steps) apidiags.Steps {
+	if pos := strings.IndexByte(namespace, '.'); pos >= 0 {
+		namespace = namespace[pos+1:]
+	}
+	end := strings.IndexAny(namespace, ".[")
+	if end < 0 {
+		end = len(namespace)
+	}
+	results := root(namespace[:end])
+	namespace = namespace[end:]
+	for namespace != "" {
+		switch namespace[0] {
+		case '.':
+			namespace = namespace[1:]
+			continue
+		case '[':
+			end := strings.IndexByte(namespace, ']')
+			if end < 0 {
+				return results.AddStep(apidiags.ObjectPropertyStep(namespace))
+			}
+			key := namespace[1:end]
+			if idx, err := strconv.ParseInt(key, 10, 64); err == nil {
+				results = results.AddStep(apidiags.ArrayIndexStep(idx))
+			} else {
+				results = results.AddStep(apidiags.ObjectPropertyStep(key))
+			}
+			namespace = namespace[end+1:]
+			continue
+		}
+		end := strings.IndexAny(namespace, ".[")
+		if end < 0 {
+			end = len(namespace)
+		}
+		results = results.AddStep(apidiags.ObjectPropertyStep(namespace[:end]))
+		namespace = namespace[end:]
+	}
+	return results
+}
+
+// UseJSONNames configures validate to name fields in its errors using
+// their `json` struct tags, so the Paths of the Diagnostics returned by
+// FromValidationErrors match the names clients use. Fields without a
+// `json` tag keep their Go names. It should be called before validate is
+// used.
+func UseJSONNames(validate *validator.Validate) {
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+		return field.Name
+	})
+}
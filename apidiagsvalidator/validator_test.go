@@ -0,0 +1,191 @@
+package apidiagsvalidator
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/go-cmp/cmp"
+
+	"impractical.co/apidiags"
+)
+
+type widget struct {
+	Name   string            `json:"name" validate:"required"`
+	Count  int               `json:"count" validate:"min=1,max=10"`
+	Ratio  float64           `json:"ratio" validate:"gt=0,lt=1"`
+	Mode   string            `json:"mode" validate:"oneof=fast slow"`
+	Tags   []string          `json:"tags" validate:"dive,alphanum"`
+	Labels map[string]string `json:"labels" validate:"dive,keys,required,endkeys,len=3"`
+	Owner  string            `validate:"email"`
+}
+
+func members(pairs ...string) map[string]json.RawMessage {
+	results := map[string]json.RawMessage{}
+	for pos := 0; pos < len(pairs); pos += 2 {
+		results[pairs[pos]] = json.RawMessage(pairs[pos+1])
+	}
+	return results
+}
+
+func TestFromError(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    widget
+		opts     []Option
+		expected apidiags.Diagnostics
+	}
+
+	valid := widget{Name: "foo", Count: 1, Ratio: 0.5, Mode: "fast", Owner: "foo@example.com"}
+
+	cases := map[string]testCase{
+		"valid": {
+			input: valid,
+		},
+		"required": {
+			input: widget{Count: 1, Ratio: 0.5, Mode: "fast", Owner: "foo@example.com"},
+			expected: apidiags.Diagnostics{{
+				Severity:   apidiags.DiagnosticError,
+				Code:       apidiags.CodeMissing,
+				Paths:      []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name"))},
+				Detail:     `The "required" validation rule failed.`,
+				Extensions: members(RuleMember, `"required"`),
+			}},
+		},
+		"bounds": {
+			input: widget{Name: "foo", Count: 11, Ratio: 1, Mode: "medium", Owner: "foo@example.com"},
+			expected: apidiags.Diagnostics{
+				{
+					Severity:   apidiags.DiagnosticError,
+					Code:       apidiags.CodeOverflow,
+					Paths:      []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("count"))},
+					Detail:     `The "max=10" validation rule failed.`,
+					Extensions: members(RuleMember, `"max=10"`, MaxMember, `10`),
+				},
+				{
+					Severity:   apidiags.DiagnosticError,
+					Code:       apidiags.CodeOverflow,
+					Paths:      []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("ratio"))},
+					Detail:     `The "lt=1" validation rule failed.`,
+					Extensions: members(RuleMember, `"lt=1"`, ExclusiveMaxMember, `1`),
+				},
+				{
+					Severity:   apidiags.DiagnosticError,
+					Code:       apidiags.CodeInvalidValue,
+					Paths:      []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("mode"))},
+					Detail:     `The "oneof=fast slow" validation rule failed.`,
+					Extensions: members(RuleMember, `"oneof=fast slow"`, AllowedMember, `["fast","slow"]`),
+				},
+			},
+		},
+		"nested": {
+			input: widget{
+				Name: "foo", Count: 1, Ratio: 0.5, Mode: "fast", Owner: "foo@example.com",
+				Tags:   []string{"ok", "not ok"},
+				Labels: map[string]string{"app": "frontend"},
+			},
+			expected: apidiags.Diagnostics{
+				{
+					Severity:   apidiags.DiagnosticError,
+					Code:       apidiags.CodeInvalidFormat,
+					Paths:      []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("tags")).AddStep(apidiags.ArrayIndexStep(1))},
+					Detail:     `The "alphanum" validation rule failed.`,
+					Extensions: members(RuleMember, `"alphanum"`),
+				},
+				{
+					Severity:   apidiags.DiagnosticError,
+					Code:       apidiags.CodeInvalidValue,
+					Paths:      []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("labels")).AddStep(apidiags.ObjectPropertyStep("app"))},
+					Detail:     `The "len=3" validation rule failed.`,
+					Extensions: members(RuleMember, `"len=3"`, MinMember, `3`, MaxMember, `3`),
+				},
+			},
+		},
+		"go-name-with-root": {
+			input: widget{Name: "foo", Count: 1, Ratio: 0.5, Mode: "fast", Owner: "foo"},
+			opts:  []Option{WithRoot(apidiags.URLParamPath)},
+			expected: apidiags.Diagnostics{{
+				Severity:   apidiags.DiagnosticError,
+				Code:       apidiags.CodeInvalidFormat,
+				Paths:      []apidiags.Steps{apidiags.URLParamPath("Owner")},
+				Detail:     `The "email" validation rule failed.`,
+				Extensions: members(RuleMember, `"email"`),
+			}},
+		},
+	}
+
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	UseJSONNames(validate)
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validate.Struct(tc.input)
+			if err == nil {
+				if tc.expected != nil {
+					t.Fatalf("expected validation to fail")
+				}
+				return
+			}
+			result := FromError(err, tc.opts...)
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestBoundsMembers(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		tag      string
+		param    string
+		expected map[string]json.RawMessage
+	}
+
+	cases := map[string]testCase{
+		"integer":      {tag: "max", param: "10", expected: members(MaxMember, `10`)},
+		"leading-dot":  {tag: "gte", param: ".5", expected: members(MinMember, `0.5`)},
+		"leading-plus": {tag: "lte", param: "+1", expected: members(MaxMember, `1`)},
+		"trailing-dot": {tag: "gt", param: "1.", expected: members(ExclusiveMinMember, `1`)},
+		"hex":          {tag: "lt", param: "0x1p3", expected: members(ExclusiveMaxMember, `8`)},
+		"infinity":     {tag: "gte", param: "inf"},
+		"not-a-number": {tag: "min", param: "NaN"},
+		"not-numeric":  {tag: "max", param: "ten"},
+		"exact":        {tag: "len", param: ".5", expected: members(MinMember, `0.5`, MaxMember, `0.5`)},
+		"unknown-rule": {tag: "email", param: "1"},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result := boundsMembers(tc.tag, tc.param)
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+			for member, value := range result {
+				if !json.Valid(value) {
+					t.Errorf("expected %s to be valid JSON, got %s", member, value)
+				}
+			}
+		})
+	}
+}
+
+func TestFromErrorOther(t *testing.T) {
+	t.Parallel()
+
+	err := errors.New("something went wrong")
+	if diff := cmp.Diff(apidiags.FromError(err), FromError(err)); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+}
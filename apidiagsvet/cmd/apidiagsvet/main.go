@@ -0,0 +1,16 @@
+// Command apidiagsvet checks how apidiags Diagnostics are constructed, as
+// described by the apidiagsvet package. It can be run on its own, or by go
+// vet:
+//
+//	go vet -vettool=$(which apidiagsvet) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"impractical.co/apidiags/apidiagsvet"
+)
+
+func main() {
+	singlechecker.Main(apidiagsvet.Analyzer)
+}
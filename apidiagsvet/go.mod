@@ -0,0 +1,15 @@
+module impractical.co/apidiags/apidiagsvet
+
+go 1.26.0
+
+require (
+	golang.org/x/tools v0.50.0
+	impractical.co/apidiags v0.0.0
+)
+
+require (
+	golang.org/x/mod v0.41.0 // indirect
+	golang.org/x/sync v0.23.0 // indirect
+)
+
+replace impractical.co/apidiags => ../
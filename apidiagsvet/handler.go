@@ -0,0 +1,79 @@
+package apidiagsvet
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"net/http"
+	"strings"
+
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// handlerPackages are the packages, or patterns ending in "/..." matching a
+// package and every package under it, whose handlers are checked for writing
+// errors without apidiags.
+var handlerPackages listFlag
+
+// checksHandlers returns true if the handlers in the package with the
+// import path pkgPath should be checked, because it's matched by
+// handlerPackages.
+func checksHandlers(pkgPath string) bool {
+	for _, pattern := range handlerPackages {
+		if prefix, ok := strings.CutSuffix(pattern, "/..."); ok {
+			if pkgPath == prefix || strings.HasPrefix(pkgPath, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if pkgPath == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// checkHandlers reports the calls in the package that write errors to an
+// http.ResponseWriter without apidiags: calls to http.Error and
+// http.NotFound, and calls to http.ResponseWriter.WriteHeader with a
+// constant status of 400 or more. Those calls are reported even if nothing
+// is written after them, as the response still has no Diagnostics. Test
+// files aren't checked, as they often write errors from fake servers.
+func (c *checker) checkHandlers(inspect *inspector.Inspector) {
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(node ast.Node) {
+		call := node.(*ast.CallExpr)
+		if strings.HasSuffix(c.pass.Fset.File(call.Pos()).Name(), "_test.go") {
+			return
+		}
+		fn, ok := typeutil.Callee(c.pass.TypesInfo, call).(*types.Func)
+		if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "net/http" {
+			return
+		}
+		recv := fn.Type().(*types.Signature).Recv()
+		switch {
+		case recv == nil && (fn.Name() == "Error" || fn.Name() == "NotFound"):
+			c.pass.ReportRangef(call, "http.%s writes a plain text error; write Diagnostics with apidiags.WriteHTTP instead", fn.Name())
+		case recv != nil && fn.Name() == "WriteHeader" && isResponseWriter(recv.Type()) && len(call.Args) == 1:
+			tv := c.pass.TypesInfo.Types[call.Args[0]]
+			if tv.Value == nil || tv.Value.Kind() != constant.Int {
+				return
+			}
+			status, ok := constant.Int64Val(tv.Value)
+			if !ok || status < http.StatusBadRequest {
+				return
+			}
+			c.pass.ReportRangef(call, "handler writes error status %d without Diagnostics; write them with apidiags.WriteHTTP instead", status)
+		}
+	})
+}
+
+// isResponseWriter returns true if typ is http.ResponseWriter.
+func isResponseWriter(typ types.Type) bool {
+	named, ok := types.Unalias(typ).(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Name() == "ResponseWriter" && obj.Pkg() != nil && obj.Pkg().Path() == "net/http"
+}
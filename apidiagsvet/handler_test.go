@@ -0,0 +1,39 @@
+package apidiagsvet
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzerHandlers(t *testing.T) {
+	err := Analyzer.Flags.Set("handler-packages", "example, handlers/...")
+	if err != nil {
+		t.Fatalf("error setting -handler-packages: %s", err)
+	}
+	t.Cleanup(func() {
+		_ = Analyzer.Flags.Set("handler-packages", "")
+	})
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "handlers", "handlers/admin", "unchecked")
+}
+
+func TestChecksHandlers(t *testing.T) {
+	previous := handlerPackages
+	handlerPackages = listFlag{"example.com/api/...", "example.com/web"}
+	t.Cleanup(func() {
+		handlerPackages = previous
+	})
+
+	for pkgPath, expected := range map[string]bool{
+		"example.com/api":         true,
+		"example.com/api/widgets": true,
+		"example.com/apis":        false,
+		"example.com/web":         true,
+		"example.com/web/static":  false,
+		"example.com/cli":         false,
+	} {
+		if result := checksHandlers(pkgPath); result != expected {
+			t.Errorf("expected checksHandlers(%q) to be %t, got %t", pkgPath, expected, result)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package codes // want package:`codes\(quota_exceeded, rate_limited\)`
+
+import "impractical.co/apidiags"
+
+const CodeQuotaExceeded apidiags.Code = "quota_exceeded"
+
+func init() {
+	_ = apidiags.RegisterCode(apidiags.CodeInfo{Code: "rate_limited"})
+}
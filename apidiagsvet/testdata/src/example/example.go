@@ -0,0 +1,60 @@
+package example // want package:`codes\(local\)`
+
+import (
+	"codes"
+
+	"impractical.co/apidiags"
+)
+
+const codeLocal apidiags.Code = "local"
+
+func diagnostics(code apidiags.Code, path apidiags.Steps) apidiags.Diagnostics {
+	diags := apidiags.Diagnostics{
+		{Severity: apidiags.DiagnosticError, Code: apidiags.CodeMissing, Paths: []apidiags.Steps{apidiags.BodyPath()}},
+		{Severity: apidiags.DiagnosticError, Code: codes.CodeQuotaExceeded},
+		{Severity: apidiags.DiagnosticError, Code: "rate_limited"},
+		{Severity: apidiags.DiagnosticError, Code: "local"},
+		{Severity: apidiags.DiagnosticError, Code: code},
+		{Severity: apidiags.DiagnosticError, Code: "not_fuond"},            // want `unknown code "not_fuond"; did you mean "not_found"\?`
+		{Severity: apidiags.DiagnosticError, Code: apidiags.Code("lcoal")}, // want `unknown code "lcoal"; did you mean "local"\?`
+		{Severity: apidiags.DiagnosticError, Code: "frobnicated"},          // want `unknown code "frobnicated"; declare it as an apidiags.Code constant or register it`
+		{Severity: apidiags.DiagnosticError, Code: ""},                     // want `Diagnostic has an empty code`
+		{apidiags.DiagnosticError, "typo", nil, ""},                        // want `unknown code "typo"; declare it`
+	}
+	diag := apidiags.Diagnostic{Severity: apidiags.DiagnosticError, Code: codeLocal}
+	diag.Code = "missin" // want `unknown code "missin"; did you mean "missing"\?`
+	diag.Code = codeLocal
+	return append(diags, diag)
+}
+
+func paths(index int64, path apidiags.Steps) []apidiags.Steps {
+	return []apidiags.Steps{
+		apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name")).AddStep(apidiags.RuneIndexStep(3)),
+		apidiags.HeaderPath("X-Name").AddStep(apidiags.StringIndexStep(1)),
+		{apidiags.URLParamStep("id"), apidiags.StringIndexStep(0)},
+		apidiags.PathOf(apidiags.ObjectPropertyStep("relative"), apidiags.ArrayIndexStep(index)),
+		path.AddStep(apidiags.BodyStep{}),
+		apidiags.BodyPath().AddStep(apidiags.BodyStep{}),                                             // want `apidiags.BodyStep must be the first step of a path`
+		{apidiags.BodyStep{}, apidiags.HeaderStep("X-Name")},                                         // want `apidiags.HeaderStep must be the first step of a path`
+		apidiags.PathOf(apidiags.ObjectPropertyStep("name"), apidiags.URLParamStep("id")),            // want `apidiags.URLParamStep must be the first step of a path`
+		apidiags.BodyPath().AddStep(apidiags.StringIndexStep(1)).AddStep(apidiags.ArrayIndexStep(0)), // want `apidiags.ArrayIndexStep can't follow an apidiags.StringIndexStep, which points to a single character`
+		{apidiags.BodyStep{}, apidiags.RuneIndexStep(1), apidiags.ObjectPropertyStep("name")},        // want `apidiags.ObjectPropertyStep can't follow an apidiags.RuneIndexStep`
+		apidiags.BodyPath().AddStep(apidiags.ArrayIndexStep(-1)),                                     // want `apidiags.ArrayIndexStep can't be negative`
+	}
+}
+
+func missingPaths() apidiags.Diagnostics {
+	return apidiags.Diagnostics{
+		{Severity: apidiags.DiagnosticError, Code: apidiags.CodeMissing},      // want `Diagnostic with code "missing" has no Paths; set them to the part of the request it's about`
+		{Severity: apidiags.DiagnosticError, Code: apidiags.CodeInvalidValue}, // want `Diagnostic with code "invalid_value" has no Paths`
+		{Severity: apidiags.DiagnosticError, Code: apidiags.CodeNotFound},
+		{Severity: apidiags.DiagnosticError, Code: apidiags.CodeActOfGod},
+		apidiags.Diagnostic{Severity: apidiags.DiagnosticError, Code: apidiags.CodeMissing}.PrependPath(apidiags.BodyPath()),
+	}
+}
+
+func prepended() apidiags.Diagnostics {
+	return apidiags.Diagnostics{
+		{Severity: apidiags.DiagnosticError, Code: apidiags.CodeMissing},
+	}.PrependPath(apidiags.BodyPath())
+}
@@ -0,0 +1,8 @@
+package fixes
+
+import "impractical.co/apidiags"
+
+var diag = apidiags.Diagnostic{
+	Severity: apidiags.DiagnosticError,
+	Code:     "not_fuond", // want `unknown code "not_fuond"; did you mean "not_found"\?`
+}
@@ -0,0 +1,9 @@
+package flags
+
+import "impractical.co/apidiags"
+
+var diags = apidiags.Diagnostics{
+	{Severity: apidiags.DiagnosticError, Code: "teapot"},
+	{Severity: apidiags.DiagnosticError, Code: apidiags.CodeMissing},
+	{Severity: apidiags.DiagnosticError, Code: apidiags.CodeNotFound}, // want `Diagnostic with code "not_found" has no Paths`
+}
@@ -0,0 +1,7 @@
+package admin
+
+import "net/http"
+
+func deleteWidget(w http.ResponseWriter, _ *http.Request) {
+	http.Error(w, "forbidden", http.StatusForbidden) // want `http.Error writes a plain text error`
+}
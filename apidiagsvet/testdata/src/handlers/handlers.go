@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"impractical.co/apidiags"
+)
+
+const statusTeapot = 418
+
+func getWidget(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("id") == "" {
+		http.Error(w, "id is required", http.StatusBadRequest) // want `http.Error writes a plain text error; write Diagnostics with apidiags.WriteHTTP instead`
+		return
+	}
+	if r.URL.Query().Get("id") == "0" {
+		http.NotFound(w, r) // want `http.NotFound writes a plain text error`
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed) // want `handler writes error status 405 without Diagnostics; write them with apidiags.WriteHTTP instead`
+		fmt.Fprintf(w, "can't %s a widget", r.Method)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, "widget")
+}
+
+func createWidget(w http.ResponseWriter, r *http.Request) {
+	if r.ContentLength == 0 {
+		_ = apidiags.WriteHTTP(w, apidiags.Diagnostics{
+			{Severity: apidiags.DiagnosticError, Code: apidiags.CodeMissing, Paths: []apidiags.Steps{apidiags.BodyPath()}},
+		})
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+var routes = map[string]http.HandlerFunc{
+	"/teapot": func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(statusTeapot) // want `handler writes error status 418`
+	},
+}
+
+// statusRecorder's WriteHeader isn't http.ResponseWriter's, so calls to it
+// aren't reported.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func record(w http.ResponseWriter, status int) {
+	(&statusRecorder{ResponseWriter: w}).WriteHeader(http.StatusInternalServerError)
+	w.WriteHeader(status)
+}
@@ -0,0 +1,7 @@
+package handlers
+
+import "net/http"
+
+func fakeUpstream(w http.ResponseWriter, _ *http.Request) {
+	http.Error(w, "unavailable", http.StatusServiceUnavailable)
+}
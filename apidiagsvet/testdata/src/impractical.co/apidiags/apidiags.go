@@ -0,0 +1,85 @@
+// Package apidiags is a stand-in for the parts of impractical.co/apidiags
+// the Analyzer's tests use.
+package apidiags
+
+import "net/http"
+
+type Severity string
+
+const (
+	DiagnosticError   Severity = "error"
+	DiagnosticWarning Severity = "warning"
+)
+
+type Code string
+
+const (
+	CodeInvalidValue Code = "invalid_value"
+	CodeMissing      Code = "missing"
+	CodeNotFound     Code = "not_found"
+	CodeActOfGod     Code = "act_of_god"
+)
+
+type Diagnostic struct {
+	Severity Severity
+	Code     Code
+	Paths    []Steps
+	Summary  string
+}
+
+func (d Diagnostic) PrependPath(prefix Steps) Diagnostic { return d }
+
+type Diagnostics []Diagnostic
+
+func (diags Diagnostics) PrependPath(prefix Steps) Diagnostics { return diags }
+
+type CodeInfo struct {
+	Code        Code
+	Description string
+}
+
+func RegisterCode(info CodeInfo) error { return nil }
+
+func WriteHTTP(w http.ResponseWriter, diags Diagnostics) error { return nil }
+
+type Step interface{ step() }
+
+type BodyStep struct{}
+
+func (BodyStep) step() {}
+
+type HeaderStep string
+
+func (HeaderStep) step() {}
+
+type URLParamStep string
+
+func (URLParamStep) step() {}
+
+type ArrayIndexStep int64
+
+func (ArrayIndexStep) step() {}
+
+type ObjectPropertyStep string
+
+func (ObjectPropertyStep) step() {}
+
+type StringIndexStep int64
+
+func (StringIndexStep) step() {}
+
+type RuneIndexStep int64
+
+func (RuneIndexStep) step() {}
+
+type Steps []Step
+
+func (s Steps) AddStep(step Step) Steps { return append(s, step) }
+
+func PathOf(steps ...Step) Steps { return Steps(steps) }
+
+func BodyPath() Steps { return Steps{BodyStep{}} }
+
+func HeaderPath(header string) Steps { return Steps{HeaderStep(header)} }
+
+func URLParamPath(param string) Steps { return Steps{URLParamStep(param)} }
@@ -0,0 +1,7 @@
+package unchecked
+
+import "net/http"
+
+func legacy(w http.ResponseWriter, _ *http.Request) {
+	http.Error(w, "gone", http.StatusGone)
+}
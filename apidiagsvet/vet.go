@@ -0,0 +1,525 @@
+// Package apidiagsvet provides an analysis.Analyzer that checks how
+// apidiags Diagnostics are constructed, so mistakes are caught by go vet
+// instead of showing up in production responses.
+//
+// The Analyzer reports:
+//
+//   - Diagnostics whose Code is a constant that isn't a known Code, like a
+//     typo of one. Codes are known if they're declared as apidiags.Code
+//     constants, or registered with a CodeInfo literal, in the package
+//     being checked or any package it depends on, or listed using the
+//     -codes flag.
+//   - Steps that can't describe part of a request: a BodyStep, HeaderStep,
+//     or URLParamStep anywhere but the start of a path, anything after a
+//     StringIndexStep or RuneIndexStep, and negative indices.
+//   - Diagnostic literals with a Code that describes a value, listed using
+//     the -path-required flag, but no Paths. Literals that PrependPath is
+//     called on aren't reported, as it gives them a Path.
+//   - HTTP handlers that write errors without Diagnostics, using http.Error,
+//     http.NotFound, or http.ResponseWriter.WriteHeader with a constant
+//     status of 400 or more, whether or not a body is written after it.
+//     Only packages listed using the -handler-packages flag are checked, so
+//     a codebase can adopt apidiags one package at a time.
+//
+// It can be run using go vet, with the apidiagsvet command:
+//
+//	go vet -vettool=$(which apidiagsvet) ./...
+package apidiagsvet
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+
+	"impractical.co/apidiags"
+)
+
+// apidiagsPath is the import path of the apidiags package.
+var apidiagsPath = reflect.TypeOf(apidiags.Diagnostic{}).PkgPath()
+
+// DefaultPathRequiredCodes are the Codes that Diagnostics need Paths for,
+// unless the -path-required flag is set. They're the builtin Codes that
+// describe a problem with a specific value in the request.
+var DefaultPathRequiredCodes = []string{
+	string(apidiags.CodeConflict),
+	string(apidiags.CodeInsufficient),
+	string(apidiags.CodeInvalidFormat),
+	string(apidiags.CodeInvalidValue),
+	string(apidiags.CodeMissing),
+	string(apidiags.CodeOverflow),
+}
+
+// Analyzer reports problems with how Diagnostics are constructed, as
+// described in the package documentation.
+var Analyzer = &analysis.Analyzer{
+	Name:      "apidiags",
+	Doc:       "check for unknown codes, invalid paths, and missing paths in apidiags Diagnostics, and handlers writing errors without them",
+	URL:       "https://pkg.go.dev/impractical.co/apidiags/apidiagsvet",
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	FactTypes: []analysis.Fact{new(codesFact)},
+	Run:       run,
+}
+
+var (
+	extraCodes    listFlag
+	pathRequired  = listFlag(DefaultPathRequiredCodes)
+	firstSteps    = []string{"BodyStep", "HeaderStep", "URLParamStep"}
+	characterStep = []string{"StringIndexStep", "RuneIndexStep"}
+	indexSteps    = []string{"ArrayIndexStep", "StringIndexStep", "RuneIndexStep"}
+)
+
+func init() {
+	Analyzer.Flags.Var(&extraCodes, "codes", "comma-separated list of codes to treat as known, in addition to those declared or registered")
+	Analyzer.Flags.Var(&pathRequired, "path-required", "comma-separated list of codes Diagnostics need Paths for")
+	Analyzer.Flags.Var(&handlerPackages, "handler-packages", "comma-separated list of packages, or patterns like example.com/api/..., whose handlers must write errors with apidiags")
+}
+
+// listFlag is a flag.Value holding a comma-separated list.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// codesFact is a package fact listing the Codes a package declares or
+// registers, so packages depending on it know about them.
+type codesFact struct {
+	Codes []string
+}
+
+func (*codesFact) AFact() {}
+
+func (f *codesFact) String() string {
+	return "codes(" + strings.Join(f.Codes, ", ") + ")"
+}
+
+// checker holds the state of a single run of the Analyzer.
+type checker struct {
+	pass  *analysis.Pass
+	known map[string]bool
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	c := &checker{pass: pass, known: map[string]bool{}}
+	if checksHandlers(pass.Pkg.Path()) {
+		c.checkHandlers(inspect)
+	}
+	if !usesAPIDiags(pass.Pkg) {
+		return nil, nil
+	}
+
+	declared := c.declaredCodes(inspect)
+	if len(declared) > 0 {
+		pass.ExportPackageFact(&codesFact{Codes: declared})
+	}
+	for _, code := range declared {
+		c.known[code] = true
+	}
+	for _, fact := range pass.AllPackageFacts() {
+		if codes, ok := fact.Fact.(*codesFact); ok {
+			for _, code := range codes.Codes {
+				c.known[code] = true
+			}
+		}
+	}
+	for _, code := range extraCodes {
+		c.known[code] = true
+	}
+
+	nodes := []ast.Node{(*ast.CompositeLit)(nil), (*ast.CallExpr)(nil), (*ast.AssignStmt)(nil)}
+	inspect.WithStack(nodes, func(node ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		switch node := node.(type) {
+		case *ast.CompositeLit:
+			switch {
+			case c.isType(c.pass.TypesInfo.TypeOf(node), "Diagnostic"):
+				c.checkDiagnostic(node, stack)
+			case c.isType(c.pass.TypesInfo.TypeOf(node), "Steps"):
+				c.checkSteps(nil, node.Elts)
+			}
+		case *ast.CallExpr:
+			c.checkCall(node)
+		case *ast.AssignStmt:
+			c.checkAssign(node)
+		}
+		return true
+	})
+	return nil, nil
+}
+
+// usesAPIDiags returns true if pkg is the apidiags package, or imports it.
+func usesAPIDiags(pkg *types.Package) bool {
+	if pkg.Path() == apidiagsPath {
+		return true
+	}
+	for _, imported := range pkg.Imports() {
+		if imported.Path() == apidiagsPath {
+			return true
+		}
+	}
+	return false
+}
+
+// declaredCodes returns the Codes declared as constants or registered with
+// CodeInfo literals in the package, in lexical order.
+func (c *checker) declaredCodes(inspect *inspector.Inspector) []string {
+	codes := map[string]bool{}
+	for _, obj := range c.pass.TypesInfo.Defs {
+		if cnst, ok := obj.(*types.Const); ok && c.isType(cnst.Type(), "Code") {
+			codes[constant.StringVal(cnst.Val())] = true
+		}
+	}
+	inspect.Preorder([]ast.Node{(*ast.CompositeLit)(nil)}, func(node ast.Node) {
+		lit := node.(*ast.CompositeLit)
+		if !c.isType(c.pass.TypesInfo.TypeOf(lit), "CodeInfo") {
+			return
+		}
+		if code := c.field(lit, "Code", 0); code != nil {
+			if value, ok := c.stringConst(code); ok {
+				codes[value] = true
+			}
+		}
+	})
+	results := make([]string, 0, len(codes))
+	for code := range codes {
+		results = append(results, code)
+	}
+	sort.Strings(results)
+	return results
+}
+
+// isType returns true if typ is the apidiags type named name.
+func (c *checker) isType(typ types.Type, name string) bool {
+	if typ == nil {
+		return false
+	}
+	named, ok := types.Unalias(typ).(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Name() == name && obj.Pkg() != nil && obj.Pkg().Path() == apidiagsPath
+}
+
+// isFunc returns true if fn is the apidiags function named name.
+func (c *checker) isFunc(fn ast.Expr, name string) bool {
+	var ident *ast.Ident
+	switch fn := ast.Unparen(fn).(type) {
+	case *ast.Ident:
+		ident = fn
+	case *ast.SelectorExpr:
+		ident = fn.Sel
+	default:
+		return false
+	}
+	obj, ok := c.pass.TypesInfo.Uses[ident].(*types.Func)
+	return ok && obj.Name() == name && obj.Pkg() != nil && obj.Pkg().Path() == apidiagsPath &&
+		obj.Type().(*types.Signature).Recv() == nil
+}
+
+// field returns the value of the field named name in lit, which is at
+// position pos if lit's fields aren't keyed, or nil if it isn't set.
+func (c *checker) field(lit *ast.CompositeLit, name string, pos int) ast.Expr {
+	for i, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			if i == pos {
+				return elt
+			}
+			continue
+		}
+		if key, ok := kv.Key.(*ast.Ident); ok && key.Name == name {
+			return kv.Value
+		}
+	}
+	return nil
+}
+
+// stringConst returns the value of expr, if it's a string constant.
+func (c *checker) stringConst(expr ast.Expr) (string, bool) {
+	tv, ok := c.pass.TypesInfo.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+// checkDiagnostic checks the Code and Paths of lit, a Diagnostic literal.
+// stack holds lit and the nodes containing it.
+func (c *checker) checkDiagnostic(lit *ast.CompositeLit, stack []ast.Node) {
+	codeExpr := c.field(lit, "Code", 1)
+	if codeExpr == nil {
+		return
+	}
+	code, ok := c.checkCode(codeExpr)
+	if !ok || !slices.Contains(pathRequired, code) || c.field(lit, "Paths", 2) != nil {
+		return
+	}
+	if prependsPath(stack) {
+		return
+	}
+	c.pass.ReportRangef(lit, "Diagnostic with code %q has no Paths; set them to the part of the request it's about", code)
+}
+
+// prependsPath returns true if the last node of stack, a Diagnostic literal,
+// is given a Path by PrependPath, either directly or as part of a
+// Diagnostics literal.
+func prependsPath(stack []ast.Node) bool {
+	for pos := len(stack) - 2; pos >= 0; pos-- {
+		switch node := stack[pos].(type) {
+		case *ast.CompositeLit, *ast.ParenExpr:
+			continue
+		case *ast.SelectorExpr:
+			return node.Sel.Name == "PrependPath"
+		}
+		return false
+	}
+	return false
+}
+
+// checkCode reports expr, the Code of a Diagnostic, if it's a constant that
+// isn't a known Code. It returns the Code, if it's a constant.
+func (c *checker) checkCode(expr ast.Expr) (string, bool) {
+	code, ok := c.stringConst(expr)
+	if !ok {
+		return "", false
+	}
+	if c.isConst(expr) || c.known[code] {
+		return code, true
+	}
+	if code == "" {
+		c.pass.ReportRangef(expr, "Diagnostic has an empty code")
+		return code, true
+	}
+	suggestion, ok := c.closestCode(code)
+	if !ok {
+		c.pass.ReportRangef(expr, "unknown code %q; declare it as an apidiags.Code constant or register it", code)
+		return code, true
+	}
+	c.pass.Report(analysis.Diagnostic{
+		Pos:     expr.Pos(),
+		End:     expr.End(),
+		Message: fmt.Sprintf("unknown code %q; did you mean %q?", code, suggestion),
+		SuggestedFixes: []analysis.SuggestedFix{{
+			Message: fmt.Sprintf("Use %q", suggestion),
+			TextEdits: []analysis.TextEdit{{
+				Pos:     expr.Pos(),
+				End:     expr.End(),
+				NewText: []byte(strconv.Quote(suggestion)),
+			}},
+		}},
+	})
+	return code, true
+}
+
+// isConst returns true if expr refers to a declared constant, which is
+// what declares a Code.
+func (c *checker) isConst(expr ast.Expr) bool {
+	var ident *ast.Ident
+	switch expr := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		ident = expr
+	case *ast.SelectorExpr:
+		ident = expr.Sel
+	default:
+		return false
+	}
+	_, ok := c.pass.TypesInfo.Uses[ident].(*types.Const)
+	return ok
+}
+
+// closestCode returns the known Code most similar to code, if one is close
+// enough to be a typo of it.
+func (c *checker) closestCode(code string) (string, bool) {
+	// allow about one mistake for every three characters
+	best, bestDistance := "", max(1, len(code)/3)+1
+	known := make([]string, 0, len(c.known))
+	for candidate := range c.known {
+		known = append(known, candidate)
+	}
+	sort.Strings(known)
+	for _, candidate := range known {
+		if distance := editDistance(code, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best, best != ""
+}
+
+// editDistance returns the number of characters that have to be inserted,
+// deleted, replaced, or swapped with their neighbor to turn a into b.
+func editDistance(a, b string) int {
+	distances := make([][]int, len(a)+1)
+	for i := range distances {
+		distances[i] = make([]int, len(b)+1)
+		distances[i][0] = i
+	}
+	for j := range distances[0] {
+		distances[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			distances[i][j] = min(distances[i-1][j]+1, distances[i][j-1]+1, distances[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				distances[i][j] = min(distances[i][j], distances[i-2][j-2]+1)
+			}
+		}
+	}
+	return distances[len(a)][len(b)]
+}
+
+// checkCall checks the Steps built by calls to PathOf and Steps.AddStep.
+func (c *checker) checkCall(call *ast.CallExpr) {
+	if call.Ellipsis != token.NoPos {
+		return
+	}
+	if c.isFunc(call.Fun, "PathOf") {
+		c.checkSteps(nil, call.Args)
+		return
+	}
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "AddStep" || len(call.Args) != 1 || !c.isType(c.pass.TypesInfo.TypeOf(sel.X), "Steps") {
+		return
+	}
+	prefix, known := c.pathSteps(sel.X)
+	if !known {
+		prefix = nil
+	}
+	c.checkStep(prefix, known, call.Args[0])
+}
+
+// checkAssign checks the Codes assigned to Diagnostics' Code fields.
+func (c *checker) checkAssign(assign *ast.AssignStmt) {
+	if len(assign.Lhs) != len(assign.Rhs) {
+		return
+	}
+	for pos, lhs := range assign.Lhs {
+		sel, ok := ast.Unparen(lhs).(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Code" {
+			continue
+		}
+		typ := c.pass.TypesInfo.TypeOf(sel.X)
+		if ptr, ok := typ.(*types.Pointer); ok {
+			typ = ptr.Elem()
+		}
+		if c.isType(typ, "Diagnostic") {
+			c.checkCode(assign.Rhs[pos])
+		}
+	}
+}
+
+// checkSteps checks steps, which follow prefix.
+func (c *checker) checkSteps(prefix []string, steps []ast.Expr) {
+	for _, step := range steps {
+		if _, ok := step.(*ast.KeyValueExpr); ok {
+			return
+		}
+		c.checkStep(prefix, true, step)
+		prefix = append(prefix, c.stepKind(step))
+	}
+}
+
+// checkStep checks step, which follows prefix. If known is false, nothing
+// is known about prefix.
+func (c *checker) checkStep(prefix []string, known bool, step ast.Expr) {
+	kind := c.stepKind(step)
+	if kind == "" {
+		return
+	}
+	if slices.Contains(indexSteps, kind) {
+		if tv := c.pass.TypesInfo.Types[step]; tv.Value != nil && constant.Sign(tv.Value) < 0 {
+			c.pass.ReportRangef(step, "apidiags.%s can't be negative", kind)
+		}
+	}
+	if !known || len(prefix) < 1 {
+		return
+	}
+	if slices.Contains(firstSteps, kind) {
+		c.pass.ReportRangef(step, "apidiags.%s must be the first step of a path", kind)
+		return
+	}
+	if last := prefix[len(prefix)-1]; slices.Contains(characterStep, last) {
+		c.pass.ReportRangef(step, "apidiags.%s can't follow an apidiags.%s, which points to a single character", kind, last)
+	}
+}
+
+// pathSteps returns the kinds of the steps of the Steps expr evaluates to,
+// if they can be determined statically.
+func (c *checker) pathSteps(expr ast.Expr) ([]string, bool) {
+	switch expr := ast.Unparen(expr).(type) {
+	case *ast.CompositeLit:
+		var results []string
+		for _, elt := range expr.Elts {
+			if _, ok := elt.(*ast.KeyValueExpr); ok {
+				return nil, false
+			}
+			results = append(results, c.stepKind(elt))
+		}
+		return results, true
+	case *ast.CallExpr:
+		switch {
+		case c.isFunc(expr.Fun, "BodyPath"):
+			return []string{"BodyStep"}, true
+		case c.isFunc(expr.Fun, "HeaderPath"):
+			return []string{"HeaderStep"}, true
+		case c.isFunc(expr.Fun, "URLParamPath"):
+			return []string{"URLParamStep"}, true
+		case c.isFunc(expr.Fun, "PathOf") && expr.Ellipsis == token.NoPos:
+			results := make([]string, 0, len(expr.Args))
+			for _, arg := range expr.Args {
+				results = append(results, c.stepKind(arg))
+			}
+			return results, true
+		}
+		sel, ok := ast.Unparen(expr.Fun).(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "AddStep" || len(expr.Args) != 1 || !c.isType(c.pass.TypesInfo.TypeOf(sel.X), "Steps") {
+			return nil, false
+		}
+		prefix, ok := c.pathSteps(sel.X)
+		if !ok {
+			return nil, false
+		}
+		return append(prefix, c.stepKind(expr.Args[0])), true
+	}
+	return nil, false
+}
+
+// stepKind returns the name of the apidiags Step type of expr, or an empty
+// string if it isn't known.
+func (c *checker) stepKind(expr ast.Expr) string {
+	typ := c.pass.TypesInfo.TypeOf(expr)
+	for _, kind := range []string{"BodyStep", "HeaderStep", "URLParamStep", "ArrayIndexStep", "ObjectPropertyStep", "StringIndexStep", "RuneIndexStep"} {
+		if c.isType(typ, kind) {
+			return kind
+		}
+	}
+	return ""
+}
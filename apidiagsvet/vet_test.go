@@ -0,0 +1,29 @@
+package apidiagsvet
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "codes", "example")
+}
+
+func TestAnalyzerSuggestedFixes(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), Analyzer, "fixes")
+}
+
+func TestAnalyzerFlags(t *testing.T) {
+	for name, value := range map[string]string{"codes": "teapot, kettle", "path-required": "not_found"} {
+		previous := Analyzer.Flags.Lookup(name).Value.String()
+		err := Analyzer.Flags.Set(name, value)
+		if err != nil {
+			t.Fatalf("error setting -%s: %s", name, err)
+		}
+		t.Cleanup(func() {
+			_ = Analyzer.Flags.Set(name, previous)
+		})
+	}
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "flags")
+}
@@ -0,0 +1,13 @@
+module impractical.co/apidiags/apidiagszap
+
+go 1.19
+
+require (
+	github.com/google/go-cmp v0.5.9
+	go.uber.org/zap v1.28.0
+	impractical.co/apidiags v0.0.0
+)
+
+require go.uber.org/multierr v1.10.0 // indirect
+
+replace impractical.co/apidiags => ../
@@ -0,0 +1,70 @@
+// Package apidiagszap logs apidiags Diagnostics with zap as structured
+// objects, without encoding them as JSON strings first.
+//
+//	logger.Warn("request failed validation", apidiagszap.Field("diagnostics", diags))
+package apidiagszap
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"impractical.co/apidiags"
+)
+
+// Diagnostic is an apidiags.Diagnostic that zap can log as an object, with
+// the same fields apidiags.Diagnostic.LogValue logs for slog: severity,
+// code, path or paths, summary, detail, and doc_url, leaving out those that
+// are empty. Paths are rendered using apidiags.Steps.String.
+type Diagnostic apidiags.Diagnostic
+
+// MarshalLogObject implements zapcore.ObjectMarshaler.
+func (d Diagnostic) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	d = Diagnostic(apidiags.Diagnostic(d).RenderMessage(nil))
+	enc.AddString("severity", string(d.Severity))
+	enc.AddString("code", string(d.Code))
+	switch len(d.Paths) {
+	case 0:
+	case 1:
+		enc.AddString("path", d.Paths[0].String())
+	default:
+		err := enc.AddArray("paths", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+			for _, path := range d.Paths {
+				arr.AppendString(path.String())
+			}
+			return nil
+		}))
+		if err != nil {
+			return err
+		}
+	}
+	if d.Summary != "" {
+		enc.AddString("summary", d.Summary)
+	}
+	if d.Detail != "" {
+		enc.AddString("detail", d.Detail)
+	}
+	if d.DocURL != "" {
+		enc.AddString("doc_url", d.DocURL)
+	}
+	return nil
+}
+
+// Diagnostics is an apidiags.Diagnostics that zap can log as an array of
+// objects, each logged like Diagnostic.
+type Diagnostics apidiags.Diagnostics
+
+// MarshalLogArray implements zapcore.ArrayMarshaler.
+func (diags Diagnostics) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, diag := range diags {
+		if err := enc.AppendObject(Diagnostic(diag)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Field returns a zap.Field logging diags under key, as an array of
+// objects.
+func Field(key string, diags apidiags.Diagnostics) zap.Field {
+	return zap.Array(key, Diagnostics(diags))
+}
@@ -0,0 +1,75 @@
+package apidiagszap
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"impractical.co/apidiags"
+)
+
+func TestField(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags    apidiags.Diagnostics
+		expected map[string]any
+	}
+
+	cases := map[string]testCase{
+		"empty": {
+			expected: map[string]any{"diagnostics": []any{}},
+		},
+		"diagnostics": {
+			diags: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name"))},
+					Summary:  "Name is required.",
+				},
+				{
+					Severity: apidiags.DiagnosticWarning,
+					Code:     apidiags.CodeDeprecated,
+					Paths:    []apidiags.Steps{apidiags.URLParamPath("a"), apidiags.HeaderPath("B")},
+					Detail:   "Use v2.",
+					DocURL:   "https://example.com",
+				},
+			},
+			expected: map[string]any{"diagnostics": []any{
+				map[string]any{
+					"severity": "error",
+					"code":     "missing",
+					"path":     "body.name",
+					"summary":  "Name is required.",
+				},
+				map[string]any{
+					"severity": "warning",
+					"code":     "deprecated",
+					"paths":    []any{`url_param("a")`, `header("B")`},
+					"detail":   "Use v2.",
+					"doc_url":  "https://example.com",
+				},
+			}},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			core, logs := observer.New(zap.InfoLevel)
+			zap.New(core).Info("test", Field("diagnostics", tc.diags))
+			entries := logs.All()
+			if len(entries) != 1 {
+				t.Fatalf("expected 1 entry, got %d", len(entries))
+			}
+			if diff := cmp.Diff(tc.expected, entries[0].ContextMap()); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
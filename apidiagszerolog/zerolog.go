@@ -0,0 +1,63 @@
+// Package apidiagszerolog logs apidiags Diagnostics with zerolog as
+// structured objects, without encoding them as JSON strings first.
+//
+//	log.Warn().Array("diagnostics", apidiagszerolog.Diagnostics(diags)).Msg("request failed validation")
+package apidiagszerolog
+
+import (
+	"github.com/rs/zerolog"
+
+	"impractical.co/apidiags"
+)
+
+// Diagnostic is an apidiags.Diagnostic that zerolog can log as an object,
+// with the same fields apidiags.Diagnostic.LogValue logs for slog:
+// severity, code, path or paths, summary, detail, and doc_url, leaving out
+// those that are empty. Paths are rendered using apidiags.Steps.String.
+type Diagnostic apidiags.Diagnostic
+
+// MarshalZerologObject implements zerolog.LogObjectMarshaler.
+func (d Diagnostic) MarshalZerologObject(e *zerolog.Event) {
+	d = Diagnostic(apidiags.Diagnostic(d).RenderMessage(nil))
+	e.Str("severity", string(d.Severity))
+	e.Str("code", string(d.Code))
+	switch len(d.Paths) {
+	case 0:
+	case 1:
+		e.Str("path", d.Paths[0].String())
+	default:
+		paths := make([]string, 0, len(d.Paths))
+		for _, path := range d.Paths {
+			paths = append(paths, path.String())
+		}
+		e.Strs("paths", paths)
+	}
+	if d.Summary != "" {
+		e.Str("summary", d.Summary)
+	}
+	if d.Detail != "" {
+		e.Str("detail", d.Detail)
+	}
+	if d.DocURL != "" {
+		e.Str("doc_url", d.DocURL)
+	}
+}
+
+// Diagnostics is an apidiags.Diagnostics that zerolog can log as an array
+// of objects, each logged like Diagnostic.
+type Diagnostics apidiags.Diagnostics
+
+// MarshalZerologArray implements zerolog.LogArrayMarshaler.
+func (diags Diagnostics) MarshalZerologArray(a *zerolog.Array) {
+	for _, diag := range diags {
+		a.Object(Diagnostic(diag))
+	}
+}
+
+// Event adds diags to e under key, as an array of objects, and returns e,
+// for use in a chain of zerolog calls like
+//
+//	apidiagszerolog.Event(log.Warn(), "diagnostics", diags).Msg("request failed validation")
+func Event(e *zerolog.Event, key string, diags apidiags.Diagnostics) *zerolog.Event {
+	return e.Array(key, Diagnostics(diags))
+}
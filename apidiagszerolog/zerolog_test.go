@@ -0,0 +1,60 @@
+package apidiagszerolog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"impractical.co/apidiags"
+)
+
+func TestEvent(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags    apidiags.Diagnostics
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"empty": {
+			expected: `{"level":"info","diagnostics":[],"message":"test"}` + "\n",
+		},
+		"diagnostics": {
+			diags: apidiags.Diagnostics{
+				{
+					Severity: apidiags.DiagnosticError,
+					Code:     apidiags.CodeMissing,
+					Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name"))},
+					Summary:  "Name is required.",
+				},
+				{
+					Severity: apidiags.DiagnosticWarning,
+					Code:     apidiags.CodeDeprecated,
+					Paths:    []apidiags.Steps{apidiags.URLParamPath("a"), apidiags.HeaderPath("B")},
+					Detail:   "Use v2.",
+					DocURL:   "https://example.com",
+				},
+			},
+			expected: `{"level":"info","diagnostics":[` +
+				`{"severity":"error","code":"missing","path":"body.name","summary":"Name is required."},` +
+				`{"severity":"warning","code":"deprecated","paths":["url_param(\"a\")","header(\"B\")"],"detail":"Use v2.","doc_url":"https://example.com"}` +
+				`],"message":"test"}` + "\n",
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			logger := zerolog.New(&buf)
+			Event(logger.Info(), "diagnostics", tc.diags).Msg("test")
+			if got := buf.String(); got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
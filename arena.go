@@ -0,0 +1,142 @@
+package apidiags
+
+import "sync"
+
+// The most Diagnostics, Paths, and Steps an Arena can have room for and
+// still be put back in arenaPool by Release, so one unusually large
+// response doesn't keep its memory alive.
+const (
+	maxPooledArenaDiagnostics = 1024
+	maxPooledArenaPaths       = 1024
+	maxPooledArenaSteps       = 8192
+)
+
+// The fewest Paths and Steps an Arena makes room for whenever it runs out,
+// so it doesn't allocate for every path while it's still small.
+const (
+	minArenaPaths = 16
+	minArenaSteps = 64
+)
+
+// arenaPool holds the Arenas returned by AcquireArena.
+var arenaPool = sync.Pool{
+	New: func() any {
+		return new(Arena)
+	},
+}
+
+// Arena holds the memory of the Diagnostics built through it, so services
+// producing very many Diagnostics can reuse it instead of allocating it
+// again for every request. Most services don't need an Arena; Diagnostics
+// built the usual way are always safe to use.
+//
+// An Arena is acquired, populated, and released once the Diagnostics have
+// been written:
+//
+//	arena := apidiags.AcquireArena()
+//	defer arena.Release()
+//	arena.Add(apidiags.Diagnostic{
+//		Severity: apidiags.DiagnosticError,
+//		Code:     apidiags.CodeMissing,
+//		Paths:    arena.Paths(arena.Path(apidiags.BodyStep{}, apidiags.ObjectPropertyStep("name"))),
+//	})
+//	return apidiags.WriteHTTP(w, arena.Diagnostics())
+//
+// Everything an Arena returns shares its memory, and must not be used once
+// the Arena is released or reset; Diagnostics that need to outlive it, like
+// ones sent to a background logger, must have their Paths copied first.
+// The Steps and Paths an Arena returns have no room to grow, so appending
+// to them copies them instead of writing over others.
+//
+// The zero value is ready to use, without a pool. An Arena isn't safe for
+// concurrent use.
+type Arena struct {
+	diags Diagnostics
+	paths []Steps
+	steps []Step
+}
+
+// AcquireArena returns an empty Arena from a shared pool. It should be
+// given back with Release once the Diagnostics built through it have been
+// written.
+func AcquireArena() *Arena {
+	return arenaPool.Get().(*Arena)
+}
+
+// Release resets the Arena and puts it back in the pool AcquireArena takes
+// Arenas from. Neither the Arena nor anything it returned may be used
+// afterwards.
+func (a *Arena) Release() {
+	if cap(a.diags) > maxPooledArenaDiagnostics || cap(a.paths) > maxPooledArenaPaths || cap(a.steps) > maxPooledArenaSteps {
+		return
+	}
+	a.Reset()
+	arenaPool.Put(a)
+}
+
+// Reset empties the Arena so it can be used again, keeping the memory it
+// has. Nothing it returned before may be used afterwards.
+func (a *Arena) Reset() {
+	for i := range a.diags {
+		a.diags[i] = Diagnostic{}
+	}
+	a.diags = a.diags[:0]
+	for i := range a.paths {
+		a.paths[i] = nil
+	}
+	a.paths = a.paths[:0]
+	for i := range a.steps {
+		a.steps[i] = nil
+	}
+	a.steps = a.steps[:0]
+}
+
+// Add adds diag to the Diagnostics returned by Diagnostics.
+func (a *Arena) Add(diag Diagnostic) {
+	a.diags = append(a.diags, diag)
+}
+
+// Diagnostics returns the Diagnostics added to the Arena so far, in order,
+// or nil if none have been.
+func (a *Arena) Diagnostics() Diagnostics {
+	if len(a.diags) == 0 {
+		return nil
+	}
+	return a.diags[:len(a.diags):len(a.diags)]
+}
+
+// Path returns Steps made up of steps, in the Arena's memory.
+func (a *Arena) Path(steps ...Step) Steps {
+	if len(a.steps)+len(steps) > cap(a.steps) {
+		// earlier Steps still use the old memory, so it's left to them
+		size := 2 * cap(a.steps)
+		if size < minArenaSteps {
+			size = minArenaSteps
+		}
+		if size < len(steps) {
+			size = len(steps)
+		}
+		a.steps = make([]Step, 0, size)
+	}
+	start := len(a.steps)
+	a.steps = append(a.steps, steps...)
+	return a.steps[start:len(a.steps):len(a.steps)]
+}
+
+// Paths returns a slice of paths, for a Diagnostic's Paths, in the Arena's
+// memory.
+func (a *Arena) Paths(paths ...Steps) []Steps {
+	if len(a.paths)+len(paths) > cap(a.paths) {
+		size := 2 * cap(a.paths)
+		if size < minArenaPaths {
+			size = minArenaPaths
+		}
+		if size < len(paths) {
+			size = len(paths)
+		}
+		a.paths = make([]Steps, 0, size)
+	}
+	start := len(a.paths)
+	a.paths = append(a.paths, paths...)
+	return a.paths[start:len(a.paths):len(a.paths)]
+}
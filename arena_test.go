@@ -0,0 +1,177 @@
+package apidiags
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// buildArenaDiagnostics adds a Diagnostic for each of the first n items of
+// a request body to arena, with the same Paths buildDiagnostics uses.
+func buildArenaDiagnostics(arena *Arena, n int) {
+	for pos := 0; pos < n; pos++ {
+		arena.Add(Diagnostic{
+			Severity: DiagnosticError,
+			Code:     CodeMissing,
+			Paths: arena.Paths(
+				arena.Path(BodyStep{}, ObjectPropertyStep("items"), ArrayIndexStep(pos), ObjectPropertyStep("name")),
+				arena.Path(BodyStep{}, ObjectPropertyStep("items"), ArrayIndexStep(pos), ObjectPropertyStep("id")),
+			),
+		})
+	}
+}
+
+// buildDiagnostics builds the Diagnostics buildArenaDiagnostics does,
+// without an Arena.
+func buildDiagnostics(n int) Diagnostics {
+	var diags Diagnostics
+	for pos := 0; pos < n; pos++ {
+		item := BodyPath().AddSteps(ObjectPropertyStep("items"), ArrayIndexStep(pos))
+		diags = append(diags, Diagnostic{
+			Severity: DiagnosticError,
+			Code:     CodeMissing,
+			Paths:    []Steps{item.AddStep(ObjectPropertyStep("name")), item.AddStep(ObjectPropertyStep("id"))},
+		})
+	}
+	return diags
+}
+
+func TestArenaMatchesBuilt(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		n int
+	}
+
+	cases := map[string]testCase{
+		"empty": {},
+		"one":   {n: 1},
+		"grown": {n: 500},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			arena := AcquireArena()
+			defer arena.Release()
+			buildArenaDiagnostics(arena, tc.n)
+			if diff := cmp.Diff(buildDiagnostics(tc.n), arena.Diagnostics()); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestArenaPathsDontAlias(t *testing.T) {
+	t.Parallel()
+
+	var arena Arena
+	first := arena.Path(BodyStep{}, ObjectPropertyStep("first"))
+	second := arena.Path(BodyStep{}, ObjectPropertyStep("second"))
+	grown := first.AddStep(ObjectPropertyStep("name"))
+	appended := append(first, ArrayIndexStep(1))
+	paths := arena.Paths(first)
+	paths = append(paths, second)
+	others := arena.Paths(grown, appended)
+
+	if diff := cmp.Diff(Steps{BodyStep{}, ObjectPropertyStep("first")}, first); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	if diff := cmp.Diff(Steps{BodyStep{}, ObjectPropertyStep("second")}, second); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	if diff := cmp.Diff([]Steps{first, second}, paths); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	if diff := cmp.Diff([]Steps{grown, appended}, others); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	diags := arena.Diagnostics()
+	arena.Add(Diagnostic{Code: CodeMissing})
+	diags = append(diags, Diagnostic{Code: CodeConflict})
+	if diff := cmp.Diff(Diagnostics{{Code: CodeMissing}}, arena.Diagnostics()); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	if diff := cmp.Diff(Diagnostics{{Code: CodeConflict}}, diags); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+}
+
+func TestArenaLongPath(t *testing.T) {
+	t.Parallel()
+
+	var arena Arena
+	short := arena.Path(BodyStep{})
+	long := make(Steps, 3*minArenaSteps)
+	for pos := range long {
+		long[pos] = ArrayIndexStep(pos)
+	}
+	if diff := cmp.Diff(long, arena.Path(long...)); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	if diff := cmp.Diff(Steps{BodyStep{}}, short); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+}
+
+func TestArenaReset(t *testing.T) {
+	t.Parallel()
+
+	var arena Arena
+	buildArenaDiagnostics(&arena, 10)
+	diags, paths, steps := arena.diags, arena.paths, arena.steps
+	arena.Reset()
+	if len(arena.Diagnostics()) != 0 {
+		t.Errorf("expected no Diagnostics after Reset, got %d", len(arena.Diagnostics()))
+	}
+	for pos, diag := range diags {
+		if diag.Paths != nil {
+			t.Errorf("expected Diagnostic %d to be cleared, got %v", pos, diag)
+		}
+	}
+	for pos, path := range paths {
+		if path != nil {
+			t.Errorf("expected path %d to be cleared, got %v", pos, path)
+		}
+	}
+	for pos, step := range steps {
+		if step != nil {
+			t.Errorf("expected step %d to be cleared, got %v", pos, step)
+		}
+	}
+}
+
+func TestArenaDoesntAllocate(t *testing.T) {
+	var arena Arena
+	buildArenaDiagnostics(&arena, 100)
+	arena.Reset()
+	allocs := testing.AllocsPerRun(100, func() {
+		buildArenaDiagnostics(&arena, 100)
+		arena.Reset()
+	})
+	if allocs != 0 {
+		t.Errorf("expected reusing an Arena not to allocate, got %v allocations", allocs)
+	}
+}
+
+func BenchmarkArena(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		arena := AcquireArena()
+		buildArenaDiagnostics(arena, 100)
+		_ = arena.Diagnostics()
+		arena.Release()
+	}
+}
+
+// BenchmarkArenaBuilt measures building the Diagnostics BenchmarkArena
+// builds without an Arena, for comparison.
+func BenchmarkArenaBuilt(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = buildDiagnostics(100)
+	}
+}
@@ -0,0 +1,260 @@
+package apidiags
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AuditRecord describes a Diagnostic with a Severity of DiagnosticError
+// that was written in a response, along with the request it was written
+// for.
+type AuditRecord struct {
+	Time       time.Time
+	Method     string
+	Path       string
+	RemoteAddr string
+	UserAgent  string
+	Diagnostic Diagnostic
+}
+
+// AuditSink stores AuditRecords somewhere durable, like an audit log or a
+// queue. WriteAudit is called with batches of AuditRecords, from a single
+// goroutine at a time.
+type AuditSink interface {
+	WriteAudit(ctx context.Context, records []AuditRecord) error
+}
+
+// AuditSinkFunc is a function that implements AuditSink.
+type AuditSinkFunc func(ctx context.Context, records []AuditRecord) error
+
+// WriteAudit calls f(ctx, records).
+func (f AuditSinkFunc) WriteAudit(ctx context.Context, records []AuditRecord) error {
+	return f(ctx, records)
+}
+
+// AuditOption configures an Auditor.
+type AuditOption func(*auditConfig)
+
+type auditConfig struct {
+	batchSize  int
+	interval   time.Duration
+	bufferSize int
+	blocking   bool
+	onError    func(error, []AuditRecord)
+}
+
+// WithAuditBatchSize sets the most AuditRecords an Auditor passes to its
+// AuditSink at once. The default is 100, which is also used if n is zero or
+// less.
+func WithAuditBatchSize(n int) AuditOption {
+	return func(c *auditConfig) {
+		c.batchSize = n
+	}
+}
+
+// WithAuditFlushInterval sets how long an Auditor waits for a batch to
+// fill before passing it to its AuditSink anyway. The default is one
+// second, which is also used if interval is zero or less.
+func WithAuditFlushInterval(interval time.Duration) AuditOption {
+	return func(c *auditConfig) {
+		c.interval = interval
+	}
+}
+
+// WithAuditBufferSize sets how many AuditRecords an Auditor holds while
+// its AuditSink is busy. The default is 1000, which is also used if n is
+// zero or less.
+func WithAuditBufferSize(n int) AuditOption {
+	return func(c *auditConfig) {
+		c.bufferSize = n
+	}
+}
+
+// WithAuditBlocking makes an Auditor whose buffer is full wait for room
+// before recording more AuditRecords, slowing down responses until its
+// AuditSink catches up, instead of dropping them.
+func WithAuditBlocking() AuditOption {
+	return func(c *auditConfig) {
+		c.blocking = true
+	}
+}
+
+// WithAuditErrorHandler sets a function an Auditor calls with the error its
+// AuditSink returns and the batch of AuditRecords it failed to write. By
+// default, errors are ignored.
+func WithAuditErrorHandler(onError func(error, []AuditRecord)) AuditOption {
+	return func(c *auditConfig) {
+		c.onError = onError
+	}
+}
+
+// Auditor records the errors written in responses with an AuditSink,
+// batching them in the background so writing a response never waits on
+// the AuditSink, unless WithAuditBlocking is used. Auditors must be
+// created with NewAuditor, and closed with Close when they're no longer
+// needed.
+type Auditor struct {
+	// dropped is first, so it's aligned for atomic access on 32-bit
+	// platforms
+	dropped uint64
+	sink    AuditSink
+	cfg     auditConfig
+	queue   chan AuditRecord
+	done    chan struct{}
+	mu      sync.RWMutex
+	closed  bool
+}
+
+// NewAuditor returns an Auditor that writes to sink, and starts the
+// goroutine that batches its AuditRecords.
+func NewAuditor(sink AuditSink, opts ...AuditOption) *Auditor {
+	defaults := auditConfig{
+		batchSize:  100,
+		interval:   time.Second,
+		bufferSize: 1000,
+	}
+	cfg := defaults
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.batchSize <= 0 {
+		cfg.batchSize = defaults.batchSize
+	}
+	if cfg.interval <= 0 {
+		cfg.interval = defaults.interval
+	}
+	if cfg.bufferSize <= 0 {
+		cfg.bufferSize = defaults.bufferSize
+	}
+	a := &Auditor{
+		sink:  sink,
+		cfg:   cfg,
+		queue: make(chan AuditRecord, cfg.bufferSize),
+		done:  make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// Record records each Diagnostic in diags with a Severity of
+// DiagnosticError as an AuditRecord describing r. If the Auditor's buffer
+// is full, the AuditRecords are dropped and counted by Dropped, unless
+// WithAuditBlocking is used, in which case Record waits for room, or for
+// r's context to be done. AuditRecords recorded after Close are dropped.
+func (a *Auditor) Record(r *http.Request, diags Diagnostics) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	now := time.Now()
+	for _, diag := range diags {
+		if diag.Severity != DiagnosticError {
+			continue
+		}
+		if a.closed {
+			atomic.AddUint64(&a.dropped, 1)
+			continue
+		}
+		record := AuditRecord{Time: now, Diagnostic: diag}
+		if r != nil {
+			record.Method = r.Method
+			record.Path = r.URL.Path
+			record.RemoteAddr = r.RemoteAddr
+			record.UserAgent = r.UserAgent()
+		}
+		if !a.cfg.blocking {
+			select {
+			case a.queue <- record:
+			default:
+				atomic.AddUint64(&a.dropped, 1)
+			}
+			continue
+		}
+		var done <-chan struct{}
+		if r != nil {
+			done = r.Context().Done()
+		}
+		select {
+		case a.queue <- record:
+		case <-done:
+			atomic.AddUint64(&a.dropped, 1)
+		}
+	}
+}
+
+// Dropped returns the number of AuditRecords the Auditor has dropped.
+func (a *Auditor) Dropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+// Close stops accepting AuditRecords, and waits for the ones already
+// recorded to be written to the AuditSink, or for ctx to be done, in which
+// case ctx's error is returned.
+func (a *Auditor) Close(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run batches the AuditRecords in the queue, writing them to the
+// AuditSink when a batch is full or the flush interval passes, until the
+// queue is closed.
+func (a *Auditor) run() {
+	defer close(a.done)
+	ticker := time.NewTicker(a.cfg.interval)
+	defer ticker.Stop()
+	batch := make([]AuditRecord, 0, a.cfg.batchSize)
+	flush := func() {
+		if len(batch) < 1 {
+			return
+		}
+		err := a.sink.WriteAudit(context.Background(), batch)
+		if err != nil && a.cfg.onError != nil {
+			a.cfg.onError(err, batch)
+		}
+		batch = make([]AuditRecord, 0, a.cfg.batchSize)
+	}
+	for {
+		select {
+		case record, ok := <-a.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, record)
+			if len(batch) >= a.cfg.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// WithAuditor makes WriteHTTP, WriteEnvelope, WriteBatch, and
+// InjectDiagnostics record the errors they write with auditor, as
+// AuditRecords describing r.
+func WithAuditor(auditor *Auditor, r *http.Request) HTTPOption {
+	return func(c *httpConfig) {
+		c.auditor = auditor
+		c.auditReq = r
+	}
+}
+
+// audit records diags with the Auditor set by WithAuditor, if any.
+func (c httpConfig) audit(diags Diagnostics) {
+	if c.auditor == nil || len(diags) < 1 {
+		return
+	}
+	c.auditor.Record(c.auditReq, diags)
+}
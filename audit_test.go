@@ -0,0 +1,153 @@
+package apidiags
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// recordingSink is an AuditSink that records the batches written to it.
+type recordingSink struct {
+	mu      sync.Mutex
+	batches [][]AuditRecord
+	block   chan struct{}
+}
+
+func (s *recordingSink) WriteAudit(_ context.Context, records []AuditRecord) error {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, records)
+	return nil
+}
+
+func TestAuditor(t *testing.T) {
+	t.Parallel()
+
+	sink := &recordingSink{}
+	auditor := NewAuditor(sink, WithAuditBatchSize(2), WithAuditFlushInterval(time.Hour))
+	r := httptest.NewRequest(http.MethodPost, "/widgets?secret=1", nil)
+	r.Header.Set("User-Agent", "test")
+
+	missing := Diagnostic{Severity: DiagnosticError, Code: CodeMissing}
+	conflict := Diagnostic{Severity: DiagnosticError, Code: CodeConflict}
+	deprecated := Diagnostic{Severity: DiagnosticWarning, Code: CodeDeprecated}
+	err := WriteHTTP(httptest.NewRecorder(), Diagnostics{missing, deprecated, conflict}, WithAuditor(auditor, r))
+	if err != nil {
+		t.Fatalf("error writing response: %s", err)
+	}
+	err = WriteEnvelope(httptest.NewRecorder(), NewEnvelope("ok", Diagnostics{missing}), WithAuditor(auditor, nil))
+	if err != nil {
+		t.Fatalf("error writing envelope: %s", err)
+	}
+	err = auditor.Close(context.Background())
+	if err != nil {
+		t.Fatalf("error closing auditor: %s", err)
+	}
+	auditor.Record(r, Diagnostics{missing})
+
+	record := func(diag Diagnostic) AuditRecord {
+		return AuditRecord{Method: http.MethodPost, Path: "/widgets", RemoteAddr: "192.0.2.1:1234", UserAgent: "test", Diagnostic: diag}
+	}
+	expected := [][]AuditRecord{
+		{record(missing), record(conflict)},
+		{{Diagnostic: missing}},
+	}
+	if diff := cmp.Diff(expected, sink.batches, cmpopts.IgnoreFields(AuditRecord{}, "Time")); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	if dropped := auditor.Dropped(); dropped != 1 {
+		t.Errorf("expected 1 dropped record, got %d", dropped)
+	}
+}
+
+func TestAuditorBackpressure(t *testing.T) {
+	t.Parallel()
+
+	sink := &recordingSink{block: make(chan struct{})}
+	var errs []error
+	auditor := NewAuditor(sink, WithAuditBatchSize(1), WithAuditBufferSize(1),
+		WithAuditErrorHandler(func(err error, _ []AuditRecord) { errs = append(errs, err) }))
+	diags := Diagnostics{{Severity: DiagnosticError, Code: CodeMissing}}
+
+	// the first record is taken by the batching goroutine, which blocks
+	// writing it; the second fills the buffer; the rest are dropped
+	for i := 0; i < 4; i++ {
+		auditor.Record(nil, diags)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if dropped := auditor.Dropped(); dropped != 2 {
+		t.Errorf("expected 2 dropped records, got %d", dropped)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := auditor.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected closing a blocked Auditor to time out, got %v", err)
+	}
+	close(sink.block)
+	if err := auditor.Close(context.Background()); err != nil {
+		t.Errorf("unexpected error closing auditor: %s", err)
+	}
+	if len(sink.batches) != 2 || len(errs) != 0 {
+		t.Errorf("expected 2 batches and no errors, got %d and %v", len(sink.batches), errs)
+	}
+}
+
+func TestAuditorInvalidOptions(t *testing.T) {
+	t.Parallel()
+
+	sink := &recordingSink{}
+	auditor := NewAuditor(sink, WithAuditBatchSize(0), WithAuditFlushInterval(0), WithAuditBufferSize(-1))
+	expected := auditConfig{batchSize: 100, interval: time.Second, bufferSize: 1000}
+	if diff := cmp.Diff(expected, auditor.cfg, cmp.AllowUnexported(auditConfig{})); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	auditor.Record(nil, Diagnostics{{Severity: DiagnosticError, Code: CodeMissing}})
+	if err := auditor.Close(context.Background()); err != nil {
+		t.Errorf("unexpected error closing auditor: %s", err)
+	}
+	if len(sink.batches) != 1 {
+		t.Errorf("expected 1 batch, got %d", len(sink.batches))
+	}
+}
+
+func TestAuditorBlocking(t *testing.T) {
+	t.Parallel()
+
+	sink := &recordingSink{block: make(chan struct{})}
+	auditor := NewAuditor(sink, WithAuditBatchSize(1), WithAuditBufferSize(1), WithAuditBlocking())
+	diags := Diagnostics{{Severity: DiagnosticError, Code: CodeMissing}}
+	auditor.Record(nil, diags)
+	auditor.Record(nil, diags)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	recorded := make(chan struct{})
+	go func() {
+		auditor.Record(r, diags)
+		close(recorded)
+	}()
+	select {
+	case <-recorded:
+		t.Fatal("expected Record to block while the buffer is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+	cancel()
+	<-recorded
+	if dropped := auditor.Dropped(); dropped != 1 {
+		t.Errorf("expected 1 dropped record, got %d", dropped)
+	}
+	close(sink.block)
+	if err := auditor.Close(context.Background()); err != nil {
+		t.Errorf("unexpected error closing auditor: %s", err)
+	}
+}
@@ -0,0 +1,160 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// AzureDiagnosticInfoType is the type of the additional info Diagnostics are
+// stored in by AzureErrorFromDiagnostics.
+const AzureDiagnosticInfoType = "apidiags.Diagnostic"
+
+// AzureErrorResponse is the body of an Azure Resource Manager error
+// response.
+type AzureErrorResponse struct {
+	Error AzureErrorDetail `json:"error"`
+}
+
+// AzureErrorDetail is an error in an Azure Resource Manager error response.
+// Details can be nested arbitrarily deep.
+type AzureErrorDetail struct {
+	Code           string                     `json:"code"`
+	Message        string                     `json:"message"`
+	Target         string                     `json:"target,omitempty"`
+	Details        []AzureErrorDetail         `json:"details,omitempty"`
+	AdditionalInfo []AzureErrorAdditionalInfo `json:"additionalInfo,omitempty"`
+}
+
+// AzureErrorAdditionalInfo is additional information about an
+// AzureErrorDetail, identified by its type.
+type AzureErrorAdditionalInfo struct {
+	Type string          `json:"type"`
+	Info json.RawMessage `json:"info,omitempty"`
+}
+
+// AzureErrorFromDiagnostics converts diags into an Azure Resource Manager
+// error response.
+//
+// Each Diagnostic becomes an error detail, with its Code as the code, its
+// Message as the message, and its first Path, rendered using
+// Steps.FieldPath, as the target. A Diagnostic with more than one Path lists
+// every Path as a nested detail. As error details can't carry Severities,
+// the Diagnostic itself is stored as additional info with a type of
+// AzureDiagnosticInfoType, which DiagnosticsFromAzureError uses to recover it
+// exactly.
+//
+// When there's a single Diagnostic, its detail is the error. Otherwise, the
+// error's code, message, and target come from the first Diagnostic with a
+// Severity of DiagnosticError, or the first Diagnostic if there are no
+// errors, and every Diagnostic's detail is listed in the details. If diags is
+// empty, the zero value is returned.
+func AzureErrorFromDiagnostics(diags Diagnostics) (AzureErrorResponse, error) {
+	var result AzureErrorResponse
+	if len(diags) < 1 {
+		return result, nil
+	}
+	details := make([]AzureErrorDetail, 0, len(diags))
+	primary := -1
+	for pos, diag := range diags {
+		if primary < 0 && diag.Severity == DiagnosticError {
+			primary = pos
+		}
+		detail, err := azureDetailFromDiagnostic(diag)
+		if err != nil {
+			return AzureErrorResponse{}, fmt.Errorf("error encoding diagnostic %d: %w", pos, err)
+		}
+		details = append(details, detail)
+	}
+	if len(details) == 1 {
+		result.Error = details[0]
+		return result, nil
+	}
+	if primary < 0 {
+		primary = 0
+	}
+	result.Error = AzureErrorDetail{
+		Code:    details[primary].Code,
+		Message: details[primary].Message,
+		Target:  details[primary].Target,
+		Details: details,
+	}
+	return result, nil
+}
+
+func azureDetailFromDiagnostic(diag Diagnostic) (AzureErrorDetail, error) {
+	info, err := json.Marshal(diag)
+	if err != nil {
+		return AzureErrorDetail{}, err
+	}
+	result := AzureErrorDetail{
+		Code:    string(diag.Code),
+		Message: diag.Message(),
+		AdditionalInfo: []AzureErrorAdditionalInfo{
+			{Type: AzureDiagnosticInfoType, Info: info},
+		},
+	}
+	if len(diag.Paths) > 0 {
+		result.Target = diag.Paths[0].FieldPath()
+	}
+	if len(diag.Paths) > 1 {
+		for _, path := range diag.Paths {
+			result.Details = append(result.Details, AzureErrorDetail{
+				Code:    string(diag.Code),
+				Message: diag.Message(),
+				Target:  path.FieldPath(),
+			})
+		}
+	}
+	return result, nil
+}
+
+// DiagnosticsFromAzureError converts an Azure Resource Manager error response
+// into Diagnostics, reversing AzureErrorFromDiagnostics.
+//
+// Error details with additional info of type AzureDiagnosticInfoType are
+// decoded from it. Other error details without nested details become a
+// Diagnostic with a Severity of DiagnosticError, with their target parsed
+// using ParseFieldPath; error details with nested details are replaced by
+// the Diagnostics of their nested details.
+func DiagnosticsFromAzureError(resp AzureErrorResponse) (Diagnostics, error) {
+	return diagnosticsFromAzureDetail(resp.Error, nil)
+}
+
+func diagnosticsFromAzureDetail(detail AzureErrorDetail, results Diagnostics) (Diagnostics, error) {
+	for _, info := range detail.AdditionalInfo {
+		if info.Type != AzureDiagnosticInfoType {
+			continue
+		}
+		var diag Diagnostic
+		err := json.Unmarshal(info.Info, &diag)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s of %q: %w", AzureDiagnosticInfoType, detail.Code, err)
+		}
+		return append(results, diag), nil
+	}
+	if len(detail.Details) > 0 {
+		var err error
+		for _, nested := range detail.Details {
+			results, err = diagnosticsFromAzureDetail(nested, results)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return results, nil
+	}
+	diag := Diagnostic{
+		Severity: DiagnosticError,
+		Code:     Code(detail.Code),
+	}
+	if detail.Message != detail.Code {
+		diag.Summary = detail.Message
+	}
+	if detail.Target != "" {
+		path, err := ParseFieldPath(detail.Target)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing target of %q: %w", detail.Code, err)
+		}
+		diag.Paths = []Steps{path}
+	}
+	return append(results, diag), nil
+}
@@ -0,0 +1,176 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nsf/jsondiff"
+)
+
+func TestAzureErrorFromDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags    Diagnostics
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"empty": {
+			expected: `{"error": {"code": "", "message": ""}}`,
+		},
+		"single": {
+			diags: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     CodeMissing,
+					Summary:  "Name is required.",
+					Paths:    []Steps{BodyPath().AddSteps(ObjectPropertyStep("properties"), ObjectPropertyStep("name"))},
+				},
+			},
+			expected: `{"error": {"code": "missing", "message": "Name is required.", "target": "properties.name", "additionalInfo": [
+				{"type": "apidiags.Diagnostic", "info": {"severity": "error", "code": "missing", "summary": "Name is required.",
+					"path": [[{"kind": "body"}, {"kind": "object_property", "value": "properties"}, {"kind": "object_property", "value": "name"}]]}}
+			]}}`,
+		},
+		"multiple": {
+			diags: Diagnostics{
+				{Severity: DiagnosticWarning, Code: CodeDeprecated, Paths: []Steps{HeaderPath("X-Legacy")}},
+				{
+					Severity: DiagnosticError,
+					Code:     CodeConflict,
+					Paths: []Steps{
+						BodyPath().AddStep(ObjectPropertyStep("start")),
+						BodyPath().AddStep(ObjectPropertyStep("end")),
+					},
+				},
+			},
+			expected: `{"error": {"code": "conflict", "message": "conflict", "target": "start", "details": [
+				{"code": "deprecated", "message": "deprecated", "target": "header(\"X-Legacy\")", "additionalInfo": [
+					{"type": "apidiags.Diagnostic", "info": {"severity": "warning", "code": "deprecated", "path": [[{"kind": "header", "value": "X-Legacy"}]]}}
+				]},
+				{"code": "conflict", "message": "conflict", "target": "start", "details": [
+					{"code": "conflict", "message": "conflict", "target": "start"},
+					{"code": "conflict", "message": "conflict", "target": "end"}
+				], "additionalInfo": [
+					{"type": "apidiags.Diagnostic", "info": {"severity": "error", "code": "conflict",
+						"path": [[{"kind": "body"}, {"kind": "object_property", "value": "start"}], [{"kind": "body"}, {"kind": "object_property", "value": "end"}]]}}
+				]}
+			]}}`,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			resp, err := AzureErrorFromDiagnostics(tc.diags)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			result, err := json.Marshal(resp)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			opts := jsondiff.DefaultConsoleOptions()
+			match, diff := jsondiff.Compare([]byte(tc.expected), result, &opts)
+			if match != jsondiff.FullMatch {
+				t.Errorf("Unexpected result: %s", diff)
+			}
+			if match > jsondiff.NoMatch {
+				t.Logf("first argument: %s", tc.expected)
+				t.Logf("second argument: %s", result)
+			}
+
+			roundTripped, err := DiagnosticsFromAzureError(resp)
+			if err != nil {
+				t.Fatalf("unexpected error parsing: %s", err)
+			}
+			if len(tc.diags) > 0 {
+				if diff := cmp.Diff(tc.diags, roundTripped); diff != "" {
+					t.Errorf("unexpected round trip results (-wanted, +got): %s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestDiagnosticsFromAzureError(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    string
+		expected Diagnostics
+		wantErr  bool
+	}
+
+	cases := map[string]testCase{
+		"no-details": {
+			input: `{"error": {"code": "ResourceNotFound", "message": "The Resource 'vm1' was not found.", "target": "name"}}`,
+			expected: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     "ResourceNotFound",
+					Summary:  "The Resource 'vm1' was not found.",
+					Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("name"))},
+				},
+			},
+		},
+		"nested-details": {
+			input: `{"error": {"code": "InvalidTemplate", "message": "Deployment template validation failed.", "details": [
+				{"code": "InvalidParameter", "message": "Bad SKU.", "target": "properties.sku"},
+				{"code": "Multiple", "message": "Several problems.", "details": [
+					{"code": "missing", "message": "missing", "target": "properties.location"}
+				]}
+			], "additionalInfo": [{"type": "PolicyViolation", "info": {"policy": "p1"}}]}}`,
+			expected: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     "InvalidParameter",
+					Summary:  "Bad SKU.",
+					Paths:    []Steps{BodyPath().AddSteps(ObjectPropertyStep("properties"), ObjectPropertyStep("sku"))},
+				},
+				{
+					Severity: DiagnosticError,
+					Code:     CodeMissing,
+					Paths:    []Steps{BodyPath().AddSteps(ObjectPropertyStep("properties"), ObjectPropertyStep("location"))},
+				},
+			},
+		},
+		"unparseable-target": {
+			input:   `{"error": {"code": "BadRequest", "message": "oops", "target": "properties/sku"}}`,
+			wantErr: true,
+		},
+		"invalid-info": {
+			input:   `{"error": {"code": "missing", "message": "missing", "additionalInfo": [{"type": "apidiags.Diagnostic", "info": {"path": "nope"}}]}}`,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var resp AzureErrorResponse
+			err := json.Unmarshal([]byte(tc.input), &resp)
+			if err != nil {
+				t.Fatalf("unexpected error decoding input: %s", err)
+			}
+			result, err := DiagnosticsFromAzureError(resp)
+			if err != nil && !tc.wantErr {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && tc.wantErr {
+				t.Fatalf("expected error, got %+v", result)
+			}
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
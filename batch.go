@@ -0,0 +1,140 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ItemResult is the outcome of a single item in a batch request, where each
+// item succeeds or fails independently. It pairs the item's position in the
+// request, and optionally a key identifying it, like its ID, with its
+// result and the Diagnostics about it.
+//
+// When the Diagnostics include an error, the item failed and has no Data,
+// and the "data" member is left out of the ItemResult's JSON encoding.
+type ItemResult[T any] struct {
+	Index       int         `json:"index"`
+	Key         string      `json:"key,omitempty"`
+	Data        T           `json:"data"`
+	Diagnostics Diagnostics `json:"diagnostics,omitempty"`
+}
+
+// Failed returns true if the item's Diagnostics include an error.
+func (i ItemResult[T]) Failed() bool {
+	return i.Diagnostics.HasErrors()
+}
+
+// MarshalJSON turns an ItemResult into a JSON object, leaving out its Data
+// if its Diagnostics include an error.
+func (i ItemResult[T]) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Index       int         `json:"index"`
+		Key         string      `json:"key,omitempty"`
+		Data        *T          `json:"data,omitempty"`
+		Diagnostics Diagnostics `json:"diagnostics,omitempty"`
+	}{Index: i.Index, Key: i.Key, Diagnostics: i.Diagnostics}
+	if !i.Failed() {
+		out.Data = &i.Data
+	}
+	return json.Marshal(out)
+}
+
+// Batch is the response to a batch request, like
+// `{"results": [...], "diagnostics": [...]}`. Its Results hold the outcome
+// of each item, and its Diagnostics are about the request as a whole, like
+// a warning that the endpoint is deprecated, or an error that kept every
+// item from being processed.
+type Batch[T any] struct {
+	Results     []ItemResult[T] `json:"results"`
+	Diagnostics Diagnostics     `json:"diagnostics,omitempty"`
+}
+
+// Status returns the HTTP status code for b, using DefaultStatusMapping. See
+// StatusMapping.BatchStatus.
+func (b Batch[T]) Status() int {
+	return b.status(defaultStatusMapping)
+}
+
+func (b Batch[T]) status(m StatusMapping) int {
+	if b.Diagnostics.HasErrors() {
+		return m.Status(b.Diagnostics)
+	}
+	items := make([]Diagnostics, 0, len(b.Results))
+	for _, result := range b.Results {
+		items = append(items, result.Diagnostics)
+	}
+	return m.BatchStatus(items)
+}
+
+// BatchStatus returns the HTTP status code for a batch response whose items
+// have the Diagnostics in items. If no item failed, http.StatusOK is
+// returned. If every item failed, and Status returns the same status code
+// for all of them, that status code is returned, so a batch that's entirely
+// not found is a 404. Otherwise, the outcome of the batch depends on the
+// item, and http.StatusMultiStatus is returned.
+func (m StatusMapping) BatchStatus(items []Diagnostics) int {
+	result := http.StatusOK
+	for pos, diags := range items {
+		status := m.Status(diags)
+		if pos == 0 {
+			result = status
+			continue
+		}
+		if status != result {
+			return http.StatusMultiStatus
+		}
+	}
+	return result
+}
+
+// WriteBatch writes b to w as an application/json response. If the Batch's
+// own Diagnostics include an error, the status code is chosen the same way
+// WriteHTTP chooses it; otherwise, it's chosen by StatusMapping.BatchStatus,
+// using the StatusMapping set by WithStatusMapping, if any. WithStatus
+// overrides both.
+//
+// The headers describing Diagnostics, like the Deprecation header, only
+// describe the Batch's own Diagnostics, not those of its Results. The
+// HTTPOptions that only apply to Problems, like WithProblemType, are
+// ignored.
+func WriteBatch[T any](w http.ResponseWriter, b Batch[T], opts ...HTTPOption) error {
+	var cfg httpConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	b.Diagnostics = cfg.written(cfg.filter(b.Diagnostics))
+	observed := append(Diagnostics(nil), b.Diagnostics...)
+	results := make([]ItemResult[T], 0, len(b.Results))
+	for _, result := range b.Results {
+		result.Diagnostics = cfg.written(cfg.filter(result.Diagnostics))
+		observed = append(observed, result.Diagnostics...)
+		results = append(results, result)
+	}
+	b.Results = results
+	err := setDiagnosticHeaders(w.Header(), b.Diagnostics, cfg)
+	if err != nil {
+		return err
+	}
+	cfg.observe(observed)
+	var body []byte
+	if cfg.canonical {
+		body, err = MarshalCanonicalJSON(b)
+	} else {
+		body, err = json.Marshal(b)
+	}
+	if err != nil {
+		return err
+	}
+	status := cfg.status
+	if status == 0 {
+		mapping := cfg.mapping
+		if mapping == nil {
+			mapping = defaultStatusMapping
+		}
+		status = b.status(mapping)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	return err
+}
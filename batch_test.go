@@ -0,0 +1,157 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestItemResultJSON(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		result   ItemResult[testWidget]
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"success": {
+			result:   ItemResult[testWidget]{Index: 0, Data: testWidget{ID: "123", Name: "foo"}},
+			expected: `{"index":0,"data":{"id":"123","name":"foo"}}`,
+		},
+		"success-with-warnings": {
+			result: ItemResult[testWidget]{Index: 1, Key: "123", Data: testWidget{ID: "123"}, Diagnostics: Diagnostics{
+				{Severity: DiagnosticWarning, Code: CodeDeprecated, Paths: []Steps{BodyPath().AddStep(ArrayIndexStep(1)).AddStep(ObjectPropertyStep("mode"))}},
+			}},
+			expected: `{"index":1,"key":"123","data":{"id":"123"},"diagnostics":[{"severity":"warning","code":"deprecated","path":[[{"kind":"body"},{"kind":"array_index","value":1},{"kind":"object_property","value":"mode"}]]}]}`,
+		},
+		"failure": {
+			result: ItemResult[testWidget]{Index: 2, Key: "456", Diagnostics: Diagnostics{
+				{Severity: DiagnosticError, Code: CodeConflict},
+			}},
+			expected: `{"index":2,"key":"456","diagnostics":[{"severity":"error","code":"conflict"}]}`,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			encoded, err := json.Marshal(tc.result)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if string(encoded) != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, encoded)
+			}
+			var decoded ItemResult[testWidget]
+			err = json.Unmarshal(encoded, &decoded)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(tc.result, decoded); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestBatchStatus(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		batch    Batch[testWidget]
+		expected int
+	}
+
+	notFound := Diagnostics{{Severity: DiagnosticError, Code: CodeNotFound}}
+	conflict := Diagnostics{{Severity: DiagnosticError, Code: CodeConflict}}
+	warning := Diagnostics{{Severity: DiagnosticWarning, Code: CodeDeprecated}}
+
+	cases := map[string]testCase{
+		"empty": {
+			expected: http.StatusOK,
+		},
+		"all-succeeded": {
+			batch: Batch[testWidget]{Results: []ItemResult[testWidget]{
+				{Index: 0}, {Index: 1, Diagnostics: warning},
+			}},
+			expected: http.StatusOK,
+		},
+		"all-failed-same-status": {
+			batch: Batch[testWidget]{Results: []ItemResult[testWidget]{
+				{Index: 0, Diagnostics: notFound}, {Index: 1, Diagnostics: notFound},
+			}},
+			expected: http.StatusNotFound,
+		},
+		"all-failed-different-statuses": {
+			batch: Batch[testWidget]{Results: []ItemResult[testWidget]{
+				{Index: 0, Diagnostics: notFound}, {Index: 1, Diagnostics: conflict},
+			}},
+			expected: http.StatusMultiStatus,
+		},
+		"partial-success": {
+			batch: Batch[testWidget]{Results: []ItemResult[testWidget]{
+				{Index: 0}, {Index: 1, Diagnostics: conflict},
+			}},
+			expected: http.StatusMultiStatus,
+		},
+		"batch-error": {
+			batch: Batch[testWidget]{
+				Results:     []ItemResult[testWidget]{{Index: 0}},
+				Diagnostics: Diagnostics{{Severity: DiagnosticError, Code: CodeAccessDenied}},
+			},
+			expected: http.StatusForbidden,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if status := tc.batch.Status(); status != tc.expected {
+				t.Errorf("expected status %d, got %d", tc.expected, status)
+			}
+		})
+	}
+}
+
+func TestWriteBatch(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodPost, "/widgets:batchCreate", nil)
+	r.Header.Set(SuppressWarningsHeader, "hint")
+	batch := Batch[testWidget]{
+		Results: []ItemResult[testWidget]{
+			{Index: 0, Data: testWidget{ID: "123"}, Diagnostics: Diagnostics{{Severity: DiagnosticWarning, Code: "hint"}}},
+			{Index: 1, Diagnostics: Diagnostics{{Severity: DiagnosticError, Code: "quota_exceeded"}}},
+		},
+	}
+	mapping := DefaultStatusMapping().With(StatusMapping{"quota_exceeded": http.StatusTooManyRequests})
+
+	w := httptest.NewRecorder()
+	err := WriteBatch(w, batch, WithStatusMapping(mapping), WithWarningSuppression(r))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if w.Code != http.StatusMultiStatus {
+		t.Errorf("expected status %d, got %d", http.StatusMultiStatus, w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("expected Content-Type application/json, got %q", contentType)
+	}
+	expected := `{"results":[{"index":0,"data":{"id":"123"}},{"index":1,"diagnostics":[{"severity":"error","code":"quota_exceeded"}]}]}`
+	if w.Body.String() != expected {
+		t.Errorf("expected %s, got %s", expected, w.Body.String())
+	}
+	if len(batch.Results[0].Diagnostics) != 1 {
+		t.Errorf("expected WriteBatch to leave the Batch's Results unmodified")
+	}
+}
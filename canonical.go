@@ -0,0 +1,176 @@
+package apidiags
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MarshalCanonicalJSON encodes v as JSON, like json.Marshal, then
+// canonicalizes the result with CanonicalizeJSON, so the same value always
+// encodes to the same bytes. It's meant for responses that are hashed,
+// cached, signed, or compared against golden files.
+func MarshalCanonicalJSON(v any) ([]byte, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return CanonicalizeJSON(encoded)
+}
+
+// CanonicalizeJSON rewrites the JSON document in in into a canonical form,
+// loosely following RFC 8785:
+//
+//   - insignificant whitespace is removed
+//   - object members are sorted by key, comparing the keys' UTF-8 bytes
+//   - strings are escaped minimally, without the HTML escaping json.Marshal
+//     does by default
+//   - integers are written in plain decimal, without a fraction or exponent,
+//     and other numbers in the shortest form that round-trips through a
+//     float64
+//
+// Objects with duplicate keys can't be canonicalized, and result in an
+// error, as does any input that isn't exactly one JSON value.
+func CanonicalizeJSON(in []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(in))
+	dec.UseNumber()
+	var buf bytes.Buffer
+	err := canonicalizeValue(dec, &buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	return buf.Bytes(), nil
+}
+
+func canonicalizeValue(dec *json.Decoder, buf *bytes.Buffer) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch value := tok.(type) {
+	case json.Delim:
+		if value == '[' {
+			return canonicalizeArray(dec, buf)
+		}
+		return canonicalizeObject(dec, buf)
+	case string:
+		return writeCanonicalString(buf, value)
+	case json.Number:
+		num, err := canonicalNumber(value)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(num)
+	case bool:
+		buf.WriteString(strconv.FormatBool(value))
+	case nil:
+		buf.WriteString("null")
+	}
+	return nil
+}
+
+func canonicalizeArray(dec *json.Decoder, buf *bytes.Buffer) error {
+	buf.WriteByte('[')
+	for pos := 0; dec.More(); pos++ {
+		if pos > 0 {
+			buf.WriteByte(',')
+		}
+		err := canonicalizeValue(dec, buf)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+func canonicalizeObject(dec *json.Decoder, buf *bytes.Buffer) error {
+	members := map[string][]byte{}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("unexpected object key %v", tok)
+		}
+		if _, ok := members[key]; ok {
+			return fmt.Errorf("duplicate object key %q", key)
+		}
+		var member bytes.Buffer
+		err = canonicalizeValue(dec, &member)
+		if err != nil {
+			return err
+		}
+		members[key] = member.Bytes()
+		keys = append(keys, key)
+	}
+	_, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	sort.Strings(keys)
+	buf.WriteByte('{')
+	for pos, key := range keys {
+		if pos > 0 {
+			buf.WriteByte(',')
+		}
+		err := writeCanonicalString(buf, key)
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(':')
+		buf.Write(members[key])
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func writeCanonicalString(buf *bytes.Buffer, s string) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(s)
+	if err != nil {
+		return err
+	}
+	// Encode always adds a trailing newline.
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+// canonicalNumber returns the canonical representation of num.
+func canonicalNumber(num json.Number) (string, error) {
+	if !strings.ContainsAny(string(num), ".eE") {
+		if i, err := strconv.ParseInt(string(num), 10, 64); err == nil {
+			return strconv.FormatInt(i, 10), nil
+		}
+		if u, err := strconv.ParseUint(string(num), 10, 64); err == nil {
+			return strconv.FormatUint(u, 10), nil
+		}
+	}
+	f, err := strconv.ParseFloat(string(num), 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid number %s: %w", num, err)
+	}
+	if f == 0 {
+		return "0", nil
+	}
+	if f == float64(int64(f)) && f < 1e21 && f > -1e21 {
+		return strconv.FormatInt(int64(f), 10), nil
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64), nil
+}
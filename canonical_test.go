@@ -0,0 +1,98 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCanonicalizeJSON(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    string
+		expected string
+		wantErr  bool
+	}
+
+	cases := map[string]testCase{
+		"whitespace-and-ordering": {
+			input:    `{ "b": [1, 2, {"z": null, "a": true}], "a": "x" }`,
+			expected: `{"a":"x","b":[1,2,{"a":true,"z":null}]}`,
+		},
+		"html-not-escaped": {
+			input:    `{"summary": "<id> & more"}`,
+			expected: `{"summary":"<id> & more"}`,
+		},
+		"numbers": {
+			input:    `[1.0, 1e2, -0, 0.5, 1.5e-7, 18446744073709551615, -9223372036854775808]`,
+			expected: `[1,100,0,0.5,1.5e-07,18446744073709551615,-9223372036854775808]`,
+		},
+		"duplicate-key": {
+			input:   `{"a": 1, "a": 2}`,
+			wantErr: true,
+		},
+		"trailing-data": {
+			input:   `{"a": 1} {"b": 2}`,
+			wantErr: true,
+		},
+		"invalid": {
+			input:   `{"a": }`,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := CanonicalizeJSON([]byte(tc.input))
+			if err != nil && !tc.wantErr {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && tc.wantErr {
+				t.Fatalf("expected error, got %s", result)
+			}
+			if string(result) != tc.expected {
+				t.Fatalf("expected %s, got %s", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestMarshalCanonicalJSONStable(t *testing.T) {
+	t.Parallel()
+
+	problem := NewProblem(400, Diagnostics{
+		{
+			Severity: DiagnosticError,
+			Code:     CodeMissing,
+			Summary:  "<name> is required.",
+			Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("name"))},
+		},
+	})
+	problem.Extensions = map[string]json.RawMessage{
+		"zeta":  json.RawMessage(`{"b": 1, "a": 2}`),
+		"alpha": json.RawMessage(`[1.0]`),
+	}
+
+	first, err := MarshalCanonicalJSON(problem)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := `{"alpha":[1],"diagnostics":[{"code":"missing","path":[[{"kind":"body"},{"kind":"object_property","value":"name"}]],` +
+		`"severity":"error","summary":"<name> is required."}],"status":400,"title":"Bad Request","zeta":{"a":2,"b":1}}`
+	if string(first) != expected {
+		t.Fatalf("expected %s, got %s", expected, first)
+	}
+	for i := 0; i < 10; i++ {
+		again, err := MarshalCanonicalJSON(problem)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if string(again) != string(first) {
+			t.Fatalf("expected %s, got %s", first, again)
+		}
+	}
+}
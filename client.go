@@ -0,0 +1,391 @@
+package apidiags
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxResponseBytes is the largest response body ParseResponse reads
+// when no other limit is set using WithMaxResponseBytes.
+const DefaultMaxResponseBytes = 1 << 20
+
+// ErrResponseTooLarge is returned by ParseResponse when a response body is
+// larger than the limit set by WithMaxResponseBytes.
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// ResponseOption configures how ParseResponse reads a response.
+type ResponseOption func(*responseConfig)
+
+type responseConfig struct {
+	maxBytes    int64
+	apiError    bool
+	deprecation DeprecationHandler
+	aggregator  *Aggregator
+}
+
+// WithMaxResponseBytes sets the largest response body, in bytes, that
+// ParseResponse reads before giving up with ErrResponseTooLarge.
+func WithMaxResponseBytes(n int64) ResponseOption {
+	return func(c *responseConfig) {
+		c.maxBytes = n
+	}
+}
+
+// WithAPIError makes ParseResponse return an *APIError alongside the
+// Diagnostics of responses that failed: those with an unsuccessful status
+// code, or with Diagnostics that include an error. Without it, failed
+// responses that could be parsed aren't an error.
+func WithAPIError() ResponseOption {
+	return func(c *responseConfig) {
+		c.apiError = true
+	}
+}
+
+// DeprecationHandler is called by ParseResponse with each Diagnostic with a
+// Code of CodeDeprecated in a response, and the response it was in, so SDKs
+// can tell their users about deprecations before they break. Use
+// Deprecation and DeprecationFrom to find out when the deprecated thing
+// stops working.
+type DeprecationHandler func(resp *http.Response, diag Diagnostic)
+
+// WithDeprecationHandler makes ParseResponse call handler with every
+// deprecated Diagnostic it parses, whether the response succeeded or not.
+// If handler is nil, the deprecations are logged with the standard logger,
+// once per unique Code and Paths for the life of the program; see
+// LogDeprecations.
+func WithDeprecationHandler(handler DeprecationHandler) ResponseOption {
+	if handler == nil {
+		handler = defaultDeprecationHandler
+	}
+	return func(c *responseConfig) {
+		c.deprecation = handler
+	}
+}
+
+var defaultDeprecationHandler = LogDeprecations(nil)
+
+// LogDeprecations returns a DeprecationHandler that logs each deprecated
+// Diagnostic to logger, or the standard logger if logger is nil. Each
+// unique combination of Code and Paths is only logged the first time it's
+// seen, so SDKs calling the same deprecated endpoint in a loop don't flood
+// their users' logs.
+func LogDeprecations(logger *log.Logger) DeprecationHandler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	return func(resp *http.Response, diag Diagnostic) {
+		paths := make([]string, 0, len(diag.Paths))
+		for _, path := range diag.Paths {
+			paths = append(paths, path.String())
+		}
+		key := string(diag.Code) + "\x00" + strings.Join(paths, "\x00")
+		mu.Lock()
+		logged := seen[key]
+		seen[key] = true
+		mu.Unlock()
+		if logged {
+			return
+		}
+		var msg strings.Builder
+		msg.WriteString("apidiags: deprecation warning")
+		if resp != nil && resp.Request != nil && resp.Request.URL != nil {
+			fmt.Fprintf(&msg, " from %s %s", resp.Request.Method, resp.Request.URL.Redacted())
+		}
+		if len(paths) > 0 {
+			fmt.Fprintf(&msg, " for %s", strings.Join(paths, ", "))
+		}
+		fmt.Fprintf(&msg, ": %s", diag.Message())
+		if _, sunset, err := diag.Deprecation(); err == nil && !sunset.IsZero() {
+			fmt.Fprintf(&msg, " (sunset %s)", sunset.Format(time.RFC3339))
+		}
+		if diag.DocURL != "" {
+			fmt.Fprintf(&msg, " (see %s)", diag.DocURL)
+		}
+		logger.Print(msg.String())
+	}
+}
+
+// ParseResponse reads the Diagnostics from resp, reading and closing its
+// body. It understands every envelope this package writes:
+//
+//   - application/problem+json Problem Details documents, like those written
+//     by WriteHTTP and WriteProblem
+//   - JSON objects with a "version" member, like those encoded by
+//     MarshalVersionedDiagnostics
+//   - other JSON objects with a "diagnostics" member
+//   - bare JSON arrays of Diagnostics, which on successful responses
+//     that aren't application/problem+json must give each Diagnostic a
+//     code and a severity of "error" or "warning", so array payloads
+//     aren't mistaken for them
+//
+// Any media type ending in +json is treated like application/json. If the
+// body has no Diagnostics, the warnings in the response's WarningsHeader
+// are returned instead, so warnings attached to a successful response's
+// payload are still surfaced.
+//
+// If resp has an unsuccessful status code but its body has no Diagnostics,
+// a single Diagnostic with a Severity of DiagnosticError is returned, with
+// a Code chosen based on the status code and a Summary of the Problem's
+// detail or title, if there is one. Successful responses without
+// Diagnostics have none, and because their bodies are usually payloads
+// rather than envelopes, bodies that can't be decoded as Diagnostics aren't
+// an error for them.
+//
+// If resp has a Retry-After header, its duration is recorded using
+// Diagnostic.WithRetryAfter on every returned error that doesn't already
+// have one. Retry-After headers that can't be parsed are ignored.
+//
+// See WithAPIError for returning failed responses as errors, and
+// WithDeprecationHandler for surfacing deprecations.
+func ParseResponse(resp *http.Response, opts ...ResponseOption) (Diagnostics, error) {
+	cfg := responseConfig{maxBytes: DefaultMaxResponseBytes}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	defer resp.Body.Close()
+	if resp.ContentLength > cfg.maxBytes {
+		return nil, fmt.Errorf("%w: %d bytes, limit is %d", ErrResponseTooLarge, resp.ContentLength, cfg.maxBytes)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, cfg.maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	if int64(len(body)) > cfg.maxBytes {
+		return nil, fmt.Errorf("%w: limit is %d bytes", ErrResponseTooLarge, cfg.maxBytes)
+	}
+	diags, err := parseResponseBody(resp, body)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.aggregator != nil {
+		cfg.aggregator.Add(diags)
+	}
+	if cfg.deprecation != nil {
+		for _, diag := range diags {
+			if diag.Code == CodeDeprecated {
+				cfg.deprecation(resp, diag)
+			}
+		}
+	}
+	if cfg.apiError && (resp.StatusCode >= 400 || diags.HasErrors()) {
+		return diags, newAPIError(resp, body, diags)
+	}
+	return diags, nil
+}
+
+// parseResponseBody returns the Diagnostics described by resp and its body.
+func parseResponseBody(resp *http.Response, body []byte) (Diagnostics, error) {
+	var problem Problem
+	var err error
+	contentType := resp.Header.Get("Content-Type")
+	if isJSONMediaType(contentType) {
+		problem, err = decodeResponseBody(body, resp.StatusCode >= 400 || isProblemMediaType(contentType))
+		if err != nil && resp.StatusCode >= 400 {
+			return nil, fmt.Errorf("error decoding response body: %w", err)
+		}
+	}
+	if len(problem.Diagnostics) > 0 {
+		return withResponseRetryAfter(resp, problem.Diagnostics)
+	}
+	warnings, err := WarningsFromHeader(resp.Header)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 400 {
+		return warnings, nil
+	}
+	diag := Diagnostic{
+		Severity: DiagnosticError,
+		Code:     CodeForStatus(resp.StatusCode),
+		Summary:  problem.Detail,
+	}
+	if diag.Summary == "" && problem.Title != http.StatusText(resp.StatusCode) {
+		diag.Summary = problem.Title
+	}
+	return withResponseRetryAfter(resp, append(Diagnostics{diag}, warnings...))
+}
+
+// APIErrorBodyBytes is the most bytes of a response body an APIError keeps.
+const APIErrorBodyBytes = 512
+
+// APIError describes a failed response, as returned by ParseResponse when
+// WithAPIError is used. Use errors.As to get it from the errors returned by
+// clients built on ParseResponse.
+//
+// APIError implements DiagnosticsError, so FromError returns the
+// Diagnostics of the failed response.
+type APIError struct {
+	// StatusCode is the response's HTTP status code.
+	StatusCode int
+
+	// RequestID is the value of the response's DefaultCorrelationIDHeader,
+	// if it has one, to include when reporting the failure to the API's
+	// maintainers.
+	RequestID string
+
+	// Body is the start of the response's body, up to APIErrorBodyBytes,
+	// for debugging responses that didn't describe themselves the way the
+	// client expected.
+	Body []byte
+
+	// Response is the failed response. Its body has already been read and
+	// closed.
+	Response *http.Response
+
+	diags Diagnostics
+}
+
+func newAPIError(resp *http.Response, body []byte, diags Diagnostics) *APIError {
+	if len(body) > APIErrorBodyBytes {
+		body = body[:APIErrorBodyBytes]
+	}
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get(DefaultCorrelationIDHeader),
+		Body:       append([]byte(nil), body...),
+		Response:   resp,
+		diags:      diags,
+	}
+}
+
+// Diagnostics returns the Diagnostics parsed from the failed response.
+func (e *APIError) Diagnostics() Diagnostics {
+	return e.diags
+}
+
+// Error describes the failed response using its status code and the first
+// of its Diagnostics with a Severity of DiagnosticError.
+func (e *APIError) Error() string {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "API error: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	var errs int
+	for _, diag := range e.diags {
+		if diag.Severity != DiagnosticError {
+			continue
+		}
+		errs++
+		if errs == 1 {
+			fmt.Fprintf(&msg, ": %s", diag.Code)
+			if diag.Summary != "" {
+				fmt.Fprintf(&msg, ": %s", diag.Summary)
+			}
+		}
+	}
+	if errs > 1 {
+		fmt.Fprintf(&msg, " (and %d more)", errs-1)
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&msg, " (request ID %s)", e.RequestID)
+	}
+	return msg.String()
+}
+
+// withResponseRetryAfter records the Retry-After header of resp on every
+// error in diags without a retry duration of its own.
+func withResponseRetryAfter(resp *http.Response, diags Diagnostics) (Diagnostics, error) {
+	after, ok, err := RetryAfterFromHeader(resp.Header, time.Now())
+	if err != nil || !ok {
+		return diags, nil
+	}
+	for pos, diag := range diags {
+		if diag.Severity != DiagnosticError {
+			continue
+		}
+		if _, ok := diag.Extensions[RetryAfterMember]; ok {
+			continue
+		}
+		diags[pos] = diag.WithRetryAfter(after)
+	}
+	return diags, nil
+}
+
+// isJSONMediaType returns true if contentType is application/json, or a
+// media type with a +json suffix, like application/problem+json.
+func isJSONMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// isProblemMediaType returns true if contentType is ProblemContentType.
+func isProblemMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == ProblemContentType
+}
+
+// decodeResponseBody decodes a JSON response body into a Problem, using
+// whichever envelope the body was written with. Bodies without Diagnostics
+// return a Problem without Diagnostics. Unless diagsExpected is true, because
+// the response failed or says it's a Problem, a bare array is only decoded
+// as Diagnostics if each of its elements has a severity or code, so
+// successful responses with arrays as their payloads aren't mistaken for
+// Diagnostics.
+func decodeResponseBody(body []byte, diagsExpected bool) (Problem, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) < 1 {
+		return Problem{}, nil
+	}
+	switch trimmed[0] {
+	case '[':
+		diags, err := DecodeVersionedDiagnostics(trimmed)
+		if !diagsExpected {
+			if err != nil {
+				return Problem{}, nil
+			}
+			for _, diag := range diags {
+				if diag.Code == "" || (diag.Severity != DiagnosticError && diag.Severity != DiagnosticWarning) {
+					return Problem{}, nil
+				}
+			}
+		}
+		return Problem{Diagnostics: diags}, err
+	case '{':
+		var members map[string]json.RawMessage
+		err := json.Unmarshal(trimmed, &members)
+		if err != nil {
+			return Problem{}, err
+		}
+		if _, ok := members["version"]; ok {
+			diags, err := DecodeVersionedDiagnostics(trimmed)
+			return Problem{Diagnostics: diags}, err
+		}
+		var problem Problem
+		err = json.Unmarshal(trimmed, &problem)
+		return problem, err
+	}
+	return Problem{}, nil
+}
+
+// CodeForStatus returns the Code that best describes a response failing
+// with the HTTP status code status, for responses that don't describe
+// themselves with Diagnostics. Statuses without a more specific Code use
+// CodeInvalidValue.
+func CodeForStatus(status int) Code {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return CodeAccessDenied
+	case status == http.StatusNotFound || status == http.StatusGone:
+		return CodeNotFound
+	case status == http.StatusConflict || status == http.StatusPreconditionFailed:
+		return CodeConflict
+	case status == http.StatusRequestEntityTooLarge:
+		return CodeOverflow
+	case status == http.StatusTooManyRequests || status >= 500:
+		return CodeActOfGod
+	default:
+		return CodeInvalidValue
+	}
+}
@@ -0,0 +1,540 @@
+// Command apidiags validates, pretty-prints, and converts Diagnostics, for
+// debugging API responses and support workflows.
+//
+// Usage:
+//
+//	apidiags validate [file...]
+//	apidiags print [-body file] [-color] [file...]
+//	apidiags convert [-from format] [-to format] [-status code] [file...]
+//	apidiags reference [-format format] [-title title] [-builtin] [file...]
+//	apidiags diff [-all] old new
+//	apidiags go-errors [-package name] [-o file] [-builtin] [file...]
+//
+// Each command reads the files named, or stdin if there are none. Input can
+// be in any of the formats convert writes, which are:
+//
+//   - diagnostics: apidiags' versioned wire format, or a bare JSON array of
+//     Diagnostics
+//   - problem: an application/problem+json document
+//   - jsonapi: a JSON:API document with an errors member
+//   - grpc: the JSON encoding of a google.rpc.Status
+//
+// Formats are detected automatically unless -from is set.
+//
+// If print is given the JSON request body the Diagnostics are about with
+// -body, it shows the part of the body each Diagnostic points to, using
+// apidiags.Render.
+//
+// The reference command writes an API error reference, using
+// apidiags.WriteReference, in markdown, html, or json. Its input is one or
+// more registry documents, like those served by apidiags.RegistryHandler;
+// -builtin adds the codes defined by apidiags, and reads no input if no
+// files are named.
+//
+// The diff command compares the registry documents of two releases of an
+// API, using apidiags.DiffRegistries, and lists the breaking changes between
+// them, like removed codes, changed statuses, and codes that are no longer
+// retryable. It exits with a non-zero status if there are any, so it can
+// gate releases. -all lists changes that aren't breaking, too.
+//
+// The go-errors command writes a Go package for API SDKs with an error type
+// for each code in one or more registry documents, using
+// apidiags.GoErrorTypes. It's meant to be run by go generate:
+//
+//	//go:generate apidiags go-errors -package apierrors -o errors.go registry.json
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/status"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"impractical.co/apidiags"
+	"impractical.co/apidiags/apidiagsgrpc"
+)
+
+// The formats Diagnostics can be read and written in.
+const (
+	formatAuto        = "auto"
+	formatDiagnostics = "diagnostics"
+	formatProblem     = "problem"
+	formatJSONAPI     = "jsonapi"
+	formatGRPC        = "grpc"
+)
+
+const usage = `usage:
+  apidiags validate [file...]
+  apidiags print [-body file] [-color] [file...]
+  apidiags convert [-from format] [-to format] [-status code] [file...]
+  apidiags reference [-format format] [-title title] [-builtin] [file...]
+  apidiags diff [-all] old new
+  apidiags go-errors [-package name] [-o file] [-builtin] [file...]
+
+formats: diagnostics, problem, jsonapi, grpc
+reference formats: markdown, html, json
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command described by args, returning its exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) < 1 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	var err error
+	switch args[0] {
+	case "validate":
+		err = validateCommand(args[1:], stdin, stdout, stderr)
+	case "print":
+		err = printCommand(args[1:], stdin, stdout, stderr)
+	case "convert":
+		err = convertCommand(args[1:], stdin, stdout, stderr)
+	case "reference":
+		err = referenceCommand(args[1:], stdin, stdout, stderr)
+	case "diff":
+		err = diffCommand(args[1:], stdin, stdout, stderr)
+	case "go-errors":
+		err = goErrorsCommand(args[1:], stdin, stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n%s", args[0], usage)
+		return 2
+	}
+	var invalid invalidError
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &invalid):
+		fmt.Fprint(stderr, invalid.Error())
+		return 1
+	case err != nil:
+		fmt.Fprintf(stderr, "apidiags: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// input is the contents of a file, or stdin, and the name to describe it
+// by.
+type input struct {
+	name     string
+	contents []byte
+}
+
+// readInputs reads the files named by paths, or stdin if there are none.
+func readInputs(paths []string, stdin io.Reader) ([]input, error) {
+	if len(paths) < 1 {
+		contents, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("error reading stdin: %w", err)
+		}
+		return []input{{name: "stdin", contents: contents}}, nil
+	}
+	results := make([]input, 0, len(paths))
+	for _, path := range paths {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, input{name: path, contents: contents})
+	}
+	return results, nil
+}
+
+// invalidError lists the problems validate found.
+type invalidError []string
+
+func (e invalidError) Error() string {
+	return strings.Join(e, "\n") + "\n"
+}
+
+func validateCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	from := flags.String("from", formatAuto, "the format of the input")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	inputs, err := readInputs(flags.Args(), stdin)
+	if err != nil {
+		return err
+	}
+	var problems invalidError
+	for _, in := range inputs {
+		diags, err := decode(in.contents, *from)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", in.name, err))
+			continue
+		}
+		found := validate(diags)
+		for _, problem := range found {
+			problems = append(problems, fmt.Sprintf("%s: %s", in.name, problem))
+		}
+		if len(found) < 1 {
+			fmt.Fprintf(stdout, "%s: %d valid diagnostics\n", in.name, len(diags))
+		}
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+	return nil
+}
+
+// validate returns the problems with diags that decoding doesn't catch.
+func validate(diags apidiags.Diagnostics) []string {
+	var results []string
+	for pos, diag := range diags {
+		switch diag.Severity {
+		case apidiags.DiagnosticError, apidiags.DiagnosticWarning:
+		case "":
+			results = append(results, fmt.Sprintf("diagnostic %d: missing severity", pos))
+		default:
+			results = append(results, fmt.Sprintf("diagnostic %d: unknown severity %q", pos, diag.Severity))
+		}
+		if diag.Code == "" {
+			results = append(results, fmt.Sprintf("diagnostic %d: missing code", pos))
+		}
+		for pathPos, path := range diag.Paths {
+			if len(path) < 1 {
+				results = append(results, fmt.Sprintf("diagnostic %d: path %d is empty", pos, pathPos))
+			}
+		}
+	}
+	return results
+}
+
+func printCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("print", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	from := flags.String("from", formatAuto, "the format of the input")
+	bodyPath := flags.String("body", "", "a file holding the JSON request body the diagnostics are about, to show snippets of")
+	color := flags.Bool("color", false, "highlight the output with ANSI colors; only used with -body")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	var body []byte
+	if *bodyPath != "" {
+		body, err = os.ReadFile(*bodyPath)
+		if err != nil {
+			return err
+		}
+	}
+	inputs, err := readInputs(flags.Args(), stdin)
+	if err != nil {
+		return err
+	}
+	for _, in := range inputs {
+		diags, err := decode(in.contents, *from)
+		if err != nil {
+			return fmt.Errorf("%s: %w", in.name, err)
+		}
+		if len(inputs) > 1 {
+			fmt.Fprintf(stdout, "%s:\n", in.name)
+		}
+		if body == nil || len(diags) < 1 {
+			printDiagnostics(stdout, diags)
+			continue
+		}
+		var opts []apidiags.RenderOption
+		if *color {
+			opts = append(opts, apidiags.WithColor())
+		}
+		err = apidiags.Render(stdout, diags, body, opts...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printDiagnostics writes diags to w in a form meant for people, like
+// `error[missing] body.name: Name is required.`.
+func printDiagnostics(w io.Writer, diags apidiags.Diagnostics) {
+	if len(diags) < 1 {
+		fmt.Fprintln(w, "no diagnostics")
+		return
+	}
+	for _, diag := range diags {
+		fmt.Fprintf(w, "%s[%s]", diag.Severity, diag.Code)
+		paths := make([]string, 0, len(diag.Paths))
+		for _, path := range diag.Paths {
+			paths = append(paths, path.String())
+		}
+		if len(paths) > 0 {
+			fmt.Fprintf(w, " %s", strings.Join(paths, ", "))
+		}
+		if diag.Summary != "" {
+			fmt.Fprintf(w, ": %s", diag.Summary)
+		}
+		fmt.Fprintln(w)
+		if diag.Detail != "" {
+			fmt.Fprintf(w, "  %s\n", diag.Detail)
+		}
+		if diag.DocURL != "" {
+			fmt.Fprintf(w, "  see %s\n", diag.DocURL)
+		}
+	}
+}
+
+func convertCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	from := flags.String("from", formatAuto, "the format of the input")
+	to := flags.String("to", formatDiagnostics, "the format to convert to")
+	httpStatus := flags.Int("status", 0, "the HTTP status code for problem and jsonapi output; defaults to the status for the diagnostics")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	inputs, err := readInputs(flags.Args(), stdin)
+	if err != nil {
+		return err
+	}
+	for _, in := range inputs {
+		diags, err := decode(in.contents, *from)
+		if err != nil {
+			return fmt.Errorf("%s: %w", in.name, err)
+		}
+		out, err := encode(diags, *to, *httpStatus)
+		if err != nil {
+			return fmt.Errorf("%s: %w", in.name, err)
+		}
+		_, err = fmt.Fprintf(stdout, "%s\n", out)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// detect returns the format in is most likely to be in.
+func detect(in []byte) (string, error) {
+	trimmed := bytes.TrimSpace(in)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		return formatDiagnostics, nil
+	}
+	var members map[string]json.RawMessage
+	err := json.Unmarshal(trimmed, &members)
+	if err != nil {
+		return "", fmt.Errorf("input isn't a JSON object or array: %w", err)
+	}
+	switch {
+	case members["version"] != nil:
+		return formatDiagnostics, nil
+	case members["errors"] != nil:
+		return formatJSONAPI, nil
+	case members["diagnostics"] != nil, members["type"] != nil, members["title"] != nil:
+		return formatProblem, nil
+	case members["code"] != nil:
+		return formatGRPC, nil
+	}
+	return "", errors.New("can't detect the input's format; set -from")
+}
+
+// decode decodes the Diagnostics in in, which is in format.
+func decode(in []byte, format string) (apidiags.Diagnostics, error) {
+	if format == formatAuto {
+		var err error
+		format, err = detect(in)
+		if err != nil {
+			return nil, err
+		}
+	}
+	switch format {
+	case formatDiagnostics:
+		return apidiags.DecodeVersionedDiagnostics(in)
+	case formatProblem:
+		var problem apidiags.Problem
+		err := json.Unmarshal(in, &problem)
+		if err != nil {
+			return nil, err
+		}
+		return problem.Diagnostics, nil
+	case formatJSONAPI:
+		var doc struct {
+			Errors []apidiags.JSONAPIError `json:"errors"`
+		}
+		err := json.Unmarshal(in, &doc)
+		if err != nil {
+			return nil, err
+		}
+		return apidiags.DiagnosticsFromJSONAPIErrors(doc.Errors)
+	case formatGRPC:
+		var st status.Status
+		err := protojson.Unmarshal(in, &st)
+		if err != nil {
+			return nil, err
+		}
+		return apidiagsgrpc.FromStatus(grpcstatus.FromProto(&st)), nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// encode encodes diags in format, indented for people to read.
+func encode(diags apidiags.Diagnostics, format string, httpStatus int) ([]byte, error) {
+	if httpStatus == 0 {
+		httpStatus = apidiags.StatusForDiagnostics(diags)
+	}
+	var out []byte
+	var err error
+	switch format {
+	case formatDiagnostics:
+		out, err = apidiags.MarshalVersionedDiagnostics(diags)
+	case formatProblem:
+		out, err = json.Marshal(apidiags.NewProblem(httpStatus, diags))
+	case formatJSONAPI:
+		var errs []apidiags.JSONAPIError
+		errs, err = apidiags.JSONAPIErrorsFromDiagnostics(httpStatus, diags)
+		if err == nil {
+			out, err = json.Marshal(map[string][]apidiags.JSONAPIError{"errors": errs})
+		}
+	case formatGRPC:
+		var st *grpcstatus.Status
+		st, err = apidiagsgrpc.ToStatus(diags)
+		if err == nil {
+			out, err = protojson.Marshal(st.Proto())
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = json.Indent(&buf, out, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func referenceCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("reference", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", string(apidiags.ReferenceMarkdown), "the format to write the reference in")
+	title := flags.String("title", apidiags.DefaultReferenceTitle, "the title of the reference")
+	builtin := flags.Bool("builtin", false, "include the codes defined by apidiags")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	registry, err := readRegistry(flags.Args(), *builtin, stdin)
+	if err != nil {
+		return err
+	}
+	return apidiags.WriteReference(stdout, registry, apidiags.ReferenceFormat(*format), apidiags.WithReferenceTitle(*title))
+}
+
+// readRegistry returns a Registry of the codes in the registry documents
+// named by paths, or stdin if there are none. If builtin is true, the
+// Registry also has the codes defined by apidiags, and stdin isn't read if
+// no paths are named.
+func readRegistry(paths []string, builtin bool, stdin io.Reader) (*apidiags.Registry, error) {
+	registry := apidiags.NewRegistry()
+	if builtin {
+		registry = apidiags.NewRegistry(apidiags.BuiltinCodes()...)
+		if len(paths) < 1 {
+			return registry, nil
+		}
+	}
+	inputs, err := readInputs(paths, stdin)
+	if err != nil {
+		return nil, err
+	}
+	for _, in := range inputs {
+		var doc apidiags.RegistryDocument
+		err = json.Unmarshal(in.contents, &doc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", in.name, err)
+		}
+		for _, info := range doc.Codes {
+			err = registry.Register(info)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", in.name, err)
+			}
+		}
+	}
+	return registry, nil
+}
+
+func goErrorsCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("go-errors", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	pkg := flags.String("package", "apierrors", "the name of the package to generate")
+	out := flags.String("o", "", "the file to write the package to, instead of stdout")
+	builtin := flags.Bool("builtin", false, "include the codes defined by apidiags")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	registry, err := readRegistry(flags.Args(), *builtin, stdin)
+	if err != nil {
+		return err
+	}
+	src, err := apidiags.GoErrorTypes(*pkg, registry)
+	if err != nil {
+		return err
+	}
+	if *out != "" {
+		return os.WriteFile(*out, src, 0o644)
+	}
+	_, err = stdout.Write(src)
+	return err
+}
+
+func diffCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	all := flags.Bool("all", false, "list changes that aren't breaking, too")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return errors.New("diff needs the old and new registry documents")
+	}
+	inputs, err := readInputs(flags.Args(), stdin)
+	if err != nil {
+		return err
+	}
+	docs := make([]apidiags.RegistryDocument, len(inputs))
+	for pos, in := range inputs {
+		err = json.Unmarshal(in.contents, &docs[pos])
+		if err != nil {
+			return fmt.Errorf("%s: %w", in.name, err)
+		}
+	}
+	var breaking int
+	for _, change := range apidiags.DiffRegistries(docs[0], docs[1]) {
+		switch {
+		case change.Breaking:
+			breaking++
+			fmt.Fprintf(stdout, "breaking: %s\n", change)
+		case *all:
+			fmt.Fprintf(stdout, "%s\n", change)
+		}
+	}
+	switch breaking {
+	case 0:
+		return nil
+	case 1:
+		return errors.New("1 breaking change")
+	}
+	return fmt.Errorf("%d breaking changes", breaking)
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		args     []string
+		stdin    string
+		stdout   string
+		stderr   string
+		exitCode int
+	}
+
+	diags := `[{"severity":"error","code":"missing","path":[[{"kind":"body"},{"kind":"object_property","value":"name"}]],"summary":"Name is required.","doc_url":"https://example.com/docs/missing"}]`
+
+	bodyPath := filepath.Join(t.TempDir(), "body.json")
+	err := os.WriteFile(bodyPath, []byte("{\n  \"name\": \"\"\n}\n"), 0o600)
+	if err != nil {
+		t.Fatalf("error writing body: %s", err)
+	}
+
+	registryPath := filepath.Join(t.TempDir(), "registry.json")
+	err = os.WriteFile(registryPath, []byte(`{"codes":[{"code":"missing"}]}`), 0o600)
+	if err != nil {
+		t.Fatalf("error writing registry: %s", err)
+	}
+
+	newRegistryPath := filepath.Join(t.TempDir(), "new.json")
+	err = os.WriteFile(newRegistryPath, []byte(`{"codes":[{"code":"missing","status":422},{"code":"quota_exceeded","status":429}]}`), 0o600)
+	if err != nil {
+		t.Fatalf("error writing registry: %s", err)
+	}
+
+	cases := map[string]testCase{
+		"no-command": {
+			stderr:   usage,
+			exitCode: 2,
+		},
+		"unknown-command": {
+			args:     []string{"frobnicate"},
+			stderr:   "unknown command \"frobnicate\"\n" + usage,
+			exitCode: 2,
+		},
+		"print": {
+			args:   []string{"print"},
+			stdin:  diags,
+			stdout: "error[missing] body.name: Name is required.\n  see https://example.com/docs/missing\n",
+		},
+		"print-body": {
+			args:  []string{"print", "-body", bodyPath},
+			stdin: diags,
+			stdout: strings.Join([]string{
+				"error[missing]: Name is required.",
+				" --> body.name (line 2, column 11)",
+				"  |",
+				`2 |   "name": ""`,
+				"  |           ^^",
+				"  = see https://example.com/docs/missing",
+				"",
+				"",
+			}, "\n"),
+		},
+		"print-empty": {
+			args:   []string{"print"},
+			stdin:  `{"version":1,"diagnostics":[]}`,
+			stdout: "no diagnostics\n",
+		},
+		"validate": {
+			args:   []string{"validate"},
+			stdin:  diags,
+			stdout: "stdin: 1 valid diagnostics\n",
+		},
+		"validate-invalid": {
+			args:     []string{"validate"},
+			stdin:    `[{"severity":"fatal","code":""}]`,
+			stderr:   "stdin: diagnostic 0: unknown severity \"fatal\"\nstdin: diagnostic 0: missing code\n",
+			exitCode: 1,
+		},
+		"validate-undetectable": {
+			args:     []string{"validate"},
+			stdin:    `{"hello":"world"}`,
+			stderr:   "stdin: can't detect the input's format; set -from\n",
+			exitCode: 1,
+		},
+		"convert-problem": {
+			args:  []string{"convert", "-to", "problem", "-status", "422"},
+			stdin: `{"errors":[{"code":"missing","source":{"pointer":"/name"}}]}`,
+			stdout: `{
+  "diagnostics": [
+    {
+      "severity": "error",
+      "code": "missing",
+      "path": [
+        [
+          {
+            "kind": "body"
+          },
+          {
+            "kind": "object_property",
+            "value": "name"
+          }
+        ]
+      ]
+    }
+  ],
+  "status": 422,
+  "title": "Unprocessable Entity"
+}
+`,
+		},
+		"convert-grpc": {
+			args:  []string{"convert", "-to", "diagnostics"},
+			stdin: `{"code":5,"message":"Widget not found."}`,
+			stdout: `{
+  "version": 1,
+  "diagnostics": [
+    {
+      "severity": "error",
+      "code": "not_found",
+      "summary": "Widget not found."
+    }
+  ]
+}
+`,
+		},
+		"reference": {
+			args:  []string{"reference", "-title", "Errors"},
+			stdin: `{"codes":[{"code":"quota_exceeded","description":"Out of quota.","status":429,"retryable":true}]}`,
+			stdout: "# Errors\n\n## `quota_exceeded`\n\nOut of quota.\n\n- Status: 429 Too Many Requests\n- Retryable: yes\n\n" +
+				"Example:\n\n```json\n{\n  \"severity\": \"error\",\n  \"code\": \"quota_exceeded\",\n  \"summary\": \"Out of quota.\"\n}\n```\n",
+		},
+		"reference-duplicate": {
+			args:     []string{"reference", "-builtin", registryPath},
+			stderr:   "apidiags: " + registryPath + ": code already registered: missing\n",
+			exitCode: 1,
+		},
+		"reference-unknown-format": {
+			args:     []string{"reference", "-builtin", "-format", "pdf"},
+			stderr:   "apidiags: unknown reference format \"pdf\"\n",
+			exitCode: 1,
+		},
+		"diff": {
+			args:     []string{"diff", registryPath, newRegistryPath},
+			stdout:   "breaking: status of missing changed from none to 422 Unprocessable Entity\n",
+			stderr:   "apidiags: 1 breaking change\n",
+			exitCode: 1,
+		},
+		"diff-all": {
+			args:     []string{"diff", "-all", registryPath, newRegistryPath},
+			stdout:   "breaking: status of missing changed from none to 422 Unprocessable Entity\nquota_exceeded was added\n",
+			stderr:   "apidiags: 1 breaking change\n",
+			exitCode: 1,
+		},
+		"diff-compatible": {
+			args: []string{"diff", registryPath, registryPath},
+		},
+		"diff-breaking": {
+			args:     []string{"diff", newRegistryPath, registryPath},
+			stdout:   "breaking: status of missing changed from 422 Unprocessable Entity to none\nbreaking: quota_exceeded was removed\n",
+			stderr:   "apidiags: 2 breaking changes\n",
+			exitCode: 1,
+		},
+		"diff-one-document": {
+			args:     []string{"diff", registryPath},
+			stderr:   "apidiags: diff needs the old and new registry documents\n",
+			exitCode: 1,
+		},
+		"go-errors-invalid-package": {
+			args:     []string{"go-errors", "-package", "widget-errors", registryPath},
+			stderr:   "apidiags: invalid package name \"widget-errors\"\n",
+			exitCode: 1,
+		},
+		"convert-unknown-format": {
+			args:     []string{"convert", "-to", "xml"},
+			stdin:    diags,
+			stderr:   "apidiags: stdin: unknown format \"xml\"\n",
+			exitCode: 1,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			exitCode := run(tc.args, strings.NewReader(tc.stdin), &stdout, &stderr)
+			if exitCode != tc.exitCode {
+				t.Errorf("expected exit code %d, got %d", tc.exitCode, exitCode)
+			}
+			if diff := cmp.Diff(tc.stdout, stdout.String()); diff != "" {
+				t.Errorf("unexpected stdout (-wanted, +got): %s", diff)
+			}
+			if diff := cmp.Diff(tc.stderr, stderr.String()); diff != "" {
+				t.Errorf("unexpected stderr (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestGoErrorsCommand(t *testing.T) {
+	t.Parallel()
+
+	out := filepath.Join(t.TempDir(), "errors.go")
+	var stdout, stderr bytes.Buffer
+	exitCode := run([]string{"go-errors", "-package", "widgeterrors", "-o", out}, strings.NewReader(`{"codes":[{"code":"quota_exceeded","status":429,"retryable":true}]}`), &stdout, &stderr)
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", exitCode, stderr.String())
+	}
+	if stdout.Len() > 0 {
+		t.Errorf("expected no output with -o, got %q", stdout.String())
+	}
+	src, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("error reading generated package: %s", err)
+	}
+	for _, expected := range []string{
+		"package widgeterrors\n",
+		"type QuotaExceeded struct {\n",
+		"func (e QuotaExceeded) RateLimit() (apidiags.RateLimit, bool, error) {\n",
+	} {
+		if !strings.Contains(string(src), expected) {
+			t.Errorf("expected generated package to contain %q, got:\n%s", expected, src)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package apidiags
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+)
+
+// DebugMember is the extension member WithDebugInfo writes a Diagnostic's
+// DebugInfo to.
+const DebugMember = "debug"
+
+// DebugHeader is the request header DebugRequested checks for callers
+// asking for DebugInfo to be included in the response.
+const DebugHeader = "X-API-Debug"
+
+// DebugInfo is information about a Diagnostic meant only for the service's
+// developers, which would leak implementation details, or worse, if it was
+// sent to every caller. It's only included in responses written with
+// WithDebugInfo(true).
+type DebugInfo struct {
+	// Error is the message of the internal error that caused the
+	// Diagnostic.
+	Error string `json:"error,omitempty"`
+
+	// Stack is the stack trace of the goroutine that produced the
+	// Diagnostic.
+	Stack string `json:"stack,omitempty"`
+
+	// Details holds any other server-only information, like the IDs of
+	// requests to upstream services.
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// WithDebug returns a copy of d with info as its Debug.
+func (d Diagnostic) WithDebug(info DebugInfo) Diagnostic {
+	d.Debug = &info
+	return d
+}
+
+// WithDebugError returns a copy of d with a Debug recording err's message
+// and the current stack trace, keeping any Details d's Debug already had.
+func (d Diagnostic) WithDebugError(err error) Diagnostic {
+	var info DebugInfo
+	if d.Debug != nil {
+		info = *d.Debug
+	}
+	if err != nil {
+		info.Error = err.Error()
+	}
+	info.Stack = string(debug.Stack())
+	return d.WithDebug(info)
+}
+
+// DebugRequested reports whether r asks for DebugInfo to be included in
+// the response, by setting the DebugHeader to a true value, like "1" or
+// "true". Callers are never entitled to DebugInfo; use it like
+//
+//	apidiags.WithDebugInfo(!production && apidiags.DebugRequested(r))
+func DebugRequested(r *http.Request) bool {
+	enabled, err := strconv.ParseBool(r.Header.Get(DebugHeader))
+	return err == nil && enabled
+}
+
+// WithDebugInfo makes WriteHTTP, WriteEnvelope, WriteBatch, and
+// InjectDiagnostics include the Debug of each Diagnostic in its
+// Extensions, as DebugMember, if enabled is true. If enabled is false, the
+// default, Debug is left out, and so is any DebugMember already in the
+// Diagnostics' Extensions, like one passed along from an upstream service,
+// so DebugInfo never reaches callers by accident. Diagnostic.MarshalJSON
+// leaves out any DebugMember WithDebugInfo(true) didn't set, so Diagnostics
+// written any other way, like with an Encoder or WriteProblem, never
+// include it either.
+func WithDebugInfo(enabled bool) HTTPOption {
+	return func(c *httpConfig) {
+		c.debug = enabled
+	}
+}
+
+// debugInfo returns diags with their Debug moved into their Extensions, if
+// cfg enables it. A DebugMember a Diagnostic already has in its Extensions,
+// like one passed along from an upstream service, becomes its Debug, unless
+// it has one, so Diagnostic.MarshalJSON encodes it. diags is returned as-is
+// if nothing needs to change; otherwise, a copy is returned.
+func (c httpConfig) debugInfo(diags Diagnostics) Diagnostics {
+	if !c.debug {
+		return diags
+	}
+	var results Diagnostics
+	for pos, diag := range diags {
+		member, hasMember := diag.Extensions[DebugMember]
+		if diag.Debug == nil && !hasMember {
+			continue
+		}
+		if diag.Debug == nil {
+			var info DebugInfo
+			if err := json.Unmarshal(member, &info); err != nil {
+				continue
+			}
+			diag.Debug = &info
+		}
+		encoded, err := json.Marshal(diag.Debug)
+		if err != nil {
+			continue
+		}
+		diag = diag.withExtension(DebugMember, encoded)
+		if results == nil {
+			results = make(Diagnostics, len(diags))
+			copy(results, diags)
+		}
+		results[pos] = diag
+	}
+	if results == nil {
+		return diags
+	}
+	return results
+}
+
+// encodesDebugMember returns true if Diagnostic.MarshalJSON should encode
+// the DebugMember in d's Extensions: only if it's the encoding of d's
+// Debug, as WithDebugInfo(true) sets it, so DebugInfo never reaches
+// callers by accident, however the Diagnostic is written.
+func (d Diagnostic) encodesDebugMember() bool {
+	member, ok := d.Extensions[DebugMember]
+	if !ok || d.Debug == nil {
+		return false
+	}
+	encoded, err := json.Marshal(d.Debug)
+	if err != nil {
+		return false
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, member); err != nil {
+		return false
+	}
+	return bytes.Equal(compacted.Bytes(), encoded)
+}
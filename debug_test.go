@@ -0,0 +1,207 @@
+package apidiags
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWithDebugInfo(t *testing.T) {
+	t.Parallel()
+
+	diags := Diagnostics{
+		Diagnostic{Severity: DiagnosticError, Code: CodeActOfGod}.WithDebug(DebugInfo{
+			Error:   "connection refused",
+			Details: map[string]string{"upstream_id": "abc"},
+		}),
+		{
+			Severity:   DiagnosticWarning,
+			Code:       CodeDeprecated,
+			Extensions: map[string]json.RawMessage{DebugMember: json.RawMessage(`{"error":"from upstream"}`)},
+		},
+	}
+
+	type testCase struct {
+		opts     []HTTPOption
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"default": {
+			expected: `[{"severity":"error","code":"act_of_god"},{"severity":"warning","code":"deprecated"}]`,
+		},
+		"disabled": {
+			opts:     []HTTPOption{WithDebugInfo(false)},
+			expected: `[{"severity":"error","code":"act_of_god"},{"severity":"warning","code":"deprecated"}]`,
+		},
+		"enabled": {
+			opts: []HTTPOption{WithDebugInfo(true)},
+			expected: `[{"severity":"error","code":"act_of_god","debug":{"error":"connection refused","details":{"upstream_id":"abc"}}},` +
+				`{"severity":"warning","code":"deprecated","debug":{"error":"from upstream"}}]`,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			err := WriteHTTP(w, diags, tc.opts...)
+			if err != nil {
+				t.Fatalf("error writing response: %s", err)
+			}
+			var problem struct {
+				Diagnostics json.RawMessage `json:"diagnostics"`
+			}
+			err = json.Unmarshal(w.Body.Bytes(), &problem)
+			if err != nil {
+				t.Fatalf("error parsing response: %s", err)
+			}
+			if got := string(problem.Diagnostics); got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+	if _, ok := diags[1].Extensions[DebugMember]; !ok {
+		t.Error("expected the Diagnostics written not to be modified")
+	}
+}
+
+func TestDebugMemberLeftOut(t *testing.T) {
+	t.Parallel()
+
+	upstream := map[string]json.RawMessage{DebugMember: json.RawMessage(`{"error":"from upstream"}`)}
+	local := Diagnostic{Severity: DiagnosticError, Code: CodeActOfGod}.WithDebug(DebugInfo{Error: "connection refused"})
+	diags := Diagnostics{
+		{Severity: DiagnosticWarning, Code: CodeDeprecated, Extensions: upstream},
+		local,
+		local.withExtension(DebugMember, upstream[DebugMember]),
+	}
+	expected := `[{"severity":"warning","code":"deprecated"},{"severity":"error","code":"act_of_god"},{"severity":"error","code":"act_of_god"}]`
+
+	type testCase struct {
+		write func(Diagnostics) ([]byte, error)
+	}
+
+	cases := map[string]testCase{
+		"marshal": {write: func(diags Diagnostics) ([]byte, error) {
+			return json.Marshal(diags)
+		}},
+		"encoder": {write: func(diags Diagnostics) ([]byte, error) {
+			var buf bytes.Buffer
+			enc := NewEncoder(&buf)
+			err := enc.Encode(diags...)
+			if err != nil {
+				return nil, err
+			}
+			err = enc.Close()
+			return buf.Bytes(), err
+		}},
+		"problem": {write: func(diags Diagnostics) ([]byte, error) {
+			w := httptest.NewRecorder()
+			err := WriteProblem(w, NewProblem(http.StatusServiceUnavailable, diags))
+			if err != nil {
+				return nil, err
+			}
+			var problem struct {
+				Diagnostics json.RawMessage `json:"diagnostics"`
+			}
+			err = json.Unmarshal(w.Body.Bytes(), &problem)
+			return problem.Diagnostics, err
+		}},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tc.write(diags)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if string(got) != expected {
+				t.Errorf("expected %s, got %s", expected, got)
+			}
+		})
+	}
+}
+
+func TestWithDebugError(t *testing.T) {
+	t.Parallel()
+
+	diag := Diagnostic{Severity: DiagnosticError, Code: CodeActOfGod}.
+		WithDebug(DebugInfo{Details: map[string]string{"upstream_id": "abc"}}).
+		WithDebugError(errors.New("connection refused"))
+	if diag.Debug.Error != "connection refused" {
+		t.Errorf("expected error %q, got %q", "connection refused", diag.Debug.Error)
+	}
+	if !strings.Contains(diag.Debug.Stack, "TestWithDebugError") {
+		t.Errorf("expected stack to include the test, got %s", diag.Debug.Stack)
+	}
+	if diff := cmp.Diff(map[string]string{"upstream_id": "abc"}, diag.Debug.Details); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+}
+
+func TestDebugRequested(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		header   string
+		expected bool
+	}
+
+	cases := map[string]testCase{
+		"unset": {},
+		"true":  {header: "true", expected: true},
+		"one":   {header: "1", expected: true},
+		"false": {header: "0"},
+		"junk":  {header: "please"},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				r.Header.Set(DebugHeader, tc.header)
+			}
+			if got := DebugRequested(r); got != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestRecoverPanicsDebugInfo(t *testing.T) {
+	t.Parallel()
+
+	handler := RecoverPanics(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("oh no")
+	}), WithRecoverHTTPOptions(WithDebugInfo(true)))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	var problem Problem
+	err := json.Unmarshal(w.Body.Bytes(), &problem)
+	if err != nil {
+		t.Fatalf("error parsing response: %s", err)
+	}
+	var info DebugInfo
+	err = json.Unmarshal(problem.Diagnostics[0].Extensions[DebugMember], &info)
+	if err != nil {
+		t.Fatalf("error parsing debug info: %s", err)
+	}
+	if info.Error != "oh no" || !strings.Contains(info.Stack, "panic") {
+		t.Errorf("unexpected debug info %+v", info)
+	}
+}
@@ -0,0 +1,381 @@
+package apidiags
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxBodyBytes is the largest request body DecodeBody reads when no
+// other limit is set using WithMaxBodyBytes.
+const DefaultMaxBodyBytes = 1 << 20
+
+// DecodeOption configures how DecodeBody decodes a request body.
+type DecodeOption func(*decodeConfig)
+
+type decodeConfig struct {
+	maxBytes      int64
+	allowUnknown  bool
+	allowTrailing bool
+}
+
+// WithMaxBodyBytes sets the largest request body, in bytes, that
+// DecodeBody reads before giving up with a Diagnostic with a Code of
+// CodeOverflow.
+func WithMaxBodyBytes(n int64) DecodeOption {
+	return func(c *decodeConfig) {
+		c.maxBytes = n
+	}
+}
+
+// AllowUnknownFields makes DecodeBody ignore object members that don't
+// match any field of the struct they're decoded into, instead of
+// reporting them.
+func AllowUnknownFields() DecodeOption {
+	return func(c *decodeConfig) {
+		c.allowUnknown = true
+	}
+}
+
+// DecodeBody decodes the JSON request body of r into dst, which must be a
+// pointer, describing everything that goes wrong as Diagnostics with a
+// Severity of DiagnosticError. If the body is decoded successfully, it
+// returns nil.
+//
+// Bodies larger than the limit set by WithMaxBodyBytes, or
+// DefaultMaxBodyBytes, fail with CodeOverflow, and empty bodies fail with
+// CodeMissing. JSON syntax errors, truncated bodies, and data after the
+// JSON value fail with CodeInvalidFormat, pointing to the byte offset of
+// the problem using a StringIndexStep. Values of the wrong type fail with
+// CodeInvalidFormat, pointing to the value. Object members that don't
+// match a field of the struct they're decoded into fail with
+// CodeInvalidValue, pointing to the member, unless AllowUnknownFields is
+// used.
+//
+// Errors reading the body are converted using FromError, except for the
+// *http.MaxBytesError returned by bodies wrapped in http.MaxBytesReader,
+// which fails with CodeOverflow.
+func DecodeBody(r *http.Request, dst any, opts ...DecodeOption) Diagnostics {
+	cfg := decodeConfig{maxBytes: DefaultMaxBodyBytes}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if r.Body == nil || r.Body == http.NoBody {
+		return Diagnostics{missingBody()}
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, cfg.maxBytes+1))
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return Diagnostics{bodyTooLarge(maxBytesErr.Limit)}
+	case err != nil:
+		return FromError(err)
+	case int64(len(body)) > cfg.maxBytes:
+		return Diagnostics{bodyTooLarge(cfg.maxBytes)}
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if !cfg.allowUnknown {
+		dec.DisallowUnknownFields()
+	}
+	err = dec.Decode(dst)
+	if err != nil {
+		return Diagnostics{decodeError(err, body, dst)}
+	}
+	end := dec.InputOffset()
+	if _, err := dec.Token(); err != io.EOF {
+		return Diagnostics{{
+			Severity: DiagnosticError,
+			Code:     CodeInvalidFormat,
+			Paths:    []Steps{BodyPath().AddStep(StringIndexStep(nextTokenOffset(body, end)))},
+			Detail:   "The request body has data after its JSON value.",
+		}}
+	}
+	return nil
+}
+
+func missingBody() Diagnostic {
+	return Diagnostic{
+		Severity: DiagnosticError,
+		Code:     CodeMissing,
+		Paths:    []Steps{BodyPath()},
+	}
+}
+
+func bodyTooLarge(limit int64) Diagnostic {
+	return Diagnostic{
+		Severity: DiagnosticError,
+		Code:     CodeOverflow,
+		Paths:    []Steps{BodyPath()},
+		Summary:  "The request body is too large.",
+		Detail:   "The request body can be at most " + strconv.FormatInt(limit, 10) + " bytes.",
+	}
+}
+
+// decodeError converts an error returned while decoding body into dst into
+// a Diagnostic.
+func decodeError(err error, body []byte, dst any) Diagnostic {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var invalidErr *json.InvalidUnmarshalError
+	switch {
+	case errors.As(err, &invalidErr):
+		// dst isn't a pointer, which isn't the client's fault
+		return FromError(err)[0]
+	case errors.Is(err, io.EOF):
+		return missingBody()
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return Diagnostic{
+			Severity: DiagnosticError,
+			Code:     CodeInvalidFormat,
+			Paths:    []Steps{BodyPath().AddStep(StringIndexStep(len(body)))},
+			Detail:   "The request body ends before its JSON value does.",
+		}
+	case errors.As(err, &syntaxErr):
+		// the error is found after reading the offending byte
+		offset := syntaxErr.Offset
+		if offset > 0 {
+			offset--
+		}
+		return Diagnostic{
+			Severity: DiagnosticError,
+			Code:     CodeInvalidFormat,
+			Paths:    []Steps{BodyPath().AddStep(StringIndexStep(offset))},
+			Detail:   syntaxErr.Error(),
+		}
+	case errors.As(err, &typeErr):
+		path := BodyPath()
+		walkJSON(body, reflect.TypeOf(dst), func(value jsonValue) bool {
+			if value.end == typeErr.Offset {
+				path = value.path
+				return true
+			}
+			return false
+		})
+		return Diagnostic{
+			Severity: DiagnosticError,
+			Code:     CodeInvalidFormat,
+			Paths:    []Steps{path},
+			Detail:   "Expected " + typeErr.Type.String() + ", got " + typeErr.Value + ".",
+		}
+	}
+	// encoding/json has no type for unknown field errors, only a message
+	const unknownPrefix = "json: unknown field "
+	if strings.HasPrefix(err.Error(), unknownPrefix) {
+		if name, err := strconv.Unquote(strings.TrimPrefix(err.Error(), unknownPrefix)); err == nil {
+			return unknownField(body, dst, name)
+		}
+	}
+	return Diagnostic{
+		Severity: DiagnosticError,
+		Code:     CodeInvalidValue,
+		Paths:    []Steps{BodyPath()},
+		Detail:   err.Error(),
+	}
+}
+
+// unknownField returns a Diagnostic pointing to the first member of body
+// named name that doesn't match a field of the struct it's decoded into.
+func unknownField(body []byte, dst any, name string) Diagnostic {
+	diag := Diagnostic{
+		Severity: DiagnosticError,
+		Code:     CodeInvalidValue,
+		Paths:    []Steps{BodyPath()},
+		Detail:   "Unknown field " + strconv.Quote(name) + ".",
+	}
+	walkJSON(body, reflect.TypeOf(dst), func(value jsonValue) bool {
+		if value.key != name || value.parent == nil || value.parent.Kind() != reflect.Struct {
+			return false
+		}
+		if _, ok := structFieldType(value.parent, name); ok {
+			return false
+		}
+		diag.Paths = []Steps{value.path}
+		return true
+	})
+	return diag
+}
+
+// jsonValue describes a value found by walkJSON.
+type jsonValue struct {
+	// path points to the value.
+	path Steps
+
+	// key is the name of the object member holding the value, if any.
+	key string
+
+	// parent is the Go type the object or array holding the value is
+	// decoded into, if known.
+	parent reflect.Type
+
+	// start is the byte offset of the value's first token.
+	start int64
+
+	// end is the byte offset just past the value's first token, like
+	// the opening brace of an object.
+	end int64
+}
+
+// jsonFrame is an object or array walkJSON is inside of.
+type jsonFrame struct {
+	path   Steps
+	typ    reflect.Type
+	object bool
+	key    string
+	index  int64
+	onKey  bool
+}
+
+// walkJSON calls fn with every value in body, in order, until fn returns
+// true. typ is the Go type body is decoded into, used to determine the
+// parent of each value; it may be nil.
+func walkJSON(body []byte, typ reflect.Type, fn func(jsonValue) bool) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	root := &jsonFrame{path: BodyPath(), typ: typ}
+	stack := []*jsonFrame{root}
+	for {
+		start := valueOffset(body, dec.InputOffset())
+		tok, err := dec.Token()
+		if err != nil {
+			return
+		}
+		top := stack[len(stack)-1]
+		if delim, ok := tok.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			advance(stack[len(stack)-1])
+			continue
+		}
+		if top.object && top.onKey {
+			top.key, _ = tok.(string)
+			top.onKey = false
+			continue
+		}
+		value := jsonValue{path: top.path, parent: derefType(top.typ), start: start, end: dec.InputOffset()}
+		var typ reflect.Type
+		switch {
+		case top == root:
+			typ = top.typ
+		case top.object:
+			value.key = top.key
+			value.path = top.path.AddStep(ObjectPropertyStep(top.key))
+			typ = elemType(top.typ, top.key)
+		default:
+			value.path = top.path.AddStep(ArrayIndexStep(top.index))
+			typ = elemType(top.typ, "")
+		}
+		if fn(value) {
+			return
+		}
+		if delim, ok := tok.(json.Delim); ok {
+			stack = append(stack, &jsonFrame{path: value.path, typ: typ, object: delim == '{', onKey: delim == '{'})
+			continue
+		}
+		advance(top)
+	}
+}
+
+// advance moves frame past the value it just finished reading.
+func advance(frame *jsonFrame) {
+	if frame.object {
+		frame.onKey = true
+		return
+	}
+	frame.index++
+}
+
+// derefType returns the type typ points to, following any number of
+// pointers.
+func derefType(typ reflect.Type) reflect.Type {
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+// elemType returns the Go type the member key of an object, or the
+// elements of an array, are decoded into when the object or array is
+// decoded into typ. If it can't be determined, nil is returned.
+func elemType(typ reflect.Type, key string) reflect.Type {
+	typ = derefType(typ)
+	if typ == nil {
+		return nil
+	}
+	switch typ.Kind() {
+	case reflect.Struct:
+		field, _ := structFieldType(typ, key)
+		return field
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return typ.Elem()
+	}
+	return nil
+}
+
+// structFieldType returns the type of the field of typ, a struct type, that
+// an object member named name is decoded into, matching names the way
+// encoding/json does: by `json` struct tag or field name, preferring exact
+// matches, and looking into embedded structs.
+func structFieldType(typ reflect.Type, name string) (reflect.Type, bool) {
+	var fold reflect.Type
+	for pos := 0; pos < typ.NumField(); pos++ {
+		field := typ.Field(pos)
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag == "-" {
+			continue
+		}
+		if field.Anonymous && tag == "" {
+			embedded := derefType(field.Type)
+			if embedded.Kind() == reflect.Struct {
+				if result, ok := structFieldType(embedded, name); ok {
+					return result, true
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		fieldName := tag
+		if fieldName == "" {
+			fieldName = field.Name
+		}
+		if fieldName == name {
+			return field.Type, true
+		}
+		if fold == nil && strings.EqualFold(fieldName, name) {
+			fold = field.Type
+		}
+	}
+	return fold, fold != nil
+}
+
+// valueOffset is like nextTokenOffset, but also skips the commas and colons
+// separating values, returning the byte offset the next value starts at.
+func valueOffset(body []byte, offset int64) int64 {
+	for offset < int64(len(body)) {
+		switch body[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+// nextTokenOffset returns the offset of the first byte at or after offset
+// in body that isn't JSON whitespace.
+func nextTokenOffset(body []byte, offset int64) int64 {
+	for offset < int64(len(body)) {
+		switch body[offset] {
+		case ' ', '\t', '\r', '\n':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
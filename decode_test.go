@@ -0,0 +1,154 @@
+package apidiags
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type testDecodeLabel struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type testDecodeMeta struct {
+	Owner string `json:"owner"`
+}
+
+type testDecodeWidget struct {
+	testDecodeMeta
+	Name   string            `json:"name"`
+	Count  int               `json:"count"`
+	Tags   []int             `json:"tags"`
+	Labels []testDecodeLabel `json:"labels"`
+	Extra  map[string]any    `json:"extra"`
+}
+
+func TestDecodeBody(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		body     string
+		opts     []DecodeOption
+		expected Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"valid": {
+			body: `{"name": "foo", "owner": "bar", "count": 1, "tags": [1, 2], "extra": {"anything": true}}` + "\n",
+		},
+		"empty": {
+			body:     "  ",
+			expected: Diagnostics{{Severity: DiagnosticError, Code: CodeMissing, Paths: []Steps{BodyPath()}}},
+		},
+		"too-large": {
+			body: `{"name": "foobar"}`,
+			opts: []DecodeOption{WithMaxBodyBytes(10)},
+			expected: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeOverflow,
+				Paths:    []Steps{BodyPath()},
+				Summary:  "The request body is too large.",
+				Detail:   "The request body can be at most 10 bytes.",
+			}},
+		},
+		"syntax": {
+			body: `{"name": "foo",}`,
+			expected: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeInvalidFormat,
+				Paths:    []Steps{PathOf(BodyStep{}, StringIndexStep(15))},
+				Detail:   "invalid character '}' looking for beginning of object key string",
+			}},
+		},
+		"truncated": {
+			body: `{"name": "foo"`,
+			expected: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeInvalidFormat,
+				Paths:    []Steps{PathOf(BodyStep{}, StringIndexStep(14))},
+				Detail:   "The request body ends before its JSON value does.",
+			}},
+		},
+		"trailing-data": {
+			body: `{"name": "foo"} {}`,
+			expected: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeInvalidFormat,
+				Paths:    []Steps{PathOf(BodyStep{}, StringIndexStep(16))},
+				Detail:   "The request body has data after its JSON value.",
+			}},
+		},
+		"wrong-type": {
+			body: `{"name": "foo", "tags": [1, "two"]}`,
+			expected: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeInvalidFormat,
+				Paths:    []Steps{PathOf(BodyStep{}, ObjectPropertyStep("tags"), ArrayIndexStep(1))},
+				Detail:   "Expected int, got string.",
+			}},
+		},
+		"wrong-type-object": {
+			body: `{"labels": [{"key": "a"}, {"key": {"nested": true}}]}`,
+			expected: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeInvalidFormat,
+				Paths:    []Steps{PathOf(BodyStep{}, ObjectPropertyStep("labels"), ArrayIndexStep(1), ObjectPropertyStep("key"))},
+				Detail:   "Expected string, got object.",
+			}},
+		},
+		"unknown-field": {
+			body: `{"labels": [{"key": "a", "value": "b"}], "extra": {"key": 1}, "more": [{"key": "c", "name": "d"}], "name": "e"}`,
+			expected: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeInvalidValue,
+				Paths:    []Steps{PathOf(BodyStep{}, ObjectPropertyStep("more"))},
+				Detail:   `Unknown field "more".`,
+			}},
+		},
+		"unknown-nested-field": {
+			body: `{"name": "foo", "labels": [{"key": "a"}, {"key": "b", "name": "c"}]}`,
+			expected: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeInvalidValue,
+				Paths:    []Steps{PathOf(BodyStep{}, ObjectPropertyStep("labels"), ArrayIndexStep(1), ObjectPropertyStep("name"))},
+				Detail:   `Unknown field "name".`,
+			}},
+		},
+		"allow-unknown-fields": {
+			body: `{"name": "foo", "more": true}`,
+			opts: []DecodeOption{AllowUnknownFields()},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodPost, "/widgets", strings.NewReader(tc.body))
+			var widget testDecodeWidget
+			result := DecodeBody(r, &widget, tc.opts...)
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestDecodeBodyMaxBytesReader(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodPost, "/widgets", strings.NewReader(`{"name": "foobar"}`))
+	r.Body = http.MaxBytesReader(httptest.NewRecorder(), r.Body, 5)
+	var widget testDecodeWidget
+	result := DecodeBody(r, &widget)
+	expected := Diagnostics{bodyTooLarge(5)}
+	if diff := cmp.Diff(expected, result); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+}
@@ -0,0 +1,105 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DeprecationMember is the extension member of a Diagnostic holding
+	// the time the thing it describes was deprecated, as an RFC 3339
+	// timestamp.
+	DeprecationMember = "deprecation"
+	// SunsetMember is the extension member of a Diagnostic holding the
+	// time the thing it describes will stop working, as an RFC 3339
+	// timestamp.
+	SunsetMember = "sunset"
+)
+
+// WithDeprecation returns a copy of d with deprecation and sunset recorded
+// in its Extensions, as DeprecationMember and SunsetMember. A zero time
+// leaves the corresponding member out. It's meant for Diagnostics with a
+// Code of CodeDeprecated, which SetDeprecationHeaders turns into headers.
+func (d Diagnostic) WithDeprecation(deprecation, sunset time.Time) Diagnostic {
+	for member, t := range map[string]time.Time{DeprecationMember: deprecation, SunsetMember: sunset} {
+		if t.IsZero() {
+			continue
+		}
+		d = d.withExtension(member, json.RawMessage(strconv.Quote(t.UTC().Format(time.RFC3339))))
+	}
+	return d
+}
+
+// Deprecation returns the times recorded in d's Extensions by
+// WithDeprecation. Members that aren't set are returned as zero times. An
+// error is returned if either member isn't an RFC 3339 timestamp.
+func (d Diagnostic) Deprecation() (deprecation, sunset time.Time, err error) {
+	deprecation, err = d.extensionTime(DeprecationMember)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	sunset, err = d.extensionTime(SunsetMember)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return deprecation, sunset, nil
+}
+
+func (d Diagnostic) extensionTime(member string) (time.Time, error) {
+	value, ok := d.Extensions[member]
+	if !ok {
+		return time.Time{}, nil
+	}
+	var result time.Time
+	err := json.Unmarshal(value, &result)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error parsing %s: %w", member, err)
+	}
+	return result, nil
+}
+
+// SetDeprecationHeaders sets the Deprecation (RFC 9745) and Sunset (RFC
+// 8594) headers in h for the Diagnostics in diags with a Code of
+// CodeDeprecated, using the times recorded by WithDeprecation. The earliest
+// deprecation and sunset times are used when there's more than one. The
+// DocURL of each of those Diagnostics is added as a Link header with a
+// relation type of "deprecation", pointing clients to migration
+// documentation.
+//
+// WriteHTTP calls SetDeprecationHeaders automatically, so the headers
+// always match the body; handlers writing their own successful responses
+// should call it themselves. Deprecated Diagnostics without times set only
+// contribute Link headers.
+func SetDeprecationHeaders(h http.Header, diags Diagnostics) error {
+	var deprecation, sunset time.Time
+	links := map[string]bool{}
+	for _, diag := range diags {
+		if diag.Code != CodeDeprecated {
+			continue
+		}
+		diagDeprecation, diagSunset, err := diag.Deprecation()
+		if err != nil {
+			return err
+		}
+		if !diagDeprecation.IsZero() && (deprecation.IsZero() || diagDeprecation.Before(deprecation)) {
+			deprecation = diagDeprecation
+		}
+		if !diagSunset.IsZero() && (sunset.IsZero() || diagSunset.Before(sunset)) {
+			sunset = diagSunset
+		}
+		if diag.DocURL != "" && !links[diag.DocURL] {
+			links[diag.DocURL] = true
+			h.Add("Link", "<"+diag.DocURL+`>; rel="deprecation"`)
+		}
+	}
+	if !deprecation.IsZero() {
+		h.Set("Deprecation", "@"+strconv.FormatInt(deprecation.Unix(), 10))
+	}
+	if !sunset.IsZero() {
+		h.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package apidiags
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiagnosticDeprecation(t *testing.T) {
+	t.Parallel()
+
+	deprecation := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2026, time.July, 1, 12, 30, 0, 0, time.FixedZone("EST", -5*60*60))
+
+	original := Diagnostic{Severity: DiagnosticWarning, Code: CodeDeprecated}
+	diag := original.WithDeprecation(deprecation, sunset)
+	if original.Extensions != nil {
+		t.Errorf("WithDeprecation modified the original Diagnostic: %v", original.Extensions)
+	}
+	encoded, err := diag.MarshalJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := `{"severity":"warning","code":"deprecated","deprecation":"2026-01-01T00:00:00Z","sunset":"2026-07-01T17:30:00Z"}`
+	if string(encoded) != expected {
+		t.Errorf("expected %s, got %s", expected, encoded)
+	}
+	var decoded Diagnostic
+	err = decoded.UnmarshalJSON(encoded)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resultDeprecation, resultSunset, err := decoded.Deprecation()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !resultDeprecation.Equal(deprecation) {
+		t.Errorf("expected deprecation %s, got %s", deprecation, resultDeprecation)
+	}
+	if !resultSunset.Equal(sunset) {
+		t.Errorf("expected sunset %s, got %s", sunset, resultSunset)
+	}
+}
+
+func TestSetDeprecationHeaders(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags    Diagnostics
+		expected http.Header
+	}
+
+	early := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	late := time.Date(2026, time.July, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := map[string]testCase{
+		"none": {
+			diags:    Diagnostics{{Severity: DiagnosticError, Code: CodeNotFound}},
+			expected: http.Header{},
+		},
+		"without-times": {
+			diags: Diagnostics{
+				{Severity: DiagnosticWarning, Code: CodeDeprecated, DocURL: "https://example.com/docs/v2"},
+			},
+			expected: http.Header{
+				"Link": []string{`<https://example.com/docs/v2>; rel="deprecation"`},
+			},
+		},
+		"earliest-wins": {
+			diags: Diagnostics{
+				Diagnostic{Severity: DiagnosticWarning, Code: CodeDeprecated, DocURL: "https://example.com/docs/v2"}.WithDeprecation(late, late),
+				Diagnostic{Severity: DiagnosticWarning, Code: CodeDeprecated, DocURL: "https://example.com/docs/v2"}.WithDeprecation(early, time.Time{}),
+				Diagnostic{Severity: DiagnosticWarning, Code: CodeDeprecated, DocURL: "https://example.com/docs/mode"}.WithDeprecation(late, late),
+			},
+			expected: http.Header{
+				"Deprecation": []string{"@1767225600"},
+				"Sunset":      []string{"Wed, 01 Jul 2026 00:00:00 GMT"},
+				"Link": []string{
+					`<https://example.com/docs/v2>; rel="deprecation"`,
+					`<https://example.com/docs/mode>; rel="deprecation"`,
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result := http.Header{}
+			err := SetDeprecationHeaders(result, tc.diags)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestSetDeprecationHeadersInvalid(t *testing.T) {
+	t.Parallel()
+
+	var diag Diagnostic
+	err := diag.UnmarshalJSON([]byte(`{"severity":"warning","code":"deprecated","sunset":"soon"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err = SetDeprecationHeaders(http.Header{}, Diagnostics{diag})
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+}
+
+func TestWriteHTTPDeprecationHeaders(t *testing.T) {
+	t.Parallel()
+
+	sunset := time.Date(2026, time.July, 1, 0, 0, 0, 0, time.UTC)
+	diags := Diagnostics{
+		Diagnostic{Severity: DiagnosticWarning, Code: CodeDeprecated}.WithDeprecation(time.Time{}, sunset),
+	}
+	w := httptest.NewRecorder()
+	err := WriteHTTP(w, diags)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if header := w.Header().Get("Sunset"); header != "Wed, 01 Jul 2026 00:00:00 GMT" {
+		t.Errorf("unexpected Sunset header %q", header)
+	}
+}
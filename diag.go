@@ -3,7 +3,13 @@ package apidiags
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 // Severity indicates whether the diagnostic is advisory or fatal.
@@ -58,129 +64,589 @@ const (
 // It can be used to inform callers about errors and to warn them of future
 // deprecations.
 type Diagnostic struct {
-	Severity Severity `json:"severity"`
-	Code     Code     `json:"code"`
-	Paths    []Steps  `json:"path,omitempty"`
+	Severity Severity `json:"severity" xml:"severity,attr" yaml:"severity"`
+	Code     Code     `json:"code" xml:"code,attr" yaml:"code"`
+	Paths    []Steps  `json:"path,omitempty" xml:"path" yaml:"path,omitempty"`
+
+	// Summary is an optional short, human-readable description of the
+	// Diagnostic. Callers should rely on the Code, not the Summary, to
+	// determine what the Diagnostic means.
+	Summary string `json:"summary,omitempty" xml:"summary,omitempty" yaml:"summary,omitempty"`
+
+	// Detail is an optional longer, human-readable explanation of the
+	// Diagnostic, ideally including how to resolve it.
+	Detail string `json:"detail,omitempty" xml:"detail,omitempty" yaml:"detail,omitempty"`
+
+	// DocURL is an optional link to documentation about the Diagnostic,
+	// usually about its Code.
+	DocURL string `json:"doc_url,omitempty" xml:"doc_url,omitempty" yaml:"doc_url,omitempty"`
+
+	// Extensions holds any members of the Diagnostic's JSON encoding this
+	// package doesn't recognize, like fields added by newer versions of a
+	// server, so they survive the Diagnostic being decoded and encoded
+	// again. Members with the same name as a field of the Diagnostic are
+	// ignored when encoding.
+	Extensions map[string]json.RawMessage `json:"-" xml:"-" yaml:"-"`
+
+	// Debug holds optional information about the Diagnostic meant only
+	// for the service's developers, like internal error messages and stack
+	// traces. It's never encoded, unless WithDebugInfo is used when
+	// writing the Diagnostic in a response.
+	Debug *DebugInfo `json:"-" xml:"-" yaml:"-"`
+
+	// Template holds an optional MessageTemplate the Diagnostic's Summary
+	// and Detail are rendered from when it's written, rather than when
+	// it's built. See RenderMessage.
+	Template *MessageTemplate `json:"-" xml:"-" yaml:"-"`
+}
+
+// diagnosticJSON has the same fields as Diagnostic, without its MarshalJSON,
+// UnmarshalJSON, and MarshalYAML methods.
+type diagnosticJSON Diagnostic
+
+// diagnosticJSONMembers are the names of the members of a Diagnostic's JSON
+// encoding that are decoded into its fields, rather than its Extensions.
+var diagnosticJSONMembers = map[string]bool{
+	"severity": true,
+	"code":     true,
+	"path":     true,
+	"summary":  true,
+	"detail":   true,
+	"doc_url":  true,
+}
+
+// MarshalJSON turns a Diagnostic into a JSON-encoded set of bytes, including
+// its Extensions, sorted by name, after its fields. If it has a Template, its
+// Summary and Detail are rendered from it, without a Catalog. The DebugMember
+// extension member is left out unless it was set by WithDebugInfo(true).
+func (d Diagnostic) MarshalJSON() ([]byte, error) {
+	d = d.RenderMessage(nil)
+	encoded, err := json.Marshal(diagnosticJSON(d))
+	if err != nil || len(d.Extensions) < 1 {
+		return encoded, err
+	}
+	keys := make([]string, 0, len(d.Extensions))
+	for key := range d.Extensions {
+		if !diagnosticJSONMembers[key] && (key != DebugMember || d.encodesDebugMember()) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	buf := bytes.NewBuffer(encoded[:len(encoded)-1])
+	for _, key := range keys {
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(",")
+		buf.Write(encodedKey)
+		buf.WriteString(":")
+		err = json.Compact(buf, d.Extensions[key])
+		if err != nil {
+			return nil, fmt.Errorf("error encoding extension %q: %w", key, err)
+		}
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON turns a JSON-encoded set of bytes into a Diagnostic. Members
+// this package doesn't recognize are kept in Extensions, rather than being
+// discarded.
+func (d *Diagnostic) UnmarshalJSON(in []byte) error {
+	var result diagnosticJSON
+	err := json.Unmarshal(in, &result)
+	if err != nil {
+		return err
+	}
+	var members map[string]json.RawMessage
+	err = json.Unmarshal(in, &members)
+	if err != nil {
+		return err
+	}
+	for key, value := range members {
+		if diagnosticJSONMembers[key] {
+			continue
+		}
+		if result.Extensions == nil {
+			result.Extensions = map[string]json.RawMessage{}
+		}
+		result.Extensions[key] = value
+	}
+	*d = Diagnostic(result)
+	return nil
+}
+
+// withExtension returns a copy of d with the extension member set to value,
+// without modifying d's Extensions.
+func (d Diagnostic) withExtension(member string, value json.RawMessage) Diagnostic {
+	extensions := make(map[string]json.RawMessage, len(d.Extensions)+1)
+	for key, existing := range d.Extensions {
+		extensions[key] = existing
+	}
+	extensions[member] = value
+	d.Extensions = extensions
+	return d
+}
+
+// Message returns a human-readable message for the Diagnostic: its Summary if
+// it has one, or its Code if it doesn't. A Diagnostic's Template is rendered
+// first, if it has one.
+func (d Diagnostic) Message() string {
+	d = d.RenderMessage(nil)
+	if d.Summary != "" {
+		return d.Summary
+	}
+	return string(d.Code)
+}
+
+// PrependPath returns a copy of the Diagnostic with prefix prepended to each
+// of its Paths. It's useful for turning a Diagnostic reported relative to a
+// sub-object into one relative to the whole request. If the Diagnostic has no
+// Paths, the copy has a single Path, prefix, as the Diagnostic is assumed to
+// be about the sub-object as a whole.
+func (d Diagnostic) PrependPath(prefix Steps) Diagnostic {
+	if len(d.Paths) == 0 {
+		d.Paths = []Steps{append(Steps{}, prefix...)}
+		return d
+	}
+	paths := make([]Steps, 0, len(d.Paths))
+	for _, path := range d.Paths {
+		joined := make(Steps, 0, len(prefix)+len(path))
+		joined = append(joined, prefix...)
+		joined = append(joined, path...)
+		paths = append(paths, joined)
+	}
+	d.Paths = paths
+	return d
+}
+
+// AppendPath returns a copy of the Diagnostic with suffix appended to each of
+// its Paths. A Diagnostic with no Paths is returned unchanged.
+func (d Diagnostic) AppendPath(suffix Steps) Diagnostic {
+	if len(d.Paths) == 0 {
+		return d
+	}
+	paths := make([]Steps, 0, len(d.Paths))
+	for _, path := range d.Paths {
+		joined := make(Steps, 0, len(path)+len(suffix))
+		joined = append(joined, path...)
+		joined = append(joined, suffix...)
+		paths = append(paths, joined)
+	}
+	d.Paths = paths
+	return d
+}
+
+// Diagnostics are a collection of Diagnostics, usually all the Diagnostics
+// being returned with a single response.
+type Diagnostics []Diagnostic
+
+// PrependPath returns a copy of the Diagnostics with Diagnostic.PrependPath
+// called on each Diagnostic.
+func (diags Diagnostics) PrependPath(prefix Steps) Diagnostics {
+	if diags == nil {
+		return nil
+	}
+	results := make(Diagnostics, 0, len(diags))
+	for _, diag := range diags {
+		results = append(results, diag.PrependPath(prefix))
+	}
+	return results
+}
+
+// AppendPath returns a copy of the Diagnostics with Diagnostic.AppendPath
+// called on each Diagnostic.
+func (diags Diagnostics) AppendPath(suffix Steps) Diagnostics {
+	if diags == nil {
+		return nil
+	}
+	results := make(Diagnostics, 0, len(diags))
+	for _, diag := range diags {
+		results = append(results, diag.AppendPath(suffix))
+	}
+	return results
+}
+
+// HasErrors returns true if any of the Diagnostics have a Severity of
+// DiagnosticError.
+func (diags Diagnostics) HasErrors() bool {
+	for _, diag := range diags {
+		if diag.Severity == DiagnosticError {
+			return true
+		}
+	}
+	return false
 }
 
 // Steps are a collection of transforms or accesses that point to
 // ever-more-specific parts of a request.
+//
+// AddStep and AddSteps never modify the Steps they're called on: the Steps
+// they return have their own copy of them. A path can be shared and
+// extended more than once without one extension overwriting another:
+//
+//	items := BodyPath().AddStep(ObjectPropertyStep("items"))
+//	first := items.AddStep(ArrayIndexStep(0))
+//	second := items.AddStep(ArrayIndexStep(1)) // first still ends in ArrayIndexStep(0)
+//
+// Steps are otherwise like any other slice: PathOf uses the slice it's
+// given as is, and setting a Step by index changes it for everything
+// sharing the slice.
 type Steps []Step
 
-// AddStep appends a Step to the Steps, pointing to another level of
-// specificity for the request.
+// Equal returns true if s and other point to the same part of a request.
+// Header names are compared case-insensitively; every other Step must match
+// exactly.
+func (s Steps) Equal(other Steps) bool {
+	if len(s) != len(other) {
+		return false
+	}
+	for pos := range s {
+		if !stepsEqual(s[pos], other[pos]) {
+			return false
+		}
+	}
+	return true
+}
+
+func stepsEqual(a, b Step) bool {
+	if header, ok := a.(HeaderStep); ok {
+		otherHeader, ok := b.(HeaderStep)
+		return ok && header.Equal(otherHeader)
+	}
+	return a == b
+}
+
+// AddStep returns a copy of the Steps with step added to the end, pointing
+// to another level of specificity for the request. The Steps it's called on
+// are never modified.
 func (s Steps) AddStep(step Step) Steps {
-	s = append(s, step)
-	return s
+	results := make(Steps, len(s), len(s)+1)
+	copy(results, s)
+	return append(results, step)
+}
+
+// AddSteps returns a copy of the Steps with one or more Steps added to the
+// end, pointing to further levels of specificity for the request. It's
+// equivalent to calling AddStep for each Step, but only copies the Steps
+// once.
+func (s Steps) AddSteps(steps ...Step) Steps {
+	results := make(Steps, len(s), len(s)+len(steps))
+	copy(results, s)
+	return append(results, steps...)
 }
 
-// UnmarshalJSON turns a JSON-encoded set of bytes into Steps.
+// UnmarshalJSON turns a JSON-encoded set of bytes into Steps. It uses a
+// StepsDecoder from a shared pool, so decoding doesn't allocate much more
+// than the Steps themselves.
 func (steps *Steps) UnmarshalJSON(in []byte) error {
-	var genSteps []genericStep
-	dec := json.NewDecoder(bytes.NewBuffer(in))
-	dec.UseNumber()
-	err := dec.Decode(&genSteps)
+	dec := getStepsDecoder()
+	results, err := dec.DecodeSteps(in)
+	putStepsDecoder(dec)
 	if err != nil {
 		return err
 	}
-	results := make(Steps, 0, len(genSteps))
-	for pos, step := range genSteps {
-		switch step.Kind {
-		case "body":
-			results = results.AddStep(BodyStep{})
-		case "header":
-			if step.Value == nil {
-				return fmt.Errorf("error parsing step %d: no value", pos)
-			}
-			header, ok := (*step.Value).(string)
-			if !ok {
-				return fmt.Errorf("error parsing step %d: wanted string, got %T", pos, *step.Value)
-			}
-			results = results.AddStep(HeaderStep(header))
-		case "url_param":
-			if step.Value == nil {
-				return fmt.Errorf("error parsing step %d: no value", pos)
-			}
-			param, ok := (*step.Value).(string)
-			if !ok {
-				return fmt.Errorf("error parsing step %d: wanted string, got %T", pos, *step.Value)
-			}
-			results = results.AddStep(URLParamStep(param))
-		case "array_index":
-			if step.Value == nil {
-				return fmt.Errorf("error parsing step %d: no value", pos)
-			}
-			index, ok := (*step.Value).(json.Number)
-			if !ok {
-				return fmt.Errorf("error parsing step %d: wanted json.Number, got %T", pos, *step.Value)
-			}
-			idx, err := index.Int64()
-			if err != nil {
-				return fmt.Errorf("error parsing step %d: %w", pos, err)
-			}
-			results = results.AddStep(ArrayIndexStep(idx))
-		case "object_property":
-			if step.Value == nil {
-				return fmt.Errorf("error parsing step %d: no value", pos)
-			}
-			property, ok := (*step.Value).(string)
-			if !ok {
-				return fmt.Errorf("error parsing step %d: wanted string, got %T", pos, *step.Value)
-			}
-			results = results.AddStep(ObjectPropertyStep(property))
-		case "string_index":
-			if step.Value == nil {
-				return fmt.Errorf("error parsing step %d: no value", pos)
-			}
-			index, ok := (*step.Value).(json.Number)
-			if !ok {
-				return fmt.Errorf("error parsing step %d: wanted json.Number, got %T", pos, *step.Value)
-			}
-			idx, err := index.Int64()
-			if err != nil {
-				return fmt.Errorf("error parsing step %d: %w", pos, err)
-			}
-			results = results.AddStep(StringIndexStep(idx))
-		default:
-			return fmt.Errorf("error parsing step %d: unexpected step kind %q with value type %T", pos, step.Kind, step.Value)
-		}
-	}
 	*steps = results
 	return nil
 }
 
 // MarshalJSON turns Steps into a JSON-encoded set of bytes.
 func (steps Steps) MarshalJSON() ([]byte, error) {
-	genSteps := make([]genericStep, 0, len(steps))
+	// most steps are a kind, a short value, and the punctuation around
+	// them, so this is usually the only allocation
+	size := 2
+	for _, step := range steps {
+		size += 40
+		switch value := step.(type) {
+		case HeaderStep:
+			size += len(value)
+		case URLParamStep:
+			size += len(value)
+		case ObjectPropertyStep:
+			size += len(value)
+		}
+	}
+	return steps.AppendJSON(make([]byte, 0, size))
+}
+
+// AppendJSON appends the JSON encoding of the Steps, as returned by
+// MarshalJSON, to dst, returning the extended buffer. It doesn't allocate if
+// dst has enough capacity, so encoders can reuse their buffers.
+func (steps Steps) AppendJSON(dst []byte) ([]byte, error) {
+	dst = append(dst, '[')
 	for pos, step := range steps {
+		if pos > 0 {
+			dst = append(dst, ',')
+		}
 		switch value := step.(type) {
 		case BodyStep:
-			genSteps = append(genSteps, genericStep{Kind: "body"})
+			dst = append(dst, `{"kind":"body"}`...)
 		case HeaderStep:
-			val := any(string(value))
-			genSteps = append(genSteps, genericStep{Kind: "header", Value: &val})
+			dst = append(dst, `{"kind":"header","value":`...)
+			dst = appendJSONString(dst, string(value))
+			dst = append(dst, '}')
 		case URLParamStep:
-			val := any(string(value))
-			genSteps = append(genSteps, genericStep{Kind: "url_param", Value: &val})
+			dst = append(dst, `{"kind":"url_param","value":`...)
+			dst = appendJSONString(dst, string(value))
+			dst = append(dst, '}')
 		case ArrayIndexStep:
-			val := any(int64(value))
-			genSteps = append(genSteps, genericStep{Kind: "array_index", Value: &val})
+			dst = append(dst, `{"kind":"array_index","value":`...)
+			dst = strconv.AppendInt(dst, int64(value), 10)
+			dst = append(dst, '}')
 		case ObjectPropertyStep:
-			val := any(string(value))
-			genSteps = append(genSteps, genericStep{Kind: "object_property", Value: &val})
+			dst = append(dst, `{"kind":"object_property","value":`...)
+			dst = appendJSONString(dst, string(value))
+			dst = append(dst, '}')
 		case StringIndexStep:
-			val := any(int64(value))
-			genSteps = append(genSteps, genericStep{Kind: "string_index", Value: &val})
+			dst = append(dst, `{"kind":"string_index","value":`...)
+			dst = strconv.AppendInt(dst, int64(value), 10)
+			dst = append(dst, '}')
+		case RuneIndexStep:
+			dst = append(dst, `{"kind":"rune_index","value":`...)
+			dst = strconv.AppendInt(dst, int64(value), 10)
+			dst = append(dst, '}')
 		default:
 			return nil, fmt.Errorf("unknown step type %T for step %d", step, pos)
 		}
 	}
-	return json.Marshal(genSteps)
+	return append(dst, ']'), nil
 }
 
+// appendJSONString appends s to dst as a JSON string, escaped the way
+// encoding/json escapes strings by default: with <, >, and & escaped so the
+// result is safe to embed in HTML, invalid UTF-8 replaced with U+FFFD, and
+// U+2028 and U+2029 escaped for JavaScript.
+func appendJSONString(dst []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	dst = append(dst, '"')
+	start := 0
+	for pos := 0; pos < len(s); {
+		if b := s[pos]; b < utf8.RuneSelf {
+			if b >= ' ' && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				pos++
+				continue
+			}
+			dst = append(dst, s[start:pos]...)
+			switch b {
+			case '"', '\\':
+				dst = append(dst, '\\', b)
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xf])
+			}
+			pos++
+			start = pos
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[pos:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			dst = append(dst, s[start:pos]...)
+			dst = append(dst, string(utf8.RuneError)...)
+		case r == '\u2028' || r == '\u2029':
+			dst = append(dst, s[start:pos]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hex[r&0xf])
+		default:
+			pos += size
+			continue
+		}
+		pos += size
+		start = pos
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
+
+var errUnexpectedStepKind = errors.New("unexpected step kind")
+
+// genericStep is the kind and value scheme Steps are encoded with in JSON,
+// XML, YAML, and the notation described by Steps.String.
 type genericStep struct {
-	Kind  string `json:"kind,omitempty"`
-	Value *any   `json:"value,omitempty"`
+	Kind  string
+	Value stepValue
+}
+
+// stepValueKind is the type of a stepValue.
+type stepValueKind uint8
+
+const (
+	valueNone stepValueKind = iota
+	valueString
+	valueInt
+	valueNumber
+	valueOther
+)
+
+// stepValue is the value of a genericStep, held without boxing it: a
+// string, an integer from a Step, or the text of a number from a decoder,
+// which is only parsed once a Step needs it. Values of any other type,
+// which no Step has, are only kept so errors can say what they were.
+type stepValue struct {
+	kind  stepValueKind
+	str   string
+	num   int64
+	other any
+}
+
+func stringStepValue(s string) stepValue {
+	return stepValue{kind: valueString, str: s}
+}
+
+func intStepValue(n int64) stepValue {
+	return stepValue{kind: valueInt, num: n}
+}
+
+func numberStepValue(text string) stepValue {
+	return stepValue{kind: valueNumber, str: text}
+}
+
+func otherStepValue(v any) stepValue {
+	if v == nil {
+		return stepValue{}
+	}
+	return stepValue{kind: valueOther, other: v}
+}
+
+// boxed returns the value as a string or an int64, or nil if there is no
+// value, for encoders that need an interface.
+func (v stepValue) boxed() any {
+	switch v.kind {
+	case valueNone:
+		return nil
+	case valueString:
+		return v.str
+	case valueInt:
+		return v.num
+	case valueNumber:
+		return json.Number(v.str)
+	}
+	return v.other
+}
+
+// String returns the value as it would be written by fmt.Sprint, or an
+// empty string if there is no value.
+func (v stepValue) String() string {
+	switch v.kind {
+	case valueNone:
+		return ""
+	case valueString, valueNumber:
+		return v.str
+	case valueInt:
+		return strconv.FormatInt(v.num, 10)
+	}
+	return fmt.Sprint(v.other)
+}
+
+// typeName returns the name of the Go type of the value, as encoding/json
+// would decode it, for errors.
+func (v stepValue) typeName() string {
+	switch v.kind {
+	case valueNone:
+		return "<nil>"
+	case valueString:
+		return "string"
+	case valueInt:
+		return "int64"
+	case valueNumber:
+		return "json.Number"
+	}
+	return fmt.Sprintf("%T", v.other)
+}
+
+// toStep converts a genericStep into the Step it describes.
+func (step genericStep) toStep() (Step, error) {
+	switch step.Kind {
+	case "body":
+		return BodyStep{}, nil
+	case "header":
+		header, err := step.stringValue()
+		if err != nil {
+			return nil, err
+		}
+		return HeaderStep(header), nil
+	case "url_param":
+		param, err := step.stringValue()
+		if err != nil {
+			return nil, err
+		}
+		return URLParamStep(param), nil
+	case "array_index":
+		idx, err := step.intValue()
+		if err != nil {
+			return nil, err
+		}
+		return ArrayIndexStep(idx), nil
+	case "object_property":
+		property, err := step.stringValue()
+		if err != nil {
+			return nil, err
+		}
+		return ObjectPropertyStep(property), nil
+	case "string_index":
+		idx, err := step.intValue()
+		if err != nil {
+			return nil, err
+		}
+		return StringIndexStep(idx), nil
+	case "rune_index":
+		idx, err := step.intValue()
+		if err != nil {
+			return nil, err
+		}
+		return RuneIndexStep(idx), nil
+	default:
+		return nil, fmt.Errorf("%w %q with value type %s", errUnexpectedStepKind, step.Kind, step.Value.typeName())
+	}
+}
+
+func (step genericStep) stringValue() (string, error) {
+	switch step.Value.kind {
+	case valueNone:
+		return "", errors.New("no value")
+	case valueString:
+		return step.Value.str, nil
+	}
+	return "", fmt.Errorf("wanted string, got %s", step.Value.typeName())
+}
+
+func (step genericStep) intValue() (int64, error) {
+	switch step.Value.kind {
+	case valueNone:
+		return 0, errors.New("no value")
+	case valueInt:
+		return step.Value.num, nil
+	case valueNumber:
+		return strconv.ParseInt(step.Value.str, 10, 64)
+	}
+	return 0, fmt.Errorf("wanted json.Number, got %s", step.Value.typeName())
+}
+
+// toGenericStep converts a Step into its genericStep representation.
+func toGenericStep(step Step) (genericStep, error) {
+	switch value := step.(type) {
+	case BodyStep:
+		return genericStep{Kind: "body"}, nil
+	case HeaderStep:
+		return genericStep{Kind: "header", Value: stringStepValue(string(value))}, nil
+	case URLParamStep:
+		return genericStep{Kind: "url_param", Value: stringStepValue(string(value))}, nil
+	case ArrayIndexStep:
+		return genericStep{Kind: "array_index", Value: intStepValue(int64(value))}, nil
+	case ObjectPropertyStep:
+		return genericStep{Kind: "object_property", Value: stringStepValue(string(value))}, nil
+	case StringIndexStep:
+		return genericStep{Kind: "string_index", Value: intStepValue(int64(value))}, nil
+	case RuneIndexStep:
+		return genericStep{Kind: "rune_index", Value: intStepValue(int64(value))}, nil
+	default:
+		return genericStep{}, fmt.Errorf("unknown step type %T", step)
+	}
 }
 
 // Step is a single transform or access that points to a more specific part of
@@ -194,11 +660,24 @@ type BodyStep struct{}
 
 func (BodyStep) step() {}
 
-// HeaderStep is a Step that specifies a single header on a request.
+// HeaderStep is a Step that specifies a single header on a request. Header
+// names are case-insensitive, so HeaderSteps should be compared using
+// Steps.Equal or HeaderStep.Equal rather than ==.
 type HeaderStep string
 
 func (HeaderStep) step() {}
 
+// Canonical returns the HeaderStep with its header name in canonical form,
+// as returned by textproto.CanonicalMIMEHeaderKey.
+func (h HeaderStep) Canonical() HeaderStep {
+	return HeaderStep(textproto.CanonicalMIMEHeaderKey(string(h)))
+}
+
+// Equal returns true if h and other refer to the same header, ignoring case.
+func (h HeaderStep) Equal(other HeaderStep) bool {
+	return strings.EqualFold(string(h), string(other))
+}
+
 // URLParamStep is a Step that specifies a single URL parameter on a request.
 type URLParamStep string
 
@@ -215,11 +694,64 @@ type ObjectPropertyStep string
 
 func (ObjectPropertyStep) step() {}
 
-// StringIndexStep is a Step that specifies a single character within a string.
+// StringIndexStep is a Step that specifies a single character within a
+// string, as an offset in bytes into the string's UTF-8 encoding. This matches
+// how Go indexes strings, but clients that work in other units may prefer a
+// RuneIndexStep.
 type StringIndexStep int64
 
 func (StringIndexStep) step() {}
 
+// RuneIndexStep is a Step that specifies a single character within a string,
+// as an offset in Unicode code points. This is unaffected by how the string
+// is encoded, so it's usually the better choice for strings that may contain
+// non-ASCII characters.
+type RuneIndexStep int64
+
+func (RuneIndexStep) step() {}
+
+// RuneIndexAt returns a RuneIndexStep pointing to the character that starts at
+// byte offset pos in s. If pos falls in the middle of a character, the
+// RuneIndexStep points to that character. If pos is past the end of s, the
+// RuneIndexStep points just past the last character of s.
+func RuneIndexAt(s string, pos int) RuneIndexStep {
+	if pos > len(s) {
+		pos = len(s)
+	}
+	if pos < 0 {
+		pos = 0
+	}
+	for pos > 0 && pos < len(s) && !utf8.RuneStart(s[pos]) {
+		pos--
+	}
+	return RuneIndexStep(utf8.RuneCountInString(s[:pos]))
+}
+
+// ByteOffset returns the byte offset in s of the character the RuneIndexStep
+// points to, suitable for indexing into s. If the RuneIndexStep points past
+// the end of s, false is returned.
+func (idx RuneIndexStep) ByteOffset(s string) (int, bool) {
+	if idx < 0 {
+		return 0, false
+	}
+	var count int64
+	for pos := range s {
+		if count == int64(idx) {
+			return pos, true
+		}
+		count++
+	}
+	return len(s), false
+}
+
+// PathOf returns Steps made up of the passed Steps, in order, like
+// PathOf(BodyStep{}, ObjectPropertyStep("items"), ArrayIndexStep(3)). When
+// called with an existing slice using `...`, the Steps share that slice's
+// backing array.
+func PathOf(steps ...Step) Steps {
+	return Steps(steps)
+}
+
 // BodyPath returns Steps that point to the body of the request.
 func BodyPath() Steps {
 	return Steps{BodyStep{}}
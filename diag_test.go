@@ -2,6 +2,8 @@ package apidiags
 
 import (
 	"encoding/json"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -41,6 +43,24 @@ func TestMarshalJSON(t *testing.T) {
 				AddStep(StringIndexStep(0)),
 			expected: `[{"kind": "body"}, {"kind": "object_property", "value": "foo"}, {"kind": "array_index", "value": 1}, {"kind": "string_index", "value": 0}]`,
 		},
+		"body-prop-runeIndex": {
+			steps: BodyPath().
+				AddStep(ObjectPropertyStep("foo")).
+				AddStep(RuneIndexStep(2)),
+			expected: `[{"kind": "body"}, {"kind": "object_property", "value": "foo"}, {"kind": "rune_index", "value": 2}]`,
+		},
+		"body-addSteps": {
+			steps: BodyPath().AddSteps(
+				ObjectPropertyStep("foo"),
+				ArrayIndexStep(1),
+				StringIndexStep(0),
+			),
+			expected: `[{"kind": "body"}, {"kind": "object_property", "value": "foo"}, {"kind": "array_index", "value": 1}, {"kind": "string_index", "value": 0}]`,
+		},
+		"pathOf": {
+			steps:    PathOf(BodyStep{}, ObjectPropertyStep("foo"), ArrayIndexStep(1)),
+			expected: `[{"kind": "body"}, {"kind": "object_property", "value": "foo"}, {"kind": "array_index", "value": 1}]`,
+		},
 		"header-step": {
 			steps:    HeaderPath("foo"),
 			expected: `[{"kind": "header", "value": "foo"}]`,
@@ -108,6 +128,12 @@ func TestUnmarshalJSON(t *testing.T) {
 				AddStep(ArrayIndexStep(1)).
 				AddStep(StringIndexStep(0)),
 		},
+		"body-prop-runeIndex": {
+			input: `[{"kind": "body"}, {"kind": "object_property", "value": "foo"}, {"kind": "rune_index", "value": 2}]`,
+			expected: BodyPath().
+				AddStep(ObjectPropertyStep("foo")).
+				AddStep(RuneIndexStep(2)),
+		},
 		"header-step": {
 			input:    `[{"kind": "header", "value": "foo"}]`,
 			expected: HeaderPath("foo"),
@@ -135,3 +161,663 @@ func TestUnmarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestRuneIndexAt(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    string
+		pos      int
+		expected RuneIndexStep
+	}
+
+	cases := map[string]testCase{
+		"ascii": {
+			input:    "hello",
+			pos:      3,
+			expected: 3,
+		},
+		"multibyte": {
+			input:    "héllo",
+			pos:      3,
+			expected: 2,
+		},
+		"mid-character": {
+			input:    "héllo",
+			pos:      2,
+			expected: 1,
+		},
+		"emoji": {
+			input:    "🙂🙃x",
+			pos:      8,
+			expected: 2,
+		},
+		"past-end": {
+			input:    "héllo",
+			pos:      100,
+			expected: 5,
+		},
+		"negative": {
+			input:    "héllo",
+			pos:      -1,
+			expected: 0,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result := RuneIndexAt(tc.input, tc.pos)
+			if result != tc.expected {
+				t.Fatalf("expected %d, got %d", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestRuneIndexStepByteOffset(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    string
+		idx      RuneIndexStep
+		expected int
+		ok       bool
+	}
+
+	cases := map[string]testCase{
+		"ascii": {
+			input:    "hello",
+			idx:      3,
+			expected: 3,
+			ok:       true,
+		},
+		"multibyte": {
+			input:    "héllo",
+			idx:      2,
+			expected: 3,
+			ok:       true,
+		},
+		"emoji": {
+			input:    "🙂🙃x",
+			idx:      2,
+			expected: 8,
+			ok:       true,
+		},
+		"past-end": {
+			input:    "héllo",
+			idx:      5,
+			expected: 6,
+			ok:       false,
+		},
+		"negative": {
+			input:    "héllo",
+			idx:      -1,
+			expected: 0,
+			ok:       false,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result, ok := tc.idx.ByteOffset(tc.input)
+			if result != tc.expected || ok != tc.ok {
+				t.Fatalf("expected (%d, %v), got (%d, %v)", tc.expected, tc.ok, result, ok)
+			}
+		})
+	}
+}
+
+func TestStepsEqual(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		a, b     Steps
+		expected bool
+	}
+
+	cases := map[string]testCase{
+		"empty": {
+			expected: true,
+		},
+		"same-body-path": {
+			a:        BodyPath().AddStep(ObjectPropertyStep("foo")),
+			b:        BodyPath().AddStep(ObjectPropertyStep("foo")),
+			expected: true,
+		},
+		"different-property-case": {
+			a:        BodyPath().AddStep(ObjectPropertyStep("foo")),
+			b:        BodyPath().AddStep(ObjectPropertyStep("Foo")),
+			expected: false,
+		},
+		"different-header-case": {
+			a:        HeaderPath("content-type"),
+			b:        HeaderPath("Content-Type"),
+			expected: true,
+		},
+		"different-header": {
+			a:        HeaderPath("Content-Type"),
+			b:        HeaderPath("Content-Length"),
+			expected: false,
+		},
+		"header-vs-url-param": {
+			a:        HeaderPath("foo"),
+			b:        URLParamPath("foo"),
+			expected: false,
+		},
+		"different-lengths": {
+			a:        BodyPath(),
+			b:        BodyPath().AddStep(ArrayIndexStep(0)),
+			expected: false,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if result := tc.a.Equal(tc.b); result != tc.expected {
+				t.Fatalf("expected %v comparing %s to %s, got %v", tc.expected, tc.a, tc.b, result)
+			}
+			if result := tc.b.Equal(tc.a); result != tc.expected {
+				t.Fatalf("expected %v comparing %s to %s, got %v", tc.expected, tc.b, tc.a, result)
+			}
+		})
+	}
+}
+
+func TestHeaderStepCanonical(t *testing.T) {
+	t.Parallel()
+
+	cases := map[HeaderStep]HeaderStep{
+		"content-type":    "Content-Type",
+		"CONTENT-LENGTH":  "Content-Length",
+		"x-request-id":    "X-Request-Id",
+		"Already-Correct": "Already-Correct",
+	}
+
+	for input, expected := range cases {
+		input, expected := input, expected
+
+		t.Run(string(input), func(t *testing.T) {
+			t.Parallel()
+
+			if result := input.Canonical(); result != expected {
+				t.Fatalf("expected %q, got %q", expected, result)
+			}
+		})
+	}
+}
+
+func TestDiagnosticsPrependPath(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags    Diagnostics
+		prefix   Steps
+		expected Diagnostics
+	}
+
+	item := BodyPath().AddSteps(ObjectPropertyStep("items"), ArrayIndexStep(3))
+
+	cases := map[string]testCase{
+		"nil": {
+			prefix: item,
+		},
+		"with-paths": {
+			diags: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     CodeConflict,
+					Paths: []Steps{
+						{ObjectPropertyStep("start")},
+						{ObjectPropertyStep("end")},
+					},
+				},
+				{
+					Severity: DiagnosticWarning,
+					Code:     CodeDeprecated,
+					Paths:    []Steps{{ObjectPropertyStep("legacy")}},
+				},
+			},
+			prefix: item,
+			expected: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     CodeConflict,
+					Paths: []Steps{
+						item.AddStep(ObjectPropertyStep("start")),
+						item.AddStep(ObjectPropertyStep("end")),
+					},
+				},
+				{
+					Severity: DiagnosticWarning,
+					Code:     CodeDeprecated,
+					Paths:    []Steps{item.AddStep(ObjectPropertyStep("legacy"))},
+				},
+			},
+		},
+		"no-paths": {
+			diags:  Diagnostics{{Severity: DiagnosticError, Code: CodeMissing}},
+			prefix: item,
+			expected: Diagnostics{
+				{Severity: DiagnosticError, Code: CodeMissing, Paths: []Steps{item}},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result := tc.diags.PrependPath(tc.prefix)
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestDiagnosticsAppendPath(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags    Diagnostics
+		suffix   Steps
+		expected Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"nil": {
+			suffix: Steps{StringIndexStep(0)},
+		},
+		"with-paths": {
+			diags: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     CodeInvalidFormat,
+					Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("name"))},
+				},
+			},
+			suffix: Steps{StringIndexStep(0)},
+			expected: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     CodeInvalidFormat,
+					Paths:    []Steps{BodyPath().AddSteps(ObjectPropertyStep("name"), StringIndexStep(0))},
+				},
+			},
+		},
+		"no-paths": {
+			diags:    Diagnostics{{Severity: DiagnosticError, Code: CodeActOfGod}},
+			suffix:   Steps{StringIndexStep(0)},
+			expected: Diagnostics{{Severity: DiagnosticError, Code: CodeActOfGod}},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result := tc.diags.AppendPath(tc.suffix)
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestPrependPathDoesNotAlias(t *testing.T) {
+	t.Parallel()
+
+	prefix := make(Steps, 0, 10)
+	prefix = append(prefix, BodyStep{})
+	diag := Diagnostic{
+		Severity: DiagnosticError,
+		Code:     CodeMissing,
+		Paths:    []Steps{{ObjectPropertyStep("a")}, {ObjectPropertyStep("b")}},
+	}
+	result := diag.PrependPath(prefix)
+	if diff := cmp.Diff([]Steps{
+		BodyPath().AddStep(ObjectPropertyStep("a")),
+		BodyPath().AddStep(ObjectPropertyStep("b")),
+	}, result.Paths); diff != "" {
+		t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+	}
+	if diff := cmp.Diff([]Steps{{ObjectPropertyStep("a")}, {ObjectPropertyStep("b")}}, diag.Paths); diff != "" {
+		t.Fatalf("original Diagnostic was modified (-wanted, +got): %s", diff)
+	}
+}
+
+func TestDiagnosticExtensionsRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    string
+		expected Diagnostic
+		output   string
+	}
+
+	cases := map[string]testCase{
+		"no-extensions": {
+			input:    `{"severity": "error", "code": "missing"}`,
+			expected: Diagnostic{Severity: DiagnosticError, Code: CodeMissing},
+			output:   `{"severity":"error","code":"missing"}`,
+		},
+		"extensions": {
+			input: `{"trace_id": "abc123", "severity": "warning", "code": "deprecated", "retry": {"after": 30}, "summary": "Use v2."}`,
+			expected: Diagnostic{
+				Severity: DiagnosticWarning,
+				Code:     CodeDeprecated,
+				Summary:  "Use v2.",
+				Extensions: map[string]json.RawMessage{
+					"trace_id": json.RawMessage(`"abc123"`),
+					"retry":    json.RawMessage(`{"after": 30}`),
+				},
+			},
+			output: `{"severity":"warning","code":"deprecated","summary":"Use v2.","retry":{"after":30},"trace_id":"abc123"}`,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var diag Diagnostic
+			err := json.Unmarshal([]byte(tc.input), &diag)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(tc.expected, diag); diff != "" {
+				t.Errorf("unexpected result (-wanted, +got): %s", diff)
+			}
+			encoded, err := json.Marshal(diag)
+			if err != nil {
+				t.Fatalf("unexpected error encoding: %s", err)
+			}
+			if string(encoded) != tc.output {
+				t.Errorf("expected %s, got %s", tc.output, encoded)
+			}
+		})
+	}
+}
+
+func TestDiagnosticExtensionsCantOverrideFields(t *testing.T) {
+	t.Parallel()
+
+	diag := Diagnostic{
+		Severity: DiagnosticError,
+		Code:     CodeMissing,
+		Extensions: map[string]json.RawMessage{
+			"code": json.RawMessage(`"not_found"`),
+		},
+	}
+	encoded, err := json.Marshal(diag)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := `{"severity":"error","code":"missing"}`
+	if string(encoded) != expected {
+		t.Errorf("expected %s, got %s", expected, encoded)
+	}
+}
+
+func TestDiagnosticJSONMembersMatchFields(t *testing.T) {
+	t.Parallel()
+
+	fromFields := map[string]bool{}
+	typ := reflect.TypeOf(Diagnostic{})
+	for i := 0; i < typ.NumField(); i++ {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if name != "-" {
+			fromFields[name] = true
+		}
+	}
+	if diff := cmp.Diff(fromFields, diagnosticJSONMembers); diff != "" {
+		t.Errorf("diagnosticJSONMembers is out of date (-fields, +members): %s", diff)
+	}
+}
+
+func TestDiagnosticsHasErrors(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags    Diagnostics
+		expected bool
+	}
+
+	cases := map[string]testCase{
+		"nil": {},
+		"warnings": {
+			diags: Diagnostics{{Severity: DiagnosticWarning, Code: CodeDeprecated}},
+		},
+		"errors": {
+			diags: Diagnostics{
+				{Severity: DiagnosticWarning, Code: CodeDeprecated},
+				{Severity: DiagnosticError, Code: CodeMissing},
+			},
+			expected: true,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if result := tc.diags.HasErrors(); result != tc.expected {
+				t.Fatalf("expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestStepsAddStepDoesntAlias(t *testing.T) {
+	t.Parallel()
+
+	// room to grow, so appending in place would share the array
+	base := make(Steps, 0, 8)
+	base = append(base, BodyStep{}, ObjectPropertyStep("items"))
+
+	first := base.AddStep(ArrayIndexStep(0))
+	second := base.AddStep(ArrayIndexStep(1))
+	third := base.AddSteps(ArrayIndexStep(2), ObjectPropertyStep("name"))
+	nested := first.AddStep(ObjectPropertyStep("id"))
+	fourth := first.AddStep(ObjectPropertyStep("name"))
+
+	expected := map[string]Steps{
+		"base":   BodyPath().AddStep(ObjectPropertyStep("items")),
+		"first":  BodyPath().AddSteps(ObjectPropertyStep("items"), ArrayIndexStep(0)),
+		"second": BodyPath().AddSteps(ObjectPropertyStep("items"), ArrayIndexStep(1)),
+		"third":  BodyPath().AddSteps(ObjectPropertyStep("items"), ArrayIndexStep(2), ObjectPropertyStep("name")),
+		"nested": BodyPath().AddSteps(ObjectPropertyStep("items"), ArrayIndexStep(0), ObjectPropertyStep("id")),
+		"fourth": BodyPath().AddSteps(ObjectPropertyStep("items"), ArrayIndexStep(0), ObjectPropertyStep("name")),
+	}
+	result := map[string]Steps{"base": base, "first": first, "second": second, "third": third, "nested": nested, "fourth": fourth}
+	if diff := cmp.Diff(expected, result); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	if spare := base[len(base):cap(base)]; spare[0] != nil {
+		t.Errorf("expected AddStep not to write to the Steps' spare capacity, got %#v", spare[0])
+	}
+}
+
+// marshalGenericSteps encodes steps the way Steps.MarshalJSON used to,
+// through genericSteps and encoding/json, to compare against.
+func marshalGenericSteps(steps Steps) ([]byte, error) {
+	type encodedStep struct {
+		Kind  string `json:"kind,omitempty"`
+		Value any    `json:"value,omitempty"`
+	}
+	encoded := make([]encodedStep, 0, len(steps))
+	for _, step := range steps {
+		genStep, err := toGenericStep(step)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, encodedStep{Kind: genStep.Kind, Value: genStep.Value.boxed()})
+	}
+	return json.Marshal(encoded)
+}
+
+func TestStepsMarshalJSONMatchesEncodingJSON(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]Steps{
+		"empty": {},
+		"nil":   nil,
+		"every-kind": PathOf(BodyStep{}, ObjectPropertyStep("items"), ArrayIndexStep(12), ObjectPropertyStep("name"), StringIndexStep(3)).
+			AddStep(RuneIndexStep(-1)),
+		"header":       HeaderPath("X-Request-ID"),
+		"url-param":    URLParamPath("page"),
+		"escapes":      BodyPath().AddSteps(ObjectPropertyStep(`"quoted" \ back\slash`), ObjectPropertyStep("line\nbreak\ttab\rreturn\x00\x1f")),
+		"html":         BodyPath().AddStep(ObjectPropertyStep("<script>&amp;</script>")),
+		"unicode":      BodyPath().AddSteps(ObjectPropertyStep("名前 é 🧑‍🚀"), ObjectPropertyStep("line para ")),
+		"empty-string": BodyPath().AddStep(ObjectPropertyStep("")),
+	}
+
+	for name, steps := range cases {
+		name, steps := name, steps
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			expected, err := marshalGenericSteps(steps)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			result, err := steps.MarshalJSON()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(string(expected), string(result)); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestStepsMarshalJSONVersionDependentEscapes(t *testing.T) {
+	t.Parallel()
+
+	// encoding/json writes \b, \f, and the replacement for invalid UTF-8
+	// differently depending on the Go version, so they're checked by what
+	// they decode to
+	steps := BodyPath().AddStep(ObjectPropertyStep("\b\f\x7f a\xffb\xc3"))
+	result, err := steps.MarshalJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(`[{"kind":"body"},{"kind":"object_property","value":"\u0008\u000c`+"\x7f a\uFFFDb\uFFFD"+`"}]`, string(result)); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	var decoded Steps
+	err = json.Unmarshal(result, &decoded)
+	if err != nil {
+		t.Fatalf("error decoding steps: %s", err)
+	}
+	expected := BodyPath().AddStep(ObjectPropertyStep("\b\f\x7f a\uFFFDb\uFFFD"))
+	if !decoded.Equal(expected) {
+		t.Errorf("expected %v, got %v", expected, decoded)
+	}
+}
+
+func TestStepsMarshalJSONUnknownStep(t *testing.T) {
+	t.Parallel()
+
+	_, err := BodyPath().AddStep(unrenderableStep{}).MarshalJSON()
+	if err == nil || err.Error() != "unknown step type apidiags.unrenderableStep for step 1" {
+		t.Errorf("expected an unknown step error, got %v", err)
+	}
+}
+
+func TestStepsAppendJSONDoesntAllocate(t *testing.T) {
+	steps := BodyPath().AddSteps(ObjectPropertyStep("items"), ArrayIndexStep(2), ObjectPropertyStep("name"))
+	buf := make([]byte, 0, 256)
+	allocs := testing.AllocsPerRun(100, func() {
+		var err error
+		buf, err = steps.AppendJSON(buf[:0])
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("expected AppendJSON not to allocate, got %v allocations", allocs)
+	}
+	allocs = testing.AllocsPerRun(100, func() {
+		_, _ = steps.MarshalJSON()
+	})
+	if allocs != 1 {
+		t.Errorf("expected MarshalJSON to allocate once, got %v allocations", allocs)
+	}
+}
+
+var benchmarkSteps = BodyPath().AddSteps(ObjectPropertyStep("items"), ArrayIndexStep(12), ObjectPropertyStep("name"), StringIndexStep(3))
+
+func BenchmarkStepsMarshalJSON(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := benchmarkSteps.MarshalJSON()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStepsAppendJSON(b *testing.B) {
+	b.ReportAllocs()
+	buf := make([]byte, 0, 256)
+	for i := 0; i < b.N; i++ {
+		var err error
+		buf, err = benchmarkSteps.AppendJSON(buf[:0])
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkStepsMarshalJSONGeneric measures encoding Steps the way
+// MarshalJSON used to, for comparison.
+func BenchmarkStepsMarshalJSONGeneric(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := marshalGenericSteps(benchmarkSteps)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestToGenericStepDoesntAllocate(t *testing.T) {
+	steps := Steps{BodyStep{}, HeaderStep("Accept"), ObjectPropertyStep("name"), ArrayIndexStep(1 << 40)}
+	allocs := testing.AllocsPerRun(100, func() {
+		for _, step := range steps {
+			genStep, err := toGenericStep(step)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if genStep.Value.kind == valueOther {
+				t.Fatalf("unexpected boxed value for %#v", step)
+			}
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("expected toGenericStep not to allocate, got %v allocations", allocs)
+	}
+}
@@ -0,0 +1,141 @@
+package apidiags
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+var errEncoderClosed = errors.New("encoder is closed")
+
+// EncoderOption configures an Encoder.
+type EncoderOption func(*Encoder)
+
+// WithVersionEnvelope makes an Encoder wrap the array of Diagnostics it
+// writes in the envelope MarshalVersionedDiagnostics uses, recording the
+// wire format version, so DecodeVersionedDiagnostics can decode it.
+func WithVersionEnvelope() EncoderOption {
+	return func(e *Encoder) {
+		e.versioned = true
+	}
+}
+
+// Encoder writes Diagnostics to an io.Writer as a JSON array, one
+// Diagnostic at a time, so jobs producing more Diagnostics than they want
+// to hold in memory, like validating a large import, can write them out as
+// they go:
+//
+//	enc := apidiags.NewEncoder(w)
+//	for _, record := range records {
+//		err := enc.Encode(validate(record)...)
+//		if err != nil {
+//			return err
+//		}
+//	}
+//	return enc.Close()
+//
+// Only the encoding of the Diagnostic being written is held in memory. The
+// array isn't complete until Close is called. An Encoder writes to its
+// io.Writer once for each Diagnostic, so writers that are expensive to
+// write to, like files and network connections, should be wrapped in a
+// bufio.Writer, flushed after Close.
+//
+// An Encoder isn't safe for concurrent use.
+type Encoder struct {
+	w         io.Writer
+	versioned bool
+	count     int
+	closed    bool
+	buf       []byte
+	err       error
+}
+
+// NewEncoder returns an Encoder writing to w.
+func NewEncoder(w io.Writer, opts ...EncoderOption) *Encoder {
+	e := &Encoder{w: w}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Encode writes diags to the array, in order. If a Diagnostic can't be
+// encoded, an error is returned and neither it nor the Diagnostics after it
+// are written, leaving the array valid. If writing to the io.Writer fails,
+// that error is returned by every call to the Encoder from then on.
+func (e *Encoder) Encode(diags ...Diagnostic) error {
+	if e.err != nil {
+		return e.err
+	}
+	if e.closed {
+		return errEncoderClosed
+	}
+	for _, diag := range diags {
+		encoded, err := diag.MarshalJSON()
+		if err != nil {
+			return fmt.Errorf("error encoding diagnostic %d: %w", e.count, err)
+		}
+		if e.count == 0 {
+			e.buf = e.appendStart(e.buf[:0])
+		} else {
+			e.buf = append(e.buf[:0], ',')
+		}
+		e.buf = append(e.buf, encoded...)
+		err = e.write(e.buf)
+		if err != nil {
+			return err
+		}
+		e.count++
+	}
+	return nil
+}
+
+// Count returns the number of Diagnostics written so far.
+func (e *Encoder) Count() int {
+	return e.count
+}
+
+// Close writes the end of the array, writing an empty array if no
+// Diagnostics were written. It doesn't close the io.Writer. Calling Close
+// again does nothing.
+func (e *Encoder) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+	if e.closed {
+		return nil
+	}
+	e.buf = e.buf[:0]
+	if e.count == 0 {
+		e.buf = e.appendStart(e.buf)
+	}
+	e.buf = append(e.buf, ']')
+	if e.versioned {
+		e.buf = append(e.buf, '}')
+	}
+	err := e.write(e.buf)
+	if err != nil {
+		return err
+	}
+	e.closed = true
+	return nil
+}
+
+// appendStart appends what comes before the first Diagnostic to dst.
+func (e *Encoder) appendStart(dst []byte) []byte {
+	if e.versioned {
+		dst = append(dst, `{"version":`...)
+		dst = strconv.AppendInt(dst, WireVersion, 10)
+		dst = append(dst, `,"diagnostics":`...)
+	}
+	return append(dst, '[')
+}
+
+func (e *Encoder) write(p []byte) error {
+	_, err := e.w.Write(p)
+	if err != nil {
+		e.err = err
+	}
+	return err
+}
@@ -0,0 +1,204 @@
+package apidiags
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// limitedWriter is an io.Writer that fails once more than limit bytes have
+// been written to it, counting its calls to Write.
+type limitedWriter struct {
+	buf    bytes.Buffer
+	limit  int
+	writes int
+}
+
+var errWriterFull = errors.New("writer is full")
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.buf.Len()+len(p) > w.limit {
+		return 0, errWriterFull
+	}
+	return w.buf.Write(p)
+}
+
+func TestEncoderMatchesMarshal(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags     Diagnostics
+		versioned bool
+	}
+
+	several := Diagnostics{
+		{Severity: DiagnosticError, Code: CodeMissing, Paths: []Steps{BodyPath().AddStep(ObjectPropertyStep("name"))}, Summary: "The name is required."},
+		Diagnostic{Severity: DiagnosticWarning, Code: CodeDeprecated, Paths: []Steps{HeaderPath("X-Legacy")}}.WithTrace("4bf92f3577b34da6a3ce929d0e0e4736", ""),
+		{Severity: DiagnosticError, Code: CodeOverflow, Extensions: map[string]json.RawMessage{"max": json.RawMessage(`[ 1, 2 ]`)}},
+	}
+
+	cases := map[string]testCase{
+		"empty":           {},
+		"one":             {diags: several[:1]},
+		"several":         {diags: several},
+		"empty-versioned": {versioned: true},
+		"versioned":       {diags: several, versioned: true},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var expected []byte
+			var err error
+			if tc.versioned {
+				expected, err = MarshalVersionedDiagnostics(tc.diags)
+			} else {
+				expected, err = json.Marshal(append(Diagnostics{}, tc.diags...))
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var buf bytes.Buffer
+			var opts []EncoderOption
+			if tc.versioned {
+				opts = append(opts, WithVersionEnvelope())
+			}
+			enc := NewEncoder(&buf, opts...)
+			for _, diag := range tc.diags {
+				err = enc.Encode(diag)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+			}
+			err = enc.Encode()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			err = enc.Close()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(string(expected), buf.String()); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+			if enc.Count() != len(tc.diags) {
+				t.Errorf("expected a count of %d, got %d", len(tc.diags), enc.Count())
+			}
+		})
+	}
+}
+
+func TestEncoderWritesEachDiagnostic(t *testing.T) {
+	t.Parallel()
+
+	w := &limitedWriter{limit: 1 << 20}
+	enc := NewEncoder(w)
+	diags := make(Diagnostics, 1000)
+	for pos := range diags {
+		diags[pos] = Diagnostic{Severity: DiagnosticError, Code: CodeInvalidValue, Paths: []Steps{BodyPath().AddStep(ArrayIndexStep(pos))}}
+	}
+	err := enc.Encode(diags...)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if w.writes != len(diags) {
+		t.Errorf("expected %d writes, got %d", len(diags), w.writes)
+	}
+	err = enc.Close()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	decoded, err := DecodeVersionedDiagnostics(w.buf.Bytes())
+	if err != nil {
+		t.Fatalf("error decoding diagnostics: %s", err)
+	}
+	if diff := cmp.Diff(diags, decoded); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+}
+
+func TestEncoderUnencodableDiagnostic(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	good := Diagnostic{Severity: DiagnosticError, Code: CodeMissing}
+	bad := Diagnostic{Severity: DiagnosticError, Code: CodeInvalidValue, Extensions: map[string]json.RawMessage{"broken": json.RawMessage(`{`)}}
+	err := enc.Encode(good, bad, good)
+	if err == nil || !strings.HasPrefix(err.Error(), "error encoding diagnostic 1: ") {
+		t.Errorf("expected an error encoding diagnostic 1, got %v", err)
+	}
+	err = enc.Encode(good)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err = enc.Close()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var decoded Diagnostics
+	err = json.Unmarshal(buf.Bytes(), &decoded)
+	if err != nil {
+		t.Fatalf("error decoding diagnostics: %s", err)
+	}
+	if diff := cmp.Diff(Diagnostics{good, good}, decoded); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+}
+
+func TestEncoderWriteError(t *testing.T) {
+	t.Parallel()
+
+	w := &limitedWriter{limit: 40}
+	enc := NewEncoder(w)
+	diag := Diagnostic{Severity: DiagnosticError, Code: CodeMissing}
+	err := enc.Encode(diag)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err = enc.Encode(diag)
+	if !errors.Is(err, errWriterFull) {
+		t.Errorf("expected %v, got %v", errWriterFull, err)
+	}
+	writes := w.writes
+	for _, err := range []error{enc.Encode(diag), enc.Close()} {
+		if !errors.Is(err, errWriterFull) {
+			t.Errorf("expected %v, got %v", errWriterFull, err)
+		}
+	}
+	if w.writes != writes {
+		t.Errorf("expected no writes after the failure, got %d", w.writes-writes)
+	}
+	if enc.Count() != 1 {
+		t.Errorf("expected a count of 1, got %d", enc.Count())
+	}
+}
+
+func TestEncoderClose(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	for i := 0; i < 2; i++ {
+		err := enc.Close()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if buf.String() != "[]" {
+		t.Errorf("expected an empty array written once, got %q", buf.String())
+	}
+	err := enc.Encode(Diagnostic{Code: CodeMissing})
+	if err == nil || err.Error() != "encoder is closed" {
+		t.Errorf("expected a closed encoder error, got %v", err)
+	}
+}
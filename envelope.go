@@ -0,0 +1,68 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Envelope pairs the payload of a response with the Diagnostics about it,
+// so successful responses can carry warnings alongside their results, like
+// `{"data": {...}, "diagnostics": [...]}`.
+//
+// When the Diagnostics include an error, the response has no payload, and
+// the "data" member is left out of the Envelope's JSON encoding.
+// ParseResponse reads the Diagnostics of an Envelope; decode the response
+// body into an Envelope to read its Data as well.
+type Envelope[T any] struct {
+	Data        T           `json:"data"`
+	Diagnostics Diagnostics `json:"diagnostics,omitempty"`
+}
+
+// NewEnvelope returns an Envelope containing data and diags.
+func NewEnvelope[T any](data T, diags Diagnostics) Envelope[T] {
+	return Envelope[T]{Data: data, Diagnostics: diags}
+}
+
+// MarshalJSON turns an Envelope into a JSON object, leaving out its Data if
+// its Diagnostics include an error.
+func (e Envelope[T]) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Data        *T          `json:"data,omitempty"`
+		Diagnostics Diagnostics `json:"diagnostics,omitempty"`
+	}{Diagnostics: e.Diagnostics}
+	if !e.Diagnostics.HasErrors() {
+		out.Data = &e.Data
+	}
+	return json.Marshal(out)
+}
+
+// WriteEnvelope writes env to w as an application/json response. The status
+// code is chosen the same way WriteHTTP chooses it, so Envelopes without
+// errors are written with http.StatusOK, and the same headers describing
+// the Diagnostics are set. The HTTPOptions that only apply to Problems,
+// like WithProblemType, are ignored.
+func WriteEnvelope[T any](w http.ResponseWriter, env Envelope[T], opts ...HTTPOption) error {
+	var cfg httpConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	env.Diagnostics = cfg.written(cfg.filter(env.Diagnostics))
+	err := setDiagnosticHeaders(w.Header(), env.Diagnostics, cfg)
+	if err != nil {
+		return err
+	}
+	cfg.observe(env.Diagnostics)
+	var body []byte
+	if cfg.canonical {
+		body, err = MarshalCanonicalJSON(env)
+	} else {
+		body, err = json.Marshal(env)
+	}
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(cfg.statusFor(env.Diagnostics))
+	_, err = w.Write(body)
+	return err
+}
@@ -0,0 +1,129 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type testWidget struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+func TestEnvelopeJSON(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		env      Envelope[testWidget]
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"data-only": {
+			env:      NewEnvelope(testWidget{ID: "123", Name: "foo"}, nil),
+			expected: `{"data":{"id":"123","name":"foo"}}`,
+		},
+		"data-with-warnings": {
+			env: NewEnvelope(testWidget{ID: "123"}, Diagnostics{
+				{Severity: DiagnosticWarning, Code: CodeDeprecated, Paths: []Steps{URLParamPath("mode")}},
+			}),
+			expected: `{"data":{"id":"123"},"diagnostics":[{"severity":"warning","code":"deprecated","path":[[{"kind":"url_param","value":"mode"}]]}]}`,
+		},
+		"errors": {
+			env: NewEnvelope(testWidget{}, Diagnostics{
+				{Severity: DiagnosticError, Code: CodeNotFound, Paths: []Steps{URLParamPath("id")}},
+			}),
+			expected: `{"diagnostics":[{"severity":"error","code":"not_found","path":[[{"kind":"url_param","value":"id"}]]}]}`,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			encoded, err := json.Marshal(tc.env)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if string(encoded) != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, encoded)
+			}
+			var decoded Envelope[testWidget]
+			err = json.Unmarshal(encoded, &decoded)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(tc.env, decoded); diff != "" {
+				t.Fatalf("unexpected round trip results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteEnvelope(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		env    Envelope[[]testWidget]
+		opts   []HTTPOption
+		status int
+	}
+
+	cases := map[string]testCase{
+		"success": {
+			env: NewEnvelope([]testWidget{{ID: "1"}, {ID: "2"}}, Diagnostics{
+				{Severity: DiagnosticWarning, Code: CodeDeprecated},
+			}),
+			status: http.StatusOK,
+		},
+		"created": {
+			env:    NewEnvelope([]testWidget{{ID: "1"}}, nil),
+			opts:   []HTTPOption{WithStatus(http.StatusCreated)},
+			status: http.StatusCreated,
+		},
+		"failure": {
+			env: NewEnvelope[[]testWidget](nil, Diagnostics{
+				{Severity: DiagnosticError, Code: CodeAccessDenied},
+			}),
+			status: http.StatusForbidden,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			err := WriteEnvelope(w, tc.env, tc.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if w.Code != tc.status {
+				t.Errorf("expected status %d, got %d", tc.status, w.Code)
+			}
+			var decoded Envelope[[]testWidget]
+			err = json.Unmarshal(w.Body.Bytes(), &decoded)
+			if err != nil {
+				t.Fatalf("unexpected error decoding response: %s", err)
+			}
+			if diff := cmp.Diff(tc.env, decoded); diff != "" {
+				t.Errorf("unexpected response body (-wanted, +got): %s", diff)
+			}
+			diags, err := ParseResponse(w.Result())
+			if err != nil {
+				t.Fatalf("unexpected error parsing response: %s", err)
+			}
+			if diff := cmp.Diff(tc.env.Diagnostics, diags); diff != "" {
+				t.Errorf("unexpected parsed Diagnostics (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package apidiags
+
+import "errors"
+
+// DiagnosticsError is implemented by errors that can describe themselves as
+// Diagnostics. FromError uses it to classify errors.
+type DiagnosticsError interface {
+	error
+	Diagnostics() Diagnostics
+}
+
+// FromError classifies err as Diagnostics.
+//
+// If err is or wraps a DiagnosticsError, its Diagnostics are returned. If err
+// wraps more than one error, like the errors returned by errors.Join, each
+// of them is classified in turn, and their Diagnostics are concatenated.
+//
+// Any other error is assumed to be a failure outside the caller's control,
+// and is described by a single Diagnostic with a Code of CodeActOfGod. As
+// the messages of such errors usually aren't meant for callers, they aren't
+// included in the Diagnostic. If err is nil, FromError returns nil.
+func FromError(err error) Diagnostics {
+	if err == nil {
+		return nil
+	}
+	switch err := err.(type) {
+	case DiagnosticsError:
+		return err.Diagnostics()
+	case interface{ Unwrap() []error }:
+		var results Diagnostics
+		for _, wrapped := range err.Unwrap() {
+			results = append(results, FromError(wrapped)...)
+		}
+		return results
+	}
+	if wrapped := errors.Unwrap(err); wrapped != nil {
+		return FromError(wrapped)
+	}
+	return Diagnostics{{
+		Severity: DiagnosticError,
+		Code:     CodeActOfGod,
+	}}
+}
@@ -0,0 +1,72 @@
+package apidiags
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type testDiagnosticsError struct {
+	diags Diagnostics
+}
+
+func (e testDiagnosticsError) Error() string { return "diagnostics" }
+
+func (e testDiagnosticsError) Diagnostics() Diagnostics { return e.diags }
+
+type testMultiError []error
+
+func (e testMultiError) Error() string { return "multiple errors" }
+
+func (e testMultiError) Unwrap() []error { return e }
+
+func TestFromError(t *testing.T) {
+	t.Parallel()
+
+	missing := Diagnostic{Severity: DiagnosticError, Code: CodeMissing, Paths: []Steps{BodyPath().AddStep(ObjectPropertyStep("name"))}}
+	notFound := Diagnostic{Severity: DiagnosticError, Code: CodeNotFound}
+	actOfGod := Diagnostic{Severity: DiagnosticError, Code: CodeActOfGod}
+
+	type testCase struct {
+		err      error
+		expected Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"nil": {},
+		"diagnostics-error": {
+			err:      testDiagnosticsError{diags: Diagnostics{missing}},
+			expected: Diagnostics{missing},
+		},
+		"wrapped": {
+			err:      fmt.Errorf("creating widget: %w", testDiagnosticsError{diags: Diagnostics{missing, notFound}}),
+			expected: Diagnostics{missing, notFound},
+		},
+		"unknown": {
+			err:      errors.New("connection refused"),
+			expected: Diagnostics{actOfGod},
+		},
+		"multiple": {
+			err: fmt.Errorf("validating: %w", testMultiError{
+				testDiagnosticsError{diags: Diagnostics{missing}},
+				errors.New("oops"),
+				fmt.Errorf("looking up owner: %w", testDiagnosticsError{diags: Diagnostics{notFound}}),
+			}),
+			expected: Diagnostics{missing, actOfGod, notFound},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tc.expected, FromError(tc.err)); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package apidiags
+
+import (
+	"context"
+	"expvar"
+	"sync"
+)
+
+// PublishExpvar publishes counts of the Diagnostics written in responses as
+// an expvar.Map named name, so services without a metrics stack can see
+// them at /debug/vars. The map is keyed by Severity, and each of its values
+// is a map keyed by Code, holding the number of Diagnostics written with
+// that Severity and Code:
+//
+//	"apidiags": {"error": {"missing": 3, "not_found": 1}, "warning": {"deprecated": 12}}
+//
+// Diagnostics are counted by a DiagnosticHook registered with
+// OnDiagnostic, so every Diagnostic written by WriteHTTP, WriteEnvelope,
+// WriteBatch, and InjectDiagnostics is counted, without any other
+// configuration. Like expvar.Publish, PublishExpvar panics if name is
+// already in use, so it should only be called once, when a program starts.
+func PublishExpvar(name string) *expvar.Map {
+	counts := expvar.NewMap(name)
+	var mu sync.Mutex
+	OnDiagnostic(func(_ context.Context, event HookEvent, diag Diagnostic) Diagnostic {
+		if event != HookWritten {
+			return diag
+		}
+		mu.Lock()
+		codes, ok := counts.Get(string(diag.Severity)).(*expvar.Map)
+		if !ok {
+			codes = new(expvar.Map).Init()
+			counts.Set(string(diag.Severity), codes)
+		}
+		mu.Unlock()
+		codes.Add(string(diag.Code), 1)
+		return diag
+	})
+	return counts
+}
@@ -0,0 +1,53 @@
+package apidiags
+
+import (
+	"context"
+	"expvar"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	t.Parallel()
+
+	counts := PublishExpvar("apidiags_test")
+	diags := Diagnostics{
+		{Severity: DiagnosticError, Code: "expvar_test_error"},
+		{Severity: DiagnosticError, Code: "expvar_test_error"},
+		{Severity: DiagnosticWarning, Code: "expvar_test_warning"},
+	}
+	err := WriteHTTP(httptest.NewRecorder(), diags)
+	if err != nil {
+		t.Fatalf("error writing response: %s", err)
+	}
+	// Diagnostics that are added but never written aren't counted
+	ctx := CollectDiagnostics(context.Background())
+	AddDiagnostics(ctx, Diagnostic{Severity: DiagnosticWarning, Code: "expvar_test_unwritten"})
+
+	type testCase struct {
+		severity Severity
+		code     Code
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"error":     {severity: DiagnosticError, code: "expvar_test_error", expected: "2"},
+		"warning":   {severity: DiagnosticWarning, code: "expvar_test_warning", expected: "1"},
+		"unwritten": {severity: DiagnosticWarning, code: "expvar_test_unwritten", expected: "<nil>"},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := "<nil>"
+			if value := counts.Get(string(tc.severity)).(*expvar.Map).Get(string(tc.code)); value != nil {
+				got = value.String()
+			}
+			if got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package apidiags
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// UnmappedField is the key FieldMessages uses for the messages of
+// Diagnostics whose Paths don't resolve to a field, so they can be shown
+// somewhere generic, like the top of a form.
+const UnmappedField = ""
+
+// FieldResolver resolves a Path to the identifier of the UI field it
+// describes, like the name of a form input. If the Path doesn't describe
+// any field, it returns false.
+type FieldResolver func(path Steps) (string, bool)
+
+// FieldMap returns a FieldResolver using fields, a map of patterns to field
+// identifiers. Patterns are written in the notation described by Pattern,
+// like `body.items[*].name`.
+//
+// Paths are resolved by the pattern matching them exactly, or if none do,
+// the pattern matching the longest prefix of them, so a pattern of
+// `body.address` resolves `body.address.zip` to the address field. When
+// more than one pattern matches, the first in lexical order wins. An error
+// is returned if any of the patterns can't be compiled.
+func FieldMap(fields map[string]string) (FieldResolver, error) {
+	patterns := make([]string, 0, len(fields))
+	for pattern := range fields {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	compiled := make([]Pattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		result, err := CompilePattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("error compiling pattern for field %q: %w", fields[pattern], err)
+		}
+		compiled = append(compiled, result)
+	}
+	return func(path Steps) (string, bool) {
+		for end := len(path); end > 0; end-- {
+			for pos, pattern := range compiled {
+				if pattern.Match(path[:end]) {
+					return fields[patterns[pos]], true
+				}
+			}
+		}
+		return "", false
+	}, nil
+}
+
+// StructFields returns a FieldResolver for forms whose fields are the JSON
+// fields of v, usually the struct the form's request body is encoded from.
+// Paths into the body resolve to the longest prefix of them that's a field
+// of v, rendered using Steps.FieldPath, like `items[3].name`. Fields are
+// matched the way encoding/json matches them, and map keys and array
+// indices are always fields of maps and arrays. Paths outside the body,
+// and Paths that don't start with a field of v, don't resolve.
+func StructFields(v any) FieldResolver {
+	typ := reflect.TypeOf(v)
+	return func(path Steps) (string, bool) {
+		if len(path) < 1 {
+			return "", false
+		}
+		if _, ok := path[0].(BodyStep); !ok {
+			return "", false
+		}
+		current := typ
+		end := 1
+		for ; end < len(path); end++ {
+			current = fieldType(current, path[end])
+			if current == nil {
+				break
+			}
+		}
+		if end < 2 {
+			return "", false
+		}
+		return path[:end].FieldPath(), true
+	}
+}
+
+// fieldType returns the type step points to in typ, or nil if step doesn't
+// point to anything in it.
+func fieldType(typ reflect.Type, step Step) reflect.Type {
+	typ = derefType(typ)
+	if typ == nil {
+		return nil
+	}
+	switch step := step.(type) {
+	case ObjectPropertyStep:
+		switch typ.Kind() {
+		case reflect.Struct:
+			field, _ := structFieldType(typ, string(step))
+			return field
+		case reflect.Map:
+			return typ.Elem()
+		case reflect.Interface:
+			return typ
+		}
+	case ArrayIndexStep:
+		switch typ.Kind() {
+		case reflect.Slice, reflect.Array:
+			return typ.Elem()
+		case reflect.Interface:
+			return typ
+		}
+	}
+	return nil
+}
+
+// FieldMessages groups the Messages of the Diagnostics in diags with a
+// Severity of DiagnosticError by the field resolve resolves their Paths to,
+// for rendering errors inline in a form. Diagnostics with more than one
+// Path are listed under each of their fields, once. Diagnostics without a
+// Path, or with Paths that don't resolve, are listed under UnmappedField.
+// If diags has no errors, nil is returned.
+func FieldMessages(diags Diagnostics, resolve FieldResolver) map[string][]string {
+	var results map[string][]string
+	add := func(field, msg string) {
+		if results == nil {
+			results = map[string][]string{}
+		}
+		results[field] = append(results[field], msg)
+	}
+	for _, diag := range diags {
+		if diag.Severity != DiagnosticError {
+			continue
+		}
+		msg := diag.Message()
+		seen := map[string]bool{}
+		for _, path := range diag.Paths {
+			field, ok := resolve(path)
+			if !ok {
+				field = UnmappedField
+			}
+			if seen[field] {
+				continue
+			}
+			seen[field] = true
+			add(field, msg)
+		}
+		if len(diag.Paths) < 1 {
+			add(UnmappedField, msg)
+		}
+	}
+	return results
+}
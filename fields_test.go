@@ -0,0 +1,105 @@
+package apidiags
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFieldMessages(t *testing.T) {
+	t.Parallel()
+
+	type address struct {
+		Street string `json:"street"`
+		Zip    string `json:"zip"`
+	}
+	type item struct {
+		Name string `json:"name"`
+	}
+	type form struct {
+		Email   string            `json:"email"`
+		Address address           `json:"address"`
+		Items   []item            `json:"items"`
+		Labels  map[string]string `json:"labels"`
+		Secret  string            `json:"-"`
+	}
+
+	mapped, err := FieldMap(map[string]string{
+		"body.email":            "email-input",
+		"body.address":          "address-fieldset",
+		"body.items[*].name":    "item-name",
+		`header("Idempotency")`: "resubmit",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	type testCase struct {
+		diags    Diagnostics
+		resolve  FieldResolver
+		expected map[string][]string
+	}
+
+	diags := Diagnostics{
+		{Severity: DiagnosticError, Code: CodeMissing, Paths: []Steps{BodyPath().AddStep(ObjectPropertyStep("email"))}, Summary: "Email is required."},
+		{Severity: DiagnosticError, Code: CodeInvalidFormat, Paths: []Steps{BodyPath().AddSteps(ObjectPropertyStep("address"), ObjectPropertyStep("zip"))}},
+		{Severity: DiagnosticError, Code: CodeOverflow, Paths: []Steps{BodyPath().AddSteps(ObjectPropertyStep("items"), ArrayIndexStep(1), ObjectPropertyStep("name"))}},
+		{Severity: DiagnosticError, Code: CodeInvalidValue, Paths: []Steps{BodyPath().AddSteps(ObjectPropertyStep("labels"), ObjectPropertyStep("app"))}},
+		{Severity: DiagnosticError, Code: CodeInvalidValue, Paths: []Steps{BodyPath().AddStep(ObjectPropertyStep("Secret"))}},
+		{Severity: DiagnosticError, Code: CodeConflict, Paths: []Steps{
+			BodyPath().AddStep(ObjectPropertyStep("email")),
+			BodyPath().AddStep(ObjectPropertyStep("email")),
+		}},
+		{Severity: DiagnosticError, Code: CodeActOfGod},
+		{Severity: DiagnosticWarning, Code: CodeDeprecated, Paths: []Steps{BodyPath().AddStep(ObjectPropertyStep("email"))}},
+	}
+
+	cases := map[string]testCase{
+		"no-errors": {
+			diags:   Diagnostics{{Severity: DiagnosticWarning, Code: CodeDeprecated}},
+			resolve: mapped,
+		},
+		"field-map": {
+			diags:   diags,
+			resolve: mapped,
+			expected: map[string][]string{
+				"email-input":      {"Email is required.", "conflict"},
+				"address-fieldset": {"invalid_format"},
+				"item-name":        {"overflow"},
+				UnmappedField:      {"invalid_value", "invalid_value", "act_of_god"},
+			},
+		},
+		"struct-fields": {
+			diags:   diags,
+			resolve: StructFields(form{}),
+			expected: map[string][]string{
+				"email":         {"Email is required.", "conflict"},
+				"address.zip":   {"invalid_format"},
+				"items[1].name": {"overflow"},
+				"labels.app":    {"invalid_value"},
+				UnmappedField:   {"invalid_value", "act_of_god"},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result := FieldMessages(tc.diags, tc.resolve)
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestFieldMapInvalidPattern(t *testing.T) {
+	t.Parallel()
+
+	if _, err := FieldMap(map[string]string{"body[": "broken"}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
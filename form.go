@@ -0,0 +1,230 @@
+package apidiags
+
+import (
+	"errors"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FormFieldPath returns Steps that point to the specified field of a
+// request body encoded as application/x-www-form-urlencoded or
+// multipart/form-data. apidiags has no Steps specific to forms, so a form
+// is treated like a JSON object, and its fields like the object's
+// properties: FormFieldPath("name") is the same as
+// BodyPath().AddStep(ObjectPropertyStep("name")).
+func FormFieldPath(field string) Steps {
+	return BodyPath().AddStep(ObjectPropertyStep(field))
+}
+
+// defaultMaxFormBytes is the limit ParseForm applies without
+// WithMaxFormBytes, matching the one net/http applies without
+// http.MaxBytesReader.
+const defaultMaxFormBytes = 10 << 20
+
+// FormOption configures how ParseForm and ParseMultipartForm parse a
+// request.
+type FormOption func(*formConfig)
+
+type formConfig struct {
+	maxBytes     int64
+	maxFileBytes int64
+	required     []string
+	partTypes    map[string][]string
+}
+
+// WithMaxFormBytes sets the largest request body, in bytes, that ParseForm
+// and ParseMultipartForm read before giving up with a Diagnostic with a
+// Code of CodeOverflow. Without it, ParseForm uses net/http's limit of 10
+// MB, and ParseMultipartForm has no limit.
+func WithMaxFormBytes(n int64) FormOption {
+	return func(c *formConfig) {
+		c.maxBytes = n
+	}
+}
+
+// RequireFormFields makes ParseForm and ParseMultipartForm report each of
+// fields that has no value, in the body or the URL, with CodeMissing. For
+// ParseMultipartForm, a file uploaded under the field's name counts as a
+// value.
+func RequireFormFields(fields ...string) FormOption {
+	return func(c *formConfig) {
+		c.required = append(c.required, fields...)
+	}
+}
+
+// LimitFileBytes makes ParseMultipartForm report each uploaded file larger
+// than n bytes with CodeOverflow.
+func LimitFileBytes(n int64) FormOption {
+	return func(c *formConfig) {
+		c.maxFileBytes = n
+	}
+}
+
+// AllowPartContentTypes makes ParseMultipartForm report each file uploaded
+// under field whose media type isn't one of mediaTypes with
+// CodeInvalidFormat. Parameters are ignored, as they are by
+// AllowContentTypes.
+func AllowPartContentTypes(field string, mediaTypes ...string) FormOption {
+	return func(c *formConfig) {
+		if c.partTypes == nil {
+			c.partTypes = map[string][]string{}
+		}
+		c.partTypes[field] = append(c.partTypes[field], mediaTypes...)
+	}
+}
+
+// ParseForm calls r.ParseForm, describing everything that goes wrong as
+// Diagnostics with a Severity of DiagnosticError. If the form is parsed
+// successfully and passes the checks set by opts, it returns nil.
+//
+// Bodies larger than the limit set by WithMaxFormBytes, or 10 MB without
+// it, fail with CodeOverflow, reporting that limit. Badly encoded bodies
+// and query strings fail with CodeInvalidFormat, pointing to the body, as
+// net/url doesn't report which field is to blame. See RequireFormFields for
+// reporting missing fields.
+func ParseForm(r *http.Request, opts ...FormOption) Diagnostics {
+	var cfg formConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	limit := cfg.maxBytes
+	if limit <= 0 {
+		limit = defaultMaxFormBytes
+	}
+	limitFormBody(r, limit)
+	err := r.ParseForm()
+	if err != nil {
+		return Diagnostics{formError(err)}
+	}
+	return missingFormFields(r, cfg, nil)
+}
+
+// ParseMultipartForm calls r.ParseMultipartForm with maxMemory, describing
+// everything that goes wrong as Diagnostics with a Severity of
+// DiagnosticError, like ParseForm. If the form is parsed successfully and
+// passes the checks set by opts, it returns nil.
+//
+// Requests that aren't multipart/form-data fail with CodeInvalidFormat,
+// pointing to the Content-Type header, and bodies that can't be parsed as
+// multipart/form-data fail with CodeInvalidFormat, pointing to the body.
+// Bodies with more parts than mime/multipart allows, or with more than
+// maxMemory plus 10 MB of fields that aren't files, fail with CodeOverflow.
+// Uploaded files that fail the checks set by LimitFileBytes or
+// AllowPartContentTypes point to the file, using FormFieldPath followed by
+// an ArrayIndexStep for the file's position among the files uploaded
+// under that field.
+func ParseMultipartForm(r *http.Request, maxMemory int64, opts ...FormOption) Diagnostics {
+	var cfg formConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	limitFormBody(r, cfg.maxBytes)
+	err := r.ParseMultipartForm(maxMemory)
+	if errors.Is(err, multipart.ErrMessageTooLarge) {
+		return Diagnostics{{
+			Severity: DiagnosticError,
+			Code:     CodeOverflow,
+			Paths:    []Steps{BodyPath()},
+			Summary:  "The request body is too large.",
+			Detail: "The request body can have at most " + strconv.FormatInt(maxMemory+10<<20, 10) +
+				" bytes of fields that aren't files, and a limited number of parts.",
+		}}
+	}
+	if err != nil {
+		return Diagnostics{formError(err)}
+	}
+	var diags Diagnostics
+	fields := make([]string, 0, len(r.MultipartForm.File))
+	for field := range r.MultipartForm.File {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		for pos, file := range r.MultipartForm.File[field] {
+			path := FormFieldPath(field).AddStep(ArrayIndexStep(pos))
+			if cfg.maxFileBytes > 0 && file.Size > cfg.maxFileBytes {
+				diags = append(diags, Diagnostic{
+					Severity: DiagnosticError,
+					Code:     CodeOverflow,
+					Paths:    []Steps{path},
+					Summary:  "The file is too large.",
+					Detail: "The file is " + strconv.FormatInt(file.Size, 10) +
+						" bytes, but can be at most " + strconv.FormatInt(cfg.maxFileBytes, 10) + " bytes.",
+				})
+			}
+			allowed, ok := cfg.partTypes[field]
+			if !ok {
+				continue
+			}
+			mediaType, _, err := mime.ParseMediaType(file.Header.Get("Content-Type"))
+			if err != nil || !containsFold(allowed, mediaType) {
+				diags = append(diags, Diagnostic{
+					Severity: DiagnosticError,
+					Code:     CodeInvalidFormat,
+					Paths:    []Steps{path},
+					Summary:  "The file's media type isn't supported.",
+					Detail:   "Supported media types: " + strings.Join(allowed, ", ") + ".",
+				})
+			}
+		}
+	}
+	return append(diags, missingFormFields(r, cfg, r.MultipartForm)...)
+}
+
+// limitFormBody wraps r's body with http.MaxBytesReader, if limit is
+// positive. Bodies wrapped by http.MaxBytesReader aren't limited by
+// net/http again, so the *http.MaxBytesError reports the limit that
+// applied.
+func limitFormBody(r *http.Request, limit int64) {
+	if limit > 0 && r.Body != nil {
+		r.Body = http.MaxBytesReader(nil, r.Body, limit)
+	}
+}
+
+// formError converts an error returned while parsing a form into a
+// Diagnostic.
+func formError(err error) Diagnostic {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return bodyTooLarge(maxBytesErr.Limit)
+	case errors.Is(err, http.ErrNotMultipart), errors.Is(err, http.ErrMissingBoundary):
+		return Diagnostic{
+			Severity: DiagnosticError,
+			Code:     CodeInvalidFormat,
+			Paths:    []Steps{HeaderPath("Content-Type")},
+			Summary:  "The request body's media type isn't supported.",
+			Detail:   "Supported media types: multipart/form-data.",
+		}
+	}
+	return Diagnostic{
+		Severity: DiagnosticError,
+		Code:     CodeInvalidFormat,
+		Paths:    []Steps{BodyPath()},
+		Detail:   err.Error(),
+	}
+}
+
+// missingFormFields returns a Diagnostic for each field cfg requires that
+// has no value in r's form, or file in form, if it isn't nil.
+func missingFormFields(r *http.Request, cfg formConfig, form *multipart.Form) Diagnostics {
+	var diags Diagnostics
+	for _, field := range cfg.required {
+		if len(r.Form[field]) > 0 {
+			continue
+		}
+		if form != nil && len(form.File[field]) > 0 {
+			continue
+		}
+		diags = append(diags, Diagnostic{
+			Severity: DiagnosticError,
+			Code:     CodeMissing,
+			Paths:    []Steps{FormFieldPath(field)},
+		})
+	}
+	return diags
+}
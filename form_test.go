@@ -0,0 +1,241 @@
+package apidiags
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseForm(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		target   string
+		body     string
+		opts     []FormOption
+		expected Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"valid": {
+			body: "name=foo&color=blue",
+			opts: []FormOption{RequireFormFields("name", "color")},
+		},
+		"required-in-query": {
+			target: "/?name=foo",
+			body:   "color=blue",
+			opts:   []FormOption{RequireFormFields("name", "color")},
+		},
+		"missing": {
+			body: "name=&color=blue",
+			opts: []FormOption{RequireFormFields("name", "size", "color")},
+			expected: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeMissing,
+				Paths:    []Steps{FormFieldPath("size")},
+			}},
+		},
+		"bad-escape": {
+			body: "name=%zz",
+			opts: []FormOption{RequireFormFields("name")},
+			expected: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeInvalidFormat,
+				Paths:    []Steps{BodyPath()},
+				Detail:   `invalid URL escape "%zz"`,
+			}},
+		},
+		"too-large": {
+			body: "name=this+is+far+too+long",
+			opts: []FormOption{WithMaxFormBytes(10)},
+			expected: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeOverflow,
+				Paths:    []Steps{BodyPath()},
+				Summary:  "The request body is too large.",
+				Detail:   "The request body can be at most 10 bytes.",
+			}},
+		},
+		"default-limit": {
+			body: "name=" + strings.Repeat("a", 10<<20),
+			expected: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeOverflow,
+				Paths:    []Steps{BodyPath()},
+				Summary:  "The request body is too large.",
+				Detail:   "The request body can be at most 10485760 bytes.",
+			}},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			target := tc.target
+			if target == "" {
+				target = "/"
+			}
+			r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(tc.body))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			got := ParseForm(r, tc.opts...)
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestParseMultipartForm(t *testing.T) {
+	t.Parallel()
+
+	type testFile struct {
+		field       string
+		contentType string
+		contents    string
+	}
+
+	type testCase struct {
+		fields      map[string]string
+		files       []testFile
+		contentType string
+		opts        []FormOption
+		expected    Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"valid": {
+			fields: map[string]string{"name": "foo"},
+			files: []testFile{
+				{field: "avatar", contentType: "image/png", contents: "png"},
+			},
+			opts: []FormOption{
+				RequireFormFields("name", "avatar"),
+				AllowPartContentTypes("avatar", "image/png", "image/jpeg"),
+				LimitFileBytes(10),
+			},
+		},
+		"not-multipart": {
+			contentType: "application/x-www-form-urlencoded",
+			expected: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeInvalidFormat,
+				Paths:    []Steps{HeaderPath("Content-Type")},
+				Summary:  "The request body's media type isn't supported.",
+				Detail:   "Supported media types: multipart/form-data.",
+			}},
+		},
+		"missing-boundary": {
+			contentType: "multipart/form-data",
+			expected: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeInvalidFormat,
+				Paths:    []Steps{HeaderPath("Content-Type")},
+				Summary:  "The request body's media type isn't supported.",
+				Detail:   "Supported media types: multipart/form-data.",
+			}},
+		},
+		"too-large": {
+			fields: map[string]string{"name": strings.Repeat("a", 100)},
+			opts:   []FormOption{WithMaxFormBytes(50)},
+			expected: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeOverflow,
+				Paths:    []Steps{BodyPath()},
+				Summary:  "The request body is too large.",
+				Detail:   "The request body can be at most 50 bytes.",
+			}},
+		},
+		"too-many-field-bytes": {
+			fields: map[string]string{"name": strings.Repeat("a", 11<<20+1)},
+			expected: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeOverflow,
+				Paths:    []Steps{BodyPath()},
+				Summary:  "The request body is too large.",
+				Detail:   "The request body can have at most 11534336 bytes of fields that aren't files, and a limited number of parts.",
+			}},
+		},
+		"bad-files": {
+			fields: map[string]string{"name": "foo"},
+			files: []testFile{
+				{field: "avatar", contentType: "image/png", contents: "png"},
+				{field: "avatar", contentType: "text/plain", contents: "definitely too large"},
+				{field: "banner", contentType: "text/plain", contents: "txt"},
+			},
+			opts: []FormOption{
+				RequireFormFields("name", "bio"),
+				AllowPartContentTypes("avatar", "image/png"),
+				LimitFileBytes(10),
+			},
+			expected: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     CodeOverflow,
+					Paths:    []Steps{FormFieldPath("avatar").AddStep(ArrayIndexStep(1))},
+					Summary:  "The file is too large.",
+					Detail:   "The file is 20 bytes, but can be at most 10 bytes.",
+				},
+				{
+					Severity: DiagnosticError,
+					Code:     CodeInvalidFormat,
+					Paths:    []Steps{FormFieldPath("avatar").AddStep(ArrayIndexStep(1))},
+					Summary:  "The file's media type isn't supported.",
+					Detail:   "Supported media types: image/png.",
+				},
+				{
+					Severity: DiagnosticError,
+					Code:     CodeMissing,
+					Paths:    []Steps{FormFieldPath("bio")},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			for field, value := range tc.fields {
+				if err := mw.WriteField(field, value); err != nil {
+					t.Fatalf("error writing field: %s", err)
+				}
+			}
+			for _, file := range tc.files {
+				h := textproto.MIMEHeader{}
+				h.Set("Content-Disposition", `form-data; name="`+file.field+`"; filename="file"`)
+				h.Set("Content-Type", file.contentType)
+				part, err := mw.CreatePart(h)
+				if err != nil {
+					t.Fatalf("error creating part: %s", err)
+				}
+				if _, err := part.Write([]byte(file.contents)); err != nil {
+					t.Fatalf("error writing part: %s", err)
+				}
+			}
+			if err := mw.Close(); err != nil {
+				t.Fatalf("error closing multipart writer: %s", err)
+			}
+			contentType := tc.contentType
+			if contentType == "" {
+				contentType = mw.FormDataContentType()
+			}
+			r := httptest.NewRequest(http.MethodPost, "/", &body)
+			r.Header.Set("Content-Type", contentType)
+			got := ParseMultipartForm(r, 1<<20, tc.opts...)
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
@@ -5,4 +5,5 @@ go 1.19
 require (
 	github.com/google/go-cmp v0.5.9
 	github.com/nsf/jsondiff v0.0.0-20210926074059-1e845ec5d249
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,265 @@
+package apidiags
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// DiagnosticTarget is implemented by pointers to error types that describe
+// a single Diagnostic, like the types generated by GoErrorTypes, so
+// errors.As can find them in an APIError.
+type DiagnosticTarget interface {
+	// FromDiagnostic sets the target to describe diag, returning true,
+	// or returns false if it can't describe diag.
+	FromDiagnostic(diag Diagnostic) bool
+}
+
+// As sets target to the first of the APIError's Diagnostics with a
+// Severity of DiagnosticError that it can describe, if target is a
+// DiagnosticTarget. It's used by errors.As.
+func (e *APIError) As(target any) bool {
+	t, ok := target.(DiagnosticTarget)
+	if !ok {
+		return false
+	}
+	for _, diag := range e.diags {
+		if diag.Severity == DiagnosticError && t.FromDiagnostic(diag) {
+			return true
+		}
+	}
+	return false
+}
+
+// goInitialisms are the words GoErrorTypes writes in upper case when they
+// appear in Codes, following Go's naming conventions.
+var goInitialisms = map[string]bool{
+	"api": true, "http": true, "id": true, "ip": true, "json": true,
+	"sql": true, "tls": true, "ttl": true, "uri": true, "url": true,
+}
+
+// goErrorType is a Code GoErrorTypes writes an error type for.
+type goErrorType struct {
+	CodeInfo
+	Name        string
+	Const       string
+	Doc         []string
+	RetryAfter  bool
+	RateLimit   bool
+	Bounds      bool
+	Deprecation bool
+}
+
+// GoErrorTypes returns the source of a Go package named pkg, for API SDKs,
+// with an error type for each Code in registry, so callers can use
+// errors.As to check for a Code instead of comparing strings:
+//
+//	var limited apierrors.QuotaExceeded
+//	if errors.As(err, &limited) {
+//		after, _ := limited.RetryAfter()
+//		// ...
+//	}
+//
+// Each type is named after its Code, like QuotaExceeded for
+// "quota_exceeded", holds the Diagnostic it describes, and implements
+// DiagnosticTarget, so errors.As finds it in the APIErrors returned by
+// ParseResponse. Each has methods returning its Code, Status, and whether
+// it's Retryable, as well as typed accessors for the metadata Diagnostics
+// with the Code carry:
+//
+//   - RetryAfter, for Codes that are Retryable or have a Status of 429
+//   - RateLimit, for Codes with a Status of 429
+//   - Bounds, for CodeInsufficient, CodeOverflow, CodeInvalidValue, and
+//     CodeInvalidFormat
+//   - Deprecation, for CodeDeprecated
+//
+// The package also has a constant for each Code, and a ForDiagnostic
+// function returning the error describing a Diagnostic. An error is
+// returned if pkg isn't a valid package name, or a Code can't be turned
+// into a Go identifier that's different from every other Code's.
+func GoErrorTypes(pkg string, registry *Registry) ([]byte, error) {
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("invalid package name %q", pkg)
+	}
+	infos := registry.Codes()
+	types := make([]goErrorType, 0, len(infos))
+	names := map[string]Code{"ForDiagnostic": ""}
+	var usesTime bool
+	for _, info := range infos {
+		name := goIdentifier(string(info.Code))
+		if name == "" {
+			return nil, fmt.Errorf("can't name an error type for code %q", info.Code)
+		}
+		typ := goErrorType{
+			CodeInfo:    info,
+			Name:        name,
+			Const:       "Code" + name,
+			Doc:         goDocLines(info),
+			RetryAfter:  info.Retryable || info.Status == http.StatusTooManyRequests,
+			RateLimit:   info.Status == http.StatusTooManyRequests,
+			Bounds:      info.Code == CodeInsufficient || info.Code == CodeOverflow || info.Code == CodeInvalidValue || info.Code == CodeInvalidFormat,
+			Deprecation: info.Code == CodeDeprecated,
+		}
+		usesTime = usesTime || typ.RetryAfter
+		for _, ident := range []string{typ.Name, typ.Const} {
+			if other, ok := names[ident]; ok {
+				return nil, fmt.Errorf("code %q would be named %s, like %q", info.Code, ident, other)
+			}
+			names[ident] = info.Code
+		}
+		types = append(types, typ)
+	}
+	var buf bytes.Buffer
+	err := goErrorsTemplate.Execute(&buf, map[string]any{"Package": pkg, "Types": types, "UsesTime": usesTime})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// goIdentifier returns an exported Go identifier for code, like
+// QuotaExceeded for "quota_exceeded", or an empty string if there isn't
+// one.
+func goIdentifier(code string) string {
+	words := strings.FieldsFunc(code, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var ident strings.Builder
+	for _, word := range words {
+		if goInitialisms[strings.ToLower(word)] {
+			ident.WriteString(strings.ToUpper(word))
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		ident.WriteString(string(runes))
+	}
+	name := ident.String()
+	if !token.IsIdentifier(name) || !token.IsExported(name) {
+		return ""
+	}
+	return name
+}
+
+// goDocLines returns the lines of the doc comment of the error type for
+// info.
+func goDocLines(info CodeInfo) []string {
+	lines := []string{fmt.Sprintf("%s is the error for Diagnostics with a Code of %q.", goIdentifier(string(info.Code)), info.Code)}
+	if info.Description != "" {
+		lines = append(lines, "")
+		lines = append(lines, strings.Split(info.Description, "\n")...)
+	}
+	if info.DocURL != "" {
+		lines = append(lines, "", "See "+info.DocURL)
+	}
+	return lines
+}
+
+var goErrorsTemplate = template.Must(template.New("goerrors").Funcs(template.FuncMap{"quote": strconv.Quote}).Parse(`// Code generated by impractical.co/apidiags. DO NOT EDIT.
+
+// Package {{.Package}} has an error type for each Code the API returns.
+package {{.Package}}
+
+import (
+{{- if .UsesTime}}
+	"time"
+{{end}}
+	"impractical.co/apidiags"
+)
+
+// The Codes the API returns.
+const (
+{{- range .Types}}
+	{{.Const}} apidiags.Code = {{quote (print .Code)}}
+{{- end}}
+)
+
+// ForDiagnostic returns the error describing diag, or nil if its Code
+// doesn't have an error type.
+func ForDiagnostic(diag apidiags.Diagnostic) error {
+	switch diag.Code {
+{{- range .Types}}
+	case {{.Const}}:
+		return {{.Name}}{Diagnostic: diag}
+{{- end}}
+	}
+	return nil
+}
+{{range .Types}}
+{{range .Doc}}//{{if .}} {{.}}{{end}}
+{{end -}}
+type {{.Name}} struct {
+	Diagnostic apidiags.Diagnostic
+}
+
+func (e {{.Name}}) Error() string {
+	return string({{.Const}}) + ": " + e.Diagnostic.Message()
+}
+
+// FromDiagnostic sets e to describe diag, if it has a Code of
+// {{.Const}}. It implements apidiags.DiagnosticTarget.
+func (e *{{.Name}}) FromDiagnostic(diag apidiags.Diagnostic) bool {
+	if diag.Code != {{.Const}} {
+		return false
+	}
+	e.Diagnostic = diag
+	return true
+}
+
+// Code returns {{.Const}}.
+func ({{.Name}}) Code() apidiags.Code {
+	return {{.Const}}
+}
+
+{{- if .Status}}
+// Status returns the HTTP status of responses failing with the error.
+{{- else}}
+// Status returns 0, as {{.Const}} isn't used for errors.
+{{- end}}
+func ({{.Name}}) Status() int {
+	return {{.Status}}
+}
+
+// Retryable returns true if requests failing with the error may succeed if
+// they're retried.
+func ({{.Name}}) Retryable() bool {
+	return {{.Retryable}}
+}
+{{- if .RetryAfter}}
+
+// RetryAfter returns how long to wait before retrying the request, if the
+// API said.
+func (e {{.Name}}) RetryAfter() (time.Duration, error) {
+	return e.Diagnostic.RetryAfter()
+}
+{{- end}}
+{{- if .RateLimit}}
+
+// RateLimit returns the rate limit the request was subject to, if the API
+// said.
+func (e {{.Name}}) RateLimit() (apidiags.RateLimit, bool, error) {
+	return apidiags.RateLimitFrom(e.Diagnostic)
+}
+{{- end}}
+{{- if .Bounds}}
+
+// Bounds returns the limits the value had to stay within, if the API said.
+func (e {{.Name}}) Bounds() (apidiags.Bounds, bool, error) {
+	return apidiags.BoundsFrom(e.Diagnostic)
+}
+{{- end}}
+{{- if .Deprecation}}
+
+// Deprecation returns when what the request used was deprecated, and when
+// it will stop working, if the API said.
+func (e {{.Name}}) Deprecation() (apidiags.Deprecation, bool, error) {
+	return apidiags.DeprecationFrom(e.Diagnostic)
+}
+{{- end}}
+{{end}}`))
@@ -0,0 +1,276 @@
+package apidiags
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGoErrorTypes(t *testing.T) {
+	t.Parallel()
+
+	expected := `// Code generated by impractical.co/apidiags. DO NOT EDIT.
+
+// Package apierrors has an error type for each Code the API returns.
+package apierrors
+
+import (
+	"time"
+
+	"impractical.co/apidiags"
+)
+
+// The Codes the API returns.
+const (
+	CodeDeprecated    apidiags.Code = "deprecated"
+	CodeQuotaExceeded apidiags.Code = "quota_exceeded"
+)
+
+// ForDiagnostic returns the error describing diag, or nil if its Code
+// doesn't have an error type.
+func ForDiagnostic(diag apidiags.Diagnostic) error {
+	switch diag.Code {
+	case CodeDeprecated:
+		return Deprecated{Diagnostic: diag}
+	case CodeQuotaExceeded:
+		return QuotaExceeded{Diagnostic: diag}
+	}
+	return nil
+}
+
+// Deprecated is the error for Diagnostics with a Code of "deprecated".
+//
+// The field is deprecated.
+type Deprecated struct {
+	Diagnostic apidiags.Diagnostic
+}
+
+func (e Deprecated) Error() string {
+	return string(CodeDeprecated) + ": " + e.Diagnostic.Message()
+}
+
+// FromDiagnostic sets e to describe diag, if it has a Code of
+// CodeDeprecated. It implements apidiags.DiagnosticTarget.
+func (e *Deprecated) FromDiagnostic(diag apidiags.Diagnostic) bool {
+	if diag.Code != CodeDeprecated {
+		return false
+	}
+	e.Diagnostic = diag
+	return true
+}
+
+// Code returns CodeDeprecated.
+func (Deprecated) Code() apidiags.Code {
+	return CodeDeprecated
+}
+
+// Status returns 0, as CodeDeprecated isn't used for errors.
+func (Deprecated) Status() int {
+	return 0
+}
+
+// Retryable returns true if requests failing with the error may succeed if
+// they're retried.
+func (Deprecated) Retryable() bool {
+	return false
+}
+
+// Deprecation returns when what the request used was deprecated, and when
+// it will stop working, if the API said.
+func (e Deprecated) Deprecation() (apidiags.Deprecation, bool, error) {
+	return apidiags.DeprecationFrom(e.Diagnostic)
+}
+
+// QuotaExceeded is the error for Diagnostics with a Code of "quota_exceeded".
+//
+// The account is out of quota.
+//
+// See https://example.com/docs/quota
+type QuotaExceeded struct {
+	Diagnostic apidiags.Diagnostic
+}
+
+func (e QuotaExceeded) Error() string {
+	return string(CodeQuotaExceeded) + ": " + e.Diagnostic.Message()
+}
+
+// FromDiagnostic sets e to describe diag, if it has a Code of
+// CodeQuotaExceeded. It implements apidiags.DiagnosticTarget.
+func (e *QuotaExceeded) FromDiagnostic(diag apidiags.Diagnostic) bool {
+	if diag.Code != CodeQuotaExceeded {
+		return false
+	}
+	e.Diagnostic = diag
+	return true
+}
+
+// Code returns CodeQuotaExceeded.
+func (QuotaExceeded) Code() apidiags.Code {
+	return CodeQuotaExceeded
+}
+
+// Status returns the HTTP status of responses failing with the error.
+func (QuotaExceeded) Status() int {
+	return 429
+}
+
+// Retryable returns true if requests failing with the error may succeed if
+// they're retried.
+func (QuotaExceeded) Retryable() bool {
+	return true
+}
+
+// RetryAfter returns how long to wait before retrying the request, if the
+// API said.
+func (e QuotaExceeded) RetryAfter() (time.Duration, error) {
+	return e.Diagnostic.RetryAfter()
+}
+
+// RateLimit returns the rate limit the request was subject to, if the API
+// said.
+func (e QuotaExceeded) RateLimit() (apidiags.RateLimit, bool, error) {
+	return apidiags.RateLimitFrom(e.Diagnostic)
+}
+`
+	registry := NewRegistry(
+		CodeInfo{
+			Code:        "quota_exceeded",
+			Description: "The account is out of quota.",
+			Status:      http.StatusTooManyRequests,
+			Retryable:   true,
+			DocURL:      "https://example.com/docs/quota",
+		},
+		CodeInfo{Code: CodeDeprecated, Description: "The field is deprecated."},
+	)
+	result, err := GoErrorTypes("apierrors", registry)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(expected, string(result)); diff != "" {
+		t.Errorf("unexpected source (-wanted, +got): %s", diff)
+	}
+}
+
+func TestGoErrorTypesBuiltin(t *testing.T) {
+	t.Parallel()
+
+	result, err := GoErrorTypes("apierrors", NewRegistry(BuiltinCodes()...))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, expected := range []string{
+		"func (e ActOfGod) RetryAfter() (time.Duration, error) {",
+		"func (e InvalidValue) Bounds() (apidiags.Bounds, bool, error) {",
+		"func (Missing) Status() int {\n\treturn 400\n}",
+	} {
+		if !strings.Contains(string(result), expected) {
+			t.Errorf("expected source to contain %q", expected)
+		}
+	}
+}
+
+func TestGoErrorTypesErrors(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		pkg      string
+		infos    []CodeInfo
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"invalid-package": {
+			pkg:      "api-errors",
+			expected: `invalid package name "api-errors"`,
+		},
+		"unnameable-code": {
+			pkg:      "apierrors",
+			infos:    []CodeInfo{{Code: "404"}},
+			expected: `can't name an error type for code "404"`,
+		},
+		"colliding-codes": {
+			pkg:      "apierrors",
+			infos:    []CodeInfo{{Code: "rate-limited"}, {Code: "rate_limited"}},
+			expected: `code "rate_limited" would be named RateLimited, like "rate-limited"`,
+		},
+		"reserved-name": {
+			pkg:      "apierrors",
+			infos:    []CodeInfo{{Code: "for_diagnostic"}},
+			expected: `code "for_diagnostic" would be named ForDiagnostic, like ""`,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := GoErrorTypes(tc.pkg, NewRegistry(tc.infos...))
+			if err == nil || err.Error() != tc.expected {
+				t.Errorf("expected error %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestGoIdentifier(t *testing.T) {
+	t.Parallel()
+
+	for code, expected := range map[string]string{
+		"quota_exceeded":  "QuotaExceeded",
+		"user-id.taken":   "UserIDTaken",
+		"invalidJSON":     "InvalidJSON",
+		"http_url_denied": "HTTPURLDenied",
+		"état_invalide":   "ÉtatInvalide",
+		"2fa_required":    "",
+		"":                "",
+	} {
+		if result := goIdentifier(code); result != expected {
+			t.Errorf("expected goIdentifier(%q) to be %q, got %q", code, expected, result)
+		}
+	}
+}
+
+// rateLimitedError is a DiagnosticTarget, like the types generated by
+// GoErrorTypes.
+type rateLimitedError struct {
+	diag Diagnostic
+}
+
+func (e rateLimitedError) Error() string {
+	return e.diag.Message()
+}
+
+func (e *rateLimitedError) FromDiagnostic(diag Diagnostic) bool {
+	if diag.Code != "rate_limited" {
+		return false
+	}
+	e.diag = diag
+	return true
+}
+
+func TestAPIErrorAs(t *testing.T) {
+	t.Parallel()
+
+	apiErr := &APIError{StatusCode: http.StatusTooManyRequests, diags: Diagnostics{
+		{Severity: DiagnosticWarning, Code: "rate_limited", Summary: "Slow down soon."},
+		{Severity: DiagnosticError, Code: CodeActOfGod},
+		{Severity: DiagnosticError, Code: "rate_limited", Summary: "Slow down."},
+	}}
+	var target rateLimitedError
+	if !errors.As(fmt.Errorf("error listing widgets: %w", apiErr), &target) {
+		t.Fatal("expected errors.As to find a rateLimitedError")
+	}
+	if target.diag.Summary != "Slow down." {
+		t.Errorf("expected the first error with the code, got %+v", target.diag)
+	}
+	apiErr.diags = apiErr.diags[:2]
+	if errors.As(apiErr, &target) {
+		t.Error("expected errors.As not to match a warning")
+	}
+}
@@ -0,0 +1,162 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// GraphQLError is an error in a GraphQL response, as described by the GraphQL
+// specification.
+type GraphQLError struct {
+	Message    string                     `json:"message"`
+	Locations  []GraphQLLocation          `json:"locations,omitempty"`
+	Path       []any                      `json:"path,omitempty"`
+	Extensions map[string]json.RawMessage `json:"extensions,omitempty"`
+}
+
+// GraphQLLocation is a location in a GraphQL document, used to indicate which
+// part of the document a GraphQLError is about.
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// GraphQLErrorsFromDiagnostics converts diags into GraphQL errors, one per
+// Diagnostic.
+//
+// The Diagnostic's Message becomes the message, and its first Path becomes the
+// path, with the leading BodyStep dropped, ObjectPropertySteps becoming
+// strings, and ArrayIndexSteps becoming integers. The Code, Severity, and
+// Detail are stored in extensions.code, extensions.severity, and
+// extensions.detail. When the Paths can't be represented exactly by the path,
+// the full Paths are stored in extensions.paths. DiagnosticsFromGraphQLErrors
+// reads those members back.
+func GraphQLErrorsFromDiagnostics(diags Diagnostics) ([]GraphQLError, error) {
+	results := make([]GraphQLError, 0, len(diags))
+	for pos, diag := range diags {
+		diag = diag.RenderMessage(nil)
+		result := GraphQLError{
+			Message:    diag.Message(),
+			Extensions: map[string]json.RawMessage{},
+		}
+		extensions := map[string]any{
+			"code":     diag.Code,
+			"severity": diag.Severity,
+		}
+		if diag.Detail != "" {
+			extensions["detail"] = diag.Detail
+		}
+		var exact bool
+		if len(diag.Paths) > 0 {
+			result.Path, exact = graphQLPathFromSteps(diag.Paths[0])
+		}
+		if len(diag.Paths) > 1 || (len(diag.Paths) == 1 && !exact) {
+			extensions["paths"] = diag.Paths
+		}
+		for key, value := range extensions {
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("error encoding %s of diagnostic %d: %w", key, pos, err)
+			}
+			result.Extensions[key] = encoded
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// graphQLPathFromSteps returns the GraphQL path that best represents steps,
+// and whether it represents steps exactly.
+func graphQLPathFromSteps(steps Steps) ([]any, bool) {
+	if len(steps) < 1 {
+		return nil, false
+	}
+	if _, ok := steps[0].(BodyStep); !ok {
+		return nil, false
+	}
+	path := make([]any, 0, len(steps)-1)
+	for _, step := range steps[1:] {
+		switch value := step.(type) {
+		case ObjectPropertyStep:
+			path = append(path, string(value))
+		case ArrayIndexStep:
+			path = append(path, int64(value))
+		default:
+			return path, false
+		}
+	}
+	return path, true
+}
+
+// DiagnosticsFromGraphQLErrors converts GraphQL errors into Diagnostics,
+// reversing GraphQLErrorsFromDiagnostics.
+//
+// Errors without extensions.severity are treated as having a Severity of
+// DiagnosticError. Errors without extensions.paths use their path, if they
+// have one, as their only Path, rooted at the body. The message is used as
+// the Summary, unless it's just the Code.
+func DiagnosticsFromGraphQLErrors(errs []GraphQLError) (Diagnostics, error) {
+	results := make(Diagnostics, 0, len(errs))
+	for pos, gqlErr := range errs {
+		result := Diagnostic{
+			Severity: DiagnosticError,
+		}
+		for key, target := range map[string]any{
+			"code":     &result.Code,
+			"severity": &result.Severity,
+			"detail":   &result.Detail,
+			"paths":    &result.Paths,
+		} {
+			value, ok := gqlErr.Extensions[key]
+			if !ok {
+				continue
+			}
+			err := json.Unmarshal(value, target)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing %s of error %d: %w", key, pos, err)
+			}
+		}
+		if gqlErr.Message != string(result.Code) {
+			result.Summary = gqlErr.Message
+		}
+		if _, ok := gqlErr.Extensions["paths"]; !ok && gqlErr.Path != nil {
+			path, err := stepsFromGraphQLPath(gqlErr.Path)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing path of error %d: %w", pos, err)
+			}
+			result.Paths = []Steps{path}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func stepsFromGraphQLPath(path []any) (Steps, error) {
+	results := make(Steps, 0, len(path)+1)
+	results = append(results, BodyStep{})
+	for pos, segment := range path {
+		switch value := segment.(type) {
+		case string:
+			results = append(results, ObjectPropertyStep(value))
+		case int:
+			results = append(results, ArrayIndexStep(value))
+		case int64:
+			results = append(results, ArrayIndexStep(value))
+		case json.Number:
+			idx, err := value.Int64()
+			if err != nil {
+				return nil, fmt.Errorf("segment %d: %w", pos, err)
+			}
+			results = append(results, ArrayIndexStep(idx))
+		case float64:
+			if value != math.Trunc(value) {
+				return nil, fmt.Errorf("segment %d: %v is not a whole number", pos, value)
+			}
+			results = append(results, ArrayIndexStep(value))
+		default:
+			return nil, fmt.Errorf("segment %d: unexpected type %T", pos, segment)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,151 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nsf/jsondiff"
+)
+
+func TestGraphQLErrorsFromDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags    Diagnostics
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"empty": {
+			expected: `[]`,
+		},
+		"body-path": {
+			diags: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     CodeNotFound,
+					Paths:    []Steps{BodyPath().AddSteps(ObjectPropertyStep("hero"), ObjectPropertyStep("friends"), ArrayIndexStep(1))},
+					Summary:  "Friend not found.",
+					Detail:   "The friend with ID 1003 was deleted.",
+				},
+			},
+			expected: `[{"message": "Friend not found.", "path": ["hero", "friends", 1], "extensions": {"code": "not_found", "severity": "error", "detail": "The friend with ID 1003 was deleted."}}]`,
+		},
+		"no-summary-no-path": {
+			diags: Diagnostics{
+				{Severity: DiagnosticWarning, Code: CodeDeprecated},
+			},
+			expected: `[{"message": "deprecated", "extensions": {"code": "deprecated", "severity": "warning"}}]`,
+		},
+		"header-path": {
+			diags: Diagnostics{
+				{Severity: DiagnosticError, Code: CodeAccessDenied, Paths: []Steps{HeaderPath("Authorization")}},
+			},
+			expected: `[{"message": "access_denied", "extensions": {"code": "access_denied", "severity": "error", "paths": [[{"kind": "header", "value": "Authorization"}]]}}]`,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			errs, err := GraphQLErrorsFromDiagnostics(tc.diags)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			result, err := json.Marshal(errs)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			opts := jsondiff.DefaultConsoleOptions()
+			match, diff := jsondiff.Compare([]byte(tc.expected), result, &opts)
+			if match != jsondiff.FullMatch {
+				t.Errorf("Unexpected result: %s", diff)
+			}
+			if match > jsondiff.NoMatch {
+				t.Logf("first argument: %s", tc.expected)
+				t.Logf("second argument: %s", result)
+			}
+
+			var decoded []GraphQLError
+			err = json.Unmarshal(result, &decoded)
+			if err != nil {
+				t.Fatalf("unexpected error decoding errors: %s", err)
+			}
+			roundTripped, err := DiagnosticsFromGraphQLErrors(decoded)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			expected := tc.diags
+			if expected == nil {
+				expected = Diagnostics{}
+			}
+			if diff := cmp.Diff(expected, roundTripped); diff != "" {
+				t.Fatalf("unexpected round trip results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestDiagnosticsFromGraphQLErrors(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    string
+		expected Diagnostics
+		wantErr  bool
+	}
+
+	cases := map[string]testCase{
+		"foreign-errors": {
+			input: `[{"message": "Name for character with ID 1002 could not be fetched.", "locations": [{"line": 6, "column": 7}], "path": ["hero", "heroFriends", 1, "name"]}]`,
+			expected: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Paths: []Steps{BodyPath().AddSteps(
+						ObjectPropertyStep("hero"),
+						ObjectPropertyStep("heroFriends"),
+						ArrayIndexStep(1),
+						ObjectPropertyStep("name"),
+					)},
+					Summary: "Name for character with ID 1002 could not be fetched.",
+				},
+			},
+		},
+		"fractional-index": {
+			input:   `[{"message": "oops", "path": ["hero", 1.5]}]`,
+			wantErr: true,
+		},
+		"invalid-code": {
+			input:   `[{"message": "oops", "extensions": {"code": 1}}]`,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var errs []GraphQLError
+			err := json.Unmarshal([]byte(tc.input), &errs)
+			if err != nil {
+				t.Fatalf("unexpected error decoding input: %s", err)
+			}
+			result, err := DiagnosticsFromGraphQLErrors(errs)
+			if err != nil && !tc.wantErr {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && tc.wantErr {
+				t.Fatalf("expected error, got %+v", result)
+			}
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
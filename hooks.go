@@ -0,0 +1,135 @@
+package apidiags
+
+import (
+	"context"
+	"sync"
+)
+
+// HookEvent describes what's happening to a Diagnostic when a
+// DiagnosticHook is called.
+type HookEvent string
+
+const (
+	// HookAdded is the HookEvent used when a Diagnostic is added to a
+	// context's collected Diagnostics, by AddDiagnostics.
+	HookAdded HookEvent = "added"
+
+	// HookWritten is the HookEvent used when a Diagnostic is about to be
+	// written in a response, by WriteHTTP, WriteEnvelope, WriteBatch, or
+	// InjectDiagnostics.
+	HookWritten HookEvent = "written"
+)
+
+// DiagnosticHook is called with each Diagnostic as it's added or written,
+// and returns the Diagnostic to use in its place, so hooks can redact or
+// annotate Diagnostics as well as observe them. Hooks that only observe
+// should return diag unchanged.
+//
+// ctx is the context passed to AddDiagnostics, or the context set with
+// WithContext when writing, falling back to the context of the request
+// passed to WithWarningSuppression, then context.Background.
+// Hooks must be safe for concurrent use.
+type DiagnosticHook func(ctx context.Context, event HookEvent, diag Diagnostic) Diagnostic
+
+type hookEntry struct {
+	hook DiagnosticHook
+}
+
+// globalHooks are the DiagnosticHooks registered with OnDiagnostic.
+var globalHooks struct {
+	mu      sync.RWMutex
+	entries []*hookEntry
+}
+
+// OnDiagnostic registers hook to be called for every Diagnostic added or
+// written anywhere in the program, and returns a function that unregisters
+// it. Hooks are called in the order they were registered, each receiving
+// the Diagnostic returned by the one before it, and before any hooks
+// registered with OnCollectedDiagnostic.
+func OnDiagnostic(hook DiagnosticHook) func() {
+	entry := &hookEntry{hook: hook}
+	globalHooks.mu.Lock()
+	defer globalHooks.mu.Unlock()
+	globalHooks.entries = append(globalHooks.entries, entry)
+	return func() {
+		globalHooks.mu.Lock()
+		defer globalHooks.mu.Unlock()
+		for pos, existing := range globalHooks.entries {
+			if existing == entry {
+				globalHooks.entries = append(globalHooks.entries[:pos:pos], globalHooks.entries[pos+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// OnCollectedDiagnostic registers hook to be called for the Diagnostics
+// added to ctx's collected Diagnostics, and those written with
+// WithContext(ctx), returning false if ctx isn't collecting Diagnostics.
+// See CollectDiagnostics.
+func OnCollectedDiagnostic(ctx context.Context, hook DiagnosticHook) bool {
+	c, ok := ctx.Value(collectorKey{}).(*collector)
+	if !ok {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks = append(c.hooks, hook)
+	return true
+}
+
+// WithContext sets the context passed to DiagnosticHooks when the
+// Diagnostics are written. Hooks registered with OnCollectedDiagnostic on
+// ctx are called, too.
+func WithContext(ctx context.Context) HTTPOption {
+	return func(c *httpConfig) {
+		c.ctx = ctx
+	}
+}
+
+// runHooks returns diags after passing each of them through the global
+// DiagnosticHooks and those of ctx's collector. If there are no hooks,
+// diags is returned as-is; otherwise, a copy is returned.
+func runHooks(ctx context.Context, event HookEvent, diags Diagnostics) Diagnostics {
+	if len(diags) < 1 {
+		return diags
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	globalHooks.mu.RLock()
+	hooks := make([]DiagnosticHook, 0, len(globalHooks.entries))
+	for _, entry := range globalHooks.entries {
+		hooks = append(hooks, entry.hook)
+	}
+	globalHooks.mu.RUnlock()
+	if c, ok := ctx.Value(collectorKey{}).(*collector); ok {
+		c.mu.Lock()
+		hooks = append(hooks, c.hooks...)
+		c.mu.Unlock()
+	}
+	if len(hooks) < 1 {
+		return diags
+	}
+	results := make(Diagnostics, 0, len(diags))
+	for _, diag := range diags {
+		for _, hook := range hooks {
+			diag = hook(ctx, event, diag)
+		}
+		results = append(results, diag)
+	}
+	return results
+}
+
+// written returns diags after running the DiagnosticHooks for writing
+// them, using the context set by WithContext, or the context of the request
+// set by WithWarningSuppression if there isn't one, including or stripping
+// their DebugInfo, and localizing them using the Catalog set by
+// WithCatalog.
+func (c httpConfig) written(diags Diagnostics) Diagnostics {
+	ctx := c.ctx
+	if ctx == nil && c.request != nil {
+		ctx = c.request.Context()
+	}
+	return c.localize(c.debugInfo(runHooks(ctx, HookWritten, diags)))
+}
@@ -0,0 +1,113 @@
+package apidiags
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type hookTestKey struct{}
+
+// hookRecorder records the Diagnostics passed to its hook, and redacts
+// their Details.
+type hookRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (h *hookRecorder) hook(name string) DiagnosticHook {
+	return func(ctx context.Context, event HookEvent, diag Diagnostic) Diagnostic {
+		// global hooks see every Diagnostic in the program, including
+		// those of tests running in parallel
+		if ctx.Value(hookTestKey{}) != h {
+			return diag
+		}
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.events = append(h.events, name+" "+string(event)+" "+string(diag.Code))
+		diag.Detail = strings.ToUpper(diag.Detail)
+		return diag
+	}
+}
+
+func TestHooks(t *testing.T) {
+	t.Parallel()
+
+	recorder := &hookRecorder{}
+	unregister := OnDiagnostic(recorder.hook("global"))
+	defer unregister()
+
+	ctx := CollectDiagnostics(context.WithValue(context.Background(), hookTestKey{}, recorder))
+	if !OnCollectedDiagnostic(ctx, recorder.hook("collector")) {
+		t.Fatal("expected to register a collector hook")
+	}
+	AddDiagnostics(ctx, Diagnostic{Severity: DiagnosticWarning, Code: CodeDeprecated, Detail: "use v2"})
+	expected := Diagnostics{{Severity: DiagnosticWarning, Code: CodeDeprecated, Detail: "USE V2"}}
+	if diff := cmp.Diff(expected, CollectedDiagnostics(ctx)); diff != "" {
+		t.Errorf("unexpected collected diff (-wanted, +got): %s", diff)
+	}
+
+	w := httptest.NewRecorder()
+	err := WriteHTTP(w, Diagnostics{{Severity: DiagnosticError, Code: CodeMissing, Detail: "name"}}, WithContext(ctx))
+	if err != nil {
+		t.Fatalf("error writing response: %s", err)
+	}
+	if !strings.Contains(w.Body.String(), `"detail":"NAME"`) {
+		t.Errorf("expected written Detail to be redacted, got %s", w.Body.String())
+	}
+
+	unregister()
+	r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	err = WriteEnvelope(httptest.NewRecorder(), NewEnvelope("ok", Diagnostics{{Severity: DiagnosticWarning, Code: CodeConflict}}),
+		WithWarningSuppression(r))
+	if err != nil {
+		t.Fatalf("error writing envelope: %s", err)
+	}
+
+	expectedEvents := []string{
+		"global added deprecated",
+		"collector added deprecated",
+		"global written missing",
+		"collector written missing",
+		"collector written conflict",
+	}
+	if diff := cmp.Diff(expectedEvents, recorder.events); diff != "" {
+		t.Errorf("unexpected events diff (-wanted, +got): %s", diff)
+	}
+	if OnCollectedDiagnostic(context.Background(), recorder.hook("collector")) {
+		t.Error("expected registering a hook without a collector to fail")
+	}
+}
+
+func TestHooksInjectDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	recorder := &hookRecorder{}
+	handler := InjectDiagnostics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		OnCollectedDiagnostic(ctx, recorder.hook("collector"))
+		AddDiagnostics(ctx, Diagnostic{Severity: DiagnosticWarning, Code: CodeDeprecated, Detail: "use v2"})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":"ok"}`))
+	}))
+	w := httptest.NewRecorder()
+	ctx := context.WithValue(context.Background(), hookTestKey{}, recorder)
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	expected := `{"data":"ok","diagnostics":[{"severity":"warning","code":"deprecated","detail":"USE V2"}]}`
+	if got := w.Body.String(); got != expected {
+		t.Errorf("expected body %s, got %s", expected, got)
+	}
+	expectedEvents := []string{
+		"collector added deprecated",
+		"collector written deprecated",
+	}
+	if diff := cmp.Diff(expectedEvents, recorder.events); diff != "" {
+		t.Errorf("unexpected events diff (-wanted, +got): %s", diff)
+	}
+}
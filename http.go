@@ -0,0 +1,283 @@
+package apidiags
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// WarningsHeader is the response header SetWarningsHeader writes warnings
+// to, so clients that don't parse the bodies of successful responses can
+// still see them.
+const WarningsHeader = "X-API-Warnings"
+
+// StatusMapping maps each Code to the HTTP status code used for responses
+// failing because of it.
+type StatusMapping map[Code]int
+
+// DefaultStatusMapping returns the StatusMapping used by StatusForDiagnostics
+// and WriteHTTP when no other StatusMapping is provided. It covers every Code
+// defined by this package except CodeDeprecated, which describes warnings
+// rather than errors. The returned StatusMapping is a copy, and can be
+// modified freely.
+func DefaultStatusMapping() StatusMapping {
+	return StatusMapping{
+		CodeAccessDenied:  http.StatusForbidden,
+		CodeInsufficient:  http.StatusBadRequest,
+		CodeOverflow:      http.StatusUnprocessableEntity,
+		CodeInvalidValue:  http.StatusBadRequest,
+		CodeInvalidFormat: http.StatusBadRequest,
+		CodeMissing:       http.StatusBadRequest,
+		CodeNotFound:      http.StatusNotFound,
+		CodeConflict:      http.StatusConflict,
+		CodeActOfGod:      http.StatusServiceUnavailable,
+	}
+}
+
+// With returns a copy of m with the entries of overrides added to it,
+// replacing any entries m already has for the same Codes. This is useful for
+// services that want to change the status codes of a few Codes, or add
+// their own Codes, while keeping the rest of DefaultStatusMapping:
+//
+//	mapping := apidiags.DefaultStatusMapping().With(apidiags.StatusMapping{
+//		apidiags.CodeOverflow: http.StatusRequestEntityTooLarge,
+//		"quota_exceeded":      http.StatusTooManyRequests,
+//	})
+func (m StatusMapping) With(overrides StatusMapping) StatusMapping {
+	result := make(StatusMapping, len(m)+len(overrides))
+	for code, status := range m {
+		result[code] = status
+	}
+	for code, status := range overrides {
+		result[code] = status
+	}
+	return result
+}
+
+// Status returns the HTTP status code for a response containing diags: the
+// highest status code m maps the Code of any Diagnostic with a Severity of
+// DiagnosticError to, so the result doesn't depend on the order of diags,
+// and server errors outrank client errors. Codes that aren't in m use
+// http.StatusBadRequest. If there are no errors, http.StatusOK is returned.
+func (m StatusMapping) Status(diags Diagnostics) int {
+	result := http.StatusOK
+	for _, diag := range diags {
+		if diag.Severity != DiagnosticError {
+			continue
+		}
+		status, ok := m[diag.Code]
+		if !ok {
+			status = http.StatusBadRequest
+		}
+		if result == http.StatusOK || status > result {
+			result = status
+		}
+	}
+	return result
+}
+
+var defaultStatusMapping = DefaultStatusMapping()
+
+// HTTPOption configures how WriteHTTP writes a response.
+type HTTPOption func(*httpConfig)
+
+type httpConfig struct {
+	status      int
+	mapping     StatusMapping
+	problemType string
+	instance    string
+	canonical   bool
+	warnings    bool
+	request     *http.Request
+	metrics     Metrics
+	endpoint    string
+	ctx         context.Context
+	debug       bool
+	auditor     *Auditor
+	auditReq    *http.Request
+	catalog     *Catalog
+	langs       []string
+}
+
+// WithStatus makes WriteHTTP use status as the response's status code,
+// instead of choosing one based on the Diagnostics.
+func WithStatus(status int) HTTPOption {
+	return func(c *httpConfig) {
+		c.status = status
+	}
+}
+
+// WithStatusMapping makes WriteHTTP use mapping to choose the response's
+// status code, instead of DefaultStatusMapping.
+func WithStatusMapping(mapping StatusMapping) HTTPOption {
+	return func(c *httpConfig) {
+		c.mapping = mapping
+	}
+}
+
+// WithProblemType sets the type of the Problem written by WriteHTTP, a URI
+// reference identifying the problem type.
+func WithProblemType(problemType string) HTTPOption {
+	return func(c *httpConfig) {
+		c.problemType = problemType
+	}
+}
+
+// WithInstance sets the instance of the Problem written by WriteHTTP, a URI
+// reference identifying this specific occurrence of the problem.
+func WithInstance(instance string) HTTPOption {
+	return func(c *httpConfig) {
+		c.instance = instance
+	}
+}
+
+// WithCanonicalJSON makes WriteHTTP encode the Problem using
+// MarshalCanonicalJSON, so the same Diagnostics always produce the same
+// response body.
+func WithCanonicalJSON() HTTPOption {
+	return func(c *httpConfig) {
+		c.canonical = true
+	}
+}
+
+// WithWarningsHeader makes WriteHTTP also write the warnings in the
+// Diagnostics to the WarningsHeader, using SetWarningsHeader.
+func WithWarningsHeader() HTTPOption {
+	return func(c *httpConfig) {
+		c.warnings = true
+	}
+}
+
+// SetWarningsHeader sets the WarningsHeader in h to the Diagnostics in diags
+// with a Severity of DiagnosticWarning, encoded as JSON and then as
+// unpadded, URL-safe base64, so the header is compact and safe to send
+// regardless of the warnings' contents. If diags has no warnings, the
+// WarningsHeader is removed from h. WarningsFromHeader decodes the header.
+//
+// Servers and proxies limit the size of headers, so the WarningsHeader is
+// best kept to a handful of short warnings, like deprecation notices.
+func SetWarningsHeader(h http.Header, diags Diagnostics) error {
+	var warnings Diagnostics
+	for _, diag := range diags {
+		if diag.Severity == DiagnosticWarning {
+			warnings = append(warnings, diag)
+		}
+	}
+	if len(warnings) < 1 {
+		h.Del(WarningsHeader)
+		return nil
+	}
+	encoded, err := json.Marshal(warnings)
+	if err != nil {
+		return err
+	}
+	h.Set(WarningsHeader, base64.RawURLEncoding.EncodeToString(encoded))
+	return nil
+}
+
+// WarningsFromHeader decodes the Diagnostics in the WarningsHeader of h, as
+// encoded by SetWarningsHeader. If h has no WarningsHeader, no Diagnostics
+// are returned.
+func WarningsFromHeader(h http.Header) (Diagnostics, error) {
+	value := h.Get(WarningsHeader)
+	if value == "" {
+		return nil, nil
+	}
+	encoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s header: %w", WarningsHeader, err)
+	}
+	var results Diagnostics
+	err = json.Unmarshal(encoded, &results)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s header: %w", WarningsHeader, err)
+	}
+	return results, nil
+}
+
+// StatusForDiagnostics returns the HTTP status code for a response
+// containing diags, using DefaultStatusMapping. See StatusMapping.Status.
+func StatusForDiagnostics(diags Diagnostics) int {
+	return defaultStatusMapping.Status(diags)
+}
+
+// WriteHTTP writes diags to w as an application/problem+json response,
+// using NewProblem. Unless overridden by WithStatus, the status code is
+// chosen by StatusForDiagnostics, or the StatusMapping passed to
+// WithStatusMapping, so Diagnostics without errors result in a
+// successful response carrying only warnings. Deprecated Diagnostics are
+// also described by headers, using SetDeprecationHeaders, as are errors the
+// caller should retry later, using SetRetryAfterHeader.
+func WriteHTTP(w http.ResponseWriter, diags Diagnostics, opts ...HTTPOption) error {
+	var cfg httpConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	diags = cfg.written(cfg.filter(diags))
+	err := setDiagnosticHeaders(w.Header(), diags, cfg)
+	if err != nil {
+		return err
+	}
+	cfg.observe(diags)
+	problem := NewProblem(cfg.statusFor(diags), diags)
+	problem.Type = cfg.problemType
+	problem.Instance = cfg.instance
+	if !cfg.canonical {
+		return WriteProblem(w, problem)
+	}
+	body, err := MarshalCanonicalJSON(problem)
+	if err != nil {
+		return err
+	}
+	return writeProblemBody(w, problem.Status, body)
+}
+
+// statusFor returns the HTTP status code for a response containing diags:
+// the status set by WithStatus, if any, or the one chosen by the configured
+// StatusMapping.
+func (c httpConfig) statusFor(diags Diagnostics) int {
+	if c.status != 0 {
+		return c.status
+	}
+	if c.mapping != nil {
+		return c.mapping.Status(diags)
+	}
+	return defaultStatusMapping.Status(diags)
+}
+
+// filter returns diags without any Diagnostics the configuration says to
+// leave out of the response.
+func (c httpConfig) filter(diags Diagnostics) Diagnostics {
+	if c.request == nil {
+		return diags
+	}
+	return FilterSuppressedWarnings(c.request, diags)
+}
+
+// setDiagnosticHeaders sets the headers describing diags in h: the
+// deprecation and Retry-After headers, and the WarningsHeader if cfg calls
+// for it.
+func setDiagnosticHeaders(h http.Header, diags Diagnostics, cfg httpConfig) error {
+	if cfg.warnings {
+		err := SetWarningsHeader(h, diags)
+		if err != nil {
+			return err
+		}
+	}
+	err := SetDeprecationHeaders(h, diags)
+	if err != nil {
+		return err
+	}
+	return SetRetryAfterHeader(h, diags)
+}
+
+// writeProblemBody writes body to w as an application/problem+json response
+// with the given status code.
+func writeProblemBody(w http.ResponseWriter, status int, body json.RawMessage) error {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(status)
+	_, err := w.Write(body)
+	return err
+}
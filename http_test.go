@@ -0,0 +1,301 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStatusForDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags    Diagnostics
+		expected int
+	}
+
+	cases := map[string]testCase{
+		"no-diags": {
+			expected: http.StatusOK,
+		},
+		"warnings-only": {
+			diags:    Diagnostics{{Severity: DiagnosticWarning, Code: CodeDeprecated}},
+			expected: http.StatusOK,
+		},
+		"highest-status-wins": {
+			diags: Diagnostics{
+				{Severity: DiagnosticWarning, Code: CodeDeprecated},
+				{Severity: DiagnosticError, Code: CodeMissing},
+				{Severity: DiagnosticError, Code: CodeNotFound},
+				{Severity: DiagnosticError, Code: CodeInvalidValue},
+			},
+			expected: http.StatusNotFound,
+		},
+		"highest-status-wins-reversed": {
+			diags: Diagnostics{
+				{Severity: DiagnosticError, Code: CodeInvalidValue},
+				{Severity: DiagnosticError, Code: CodeNotFound},
+				{Severity: DiagnosticError, Code: CodeMissing},
+				{Severity: DiagnosticWarning, Code: CodeDeprecated},
+			},
+			expected: http.StatusNotFound,
+		},
+		"server-error-wins": {
+			diags: Diagnostics{
+				{Severity: DiagnosticError, Code: CodeConflict},
+				{Severity: DiagnosticError, Code: CodeActOfGod},
+				{Severity: DiagnosticError, Code: "quota_exceeded"},
+			},
+			expected: http.StatusServiceUnavailable,
+		},
+		"act-of-god": {
+			diags:    Diagnostics{{Severity: DiagnosticError, Code: CodeActOfGod}},
+			expected: http.StatusServiceUnavailable,
+		},
+		"unknown-code": {
+			diags:    Diagnostics{{Severity: DiagnosticError, Code: "quota_exceeded"}},
+			expected: http.StatusBadRequest,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if result := StatusForDiagnostics(tc.diags); result != tc.expected {
+				t.Fatalf("expected %d, got %d", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestDefaultStatusMappingCoversBuiltinCodes(t *testing.T) {
+	t.Parallel()
+
+	mapping := DefaultStatusMapping()
+	for _, code := range builtinCodes {
+		if _, ok := mapping[code]; !ok && code != CodeDeprecated {
+			t.Errorf("no status for %q", code)
+		}
+	}
+}
+
+func TestStatusMappingWith(t *testing.T) {
+	t.Parallel()
+
+	defaults := DefaultStatusMapping()
+	mapping := defaults.With(StatusMapping{
+		CodeOverflow:     http.StatusRequestEntityTooLarge,
+		"quota_exceeded": http.StatusTooManyRequests,
+	})
+
+	type testCase struct {
+		code     Code
+		expected int
+	}
+
+	cases := map[string]testCase{
+		"overridden": {
+			code:     CodeOverflow,
+			expected: http.StatusRequestEntityTooLarge,
+		},
+		"added": {
+			code:     "quota_exceeded",
+			expected: http.StatusTooManyRequests,
+		},
+		"kept": {
+			code:     CodeNotFound,
+			expected: http.StatusNotFound,
+		},
+		"unknown": {
+			code:     "rate_limited",
+			expected: http.StatusBadRequest,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result := mapping.Status(Diagnostics{{Severity: DiagnosticError, Code: tc.code}})
+			if result != tc.expected {
+				t.Fatalf("expected %d, got %d", tc.expected, result)
+			}
+		})
+	}
+
+	if status := defaults[CodeOverflow]; status != http.StatusUnprocessableEntity {
+		t.Errorf("With modified the original StatusMapping: overflow is %d", status)
+	}
+}
+
+func TestWarningsHeader(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags    Diagnostics
+		expected Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"none": {},
+		"errors-only": {
+			diags: Diagnostics{{Severity: DiagnosticError, Code: CodeNotFound}},
+		},
+		"mixed": {
+			diags: Diagnostics{
+				{Severity: DiagnosticError, Code: CodeNotFound},
+				{Severity: DiagnosticWarning, Code: CodeDeprecated, Summary: "Use <code>v2</code>, it's better.", Paths: []Steps{URLParamPath("mode")}},
+			},
+			expected: Diagnostics{
+				{Severity: DiagnosticWarning, Code: CodeDeprecated, Summary: "Use <code>v2</code>, it's better.", Paths: []Steps{URLParamPath("mode")}},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			h := http.Header{}
+			h.Set(WarningsHeader, "stale")
+			err := SetWarningsHeader(h, tc.diags)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if tc.expected == nil && h.Get(WarningsHeader) != "" {
+				t.Fatalf("expected no %s header, got %q", WarningsHeader, h.Get(WarningsHeader))
+			}
+			result, err := WarningsFromHeader(h)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestWarningsFromHeaderInvalid(t *testing.T) {
+	t.Parallel()
+
+	h := http.Header{}
+	h.Set(WarningsHeader, "not base64!")
+	_, err := WarningsFromHeader(h)
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+}
+
+func TestWriteHTTP(t *testing.T) {
+	t.Parallel()
+
+	diags := Diagnostics{
+		{Severity: DiagnosticError, Code: CodeConflict, Summary: "<name> is taken.", Paths: []Steps{BodyPath().AddStep(ObjectPropertyStep("name"))}},
+	}
+
+	type testCase struct {
+		opts     []HTTPOption
+		status   int
+		expected Problem
+		body     string
+	}
+
+	cases := map[string]testCase{
+		"defaults": {
+			status:   http.StatusConflict,
+			expected: NewProblem(http.StatusConflict, diags),
+		},
+		"options": {
+			opts: []HTTPOption{
+				WithStatus(http.StatusUnprocessableEntity),
+				WithProblemType("https://example.com/problems/taken"),
+				WithInstance("/widgets/123"),
+			},
+			status: http.StatusUnprocessableEntity,
+			expected: Problem{
+				Type:        "https://example.com/problems/taken",
+				Title:       http.StatusText(http.StatusUnprocessableEntity),
+				Status:      http.StatusUnprocessableEntity,
+				Instance:    "/widgets/123",
+				Diagnostics: diags,
+			},
+		},
+		"mapping": {
+			opts:     []HTTPOption{WithStatusMapping(StatusMapping{CodeConflict: http.StatusUnprocessableEntity})},
+			status:   http.StatusUnprocessableEntity,
+			expected: NewProblem(http.StatusUnprocessableEntity, diags),
+		},
+		"canonical": {
+			opts:     []HTTPOption{WithCanonicalJSON()},
+			status:   http.StatusConflict,
+			expected: NewProblem(http.StatusConflict, diags),
+			body: `{"diagnostics":[{"code":"conflict","path":[[{"kind":"body"},{"kind":"object_property","value":"name"}]],` +
+				`"severity":"error","summary":"<name> is taken."}],"status":409,"title":"Conflict"}`,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			err := WriteHTTP(w, diags, tc.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if w.Code != tc.status {
+				t.Errorf("expected status %d, got %d", tc.status, w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != ProblemContentType {
+				t.Errorf("expected Content-Type %q, got %q", ProblemContentType, ct)
+			}
+			if tc.body != "" && w.Body.String() != tc.body {
+				t.Errorf("expected body %s, got %s", tc.body, w.Body.String())
+			}
+			var result Problem
+			err = json.Unmarshal(w.Body.Bytes(), &result)
+			if err != nil {
+				t.Fatalf("unexpected error decoding response: %s", err)
+			}
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteHTTPWarningsHeader(t *testing.T) {
+	t.Parallel()
+
+	diags := Diagnostics{
+		{Severity: DiagnosticWarning, Code: CodeDeprecated, Paths: []Steps{URLParamPath("mode")}},
+	}
+	w := httptest.NewRecorder()
+	err := WriteHTTP(w, diags, WithWarningsHeader())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	result, err := WarningsFromHeader(w.Header())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(diags, result); diff != "" {
+		t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+	}
+}
@@ -0,0 +1,312 @@
+package apidiags
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+type collectorKey struct{}
+
+// collector holds the Diagnostics added to a context by AddDiagnostics.
+type collector struct {
+	mu    sync.Mutex
+	diags Diagnostics
+	hooks []DiagnosticHook
+}
+
+// CollectDiagnostics returns a copy of ctx that collects the Diagnostics
+// passed to AddDiagnostics, so they can be retrieved with
+// CollectedDiagnostics. If ctx is already collecting Diagnostics, it's
+// returned as-is.
+func CollectDiagnostics(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(collectorKey{}).(*collector); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, collectorKey{}, &collector{})
+}
+
+// AddDiagnostics adds diags to the Diagnostics collected by ctx, returning
+// false if ctx isn't collecting Diagnostics. It's safe to call from
+// multiple goroutines.
+func AddDiagnostics(ctx context.Context, diags ...Diagnostic) bool {
+	c, ok := ctx.Value(collectorKey{}).(*collector)
+	if !ok {
+		return false
+	}
+	diags = runHooks(ctx, HookAdded, diags)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.diags = append(c.diags, diags...)
+	return true
+}
+
+// CollectedDiagnostics returns the Diagnostics added to ctx by
+// AddDiagnostics so far.
+func CollectedDiagnostics(ctx context.Context) Diagnostics {
+	c, ok := ctx.Value(collectorKey{}).(*collector)
+	if !ok {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.diags) < 1 {
+		return nil
+	}
+	results := make(Diagnostics, len(c.diags))
+	copy(results, c.diags)
+	return results
+}
+
+// InjectDiagnostics returns an http.Handler that calls next with a request
+// whose context collects Diagnostics, and adds whatever Diagnostics are
+// collected to next's response. This lets cross-cutting layers, like
+// authentication or deprecation scanners, add warnings to a response
+// without the cooperation of the handler writing it.
+//
+// next's response is buffered until it returns, then the collected
+// Diagnostics are added to it:
+//
+//   - if the response is successful but the Diagnostics include an error,
+//     next's body is discarded, and the Diagnostics are written using
+//     WriteHTTP instead
+//   - if the response is an application/problem+json Problem Details
+//     document, the Diagnostics are added to the Problem's
+//   - if the response is a JSON object with a "diagnostics" or "data"
+//     member, like an Envelope, the Diagnostics are added to that member
+//   - otherwise, the response's body can't carry the Diagnostics, and
+//     they're added to the WarningsHeader instead, so only warnings survive
+//
+// The deprecation and Retry-After headers describing the collected
+// Diagnostics are set, replacing any next set. opts configure how the
+// Diagnostics are written, as they do for WriteHTTP; WithWarningSuppression
+// applies to the collected Diagnostics only.
+//
+// If next flushes its response, the response is sent as-is, with only the
+// headers, including the WarningsHeader, describing the Diagnostics
+// collected up to that point, and Diagnostics collected afterward are
+// dropped. Use RecoverPanics outside of
+// InjectDiagnostics, so panics in next are handled before anything is
+// buffered.
+func InjectDiagnostics(next http.Handler, opts ...HTTPOption) http.Handler {
+	var cfg httpConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := CollectDiagnostics(r.Context())
+		reqCfg := cfg
+		reqCfg.ctx = ctx
+		bw := &bufferedResponseWriter{
+			ResponseWriter: w,
+			ctx:            ctx,
+			opts:           append(opts[:len(opts):len(opts)], WithContext(ctx)),
+			cfg:            reqCfg,
+		}
+		next.ServeHTTP(bw, r.WithContext(ctx))
+		if bw.streaming {
+			return
+		}
+		bw.finish()
+	})
+}
+
+// bufferedResponseWriter is an http.ResponseWriter that holds on to a
+// response until InjectDiagnostics has added the collected Diagnostics to
+// it.
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	ctx       context.Context
+	opts      []HTTPOption
+	cfg       httpConfig
+	status    int
+	buf       bytes.Buffer
+	streaming bool
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	if w.streaming {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.Write(b)
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.buf.Write(b)
+}
+
+// Unwrap returns the http.ResponseWriter being wrapped, so
+// http.ResponseController can reach it.
+func (w *bufferedResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Flush stops buffering the response, sending what's been buffered so far
+// along with the headers describing the Diagnostics collected up to this
+// point, then flushes the response being written, if the
+// http.ResponseWriter being wrapped supports it.
+func (w *bufferedResponseWriter) Flush() {
+	if !w.streaming {
+		w.streaming = true
+		diags := w.cfg.written(w.cfg.filter(CollectedDiagnostics(w.ctx)))
+		if len(diags) > 0 {
+			// the response has started, so there's no way to report
+			// errors setting the headers
+			_ = w.setHeaders(diags, true)
+			w.cfg.observe(diags)
+		}
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		w.ResponseWriter.WriteHeader(w.status)
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// finish adds the collected Diagnostics to the buffered response and
+// writes it. If the Diagnostics can't be added, the buffered response is
+// written unchanged.
+func (w *bufferedResponseWriter) finish() {
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	body := w.buf.Bytes()
+	collected := CollectedDiagnostics(w.ctx)
+	diags := w.cfg.filter(collected)
+	if len(diags) < 1 {
+		w.write(status, body)
+		return
+	}
+	if status < http.StatusBadRequest && diags.HasErrors() {
+		w.Header().Del("Content-Length")
+		// the response hasn't started if WriteHTTP fails, but there's
+		// nothing better to write
+		_ = WriteHTTP(w.ResponseWriter, collected, w.opts...)
+		return
+	}
+	diags = w.cfg.written(diags)
+	w.cfg.observe(diags)
+	var err error
+	injected, merged, ok := w.injectBody(body, diags)
+	if ok {
+		if w.cfg.warnings {
+			err = SetWarningsHeader(w.Header(), merged)
+		}
+		if err == nil {
+			err = w.setHeaders(diags, false)
+		}
+		body = injected
+	} else {
+		err = w.setHeaders(diags, true)
+	}
+	if err != nil {
+		w.write(status, w.buf.Bytes())
+		return
+	}
+	w.write(status, body)
+}
+
+// injectBody returns body with diags added to it, along with every
+// Diagnostic the new body carries, if body is a JSON document that can
+// carry Diagnostics. If it isn't, false is returned.
+func (w *bufferedResponseWriter) injectBody(body []byte, diags Diagnostics) ([]byte, Diagnostics, bool) {
+	contentType := w.Header().Get("Content-Type")
+	if !isJSONMediaType(contentType) {
+		return nil, nil, false
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	var merged Diagnostics
+	var result any
+	if mediaType == ProblemContentType {
+		var problem Problem
+		err := json.Unmarshal(body, &problem)
+		if err != nil {
+			return nil, nil, false
+		}
+		problem.Diagnostics = append(problem.Diagnostics, diags...)
+		merged = problem.Diagnostics
+		result = problem
+	} else {
+		var members map[string]json.RawMessage
+		err := json.Unmarshal(body, &members)
+		if err != nil {
+			return nil, nil, false
+		}
+		existing, hasDiags := members[problemDiagnosticsMember]
+		_, hasData := members["data"]
+		if !hasDiags && !hasData {
+			return nil, nil, false
+		}
+		if hasDiags {
+			err = json.Unmarshal(existing, &merged)
+			if err != nil {
+				return nil, nil, false
+			}
+		}
+		merged = append(merged, diags...)
+		encoded, err := json.Marshal(merged)
+		if err != nil {
+			return nil, nil, false
+		}
+		members[problemDiagnosticsMember] = encoded
+		result = members
+	}
+	var encoded []byte
+	var err error
+	if w.cfg.canonical {
+		encoded, err = MarshalCanonicalJSON(result)
+	} else {
+		encoded, err = json.Marshal(result)
+	}
+	if err != nil {
+		return nil, nil, false
+	}
+	return encoded, merged, true
+}
+
+// setHeaders sets the deprecation and Retry-After headers describing diags,
+// and adds diags' warnings to the WarningsHeader if warnings is true.
+func (w *bufferedResponseWriter) setHeaders(diags Diagnostics, warnings bool) error {
+	h := w.Header()
+	if warnings {
+		// a WarningsHeader that can't be parsed is replaced
+		existing, _ := WarningsFromHeader(h)
+		err := SetWarningsHeader(h, append(existing, diags...))
+		if err != nil {
+			return err
+		}
+	}
+	err := SetDeprecationHeaders(h, diags)
+	if err != nil {
+		return err
+	}
+	return SetRetryAfterHeader(h, diags)
+}
+
+func (w *bufferedResponseWriter) write(status int, body []byte) {
+	if w.Header().Get("Content-Length") != "" {
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	w.ResponseWriter.WriteHeader(status)
+	// the handler's response has already been accepted, so there's
+	// nowhere to report write errors
+	_, _ = w.ResponseWriter.Write(body)
+}
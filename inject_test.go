@@ -0,0 +1,152 @@
+package apidiags
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCollectDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	if AddDiagnostics(context.Background(), Diagnostic{Code: CodeDeprecated}) {
+		t.Errorf("expected AddDiagnostics to fail without a collector")
+	}
+	ctx := CollectDiagnostics(context.Background())
+	if CollectDiagnostics(ctx) != ctx {
+		t.Errorf("expected CollectDiagnostics to reuse the existing collector")
+	}
+	if !AddDiagnostics(ctx, Diagnostic{Severity: DiagnosticWarning, Code: CodeDeprecated}) {
+		t.Errorf("expected AddDiagnostics to succeed with a collector")
+	}
+	AddDiagnostics(ctx, Diagnostic{Severity: DiagnosticWarning, Code: "hint"})
+	expected := Diagnostics{
+		{Severity: DiagnosticWarning, Code: CodeDeprecated},
+		{Severity: DiagnosticWarning, Code: "hint"},
+	}
+	if diff := cmp.Diff(expected, CollectedDiagnostics(ctx)); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+}
+
+func TestInjectDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		handler          http.HandlerFunc
+		collected        Diagnostics
+		expectedStatus   int
+		expectedBody     string
+		expectedWarnings Diagnostics
+	}
+
+	warning := Diagnostic{Severity: DiagnosticWarning, Code: CodeDeprecated, Paths: []Steps{HeaderPath("X-Legacy-Auth")}}
+
+	cases := map[string]testCase{
+		"nothing-collected": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = io.WriteString(w, `{"data":{"id":"123"}}`)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data":{"id":"123"}}`,
+		},
+		"envelope": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_ = WriteEnvelope(w, NewEnvelope(testWidget{ID: "123"}, Diagnostics{{Severity: DiagnosticWarning, Code: "hint"}}))
+			},
+			collected:      Diagnostics{warning},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data":{"id":"123"},"diagnostics":[{"severity":"warning","code":"hint"},{"severity":"warning","code":"deprecated","path":[[{"kind":"header","value":"X-Legacy-Auth"}]]}]}`,
+		},
+		"problem": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_ = WriteHTTP(w, Diagnostics{{Severity: DiagnosticError, Code: CodeNotFound}})
+			},
+			collected:      Diagnostics{warning},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"diagnostics":[{"severity":"error","code":"not_found"},{"severity":"warning","code":"deprecated","path":[[{"kind":"header","value":"X-Legacy-Auth"}]]}],"status":404,"title":"Not Found"}`,
+		},
+		"not-an-envelope": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.WriteHeader(http.StatusAccepted)
+				_, _ = io.WriteString(w, "accepted")
+			},
+			collected:        Diagnostics{warning},
+			expectedStatus:   http.StatusAccepted,
+			expectedBody:     "accepted",
+			expectedWarnings: Diagnostics{warning},
+		},
+		"collected-error": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = io.WriteString(w, `{"data":{"id":"123"}}`)
+			},
+			collected:      Diagnostics{{Severity: DiagnosticError, Code: CodeAccessDenied}},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"diagnostics":[{"severity":"error","code":"access_denied"}],"status":403,"title":"Forbidden"}`,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := InjectDiagnostics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				AddDiagnostics(r.Context(), tc.collected...)
+				tc.handler(w, r)
+			}))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+			if w.Body.String() != tc.expectedBody {
+				t.Errorf("expected body %s, got %s", tc.expectedBody, w.Body.String())
+			}
+			warnings, err := WarningsFromHeader(w.Header())
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(tc.expectedWarnings, warnings); diff != "" {
+				t.Errorf("unexpected warnings diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestInjectDiagnosticsFlush(t *testing.T) {
+	t.Parallel()
+
+	warning := Diagnostic{Severity: DiagnosticWarning, Code: CodeDeprecated}
+	handler := InjectDiagnostics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		AddDiagnostics(r.Context(), warning)
+		events := NewEventWriter(w)
+		_ = events.WriteEvent("widget", testWidget{ID: "123"})
+		AddDiagnostics(r.Context(), Diagnostic{Severity: DiagnosticWarning, Code: "hint"})
+		_ = events.WriteEvent("widget", testWidget{ID: "456"})
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !w.Flushed {
+		t.Errorf("expected response to be flushed")
+	}
+	expected := "event: widget\ndata: {\"id\":\"123\"}\n\nevent: widget\ndata: {\"id\":\"456\"}\n\n"
+	if w.Body.String() != expected {
+		t.Errorf("expected body %q, got %q", expected, w.Body.String())
+	}
+	warnings, err := WarningsFromHeader(w.Header())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(Diagnostics{warning}, warnings); diff != "" {
+		t.Errorf("unexpected warnings diff (-wanted, +got): %s", diff)
+	}
+}
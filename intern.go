@@ -0,0 +1,165 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// internMu is held while a path or Diagnostic is being interned.
+	internMu sync.Mutex
+
+	// internedPaths holds a map[string]Steps of the Steps returned by
+	// InternPath, by their keys from appendPathKey. The map is replaced
+	// rather than changed when a path is interned, so looking paths up
+	// doesn't need internMu.
+	internedPaths atomic.Value
+
+	// internedDiags holds the Diagnostics returned by InternDiagnostic, by
+	// their canonical JSON encodings. It's guarded by internMu.
+	internedDiags = map[string]Diagnostic{}
+)
+
+func init() {
+	internedPaths.Store(map[string]Steps{})
+}
+
+// InternPath returns Steps made up of steps that are shared by every call
+// to InternPath with the same steps, so the paths handlers use over and
+// over, like
+//
+//	apidiags.InternPath(apidiags.BodyStep{}, apidiags.ObjectPropertyStep("name"))
+//
+// are only built once. Once a path has been interned, getting it again
+// doesn't allocate.
+//
+// The Steps returned have no room to grow, so AddStep, AddSteps, and append
+// all copy them instead of changing them for everyone sharing them. They
+// must never be changed by setting a Step by index. Interned paths are
+// never freed, so only paths from a fixed set, like the fields of a request
+// type, should be interned, never paths built from request data. Steps
+// with Steps of types this package doesn't define aren't interned; a copy
+// of them is returned instead.
+func InternPath(steps ...Step) Steps {
+	var buf [128]byte
+	key, ok := appendPathKey(buf[:0], steps)
+	if !ok {
+		path := make(Steps, len(steps))
+		copy(path, steps)
+		return path
+	}
+	if path, ok := internedPaths.Load().(map[string]Steps)[string(key)]; ok {
+		return path
+	}
+
+	internMu.Lock()
+	defer internMu.Unlock()
+	paths := internedPaths.Load().(map[string]Steps)
+	if path, ok := paths[string(key)]; ok {
+		return path
+	}
+	path := make(Steps, len(steps))
+	copy(path, steps)
+	updated := make(map[string]Steps, len(paths)+1)
+	for existing, existingPath := range paths {
+		updated[existing] = existingPath
+	}
+	updated[string(key)] = path
+	internedPaths.Store(updated)
+	return path
+}
+
+// appendPathKey appends the key InternPath interns steps by to dst: a byte
+// for the kind of each Step, followed by its value, with strings prefixed
+// by their length so no two paths have the same key. It returns false if
+// any of the Steps is of a type this package doesn't define.
+func appendPathKey(dst []byte, steps []Step) ([]byte, bool) {
+	for _, step := range steps {
+		switch value := step.(type) {
+		case BodyStep:
+			dst = append(dst, 'b')
+		case HeaderStep:
+			dst = appendPathKeyString(append(dst, 'h'), string(value))
+		case URLParamStep:
+			dst = appendPathKeyString(append(dst, 'u'), string(value))
+		case ObjectPropertyStep:
+			dst = appendPathKeyString(append(dst, 'o'), string(value))
+		case ArrayIndexStep:
+			dst = append(strconv.AppendInt(append(dst, 'a'), int64(value), 10), ';')
+		case StringIndexStep:
+			dst = append(strconv.AppendInt(append(dst, 's'), int64(value), 10), ';')
+		case RuneIndexStep:
+			dst = append(strconv.AppendInt(append(dst, 'r'), int64(value), 10), ';')
+		default:
+			return dst, false
+		}
+	}
+	return dst, true
+}
+
+func appendPathKeyString(dst []byte, s string) []byte {
+	dst = append(strconv.AppendInt(dst, int64(len(s)), 10), ':')
+	return append(dst, s...)
+}
+
+// InternDiagnostic returns a Diagnostic equal to diag that's shared by
+// every call to InternDiagnostic with an equal Diagnostic, so fully static
+// Diagnostics can be built once, in a package-level variable, and returned
+// from any number of requests:
+//
+//	var nameMissing = apidiags.InternDiagnostic(apidiags.Diagnostic{
+//		Severity: apidiags.DiagnosticError,
+//		Code:     apidiags.CodeMissing,
+//		Paths:    []apidiags.Steps{apidiags.BodyPath().AddStep(apidiags.ObjectPropertyStep("name"))},
+//		Summary:  "The name is required.",
+//	})
+//
+// Its Paths are interned with InternPath, and it has its own copy of
+// diag's Extensions, so changing diag afterwards doesn't change it. Like
+// the Steps returned by InternPath, it must never be changed in place, by
+// setting one of its Paths or Extensions; methods like WithTrace and
+// PrependPath, which return a changed copy, are safe to use. Interned
+// Diagnostics are never freed, so they should come from a fixed set.
+// Diagnostics that can't be encoded as JSON, Diagnostics with a Template,
+// whose messages aren't known until they're written, and Diagnostics with a
+// Debug or a DebugMember extension member, which their JSON encoding leaves
+// out, aren't interned, and are returned as is.
+func InternDiagnostic(diag Diagnostic) Diagnostic {
+	if _, ok := diag.Extensions[DebugMember]; ok || diag.Template != nil || diag.Debug != nil {
+		return diag
+	}
+	key, err := MarshalCanonicalJSON(diag)
+	if err != nil {
+		return diag
+	}
+	internMu.Lock()
+	result, ok := internedDiags[string(key)]
+	internMu.Unlock()
+	if ok {
+		return result
+	}
+
+	result = diag
+	if diag.Paths != nil {
+		result.Paths = make([]Steps, len(diag.Paths))
+		for pos, path := range diag.Paths {
+			result.Paths[pos] = InternPath(path...)
+		}
+	}
+	if diag.Extensions != nil {
+		result.Extensions = make(map[string]json.RawMessage, len(diag.Extensions))
+		for member, value := range diag.Extensions {
+			result.Extensions[member] = append(json.RawMessage(nil), value...)
+		}
+	}
+
+	internMu.Lock()
+	defer internMu.Unlock()
+	if existing, ok := internedDiags[string(key)]; ok {
+		return existing
+	}
+	internedDiags[string(key)] = result
+	return result
+}
@@ -0,0 +1,191 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInternPath(t *testing.T) {
+	t.Parallel()
+
+	first := InternPath(BodyStep{}, ObjectPropertyStep("intern-path"))
+	second := InternPath(BodyPath().AddStep(ObjectPropertyStep("intern-path"))...)
+	other := InternPath(BodyStep{}, ObjectPropertyStep("intern-path-other"))
+
+	if diff := cmp.Diff(BodyPath().AddStep(ObjectPropertyStep("intern-path")), first); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	if &first[0] != &second[0] {
+		t.Error("expected equal paths to be shared")
+	}
+	if &first[0] == &other[0] {
+		t.Error("expected different paths not to be shared")
+	}
+	if len(first) != cap(first) {
+		t.Errorf("expected interned path to have no room to grow, got length %d and capacity %d", len(first), cap(first))
+	}
+
+	extended := first.AddStep(ArrayIndexStep(1))
+	appended := append(first, ArrayIndexStep(2))
+	if diff := cmp.Diff(BodyPath().AddStep(ObjectPropertyStep("intern-path")), second); diff != "" {
+		t.Errorf("unexpected diff after extending (-wanted, +got): %s", diff)
+	}
+	if extended[2] != ArrayIndexStep(1) || appended[2] != ArrayIndexStep(2) {
+		t.Errorf("expected extensions not to overwrite each other, got %v and %v", extended, appended)
+	}
+}
+
+func TestInternPathCopiesInput(t *testing.T) {
+	t.Parallel()
+
+	steps := []Step{BodyStep{}, ObjectPropertyStep("intern-path-copied")}
+	path := InternPath(steps...)
+	steps[1] = ObjectPropertyStep("changed")
+	if diff := cmp.Diff(BodyPath().AddStep(ObjectPropertyStep("intern-path-copied")), path); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+}
+
+func TestInternPathUnknownStep(t *testing.T) {
+	t.Parallel()
+
+	first := InternPath(BodyStep{}, unrenderableStep{})
+	second := InternPath(BodyStep{}, unrenderableStep{})
+	if diff := cmp.Diff(Steps{BodyStep{}, unrenderableStep{}}, first); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	if &first[0] == &second[0] {
+		t.Error("expected paths with unknown steps not to be interned")
+	}
+}
+
+func TestInternPathDoesntAllocate(t *testing.T) {
+	InternPath(BodyStep{}, ObjectPropertyStep("items"), ArrayIndexStep(3), ObjectPropertyStep("name"))
+	allocs := testing.AllocsPerRun(100, func() {
+		InternPath(BodyStep{}, ObjectPropertyStep("items"), ArrayIndexStep(3), ObjectPropertyStep("name"))
+	})
+	if allocs != 0 {
+		t.Errorf("expected getting an interned path not to allocate, got %v allocations", allocs)
+	}
+}
+
+func TestAppendPathKeyDistinct(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		a, b Steps
+	}
+
+	cases := map[string]testCase{
+		"split-property":  {a: PathOf(ObjectPropertyStep("ab")), b: PathOf(ObjectPropertyStep("a"), ObjectPropertyStep("b"))},
+		"kinds":           {a: HeaderPath("name"), b: PathOf(ObjectPropertyStep("name"))},
+		"split-index":     {a: PathOf(ArrayIndexStep(1), ArrayIndexStep(2)), b: PathOf(ArrayIndexStep(12))},
+		"length-in-value": {a: PathOf(ObjectPropertyStep("1:o")), b: PathOf(ObjectPropertyStep("1"), ObjectPropertyStep("o"))},
+		"index-kinds":     {a: PathOf(StringIndexStep(3)), b: PathOf(RuneIndexStep(3))},
+		"header-case":     {a: HeaderPath("X-Name"), b: HeaderPath("x-name")},
+		"empty":           {a: Steps{}, b: PathOf(ObjectPropertyStep(""))},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			a, ok := appendPathKey(nil, tc.a)
+			if !ok {
+				t.Fatalf("expected a key for %v", tc.a)
+			}
+			b, ok := appendPathKey(nil, tc.b)
+			if !ok {
+				t.Fatalf("expected a key for %v", tc.b)
+			}
+			if string(a) == string(b) {
+				t.Errorf("expected different keys for %v and %v, both got %q", tc.a, tc.b, a)
+			}
+		})
+	}
+}
+
+func TestInternDiagnostic(t *testing.T) {
+	t.Parallel()
+
+	build := func() Diagnostic {
+		return Diagnostic{
+			Severity:   DiagnosticError,
+			Code:       CodeMissing,
+			Paths:      []Steps{BodyPath().AddStep(ObjectPropertyStep("intern-diagnostic"))},
+			Summary:    "The intern-diagnostic field is required.",
+			Extensions: map[string]json.RawMessage{"field": json.RawMessage(`"intern-diagnostic"`)},
+		}
+	}
+	input := build()
+	first := InternDiagnostic(input)
+	second := InternDiagnostic(build())
+
+	if diff := cmp.Diff(build(), first); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	if reflect.ValueOf(first.Extensions).Pointer() != reflect.ValueOf(second.Extensions).Pointer() {
+		t.Error("expected equal Diagnostics to share Extensions")
+	}
+	if &first.Paths[0][0] != &second.Paths[0][0] {
+		t.Error("expected equal Diagnostics to share Paths")
+	}
+	if &first.Paths[0][0] != &InternPath(BodyStep{}, ObjectPropertyStep("intern-diagnostic"))[0] {
+		t.Error("expected the Diagnostic's Paths to be interned")
+	}
+
+	// changing the Diagnostic it was interned from, or copies made with
+	// its methods, doesn't change the interned Diagnostic
+	input.Extensions["field"] = json.RawMessage(`"changed"`)
+	input.Paths[0][1] = ObjectPropertyStep("changed")
+	_ = first.WithTrace("4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7").PrependPath(HeaderPath("X-Changed"))
+	if diff := cmp.Diff(build(), InternDiagnostic(build())); diff != "" {
+		t.Errorf("unexpected diff after changes (-wanted, +got): %s", diff)
+	}
+}
+
+func TestInternDiagnosticUnencodable(t *testing.T) {
+	t.Parallel()
+
+	diag := Diagnostic{Code: CodeMissing, Paths: []Steps{{unrenderableStep{}}}}
+	if diff := cmp.Diff(diag, InternDiagnostic(diag)); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+}
+
+func TestInternDiagnosticDebug(t *testing.T) {
+	t.Parallel()
+
+	diag := Diagnostic{Severity: DiagnosticError, Code: CodeActOfGod, Summary: "Try again later."}
+	first := diag.WithDebug(DebugInfo{Error: "first"})
+	second := diag.WithDebug(DebugInfo{Error: "second"})
+	for _, expected := range []Diagnostic{first, second} {
+		if diff := cmp.Diff(expected, InternDiagnostic(expected)); diff != "" {
+			t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+		}
+	}
+	if result := InternDiagnostic(diag); result.Debug != nil {
+		t.Errorf("expected no Debug, got %+v", result.Debug)
+	}
+}
+
+func BenchmarkInternPath(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		InternPath(BodyStep{}, ObjectPropertyStep("items"), ArrayIndexStep(12), ObjectPropertyStep("name"))
+	}
+}
+
+// BenchmarkBuildPath measures building the path BenchmarkInternPath
+// interns, for comparison.
+func BenchmarkBuildPath(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = BodyPath().AddSteps(ObjectPropertyStep("items"), ArrayIndexStep(12), ObjectPropertyStep("name"))
+	}
+}
@@ -0,0 +1,69 @@
+//go:build go1.23
+
+package apidiags
+
+import "iter"
+
+// All returns an iterator over the position and value of each Step in the
+// Steps, in order.
+func (s Steps) All() iter.Seq2[int, Step] {
+	return func(yield func(int, Step) bool) {
+		for pos, step := range s {
+			if !yield(pos, step) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over each Step in the Steps, in order.
+func (s Steps) Values() iter.Seq[Step] {
+	return func(yield func(Step) bool) {
+		for _, step := range s {
+			if !yield(step) {
+				return
+			}
+		}
+	}
+}
+
+// StepsOf returns an iterator over every Step of every Path of the
+// Diagnostic, in order, along with the position in Paths of the Path the
+// Step is part of.
+func (d Diagnostic) StepsOf() iter.Seq2[int, Step] {
+	return func(yield func(int, Step) bool) {
+		for pos, path := range d.Paths {
+			for _, step := range path {
+				if !yield(pos, step) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// All returns an iterator over the position and value of each Diagnostic in
+// the Diagnostics, in order.
+func (diags Diagnostics) All() iter.Seq2[int, Diagnostic] {
+	return func(yield func(int, Diagnostic) bool) {
+		for pos, diag := range diags {
+			if !yield(pos, diag) {
+				return
+			}
+		}
+	}
+}
+
+// Paths returns an iterator over every Path of every Diagnostic in the
+// Diagnostics, in order.
+func (diags Diagnostics) Paths() iter.Seq[Steps] {
+	return func(yield func(Steps) bool) {
+		for _, diag := range diags {
+			for _, path := range diag.Paths {
+				if !yield(path) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+//go:build go1.23
+
+package apidiags
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStepsIterators(t *testing.T) {
+	t.Parallel()
+
+	steps := BodyPath().AddSteps(ObjectPropertyStep("items"), ArrayIndexStep(3))
+
+	var positions []int
+	var all Steps
+	for pos, step := range steps.All() {
+		positions = append(positions, pos)
+		all = append(all, step)
+	}
+	if diff := cmp.Diff([]int{0, 1, 2}, positions); diff != "" {
+		t.Errorf("unexpected positions (-wanted, +got): %s", diff)
+	}
+	if diff := cmp.Diff(steps, all); diff != "" {
+		t.Errorf("unexpected steps from All (-wanted, +got): %s", diff)
+	}
+
+	var values Steps
+	for step := range steps.Values() {
+		values = append(values, step)
+		if len(values) == 2 {
+			break
+		}
+	}
+	if diff := cmp.Diff(steps[:2], values); diff != "" {
+		t.Errorf("unexpected steps from Values (-wanted, +got): %s", diff)
+	}
+}
+
+func TestDiagnosticsIterators(t *testing.T) {
+	t.Parallel()
+
+	diags := Diagnostics{
+		{
+			Severity: DiagnosticError,
+			Code:     CodeConflict,
+			Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("start")), BodyPath().AddStep(ObjectPropertyStep("end"))},
+		},
+		{
+			Severity: DiagnosticError,
+			Code:     CodeActOfGod,
+		},
+		{
+			Severity: DiagnosticWarning,
+			Code:     CodeDeprecated,
+			Paths:    []Steps{HeaderPath("X-Legacy")},
+		},
+	}
+
+	var all Diagnostics
+	for pos, diag := range diags.All() {
+		if diff := cmp.Diff(diags[pos], diag); diff != "" {
+			t.Errorf("unexpected diagnostic at %d (-wanted, +got): %s", pos, diff)
+		}
+		all = append(all, diag)
+	}
+	if diff := cmp.Diff(diags, all); diff != "" {
+		t.Errorf("unexpected diagnostics from All (-wanted, +got): %s", diff)
+	}
+
+	var paths []Steps
+	for path := range diags.Paths() {
+		paths = append(paths, path)
+	}
+	expected := []Steps{diags[0].Paths[0], diags[0].Paths[1], diags[2].Paths[0]}
+	if diff := cmp.Diff(expected, paths); diff != "" {
+		t.Errorf("unexpected paths (-wanted, +got): %s", diff)
+	}
+
+	var pathPositions []int
+	var steps Steps
+	for pos, step := range diags[0].StepsOf() {
+		pathPositions = append(pathPositions, pos)
+		steps = append(steps, step)
+	}
+	if diff := cmp.Diff([]int{0, 0, 1, 1}, pathPositions); diff != "" {
+		t.Errorf("unexpected path positions (-wanted, +got): %s", diff)
+	}
+	expectedSteps := append(append(Steps{}, diags[0].Paths[0]...), diags[0].Paths[1]...)
+	if diff := cmp.Diff(expectedSteps, steps); diff != "" {
+		t.Errorf("unexpected steps from StepsOf (-wanted, +got): %s", diff)
+	}
+	for range diags[1].StepsOf() {
+		t.Error("expected no steps for a Diagnostic without Paths")
+	}
+	for _, step := range diags[0].StepsOf() {
+		if diff := cmp.Diff(diags[0].Paths[0][0], step); diff != "" {
+			t.Errorf("unexpected first step (-wanted, +got): %s", diff)
+		}
+		break
+	}
+}
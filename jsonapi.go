@@ -0,0 +1,143 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// JSONAPIContentType is the media type for JSON:API documents.
+const JSONAPIContentType = "application/vnd.api+json"
+
+// JSONAPIError is a JSON:API error object.
+type JSONAPIError struct {
+	ID     string                     `json:"id,omitempty"`
+	Status string                     `json:"status,omitempty"`
+	Code   string                     `json:"code,omitempty"`
+	Title  string                     `json:"title,omitempty"`
+	Detail string                     `json:"detail,omitempty"`
+	Source *JSONAPIErrorSource        `json:"source,omitempty"`
+	Meta   map[string]json.RawMessage `json:"meta,omitempty"`
+}
+
+// JSONAPIErrorSource is the source member of a JSON:API error object,
+// identifying the part of the request that caused the error.
+type JSONAPIErrorSource struct {
+	// Pointer is a JSON Pointer to the part of the request document
+	// that caused the error.
+	Pointer string `json:"pointer,omitempty"`
+
+	// Parameter is the URL query parameter that caused the error.
+	Parameter string `json:"parameter,omitempty"`
+
+	// Header is the request header that caused the error.
+	Header string `json:"header,omitempty"`
+}
+
+// JSONAPIErrorsFromDiagnostics converts diags into JSON:API error objects,
+// one per Diagnostic, using status as each error object's status.
+//
+// The Diagnostic's Code becomes the code, and its first Path becomes the
+// source. As JSON:API error objects can't carry Severities, or more than one
+// source, the Severity is stored in meta.severity and, when the Paths can't
+// be represented exactly by the source, the full Paths are stored in
+// meta.paths. DiagnosticsFromJSONAPIErrors reads those members back.
+func JSONAPIErrorsFromDiagnostics(status int, diags Diagnostics) ([]JSONAPIError, error) {
+	results := make([]JSONAPIError, 0, len(diags))
+	for pos, diag := range diags {
+		result := JSONAPIError{
+			Code: string(diag.Code),
+			Meta: map[string]json.RawMessage{},
+		}
+		if status != 0 {
+			result.Status = strconv.Itoa(status)
+		}
+		severity, err := json.Marshal(diag.Severity)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding severity of diagnostic %d: %w", pos, err)
+		}
+		result.Meta["severity"] = severity
+
+		var exact bool
+		if len(diag.Paths) > 0 {
+			result.Source, exact = jsonAPISourceFromSteps(diag.Paths[0])
+		}
+		if len(diag.Paths) > 1 || (len(diag.Paths) == 1 && !exact) {
+			paths, err := json.Marshal(diag.Paths)
+			if err != nil {
+				return nil, fmt.Errorf("error encoding paths of diagnostic %d: %w", pos, err)
+			}
+			result.Meta["paths"] = paths
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// jsonAPISourceFromSteps returns the JSON:API error source that best
+// represents steps, and whether it represents steps exactly.
+func jsonAPISourceFromSteps(steps Steps) (*JSONAPIErrorSource, bool) {
+	if len(steps) < 1 {
+		return nil, false
+	}
+	switch value := steps[0].(type) {
+	case BodyStep:
+		pointer, ok := steps.JSONPointer()
+		if !ok {
+			return nil, false
+		}
+		source := &JSONAPIErrorSource{Pointer: pointer}
+		roundTripped, err := BodyPathFromJSONPointer(pointer)
+		return source, err == nil && roundTripped.Equal(steps)
+	case URLParamStep:
+		return &JSONAPIErrorSource{Parameter: string(value)}, len(steps) == 1
+	case HeaderStep:
+		return &JSONAPIErrorSource{Header: string(value)}, len(steps) == 1
+	default:
+		return nil, false
+	}
+}
+
+// DiagnosticsFromJSONAPIErrors converts JSON:API error objects into
+// Diagnostics, reversing JSONAPIErrorsFromDiagnostics.
+//
+// Error objects without meta.severity are treated as having a Severity of
+// DiagnosticError. Error objects without meta.paths use their source as their
+// only Path, if they have one; see BodyPathFromJSONPointer for how pointers
+// are interpreted.
+func DiagnosticsFromJSONAPIErrors(errs []JSONAPIError) (Diagnostics, error) {
+	results := make(Diagnostics, 0, len(errs))
+	for pos, apiErr := range errs {
+		result := Diagnostic{
+			Severity: DiagnosticError,
+			Code:     Code(apiErr.Code),
+		}
+		if severity, ok := apiErr.Meta["severity"]; ok {
+			err := json.Unmarshal(severity, &result.Severity)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing severity of error %d: %w", pos, err)
+			}
+		}
+		if paths, ok := apiErr.Meta["paths"]; ok {
+			err := json.Unmarshal(paths, &result.Paths)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing paths of error %d: %w", pos, err)
+			}
+		} else if apiErr.Source != nil {
+			switch {
+			case apiErr.Source.Header != "":
+				result.Paths = []Steps{HeaderPath(apiErr.Source.Header)}
+			case apiErr.Source.Parameter != "":
+				result.Paths = []Steps{URLParamPath(apiErr.Source.Parameter)}
+			case apiErr.Source.Pointer != "":
+				path, err := BodyPathFromJSONPointer(apiErr.Source.Pointer)
+				if err != nil {
+					return nil, fmt.Errorf("error parsing source pointer of error %d: %w", pos, err)
+				}
+				result.Paths = []Steps{path}
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
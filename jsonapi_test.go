@@ -0,0 +1,173 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nsf/jsondiff"
+)
+
+func TestJSONAPIErrorsFromDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		status   int
+		diags    Diagnostics
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"empty": {
+			status:   http.StatusBadRequest,
+			expected: `[]`,
+		},
+		"pointer": {
+			status: http.StatusUnprocessableEntity,
+			diags: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     CodeInsufficient,
+					Paths:    []Steps{BodyPath().AddSteps(ObjectPropertyStep("data"), ObjectPropertyStep("attributes"), ObjectPropertyStep("title"))},
+				},
+			},
+			expected: `[{"status": "422", "code": "insufficient", "source": {"pointer": "/data/attributes/title"}, "meta": {"severity": "error"}}]`,
+		},
+		"parameter-and-header": {
+			status: http.StatusBadRequest,
+			diags: Diagnostics{
+				{Severity: DiagnosticError, Code: CodeInvalidValue, Paths: []Steps{URLParamPath("page")}},
+				{Severity: DiagnosticWarning, Code: CodeDeprecated, Paths: []Steps{HeaderPath("X-Legacy")}},
+			},
+			expected: `[{"status": "400", "code": "invalid_value", "source": {"parameter": "page"}, "meta": {"severity": "error"}}, {"status": "400", "code": "deprecated", "source": {"header": "X-Legacy"}, "meta": {"severity": "warning"}}]`,
+		},
+		"multiple-paths": {
+			diags: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     CodeConflict,
+					Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("start")), BodyPath().AddStep(ObjectPropertyStep("end"))},
+				},
+			},
+			expected: `[{"code": "conflict", "source": {"pointer": "/start"}, "meta": {"severity": "error", "paths": [[{"kind": "body"}, {"kind": "object_property", "value": "start"}], [{"kind": "body"}, {"kind": "object_property", "value": "end"}]]}}]`,
+		},
+		"inexact-pointer": {
+			diags: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     CodeInvalidFormat,
+					Paths:    []Steps{BodyPath().AddSteps(ObjectPropertyStep("name"), StringIndexStep(2))},
+				},
+			},
+			expected: `[{"code": "invalid_format", "source": {"pointer": "/name"}, "meta": {"severity": "error", "paths": [[{"kind": "body"}, {"kind": "object_property", "value": "name"}, {"kind": "string_index", "value": 2}]]}}]`,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			errs, err := JSONAPIErrorsFromDiagnostics(tc.status, tc.diags)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			result, err := json.Marshal(errs)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			opts := jsondiff.DefaultConsoleOptions()
+			match, diff := jsondiff.Compare([]byte(tc.expected), result, &opts)
+			if match != jsondiff.FullMatch {
+				t.Errorf("Unexpected result: %s", diff)
+			}
+			if match > jsondiff.NoMatch {
+				t.Logf("first argument: %s", tc.expected)
+				t.Logf("second argument: %s", result)
+			}
+
+			roundTripped, err := DiagnosticsFromJSONAPIErrors(errs)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			expected := tc.diags
+			if expected == nil {
+				expected = Diagnostics{}
+			}
+			if diff := cmp.Diff(expected, roundTripped); diff != "" {
+				t.Fatalf("unexpected round trip results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestDiagnosticsFromJSONAPIErrors(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    string
+		expected Diagnostics
+		wantErr  bool
+	}
+
+	cases := map[string]testCase{
+		"foreign-errors": {
+			input: `[{"status": "422", "code": "too_short", "title": "Too short", "source": {"pointer": "/data/attributes/tags/0"}}, {"code": "bad_filter", "source": {"parameter": "filter"}}, {"code": "no_source"}]`,
+			expected: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     "too_short",
+					Paths: []Steps{BodyPath().AddSteps(
+						ObjectPropertyStep("data"),
+						ObjectPropertyStep("attributes"),
+						ObjectPropertyStep("tags"),
+						ArrayIndexStep(0),
+					)},
+				},
+				{
+					Severity: DiagnosticError,
+					Code:     "bad_filter",
+					Paths:    []Steps{URLParamPath("filter")},
+				},
+				{
+					Severity: DiagnosticError,
+					Code:     "no_source",
+				},
+			},
+		},
+		"invalid-paths": {
+			input:   `[{"code": "conflict", "meta": {"paths": "nope"}}]`,
+			wantErr: true,
+		},
+		"invalid-pointer": {
+			input:   `[{"code": "conflict", "source": {"pointer": "data"}}]`,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var errs []JSONAPIError
+			err := json.Unmarshal([]byte(tc.input), &errs)
+			if err != nil {
+				t.Fatalf("unexpected error decoding input: %s", err)
+			}
+			result, err := DiagnosticsFromJSONAPIErrors(errs)
+			if err != nil && !tc.wantErr {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && tc.wantErr {
+				t.Fatalf("expected error, got %+v", result)
+			}
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
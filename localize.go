@@ -0,0 +1,285 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Catalog holds translations of the messages for Codes, so clients can show
+// Diagnostics in their users' languages while APIs keep their responses
+// language-neutral.
+//
+// Messages are templates, with placeholders in braces filled in from the
+// Diagnostic being rendered: `{path}` is its first Path, rendered using
+// Steps.FieldPath, and any other placeholder, like `{max}`, is the extension
+// member of the same name. String members are used as-is, arrays are joined
+// with commas, and any other value is used as its JSON encoding.
+// Placeholders without a value are left alone.
+//
+// The zero value is an empty Catalog, ready to use. A Catalog is safe for
+// concurrent use once it's done being added to.
+type Catalog struct {
+	messages map[string]map[Code]string
+}
+
+// Add adds messages, a map of Codes to message templates, to the Catalog as
+// translations into lang, a BCP 47 language tag like "fr" or "pt-BR".
+// Messages already in the Catalog for the same language and Code are
+// replaced.
+func (c *Catalog) Add(lang string, messages map[Code]string) {
+	if c.messages == nil {
+		c.messages = map[string]map[Code]string{}
+	}
+	lang = strings.ToLower(lang)
+	if c.messages[lang] == nil {
+		c.messages[lang] = map[Code]string{}
+	}
+	for code, msg := range messages {
+		c.messages[lang][code] = msg
+	}
+}
+
+// Languages returns the language tags the Catalog has messages for, in
+// lexical order.
+func (c *Catalog) Languages() []string {
+	results := make([]string, 0, len(c.messages))
+	for lang := range c.messages {
+		results = append(results, lang)
+	}
+	sort.Strings(results)
+	return results
+}
+
+// LoadCatalog builds a Catalog from the JSON files in dir of fsys, usually
+// an embed.FS bundled with an SDK. Each file is named for its language,
+// like `fr.json` or `pt-BR.json`, and holds an object mapping Codes to
+// message templates:
+//
+//	{"missing": "Ce champ est obligatoire.", "overflow": "{max} au maximum."}
+//
+// Files without a .json extension are ignored.
+func LoadCatalog(fsys fs.FS, dir string) (*Catalog, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading catalog directory: %w", err)
+	}
+	var catalog Catalog
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		contents, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", entry.Name(), err)
+		}
+		var messages map[Code]string
+		err = json.Unmarshal(contents, &messages)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", entry.Name(), err)
+		}
+		catalog.Add(strings.TrimSuffix(entry.Name(), ".json"), messages)
+	}
+	return &catalog, nil
+}
+
+// lookup returns the template for code in the first of langs the Catalog
+// has one for, and the language it's in. Each language tag is tried as-is,
+// then with its subtags removed one at a time, so "pt-BR" falls back to
+// "pt". A nil Catalog has no templates.
+func (c *Catalog) lookup(code Code, langs []string) (string, string, bool) {
+	if c == nil {
+		return "", "", false
+	}
+	for _, lang := range langs {
+		lang = strings.ToLower(lang)
+		for lang != "" {
+			if msg, ok := c.messages[lang][code]; ok {
+				return msg, lang, true
+			}
+			end := strings.LastIndexByte(lang, '-')
+			if end < 0 {
+				break
+			}
+			lang = lang[:end]
+		}
+	}
+	return "", "", false
+}
+
+// Message renders diag's message in the first of langs the Catalog has a
+// message for diag's Code in, in order of preference, like those returned
+// by ParseAcceptLanguage. If it doesn't have one in any of them,
+// diag.Message is returned.
+func (c *Catalog) Message(diag Diagnostic, langs ...string) string {
+	if msg, _, ok := c.Translation(diag, langs...); ok {
+		return msg
+	}
+	return diag.Message()
+}
+
+// Translation renders diag's message in the first of langs the Catalog has
+// a message for diag's Code in, like Message, and returns it along with the
+// language it's in, as returned by Languages. If the Catalog has no message
+// for diag's Code in any of langs, ok is false.
+func (c *Catalog) Translation(diag Diagnostic, langs ...string) (msg, lang string, ok bool) {
+	template, lang, ok := c.lookup(diag.Code, langs)
+	if !ok {
+		return "", "", false
+	}
+	return renderTemplate(template, diag), lang, true
+}
+
+// Localize returns a copy of diags with the Summary of each Diagnostic the
+// Catalog has a message for replaced by Message, rendered in the first of
+// langs it can be. The Detail of those Diagnostics is removed, as it's in
+// the API's language, not the user's. Diagnostics the Catalog has no
+// message for are left unchanged, except for having their Template
+// rendered, using Diagnostic.RenderMessage.
+func (c *Catalog) Localize(diags Diagnostics, langs ...string) Diagnostics {
+	if diags == nil {
+		return nil
+	}
+	results := make(Diagnostics, 0, len(diags))
+	for _, diag := range diags {
+		if diag.Template != nil {
+			results = append(results, diag.RenderMessage(c, langs...))
+			continue
+		}
+		if template, _, ok := c.lookup(diag.Code, langs); ok {
+			diag.Summary = renderTemplate(template, diag)
+			diag.Detail = ""
+		}
+		results = append(results, diag)
+	}
+	return results
+}
+
+// renderTemplate fills in the placeholders in template from diag.
+func renderTemplate(template string, diag Diagnostic) string {
+	var result strings.Builder
+	for {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		result.WriteString(template[:start])
+		value, ok := placeholderValue(template[start+1:end], diag)
+		if ok {
+			result.WriteString(value)
+		} else {
+			result.WriteString(template[start : end+1])
+		}
+		template = template[end+1:]
+	}
+	result.WriteString(template)
+	return result.String()
+}
+
+// placeholderValue returns the value of the placeholder name for diag.
+func placeholderValue(name string, diag Diagnostic) (string, bool) {
+	if diag.Template != nil {
+		if value, ok := diag.Template.Args[name]; ok {
+			return fmt.Sprint(value), true
+		}
+	}
+	if name == "path" {
+		if len(diag.Paths) < 1 {
+			return "", false
+		}
+		return diag.Paths[0].FieldPath(), true
+	}
+	value, ok := diag.Extensions[name]
+	if !ok {
+		return "", false
+	}
+	return extensionText(value), true
+}
+
+// extensionText renders an extension member's value as text.
+func extensionText(value json.RawMessage) string {
+	var str string
+	if err := json.Unmarshal(value, &str); err == nil {
+		return str
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(value, &list); err == nil {
+		items := make([]string, 0, len(list))
+		for _, item := range list {
+			items = append(items, extensionText(item))
+		}
+		return strings.Join(items, ", ")
+	}
+	return string(value)
+}
+
+// WithCatalog makes WriteHTTP, WriteEnvelope, WriteBatch, and
+// InjectDiagnostics localize the Diagnostics they write using catalog, in
+// the first of langs it can, as Catalog.Localize does. Templates are only
+// rendered then, after Diagnostics have been filtered out and hooks have
+// run, and only in the language being written:
+//
+//	apidiags.WithCatalog(catalog, apidiags.ParseAcceptLanguage(r.Header.Get("Accept-Language"))...)
+func WithCatalog(catalog *Catalog, langs ...string) HTTPOption {
+	return func(c *httpConfig) {
+		c.catalog = catalog
+		c.langs = langs
+	}
+}
+
+// localize returns diags localized using the Catalog passed to
+// WithCatalog, or as-is if there isn't one.
+func (c httpConfig) localize(diags Diagnostics) Diagnostics {
+	if c.catalog == nil {
+		return diags
+	}
+	return c.catalog.Localize(diags, c.langs...)
+}
+
+// ParseAcceptLanguage returns the language tags in an Accept-Language
+// header, ordered from most to least preferred by their quality values.
+// Tags with a quality of 0, and the `*` wildcard, are left out.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		params = strings.TrimSpace(params)
+		if strings.HasPrefix(params, "q=") {
+			parsed, err := strconv.ParseFloat(params[len("q="):], 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag: tag, quality: quality})
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].quality > tags[j].quality
+	})
+	results := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		results = append(results, tag.tag)
+	}
+	return results
+}
@@ -0,0 +1,164 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCatalogMessage(t *testing.T) {
+	t.Parallel()
+
+	catalog, err := LoadCatalog(fstest.MapFS{
+		"locales/fr.json":    {Data: []byte(`{"missing": "{path} est obligatoire.", "overflow": "{max} caractères au maximum.", "invalid_value": "Valeurs autorisées : {allowed}."}`)},
+		"locales/pt-BR.json": {Data: []byte(`{"missing": "{path} é obrigatório."}`)},
+		"locales/README.md":  {Data: []byte(`Translations.`)},
+	}, "locales")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff([]string{"fr", "pt-br"}, catalog.Languages()); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+
+	type testCase struct {
+		diag     Diagnostic
+		langs    []string
+		expected string
+		lang     string
+	}
+
+	namePath := BodyPath().AddStep(ObjectPropertyStep("name"))
+	cases := map[string]testCase{
+		"path": {
+			diag:     Diagnostic{Severity: DiagnosticError, Code: CodeMissing, Paths: []Steps{namePath}},
+			langs:    []string{"fr"},
+			expected: "name est obligatoire.",
+			lang:     "fr",
+		},
+		"extension": {
+			diag: Diagnostic{Severity: DiagnosticError, Code: CodeOverflow, Extensions: map[string]json.RawMessage{
+				MaxMember: json.RawMessage(`80`),
+			}},
+			langs:    []string{"fr-CA"},
+			expected: "80 caractères au maximum.",
+			lang:     "fr",
+		},
+		"list": {
+			diag: Diagnostic{Severity: DiagnosticError, Code: CodeInvalidValue, Extensions: map[string]json.RawMessage{
+				AllowedMember: json.RawMessage(`["red","blue"]`),
+			}},
+			langs:    []string{"fr"},
+			expected: "Valeurs autorisées : red, blue.",
+			lang:     "fr",
+		},
+		"missing-placeholder": {
+			diag:     Diagnostic{Severity: DiagnosticError, Code: CodeOverflow},
+			langs:    []string{"fr"},
+			expected: "{max} caractères au maximum.",
+			lang:     "fr",
+		},
+		"preference": {
+			diag:     Diagnostic{Severity: DiagnosticError, Code: CodeMissing, Paths: []Steps{namePath}},
+			langs:    []string{"de", "PT-br", "fr"},
+			expected: "name é obrigatório.",
+			lang:     "pt-br",
+		},
+		"fallback": {
+			diag:     Diagnostic{Severity: DiagnosticError, Code: CodeConflict, Summary: "That name is taken."},
+			langs:    []string{"fr"},
+			expected: "That name is taken.",
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if result := catalog.Message(tc.diag, tc.langs...); result != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, result)
+			}
+			msg, lang, ok := catalog.Translation(tc.diag, tc.langs...)
+			if ok != (tc.lang != "") {
+				t.Fatalf("expected ok to be %v, got %v", tc.lang != "", ok)
+			}
+			if ok && msg != tc.expected {
+				t.Errorf("expected translation %q, got %q", tc.expected, msg)
+			}
+			if lang != tc.lang {
+				t.Errorf("expected language %q, got %q", tc.lang, lang)
+			}
+		})
+	}
+}
+
+func TestCatalogLocalize(t *testing.T) {
+	t.Parallel()
+
+	var catalog Catalog
+	catalog.Add("fr", map[Code]string{CodeMissing: "Obligatoire."})
+	diags := Diagnostics{
+		{Severity: DiagnosticError, Code: CodeMissing, Summary: "Required.", Detail: "Set a name."},
+		{Severity: DiagnosticError, Code: CodeConflict, Summary: "Taken.", Detail: "Choose another name."},
+	}
+	expected := Diagnostics{
+		{Severity: DiagnosticError, Code: CodeMissing, Summary: "Obligatoire."},
+		{Severity: DiagnosticError, Code: CodeConflict, Summary: "Taken.", Detail: "Choose another name."},
+	}
+	if diff := cmp.Diff(expected, catalog.Localize(diags, "fr")); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	if diags[0].Summary != "Required." {
+		t.Errorf("expected the original Diagnostics to be unchanged, got %q", diags[0].Summary)
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string][]string{
+		"":                                   {},
+		"fr":                                 {"fr"},
+		"fr-CH, fr;q=0.9, en;q=0.8, *;q=0.5": {"fr-CH", "fr", "en"},
+		"en;q=0.5, de, es;q=0":               {"de", "en"},
+		"en;q=bad, de":                       {"de"},
+	}
+
+	for header, expected := range cases {
+		header, expected := header, expected
+
+		t.Run(header, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(expected, ParseAcceptLanguage(header)); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestCatalogLocalizeTemplates(t *testing.T) {
+	t.Parallel()
+
+	var catalog Catalog
+	catalog.Add("fr", map[Code]string{CodeMissing: "{field} est obligatoire."})
+	tmpl := MessageTemplate{Summary: "{field} is required.", Detail: "Set {field}.", Args: map[string]any{"field": "name"}}
+	diags := Diagnostics{
+		Diagnostic{Severity: DiagnosticError, Code: CodeMissing}.WithTemplate(tmpl),
+		Diagnostic{Severity: DiagnosticError, Code: CodeConflict}.WithTemplate(tmpl),
+	}
+	expected := Diagnostics{
+		{Severity: DiagnosticError, Code: CodeMissing, Summary: "name est obligatoire."},
+		{Severity: DiagnosticError, Code: CodeConflict, Summary: "name is required.", Detail: "Set name."},
+	}
+	if diff := cmp.Diff(expected, catalog.Localize(diags, "fr")); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	if message := catalog.Message(diags[1], "fr"); message != "name is required." {
+		t.Errorf("expected %q, got %q", "name is required.", message)
+	}
+}
@@ -0,0 +1,82 @@
+package apidiags
+
+// Pattern is a compiled pattern that can be matched against Steps. Patterns
+// are written in the notation described by Steps.String, with the addition of
+// wildcards:
+//
+//   - `.*` matches any single ObjectPropertyStep
+//   - `[*]` matches any single ArrayIndexStep
+//   - `kind(*)`, like `header(*)`, matches any single Step of that kind
+//   - `.**` matches any number of Steps, including none
+//
+// So `body.items[*].name` matches the name property of every item in the
+// items array of the body, and `body.**.name` matches every name property
+// anywhere in the body.
+type Pattern struct {
+	segments []notationSegment
+}
+
+// CompilePattern parses a pattern so it can be reused to match many Steps.
+func CompilePattern(pattern string) (Pattern, error) {
+	segments, err := parseNotation(pattern, true)
+	if err != nil {
+		return Pattern{}, err
+	}
+	return Pattern{segments: segments}, nil
+}
+
+// MustCompilePattern is like CompilePattern, but panics if the pattern can't
+// be parsed. It's intended for patterns that are hardcoded.
+func MustCompilePattern(pattern string) Pattern {
+	compiled, err := CompilePattern(pattern)
+	if err != nil {
+		panic("apidiags: error compiling pattern " + pattern + ": " + err.Error())
+	}
+	return compiled
+}
+
+// Match returns true if the pattern matches steps exactly. Header names are
+// matched case-insensitively.
+func (p Pattern) Match(steps Steps) bool {
+	return matchSegments(p.segments, steps)
+}
+
+// Match returns true if pattern matches steps exactly. See Pattern for the
+// pattern syntax. The only possible error is from parsing the pattern.
+func Match(pattern string, steps Steps) (bool, error) {
+	compiled, err := CompilePattern(pattern)
+	if err != nil {
+		return false, err
+	}
+	return compiled.Match(steps), nil
+}
+
+func matchSegments(segments []notationSegment, steps Steps) bool {
+	for len(segments) > 0 {
+		segment := segments[0]
+		if segment.kind == "**" {
+			for skip := 0; skip <= len(steps); skip++ {
+				if matchSegments(segments[1:], steps[skip:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(steps) == 0 || !segment.matches(steps[0]) {
+			return false
+		}
+		segments, steps = segments[1:], steps[1:]
+	}
+	return len(steps) == 0
+}
+
+func (segment notationSegment) matches(step Step) bool {
+	if segment.kind == "" {
+		return stepsEqual(segment.step, step)
+	}
+	genStep, err := toGenericStep(step)
+	if err != nil {
+		return false
+	}
+	return genStep.Kind == segment.kind
+}
@@ -0,0 +1,134 @@
+package apidiags
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		pattern  string
+		steps    Steps
+		expected bool
+	}
+
+	itemName := BodyPath().
+		AddStep(ObjectPropertyStep("items")).
+		AddStep(ArrayIndexStep(3)).
+		AddStep(ObjectPropertyStep("name"))
+
+	cases := map[string]testCase{
+		"exact": {
+			pattern:  "body.items[3].name",
+			steps:    itemName,
+			expected: true,
+		},
+		"exact-mismatch": {
+			pattern:  "body.items[2].name",
+			steps:    itemName,
+			expected: false,
+		},
+		"index-wildcard": {
+			pattern:  "body.items[*].name",
+			steps:    itemName,
+			expected: true,
+		},
+		"property-wildcard": {
+			pattern:  "body.*[3].name",
+			steps:    itemName,
+			expected: true,
+		},
+		"index-wildcard-on-property": {
+			pattern:  "body[*][3].name",
+			steps:    itemName,
+			expected: false,
+		},
+		"prefix-only": {
+			pattern:  "body.items",
+			steps:    itemName,
+			expected: false,
+		},
+		"too-long": {
+			pattern:  "body.items[3].name.first",
+			steps:    itemName,
+			expected: false,
+		},
+		"double-wildcard": {
+			pattern:  "body.**.name",
+			steps:    itemName,
+			expected: true,
+		},
+		"double-wildcard-empty": {
+			pattern:  "body.**.items[3].name",
+			steps:    itemName,
+			expected: true,
+		},
+		"double-wildcard-trailing": {
+			pattern:  "body.**",
+			steps:    itemName,
+			expected: true,
+		},
+		"double-wildcard-mismatch": {
+			pattern:  "body.**.id",
+			steps:    itemName,
+			expected: false,
+		},
+		"kind-wildcard": {
+			pattern:  "header(*)",
+			steps:    HeaderPath("Content-Type"),
+			expected: true,
+		},
+		"header-case-insensitive": {
+			pattern:  `header("content-type")`,
+			steps:    HeaderPath("Content-Type"),
+			expected: true,
+		},
+		"kind-wildcard-mismatch": {
+			pattern:  "header(*)",
+			steps:    URLParamPath("Content-Type"),
+			expected: false,
+		},
+		"empty": {
+			pattern:  "",
+			steps:    Steps{},
+			expected: true,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := Match(tc.pattern, tc.steps)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if result != tc.expected {
+				t.Fatalf("expected %v matching %q against %s, got %v", tc.expected, tc.pattern, tc.steps, result)
+			}
+		})
+	}
+}
+
+func TestCompilePatternErrors(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"unknown-kind-wildcard": "foo(*)",
+		"unterminated":          "body.items[*",
+	}
+
+	for name, pattern := range cases {
+		name, pattern := name, pattern
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := CompilePattern(pattern)
+			if err == nil {
+				t.Fatalf("expected error compiling %q", pattern)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// The extension members describing the bounds a value had to stay within,
+// as written by apidiagschecks and apidiagsvalidator, and read by
+// BoundsFrom. For strings, slices, and maps, the bounds apply to their
+// length.
+const (
+	// MinMember holds the smallest value allowed, inclusive.
+	MinMember = "min"
+
+	// MaxMember holds the largest value allowed, inclusive.
+	MaxMember = "max"
+
+	// ExclusiveMinMember holds the value every allowed value is greater
+	// than.
+	ExclusiveMinMember = "exclusive_min"
+
+	// ExclusiveMaxMember holds the value every allowed value is less than.
+	ExclusiveMaxMember = "exclusive_max"
+
+	// AllowedMember holds the list of allowed values.
+	AllowedMember = "allowed"
+
+	// PatternMember holds the regular expression a value had to match.
+	PatternMember = "pattern"
+)
+
+// Bounds are the limits a value had to stay within, as described by a
+// Diagnostic's Extensions. Limits that weren't described are nil or empty.
+type Bounds struct {
+	Min          *float64
+	Max          *float64
+	ExclusiveMin *float64
+	ExclusiveMax *float64
+
+	// Allowed are the allowed values. Strings are unquoted; any other
+	// values are kept as their JSON encoding, like `3` or `true`.
+	Allowed []string
+
+	Pattern string
+}
+
+// BoundsFrom returns the Bounds described by diag's MinMember, MaxMember,
+// ExclusiveMinMember, ExclusiveMaxMember, AllowedMember, and PatternMember
+// extensions. If diag has none of them, false is returned. An error is
+// returned if any of them have the wrong type.
+func BoundsFrom(diag Diagnostic) (Bounds, bool, error) {
+	var result Bounds
+	var found bool
+	numbers := []struct {
+		member string
+		target **float64
+	}{
+		{MinMember, &result.Min},
+		{MaxMember, &result.Max},
+		{ExclusiveMinMember, &result.ExclusiveMin},
+		{ExclusiveMaxMember, &result.ExclusiveMax},
+	}
+	for _, number := range numbers {
+		value, ok := diag.Extensions[number.member]
+		if !ok {
+			continue
+		}
+		var bound float64
+		if err := json.Unmarshal(value, &bound); err != nil {
+			return Bounds{}, false, fmt.Errorf("error parsing %s: %w", number.member, err)
+		}
+		*number.target = &bound
+		found = true
+	}
+	if value, ok := diag.Extensions[AllowedMember]; ok {
+		var allowed []json.RawMessage
+		if err := json.Unmarshal(value, &allowed); err != nil {
+			return Bounds{}, false, fmt.Errorf("error parsing %s: %w", AllowedMember, err)
+		}
+		result.Allowed = make([]string, 0, len(allowed))
+		for _, raw := range allowed {
+			var str string
+			if err := json.Unmarshal(raw, &str); err == nil {
+				result.Allowed = append(result.Allowed, str)
+				continue
+			}
+			result.Allowed = append(result.Allowed, string(raw))
+		}
+		found = true
+	}
+	if _, ok := diag.Extensions[PatternMember]; ok {
+		pattern, err := diag.extensionString(PatternMember)
+		if err != nil {
+			return Bounds{}, false, err
+		}
+		result.Pattern = pattern
+		found = true
+	}
+	return result, found, nil
+}
+
+// RateLimitMember is the extension member of a Diagnostic holding the rate
+// limit the request was subject to, as an object with "limit", "remaining",
+// and "reset" members. It's usually set on Diagnostics with a Code of
+// CodeActOfGod, alongside RetryAfterMember.
+const RateLimitMember = "rate_limit"
+
+// RateLimit describes the rate limit a request was subject to.
+type RateLimit struct {
+	// Limit is the number of requests allowed in each window.
+	Limit int64
+
+	// Remaining is the number of requests left in the current window.
+	Remaining int64
+
+	// Reset is how long until the current window ends and Remaining
+	// resets to Limit. It's recorded in whole seconds, rounded up.
+	Reset time.Duration
+}
+
+type rateLimitJSON struct {
+	Limit     int64 `json:"limit"`
+	Remaining int64 `json:"remaining"`
+	Reset     int64 `json:"reset"`
+}
+
+// WithRateLimit returns a copy of d with limit recorded in its Extensions
+// as RateLimitMember.
+func (d Diagnostic) WithRateLimit(limit RateLimit) Diagnostic {
+	encoded, err := json.Marshal(rateLimitJSON{
+		Limit:     limit.Limit,
+		Remaining: limit.Remaining,
+		Reset:     retryAfterSeconds(limit.Reset),
+	})
+	if err != nil {
+		return d
+	}
+	return d.withExtension(RateLimitMember, encoded)
+}
+
+// RateLimitFrom returns the RateLimit recorded in diag's Extensions by
+// WithRateLimit. If diag doesn't have one, false is returned. An error is
+// returned if RateLimitMember isn't an object, or any of its members are
+// negative.
+func RateLimitFrom(diag Diagnostic) (RateLimit, bool, error) {
+	value, ok := diag.Extensions[RateLimitMember]
+	if !ok {
+		return RateLimit{}, false, nil
+	}
+	var decoded rateLimitJSON
+	if err := json.Unmarshal(value, &decoded); err != nil {
+		return RateLimit{}, false, fmt.Errorf("error parsing %s: %w", RateLimitMember, err)
+	}
+	if decoded.Limit < 0 || decoded.Remaining < 0 || decoded.Reset < 0 {
+		return RateLimit{}, false, fmt.Errorf("error parsing %s: negative value in %s", RateLimitMember, value)
+	}
+	return RateLimit{
+		Limit:     decoded.Limit,
+		Remaining: decoded.Remaining,
+		Reset:     time.Duration(decoded.Reset) * time.Second,
+	}, true, nil
+}
+
+// Deprecation describes something a request used that's deprecated.
+type Deprecation struct {
+	// Deprecated is when it was deprecated, or the zero time if that
+	// wasn't recorded.
+	Deprecated time.Time
+
+	// Sunset is when it will stop working, or the zero time if that
+	// wasn't recorded.
+	Sunset time.Time
+
+	// DocURL is the Diagnostic's DocURL, which usually points to migration
+	// documentation.
+	DocURL string
+}
+
+// DeprecationFrom returns the Deprecation described by diag, using the
+// times recorded by WithDeprecation. If diag doesn't have a Code of
+// CodeDeprecated, false is returned. An error is returned if either time
+// isn't an RFC 3339 timestamp.
+func DeprecationFrom(diag Diagnostic) (Deprecation, bool, error) {
+	if diag.Code != CodeDeprecated {
+		return Deprecation{}, false, nil
+	}
+	deprecated, sunset, err := diag.Deprecation()
+	if err != nil {
+		return Deprecation{}, false, err
+	}
+	return Deprecation{
+		Deprecated: deprecated,
+		Sunset:     sunset,
+		DocURL:     diag.DocURL,
+	}, true, nil
+}
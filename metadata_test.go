@@ -0,0 +1,147 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBoundsFrom(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		extensions map[string]json.RawMessage
+		expected   Bounds
+		found      bool
+		err        bool
+	}
+
+	one, ten := 1.0, 10.5
+
+	cases := map[string]testCase{
+		"none": {
+			extensions: map[string]json.RawMessage{RetryAfterMember: json.RawMessage(`1`)},
+		},
+		"numbers": {
+			extensions: map[string]json.RawMessage{
+				MinMember:          json.RawMessage(`1`),
+				ExclusiveMaxMember: json.RawMessage(`10.5`),
+			},
+			expected: Bounds{Min: &one, ExclusiveMax: &ten},
+			found:    true,
+		},
+		"allowed": {
+			extensions: map[string]json.RawMessage{
+				AllowedMember: json.RawMessage(`["red", 3, true]`),
+			},
+			expected: Bounds{Allowed: []string{"red", "3", "true"}},
+			found:    true,
+		},
+		"pattern": {
+			extensions: map[string]json.RawMessage{
+				PatternMember: json.RawMessage(`"^[a-z]+$"`),
+			},
+			expected: Bounds{Pattern: "^[a-z]+$"},
+			found:    true,
+		},
+		"invalid-number": {
+			extensions: map[string]json.RawMessage{MaxMember: json.RawMessage(`"ten"`)},
+			err:        true,
+		},
+		"invalid-allowed": {
+			extensions: map[string]json.RawMessage{AllowedMember: json.RawMessage(`"red"`)},
+			err:        true,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			bounds, found, err := BoundsFrom(Diagnostic{Severity: DiagnosticError, Code: CodeOverflow, Extensions: tc.extensions})
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", bounds)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if found != tc.found {
+				t.Errorf("expected found to be %v, got %v", tc.found, found)
+			}
+			if diff := cmp.Diff(tc.expected, bounds); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestRateLimitFrom(t *testing.T) {
+	t.Parallel()
+
+	diag := Diagnostic{Severity: DiagnosticError, Code: CodeActOfGod}.WithRateLimit(RateLimit{
+		Limit:     100,
+		Remaining: 0,
+		Reset:     1500 * time.Millisecond,
+	})
+	encoded, err := diag.MarshalJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := `{"severity":"error","code":"act_of_god","rate_limit":{"limit":100,"remaining":0,"reset":2}}`
+	if string(encoded) != expected {
+		t.Errorf("expected %s, got %s", expected, encoded)
+	}
+	limit, found, err := RateLimitFrom(diag)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !found {
+		t.Fatal("expected a rate limit to be found")
+	}
+	if diff := cmp.Diff(RateLimit{Limit: 100, Reset: 2 * time.Second}, limit); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+
+	if _, found, err := RateLimitFrom(Diagnostic{}); found || err != nil {
+		t.Errorf("expected no rate limit and no error, got %v and %v", found, err)
+	}
+	negative := Diagnostic{Extensions: map[string]json.RawMessage{RateLimitMember: json.RawMessage(`{"limit":-1}`)}}
+	if _, _, err := RateLimitFrom(negative); err == nil {
+		t.Error("expected an error for a negative limit")
+	}
+}
+
+func TestDeprecationFrom(t *testing.T) {
+	t.Parallel()
+
+	deprecated := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	diag := Diagnostic{
+		Severity: DiagnosticWarning,
+		Code:     CodeDeprecated,
+		DocURL:   "https://example.com/migrate",
+	}.WithDeprecation(deprecated, sunset)
+
+	result, found, err := DeprecationFrom(diag)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !found {
+		t.Fatal("expected a deprecation to be found")
+	}
+	expected := Deprecation{Deprecated: deprecated, Sunset: sunset, DocURL: "https://example.com/migrate"}
+	if diff := cmp.Diff(expected, result); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+
+	if _, found, err := DeprecationFrom(Diagnostic{Severity: DiagnosticError, Code: CodeMissing}); found || err != nil {
+		t.Errorf("expected no deprecation and no error, got %v and %v", found, err)
+	}
+}
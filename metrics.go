@@ -0,0 +1,45 @@
+package apidiags
+
+// Metrics records the Diagnostics a service sends in its responses, so
+// they can be counted by Code, Severity, and endpoint, and alerted on.
+// apidiagsprometheus has an implementation that exports them as Prometheus
+// metrics.
+//
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// ObserveDiagnostics records that diags were sent in a response from
+	// endpoint.
+	ObserveDiagnostics(endpoint string, diags Diagnostics)
+}
+
+// MetricsFunc is a function that implements Metrics.
+type MetricsFunc func(endpoint string, diags Diagnostics)
+
+// ObserveDiagnostics calls f(endpoint, diags).
+func (f MetricsFunc) ObserveDiagnostics(endpoint string, diags Diagnostics) {
+	f(endpoint, diags)
+}
+
+// WithMetrics makes WriteHTTP, WriteEnvelope, WriteBatch, and
+// InjectDiagnostics record the Diagnostics they write with metrics, as
+// being sent from endpoint. endpoint should identify the route, like
+// "POST /widgets/{id}", rather than the specific URL requested, to keep the
+// number of distinct endpoints small. Diagnostics left out of the response,
+// like suppressed warnings, aren't recorded, and nothing is recorded when
+// there are no Diagnostics.
+func WithMetrics(metrics Metrics, endpoint string) HTTPOption {
+	return func(c *httpConfig) {
+		c.metrics = metrics
+		c.endpoint = endpoint
+	}
+}
+
+// observe records diags with the Metrics set by WithMetrics and the
+// Auditor set by WithAuditor, if any.
+func (c httpConfig) observe(diags Diagnostics) {
+	c.audit(diags)
+	if c.metrics == nil || len(diags) < 1 {
+		return
+	}
+	c.metrics.ObserveDiagnostics(c.endpoint, diags)
+}
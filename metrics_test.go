@@ -0,0 +1,108 @@
+package apidiags
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// recordingMetrics is a Metrics that records every observation.
+type recordingMetrics struct {
+	mu       sync.Mutex
+	observed map[string]Diagnostics
+}
+
+func (m *recordingMetrics) ObserveDiagnostics(endpoint string, diags Diagnostics) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.observed == nil {
+		m.observed = map[string]Diagnostics{}
+	}
+	m.observed[endpoint] = append(m.observed[endpoint], diags...)
+}
+
+func TestWithMetrics(t *testing.T) {
+	t.Parallel()
+
+	warning := Diagnostic{Severity: DiagnosticWarning, Code: CodeDeprecated}
+	conflict := Diagnostic{Severity: DiagnosticError, Code: CodeConflict}
+	missing := Diagnostic{Severity: DiagnosticError, Code: CodeMissing}
+
+	type testCase struct {
+		write    func(w http.ResponseWriter, r *http.Request, opts ...HTTPOption)
+		expected map[string]Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"write-http": {
+			write: func(w http.ResponseWriter, _ *http.Request, opts ...HTTPOption) {
+				_ = WriteHTTP(w, Diagnostics{warning, conflict}, opts...)
+			},
+			expected: map[string]Diagnostics{"test": {warning, conflict}},
+		},
+		"write-http-suppressed": {
+			write: func(w http.ResponseWriter, r *http.Request, opts ...HTTPOption) {
+				r.Header.Set(SuppressWarningsHeader, "*")
+				_ = WriteHTTP(w, Diagnostics{warning}, append(opts, WithWarningSuppression(r))...)
+			},
+		},
+		"write-envelope": {
+			write: func(w http.ResponseWriter, _ *http.Request, opts ...HTTPOption) {
+				_ = WriteEnvelope(w, NewEnvelope("ok", Diagnostics{warning}), opts...)
+			},
+			expected: map[string]Diagnostics{"test": {warning}},
+		},
+		"write-batch": {
+			write: func(w http.ResponseWriter, _ *http.Request, opts ...HTTPOption) {
+				_ = WriteBatch(w, Batch[string]{
+					Results: []ItemResult[string]{
+						{Index: 0, Data: "ok"},
+						{Index: 1, Diagnostics: Diagnostics{missing}},
+					},
+					Diagnostics: Diagnostics{warning},
+				}, opts...)
+			},
+			expected: map[string]Diagnostics{"test": {warning, missing}},
+		},
+		"inject": {
+			write: func(w http.ResponseWriter, r *http.Request, opts ...HTTPOption) {
+				InjectDiagnostics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					AddDiagnostics(r.Context(), warning)
+					w.Header().Set("Content-Type", "application/json")
+					_, _ = w.Write([]byte(`{"data":"ok"}`))
+				}), opts...).ServeHTTP(w, r)
+			},
+			expected: map[string]Diagnostics{"test": {warning}},
+		},
+		"inject-error": {
+			write: func(w http.ResponseWriter, r *http.Request, opts ...HTTPOption) {
+				InjectDiagnostics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					AddDiagnostics(r.Context(), conflict)
+					_, _ = w.Write([]byte(`ok`))
+				}), opts...).ServeHTTP(w, r)
+			},
+			expected: map[string]Diagnostics{"test": {conflict}},
+		},
+		"nothing": {
+			write: func(w http.ResponseWriter, _ *http.Request, opts ...HTTPOption) {
+				_ = WriteEnvelope(w, NewEnvelope("ok", nil), opts...)
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			metrics := &recordingMetrics{}
+			tc.write(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), WithMetrics(metrics, "test"))
+			if diff := cmp.Diff(tc.expected, metrics.observed); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,354 @@
+package apidiags
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// String renders the Steps in a compact, human-readable notation, like
+// `body.items[3].name` or `header("Content-Type")`.
+//
+// ObjectPropertySteps are written as `.name` when the property name is a
+// simple identifier, and as `["property name"]` otherwise. ArrayIndexSteps
+// are written as `[3]`. Every other Step is written using its kind, with its
+// value (if any) JSON-encoded in parentheses: `body`, `url_param("id")`,
+// `string_index(2)`. Steps after the first are separated by a `.`, except
+// for bracketed steps, which need no separator.
+//
+// Steps that can't be rendered are written as `<unknown>`.
+func (steps Steps) String() string {
+	var buf strings.Builder
+	for pos, step := range steps {
+		switch value := step.(type) {
+		case ArrayIndexStep:
+			fmt.Fprintf(&buf, "[%d]", int64(value))
+			continue
+		case ObjectPropertyStep:
+			if !isNotationIdent(string(value)) || isValuelessKind(string(value)) {
+				buf.WriteString("[")
+				buf.WriteString(quoteNotationString(string(value)))
+				buf.WriteString("]")
+				continue
+			}
+			if pos > 0 {
+				buf.WriteString(".")
+			}
+			buf.WriteString(string(value))
+			continue
+		}
+		if pos > 0 {
+			buf.WriteString(".")
+		}
+		genStep, err := toGenericStep(step)
+		if err != nil {
+			buf.WriteString("<unknown>")
+			continue
+		}
+		buf.WriteString(genStep.Kind)
+		if genStep.Value.kind == valueNone {
+			continue
+		}
+		buf.WriteString("(")
+		if genStep.Value.kind == valueString {
+			buf.WriteString(quoteNotationString(genStep.Value.str))
+		} else {
+			buf.WriteString(genStep.Value.String())
+		}
+		buf.WriteString(")")
+	}
+	return buf.String()
+}
+
+// ParseSteps parses Steps written in the notation described by Steps.String.
+func ParseSteps(in string) (Steps, error) {
+	segments, err := parseNotation(in, false)
+	if err != nil {
+		return nil, err
+	}
+	results := make(Steps, 0, len(segments))
+	for _, segment := range segments {
+		results = append(results, segment.step)
+	}
+	return results, nil
+}
+
+// MarshalText renders the Steps using the notation described by
+// Steps.String, so Steps can be used as URL query parameters, log fields, or
+// flag values. Unlike String, it returns an error if any Step can't be
+// rendered. The JSON, XML, and YAML encodings of Steps are unaffected, and
+// still use the kind and value scheme.
+func (steps Steps) MarshalText() ([]byte, error) {
+	for pos, step := range steps {
+		if _, err := toGenericStep(step); err != nil {
+			return nil, fmt.Errorf("%w for step %d", err, pos)
+		}
+	}
+	return []byte(steps.String()), nil
+}
+
+// UnmarshalText parses Steps written in the notation described by
+// Steps.String, using ParseSteps.
+func (steps *Steps) UnmarshalText(in []byte) error {
+	parsed, err := ParseSteps(string(in))
+	if err != nil {
+		return err
+	}
+	*steps = parsed
+	return nil
+}
+
+// FieldPath renders the Steps like String does, but with the leading BodyStep
+// dropped for Steps pointing into the body, like `items[3].name`. This is the
+// convention for field paths in many error formats, which assume paths point
+// into the body unless told otherwise.
+func (steps Steps) FieldPath() string {
+	if len(steps) > 0 {
+		if _, ok := steps[0].(BodyStep); ok {
+			return steps[1:].String()
+		}
+	}
+	return steps.String()
+}
+
+// ParseFieldPath parses Steps rendered by Steps.FieldPath. Field paths
+// starting with a property name or array index are assumed to point into the
+// body.
+func ParseFieldPath(in string) (Steps, error) {
+	steps, err := ParseSteps(in)
+	if err != nil {
+		return nil, err
+	}
+	if len(steps) > 0 {
+		switch steps[0].(type) {
+		case ObjectPropertyStep, ArrayIndexStep:
+		default:
+			return steps, nil
+		}
+	}
+	return BodyPath().AddSteps(steps...), nil
+}
+
+// isValuelessKind returns true if kind is the kind of a Step that has no
+// value, and would therefore be ambiguous with an ObjectPropertyStep of the
+// same name.
+func isValuelessKind(kind string) bool {
+	return kind == "body"
+}
+
+func isNotationIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for pos := 0; pos < len(s); pos++ {
+		if !isNotationIdentByte(s[pos], pos == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNotationIdentByte(b byte, first bool) bool {
+	switch {
+	case b == '_', b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z':
+		return true
+	case !first && b >= '0' && b <= '9':
+		return true
+	default:
+		return false
+	}
+}
+
+func quoteNotationString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	// encoding a string can't fail
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// notationSegment is a single parsed segment of a path in the notation
+// described by Steps.String. When wildcards are allowed, a segment may
+// describe a whole class of Steps instead of a single Step.
+type notationSegment struct {
+	// step is the Step the segment describes, if it's not a wildcard.
+	step Step
+
+	// kind is set for wildcard segments, and is either the step kind
+	// the wildcard can match, or "**" for a wildcard matching any number
+	// of Steps of any kind.
+	kind string
+}
+
+// parseNotation parses a path written in the notation described by
+// Steps.String. If allowWildcards is true, the `*` wildcard may be used in
+// place of a property name, array index, or value, and `**` may be used in
+// place of a property name to match any number of Steps.
+func parseNotation(in string, allowWildcards bool) ([]notationSegment, error) {
+	parser := notationParser{in: in, allowWildcards: allowWildcards}
+	var segments []notationSegment
+	for parser.pos < len(parser.in) {
+		first := parser.pos == 0
+		var segment notationSegment
+		var err error
+		switch parser.in[parser.pos] {
+		case '[':
+			segment, err = parser.parseBracket()
+		case '.':
+			parser.pos++
+			segment, err = parser.parseName()
+		default:
+			if !first {
+				return nil, fmt.Errorf("unexpected %q at position %d, expected '.' or '['", parser.in[parser.pos], parser.pos)
+			}
+			segment, err = parser.parseName()
+		}
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+type notationParser struct {
+	in             string
+	pos            int
+	allowWildcards bool
+}
+
+func (p *notationParser) peek(s string) bool {
+	return strings.HasPrefix(p.in[p.pos:], s)
+}
+
+func (p *notationParser) expect(s string) error {
+	if !p.peek(s) {
+		return fmt.Errorf("expected %q at position %d", s, p.pos)
+	}
+	p.pos += len(s)
+	return nil
+}
+
+func (p *notationParser) wildcard(kind string) (notationSegment, error) {
+	if !p.allowWildcards {
+		return notationSegment{}, fmt.Errorf("unexpected wildcard at position %d", p.pos)
+	}
+	p.pos++
+	if kind == "object_property" && p.peek("*") {
+		p.pos++
+		kind = "**"
+	}
+	if _, err := (genericStep{Kind: kind}).toStep(); kind != "**" && errors.Is(err, errUnexpectedStepKind) {
+		return notationSegment{}, fmt.Errorf("unexpected step kind %q at position %d", kind, p.pos)
+	}
+	return notationSegment{kind: kind}, nil
+}
+
+// parseBracket parses an array index or quoted property name in brackets.
+func (p *notationParser) parseBracket() (notationSegment, error) {
+	if err := p.expect("["); err != nil {
+		return notationSegment{}, err
+	}
+	var segment notationSegment
+	switch {
+	case p.peek("*"):
+		var err error
+		segment, err = p.wildcard("array_index")
+		if err != nil {
+			return notationSegment{}, err
+		}
+	case p.peek(`"`):
+		value, err := p.parseValue()
+		if err != nil {
+			return notationSegment{}, err
+		}
+		if value.kind != valueString {
+			return notationSegment{}, fmt.Errorf("expected string at position %d", p.pos)
+		}
+		segment = notationSegment{step: ObjectPropertyStep(value.str)}
+	default:
+		value, err := p.parseValue()
+		if err != nil {
+			return notationSegment{}, err
+		}
+		idx, err := genericStep{Kind: "array_index", Value: value}.toStep()
+		if err != nil {
+			return notationSegment{}, fmt.Errorf("invalid array index at position %d: %w", p.pos, err)
+		}
+		segment = notationSegment{step: idx}
+	}
+	if err := p.expect("]"); err != nil {
+		return notationSegment{}, err
+	}
+	return segment, nil
+}
+
+// parseName parses a property name or a step kind, with its value if it has
+// one.
+func (p *notationParser) parseName() (notationSegment, error) {
+	if p.peek("*") {
+		return p.wildcard("object_property")
+	}
+	start := p.pos
+	for p.pos < len(p.in) && isNotationIdentByte(p.in[p.pos], p.pos == start) {
+		p.pos++
+	}
+	name := p.in[start:p.pos]
+	if name == "" {
+		return notationSegment{}, fmt.Errorf("expected name at position %d", p.pos)
+	}
+	if !p.peek("(") {
+		if isValuelessKind(name) {
+			step, err := genericStep{Kind: name}.toStep()
+			return notationSegment{step: step}, err
+		}
+		return notationSegment{step: ObjectPropertyStep(name)}, nil
+	}
+	p.pos++
+	var segment notationSegment
+	if p.peek("*") {
+		var err error
+		segment, err = p.wildcard(name)
+		if err != nil {
+			return notationSegment{}, err
+		}
+	} else {
+		value, err := p.parseValue()
+		if err != nil {
+			return notationSegment{}, err
+		}
+		step, err := genericStep{Kind: name, Value: value}.toStep()
+		if err != nil {
+			return notationSegment{}, fmt.Errorf("invalid step at position %d: %w", start, err)
+		}
+		segment = notationSegment{step: step}
+	}
+	if err := p.expect(")"); err != nil {
+		return notationSegment{}, err
+	}
+	return segment, nil
+}
+
+// parseValue parses a JSON string or number.
+func (p *notationParser) parseValue() (stepValue, error) {
+	dec := json.NewDecoder(strings.NewReader(p.in[p.pos:]))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return stepValue{}, fmt.Errorf("invalid value at position %d: %w", p.pos, err)
+	}
+	var value stepValue
+	switch tok := tok.(type) {
+	case string:
+		value = stringStepValue(tok)
+	case json.Number:
+		value = numberStepValue(string(tok))
+	default:
+		return stepValue{}, fmt.Errorf("invalid value at position %d: unexpected %v", p.pos, tok)
+	}
+	p.pos += int(dec.InputOffset())
+	return value, nil
+}
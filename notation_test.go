@@ -0,0 +1,216 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"flag"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStepsString(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		steps    Steps
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"no-steps": {
+			expected: "",
+		},
+		"body": {
+			steps:    BodyPath(),
+			expected: "body",
+		},
+		"body-prop-arrayIndex-prop": {
+			steps: BodyPath().
+				AddStep(ObjectPropertyStep("items")).
+				AddStep(ArrayIndexStep(3)).
+				AddStep(ObjectPropertyStep("name")),
+			expected: "body.items[3].name",
+		},
+		"body-quotedProp": {
+			steps:    BodyPath().AddStep(ObjectPropertyStep("first name")),
+			expected: `body["first name"]`,
+		},
+		"body-keywordProp": {
+			steps:    BodyPath().AddStep(ObjectPropertyStep("body")),
+			expected: `body["body"]`,
+		},
+		"body-prop-stringIndex": {
+			steps: BodyPath().
+				AddStep(ObjectPropertyStep("name")).
+				AddStep(StringIndexStep(2)),
+			expected: "body.name.string_index(2)",
+		},
+		"header": {
+			steps:    HeaderPath("Content-Type"),
+			expected: `header("Content-Type")`,
+		},
+		"urlParam": {
+			steps:    URLParamPath("<id>"),
+			expected: `url_param("<id>")`,
+		},
+		"relative": {
+			steps:    Steps{ObjectPropertyStep("foo"), ArrayIndexStep(0)},
+			expected: "foo[0]",
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result := tc.steps.String()
+			if result != tc.expected {
+				t.Fatalf("expected %q, got %q", tc.expected, result)
+			}
+
+			parsed, err := ParseSteps(result)
+			if err != nil {
+				t.Fatalf("unexpected error parsing %q: %s", result, err)
+			}
+			if diff := cmp.Diff(tc.steps, parsed); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestParseStepsErrors(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"unknown-kind":       `foo(1)`,
+		"wrong-value-type":   `header(1)`,
+		"unquoted-value":     `header(foo)`,
+		"unterminated-index": `body[1`,
+		"missing-separator":  `body"foo"`,
+		"empty-name":         `body.`,
+		"wildcard":           `body.*`,
+		"fractional-index":   `body[1.5]`,
+	}
+
+	for name, input := range cases {
+		name, input := name, input
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			steps, err := ParseSteps(input)
+			if err == nil {
+				t.Fatalf("expected error, got %s", steps)
+			}
+		})
+	}
+}
+
+type unrenderableStep struct{}
+
+func (unrenderableStep) step() {}
+
+func TestStepsMarshalText(t *testing.T) {
+	t.Parallel()
+
+	steps := BodyPath().AddSteps(ObjectPropertyStep("first name"), ArrayIndexStep(2))
+
+	var flagValue Steps
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.TextVar(&flagValue, "path", Steps{}, "path to check")
+	err := flags.Parse([]string{"-path", `body["first name"][2]`})
+	if err != nil {
+		t.Fatalf("unexpected error parsing flags: %s", err)
+	}
+	if diff := cmp.Diff(steps, flagValue); diff != "" {
+		t.Errorf("unexpected flag value (-wanted, +got): %s", diff)
+	}
+
+	text, err := steps.MarshalText()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	query := url.Values{"path": []string{string(text)}}.Encode()
+	parsedQuery, err := url.ParseQuery(query)
+	if err != nil {
+		t.Fatalf("unexpected error parsing query %q: %s", query, err)
+	}
+	var fromQuery Steps
+	err = fromQuery.UnmarshalText([]byte(parsedQuery.Get("path")))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(steps, fromQuery); diff != "" {
+		t.Errorf("unexpected query value (-wanted, +got): %s", diff)
+	}
+
+	// JSON keeps using the kind and value scheme, not the notation.
+	encoded, err := json.Marshal(steps)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if encoded[0] != '[' {
+		t.Errorf("expected JSON array, got %s", encoded)
+	}
+
+	_, err = Steps{BodyStep{}, unrenderableStep{}}.MarshalText()
+	if err == nil {
+		t.Errorf("expected error marshaling unrenderable step")
+	}
+}
+
+func TestStepsFieldPath(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		steps    Steps
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"body": {
+			steps:    BodyPath(),
+			expected: "",
+		},
+		"body-prop-arrayIndex-prop": {
+			steps:    BodyPath().AddSteps(ObjectPropertyStep("items"), ArrayIndexStep(3), ObjectPropertyStep("name")),
+			expected: "items[3].name",
+		},
+		"body-arrayIndex": {
+			steps:    BodyPath().AddStep(ArrayIndexStep(0)),
+			expected: "[0]",
+		},
+		"body-quotedProp": {
+			steps:    BodyPath().AddStep(ObjectPropertyStep("app.kubernetes.io/name")),
+			expected: `["app.kubernetes.io/name"]`,
+		},
+		"header": {
+			steps:    HeaderPath("X-Request-Id"),
+			expected: `header("X-Request-Id")`,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result := tc.steps.FieldPath()
+			if result != tc.expected {
+				t.Fatalf("expected %q, got %q", tc.expected, result)
+			}
+			parsed, err := ParseFieldPath(result)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(tc.steps, parsed); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
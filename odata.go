@@ -0,0 +1,105 @@
+package apidiags
+
+import "fmt"
+
+// ODataErrorResponse is the body of an OData v4 error response.
+type ODataErrorResponse struct {
+	Error ODataError `json:"error"`
+}
+
+// ODataError is the error object of an OData v4 error response.
+type ODataError struct {
+	Code    string             `json:"code"`
+	Message string             `json:"message"`
+	Target  string             `json:"target,omitempty"`
+	Details []ODataErrorDetail `json:"details,omitempty"`
+}
+
+// ODataErrorDetail is a single entry in the details of an OData v4 error.
+type ODataErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Target  string `json:"target,omitempty"`
+}
+
+// ODataErrorFromDiagnostics converts diags into an OData v4 error response.
+//
+// The top-level code, message, and target come from the first Diagnostic with
+// a Severity of DiagnosticError, or the first Diagnostic if there are no
+// errors. When there's more than one Diagnostic, or a Diagnostic has more
+// than one Path, every Path of every Diagnostic is listed in the details.
+//
+// Targets are rendered using Steps.FieldPath, like `items[3].name`. OData
+// has no concept of Severity, so warnings are indistinguishable from errors
+// in the details. If diags is empty, the zero value is returned.
+func ODataErrorFromDiagnostics(diags Diagnostics) ODataErrorResponse {
+	var result ODataErrorResponse
+	if len(diags) < 1 {
+		return result
+	}
+	primary := diags[0]
+	var details []ODataErrorDetail
+	for _, diag := range diags {
+		if diag.Severity == DiagnosticError && primary.Severity != DiagnosticError {
+			primary = diag
+		}
+		if len(diag.Paths) < 1 {
+			details = append(details, ODataErrorDetail{
+				Code:    string(diag.Code),
+				Message: diag.Message(),
+			})
+		}
+		for _, path := range diag.Paths {
+			details = append(details, ODataErrorDetail{
+				Code:    string(diag.Code),
+				Message: diag.Message(),
+				Target:  path.FieldPath(),
+			})
+		}
+	}
+	result.Error = ODataError{
+		Code:    string(primary.Code),
+		Message: primary.Message(),
+	}
+	if len(primary.Paths) > 0 {
+		result.Error.Target = primary.Paths[0].FieldPath()
+	}
+	if len(details) > 1 {
+		result.Error.Details = details
+	}
+	return result
+}
+
+// DiagnosticsFromODataError converts an OData v4 error response into
+// Diagnostics, one per detail, or a single Diagnostic from the top-level
+// error if there are no details. Every Diagnostic has a Severity of
+// DiagnosticError. Targets are parsed using ParseFieldPath.
+func DiagnosticsFromODataError(resp ODataErrorResponse) (Diagnostics, error) {
+	details := resp.Error.Details
+	if len(details) < 1 {
+		details = []ODataErrorDetail{{
+			Code:    resp.Error.Code,
+			Message: resp.Error.Message,
+			Target:  resp.Error.Target,
+		}}
+	}
+	results := make(Diagnostics, 0, len(details))
+	for pos, detail := range details {
+		diag := Diagnostic{
+			Severity: DiagnosticError,
+			Code:     Code(detail.Code),
+		}
+		if detail.Message != detail.Code {
+			diag.Summary = detail.Message
+		}
+		if detail.Target != "" {
+			path, err := ParseFieldPath(detail.Target)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing target of detail %d: %w", pos, err)
+			}
+			diag.Paths = []Steps{path}
+		}
+		results = append(results, diag)
+	}
+	return results, nil
+}
@@ -0,0 +1,151 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nsf/jsondiff"
+)
+
+func TestODataErrorFromDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags    Diagnostics
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"single": {
+			diags: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     CodeMissing,
+					Summary:  "Name is required.",
+					Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("name"))},
+				},
+			},
+			expected: `{"error": {"code": "missing", "message": "Name is required.", "target": "name"}}`,
+		},
+		"multiple": {
+			diags: Diagnostics{
+				{Severity: DiagnosticWarning, Code: CodeDeprecated, Paths: []Steps{HeaderPath("X-Legacy")}},
+				{
+					Severity: DiagnosticError,
+					Code:     CodeConflict,
+					Paths: []Steps{
+						BodyPath().AddSteps(ObjectPropertyStep("items"), ArrayIndexStep(0), ObjectPropertyStep("start")),
+						BodyPath().AddSteps(ObjectPropertyStep("items"), ArrayIndexStep(0), ObjectPropertyStep("end")),
+					},
+				},
+				{Severity: DiagnosticError, Code: CodeActOfGod},
+			},
+			expected: `{"error": {"code": "conflict", "message": "conflict", "target": "items[0].start", "details": [
+				{"code": "deprecated", "message": "deprecated", "target": "header(\"X-Legacy\")"},
+				{"code": "conflict", "message": "conflict", "target": "items[0].start"},
+				{"code": "conflict", "message": "conflict", "target": "items[0].end"},
+				{"code": "act_of_god", "message": "act_of_god"}
+			]}}`,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := json.Marshal(ODataErrorFromDiagnostics(tc.diags))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			opts := jsondiff.DefaultConsoleOptions()
+			match, diff := jsondiff.Compare([]byte(tc.expected), result, &opts)
+			if match != jsondiff.FullMatch {
+				t.Errorf("Unexpected result: %s", diff)
+			}
+			if match > jsondiff.NoMatch {
+				t.Logf("first argument: %s", tc.expected)
+				t.Logf("second argument: %s", result)
+			}
+		})
+	}
+}
+
+func TestDiagnosticsFromODataError(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    string
+		expected Diagnostics
+		wantErr  bool
+	}
+
+	cases := map[string]testCase{
+		"no-details": {
+			input: `{"error": {"code": "missing", "message": "Name is required.", "target": "name"}}`,
+			expected: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     CodeMissing,
+					Summary:  "Name is required.",
+					Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("name"))},
+				},
+			},
+		},
+		"details": {
+			input: `{"error": {"code": "BadArgument", "message": "Multiple errors in ContactInfo data", "target": "ContactInfo", "details": [
+				{"code": "NullValue", "message": "Phone number must not be null", "target": "PhoneNumber"},
+				{"code": "missing", "message": "missing", "target": "header(\"X-Tenant\")"},
+				{"code": "act_of_god", "message": "Try again later"}
+			]}}`,
+			expected: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     "NullValue",
+					Summary:  "Phone number must not be null",
+					Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("PhoneNumber"))},
+				},
+				{
+					Severity: DiagnosticError,
+					Code:     CodeMissing,
+					Paths:    []Steps{HeaderPath("X-Tenant")},
+				},
+				{
+					Severity: DiagnosticError,
+					Code:     CodeActOfGod,
+					Summary:  "Try again later",
+				},
+			},
+		},
+		"unparseable-target": {
+			input:   `{"error": {"code": "BadArgument", "message": "oops", "target": "Items/0"}}`,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var resp ODataErrorResponse
+			err := json.Unmarshal([]byte(tc.input), &resp)
+			if err != nil {
+				t.Fatalf("unexpected error decoding input: %s", err)
+			}
+			result, err := DiagnosticsFromODataError(resp)
+			if err != nil && !tc.wantErr {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && tc.wantErr {
+				t.Fatalf("expected error, got %+v", result)
+			}
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// OpenAPISchemaRefPrefix is the prefix of the references between the schemas
+// returned by OpenAPISchemas, and of the references to them in the responses
+// built by OpenAPIErrorResponse.
+const OpenAPISchemaRefPrefix = "#/components/schemas/"
+
+// OpenAPISchemas returns OpenAPI 3.1 schemas describing the JSON encoding of
+// Diagnostics and of Problems, keyed by name, to be merged into the
+// components.schemas of an OpenAPI document. The schemas are named Problem,
+// Diagnostic, Severity, Code, Steps, and Step.
+//
+// As with JSONSchema, codes are listed as examples of Codes in addition to
+// the ones defined by this package.
+func OpenAPISchemas(codes ...Code) map[string]any {
+	schemas := diagnosticSchemas(OpenAPISchemaRefPrefix, codes)
+	schemas["Problem"] = map[string]any{
+		"type":        "object",
+		"description": "An RFC 9457 Problem Details document, with the Diagnostics that prompted it.",
+		"properties": map[string]any{
+			"type":     map[string]any{"type": "string", "format": "uri-reference"},
+			"title":    map[string]any{"type": "string"},
+			"status":   map[string]any{"type": "integer"},
+			"detail":   map[string]any{"type": "string"},
+			"instance": map[string]any{"type": "string", "format": "uri-reference"},
+			problemDiagnosticsMember: map[string]any{
+				"type":  "array",
+				"items": map[string]any{"$ref": OpenAPISchemaRefPrefix + "Diagnostic"},
+			},
+		},
+	}
+	return schemas
+}
+
+// OpenAPIErrorResponse builds an OpenAPI 3.1 Response Object for an
+// operation responding with the HTTP status code status and a Problem, like
+// the ones written by WriteProblem. The schema references the Problem schema
+// returned by OpenAPISchemas.
+//
+// Each of examples becomes a named example of the response, as the only
+// Diagnostic of a Problem returned by NewProblem. The response's description
+// lists the Codes of the examples, so the Codes an operation can return are
+// documented alongside it. Examples are named after their Codes, with a
+// numeric suffix if more than one example has the same Code.
+func OpenAPIErrorResponse(status int, examples ...Diagnostic) (map[string]any, error) {
+	var codes []string
+	seen := map[Code]int{}
+	namedExamples := make(map[string]any, len(examples))
+	for pos, example := range examples {
+		seen[example.Code]++
+		name := string(example.Code)
+		if seen[example.Code] == 1 {
+			codes = append(codes, "`"+string(example.Code)+"`")
+		} else {
+			name += "_" + strconv.Itoa(seen[example.Code])
+		}
+		encoded, err := json.Marshal(NewProblem(status, Diagnostics{example}))
+		if err != nil {
+			return nil, fmt.Errorf("error encoding example %d: %w", pos, err)
+		}
+		var value map[string]any
+		err = json.Unmarshal(encoded, &value)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding example %d: %w", pos, err)
+		}
+		namedExamples[name] = map[string]any{
+			"summary": example.Message(),
+			"value":   value,
+		}
+	}
+
+	description := http.StatusText(status)
+	if description == "" {
+		description = strconv.Itoa(status)
+	}
+	description += "."
+	if len(codes) > 0 {
+		description += " The diagnostics may include these codes: " + strings.Join(codes, ", ") + "."
+	}
+
+	content := map[string]any{
+		"schema": map[string]any{"$ref": OpenAPISchemaRefPrefix + "Problem"},
+	}
+	if len(namedExamples) > 0 {
+		content["examples"] = namedExamples
+	}
+	return map[string]any{
+		"description": description,
+		"content": map[string]any{
+			ProblemContentType: content,
+		},
+	}, nil
+}
@@ -0,0 +1,119 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nsf/jsondiff"
+)
+
+func TestOpenAPISchemasReferences(t *testing.T) {
+	t.Parallel()
+
+	schemas := OpenAPISchemas()
+	encoded, err := json.Marshal(schemas)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var refs []string
+	var collect func(any)
+	collect = func(value any) {
+		switch value := value.(type) {
+		case map[string]any:
+			for key, member := range value {
+				if ref, ok := member.(string); ok && key == "$ref" {
+					refs = append(refs, ref)
+				}
+				collect(member)
+			}
+		case []any:
+			for _, item := range value {
+				collect(item)
+			}
+		}
+	}
+	var decoded map[string]any
+	err = json.Unmarshal(encoded, &decoded)
+	if err != nil {
+		t.Fatalf("unexpected error decoding: %s", err)
+	}
+	collect(decoded)
+	if len(refs) < 1 {
+		t.Fatal("expected references between schemas")
+	}
+	for _, ref := range refs {
+		name := ref[len(OpenAPISchemaRefPrefix):]
+		if ref[:len(OpenAPISchemaRefPrefix)] != OpenAPISchemaRefPrefix || schemas[name] == nil {
+			t.Errorf("dangling reference %q", ref)
+		}
+	}
+}
+
+func TestOpenAPIErrorResponse(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		status   int
+		examples []Diagnostic
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"no-examples": {
+			status: 500,
+			expected: `{"description": "Internal Server Error.", "content": {
+				"application/problem+json": {"schema": {"$ref": "#/components/schemas/Problem"}}
+			}}`,
+		},
+		"examples": {
+			status: 400,
+			examples: []Diagnostic{
+				{Severity: DiagnosticError, Code: CodeMissing, Summary: "Name is required.", Paths: []Steps{BodyPath().AddStep(ObjectPropertyStep("name"))}},
+				{Severity: DiagnosticError, Code: CodeInvalidValue, Paths: []Steps{URLParamPath("limit")}},
+				{Severity: DiagnosticError, Code: CodeMissing, Paths: []Steps{BodyPath().AddStep(ObjectPropertyStep("email"))}},
+			},
+			expected: `{"description": "Bad Request. The diagnostics may include these codes: ` + "`missing`, `invalid_value`" + `.", "content": {
+				"application/problem+json": {
+					"schema": {"$ref": "#/components/schemas/Problem"},
+					"examples": {
+						"missing": {"summary": "Name is required.", "value": {"title": "Bad Request", "status": 400, "diagnostics": [
+							{"severity": "error", "code": "missing", "summary": "Name is required.", "path": [[{"kind": "body"}, {"kind": "object_property", "value": "name"}]]}
+						]}},
+						"invalid_value": {"summary": "invalid_value", "value": {"title": "Bad Request", "status": 400, "diagnostics": [
+							{"severity": "error", "code": "invalid_value", "path": [[{"kind": "url_param", "value": "limit"}]]}
+						]}},
+						"missing_2": {"summary": "missing", "value": {"title": "Bad Request", "status": 400, "diagnostics": [
+							{"severity": "error", "code": "missing", "path": [[{"kind": "body"}, {"kind": "object_property", "value": "email"}]]}
+						]}}
+					}
+				}
+			}}`,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			response, err := OpenAPIErrorResponse(tc.status, tc.examples...)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			result, err := json.Marshal(response)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			opts := jsondiff.DefaultConsoleOptions()
+			match, diff := jsondiff.Compare([]byte(tc.expected), result, &opts)
+			if match != jsondiff.FullMatch {
+				t.Errorf("Unexpected result: %s", diff)
+			}
+			if match > jsondiff.NoMatch {
+				t.Logf("first argument: %s", tc.expected)
+				t.Logf("second argument: %s", result)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package apidiags
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+var jsonPointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// JSONPointer returns an RFC 6901 JSON Pointer to the part of the request
+// body the Steps point to. The Steps must start with a BodyStep, and be made
+// up of ObjectPropertySteps and ArrayIndexSteps after that. As JSON Pointers
+// can't point inside strings, any StringIndexSteps or RuneIndexSteps at the
+// end of the Steps are dropped, and the JSON Pointer points to the string
+// itself.
+//
+// If the Steps can't be represented as a JSON Pointer, false is returned.
+func (s Steps) JSONPointer() (string, bool) {
+	if len(s) < 1 {
+		return "", false
+	}
+	if _, ok := s[0].(BodyStep); !ok {
+		return "", false
+	}
+	var buf strings.Builder
+	var inString bool
+	for _, step := range s[1:] {
+		switch value := step.(type) {
+		case ObjectPropertyStep:
+			if inString {
+				return "", false
+			}
+			buf.WriteString("/")
+			buf.WriteString(jsonPointerEscaper.Replace(string(value)))
+		case ArrayIndexStep:
+			if inString {
+				return "", false
+			}
+			buf.WriteString("/")
+			buf.WriteString(strconv.FormatInt(int64(value), 10))
+		case StringIndexStep, RuneIndexStep:
+			inString = true
+		default:
+			return "", false
+		}
+	}
+	return buf.String(), true
+}
+
+// BodyPathFromJSONPointer returns Steps pointing to the part of the request
+// body identified by the RFC 6901 JSON Pointer pointer.
+//
+// JSON Pointers don't distinguish between array indexes and object
+// properties, so reference tokens made up only of digits are assumed to be
+// ArrayIndexSteps, and all other reference tokens are assumed to be
+// ObjectPropertySteps.
+func BodyPathFromJSONPointer(pointer string) (Steps, error) {
+	results := BodyPath()
+	if pointer == "" {
+		return results, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, errors.New("JSON Pointer must be empty or start with /")
+	}
+	for _, token := range strings.Split(pointer[1:], "/") {
+		if isJSONPointerIndex(token) {
+			idx, err := strconv.ParseInt(token, 10, 64)
+			if err == nil {
+				results = append(results, ArrayIndexStep(idx))
+				continue
+			}
+		}
+		results = append(results, ObjectPropertyStep(jsonPointerUnescaper.Replace(token)))
+	}
+	return results, nil
+}
+
+// isJSONPointerIndex returns true if token is a valid array index, per RFC
+// 6901: either "0" or digits without a leading zero.
+func isJSONPointerIndex(token string) bool {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return false
+	}
+	for _, r := range token {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
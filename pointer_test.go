@@ -0,0 +1,119 @@
+package apidiags
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStepsJSONPointer(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		steps    Steps
+		expected string
+		ok       bool
+	}
+
+	cases := map[string]testCase{
+		"no-steps": {},
+		"body": {
+			steps:    BodyPath(),
+			expected: "",
+			ok:       true,
+		},
+		"body-prop-arrayIndex": {
+			steps:    BodyPath().AddSteps(ObjectPropertyStep("data"), ArrayIndexStep(0), ObjectPropertyStep("id")),
+			expected: "/data/0/id",
+			ok:       true,
+		},
+		"escaped": {
+			steps:    BodyPath().AddSteps(ObjectPropertyStep("a/b"), ObjectPropertyStep("m~n")),
+			expected: "/a~1b/m~0n",
+			ok:       true,
+		},
+		"trailing-stringIndex": {
+			steps:    BodyPath().AddSteps(ObjectPropertyStep("name"), StringIndexStep(3)),
+			expected: "/name",
+			ok:       true,
+		},
+		"property-after-stringIndex": {
+			steps: BodyPath().AddSteps(ObjectPropertyStep("name"), StringIndexStep(3), ObjectPropertyStep("x")),
+		},
+		"header": {
+			steps: HeaderPath("Content-Type"),
+		},
+		"relative": {
+			steps: Steps{ObjectPropertyStep("name")},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result, ok := tc.steps.JSONPointer()
+			if result != tc.expected || ok != tc.ok {
+				t.Fatalf("expected (%q, %v), got (%q, %v)", tc.expected, tc.ok, result, ok)
+			}
+		})
+	}
+}
+
+func TestBodyPathFromJSONPointer(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		pointer  string
+		expected Steps
+		wantErr  bool
+	}
+
+	cases := map[string]testCase{
+		"root": {
+			pointer:  "",
+			expected: BodyPath(),
+		},
+		"prop-index-prop": {
+			pointer:  "/data/0/id",
+			expected: BodyPath().AddSteps(ObjectPropertyStep("data"), ArrayIndexStep(0), ObjectPropertyStep("id")),
+		},
+		"escaped": {
+			pointer:  "/a~1b/m~0n/~01",
+			expected: BodyPath().AddSteps(ObjectPropertyStep("a/b"), ObjectPropertyStep("m~n"), ObjectPropertyStep("~1")),
+		},
+		"leading-zero": {
+			pointer:  "/items/01",
+			expected: BodyPath().AddSteps(ObjectPropertyStep("items"), ObjectPropertyStep("01")),
+		},
+		"empty-token": {
+			pointer:  "/",
+			expected: BodyPath().AddStep(ObjectPropertyStep("")),
+		},
+		"no-leading-slash": {
+			pointer: "data",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := BodyPathFromJSONPointer(tc.pointer)
+			if err != nil && !tc.wantErr {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && tc.wantErr {
+				t.Fatalf("expected error, got %s", result)
+			}
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package apidiags
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// RequestCheck configures the checks CheckRequests makes before calling
+// its handler.
+type RequestCheck func(*requestChecks)
+
+type requestChecks struct {
+	methods      []string
+	maxBodyBytes int64
+	contentTypes []string
+	headers      []string
+	httpOpts     []HTTPOption
+}
+
+// AllowMethods makes CheckRequests reject requests whose method isn't one of
+// methods with http.StatusMethodNotAllowed, listing methods in the Allow
+// header.
+func AllowMethods(methods ...string) RequestCheck {
+	return func(c *requestChecks) {
+		c.methods = append(c.methods, methods...)
+	}
+}
+
+// LimitBodyBytes makes CheckRequests reject requests with bodies larger
+// than n bytes with http.StatusRequestEntityTooLarge. Requests that don't
+// declare their length up front have their bodies wrapped with
+// http.MaxBytesReader instead, so the handler gets an error reading past
+// the limit.
+func LimitBodyBytes(n int64) RequestCheck {
+	return func(c *requestChecks) {
+		c.maxBodyBytes = n
+	}
+}
+
+// AllowContentTypes makes CheckRequests reject requests with bodies whose
+// media type isn't one of mediaTypes with
+// http.StatusUnsupportedMediaType. Parameters are ignored, so
+// "application/json" allows "application/json; charset=utf-8".
+func AllowContentTypes(mediaTypes ...string) RequestCheck {
+	return func(c *requestChecks) {
+		c.contentTypes = append(c.contentTypes, mediaTypes...)
+	}
+}
+
+// RequireHeaders makes CheckRequests reject requests missing any of headers
+// with http.StatusBadRequest.
+func RequireHeaders(headers ...string) RequestCheck {
+	return func(c *requestChecks) {
+		c.headers = append(c.headers, headers...)
+	}
+}
+
+// WithCheckHTTPOptions sets the HTTPOptions CheckRequests passes to
+// WriteHTTP when rejecting a request.
+func WithCheckHTTPOptions(opts ...HTTPOption) RequestCheck {
+	return func(c *requestChecks) {
+		c.httpOpts = append(c.httpOpts, opts...)
+	}
+}
+
+// CheckRequests returns an http.Handler that checks the structure of each
+// request before calling next, rejecting requests that fail the checks
+// with Diagnostics pointing to the offending parts of the request, written
+// by WriteHTTP.
+//
+// Unsupported methods are rejected on their own. Otherwise, every failed
+// check is reported, in the order LimitBodyBytes, AllowContentTypes, and
+// RequireHeaders, with the status code of the first failed check.
+func CheckRequests(next http.Handler, checks ...RequestCheck) http.Handler {
+	var cfg requestChecks
+	for _, check := range checks {
+		check(&cfg)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(cfg.methods) > 0 && !contains(cfg.methods, r.Method) {
+			w.Header().Set("Allow", strings.Join(cfg.methods, ", "))
+			cfg.reject(w, http.StatusMethodNotAllowed, Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeInvalidValue,
+				Summary:  "The " + r.Method + " method isn't supported.",
+				Detail:   "Supported methods: " + strings.Join(cfg.methods, ", ") + ".",
+			}})
+			return
+		}
+		var status int
+		var diags Diagnostics
+		fail := func(failStatus int, diag Diagnostic) {
+			if status == 0 {
+				status = failStatus
+			}
+			diags = append(diags, diag)
+		}
+		if cfg.maxBodyBytes > 0 && r.ContentLength > cfg.maxBodyBytes {
+			fail(http.StatusRequestEntityTooLarge, Diagnostic{
+				Severity: DiagnosticError,
+				Code:     CodeOverflow,
+				Paths:    []Steps{BodyPath()},
+				Summary:  "The request body is too large.",
+				Detail: "The request body is " + strconv.FormatInt(r.ContentLength, 10) +
+					" bytes, but can be at most " + strconv.FormatInt(cfg.maxBodyBytes, 10) + " bytes.",
+			})
+		}
+		if len(cfg.contentTypes) > 0 && hasBody(r) {
+			contentType := r.Header.Get("Content-Type")
+			mediaType, _, err := mime.ParseMediaType(contentType)
+			switch {
+			case contentType == "":
+				fail(http.StatusUnsupportedMediaType, Diagnostic{
+					Severity: DiagnosticError,
+					Code:     CodeMissing,
+					Paths:    []Steps{HeaderPath("Content-Type")},
+					Summary:  "The request body's media type must be specified.",
+					Detail:   "Supported media types: " + strings.Join(cfg.contentTypes, ", ") + ".",
+				})
+			case err != nil || !containsFold(cfg.contentTypes, mediaType):
+				fail(http.StatusUnsupportedMediaType, Diagnostic{
+					Severity: DiagnosticError,
+					Code:     CodeInvalidFormat,
+					Paths:    []Steps{HeaderPath("Content-Type")},
+					Summary:  "The request body's media type isn't supported.",
+					Detail:   "Supported media types: " + strings.Join(cfg.contentTypes, ", ") + ".",
+				})
+			}
+		}
+		for _, header := range cfg.headers {
+			if r.Header.Get(header) == "" {
+				fail(http.StatusBadRequest, Diagnostic{
+					Severity: DiagnosticError,
+					Code:     CodeMissing,
+					Paths:    []Steps{HeaderPath(header)},
+				})
+			}
+		}
+		if len(diags) > 0 {
+			cfg.reject(w, status, diags)
+			return
+		}
+		if cfg.maxBodyBytes > 0 && r.ContentLength < 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, cfg.maxBodyBytes)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// reject writes diags to w with the given status code.
+func (c requestChecks) reject(w http.ResponseWriter, status int, diags Diagnostics) {
+	opts := append(append([]HTTPOption{}, c.httpOpts...), WithStatus(status))
+	_ = WriteHTTP(w, diags, opts...)
+}
+
+// hasBody returns true if r has, or may have, a body.
+func hasBody(r *http.Request) bool {
+	return r.ContentLength != 0 && r.Body != nil && r.Body != http.NoBody
+}
+
+// contains returns true if any of values is equal to value.
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold returns true if any of values is equal to value, ignoring
+// case.
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
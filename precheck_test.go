@@ -0,0 +1,155 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheckRequests(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		method      string
+		body        string
+		chunked     bool
+		headers     map[string]string
+		status      int
+		handlerBody string
+		expected    Diagnostics
+	}
+
+	cases := map[string]testCase{
+		"valid": {
+			method:      http.MethodPost,
+			body:        `{"name":"foo"}`,
+			headers:     map[string]string{"Content-Type": "application/json; charset=utf-8", "Idempotency-Key": "abc"},
+			status:      http.StatusOK,
+			handlerBody: `{"name":"foo"}`,
+		},
+		"valid-without-body": {
+			method:  http.MethodGet,
+			headers: map[string]string{"Idempotency-Key": "abc"},
+			status:  http.StatusOK,
+		},
+		"method": {
+			method: http.MethodDelete,
+			status: http.StatusMethodNotAllowed,
+			expected: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeInvalidValue,
+				Summary:  "The DELETE method isn't supported.",
+				Detail:   "Supported methods: GET, POST.",
+			}},
+		},
+		"everything-else": {
+			method:  http.MethodPost,
+			body:    `{"name":"this is far too long"}`,
+			headers: map[string]string{"Content-Type": "text/plain"},
+			status:  http.StatusRequestEntityTooLarge,
+			expected: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     CodeOverflow,
+					Paths:    []Steps{BodyPath()},
+					Summary:  "The request body is too large.",
+					Detail:   "The request body is 31 bytes, but can be at most 20 bytes.",
+				},
+				{
+					Severity: DiagnosticError,
+					Code:     CodeInvalidFormat,
+					Paths:    []Steps{HeaderPath("Content-Type")},
+					Summary:  "The request body's media type isn't supported.",
+					Detail:   "Supported media types: application/json.",
+				},
+				{
+					Severity: DiagnosticError,
+					Code:     CodeMissing,
+					Paths:    []Steps{HeaderPath("Idempotency-Key")},
+				},
+			},
+		},
+		"missing-content-type": {
+			method:  http.MethodPost,
+			body:    `{}`,
+			headers: map[string]string{"Idempotency-Key": "abc"},
+			status:  http.StatusUnsupportedMediaType,
+			expected: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeMissing,
+				Paths:    []Steps{HeaderPath("Content-Type")},
+				Summary:  "The request body's media type must be specified.",
+				Detail:   "Supported media types: application/json.",
+			}},
+		},
+		"chunked-too-large": {
+			method:      http.MethodPost,
+			body:        `{"name":"this is far too long"}`,
+			chunked:     true,
+			headers:     map[string]string{"Content-Type": "application/json", "Idempotency-Key": "abc"},
+			status:      http.StatusOK,
+			handlerBody: "error: http: request body too large",
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := CheckRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					_, _ = w.Write([]byte("error: " + err.Error()))
+					return
+				}
+				_, _ = w.Write(body)
+			}),
+				AllowMethods(http.MethodGet, http.MethodPost),
+				LimitBodyBytes(20),
+				AllowContentTypes("application/json"),
+				RequireHeaders("Idempotency-Key"),
+			)
+			var body io.Reader
+			if tc.body != "" {
+				body = strings.NewReader(tc.body)
+			}
+			r := httptest.NewRequest(tc.method, "/", body)
+			if tc.chunked {
+				r.ContentLength = -1
+			}
+			for key, value := range tc.headers {
+				r.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tc.status {
+				t.Errorf("expected status %d, got %d", tc.status, w.Code)
+			}
+			if tc.status == http.StatusMethodNotAllowed && w.Header().Get("Allow") != "GET, POST" {
+				t.Errorf("expected Allow header %q, got %q", "GET, POST", w.Header().Get("Allow"))
+			}
+			if tc.expected == nil {
+				if w.Body.String() != tc.handlerBody {
+					t.Errorf("expected handler to respond with %q, got %q", tc.handlerBody, w.Body.String())
+				}
+				return
+			}
+			var problem Problem
+			err := json.Unmarshal(w.Body.Bytes(), &problem)
+			if err != nil {
+				t.Fatalf("unexpected error decoding response: %s", err)
+			}
+			if diff := cmp.Diff(tc.expected, problem.Diagnostics); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
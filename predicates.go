@@ -0,0 +1,77 @@
+package apidiags
+
+// HasCode returns true if any of the Diagnostics have a Code of code,
+// regardless of their Severity.
+func (diags Diagnostics) HasCode(code Code) bool {
+	for _, diag := range diags {
+		if diag.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// HasErrorCode returns true if any of the Diagnostics have a Code of code
+// and a Severity of DiagnosticError.
+func (diags Diagnostics) HasErrorCode(code Code) bool {
+	for _, diag := range diags {
+		if diag.Severity == DiagnosticError && diag.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// AnyAtPath returns true if any of the Diagnostics have a Path equal to
+// steps, as determined by Steps.Equal.
+func (diags Diagnostics) AnyAtPath(steps Steps) bool {
+	for _, diag := range diags {
+		for _, path := range diag.Paths {
+			if path.Equal(steps) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsNotFound returns true if any of the Diagnostics are errors with a Code
+// of CodeNotFound.
+func (diags Diagnostics) IsNotFound() bool {
+	return diags.HasErrorCode(CodeNotFound)
+}
+
+// IsAccessDenied returns true if any of the Diagnostics are errors with a
+// Code of CodeAccessDenied.
+func (diags Diagnostics) IsAccessDenied() bool {
+	return diags.HasErrorCode(CodeAccessDenied)
+}
+
+// IsConflict returns true if any of the Diagnostics are errors with a Code
+// of CodeConflict.
+func (diags Diagnostics) IsConflict() bool {
+	return diags.HasErrorCode(CodeConflict)
+}
+
+// IsActOfGod returns true if any of the Diagnostics are errors with a Code
+// of CodeActOfGod, meaning the request failed for reasons outside the
+// caller's control, and may succeed if it's retried.
+func (diags Diagnostics) IsActOfGod() bool {
+	return diags.HasErrorCode(CodeActOfGod)
+}
+
+// IsInvalid returns true if any of the Diagnostics are errors describing a
+// problem with the request's contents: a Code of CodeMissing,
+// CodeInvalidValue, CodeInvalidFormat, CodeInsufficient, or CodeOverflow.
+func (diags Diagnostics) IsInvalid() bool {
+	for _, diag := range diags {
+		if diag.Severity != DiagnosticError {
+			continue
+		}
+		switch diag.Code {
+		case CodeMissing, CodeInvalidValue, CodeInvalidFormat, CodeInsufficient, CodeOverflow:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package apidiags
+
+import "testing"
+
+func TestDiagnosticsPredicates(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags    Diagnostics
+		check    func(Diagnostics) bool
+		expected bool
+	}
+
+	namePath := BodyPath().AddStep(ObjectPropertyStep("name"))
+	diags := Diagnostics{
+		{Severity: DiagnosticWarning, Code: CodeDeprecated, Paths: []Steps{HeaderPath("X-Legacy")}},
+		{Severity: DiagnosticWarning, Code: CodeNotFound},
+		{Severity: DiagnosticError, Code: CodeMissing, Paths: []Steps{namePath}},
+		{Severity: DiagnosticError, Code: CodeConflict},
+	}
+
+	cases := map[string]testCase{
+		"nil": {
+			check: func(d Diagnostics) bool { return d.HasCode(CodeMissing) },
+		},
+		"has-code-error": {
+			diags:    diags,
+			check:    func(d Diagnostics) bool { return d.HasCode(CodeMissing) },
+			expected: true,
+		},
+		"has-code-warning": {
+			diags:    diags,
+			check:    func(d Diagnostics) bool { return d.HasCode(CodeDeprecated) },
+			expected: true,
+		},
+		"has-code-absent": {
+			diags: diags,
+			check: func(d Diagnostics) bool { return d.HasCode(CodeOverflow) },
+		},
+		"has-error-code-warning": {
+			diags: diags,
+			check: func(d Diagnostics) bool { return d.HasErrorCode(CodeDeprecated) },
+		},
+		"any-at-path": {
+			diags:    diags,
+			check:    func(d Diagnostics) bool { return d.AnyAtPath(BodyPath().AddStep(ObjectPropertyStep("name"))) },
+			expected: true,
+		},
+		"any-at-path-header-case": {
+			diags:    diags,
+			check:    func(d Diagnostics) bool { return d.AnyAtPath(HeaderPath("x-legacy")) },
+			expected: true,
+		},
+		"any-at-path-prefix": {
+			diags: diags,
+			check: func(d Diagnostics) bool { return d.AnyAtPath(BodyPath()) },
+		},
+		"is-not-found-warning": {
+			diags: diags,
+			check: Diagnostics.IsNotFound,
+		},
+		"is-conflict": {
+			diags:    diags,
+			check:    Diagnostics.IsConflict,
+			expected: true,
+		},
+		"is-access-denied": {
+			diags: diags,
+			check: Diagnostics.IsAccessDenied,
+		},
+		"is-act-of-god": {
+			diags: diags,
+			check: Diagnostics.IsActOfGod,
+		},
+		"is-invalid": {
+			diags:    diags,
+			check:    Diagnostics.IsInvalid,
+			expected: true,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if result := tc.check(tc.diags); result != tc.expected {
+				t.Fatalf("expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
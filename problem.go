@@ -0,0 +1,137 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ProblemContentType is the media type for Problem Details documents, as
+// defined by RFC 9457.
+const ProblemContentType = "application/problem+json"
+
+// problemDiagnosticsMember is the extension member Diagnostics are stored
+// under in a Problem Details document.
+const problemDiagnosticsMember = "diagnostics"
+
+// Problem is an RFC 9457 Problem Details document. The Diagnostics that
+// prompted the Problem are carried in the "diagnostics" extension member.
+type Problem struct {
+	// Type is a URI reference identifying the problem type. When empty,
+	// it's treated as "about:blank", meaning the problem has no semantics
+	// beyond those of the HTTP status code.
+	Type string
+
+	// Title is a short, human-readable summary of the problem type.
+	Title string
+
+	// Status is the HTTP status code of the response.
+	Status int
+
+	// Detail is a human-readable explanation specific to this occurrence
+	// of the problem.
+	Detail string
+
+	// Instance is a URI reference identifying this specific occurrence
+	// of the problem.
+	Instance string
+
+	// Diagnostics are the Diagnostics that prompted the problem.
+	Diagnostics Diagnostics
+
+	// Extensions holds any extension members other than the
+	// diagnostics, so they can be preserved when a Problem is decoded and
+	// encoded again.
+	Extensions map[string]json.RawMessage
+}
+
+// NewProblem returns a Problem describing diags, to be sent with a response
+// using the HTTP status code status. The Problem uses the "about:blank" type,
+// and its Title is the standard text for status.
+func NewProblem(status int, diags Diagnostics) Problem {
+	return Problem{
+		Title:       http.StatusText(status),
+		Status:      status,
+		Diagnostics: diags,
+	}
+}
+
+// MarshalJSON turns a Problem into a JSON-encoded Problem Details document.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]any, len(p.Extensions)+6)
+	for key, value := range p.Extensions {
+		members[key] = value
+	}
+	if p.Type != "" {
+		members["type"] = p.Type
+	}
+	if p.Title != "" {
+		members["title"] = p.Title
+	}
+	if p.Status != 0 {
+		members["status"] = p.Status
+	}
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+	if p.Diagnostics != nil {
+		members[problemDiagnosticsMember] = p.Diagnostics
+	}
+	return json.Marshal(members)
+}
+
+// UnmarshalJSON turns a JSON-encoded Problem Details document into a Problem.
+// As required by RFC 9457, standard members with the wrong type are ignored.
+// Unrecognized members are kept in Extensions.
+func (p *Problem) UnmarshalJSON(in []byte) error {
+	var members map[string]json.RawMessage
+	err := json.Unmarshal(in, &members)
+	if err != nil {
+		return err
+	}
+	var result Problem
+	for key, value := range members {
+		switch key {
+		case "type":
+			_ = json.Unmarshal(value, &result.Type)
+		case "title":
+			_ = json.Unmarshal(value, &result.Title)
+		case "status":
+			_ = json.Unmarshal(value, &result.Status)
+		case "detail":
+			_ = json.Unmarshal(value, &result.Detail)
+		case "instance":
+			_ = json.Unmarshal(value, &result.Instance)
+		case problemDiagnosticsMember:
+			err = json.Unmarshal(value, &result.Diagnostics)
+			if err != nil {
+				return fmt.Errorf("error parsing %s: %w", problemDiagnosticsMember, err)
+			}
+		default:
+			if result.Extensions == nil {
+				result.Extensions = map[string]json.RawMessage{}
+			}
+			result.Extensions[key] = value
+		}
+	}
+	*p = result
+	return nil
+}
+
+// WriteProblem writes problem to w as an application/problem+json response,
+// using problem.Status as the response's status code. If problem.Status isn't
+// set, http.StatusInternalServerError is used.
+func WriteProblem(w http.ResponseWriter, problem Problem) error {
+	body, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+	status := problem.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	return writeProblemBody(w, status, body)
+}
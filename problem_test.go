@@ -0,0 +1,170 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nsf/jsondiff"
+)
+
+func TestProblemMarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		problem  Problem
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"empty": {
+			expected: `{}`,
+		},
+		"new-problem": {
+			problem: NewProblem(http.StatusBadRequest, Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     CodeMissing,
+					Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("name"))},
+				},
+			}),
+			expected: `{"title": "Bad Request", "status": 400, "diagnostics": [{"severity": "error", "code": "missing", "path": [[{"kind": "body"}, {"kind": "object_property", "value": "name"}]]}]}`,
+		},
+		"all-members": {
+			problem: Problem{
+				Type:     "https://example.com/probs/out-of-credit",
+				Title:    "You do not have enough credit.",
+				Status:   http.StatusForbidden,
+				Detail:   "Your current balance is 30, but that costs 50.",
+				Instance: "/account/12345/msgs/abc",
+				Extensions: map[string]json.RawMessage{
+					"balance": json.RawMessage(`30`),
+				},
+			},
+			expected: `{"type": "https://example.com/probs/out-of-credit", "title": "You do not have enough credit.", "status": 403, "detail": "Your current balance is 30, but that costs 50.", "instance": "/account/12345/msgs/abc", "balance": 30}`,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := json.Marshal(tc.problem)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			opts := jsondiff.DefaultConsoleOptions()
+			match, diff := jsondiff.Compare([]byte(tc.expected), result, &opts)
+			if match != jsondiff.FullMatch {
+				t.Errorf("Unexpected result: %s", diff)
+			}
+			if match > jsondiff.NoMatch {
+				t.Logf("first argument: %s", tc.expected)
+				t.Logf("second argument: %s", result)
+			}
+		})
+	}
+}
+
+func TestProblemUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    string
+		expected Problem
+		wantErr  bool
+	}
+
+	cases := map[string]testCase{
+		"empty": {
+			input:    `{}`,
+			expected: Problem{},
+		},
+		"diagnostics": {
+			input: `{"title": "Bad Request", "status": 400, "diagnostics": [{"severity": "error", "code": "missing", "path": [[{"kind": "body"}, {"kind": "object_property", "value": "name"}]]}]}`,
+			expected: NewProblem(http.StatusBadRequest, Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     CodeMissing,
+					Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("name"))},
+				},
+			}),
+		},
+		"extensions": {
+			input: `{"type": "https://example.com/probs/out-of-credit", "balance": 30, "accounts": ["/account/12345"]}`,
+			expected: Problem{
+				Type: "https://example.com/probs/out-of-credit",
+				Extensions: map[string]json.RawMessage{
+					"balance":  json.RawMessage(`30`),
+					"accounts": json.RawMessage(`["/account/12345"]`),
+				},
+			},
+		},
+		"wrong-types-ignored": {
+			input:    `{"type": 1, "title": false, "status": "400", "detail": {}, "instance": []}`,
+			expected: Problem{},
+		},
+		"invalid-diagnostics": {
+			input:   `{"diagnostics": [{"path": [[{"kind": "nope"}]]}]}`,
+			wantErr: true,
+		},
+		"not-an-object": {
+			input:   `[]`,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var result Problem
+			err := json.Unmarshal([]byte(tc.input), &result)
+			if err != nil && !tc.wantErr {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && tc.wantErr {
+				t.Fatalf("expected error, got %+v", result)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteProblem(t *testing.T) {
+	t.Parallel()
+
+	problem := NewProblem(http.StatusNotFound, Diagnostics{
+		{Severity: DiagnosticError, Code: CodeNotFound, Paths: []Steps{URLParamPath("id")}},
+	})
+	w := httptest.NewRecorder()
+	err := WriteProblem(w, problem)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ProblemContentType {
+		t.Errorf("expected Content-Type %q, got %q", ProblemContentType, ct)
+	}
+	var result Problem
+	err = json.Unmarshal(w.Body.Bytes(), &result)
+	if err != nil {
+		t.Fatalf("unexpected error decoding response: %s", err)
+	}
+	if diff := cmp.Diff(problem, result); diff != "" {
+		t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+	}
+}
@@ -0,0 +1,174 @@
+package apidiags
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// DefaultCorrelationIDHeader is the header RecoverPanics reads correlation
+// IDs from, and writes them to, when no other header is set using
+// WithCorrelationIDHeader.
+const DefaultCorrelationIDHeader = "X-Request-ID"
+
+// CorrelationIDMember is the extension member of the Diagnostic written by
+// RecoverPanics that holds the correlation ID of the failed request.
+const CorrelationIDMember = "correlation_id"
+
+// PanicHook is called by RecoverPanics when a handler panics, with the
+// request that was being handled, the value the handler panicked with, the
+// stack trace of the panic, and the correlation ID included in the
+// response.
+type PanicHook func(r *http.Request, recovered any, stack []byte, correlationID string)
+
+// RecoverOption configures RecoverPanics.
+type RecoverOption func(*recoverConfig)
+
+type recoverConfig struct {
+	hooks         []PanicHook
+	header        string
+	correlationID func(*http.Request) string
+	httpOpts      []HTTPOption
+}
+
+// WithPanicHook makes RecoverPanics call hook whenever it recovers a panic,
+// so the panic can be logged or reported. It can be used more than once to
+// call more than one PanicHook; they're called in the order they were set.
+func WithPanicHook(hook PanicHook) RecoverOption {
+	return func(c *recoverConfig) {
+		c.hooks = append(c.hooks, hook)
+	}
+}
+
+// WithCorrelationIDHeader sets the header RecoverPanics reads correlation
+// IDs from, and writes them to, instead of DefaultCorrelationIDHeader.
+func WithCorrelationIDHeader(header string) RecoverOption {
+	return func(c *recoverConfig) {
+		c.header = header
+	}
+}
+
+// WithCorrelationIDFunc makes RecoverPanics use fn to find the correlation
+// ID of a request, instead of reading the correlation ID header, for
+// services that keep request IDs in the request's context. If fn returns an
+// empty string, a correlation ID is randomly generated.
+func WithCorrelationIDFunc(fn func(*http.Request) string) RecoverOption {
+	return func(c *recoverConfig) {
+		c.correlationID = fn
+	}
+}
+
+// WithRecoverHTTPOptions sets the HTTPOptions RecoverPanics passes to
+// WriteHTTP when writing its response.
+func WithRecoverHTTPOptions(opts ...HTTPOption) RecoverOption {
+	return func(c *recoverConfig) {
+		c.httpOpts = append(c.httpOpts, opts...)
+	}
+}
+
+// RecoverPanics returns an http.Handler that calls next, recovering any
+// panic and responding to the request with a single Diagnostic with a Code
+// of CodeActOfGod, written by WriteHTTP.
+//
+// Every recovered panic is assigned a correlation ID, so the response a
+// client reports can be matched to the panic. If WithCorrelationIDFunc was
+// used, its function provides the correlation ID, and the request's
+// correlation ID header is never read. Otherwise, the correlation ID comes
+// from that header. If neither provides one, it's randomly generated. It's
+// set in the response's correlation ID header, and included in the
+// Diagnostic's Extensions as CorrelationIDMember. The
+// value the handler panicked with and the stack trace are recorded in the
+// Diagnostic's Debug, so they're only sent when WithDebugInfo is passed to
+// WithRecoverHTTPOptions.
+//
+// If next has already started writing a response when it panics, the
+// response can't be replaced, and is left as-is. Panics with
+// http.ErrAbortHandler aren't recovered, so net/http can abort the response
+// as intended.
+func RecoverPanics(next http.Handler, opts ...RecoverOption) http.Handler {
+	cfg := recoverConfig{header: DefaultCorrelationIDHeader}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.correlationID == nil {
+		cfg.correlationID = func(r *http.Request) string {
+			return r.Header.Get(cfg.header)
+		}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &recoverResponseWriter{ResponseWriter: w}
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			stack := debug.Stack()
+			correlationID := cfg.correlationID(r)
+			if correlationID == "" {
+				correlationID = newCorrelationID()
+			}
+			for _, hook := range cfg.hooks {
+				hook(r, recovered, stack, correlationID)
+			}
+			if rw.wroteHeader {
+				return
+			}
+			encodedID, _ := json.Marshal(correlationID)
+			diags := Diagnostics{{
+				Severity:   DiagnosticError,
+				Code:       CodeActOfGod,
+				Extensions: map[string]json.RawMessage{CorrelationIDMember: encodedID},
+				Debug:      &DebugInfo{Error: fmt.Sprint(recovered), Stack: string(stack)},
+			}}
+			w.Header().Set(cfg.header, correlationID)
+			_ = WriteHTTP(w, diags, cfg.httpOpts...)
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// newCorrelationID returns a random, hex-encoded correlation ID.
+func newCorrelationID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// recoverResponseWriter is an http.ResponseWriter that records whether a
+// response has been started, so RecoverPanics knows whether it can still
+// write its own.
+type recoverResponseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *recoverResponseWriter) WriteHeader(status int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recoverResponseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the http.ResponseWriter being wrapped, so
+// http.ResponseController can reach it.
+func (w *recoverResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Flush flushes the response being written, if the http.ResponseWriter
+// being wrapped supports it.
+func (w *recoverResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		flusher.Flush()
+	}
+}
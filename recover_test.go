@@ -0,0 +1,151 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRecoverPanics(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		handler       http.HandlerFunc
+		requestID     string
+		status        int
+		expectPanic   bool
+		expectProblem bool
+	}
+
+	cases := map[string]testCase{
+		"no-panic": {
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			status: http.StatusNoContent,
+		},
+		"panic": {
+			handler: func(http.ResponseWriter, *http.Request) {
+				panic("oops")
+			},
+			status:        http.StatusServiceUnavailable,
+			expectPanic:   true,
+			expectProblem: true,
+		},
+		"panic-with-request-id": {
+			handler: func(http.ResponseWriter, *http.Request) {
+				panic("oops")
+			},
+			requestID:     "abc123",
+			status:        http.StatusServiceUnavailable,
+			expectPanic:   true,
+			expectProblem: true,
+		},
+		"panic-after-write": {
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+				panic("oops")
+			},
+			status:      http.StatusAccepted,
+			expectPanic: true,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var hookID string
+			var hookRecovered any
+			var hookStack []byte
+			handler := RecoverPanics(tc.handler, WithPanicHook(func(_ *http.Request, recovered any, stack []byte, correlationID string) {
+				hookRecovered, hookStack, hookID = recovered, stack, correlationID
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.requestID != "" {
+				r.Header.Set(DefaultCorrelationIDHeader, tc.requestID)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tc.status {
+				t.Errorf("expected status %d, got %d", tc.status, w.Code)
+			}
+			if !tc.expectPanic {
+				if hookRecovered != nil {
+					t.Errorf("unexpected call to hook with %v", hookRecovered)
+				}
+				return
+			}
+			if hookRecovered != "oops" {
+				t.Errorf("expected hook to be called with %q, got %v", "oops", hookRecovered)
+			}
+			if len(hookStack) < 1 {
+				t.Error("expected hook to be called with a stack trace")
+			}
+			if tc.requestID != "" && hookID != tc.requestID {
+				t.Errorf("expected correlation ID %q, got %q", tc.requestID, hookID)
+			}
+			if hookID == "" {
+				t.Error("expected a correlation ID")
+			}
+			if !tc.expectProblem {
+				return
+			}
+			if header := w.Header().Get(DefaultCorrelationIDHeader); header != hookID {
+				t.Errorf("expected correlation ID header %q, got %q", hookID, header)
+			}
+			var problem Problem
+			err := json.Unmarshal(w.Body.Bytes(), &problem)
+			if err != nil {
+				t.Fatalf("unexpected error decoding response: %s", err)
+			}
+			encodedID, err := json.Marshal(hookID)
+			if err != nil {
+				t.Fatalf("unexpected error encoding correlation ID: %s", err)
+			}
+			expected := Diagnostics{{
+				Severity:   DiagnosticError,
+				Code:       CodeActOfGod,
+				Extensions: map[string]json.RawMessage{CorrelationIDMember: encodedID},
+			}}
+			if diff := cmp.Diff(expected, problem.Diagnostics); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestRecoverPanicsErrAbortHandler(t *testing.T) {
+	t.Parallel()
+
+	handler := RecoverPanics(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Fatalf("expected panic with http.ErrAbortHandler, got %v", recovered)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestRecoverPanicsCorrelationIDFunc(t *testing.T) {
+	t.Parallel()
+
+	handler := RecoverPanics(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("oops")
+	}), WithCorrelationIDFunc(func(*http.Request) string {
+		return "from-context"
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if header := w.Header().Get(DefaultCorrelationIDHeader); header != "from-context" {
+		t.Fatalf("expected correlation ID %q, got %q", "from-context", header)
+	}
+}
@@ -0,0 +1,191 @@
+package apidiags
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// DefaultReferenceTitle is the title WriteReference gives documents, unless
+// another is set using WithReferenceTitle.
+const DefaultReferenceTitle = "API error reference"
+
+// ReferenceFormat is a format WriteReference can write documents in.
+type ReferenceFormat string
+
+const (
+	// ReferenceMarkdown is a ReferenceFormat for writing Markdown, with a
+	// section for each Code.
+	ReferenceMarkdown ReferenceFormat = "markdown"
+
+	// ReferenceHTML is a ReferenceFormat for writing a standalone HTML
+	// page, with a section for each Code.
+	ReferenceHTML ReferenceFormat = "html"
+
+	// ReferenceJSON is a ReferenceFormat for writing a RegistryDocument,
+	// with an Example for each Code.
+	ReferenceJSON ReferenceFormat = "json"
+)
+
+// ReferenceOption configures the document WriteReference writes.
+type ReferenceOption func(*referenceConfig)
+
+type referenceConfig struct {
+	title string
+}
+
+// WithReferenceTitle sets the title of the document WriteReference writes.
+// It isn't used by ReferenceJSON.
+func WithReferenceTitle(title string) ReferenceOption {
+	return func(c *referenceConfig) {
+		c.title = title
+	}
+}
+
+// ExampleDiagnostic returns info's Example, if it has one, and otherwise a
+// Diagnostic like the ones the Code is used in: an error with info's
+// Description as its Summary if info has a Status, and a warning if not.
+func (info CodeInfo) ExampleDiagnostic() Diagnostic {
+	if info.Example != nil {
+		return *info.Example
+	}
+	diag := Diagnostic{
+		Severity: DiagnosticError,
+		Code:     info.Code,
+		Summary:  info.Description,
+		DocURL:   info.DocURL,
+	}
+	if info.Status == 0 {
+		diag.Severity = DiagnosticWarning
+	}
+	return diag
+}
+
+// referenceEntry is the information about a Code written by WriteReference.
+type referenceEntry struct {
+	CodeInfo
+	StatusText string
+	Example    string
+}
+
+// WriteReference writes a document describing every Code in registry to w
+// in format, like an API's public error reference. Each Code is described
+// with its Description, Status, whether it's Retryable, its DocURL, and the
+// JSON encoding of its ExampleDiagnostic, in the order returned by
+// Registry.Codes. An error is returned if format isn't a known
+// ReferenceFormat.
+func WriteReference(w io.Writer, registry *Registry, format ReferenceFormat, opts ...ReferenceOption) error {
+	cfg := referenceConfig{title: DefaultReferenceTitle}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	infos := registry.Codes()
+	if format == ReferenceJSON {
+		for pos := range infos {
+			example := infos[pos].ExampleDiagnostic()
+			infos[pos].Example = &example
+		}
+		encoded, err := json.MarshalIndent(RegistryDocument{Codes: infos}, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", encoded)
+		return err
+	}
+	entries := make([]referenceEntry, 0, len(infos))
+	for _, info := range infos {
+		example, err := json.MarshalIndent(info.ExampleDiagnostic(), "", "  ")
+		if err != nil {
+			return fmt.Errorf("error encoding example for %s: %w", info.Code, err)
+		}
+		entries = append(entries, referenceEntry{
+			CodeInfo:   info,
+			StatusText: statusText(info.Status),
+			Example:    string(example),
+		})
+	}
+	switch format {
+	case ReferenceMarkdown:
+		return writeMarkdownReference(w, cfg.title, entries)
+	case ReferenceHTML:
+		return referenceTemplate.Execute(w, map[string]any{"Title": cfg.title, "Entries": entries})
+	}
+	return fmt.Errorf("unknown reference format %q", format)
+}
+
+// statusText describes status like "404 Not Found".
+func statusText(status int) string {
+	if status == 0 {
+		return ""
+	}
+	if text := http.StatusText(status); text != "" {
+		return strconv.Itoa(status) + " " + text
+	}
+	return strconv.Itoa(status)
+}
+
+func writeMarkdownReference(w io.Writer, title string, entries []referenceEntry) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "# %s\n", title)
+	for _, entry := range entries {
+		fmt.Fprintf(out, "\n## `%s`\n\n", entry.Code)
+		if entry.Description != "" {
+			fmt.Fprintf(out, "%s\n\n", entry.Description)
+		}
+		if entry.StatusText != "" {
+			fmt.Fprintf(out, "- Status: %s\n", entry.StatusText)
+		}
+		fmt.Fprintf(out, "- Retryable: %s\n", yesNo(entry.Retryable))
+		if entry.DocURL != "" {
+			fmt.Fprintf(out, "- Documentation: <%s>\n", entry.DocURL)
+		}
+		fmt.Fprintf(out, "\nExample:\n\n```json\n%s\n```\n", entry.Example)
+	}
+	return out.Flush()
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+var referenceTemplate = template.Must(template.New("reference").Funcs(template.FuncMap{"yesNo": yesNo}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<ul>
+{{- range .Entries}}
+<li><a href="#{{.Code}}"><code>{{.Code}}</code></a></li>
+{{- end}}
+</ul>
+{{- range .Entries}}
+<section id="{{.Code}}">
+<h2><code>{{.Code}}</code></h2>
+{{- if .Description}}
+<p>{{.Description}}</p>
+{{- end}}
+<dl>
+{{- if .StatusText}}
+<dt>Status</dt><dd>{{.StatusText}}</dd>
+{{- end}}
+<dt>Retryable</dt><dd>{{yesNo .Retryable}}</dd>
+{{- if .DocURL}}
+<dt>Documentation</dt><dd><a href="{{.DocURL}}">{{.DocURL}}</a></dd>
+{{- end}}
+</dl>
+<pre><code>{{.Example}}</code></pre>
+</section>
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,120 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func testReferenceRegistry() *Registry {
+	return NewRegistry(
+		CodeInfo{
+			Code:        "quota_exceeded",
+			Description: "The account is out of <quota>.",
+			Status:      http.StatusTooManyRequests,
+			Retryable:   true,
+			DocURL:      "https://example.com/docs/quota",
+			Example: &Diagnostic{
+				Severity: DiagnosticError,
+				Code:     "quota_exceeded",
+				Paths:    []Steps{HeaderPath("X-Account")},
+				Summary:  "Out of quota.",
+			},
+		},
+		CodeInfo{
+			Code:        CodeDeprecated,
+			Description: "The field is deprecated.",
+		},
+	)
+}
+
+func TestWriteReferenceMarkdown(t *testing.T) {
+	t.Parallel()
+
+	var out strings.Builder
+	err := WriteReference(&out, testReferenceRegistry(), ReferenceMarkdown, WithReferenceTitle("Widgets API errors"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := "# Widgets API errors\n" +
+		"\n## `deprecated`\n\n" +
+		"The field is deprecated.\n\n" +
+		"- Retryable: no\n" +
+		"\nExample:\n\n```json\n" +
+		"{\n  \"severity\": \"warning\",\n  \"code\": \"deprecated\",\n  \"summary\": \"The field is deprecated.\"\n}\n" +
+		"```\n" +
+		"\n## `quota_exceeded`\n\n" +
+		"The account is out of <quota>.\n\n" +
+		"- Status: 429 Too Many Requests\n" +
+		"- Retryable: yes\n" +
+		"- Documentation: <https://example.com/docs/quota>\n" +
+		"\nExample:\n\n```json\n" +
+		"{\n  \"severity\": \"error\",\n  \"code\": \"quota_exceeded\",\n  \"path\": [\n    [\n      {\n        \"kind\": \"header\",\n        \"value\": \"X-Account\"\n      }\n    ]\n  ],\n  \"summary\": \"Out of quota.\"\n}\n" +
+		"```\n"
+	if diff := cmp.Diff(expected, out.String()); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+}
+
+func TestWriteReferenceHTML(t *testing.T) {
+	t.Parallel()
+
+	var out strings.Builder
+	err := WriteReference(&out, testReferenceRegistry(), ReferenceHTML)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, expected := range []string{
+		"<title>API error reference</title>",
+		`<li><a href="#deprecated"><code>deprecated</code></a></li>`,
+		`<section id="quota_exceeded">`,
+		"<p>The account is out of &lt;quota&gt;.</p>",
+		"<dt>Status</dt><dd>429 Too Many Requests</dd>",
+		"<dt>Retryable</dt><dd>yes</dd>",
+		`<dd><a href="https://example.com/docs/quota">https://example.com/docs/quota</a></dd>`,
+		"&#34;summary&#34;: &#34;Out of quota.&#34;",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "<dt>Status</dt><dd></dd>") {
+		t.Errorf("expected codes without a status not to list one, got:\n%s", out.String())
+	}
+}
+
+func TestWriteReferenceJSON(t *testing.T) {
+	t.Parallel()
+
+	var out strings.Builder
+	err := WriteReference(&out, testReferenceRegistry(), ReferenceJSON)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var doc RegistryDocument
+	err = json.Unmarshal([]byte(out.String()), &doc)
+	if err != nil {
+		t.Fatalf("error decoding reference: %s", err)
+	}
+	registry := testReferenceRegistry()
+	deprecated, _ := registry.Lookup(CodeDeprecated)
+	example := deprecated.ExampleDiagnostic()
+	deprecated.Example = &example
+	quota, _ := registry.Lookup("quota_exceeded")
+	if diff := cmp.Diff(RegistryDocument{Codes: []CodeInfo{deprecated, quota}}, doc); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+}
+
+func TestWriteReferenceUnknownFormat(t *testing.T) {
+	t.Parallel()
+
+	var out strings.Builder
+	err := WriteReference(&out, testReferenceRegistry(), "pdf")
+	if err == nil || err.Error() != `unknown reference format "pdf"` {
+		t.Errorf("expected an unknown format error, got %v", err)
+	}
+}
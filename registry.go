@@ -0,0 +1,314 @@
+package apidiags
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CodeInfo describes a Code an API can return, so generic tooling can learn
+// what it means without knowing about the API ahead of time.
+type CodeInfo struct {
+	// Code is the Code being described.
+	Code Code `json:"code"`
+
+	// Description is a human-readable explanation of what the Code means.
+	Description string `json:"description,omitempty"`
+
+	// Status is the HTTP status code used for responses failing because of
+	// the Code, if it's an error Code.
+	Status int `json:"status,omitempty"`
+
+	// Retryable is true if requests failing because of the Code may
+	// succeed if they're retried.
+	Retryable bool `json:"retryable"`
+
+	// DocURL points to documentation about the Code.
+	DocURL string `json:"doc_url,omitempty"`
+
+	// Example is an optional example of a Diagnostic with the Code, for
+	// documentation like that written by WriteReference.
+	Example *Diagnostic `json:"example,omitempty"`
+}
+
+// ErrCodeRegistered is returned by Registry.Register when the Code being
+// registered already is.
+var ErrCodeRegistered = errors.New("code already registered")
+
+// Registry is a collection of the Codes an API can return, described by
+// CodeInfo. The zero value is an empty Registry, ready to use. A Registry
+// is safe for concurrent use.
+type Registry struct {
+	mu    sync.RWMutex
+	codes map[Code]CodeInfo
+}
+
+// NewRegistry returns a Registry containing infos. It panics if infos
+// describe the same Code more than once.
+func NewRegistry(infos ...CodeInfo) *Registry {
+	registry := &Registry{codes: map[Code]CodeInfo{}}
+	for _, info := range infos {
+		registry.MustRegister(info)
+	}
+	return registry
+}
+
+// BuiltinCodes returns CodeInfo describing the Codes defined by this
+// package, using their statuses from DefaultStatusMapping. Only
+// CodeActOfGod is Retryable.
+func BuiltinCodes() []CodeInfo {
+	statuses := DefaultStatusMapping()
+	descriptions := map[Code]string{
+		CodeAccessDenied:  "The caller doesn't have permission to perform the action.",
+		CodeInsufficient:  "The value is too low, too short, or has too few items.",
+		CodeOverflow:      "The value is too high, too long, or has too many items.",
+		CodeInvalidValue:  "The value isn't acceptable.",
+		CodeInvalidFormat: "The value is in a format that can't be read.",
+		CodeMissing:       "A required value wasn't present.",
+		CodeNotFound:      "The resource the value specifies wasn't found.",
+		CodeConflict:      "The values can't be used together, or can't be set to those values.",
+		CodeActOfGod:      "Something outside the caller's control disrupted the request; try again.",
+		CodeDeprecated:    "The field or value is deprecated, and may be removed or change behavior.",
+	}
+	results := make([]CodeInfo, 0, len(builtinCodes))
+	for _, code := range builtinCodes {
+		results = append(results, CodeInfo{
+			Code:        code,
+			Description: descriptions[code],
+			Status:      statuses[code],
+			Retryable:   code == CodeActOfGod,
+		})
+	}
+	return results
+}
+
+// DefaultRegistry is the Registry RegisterCode adds to. It starts out
+// containing BuiltinCodes.
+var DefaultRegistry = NewRegistry(BuiltinCodes()...)
+
+// RegisterCode registers info with DefaultRegistry. It's meant to be called
+// when a package defining its own Codes is initialized.
+func RegisterCode(info CodeInfo) error {
+	return DefaultRegistry.Register(info)
+}
+
+// Register adds info to the Registry. An error wrapping ErrCodeRegistered
+// is returned if its Code is already registered, and an error is returned
+// if it has no Code.
+func (r *Registry) Register(info CodeInfo) error {
+	if info.Code == "" {
+		return errors.New("can't register a CodeInfo without a Code")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.codes[info.Code]; ok {
+		return fmt.Errorf("%w: %s", ErrCodeRegistered, info.Code)
+	}
+	if r.codes == nil {
+		r.codes = map[Code]CodeInfo{}
+	}
+	r.codes[info.Code] = info
+	return nil
+}
+
+// MustRegister is like Register, but panics if info can't be registered.
+func (r *Registry) MustRegister(info CodeInfo) {
+	if err := r.Register(info); err != nil {
+		panic("apidiags: error registering code: " + err.Error())
+	}
+}
+
+// Lookup returns the CodeInfo registered for code, and false if there
+// isn't one.
+func (r *Registry) Lookup(code Code) (CodeInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info, ok := r.codes[code]
+	return info, ok
+}
+
+// Codes returns the CodeInfo of every registered Code, ordered by Code.
+func (r *Registry) Codes() []CodeInfo {
+	r.mu.RLock()
+	results := make([]CodeInfo, 0, len(r.codes))
+	for _, info := range r.codes {
+		results = append(results, info)
+	}
+	r.mu.RUnlock()
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Code < results[j].Code
+	})
+	return results
+}
+
+// Retryable reports whether diag can succeed if it's retried, using the
+// Retryable of its Code's CodeInfo if it's registered, and IsRetryable
+// otherwise. Errors recording how long to wait using WithRetryAfter are
+// always retryable. It can be used as a RetryPolicy's Retryable.
+func (r *Registry) Retryable(diag Diagnostic) bool {
+	info, ok := r.Lookup(diag.Code)
+	if !ok || diag.Severity != DiagnosticError {
+		return IsRetryable(diag)
+	}
+	if _, ok := diag.Extensions[RetryAfterMember]; ok {
+		return true
+	}
+	return info.Retryable
+}
+
+// RegistryDocument is the JSON document describing a Registry, as served by
+// RegistryHandler.
+type RegistryDocument struct {
+	Codes []CodeInfo `json:"codes"`
+}
+
+// Document returns a RegistryDocument describing the Registry.
+func (r *Registry) Document() RegistryDocument {
+	return RegistryDocument{Codes: r.Codes()}
+}
+
+// RegistryHandler returns an http.Handler serving registry's
+// RegistryDocument as JSON, so clients like RegistryClient can discover the
+// Codes an API returns at runtime. Responses have an ETag, so clients can
+// revalidate them cheaply with If-None-Match.
+func RegistryHandler(registry *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			_ = WriteHTTP(w, Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeInvalidValue,
+				Summary:  "Method not allowed.",
+			}}, WithStatus(http.StatusMethodNotAllowed))
+			return
+		}
+		body, err := json.Marshal(registry.Document())
+		if err != nil {
+			_ = WriteHTTP(w, Diagnostics{{Severity: DiagnosticError, Code: CodeActOfGod}})
+			return
+		}
+		sum := sha256.Sum256(body)
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodHead {
+			return
+		}
+		_, _ = w.Write(body)
+	})
+}
+
+// DefaultRegistryTTL is how long a RegistryClient uses a RegistryDocument
+// before fetching it again, when no TTL is set.
+const DefaultRegistryTTL = 5 * time.Minute
+
+// RegistryClient fetches and caches the RegistryDocument served by a
+// RegistryHandler. The zero value isn't usable; URL must be set. A
+// RegistryClient is safe for concurrent use.
+type RegistryClient struct {
+	// URL is the URL the RegistryHandler is served at.
+	URL string
+
+	// Client is used to fetch the RegistryDocument. It defaults to
+	// http.DefaultClient.
+	Client *http.Client
+
+	// TTL is how long a fetched RegistryDocument is used before it's
+	// fetched again. It defaults to DefaultRegistryTTL.
+	TTL time.Duration
+
+	mu       sync.Mutex
+	registry *Registry
+	etag     string
+	fetched  time.Time
+}
+
+// Registry returns a Registry built from the RegistryDocument at c.URL,
+// fetching it if it hasn't been fetched within the TTL. If fetching fails
+// but a RegistryDocument was fetched before, the stale Registry is returned
+// along with the error.
+func (c *RegistryClient) Registry(ctx context.Context) (*Registry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ttl := c.TTL
+	if ttl <= 0 {
+		ttl = DefaultRegistryTTL
+	}
+	if c.registry != nil && time.Since(c.fetched) < ttl {
+		return c.registry, nil
+	}
+	registry, err := c.fetch(ctx)
+	if err != nil {
+		return c.registry, err
+	}
+	c.registry = registry
+	c.fetched = time.Now()
+	return registry, nil
+}
+
+// Lookup returns the CodeInfo the API registered for code, fetching the
+// RegistryDocument if necessary.
+func (c *RegistryClient) Lookup(ctx context.Context, code Code) (CodeInfo, bool, error) {
+	registry, err := c.Registry(ctx)
+	if registry == nil {
+		return CodeInfo{}, false, err
+	}
+	info, ok := registry.Lookup(code)
+	return info, ok, err
+}
+
+// fetch fetches the RegistryDocument, revalidating the one already fetched
+// if there is one. It must be called with c.mu held.
+func (c *RegistryClient) fetch(ctx context.Context) (*Registry, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error building registry request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.registry != nil && c.etag != "" {
+		req.Header.Set("If-None-Match", c.etag)
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching registry: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && c.registry != nil {
+		return c.registry, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching registry: unexpected status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, DefaultMaxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("error reading registry: %w", err)
+	}
+	var doc RegistryDocument
+	err = json.Unmarshal(body, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing registry: %w", err)
+	}
+	registry := &Registry{codes: make(map[Code]CodeInfo, len(doc.Codes))}
+	for _, info := range doc.Codes {
+		registry.codes[info.Code] = info
+	}
+	c.etag = resp.Header.Get("ETag")
+	return registry, nil
+}
@@ -0,0 +1,123 @@
+package apidiags
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(BuiltinCodes()...)
+	quota := CodeInfo{
+		Code:        "quota_exceeded",
+		Description: "The account is out of quota.",
+		Status:      http.StatusTooManyRequests,
+		Retryable:   true,
+		DocURL:      "https://example.com/docs/quota",
+	}
+	if err := registry.Register(quota); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := registry.Register(quota); !errors.Is(err, ErrCodeRegistered) {
+		t.Errorf("expected ErrCodeRegistered, got %v", err)
+	}
+	if err := registry.Register(CodeInfo{}); err == nil {
+		t.Error("expected an error registering a CodeInfo without a Code")
+	}
+
+	info, ok := registry.Lookup(CodeNotFound)
+	if !ok {
+		t.Fatal("expected not_found to be registered")
+	}
+	if info.Status != http.StatusNotFound || info.Retryable {
+		t.Errorf("unexpected CodeInfo for not_found: %+v", info)
+	}
+	codes := registry.Codes()
+	if len(codes) != len(builtinCodes)+1 {
+		t.Errorf("expected %d codes, got %d", len(builtinCodes)+1, len(codes))
+	}
+	for pos := 1; pos < len(codes); pos++ {
+		if codes[pos-1].Code >= codes[pos].Code {
+			t.Errorf("expected codes to be sorted, got %s before %s", codes[pos-1].Code, codes[pos].Code)
+		}
+	}
+
+	retryable := map[string]struct {
+		diag     Diagnostic
+		expected bool
+	}{
+		"registered":    {Diagnostic{Severity: DiagnosticError, Code: "quota_exceeded"}, true},
+		"builtin":       {Diagnostic{Severity: DiagnosticError, Code: CodeActOfGod}, true},
+		"not-retryable": {Diagnostic{Severity: DiagnosticError, Code: CodeConflict}, false},
+		"retry-after":   {Diagnostic{Severity: DiagnosticError, Code: CodeConflict}.WithRetryAfter(time.Second), true},
+		"warning":       {Diagnostic{Severity: DiagnosticWarning, Code: "quota_exceeded"}, false},
+		"unregistered":  {Diagnostic{Severity: DiagnosticError, Code: "unknown"}, false},
+	}
+	for name, tc := range retryable {
+		if result := registry.Retryable(tc.diag); result != tc.expected {
+			t.Errorf("%s: expected %v, got %v", name, tc.expected, result)
+		}
+	}
+}
+
+func TestRegistryClient(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(CodeInfo{Code: "quota_exceeded", Retryable: true})
+	var requests, notModified int64
+	handler := RegistryHandler(registry)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		if r.Header.Get("If-None-Match") != "" {
+			atomic.AddInt64(&notModified, 1)
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := &RegistryClient{URL: server.URL, Client: server.Client(), TTL: time.Hour}
+	ctx := context.Background()
+	info, ok, err := client.Lookup(ctx, "quota_exceeded")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !ok {
+		t.Fatal("expected quota_exceeded to be found")
+	}
+	if diff := cmp.Diff(CodeInfo{Code: "quota_exceeded", Retryable: true}, info); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	if _, ok, err := client.Lookup(ctx, CodeNotFound); ok || err != nil {
+		t.Errorf("expected not_found to be missing without an error, got %v and %v", ok, err)
+	}
+	if got := atomic.LoadInt64(&requests); got != 1 {
+		t.Errorf("expected the registry to be fetched once, got %d", got)
+	}
+
+	client.mu.Lock()
+	client.fetched = time.Time{}
+	client.mu.Unlock()
+	if _, err := client.Registry(ctx); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := atomic.LoadInt64(&notModified); got != 1 {
+		t.Errorf("expected the registry to be revalidated, got %d conditional requests", got)
+	}
+
+	resp, err := server.Client().Post(server.URL, "application/json", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected status %d, got %d", http.StatusMethodNotAllowed, resp.StatusCode)
+	}
+}
@@ -0,0 +1,146 @@
+package apidiags
+
+import (
+	"fmt"
+	"sort"
+)
+
+// RegistryChangeKind is a kind of difference DiffRegistries reports between
+// two RegistryDocuments.
+type RegistryChangeKind string
+
+const (
+	// ChangeCodeRemoved is a RegistryChangeKind for a Code that's no longer
+	// described. It's a breaking change, as clients handling the Code
+	// won't get it anymore.
+	ChangeCodeRemoved RegistryChangeKind = "code_removed"
+
+	// ChangeCodeAdded is a RegistryChangeKind for a Code that wasn't
+	// described before.
+	ChangeCodeAdded RegistryChangeKind = "code_added"
+
+	// ChangeStatus is a RegistryChangeKind for a Code whose Status changed.
+	// It's a breaking change, as clients can tell what went wrong by the
+	// status of a response.
+	ChangeStatus RegistryChangeKind = "status"
+
+	// ChangeRetryable is a RegistryChangeKind for a Code whose Retryable
+	// changed. It's a breaking change if the Code isn't Retryable anymore,
+	// as clients retrying requests failing because of it shouldn't.
+	ChangeRetryable RegistryChangeKind = "retryable"
+
+	// ChangeDescription is a RegistryChangeKind for a Code whose
+	// Description changed.
+	ChangeDescription RegistryChangeKind = "description"
+
+	// ChangeDocURL is a RegistryChangeKind for a Code whose DocURL changed.
+	ChangeDocURL RegistryChangeKind = "doc_url"
+)
+
+// RegistryChange is a difference between the descriptions of a Code in two
+// RegistryDocuments.
+type RegistryChange struct {
+	// Code is the Code whose description changed.
+	Code Code
+
+	// Kind is the kind of change.
+	Kind RegistryChangeKind
+
+	// Breaking is true if the change can break clients of the API that
+	// rely on the old description.
+	Breaking bool
+
+	// Old and New are the old and new descriptions of the Code. Old is
+	// the zero value for ChangeCodeAdded, and New for ChangeCodeRemoved.
+	Old, New CodeInfo
+}
+
+func (c RegistryChange) String() string {
+	switch c.Kind {
+	case ChangeCodeRemoved:
+		return fmt.Sprintf("%s was removed", c.Code)
+	case ChangeCodeAdded:
+		return fmt.Sprintf("%s was added", c.Code)
+	case ChangeStatus:
+		return fmt.Sprintf("status of %s changed from %s to %s", c.Code, statusOrNone(c.Old.Status), statusOrNone(c.New.Status))
+	case ChangeRetryable:
+		if c.New.Retryable {
+			return fmt.Sprintf("%s is now retryable", c.Code)
+		}
+		return fmt.Sprintf("%s is no longer retryable", c.Code)
+	case ChangeDescription:
+		return fmt.Sprintf("description of %s changed from %q to %q", c.Code, c.Old.Description, c.New.Description)
+	case ChangeDocURL:
+		return fmt.Sprintf("doc URL of %s changed from %q to %q", c.Code, c.Old.DocURL, c.New.DocURL)
+	}
+	return fmt.Sprintf("%s of %s changed", c.Kind, c.Code)
+}
+
+func statusOrNone(status int) string {
+	if status == 0 {
+		return "none"
+	}
+	return statusText(status)
+}
+
+// DiffRegistries compares the RegistryDocuments of two releases of an API,
+// returning the changes between the Codes described by oldDoc and newDoc,
+// ordered by Code, so a release can be blocked on the ones that are
+// Breaking. If a document describes a Code more than once, the last
+// description is used. Changes to Examples aren't reported.
+func DiffRegistries(oldDoc, newDoc RegistryDocument) []RegistryChange {
+	oldCodes := indexCodes(oldDoc)
+	newCodes := indexCodes(newDoc)
+	codes := make([]Code, 0, len(oldCodes)+len(newCodes))
+	for code := range oldCodes {
+		codes = append(codes, code)
+	}
+	for code := range newCodes {
+		if _, ok := oldCodes[code]; !ok {
+			codes = append(codes, code)
+		}
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+
+	var changes []RegistryChange
+	for _, code := range codes {
+		oldInfo, inOld := oldCodes[code]
+		newInfo, inNew := newCodes[code]
+		change := RegistryChange{Code: code, Old: oldInfo, New: newInfo}
+		switch {
+		case !inNew:
+			change.Kind, change.Breaking = ChangeCodeRemoved, true
+			changes = append(changes, change)
+			continue
+		case !inOld:
+			change.Kind = ChangeCodeAdded
+			changes = append(changes, change)
+			continue
+		}
+		if oldInfo.Status != newInfo.Status {
+			change.Kind, change.Breaking = ChangeStatus, true
+			changes = append(changes, change)
+		}
+		if oldInfo.Retryable != newInfo.Retryable {
+			change.Kind, change.Breaking = ChangeRetryable, oldInfo.Retryable
+			changes = append(changes, change)
+		}
+		if oldInfo.Description != newInfo.Description {
+			change.Kind, change.Breaking = ChangeDescription, false
+			changes = append(changes, change)
+		}
+		if oldInfo.DocURL != newInfo.DocURL {
+			change.Kind, change.Breaking = ChangeDocURL, false
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+func indexCodes(doc RegistryDocument) map[Code]CodeInfo {
+	codes := make(map[Code]CodeInfo, len(doc.Codes))
+	for _, info := range doc.Codes {
+		codes[info.Code] = info
+	}
+	return codes
+}
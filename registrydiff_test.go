@@ -0,0 +1,99 @@
+package apidiags
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiffRegistries(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		old, new RegistryDocument
+		expected []RegistryChange
+		strings  []string
+	}
+
+	missing := CodeInfo{Code: CodeMissing, Description: "A required value is missing.", Status: http.StatusBadRequest}
+	quota := CodeInfo{Code: "quota_exceeded", Status: http.StatusTooManyRequests, Retryable: true, DocURL: "https://example.com/quota"}
+
+	cases := map[string]testCase{
+		"unchanged": {
+			old: RegistryDocument{Codes: []CodeInfo{missing, quota}},
+			new: RegistryDocument{Codes: []CodeInfo{quota, missing}},
+		},
+		"removed-and-added": {
+			old: RegistryDocument{Codes: []CodeInfo{missing}},
+			new: RegistryDocument{Codes: []CodeInfo{quota}},
+			expected: []RegistryChange{
+				{Code: CodeMissing, Kind: ChangeCodeRemoved, Breaking: true, Old: missing},
+				{Code: "quota_exceeded", Kind: ChangeCodeAdded, New: quota},
+			},
+			strings: []string{"missing was removed", "quota_exceeded was added"},
+		},
+		"status": {
+			old: RegistryDocument{Codes: []CodeInfo{missing}},
+			new: RegistryDocument{Codes: []CodeInfo{{Code: CodeMissing, Description: missing.Description, Status: http.StatusUnprocessableEntity}}},
+			expected: []RegistryChange{
+				{Code: CodeMissing, Kind: ChangeStatus, Breaking: true, Old: missing, New: CodeInfo{Code: CodeMissing, Description: missing.Description, Status: http.StatusUnprocessableEntity}},
+			},
+			strings: []string{"status of missing changed from 400 Bad Request to 422 Unprocessable Entity"},
+		},
+		"status-removed": {
+			old:      RegistryDocument{Codes: []CodeInfo{{Code: CodeDeprecated, Status: http.StatusGone}}},
+			new:      RegistryDocument{Codes: []CodeInfo{{Code: CodeDeprecated}}},
+			expected: []RegistryChange{{Code: CodeDeprecated, Kind: ChangeStatus, Breaking: true, Old: CodeInfo{Code: CodeDeprecated, Status: http.StatusGone}, New: CodeInfo{Code: CodeDeprecated}}},
+			strings:  []string{"status of deprecated changed from 410 Gone to none"},
+		},
+		"retryable-tightened": {
+			old:      RegistryDocument{Codes: []CodeInfo{quota}},
+			new:      RegistryDocument{Codes: []CodeInfo{{Code: "quota_exceeded", Status: http.StatusTooManyRequests, DocURL: quota.DocURL}}},
+			expected: []RegistryChange{{Code: "quota_exceeded", Kind: ChangeRetryable, Breaking: true, Old: quota, New: CodeInfo{Code: "quota_exceeded", Status: http.StatusTooManyRequests, DocURL: quota.DocURL}}},
+			strings:  []string{"quota_exceeded is no longer retryable"},
+		},
+		"retryable-loosened": {
+			old:      RegistryDocument{Codes: []CodeInfo{missing}},
+			new:      RegistryDocument{Codes: []CodeInfo{{Code: CodeMissing, Description: missing.Description, Status: http.StatusBadRequest, Retryable: true}}},
+			expected: []RegistryChange{{Code: CodeMissing, Kind: ChangeRetryable, Old: missing, New: CodeInfo{Code: CodeMissing, Description: missing.Description, Status: http.StatusBadRequest, Retryable: true}}},
+			strings:  []string{"missing is now retryable"},
+		},
+		"documentation": {
+			old: RegistryDocument{Codes: []CodeInfo{quota}},
+			new: RegistryDocument{Codes: []CodeInfo{{Code: "quota_exceeded", Description: "Out of quota.", Status: http.StatusTooManyRequests, Retryable: true}}},
+			expected: []RegistryChange{
+				{Code: "quota_exceeded", Kind: ChangeDescription, Old: quota, New: CodeInfo{Code: "quota_exceeded", Description: "Out of quota.", Status: http.StatusTooManyRequests, Retryable: true}},
+				{Code: "quota_exceeded", Kind: ChangeDocURL, Old: quota, New: CodeInfo{Code: "quota_exceeded", Description: "Out of quota.", Status: http.StatusTooManyRequests, Retryable: true}},
+			},
+			strings: []string{
+				`description of quota_exceeded changed from "" to "Out of quota."`,
+				`doc URL of quota_exceeded changed from "https://example.com/quota" to ""`,
+			},
+		},
+		"duplicate-uses-last": {
+			old: RegistryDocument{Codes: []CodeInfo{{Code: CodeMissing}, missing}},
+			new: RegistryDocument{Codes: []CodeInfo{missing}},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result := DiffRegistries(tc.old, tc.new)
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+			var strs []string
+			for _, change := range result {
+				strs = append(strs, change.String())
+			}
+			if diff := cmp.Diff(tc.strings, strs); diff != "" {
+				t.Errorf("unexpected strings (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
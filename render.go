@@ -0,0 +1,302 @@
+package apidiags
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The ANSI escape sequences Render uses when WithColor is set.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[1;31m"
+	ansiYellow = "\x1b[1;33m"
+	ansiBlue   = "\x1b[1;34m"
+)
+
+// RenderOption configures how Render writes Diagnostics.
+type RenderOption func(*renderConfig)
+
+type renderConfig struct {
+	color bool
+}
+
+// WithColor makes Render highlight its output with ANSI escape sequences,
+// for writing to terminals. Errors are red, warnings are yellow.
+func WithColor() RenderOption {
+	return func(c *renderConfig) {
+		c.color = true
+	}
+}
+
+// Render writes diags to w in a form meant for people, like a compiler's
+// diagnostics, using body, the JSON request body the Diagnostics are
+// about, to show the part of the request each one points to:
+//
+//	error[invalid_format]: Expected int, got string.
+//	 --> body.count (line 3, column 12)
+//	  |
+//	3 |   "count": "three",
+//	  |            ^^^^^^^
+//
+// Paths into body are shown by underlining the value they point to, or if
+// it isn't in body, the closest value containing it. Paths ending in a
+// StringIndexStep or RuneIndexStep point to a single character of a string
+// value, or of body itself if they come right after the BodyStep, like
+// those DecodeBody uses for syntax errors. Paths that point outside body,
+// like to headers, are listed without a snippet.
+//
+// Lines end in a newline. Each Diagnostic is followed by a blank line.
+func Render(w io.Writer, diags Diagnostics, body []byte, opts ...RenderOption) error {
+	var cfg renderConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	out := bufio.NewWriter(w)
+	for _, diag := range diags {
+		renderDiagnostic(out, &cfg, diag, body)
+	}
+	return out.Flush()
+}
+
+// renderDiagnostic writes diag to w, as described by Render.
+func renderDiagnostic(w *bufio.Writer, cfg *renderConfig, diag Diagnostic, body []byte) {
+	diag = diag.RenderMessage(nil)
+	color := ansiRed
+	if diag.Severity == DiagnosticWarning {
+		color = ansiYellow
+	}
+	paint := func(style, text string) string {
+		if !cfg.color {
+			return text
+		}
+		return style + text + ansiReset
+	}
+	fmt.Fprintf(w, "%s%s\n", paint(color, string(diag.Severity)+"["+string(diag.Code)+"]"), paint(ansiBold, ": "+diag.Message()))
+
+	type snippet struct {
+		path         Steps
+		line, col    int
+		text, marker string
+		located      bool
+	}
+	snippets := make([]snippet, 0, len(diag.Paths))
+	gutter := 1
+	for _, path := range diag.Paths {
+		snip := snippet{path: path}
+		start, end, ok := locateSpan(body, path)
+		if ok {
+			snip.located = true
+			snip.line, snip.col, snip.text, snip.marker = sourceLine(body, start, end)
+			if width := len(strconv.Itoa(snip.line)); width > gutter {
+				gutter = width
+			}
+		}
+		snippets = append(snippets, snip)
+	}
+	pad := strings.Repeat(" ", gutter)
+	for _, snip := range snippets {
+		if !snip.located {
+			fmt.Fprintf(w, "%s%s %s\n", pad, paint(ansiBlue, "-->"), snip.path)
+			continue
+		}
+		fmt.Fprintf(w, "%s%s %s (line %d, column %d)\n", pad, paint(ansiBlue, "-->"), snip.path, snip.line, snip.col)
+		fmt.Fprintf(w, "%s %s\n", pad, paint(ansiBlue, "|"))
+		fmt.Fprintf(w, "%s %s %s\n", paint(ansiBlue, fmt.Sprintf("%*d", gutter, snip.line)), paint(ansiBlue, "|"), snip.text)
+		fmt.Fprintf(w, "%s %s %s\n", pad, paint(ansiBlue, "|"), paint(color, snip.marker))
+	}
+	if diag.Detail != "" && diag.Detail != diag.Summary {
+		fmt.Fprintf(w, "%s %s %s\n", pad, paint(ansiBlue, "="), diag.Detail)
+	}
+	if diag.DocURL != "" {
+		fmt.Fprintf(w, "%s %s see %s\n", pad, paint(ansiBlue, "="), diag.DocURL)
+	}
+	w.WriteString("\n")
+}
+
+// sourceLine returns the line and column, both starting at 1, of the
+// character at byte offset start in body, along with the text of the line
+// and a marker underlining the characters from start to end on it. Columns
+// are counted in characters.
+func sourceLine(body []byte, start, end int) (line, col int, text, marker string) {
+	lineStart := bytes.LastIndexByte(body[:start], '\n') + 1
+	lineEnd := bytes.IndexByte(body[start:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(body)
+	} else {
+		lineEnd += start
+	}
+	if end > lineEnd {
+		end = lineEnd
+	}
+	line = bytes.Count(body[:start], []byte{'\n'}) + 1
+	col = utf8.RuneCount(body[lineStart:start]) + 1
+	text = strings.TrimRight(string(body[lineStart:lineEnd]), "\r")
+
+	// keep tabs in the indent, so the marker lines up however wide the
+	// terminal renders them
+	var indent strings.Builder
+	for _, r := range string(body[lineStart:start]) {
+		if r == '\t' {
+			indent.WriteRune('\t')
+		} else {
+			indent.WriteRune(' ')
+		}
+	}
+	width := utf8.RuneCount(bytes.TrimRight(body[start:end], "\r"))
+	if width < 1 {
+		width = 1
+	}
+	return line, col, text, indent.String() + strings.Repeat("^", width)
+}
+
+// locateSpan returns the byte offsets in body of the start and end of the
+// part of it path points to, as described by Render. If path doesn't point
+// into body, false is returned.
+func locateSpan(body []byte, path Steps) (start, end int, ok bool) {
+	if len(path) < 2 || len(body) < 1 {
+		return 0, 0, false
+	}
+	if _, ok := path[0].(BodyStep); !ok {
+		return 0, 0, false
+	}
+	if len(path) == 2 {
+		switch step := path[1].(type) {
+		case StringIndexStep:
+			return charSpan(body, int(step))
+		case RuneIndexStep:
+			offset, _ := step.ByteOffset(string(body))
+			return charSpan(body, offset)
+		}
+	}
+
+	// find the deepest value in body that path points to or into
+	var found *jsonValue
+	walkJSON(body, nil, func(value jsonValue) bool {
+		if len(value.path) > len(path) || !value.path.Equal(path[:len(value.path)]) {
+			return false
+		}
+		found = &value
+		return len(value.path) == len(path)
+	})
+	if found == nil {
+		return 0, 0, false
+	}
+	start = int(found.start)
+	dec := json.NewDecoder(bytes.NewReader(body[start:]))
+	var raw json.RawMessage
+	if err := dec.Decode(&raw); err != nil {
+		return int(found.start), int(found.end), true
+	}
+	end = start + int(dec.InputOffset())
+	if len(found.path) == len(path)-1 && raw[0] == '"' {
+		var charStart, charEnd int
+		var ok bool
+		switch step := path[len(path)-1].(type) {
+		case StringIndexStep:
+			charStart, charEnd, ok = stringCharSpan(raw, int(step), false)
+		case RuneIndexStep:
+			charStart, charEnd, ok = stringCharSpan(raw, int(step), true)
+		}
+		if ok {
+			return start + charStart, start + charEnd, true
+		}
+	}
+	return start, end, true
+}
+
+// charSpan returns the byte offsets of the start and end of the character
+// at byte offset pos in body. Offsets at or past the end of body point to
+// the end of body.
+func charSpan(body []byte, pos int) (start, end int, ok bool) {
+	if pos < 0 {
+		return 0, 0, false
+	}
+	if pos >= len(body) {
+		return len(body), len(body), true
+	}
+	_, size := utf8.DecodeRune(body[pos:])
+	return pos, pos + size, true
+}
+
+// stringCharSpan returns the byte offsets in raw, a JSON string literal
+// including its quotes, of the start and end of the character at index idx
+// of the string it decodes to, including the whole escape sequence if the
+// character is escaped. idx is counted in runes if runes is true, and bytes
+// of the string's UTF-8 encoding otherwise. An index just past the end of
+// the string points to its closing quote; false is returned for indices
+// past that, or that fall in the middle of a character.
+func stringCharSpan(raw []byte, idx int, runes bool) (start, end int, ok bool) {
+	var decoded int
+	pos := 1
+	for pos < len(raw)-1 {
+		if decoded > idx {
+			return 0, 0, false
+		}
+		next, size := stringChar(raw, pos)
+		if decoded == idx {
+			return pos, pos + next, true
+		}
+		pos += next
+		if runes {
+			decoded++
+		} else {
+			decoded += size
+		}
+	}
+	if decoded == idx {
+		return pos, pos + 1, true
+	}
+	return 0, 0, false
+}
+
+// stringChar returns the length in bytes of the character starting at
+// byte offset pos of raw, a JSON string literal, and of its UTF-8 encoding
+// once it's decoded.
+func stringChar(raw []byte, pos int) (length, size int) {
+	if raw[pos] != '\\' {
+		r, length := utf8.DecodeRune(raw[pos:])
+		if r == utf8.RuneError && length == 1 {
+			// encoding/json replaces invalid bytes with U+FFFD
+			return length, utf8.RuneLen(utf8.RuneError)
+		}
+		return length, length
+	}
+	if pos+1 >= len(raw) || raw[pos+1] != 'u' {
+		return 2, 1
+	}
+	length = 6
+	if pos+length > len(raw) {
+		length = len(raw) - pos
+	}
+	r := unquoteRune(raw[pos : pos+length])
+	if r >= 0xd800 && r < 0xdc00 && pos+12 <= len(raw) {
+		// a surrogate pair, encoding a single character
+		if low := unquoteRune(raw[pos+6 : pos+12]); low >= 0xdc00 && low < 0xe000 {
+			return 12, utf8.RuneLen((r-0xd800)<<10 + (low - 0xdc00) + 0x10000)
+		}
+	}
+	if !utf8.ValidRune(r) {
+		return length, utf8.RuneLen(utf8.RuneError)
+	}
+	return length, utf8.RuneLen(r)
+}
+
+// unquoteRune returns the rune encoded by esc, a `\uXXXX` escape sequence,
+// or -1 if it isn't one.
+func unquoteRune(esc []byte) rune {
+	if len(esc) != 6 {
+		return -1
+	}
+	r, err := strconv.ParseUint(string(esc[2:]), 16, 32)
+	if err != nil {
+		return -1
+	}
+	return rune(r)
+}
@@ -0,0 +1,336 @@
+package apidiags
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	body := "{\n" +
+		`  "name": "caf\u00e9 bar",` + "\n" +
+		"\t\"count\": \"three\",\n" +
+		`  "tags": [1, 2]` + "\n" +
+		"}\n"
+
+	type testCase struct {
+		diags    Diagnostics
+		body     string
+		opts     []RenderOption
+		expected []string
+	}
+
+	cases := map[string]testCase{
+		"value": {
+			diags: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeInvalidFormat,
+				Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("count"))},
+				Summary:  "Expected int, got string.",
+			}},
+			body: body,
+			expected: []string{
+				"error[invalid_format]: Expected int, got string.",
+				" --> body.count (line 3, column 11)",
+				"  |",
+				"3 | \t\"count\": \"three\",",
+				"  | \t         ^^^^^^^",
+				"",
+			},
+		},
+		"array-element": {
+			diags: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeInsufficient,
+				Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("tags")).AddStep(ArrayIndexStep(1))},
+			}},
+			body: body,
+			expected: []string{
+				"error[insufficient]: insufficient",
+				" --> body.tags[1] (line 4, column 15)",
+				"  |",
+				`4 |   "tags": [1, 2]`,
+				"  |               ^",
+				"",
+			},
+		},
+		"array": {
+			diags: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeOverflow,
+				Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("tags"))},
+			}},
+			body: body,
+			expected: []string{
+				"error[overflow]: overflow",
+				" --> body.tags (line 4, column 11)",
+				"  |",
+				`4 |   "tags": [1, 2]`,
+				"  |           ^^^^^^",
+				"",
+			},
+		},
+		"multi-line-value": {
+			diags: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeInvalidValue,
+				Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("extra"))},
+			}},
+			body: "{\"extra\": {\n  \"a\": 1\n}}",
+			expected: []string{
+				"error[invalid_value]: invalid_value",
+				" --> body.extra (line 1, column 11)",
+				"  |",
+				`1 | {"extra": {`,
+				"  |           ^",
+				"",
+			},
+		},
+		"rune-index-escaped": {
+			diags: Diagnostics{{
+				Severity: DiagnosticWarning,
+				Code:     CodeInvalidValue,
+				Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("name")).AddStep(RuneIndexStep(3))},
+			}},
+			body: body,
+			expected: []string{
+				"warning[invalid_value]: invalid_value",
+				" --> body.name.rune_index(3) (line 2, column 15)",
+				"  |",
+				`2 |   "name": "caf\u00e9 bar",`,
+				"  |               ^^^^^^",
+				"",
+			},
+		},
+		"string-index-after-escape": {
+			diags: Diagnostics{{
+				Severity: DiagnosticWarning,
+				Code:     CodeInvalidValue,
+				// é is two bytes, so the space after it is at 5
+				Paths: []Steps{BodyPath().AddStep(ObjectPropertyStep("name")).AddStep(StringIndexStep(5))},
+			}},
+			body: body,
+			expected: []string{
+				"warning[invalid_value]: invalid_value",
+				" --> body.name.string_index(5) (line 2, column 21)",
+				"  |",
+				`2 |   "name": "caf\u00e9 bar",`,
+				"  |                     ^",
+				"",
+			},
+		},
+		"string-index-mid-character": {
+			diags: Diagnostics{{
+				Severity: DiagnosticWarning,
+				Code:     CodeInvalidValue,
+				Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("name")).AddStep(StringIndexStep(4))},
+			}},
+			body: body,
+			expected: []string{
+				"warning[invalid_value]: invalid_value",
+				" --> body.name.string_index(4) (line 2, column 11)",
+				"  |",
+				`2 |   "name": "caf\u00e9 bar",`,
+				"  |           ^^^^^^^^^^^^^^^",
+				"",
+			},
+		},
+		"multibyte": {
+			diags: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeInvalidValue,
+				Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("café")).AddStep(RuneIndexStep(1))},
+			}},
+			body: `{"café": "ñoño"}`,
+			expected: []string{
+				"error[invalid_value]: invalid_value",
+				` --> body["café"].rune_index(1) (line 1, column 12)`,
+				"  |",
+				`1 | {"café": "ñoño"}`,
+				"  |            ^",
+				"",
+			},
+		},
+		"string-index-end": {
+			diags: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeInsufficient,
+				Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("id")).AddStep(RuneIndexStep(2))},
+			}},
+			body: `{"id": "ab"}`,
+			expected: []string{
+				"error[insufficient]: insufficient",
+				" --> body.id.rune_index(2) (line 1, column 11)",
+				"  |",
+				`1 | {"id": "ab"}`,
+				"  |           ^",
+				"",
+			},
+		},
+		"body-offset": {
+			diags: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeInvalidFormat,
+				Paths:    []Steps{BodyPath().AddStep(StringIndexStep(15))},
+				Detail:   "invalid character '}' looking for beginning of object key string",
+			}},
+			body: `{"name": "foo",}`,
+			expected: []string{
+				"error[invalid_format]: invalid_format",
+				" --> body.string_index(15) (line 1, column 16)",
+				"  |",
+				`1 | {"name": "foo",}`,
+				"  |                ^",
+				"  = invalid character '}' looking for beginning of object key string",
+				"",
+			},
+		},
+		"body-end": {
+			diags: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeInvalidFormat,
+				Paths:    []Steps{BodyPath().AddStep(StringIndexStep(8))},
+			}},
+			body: `{"a": 1`,
+			expected: []string{
+				"error[invalid_format]: invalid_format",
+				" --> body.string_index(8) (line 1, column 8)",
+				"  |",
+				`1 | {"a": 1`,
+				"  |        ^",
+				"",
+			},
+		},
+		"missing-member": {
+			diags: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeMissing,
+				Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("labels")).AddStep(ObjectPropertyStep("key"))},
+				Summary:  "A key is required.",
+			}},
+			body: `{"labels": {"value": "x"}}`,
+			expected: []string{
+				"error[missing]: A key is required.",
+				" --> body.labels.key (line 1, column 12)",
+				"  |",
+				`1 | {"labels": {"value": "x"}}`,
+				"  |            ^^^^^^^^^^^^^^",
+				"",
+			},
+		},
+		"outside-body": {
+			diags: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeMissing,
+				Paths:    []Steps{HeaderPath("Idempotency-Key"), BodyPath()},
+				Summary:  "An idempotency key is required.",
+				Detail:   "Set the Idempotency-Key header.",
+				DocURL:   "https://example.com/docs/idempotency",
+			}},
+			body: body,
+			expected: []string{
+				"error[missing]: An idempotency key is required.",
+				` --> header("Idempotency-Key")`,
+				" --> body",
+				"  = Set the Idempotency-Key header.",
+				"  = see https://example.com/docs/idempotency",
+				"",
+			},
+		},
+		"no-body": {
+			diags: Diagnostics{{
+				Severity: DiagnosticError,
+				Code:     CodeMissing,
+				Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("name"))},
+			}},
+			expected: []string{
+				"error[missing]: missing",
+				" --> body.name",
+				"",
+			},
+		},
+		"gutter": {
+			diags: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     CodeConflict,
+					Paths: []Steps{
+						BodyPath().AddStep(ObjectPropertyStep("a")),
+						BodyPath().AddStep(ObjectPropertyStep("b")),
+					},
+				},
+				{
+					Severity: DiagnosticWarning,
+					Code:     CodeDeprecated,
+					Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("a"))},
+				},
+			},
+			body: "{\"a\": 1,\n\n\n\n\n\n\n\n\n\"b\": 2}",
+			expected: []string{
+				"error[conflict]: conflict",
+				"  --> body.a (line 1, column 7)",
+				"   |",
+				` 1 | {"a": 1,`,
+				"   |       ^",
+				"  --> body.b (line 10, column 6)",
+				"   |",
+				`10 | "b": 2}`,
+				"   |      ^",
+				"",
+				"warning[deprecated]: deprecated",
+				" --> body.a (line 1, column 7)",
+				"  |",
+				`1 | {"a": 1,`,
+				"  |       ^",
+				"",
+			},
+		},
+		"color": {
+			diags: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     CodeInvalidValue,
+					Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("a"))},
+					Summary:  "Bad.",
+					Detail:   "Very bad.",
+				},
+				{
+					Severity: DiagnosticWarning,
+					Code:     CodeDeprecated,
+				},
+			},
+			body: `{"a": 1}`,
+			opts: []RenderOption{WithColor()},
+			expected: []string{
+				"\x1b[1;31merror[invalid_value]\x1b[0m\x1b[1m: Bad.\x1b[0m",
+				" \x1b[1;34m-->\x1b[0m body.a (line 1, column 7)",
+				"  \x1b[1;34m|\x1b[0m",
+				"\x1b[1;34m1\x1b[0m \x1b[1;34m|\x1b[0m {\"a\": 1}",
+				"  \x1b[1;34m|\x1b[0m \x1b[1;31m      ^\x1b[0m",
+				"  \x1b[1;34m=\x1b[0m Very bad.",
+				"",
+				"\x1b[1;33mwarning[deprecated]\x1b[0m\x1b[1m: deprecated\x1b[0m",
+				"",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var out strings.Builder
+			err := Render(&out, tc.diags, []byte(tc.body), tc.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(strings.Join(tc.expected, "\n")+"\n", out.String()); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryAfterMember is the extension member of a Diagnostic holding how
+// long, in whole seconds, the caller should wait before retrying the
+// request, usually for Diagnostics with a Code of CodeActOfGod or a
+// rate-limiting Code.
+const RetryAfterMember = "retry_after"
+
+// WithRetryAfter returns a copy of d with after recorded in its Extensions
+// as RetryAfterMember, rounded up to the nearest second.
+func (d Diagnostic) WithRetryAfter(after time.Duration) Diagnostic {
+	return d.withExtension(RetryAfterMember, json.RawMessage(strconv.FormatInt(retryAfterSeconds(after), 10)))
+}
+
+// RetryAfter returns the duration recorded in d's Extensions by
+// WithRetryAfter, or 0 if there isn't one. An error is returned if
+// RetryAfterMember isn't a non-negative integer.
+func (d Diagnostic) RetryAfter() (time.Duration, error) {
+	value, ok := d.Extensions[RetryAfterMember]
+	if !ok {
+		return 0, nil
+	}
+	var seconds int64
+	err := json.Unmarshal(value, &seconds)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing %s: %w", RetryAfterMember, err)
+	}
+	if seconds < 0 {
+		return 0, fmt.Errorf("error parsing %s: negative duration %d", RetryAfterMember, seconds)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// SetRetryAfterHeader sets the Retry-After header in h to the longest
+// duration recorded by WithRetryAfter on the Diagnostics in diags with a
+// Severity of DiagnosticError, so clients wait long enough for every error
+// to clear. If none of them have one, h is left unchanged. WriteHTTP calls
+// SetRetryAfterHeader automatically.
+func SetRetryAfterHeader(h http.Header, diags Diagnostics) error {
+	longest, found, err := longestRetryAfter(diags)
+	if err != nil {
+		return err
+	}
+	if found {
+		h.Set("Retry-After", strconv.FormatInt(retryAfterSeconds(longest), 10))
+	}
+	return nil
+}
+
+// longestRetryAfter returns the longest duration recorded by WithRetryAfter
+// on the Diagnostics in diags with a Severity of DiagnosticError, and
+// whether any of them had one.
+func longestRetryAfter(diags Diagnostics) (time.Duration, bool, error) {
+	var longest time.Duration
+	var found bool
+	for _, diag := range diags {
+		if diag.Severity != DiagnosticError {
+			continue
+		}
+		if _, ok := diag.Extensions[RetryAfterMember]; !ok {
+			continue
+		}
+		after, err := diag.RetryAfter()
+		if err != nil {
+			return 0, false, err
+		}
+		found = true
+		if after > longest {
+			longest = after
+		}
+	}
+	return longest, found, nil
+}
+
+// RetryAfterFromHeader parses the Retry-After header in h, which can be
+// either a number of seconds or an HTTP date. HTTP dates are measured from
+// the Date header in h, if it has one, or now otherwise; dates in the past
+// return 0. If h has no Retry-After header, false is returned.
+func RetryAfterFromHeader(h http.Header, now time.Time) (time.Duration, bool, error) {
+	value := strings.TrimSpace(h.Get("Retry-After"))
+	if value == "" {
+		return 0, false, nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false, fmt.Errorf("invalid Retry-After header %q", value)
+		}
+		return time.Duration(seconds) * time.Second, true, nil
+	}
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid Retry-After header %q", value)
+	}
+	if date, err := http.ParseTime(h.Get("Date")); err == nil {
+		now = date
+	}
+	after := at.Sub(now)
+	if after < 0 {
+		after = 0
+	}
+	return after, true, nil
+}
+
+// retryAfterSeconds returns after in whole seconds, rounded up.
+func retryAfterSeconds(after time.Duration) int64 {
+	if after <= 0 {
+		return 0
+	}
+	return int64((after + time.Second - 1) / time.Second)
+}
+
+// The values RetryPolicy uses for fields left unset.
+const (
+	// DefaultRetryAttempts is the number of attempts a RetryPolicy makes
+	// when MaxAttempts isn't set, including the first.
+	DefaultRetryAttempts = 3
+
+	// DefaultRetryBaseDelay is the delay before a RetryPolicy's first retry
+	// when BaseDelay isn't set.
+	DefaultRetryBaseDelay = 100 * time.Millisecond
+
+	// DefaultRetryMaxDelay is the longest a RetryPolicy waits between
+	// attempts when MaxDelay isn't set.
+	DefaultRetryMaxDelay = 30 * time.Second
+)
+
+// RetryPolicy decides whether a request that failed with Diagnostics should
+// be retried, and how long to wait before retrying it, so clients of APIs
+// using apidiags retry the same errors in the same way. The zero value is
+// ready to use, with the defaults described on each field.
+type RetryPolicy struct {
+	// MaxAttempts is the most times a request is attempted, including the
+	// first. It defaults to DefaultRetryAttempts.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry, which doubles with
+	// every retry after it. It defaults to DefaultRetryBaseDelay.
+	BaseDelay time.Duration
+
+	// MaxDelay is the longest delay between attempts. Requests the server
+	// asks to be retried after longer than MaxDelay aren't retried at all.
+	// It defaults to DefaultRetryMaxDelay.
+	MaxDelay time.Duration
+
+	// Retryable reports whether an error can succeed if it's retried. It
+	// defaults to IsRetryable.
+	Retryable func(Diagnostic) bool
+}
+
+// IsRetryable returns true if diag is an error that may succeed if the
+// request is retried: one with a Code of CodeActOfGod, or one recording how
+// long to wait using WithRetryAfter, like rate-limiting errors.
+func IsRetryable(diag Diagnostic) bool {
+	if diag.Severity != DiagnosticError {
+		return false
+	}
+	if diag.Code == CodeActOfGod {
+		return true
+	}
+	_, ok := diag.Extensions[RetryAfterMember]
+	return ok
+}
+
+// ShouldRetry reports whether a request that has been attempted attempts
+// times, and whose last attempt returned diags, should be retried, and how
+// long to wait before retrying it.
+//
+// Requests are only retried if diags has errors, and every error is
+// retryable; if any of them aren't, retrying can't make the request
+// succeed. Requests aren't retried once they've been attempted MaxAttempts
+// times.
+//
+// The delay is BaseDelay doubled for every attempt after the first, up to
+// MaxDelay, or the longest duration recorded on the errors with
+// WithRetryAfter, whichever is longer. If the recorded duration is longer
+// than MaxDelay, or can't be parsed, the request isn't retried.
+func (p RetryPolicy) ShouldRetry(diags Diagnostics, attempts int) (time.Duration, bool) {
+	maxAttempts, base, maxDelay := p.MaxAttempts, p.BaseDelay, p.MaxDelay
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultRetryAttempts
+	}
+	if base <= 0 {
+		base = DefaultRetryBaseDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = DefaultRetryMaxDelay
+	}
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+	if attempts >= maxAttempts || !diags.HasErrors() {
+		return 0, false
+	}
+	for _, diag := range diags {
+		if diag.Severity == DiagnosticError && !retryable(diag) {
+			return 0, false
+		}
+	}
+	hint, _, err := longestRetryAfter(diags)
+	if err != nil || hint > maxDelay {
+		return 0, false
+	}
+	delay := base
+	for retry := 1; retry < attempts && delay < maxDelay; retry++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	if hint > delay {
+		delay = hint
+	}
+	return delay, true
+}
@@ -0,0 +1,266 @@
+package apidiags
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDiagnosticRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	diag := Diagnostic{Severity: DiagnosticError, Code: CodeActOfGod}.WithRetryAfter(1500 * time.Millisecond)
+	encoded, err := diag.MarshalJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := `{"severity":"error","code":"act_of_god","retry_after":2}`
+	if string(encoded) != expected {
+		t.Errorf("expected %s, got %s", expected, encoded)
+	}
+	after, err := diag.RetryAfter()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if after != 2*time.Second {
+		t.Errorf("expected %s, got %s", 2*time.Second, after)
+	}
+}
+
+func TestSetRetryAfterHeader(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags    Diagnostics
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"none": {
+			diags: Diagnostics{{Severity: DiagnosticError, Code: CodeActOfGod}},
+		},
+		"longest-error": {
+			diags: Diagnostics{
+				Diagnostic{Severity: DiagnosticError, Code: CodeActOfGod}.WithRetryAfter(10 * time.Second),
+				Diagnostic{Severity: DiagnosticError, Code: "rate_limited"}.WithRetryAfter(time.Minute),
+				Diagnostic{Severity: DiagnosticWarning, Code: "rate_limited"}.WithRetryAfter(time.Hour),
+			},
+			expected: "60",
+		},
+		"zero": {
+			diags: Diagnostics{
+				Diagnostic{Severity: DiagnosticError, Code: CodeActOfGod}.WithRetryAfter(0),
+			},
+			expected: "0",
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			h := http.Header{}
+			err := SetRetryAfterHeader(h, tc.diags)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if result := h.Get("Retry-After"); result != tc.expected {
+				t.Fatalf("expected %q, got %q", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestRetryAfterFromHeader(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	type testCase struct {
+		header      http.Header
+		expected    time.Duration
+		expectedOK  bool
+		expectedErr bool
+	}
+
+	cases := map[string]testCase{
+		"missing": {
+			header: http.Header{},
+		},
+		"seconds": {
+			header:     http.Header{"Retry-After": []string{"120"}},
+			expected:   2 * time.Minute,
+			expectedOK: true,
+		},
+		"date": {
+			header:     http.Header{"Retry-After": []string{"Sun, 01 Mar 2026 12:00:30 GMT"}},
+			expected:   30 * time.Second,
+			expectedOK: true,
+		},
+		"date-relative-to-date-header": {
+			header: http.Header{
+				"Retry-After": []string{"Sun, 01 Mar 2026 12:00:30 GMT"},
+				"Date":        []string{"Sun, 01 Mar 2026 12:00:20 GMT"},
+			},
+			expected:   10 * time.Second,
+			expectedOK: true,
+		},
+		"date-in-past": {
+			header:     http.Header{"Retry-After": []string{"Sun, 01 Mar 2026 11:00:00 GMT"}},
+			expectedOK: true,
+		},
+		"invalid": {
+			header:      http.Header{"Retry-After": []string{"soon"}},
+			expectedErr: true,
+		},
+		"negative": {
+			header:      http.Header{"Retry-After": []string{"-5"}},
+			expectedErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result, ok, err := RetryAfterFromHeader(tc.header, now)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if ok != tc.expectedOK {
+				t.Errorf("expected ok to be %v, got %v", tc.expectedOK, ok)
+			}
+			if result != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestWriteHTTPRetryAfterRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+	err := WriteHTTP(w, Diagnostics{
+		Diagnostic{Severity: DiagnosticError, Code: CodeActOfGod}.WithRetryAfter(30 * time.Second),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if header := w.Header().Get("Retry-After"); header != "30" {
+		t.Errorf("expected Retry-After of %q, got %q", "30", header)
+	}
+
+	resp := w.Result()
+	resp.Header.Set("Retry-After", "45")
+	diags, err := ParseResponse(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	after, err := diags[0].RetryAfter()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if after != 30*time.Second {
+		t.Errorf("expected the body's retry duration of %s to be kept, got %s", 30*time.Second, after)
+	}
+}
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		policy   RetryPolicy
+		diags    Diagnostics
+		attempts int
+		delay    time.Duration
+		retry    bool
+	}
+
+	unavailable := Diagnostic{Severity: DiagnosticError, Code: CodeActOfGod}
+	rateLimited := Diagnostic{Severity: DiagnosticError, Code: "rate_limited"}.WithRetryAfter(5 * time.Second)
+
+	cases := map[string]testCase{
+		"no-diagnostics": {
+			attempts: 1,
+		},
+		"warnings": {
+			diags:    Diagnostics{{Severity: DiagnosticWarning, Code: CodeDeprecated}},
+			attempts: 1,
+		},
+		"not-retryable": {
+			diags:    Diagnostics{unavailable, {Severity: DiagnosticError, Code: CodeMissing}},
+			attempts: 1,
+		},
+		"first-retry": {
+			diags:    Diagnostics{unavailable},
+			attempts: 1,
+			delay:    DefaultRetryBaseDelay,
+			retry:    true,
+		},
+		"backoff": {
+			diags:    Diagnostics{unavailable},
+			attempts: 2,
+			delay:    2 * DefaultRetryBaseDelay,
+			retry:    true,
+		},
+		"out-of-attempts": {
+			diags:    Diagnostics{unavailable},
+			attempts: DefaultRetryAttempts,
+		},
+		"max-delay": {
+			policy:   RetryPolicy{MaxAttempts: 10, BaseDelay: time.Second, MaxDelay: 5 * time.Second},
+			diags:    Diagnostics{unavailable},
+			attempts: 9,
+			delay:    5 * time.Second,
+			retry:    true,
+		},
+		"retry-after": {
+			diags:    Diagnostics{rateLimited},
+			attempts: 1,
+			delay:    5 * time.Second,
+			retry:    true,
+		},
+		"retry-after-too-long": {
+			policy:   RetryPolicy{MaxDelay: time.Second},
+			diags:    Diagnostics{rateLimited},
+			attempts: 1,
+		},
+		"custom-retryable": {
+			policy: RetryPolicy{Retryable: func(diag Diagnostic) bool {
+				return diag.Code == CodeConflict
+			}},
+			diags:    Diagnostics{{Severity: DiagnosticError, Code: CodeConflict}},
+			attempts: 1,
+			delay:    DefaultRetryBaseDelay,
+			retry:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			delay, retry := tc.policy.ShouldRetry(tc.diags, tc.attempts)
+			if retry != tc.retry {
+				t.Errorf("expected retry to be %v, got %v", tc.retry, retry)
+			}
+			if delay != tc.delay {
+				t.Errorf("expected delay of %s, got %s", tc.delay, delay)
+			}
+		})
+	}
+}
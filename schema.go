@@ -0,0 +1,124 @@
+package apidiags
+
+import "encoding/json"
+
+// JSONSchemaID is the $id of the JSON Schema produced by JSONSchema.
+const JSONSchemaID = "https://impractical.co/apidiags/diagnostics.schema.json"
+
+// builtinCodes are the Codes defined by this package.
+var builtinCodes = []Code{
+	CodeAccessDenied,
+	CodeInsufficient,
+	CodeOverflow,
+	CodeInvalidValue,
+	CodeInvalidFormat,
+	CodeMissing,
+	CodeNotFound,
+	CodeConflict,
+	CodeActOfGod,
+	CodeDeprecated,
+}
+
+// wireStepKind describes a step kind in the wire format: its name, and the
+// JSON type of its value, or an empty string if it has no value.
+type wireStepKind struct {
+	kind      string
+	valueType string
+}
+
+// wireStepKinds are the step kinds of the wire format, in the same order as
+// the Step types are declared.
+var wireStepKinds = []wireStepKind{
+	{kind: "body"},
+	{kind: "header", valueType: "string"},
+	{kind: "url_param", valueType: "string"},
+	{kind: "array_index", valueType: "integer"},
+	{kind: "object_property", valueType: "string"},
+	{kind: "string_index", valueType: "integer"},
+	{kind: "rune_index", valueType: "integer"},
+}
+
+// JSONSchema returns a JSON Schema, using the 2020-12 draft, describing the
+// JSON encoding of Diagnostics. The schema's definitions for Diagnostic,
+// Steps, and Step can be referenced from other schemas as
+// JSONSchemaID + "#/$defs/Diagnostic" and so on.
+//
+// Every step kind is described exactly, so payloads with unknown step kinds
+// fail validation. Codes are open-ended, so the schema accepts any string as
+// a Code, but lists the Codes defined by this package, followed by codes, as
+// examples.
+func JSONSchema(codes ...Code) ([]byte, error) {
+	return json.Marshal(jsonSchema(codes))
+}
+
+func jsonSchema(codes []Code) map[string]any {
+	return map[string]any{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"$id":         JSONSchemaID,
+		"title":       "Diagnostics",
+		"description": "Information about an API request, like errors and warnings, returned with the response.",
+		"type":        "array",
+		"items":       map[string]any{"$ref": "#/$defs/Diagnostic"},
+		"$defs":       diagnosticSchemas("#/$defs/", codes),
+	}
+}
+
+// diagnosticSchemas returns the schemas describing the JSON encoding of a
+// Diagnostic, keyed by name. References between them are prefixed with
+// refPrefix, which should point to wherever the schemas will live.
+func diagnosticSchemas(refPrefix string, codes []Code) map[string]any {
+	examples := make([]Code, 0, len(builtinCodes)+len(codes))
+	examples = append(examples, builtinCodes...)
+	examples = append(examples, codes...)
+
+	stepKinds := make([]any, 0, len(wireStepKinds))
+	for _, kind := range wireStepKinds {
+		properties := map[string]any{
+			"kind": map[string]any{"const": kind.kind},
+		}
+		required := []string{"kind"}
+		if kind.valueType != "" {
+			properties["value"] = map[string]any{"type": kind.valueType}
+			required = append(required, "value")
+		}
+		stepKinds = append(stepKinds, map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		})
+	}
+
+	return map[string]any{
+		"Diagnostic": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"severity": map[string]any{"$ref": refPrefix + "Severity"},
+				"code":     map[string]any{"$ref": refPrefix + "Code"},
+				"path": map[string]any{
+					"type":  "array",
+					"items": map[string]any{"$ref": refPrefix + "Steps"},
+				},
+				"summary": map[string]any{"type": "string"},
+				"detail":  map[string]any{"type": "string"},
+				"doc_url": map[string]any{"type": "string", "format": "uri"},
+			},
+			"required": []string{"severity", "code"},
+		},
+		"Severity": map[string]any{
+			"type": "string",
+			"enum": []Severity{DiagnosticError, DiagnosticWarning},
+		},
+		"Code": map[string]any{
+			"type":     "string",
+			"examples": examples,
+		},
+		"Steps": map[string]any{
+			"type":  "array",
+			"items": map[string]any{"$ref": refPrefix + "Step"},
+		},
+		"Step": map[string]any{
+			"oneOf": stepKinds,
+		},
+	}
+}
@@ -0,0 +1,93 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJSONSchemaStepKinds(t *testing.T) {
+	t.Parallel()
+
+	encoded, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var schema struct {
+		Defs struct {
+			Step struct {
+				OneOf []struct {
+					Properties struct {
+						Kind struct {
+							Const string `json:"const"`
+						} `json:"kind"`
+						Value *struct {
+							Type string `json:"type"`
+						} `json:"value"`
+					} `json:"properties"`
+				} `json:"oneOf"`
+			} `json:"Step"`
+		} `json:"$defs"`
+	}
+	err = json.Unmarshal(encoded, &schema)
+	if err != nil {
+		t.Fatalf("unexpected error decoding schema: %s", err)
+	}
+	inSchema := map[string]string{}
+	for _, option := range schema.Defs.Step.OneOf {
+		var valueType string
+		if option.Properties.Value != nil {
+			valueType = option.Properties.Value.Type
+		}
+		inSchema[option.Properties.Kind.Const] = valueType
+	}
+
+	// every Step type should be described, with the right value type
+	steps := Steps{
+		BodyStep{}, HeaderStep("a"), URLParamStep("b"), ArrayIndexStep(1),
+		ObjectPropertyStep("c"), StringIndexStep(2), RuneIndexStep(3),
+	}
+	fromSteps := map[string]string{}
+	for _, step := range steps {
+		genStep, err := toGenericStep(step)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		var valueType string
+		switch genStep.Value.kind {
+		case valueString:
+			valueType = "string"
+		case valueInt:
+			valueType = "integer"
+		}
+		fromSteps[genStep.Kind] = valueType
+	}
+	if diff := cmp.Diff(fromSteps, inSchema); diff != "" {
+		t.Errorf("unexpected step kinds (-wanted, +got): %s", diff)
+	}
+}
+
+func TestJSONSchemaCodes(t *testing.T) {
+	t.Parallel()
+
+	encoded, err := JSONSchema("quota_exceeded")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var schema struct {
+		Defs struct {
+			Code struct {
+				Examples []Code `json:"examples"`
+			} `json:"Code"`
+		} `json:"$defs"`
+	}
+	err = json.Unmarshal(encoded, &schema)
+	if err != nil {
+		t.Fatalf("unexpected error decoding schema: %s", err)
+	}
+	expected := append(append([]Code{}, builtinCodes...), "quota_exceeded")
+	if diff := cmp.Diff(expected, schema.Defs.Code.Examples); diff != "" {
+		t.Errorf("unexpected codes (-wanted, +got): %s", diff)
+	}
+}
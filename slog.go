@@ -0,0 +1,63 @@
+//go:build go1.21
+
+package apidiags
+
+import (
+	"log/slog"
+	"strconv"
+)
+
+// LogValue implements slog.LogValuer, logging the Diagnostic as a group
+// with its severity, code, summary, detail, and doc_url, leaving out those
+// that are empty. Its Path is logged as path, rendered using Steps.String,
+// like `body.items[3].name`; if it has more than one Path, they're logged
+// as a list in paths instead. Extensions aren't logged. If it has a
+// Template, its Summary and Detail are rendered from it first.
+func (d Diagnostic) LogValue() slog.Value {
+	d = d.RenderMessage(nil)
+	attrs := make([]slog.Attr, 0, 6)
+	attrs = append(attrs,
+		slog.String("severity", string(d.Severity)),
+		slog.String("code", string(d.Code)),
+	)
+	switch len(d.Paths) {
+	case 0:
+	case 1:
+		attrs = append(attrs, slog.String("path", d.Paths[0].String()))
+	default:
+		paths := make([]string, 0, len(d.Paths))
+		for _, path := range d.Paths {
+			paths = append(paths, path.String())
+		}
+		attrs = append(attrs, slog.Any("paths", paths))
+	}
+	if d.Summary != "" {
+		attrs = append(attrs, slog.String("summary", d.Summary))
+	}
+	if d.Detail != "" {
+		attrs = append(attrs, slog.String("detail", d.Detail))
+	}
+	if d.DocURL != "" {
+		attrs = append(attrs, slog.String("doc_url", d.DocURL))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// LogValue implements slog.LogValuer, logging the Diagnostics as a group
+// with the number of Diagnostics in count, the number with a Severity of
+// DiagnosticError in errors, and each Diagnostic as a group keyed by its
+// position, like "0".
+func (diags Diagnostics) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(diags)+2)
+	var errs int
+	for _, diag := range diags {
+		if diag.Severity == DiagnosticError {
+			errs++
+		}
+	}
+	attrs = append(attrs, slog.Int("count", len(diags)), slog.Int("errors", errs))
+	for pos, diag := range diags {
+		attrs = append(attrs, slog.Any(strconv.Itoa(pos), diag))
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,67 @@
+//go:build go1.21
+
+package apidiags
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestLogValue(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		value    any
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"diagnostic": {
+			value: Diagnostic{
+				Severity: DiagnosticError,
+				Code:     CodeMissing,
+				Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("name"))},
+				Summary:  "Name is required.",
+			},
+			expected: `level=INFO msg=test diag.severity=error diag.code=missing diag.path=body.name diag.summary="Name is required."` + "\n",
+		},
+		"multiple-paths": {
+			value: Diagnostic{
+				Severity: DiagnosticWarning,
+				Code:     CodeDeprecated,
+				Paths:    []Steps{URLParamPath("a"), HeaderPath("B")},
+				DocURL:   "https://example.com",
+			},
+			expected: `level=INFO msg=test diag.severity=warning diag.code=deprecated diag.paths="[url_param(\"a\") header(\"B\")]" diag.doc_url=https://example.com` + "\n",
+		},
+		"diagnostics": {
+			value: Diagnostics{
+				{Severity: DiagnosticError, Code: CodeNotFound},
+				{Severity: DiagnosticWarning, Code: CodeDeprecated, Detail: "Use v2."},
+			},
+			expected: `level=INFO msg=test diag.count=2 diag.errors=1 diag.0.severity=error diag.0.code=not_found diag.1.severity=warning diag.1.code=deprecated diag.1.detail="Use v2."` + "\n",
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+					if len(groups) == 0 && attr.Key == slog.TimeKey {
+						return slog.Attr{}
+					}
+					return attr
+				},
+			}))
+			logger.Info("test", "diag", tc.value)
+			if got := buf.String(); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
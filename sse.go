@@ -0,0 +1,160 @@
+package apidiags
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DiagnosticsEvent is the name of the server-sent events EventWriter uses
+// to carry Diagnostics, interleaved with the events carrying a stream's
+// results.
+const DiagnosticsEvent = "diagnostics"
+
+// Event is a single server-sent event.
+type Event struct {
+	// Name is the type of the event, from its "event" field. Events
+	// without one are named "message".
+	Name string
+
+	// ID is the event's ID, from its "id" field.
+	ID string
+
+	// Data is the event's payload, from its "data" fields.
+	Data []byte
+}
+
+// EventWriter writes a text/event-stream response, for long-running
+// requests that stream their results as server-sent events and emit
+// Diagnostics as they come up, instead of all at once at the end.
+type EventWriter struct {
+	w http.ResponseWriter
+}
+
+// NewEventWriter returns an EventWriter writing to w, setting the headers
+// of a text/event-stream response. The status code is written with the
+// first event.
+func NewEventWriter(w http.ResponseWriter) *EventWriter {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	return &EventWriter{w: w}
+}
+
+// WriteEvent writes v, encoded as JSON, as the data of an event named name,
+// and flushes it to the client if w supports it.
+func (e *EventWriter) WriteEvent(name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if name != "" {
+		buf.WriteString("event: " + name + "\n")
+	}
+	buf.WriteString("data: ")
+	buf.Write(data)
+	buf.WriteString("\n\n")
+	_, err = e.w.Write(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	if flusher, ok := e.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// WriteDiagnostics writes diags as the data of a DiagnosticsEvent. If diags
+// is empty, nothing is written.
+func (e *EventWriter) WriteDiagnostics(diags Diagnostics) error {
+	if len(diags) < 1 {
+		return nil
+	}
+	return e.WriteEvent(DiagnosticsEvent, diags)
+}
+
+// EventReader reads the server-sent events of a text/event-stream
+// response, collecting the Diagnostics of any DiagnosticsEvents along the
+// way.
+type EventReader struct {
+	scanner *bufio.Scanner
+	diags   Diagnostics
+}
+
+// NewEventReader returns an EventReader reading from r. Lines longer than
+// DefaultMaxResponseBytes are an error.
+func NewEventReader(r io.Reader) *EventReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, DefaultMaxResponseBytes)
+	return &EventReader{scanner: scanner}
+}
+
+// Next returns the next event. DiagnosticsEvents aren't returned; they're
+// decoded and added to the Diagnostics returned by Diagnostics instead.
+// Once the stream ends, io.EOF is returned, and any incomplete event at the
+// end of the stream is discarded.
+func (e *EventReader) Next() (Event, error) {
+	for {
+		event, err := e.next()
+		if err != nil {
+			return Event{}, err
+		}
+		if event.Name != DiagnosticsEvent {
+			return event, nil
+		}
+		var diags Diagnostics
+		err = json.Unmarshal(event.Data, &diags)
+		if err != nil {
+			return Event{}, fmt.Errorf("error decoding %s event: %w", DiagnosticsEvent, err)
+		}
+		e.diags = append(e.diags, diags...)
+	}
+}
+
+// Diagnostics returns the Diagnostics of every DiagnosticsEvent read so
+// far.
+func (e *EventReader) Diagnostics() Diagnostics {
+	return e.diags
+}
+
+func (e *EventReader) next() (Event, error) {
+	var event Event
+	var data []string
+	var hasData bool
+	for e.scanner.Scan() {
+		line := e.scanner.Text()
+		if line == "" {
+			if !hasData {
+				event = Event{}
+				continue
+			}
+			if event.Name == "" {
+				event.Name = "message"
+			}
+			event.Data = []byte(strings.Join(data, "\n"))
+			return event, nil
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.Name = value
+		case "id":
+			event.ID = value
+		case "data":
+			data = append(data, value)
+			hasData = true
+		}
+	}
+	if err := e.scanner.Err(); err != nil {
+		return Event{}, err
+	}
+	return Event{}, io.EOF
+}
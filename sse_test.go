@@ -0,0 +1,118 @@
+package apidiags
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEventWriter(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+	events := NewEventWriter(w)
+	err := events.WriteEvent("widget", testWidget{ID: "123"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err = events.WriteDiagnostics(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err = events.WriteDiagnostics(Diagnostics{{Severity: DiagnosticWarning, Code: CodeDeprecated}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("expected Content-Type text/event-stream, got %q", contentType)
+	}
+	if !w.Flushed {
+		t.Errorf("expected events to be flushed")
+	}
+	expected := "event: widget\ndata: {\"id\":\"123\"}\n\n" +
+		"event: diagnostics\ndata: [{\"severity\":\"warning\",\"code\":\"deprecated\"}]\n\n"
+	if w.Body.String() != expected {
+		t.Errorf("expected %q, got %q", expected, w.Body.String())
+	}
+}
+
+func TestEventReader(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		stream        string
+		expected      []Event
+		expectedDiags Diagnostics
+		expectedErr   bool
+	}
+
+	cases := map[string]testCase{
+		"interleaved": {
+			stream: "event: widget\ndata: {\"id\":\"123\"}\n\n" +
+				"event: diagnostics\ndata: [{\"severity\":\"warning\",\"code\":\"deprecated\"}]\n\n" +
+				"event: widget\nid: 2\ndata: {\"id\":\"456\"}\n\n" +
+				"event: diagnostics\ndata: [{\"severity\":\"error\",\"code\":\"act_of_god\"}]\n\n",
+			expected: []Event{
+				{Name: "widget", Data: []byte(`{"id":"123"}`)},
+				{Name: "widget", ID: "2", Data: []byte(`{"id":"456"}`)},
+			},
+			expectedDiags: Diagnostics{
+				{Severity: DiagnosticWarning, Code: CodeDeprecated},
+				{Severity: DiagnosticError, Code: CodeActOfGod},
+			},
+		},
+		"comments-and-multiline-data": {
+			stream: ": keepalive\r\n\r\ndata: first\r\ndata:second\r\n\r\n",
+			expected: []Event{
+				{Name: "message", Data: []byte("first\nsecond")},
+			},
+		},
+		"incomplete-event": {
+			stream: "event: widget\ndata: {\"id\":\"123\"}\n\nevent: widget\ndata: {\"id\":",
+			expected: []Event{
+				{Name: "widget", Data: []byte(`{"id":"123"}`)},
+			},
+		},
+		"invalid-diagnostics": {
+			stream:      "event: diagnostics\ndata: {}\n\n",
+			expectedErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			reader := NewEventReader(strings.NewReader(tc.stream))
+			var events []Event
+			for {
+				event, err := reader.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil && !tc.expectedErr {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if err != nil {
+					return
+				}
+				events = append(events, event)
+			}
+			if tc.expectedErr {
+				t.Fatalf("expected error, got none")
+			}
+			if diff := cmp.Diff(tc.expected, events); diff != "" {
+				t.Errorf("unexpected events diff (-wanted, +got): %s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedDiags, reader.Diagnostics()); diff != "" {
+				t.Errorf("unexpected diagnostics diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,414 @@
+package apidiags
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// StepError describes a step Steps couldn't be decoded from.
+type StepError struct {
+	// Step is the position of the step in the Steps.
+	Step int
+
+	// Offset is the byte offset of the start of the step in the JSON the
+	// Steps were being decoded from.
+	Offset int64
+
+	// Err describes the problem.
+	Err error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("error parsing step %d at offset %d: %s", e.Step, e.Offset, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// maxPooledSteps is the most Steps a StepsDecoder can have room for and
+// still be put back in stepsDecoderPool, so one unusually long path doesn't
+// keep a large scratch slice alive.
+const maxPooledSteps = 64
+
+// stepsDecoderPool holds the StepsDecoders Steps.UnmarshalJSON uses.
+var stepsDecoderPool = sync.Pool{
+	New: func() any {
+		return new(StepsDecoder)
+	},
+}
+
+func getStepsDecoder() *StepsDecoder {
+	return stepsDecoderPool.Get().(*StepsDecoder)
+}
+
+func putStepsDecoder(dec *StepsDecoder) {
+	if cap(dec.scratch) > maxPooledSteps {
+		return
+	}
+	dec.Reset()
+	stepsDecoderPool.Put(dec)
+}
+
+// StepsDecoder decodes JSON-encoded Steps, reusing its scratch space from
+// one call to the next, so services decoding many Diagnostics, like
+// gateways, allocate little more than the Steps they decode.
+// Steps.UnmarshalJSON uses StepsDecoders from a shared pool; callers
+// decoding Steps in a tight loop can keep their own and skip the pool.
+//
+// The zero value is ready to use. A StepsDecoder isn't safe for concurrent
+// use.
+type StepsDecoder struct {
+	scratch []Step
+}
+
+// Reset lets go of the Steps the StepsDecoder decoded last, keeping the
+// room it made for them, so a StepsDecoder that will sit idle doesn't keep
+// them alive.
+func (d *StepsDecoder) Reset() {
+	for i := range d.scratch {
+		d.scratch[i] = nil
+	}
+	d.scratch = d.scratch[:0]
+}
+
+// DecodeSteps turns a JSON-encoded set of bytes into Steps, like
+// Steps.UnmarshalJSON. The Steps are the caller's; they don't share memory
+// with the StepsDecoder or with in.
+//
+// Steps in the form Steps.MarshalJSON writes them are parsed directly. Any
+// other input, including invalid input, is decoded from its JSON tokens,
+// accepting everything encoding/json would: member names are matched
+// case-insensitively, unknown members are ignored, and the last of
+// repeated members is used. Problems with a step are reported as a
+// *StepError.
+func (d *StepsDecoder) DecodeSteps(in []byte) (Steps, error) {
+	d.Reset()
+	if !d.parse(in) {
+		d.Reset()
+		return decodeStepTokens(in)
+	}
+	results := make(Steps, len(d.scratch))
+	copy(results, d.scratch)
+	return results, nil
+}
+
+// parse parses in into d.scratch, returning false if in isn't an array of
+// steps that each have exactly the members their kind needs, spelled the
+// way Steps.MarshalJSON spells them, with strings that don't need
+// unescaping and integer values that fit in an int64.
+func (d *StepsDecoder) parse(in []byte) bool {
+	p := stepsParser{in: in}
+	p.skipSpace()
+	if !p.consume('[') {
+		return false
+	}
+	p.skipSpace()
+	if p.consume(']') {
+		return p.done()
+	}
+	for {
+		step, ok := p.step()
+		if !ok {
+			return false
+		}
+		d.scratch = append(d.scratch, step)
+		p.skipSpace()
+		if p.consume(']') {
+			return p.done()
+		}
+		if !p.consume(',') {
+			return false
+		}
+		p.skipSpace()
+	}
+}
+
+// stepsParser is the state of a StepsDecoder parsing its input.
+type stepsParser struct {
+	in  []byte
+	pos int
+}
+
+func (p *stepsParser) skipSpace() {
+	for p.pos < len(p.in) {
+		switch p.in[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *stepsParser) consume(b byte) bool {
+	if p.pos < len(p.in) && p.in[p.pos] == b {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// done returns true if there's nothing but whitespace left in the input.
+func (p *stepsParser) done() bool {
+	p.skipSpace()
+	return p.pos == len(p.in)
+}
+
+// step parses an object describing a Step.
+func (p *stepsParser) step() (Step, bool) {
+	if !p.consume('{') {
+		return nil, false
+	}
+	var kind, str []byte
+	var num int64
+	var hasKind, hasValue, isString bool
+	p.skipSpace()
+	for {
+		key, ok := p.str()
+		if !ok {
+			return nil, false
+		}
+		p.skipSpace()
+		if !p.consume(':') {
+			return nil, false
+		}
+		p.skipSpace()
+		switch string(key) {
+		case "kind":
+			if hasKind {
+				return nil, false
+			}
+			hasKind = true
+			kind, ok = p.str()
+		case "value":
+			if hasValue {
+				return nil, false
+			}
+			hasValue = true
+			if p.pos < len(p.in) && p.in[p.pos] == '"' {
+				str, ok = p.str()
+				isString = true
+			} else {
+				num, ok = p.int()
+			}
+		default:
+			return nil, false
+		}
+		if !ok {
+			return nil, false
+		}
+		p.skipSpace()
+		if p.consume('}') {
+			break
+		}
+		if !p.consume(',') {
+			return nil, false
+		}
+		p.skipSpace()
+	}
+	switch string(kind) {
+	case "body":
+		return BodyStep{}, !hasValue
+	case "header":
+		return HeaderStep(str), hasValue && isString
+	case "url_param":
+		return URLParamStep(str), hasValue && isString
+	case "object_property":
+		return ObjectPropertyStep(str), hasValue && isString
+	case "array_index":
+		return ArrayIndexStep(num), hasValue && !isString
+	case "string_index":
+		return StringIndexStep(num), hasValue && !isString
+	case "rune_index":
+		return RuneIndexStep(num), hasValue && !isString
+	}
+	return nil, false
+}
+
+// str parses a string without escapes, returning its contents.
+func (p *stepsParser) str() ([]byte, bool) {
+	if !p.consume('"') {
+		return nil, false
+	}
+	start := p.pos
+	ascii := true
+	for p.pos < len(p.in) {
+		b := p.in[p.pos]
+		switch {
+		case b == '"':
+			s := p.in[start:p.pos]
+			p.pos++
+			return s, ascii || utf8.Valid(s)
+		case b == '\\' || b < 0x20:
+			return nil, false
+		case b >= utf8.RuneSelf:
+			ascii = false
+		}
+		p.pos++
+	}
+	return nil, false
+}
+
+// int parses a number that's an integer written without a fraction or
+// exponent, and fits in an int64.
+func (p *stepsParser) int() (int64, bool) {
+	neg := p.consume('-')
+	limit := uint64(1<<63 - 1)
+	if neg {
+		limit++
+	}
+	start := p.pos
+	var n uint64
+	for p.pos < len(p.in) && p.in[p.pos] >= '0' && p.in[p.pos] <= '9' {
+		digit := uint64(p.in[p.pos] - '0')
+		if n > (limit-digit)/10 {
+			return 0, false
+		}
+		n = n*10 + digit
+		p.pos++
+	}
+	switch {
+	case p.pos == start:
+		return 0, false
+	case p.in[start] == '0' && p.pos-start > 1:
+		return 0, false
+	case p.pos < len(p.in) && (p.in[p.pos] == '.' || p.in[p.pos] == 'e' || p.in[p.pos] == 'E'):
+		return 0, false
+	}
+	if neg {
+		return int64(-n), true
+	}
+	return int64(n), true
+}
+
+// decodeStepTokens decodes Steps in a single pass over the JSON tokens of
+// in, turning each step into a Step as soon as it's read. Anything after
+// the array of steps is ignored.
+func decodeStepTokens(in []byte) (Steps, error) {
+	dec := json.NewDecoder(bytes.NewReader(in))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok == nil {
+		return Steps{}, nil
+	}
+	if tok != json.Delim('[') {
+		value, err := tokenStepValue(dec, tok)
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("wanted array, got %s", value.typeName())
+	}
+	results := Steps{}
+	for dec.More() {
+		offset := valueOffset(in, dec.InputOffset())
+		step, problem, err := decodeStepToken(dec)
+		if err != nil {
+			return nil, err
+		}
+		if problem != nil {
+			return nil, &StepError{Step: len(results), Offset: offset, Err: problem}
+		}
+		results = append(results, step)
+	}
+	_, err = dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// decodeStepToken reads the next step from dec, returning the Step, or a
+// problem with the step if it doesn't describe one, or an error if dec
+// couldn't read it.
+func decodeStepToken(dec *json.Decoder) (step Step, problem, err error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	var genStep genericStep
+	if tok != nil {
+		if tok != json.Delim('{') {
+			value, err := tokenStepValue(dec, tok)
+			if err != nil {
+				return nil, nil, err
+			}
+			return nil, fmt.Errorf("wanted object, got %s", value.typeName()), nil
+		}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, nil, err
+			}
+			key, _ := tok.(string)
+			tok, err = dec.Token()
+			if err != nil {
+				return nil, nil, err
+			}
+			value, err := tokenStepValue(dec, tok)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch {
+			case strings.EqualFold(key, "kind"):
+				switch value.kind {
+				case valueString:
+					genStep.Kind = value.str
+				case valueNone:
+					// like encoding/json, null leaves the kind as it was
+				default:
+					return nil, fmt.Errorf("wanted string kind, got %s", value.typeName()), nil
+				}
+			case strings.EqualFold(key, "value"):
+				genStep.Value = value
+			}
+		}
+		_, err = dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	step, problem = genStep.toStep()
+	return step, problem, nil
+}
+
+// tokenStepValue returns the stepValue of the JSON value tok starts,
+// reading the rest of the value from dec if it's an object or array.
+// Objects and arrays are only kept as the types encoding/json would decode
+// them into, for errors.
+func tokenStepValue(dec *json.Decoder, tok json.Token) (stepValue, error) {
+	switch tok := tok.(type) {
+	case string:
+		return stringStepValue(tok), nil
+	case json.Number:
+		return numberStepValue(string(tok)), nil
+	case json.Delim:
+		var value stepValue
+		if tok == '{' {
+			value = otherStepValue(map[string]any(nil))
+		} else {
+			value = otherStepValue([]any(nil))
+		}
+		for depth := 1; depth > 0; {
+			tok, err := dec.Token()
+			if err != nil {
+				return stepValue{}, err
+			}
+			switch tok {
+			case json.Delim('{'), json.Delim('['):
+				depth++
+			case json.Delim('}'), json.Delim(']'):
+				depth--
+			}
+		}
+		return value, nil
+	}
+	return otherStepValue(tok), nil
+}
@@ -0,0 +1,310 @@
+package apidiags
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// decodeGenericSteps decodes Steps the way Steps.UnmarshalJSON used to,
+// through encoding/json and genericSteps, to compare against.
+func decodeGenericSteps(in []byte) (Steps, error) {
+	var encoded []struct {
+		Kind  string `json:"kind"`
+		Value *any   `json:"value"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(in))
+	dec.UseNumber()
+	err := dec.Decode(&encoded)
+	if err != nil {
+		return nil, err
+	}
+	results := Steps{}
+	for pos, encodedStep := range encoded {
+		genStep := genericStep{Kind: encodedStep.Kind}
+		if encodedStep.Value != nil {
+			switch value := (*encodedStep.Value).(type) {
+			case string:
+				genStep.Value = stringStepValue(value)
+			case json.Number:
+				genStep.Value = numberStepValue(string(value))
+			default:
+				genStep.Value = otherStepValue(value)
+			}
+		}
+		step, err := genStep.toStep()
+		if err != nil {
+			return nil, fmt.Errorf("error parsing step %d: %w", pos, err)
+		}
+		results = append(results, step)
+	}
+	return results, nil
+}
+
+func TestStepsDecoderMatchesEncodingJSON(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"empty":             `[]`,
+		"null":              `null`,
+		"spaces":            " \t\n[ {\r\n\"kind\" : \"body\" } , {\"value\":\"name\",\"kind\":\"object_property\"} ] \n",
+		"every-kind":        `[{"kind":"body"},{"kind":"object_property","value":"items"},{"kind":"array_index","value":12},{"kind":"string_index","value":3},{"kind":"rune_index","value":-1}]`,
+		"header":            `[{"kind":"header","value":"X-Request-ID"}]`,
+		"url-param":         `[{"kind":"url_param","value":"page"}]`,
+		"escapes":           `[{"kind":"body"},{"kind":"object_property","value":"\"quoted\" \\ é \n"}]`,
+		"unicode":           `[{"kind":"body"},{"kind":"object_property","value":"名前 🧑‍🚀"}]`,
+		"invalid-utf8":      "[{\"kind\":\"body\"},{\"kind\":\"object_property\",\"value\":\"a\xffb\"}]",
+		"control-character": "[{\"kind\":\"object_property\",\"value\":\"a\tb\"}]",
+		"min-int":           `[{"kind":"array_index","value":-9223372036854775808}]`,
+		"max-int":           `[{"kind":"array_index","value":9223372036854775807}]`,
+		"overflow":          `[{"kind":"array_index","value":9223372036854775808}]`,
+		"underflow":         `[{"kind":"array_index","value":-9223372036854775809}]`,
+		"zero":              `[{"kind":"array_index","value":0}]`,
+		"negative-zero":     `[{"kind":"array_index","value":-0}]`,
+		"leading-zero":      `[{"kind":"array_index","value":01}]`,
+		"fraction":          `[{"kind":"array_index","value":1.0}]`,
+		"exponent":          `[{"kind":"array_index","value":1e3}]`,
+		"just-minus":        `[{"kind":"array_index","value":-}]`,
+		"string-index":      `[{"kind":"array_index","value":"1"}]`,
+		"number-property":   `[{"kind":"object_property","value":1}]`,
+		"bool-value":        `[{"kind":"header","value":true}]`,
+		"null-value":        `[{"kind":"header","value":null}]`,
+		"no-value":          `[{"kind":"url_param"}]`,
+		"body-value":        `[{"kind":"body","value":"ignored"}]`,
+		"no-kind":           `[{"value":"name"}]`,
+		"empty-object":      `[{}]`,
+		"unknown-kind":      `[{"kind":"cookie","value":"session"}]`,
+		"unknown-member":    `[{"kind":"body","extra":[1,2]}]`,
+		"capitalized":       `[{"Kind":"header","VALUE":"Accept"}]`,
+		"duplicate-kind":    `[{"kind":"body","kind":"header","value":"Accept"}]`,
+		"duplicate-value":   `[{"kind":"header","value":"Accept","value":"Accept-Language"}]`,
+		"trailing-data":     `[{"kind":"body"}] [`,
+		"trailing-comma":    `[{"kind":"body"},]`,
+		"unterminated":      `[{"kind":"body"`,
+		"not-an-array":      `{"kind":"body"}`,
+		"not-json":          `nope`,
+		"empty-input":       ``,
+		"null-step":         `[null]`,
+		"null-kind":         `[{"kind":"body","kind":null}]`,
+		"number-kind":       `[{"kind":1}]`,
+		"number-step":       `[{"kind":"body"},2]`,
+		"nested-unknown":    `[{"kind":"header","extra":{"a":[{"b":null}]},"value":"Accept"}]`,
+		"mixed-case":        `[{"KIND":"object_property","Value":"name","value":"id"}]`,
+	}
+
+	for name, input := range cases {
+		name, input := name, input
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			expected, expectedErr := decodeGenericSteps([]byte(input))
+			var dec StepsDecoder
+			result, err := dec.DecodeSteps([]byte(input))
+			if (expectedErr == nil) != (err == nil) {
+				t.Fatalf("expected error %v, got %v", expectedErr, err)
+			}
+			// errors about a step are the same, apart from saying where
+			// the step is
+			var stepErr *StepError
+			if errors.As(err, &stepErr) && strings.HasPrefix(expectedErr.Error(), "error parsing step ") {
+				if msg := fmt.Sprintf("error parsing step %d: %s", stepErr.Step, stepErr.Err); msg != expectedErr.Error() {
+					t.Errorf("expected error %v, got %v", expectedErr, err)
+				}
+			}
+			if diff := cmp.Diff(expected, result); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestStepsDecoderErrors(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		`[{"kind":"cookie","value":"session"}]`:                  `error parsing step 0 at offset 1: unexpected step kind "cookie" with value type string`,
+		`[{"kind":"body"}, {"kind":"cookie"}]`:                   `error parsing step 1 at offset 18: unexpected step kind "cookie" with value type <nil>`,
+		`[{"kind":"cookie","value":[1, {"a": 2}]}]`:              `error parsing step 0 at offset 1: unexpected step kind "cookie" with value type []interface {}`,
+		`[{"kind":"header","value":true}]`:                       `error parsing step 0 at offset 1: wanted string, got bool`,
+		`[{"kind":"header","value":{"name":"Accept"}}]`:          `error parsing step 0 at offset 1: wanted string, got map[string]interface {}`,
+		`[{"kind":"object_property","value":1}]`:                 `error parsing step 0 at offset 1: wanted string, got json.Number`,
+		`[{"kind":"array_index","value":"1"}]`:                   `error parsing step 0 at offset 1: wanted json.Number, got string`,
+		`[{"kind":"body"},{"kind":"array_index","value":1.5}]`:   `error parsing step 1 at offset 17: strconv.ParseInt: parsing "1.5": invalid syntax`,
+		`[{"kind":"url_param","value":null}]`:                    `error parsing step 0 at offset 1: no value`,
+		`[{"kind":"url_param","value":"page","value":null}]`:     `error parsing step 0 at offset 1: no value`,
+		`[{"kind":"rune_index","value":99999999999999999999}]`:   `error parsing step 0 at offset 1: strconv.ParseInt: parsing "99999999999999999999": value out of range`,
+		"[\n  {\"kind\": \"body\"},\n  {\"kind\": 7}\n]":         `error parsing step 1 at offset 24: wanted string kind, got json.Number`,
+		`[{"kind":"body"},"header"]`:                             `error parsing step 1 at offset 17: wanted object, got string`,
+		`[null]`:                                                 `error parsing step 0 at offset 1: unexpected step kind "" with value type <nil>`,
+		`{"kind":"body"}`:                                        `wanted array, got map[string]interface {}`,
+		`[{"kind":"body"},{"kind":"header","value":"Accept"},{]`: `invalid character ']' looking for beginning of value`,
+	}
+
+	for input, expected := range cases {
+		input, expected := input, expected
+
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+
+			var dec StepsDecoder
+			_, err := dec.DecodeSteps([]byte(input))
+			if err == nil || err.Error() != expected {
+				t.Errorf("expected error %q, got %v", expected, err)
+			}
+		})
+	}
+}
+
+func TestStepsDecoderStepErrorUnwraps(t *testing.T) {
+	t.Parallel()
+
+	var steps Steps
+	err := json.Unmarshal([]byte(`[{"kind":"body"},{"kind":"cookie","value":"session"}]`), &steps)
+	var stepErr *StepError
+	if !errors.As(err, &stepErr) {
+		t.Fatalf("expected a *StepError, got %v", err)
+	}
+	if stepErr.Step != 1 || stepErr.Offset != 17 {
+		t.Errorf("expected step 1 at offset 17, got step %d at offset %d", stepErr.Step, stepErr.Offset)
+	}
+	if !errors.Is(err, errUnexpectedStepKind) {
+		t.Errorf("expected %v to wrap %v", err, errUnexpectedStepKind)
+	}
+}
+
+func TestStepsDecoderStrings(t *testing.T) {
+	t.Parallel()
+
+	var dec StepsDecoder
+	steps, err := dec.DecodeSteps([]byte("[{\"kind\":\"object_property\",\"value\":\"plain\"},{\"kind\":\"object_property\",\"value\":\"\\\"quoted\\\" \\u00e9\"},{\"kind\":\"object_property\",\"value\":\"a\xffb\"}]"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(PathOf(ObjectPropertyStep("plain"), ObjectPropertyStep(`"quoted" é`), ObjectPropertyStep("a\uFFFDb")), steps); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+}
+
+func TestStepsDecoderReuse(t *testing.T) {
+	t.Parallel()
+
+	var dec StepsDecoder
+	first, err := dec.DecodeSteps([]byte(`[{"kind":"body"},{"kind":"object_property","value":"items"},{"kind":"array_index","value":2}]`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	second, err := dec.DecodeSteps([]byte(`[{"kind":"header","value":"Accept"}]`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(BodyPath().AddSteps(ObjectPropertyStep("items"), ArrayIndexStep(2)), first); diff != "" {
+		t.Errorf("unexpected diff in first steps (-wanted, +got): %s", diff)
+	}
+	if diff := cmp.Diff(HeaderPath("Accept"), second); diff != "" {
+		t.Errorf("unexpected diff in second steps (-wanted, +got): %s", diff)
+	}
+
+	dec.Reset()
+	if len(dec.scratch) != 0 || cap(dec.scratch) == 0 {
+		t.Errorf("expected Reset to empty the scratch space and keep its room, got length %d and capacity %d", len(dec.scratch), cap(dec.scratch))
+	}
+	for pos, step := range dec.scratch[:cap(dec.scratch)] {
+		if step != nil {
+			t.Errorf("expected Reset to let go of step %d, still have %#v", pos, step)
+		}
+	}
+}
+
+func TestStepsUnmarshalJSONLongPath(t *testing.T) {
+	t.Parallel()
+
+	// longer than a pooled StepsDecoder keeps room for
+	expected := BodyPath()
+	var input strings.Builder
+	input.WriteString(`[{"kind":"body"}`)
+	for i := 0; i < maxPooledSteps*2; i++ {
+		expected = expected.AddStep(ArrayIndexStep(i))
+		input.WriteString(`,{"kind":"array_index","value":`)
+		input.WriteString(strconv.Itoa(i))
+		input.WriteString(`}`)
+	}
+	input.WriteString(`]`)
+	var result Steps
+	err := json.Unmarshal([]byte(input.String()), &result)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(expected, result); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+}
+
+func TestStepsDecoderAllocations(t *testing.T) {
+	input := []byte(`[{"kind":"body"},{"kind":"object_property","value":"items"},{"kind":"array_index","value":2},{"kind":"object_property","value":"name"}]`)
+	var dec StepsDecoder
+	allocs := testing.AllocsPerRun(100, func() {
+		_, err := dec.DecodeSteps(input)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	})
+	// the Steps, and a string and its Step for each property
+	if allocs != 5 {
+		t.Errorf("expected DecodeSteps to allocate 5 times, got %v allocations", allocs)
+	}
+}
+
+var benchmarkStepsJSON = []byte(`[{"kind":"body"},{"kind":"object_property","value":"items"},{"kind":"array_index","value":12},{"kind":"object_property","value":"name"},{"kind":"string_index","value":3}]`)
+
+func BenchmarkStepsUnmarshalJSON(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var steps Steps
+		err := steps.UnmarshalJSON(benchmarkStepsJSON)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStepsDecoderDecodeSteps(b *testing.B) {
+	b.ReportAllocs()
+	var dec StepsDecoder
+	for i := 0; i < b.N; i++ {
+		_, err := dec.DecodeSteps(benchmarkStepsJSON)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkStepsDecoderTokens measures decoding Steps the way StepsDecoders
+// decode input they don't parse directly.
+func BenchmarkStepsDecoderTokens(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := decodeStepTokens(benchmarkStepsJSON)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkStepsUnmarshalJSONGeneric measures decoding Steps the way
+// UnmarshalJSON used to, for comparison.
+func BenchmarkStepsUnmarshalJSONGeneric(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := decodeGenericSteps(benchmarkStepsJSON)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
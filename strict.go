@@ -0,0 +1,380 @@
+package apidiags
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxIndex is the largest index a StrictDecoder accepts in an
+// ArrayIndexStep, StringIndexStep, or RuneIndexStep, unless another limit
+// is set using WithMaxIndex. No index can point past the end of the largest
+// request body DecodeBody reads by default.
+const DefaultMaxIndex = DefaultMaxBodyBytes
+
+var (
+	// ErrDuplicateKey is returned by a StrictDecoder when an object has
+	// more than one member with the same name.
+	ErrDuplicateKey = errors.New("duplicate key")
+
+	// ErrNonIntegerIndex is returned by a StrictDecoder when the value of
+	// an index step is a number that isn't an integer, like 1.5 or 1e3.
+	ErrNonIntegerIndex = errors.New("index isn't an integer")
+
+	// ErrIndexOutOfRange is returned by a StrictDecoder when the value of
+	// an index step is negative, or larger than its maximum.
+	ErrIndexOutOfRange = errors.New("index out of range")
+
+	// ErrTrailingData is returned by a StrictDecoder when its input has
+	// data after the JSON value being decoded.
+	ErrTrailingData = errors.New("data after JSON value")
+)
+
+// StrictDecodeError describes the part of its input a StrictDecoder
+// rejected. Err is one of the errors StrictDecoder documents, or an error
+// from encoding/json for malformed JSON.
+type StrictDecodeError struct {
+	// Offset is the byte offset of the problem in the input.
+	Offset int64
+
+	// Location describes where in the input the problem is, like
+	// `[0].path[1][2].value`. It's empty for problems at the top level.
+	Location string
+
+	// Err describes the problem.
+	Err error
+}
+
+func (e *StrictDecodeError) Error() string {
+	if e.Location == "" {
+		return fmt.Sprintf("%s (offset %d)", e.Err, e.Offset)
+	}
+	return fmt.Sprintf("%s at %s (offset %d)", e.Err, e.Location, e.Offset)
+}
+
+func (e *StrictDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// StrictOption configures a StrictDecoder.
+type StrictOption func(*StrictDecoder)
+
+// WithMaxIndex sets the largest index a StrictDecoder accepts in an
+// ArrayIndexStep, StringIndexStep, or RuneIndexStep. A limit of zero or less
+// means DefaultMaxIndex.
+func WithMaxIndex(n int64) StrictOption {
+	return func(d *StrictDecoder) {
+		d.maxIndex = n
+	}
+}
+
+// StrictDecoder decodes Diagnostics and Steps from untrusted input, like
+// the responses of third-party services a gateway passes on, rejecting
+// input that decoding with encoding/json would accept or silently change.
+// On top of everything encoding/json rejects, it rejects:
+//
+//   - objects with more than one member with the same name, anywhere in
+//     the input, with ErrDuplicateKey
+//   - index steps whose value isn't an integer, with ErrNonIntegerIndex
+//   - index steps whose value is negative or larger than the limit set by
+//     WithMaxIndex, or DefaultMaxIndex, with ErrIndexOutOfRange
+//   - data after the JSON value being decoded, with ErrTrailingData
+//
+// Those problems are reported as a *StrictDecodeError saying where in the
+// input they are. The zero value is ready to use, with a limit of
+// DefaultMaxIndex. A StrictDecoder is safe for concurrent use.
+type StrictDecoder struct {
+	maxIndex int64
+}
+
+// NewStrictDecoder returns a StrictDecoder configured by opts.
+func NewStrictDecoder(opts ...StrictOption) *StrictDecoder {
+	decoder := &StrictDecoder{maxIndex: DefaultMaxIndex}
+	for _, opt := range opts {
+		opt(decoder)
+	}
+	return decoder
+}
+
+// DecodeDiagnostics decodes the Diagnostics in in, which can be in any
+// format DecodeVersionedDiagnostics accepts.
+func (d *StrictDecoder) DecodeDiagnostics(in []byte) (Diagnostics, error) {
+	p := d.parser(in)
+	tok, _, err := p.next("")
+	if err != nil {
+		return nil, err
+	}
+	if tok == json.Delim('{') {
+		err = p.object("", versionedDiagnosticsMembers, func(key, loc string) error {
+			if key == "diagnostics" {
+				return p.diagnostics(loc)
+			}
+			return p.skip(loc)
+		})
+	} else {
+		err = p.diagnosticsFrom("", tok)
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = p.end()
+	if err != nil {
+		return nil, err
+	}
+	return DecodeVersionedDiagnostics(in)
+}
+
+// DecodeSteps decodes the Steps in in, which must be in the format
+// produced by Steps.MarshalJSON.
+func (d *StrictDecoder) DecodeSteps(in []byte) (Steps, error) {
+	p := d.parser(in)
+	tok, _, err := p.next("")
+	if err != nil {
+		return nil, err
+	}
+	err = p.stepsFrom("", tok)
+	if err != nil {
+		return nil, err
+	}
+	err = p.end()
+	if err != nil {
+		return nil, err
+	}
+	var steps Steps
+	err = json.Unmarshal(in, &steps)
+	if err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+// versionedDiagnosticsMembers and stepMembers are the names of the members
+// of the JSON encodings of versionedDiagnostics and of each Step.
+var (
+	versionedDiagnosticsMembers = map[string]bool{"version": true, "diagnostics": true}
+	stepMembers                 = map[string]bool{"kind": true, "value": true}
+)
+
+func (d *StrictDecoder) parser(in []byte) *strictParser {
+	dec := json.NewDecoder(bytes.NewReader(in))
+	dec.UseNumber()
+	maxIndex := d.maxIndex
+	if maxIndex <= 0 {
+		maxIndex = DefaultMaxIndex
+	}
+	return &strictParser{dec: dec, in: in, maxIndex: maxIndex}
+}
+
+// strictParser checks the tokens of a StrictDecoder's input for the
+// problems it rejects. Anything it doesn't understand, like a value of the
+// wrong type, is skipped, to be reported when the input is decoded.
+type strictParser struct {
+	dec      *json.Decoder
+	in       []byte
+	maxIndex int64
+}
+
+func (p *strictParser) fail(loc string, offset int64, err error) error {
+	return &StrictDecodeError{Offset: offset, Location: loc, Err: err}
+}
+
+// next returns the next token, and the byte offset it starts at.
+func (p *strictParser) next(loc string) (json.Token, int64, error) {
+	offset := valueOffset(p.in, p.dec.InputOffset())
+	tok, err := p.dec.Token()
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, offset, p.fail(loc, offset, err)
+	}
+	return tok, offset, nil
+}
+
+// end checks that the input has nothing after the value that was parsed.
+func (p *strictParser) end() error {
+	offset := nextTokenOffset(p.in, p.dec.InputOffset())
+	if _, err := p.dec.Token(); err != io.EOF {
+		return p.fail("", offset, ErrTrailingData)
+	}
+	return nil
+}
+
+// skip checks the next value, which has no special meaning.
+func (p *strictParser) skip(loc string) error {
+	tok, _, err := p.next(loc)
+	if err != nil {
+		return err
+	}
+	return p.skipFrom(loc, tok)
+}
+
+// skipFrom checks the value starting with tok, which has no special
+// meaning.
+func (p *strictParser) skipFrom(loc string, tok json.Token) error {
+	switch tok {
+	case json.Delim('{'):
+		return p.object(loc, nil, func(_, loc string) error {
+			return p.skip(loc)
+		})
+	case json.Delim('['):
+		return p.array(loc, p.skip)
+	}
+	return nil
+}
+
+// object checks the members of an object whose opening brace was just
+// read, calling member to check the value of each. The object is decoded
+// into a struct with fields, which encoding/json matches to members
+// ignoring case, so members with those names are passed to member in lower
+// case, and compared ignoring case when looking for duplicates.
+func (p *strictParser) object(loc string, fields map[string]bool, member func(key, loc string) error) error {
+	seen := map[string]bool{}
+	for {
+		tok, keyOffset, err := p.next(loc)
+		if err != nil {
+			return err
+		}
+		if tok == json.Delim('}') {
+			return nil
+		}
+		key, _ := tok.(string)
+		memberLoc := key
+		if loc != "" {
+			memberLoc = loc + "." + key
+		}
+		name := key
+		if fields[strings.ToLower(key)] {
+			name = strings.ToLower(key)
+		}
+		if seen[name] {
+			return p.fail(memberLoc, keyOffset, fmt.Errorf("%w %q", ErrDuplicateKey, key))
+		}
+		seen[name] = true
+		err = member(name, memberLoc)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// array checks the elements of an array whose opening bracket was just
+// read, calling elem to check each.
+func (p *strictParser) array(loc string, elem func(loc string) error) error {
+	for pos := 0; p.dec.More(); pos++ {
+		err := elem(loc + "[" + strconv.Itoa(pos) + "]")
+		if err != nil {
+			return err
+		}
+	}
+	_, _, err := p.next(loc)
+	return err
+}
+
+// diagnostics checks the next value, an array of Diagnostics.
+func (p *strictParser) diagnostics(loc string) error {
+	tok, _, err := p.next(loc)
+	if err != nil {
+		return err
+	}
+	return p.diagnosticsFrom(loc, tok)
+}
+
+// diagnosticsFrom checks the value starting with tok, an array of
+// Diagnostics.
+func (p *strictParser) diagnosticsFrom(loc string, tok json.Token) error {
+	if tok != json.Delim('[') {
+		return p.skipFrom(loc, tok)
+	}
+	return p.array(loc, func(loc string) error {
+		tok, _, err := p.next(loc)
+		if err != nil {
+			return err
+		}
+		if tok != json.Delim('{') {
+			return p.skipFrom(loc, tok)
+		}
+		return p.object(loc, diagnosticJSONMembers, func(key, loc string) error {
+			if key != "path" {
+				return p.skip(loc)
+			}
+			tok, _, err := p.next(loc)
+			if err != nil {
+				return err
+			}
+			if tok != json.Delim('[') {
+				return p.skipFrom(loc, tok)
+			}
+			return p.array(loc, func(loc string) error {
+				tok, _, err := p.next(loc)
+				if err != nil {
+					return err
+				}
+				return p.stepsFrom(loc, tok)
+			})
+		})
+	})
+}
+
+// stepsFrom checks the value starting with tok, an array of steps.
+func (p *strictParser) stepsFrom(loc string, tok json.Token) error {
+	if tok != json.Delim('[') {
+		return p.skipFrom(loc, tok)
+	}
+	return p.array(loc, p.step)
+}
+
+// step checks the next value, a step.
+func (p *strictParser) step(loc string) error {
+	tok, _, err := p.next(loc)
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return p.skipFrom(loc, tok)
+	}
+	var kind string
+	var value json.Number
+	var valueLoc string
+	var valueAt int64
+	err = p.object(loc, stepMembers, func(key, memberLoc string) error {
+		tok, offset, err := p.next(memberLoc)
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "kind":
+			kind, _ = tok.(string)
+		case "value":
+			value, _ = tok.(json.Number)
+			valueLoc, valueAt = memberLoc, offset
+		}
+		return p.skipFrom(memberLoc, tok)
+	})
+	if err != nil {
+		return err
+	}
+	switch kind {
+	case "array_index", "string_index", "rune_index":
+	default:
+		return nil
+	}
+	if value == "" {
+		return nil
+	}
+	if strings.ContainsAny(string(value), ".eE") {
+		return p.fail(valueLoc, valueAt, fmt.Errorf("%w: %s value %s", ErrNonIntegerIndex, kind, value))
+	}
+	if strings.HasPrefix(string(value), "-") {
+		return p.fail(valueLoc, valueAt, fmt.Errorf("%w: %s value %s is negative", ErrIndexOutOfRange, kind, value))
+	}
+	idx, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil || idx > p.maxIndex {
+		return p.fail(valueLoc, valueAt, fmt.Errorf("%w: %s value %s is larger than %d", ErrIndexOutOfRange, kind, value, p.maxIndex))
+	}
+	return nil
+}
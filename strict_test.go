@@ -0,0 +1,246 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStrictDecoderDecodeDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    string
+		opts     []StrictOption
+		expected Diagnostics
+		wantErr  error
+		errMsg   string
+	}
+
+	cases := map[string]testCase{
+		"unversioned": {
+			input: `[{"severity": "error", "code": "missing", "path": [[{"kind": "body"}, {"kind": "array_index", "value": 3}]]}]`,
+			expected: Diagnostics{
+				{Severity: DiagnosticError, Code: CodeMissing, Paths: []Steps{BodyPath().AddStep(ArrayIndexStep(3))}},
+			},
+		},
+		"versioned": {
+			input: `{"version": 1, "diagnostics": [{"severity": "warning", "code": "deprecated", "path": [[{"kind": "header", "value": "X-Legacy"}, {"kind": "rune_index", "value": 0}]]}]}` + "\n",
+			expected: Diagnostics{
+				{Severity: DiagnosticWarning, Code: CodeDeprecated, Paths: []Steps{HeaderPath("X-Legacy").AddStep(RuneIndexStep(0))}},
+			},
+		},
+		"extensions": {
+			input: `[{"severity": "error", "code": "overflow", "max": 10, "Max": 11, "nested": {"a": [1.5]}}]`,
+			expected: Diagnostics{{
+				Severity:   DiagnosticError,
+				Code:       CodeOverflow,
+				Extensions: map[string]json.RawMessage{"max": json.RawMessage(`10`), "Max": json.RawMessage(`11`), "nested": json.RawMessage(`{"a": [1.5]}`)},
+			}},
+		},
+		"duplicate-field": {
+			input:   `[{"severity": "error", "code": "missing", "code": "not_found"}]`,
+			wantErr: ErrDuplicateKey,
+			errMsg:  `duplicate key "code" at [0].code (offset 42)`,
+		},
+		"duplicate-field-case": {
+			input:   `[{"severity": "error", "code": "missing", "Code": "not_found"}]`,
+			wantErr: ErrDuplicateKey,
+			errMsg:  `duplicate key "Code" at [0].Code (offset 42)`,
+		},
+		"duplicate-extension": {
+			input:   `[{"severity": "error", "code": "missing", "extra": {"a": 1, "a": 2}}]`,
+			wantErr: ErrDuplicateKey,
+			errMsg:  `duplicate key "a" at [0].extra.a (offset 60)`,
+		},
+		"duplicate-envelope": {
+			input:   `{"version": 1, "diagnostics": [], "diagnostics": [{"severity": "error", "code": "missing"}]}`,
+			wantErr: ErrDuplicateKey,
+			errMsg:  `duplicate key "diagnostics" at diagnostics (offset 34)`,
+		},
+		"duplicate-step-member": {
+			input:   `[{"severity": "error", "code": "missing", "path": [[{"kind": "body", "kind": "header", "value": "X"}]]}]`,
+			wantErr: ErrDuplicateKey,
+			errMsg:  `duplicate key "kind" at [0].path[0][0].kind (offset 69)`,
+		},
+		"fractional-index": {
+			input:   `{"version": 1, "diagnostics": [{"severity": "error", "code": "missing", "path": [[{"kind": "body"}, {"kind": "array_index", "value": 1.5}]]}]}`,
+			wantErr: ErrNonIntegerIndex,
+			errMsg:  "index isn't an integer: array_index value 1.5 at diagnostics[0].path[0][1].value (offset 133)",
+		},
+		"exponent-index": {
+			input:   `[{"severity": "error", "code": "missing", "path": [[{"kind": "body"}, {"kind": "string_index", "value": 1e2}]]}]`,
+			wantErr: ErrNonIntegerIndex,
+			errMsg:  "index isn't an integer: string_index value 1e2 at [0].path[0][1].value (offset 104)",
+		},
+		"negative-index": {
+			input:   `[{"severity": "error", "code": "missing", "path": [[{"kind": "body"}, {"value": -1, "kind": "rune_index"}]]}]`,
+			wantErr: ErrIndexOutOfRange,
+			errMsg:  "index out of range: rune_index value -1 is negative at [0].path[0][1].value (offset 80)",
+		},
+		"huge-index": {
+			input:   `[{"severity": "error", "code": "missing", "path": [[{"kind": "body"}, {"kind": "array_index", "value": 99999999999999999999}]]}]`,
+			wantErr: ErrIndexOutOfRange,
+			errMsg:  "index out of range: array_index value 99999999999999999999 is larger than 1048576 at [0].path[0][1].value (offset 103)",
+		},
+		"index-over-limit": {
+			input:   `[{"severity": "error", "code": "missing", "path": [[{"kind": "body"}, {"kind": "array_index", "value": 11}]]}]`,
+			opts:    []StrictOption{WithMaxIndex(10)},
+			wantErr: ErrIndexOutOfRange,
+			errMsg:  "index out of range: array_index value 11 is larger than 10 at [0].path[0][1].value (offset 103)",
+		},
+		"index-at-limit": {
+			input: `[{"severity": "error", "code": "missing", "path": [[{"kind": "body"}, {"kind": "array_index", "value": 10}]]}]`,
+			opts:  []StrictOption{WithMaxIndex(10)},
+			expected: Diagnostics{
+				{Severity: DiagnosticError, Code: CodeMissing, Paths: []Steps{BodyPath().AddStep(ArrayIndexStep(10))}},
+			},
+		},
+		"trailing-data": {
+			input:   `[{"severity": "error", "code": "missing"}] {}`,
+			wantErr: ErrTrailingData,
+			errMsg:  "data after JSON value (offset 43)",
+		},
+		"trailing-garbage": {
+			input:   `{"version": 1, "diagnostics": []}garbage`,
+			wantErr: ErrTrailingData,
+			errMsg:  "data after JSON value (offset 33)",
+		},
+		"truncated": {
+			input:  `[{"severity": "error", "code": "missing"`,
+			errMsg: "unexpected EOF at [0] (offset 40)",
+		},
+		"empty": {
+			input:  ` `,
+			errMsg: "unexpected EOF (offset 1)",
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := NewStrictDecoder(tc.opts...).DecodeDiagnostics([]byte(tc.input))
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.errMsg == "" && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if tc.errMsg != "" {
+				var strictErr *StrictDecodeError
+				if !errors.As(err, &strictErr) {
+					t.Fatalf("expected a *StrictDecodeError, got %T: %v", err, err)
+				}
+				if diff := cmp.Diff(tc.errMsg, err.Error()); diff != "" {
+					t.Errorf("unexpected error message (-wanted, +got): %s", diff)
+				}
+			}
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestStrictDecoderDecodeDiagnosticsMatchesLenient(t *testing.T) {
+	t.Parallel()
+
+	// errors encoding/json reports are passed on without being wrapped
+	for name, input := range map[string]string{
+		"wrong-type":   `[{"severity": "error", "code": 1}]`,
+		"unknown-step": `[{"severity": "error", "code": "missing", "path": [[{"kind": "cookie", "value": "session"}]]}]`,
+		"newer":        `{"version": 99, "diagnostics": []}`,
+	} {
+		name, input := name, input
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, expected := DecodeVersionedDiagnostics([]byte(input))
+			if expected == nil {
+				t.Fatal("expected DecodeVersionedDiagnostics to fail")
+			}
+			_, err := NewStrictDecoder().DecodeDiagnostics([]byte(input))
+			if err == nil || err.Error() != expected.Error() {
+				t.Errorf("expected error %v, got %v", expected, err)
+			}
+		})
+	}
+}
+
+func TestStrictDecoderZeroValue(t *testing.T) {
+	t.Parallel()
+
+	var decoder StrictDecoder
+	result, err := decoder.DecodeSteps([]byte(`[{"kind": "body"}, {"kind": "array_index", "value": 12}]`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(BodyPath().AddStep(ArrayIndexStep(12)), result); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	_, err = decoder.DecodeSteps([]byte(fmt.Sprintf(`[{"kind": "array_index", "value": %d}]`, int64(DefaultMaxIndex)+1)))
+	if !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("expected error %v, got %v", ErrIndexOutOfRange, err)
+	}
+}
+
+func TestStrictDecoderDecodeSteps(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    string
+		expected Steps
+		wantErr  error
+		errMsg   string
+	}
+
+	cases := map[string]testCase{
+		"valid": {
+			input:    `[{"kind": "body"}, {"kind": "object_property", "value": "tags"}, {"kind": "array_index", "value": 2}]`,
+			expected: BodyPath().AddStep(ObjectPropertyStep("tags")).AddStep(ArrayIndexStep(2)),
+		},
+		"duplicate-key": {
+			input:   `[{"kind": "object_property", "value": "a", "VALUE": "b"}]`,
+			wantErr: ErrDuplicateKey,
+			errMsg:  `duplicate key "VALUE" at [0].VALUE (offset 43)`,
+		},
+		"fractional-index": {
+			input:   `[{"kind": "array_index", "value": 2.0}]`,
+			wantErr: ErrNonIntegerIndex,
+			errMsg:  "index isn't an integer: array_index value 2.0 at [0].value (offset 34)",
+		},
+		"trailing-data": {
+			input:   `[{"kind": "body"}]]`,
+			wantErr: ErrTrailingData,
+			errMsg:  "data after JSON value (offset 18)",
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := NewStrictDecoder().DecodeSteps([]byte(tc.input))
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.errMsg != "" {
+				if diff := cmp.Diff(tc.errMsg, err.Error()); diff != "" {
+					t.Errorf("unexpected error message (-wanted, +got): %s", diff)
+				}
+			}
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
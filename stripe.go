@@ -0,0 +1,187 @@
+package apidiags
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StripeErrorResponse is the body of an error response in the style of
+// Stripe's API.
+type StripeErrorResponse struct {
+	Error StripeError `json:"error"`
+}
+
+// StripeError is the error object of a StripeErrorResponse.
+type StripeError struct {
+	// Type is the broad category of the error, like
+	// "invalid_request_error" or "api_error".
+	Type string `json:"type"`
+
+	// Code is a short string identifying the error.
+	Code string `json:"code,omitempty"`
+
+	// Param is the request parameter the error is about, like
+	// `items[0][name]`.
+	Param string `json:"param,omitempty"`
+
+	// Message is a human-readable description of the error.
+	Message string `json:"message,omitempty"`
+
+	// DocURL is a link to documentation about the error.
+	DocURL string `json:"doc_url,omitempty"`
+}
+
+const (
+	// StripeInvalidRequestError is the StripeError Type used for errors
+	// caused by the request.
+	StripeInvalidRequestError = "invalid_request_error"
+
+	// StripeAPIError is the StripeError Type used for errors that aren't
+	// the request's fault.
+	StripeAPIError = "api_error"
+)
+
+// StripeErrorFromDiagnostics converts diags into a Stripe-style error
+// response, describing the first Diagnostic with a Severity of
+// DiagnosticError, or the first Diagnostic if there are no errors. Stripe
+// errors can only describe a single problem, so the other Diagnostics are
+// left out.
+//
+// Diagnostics with a Code of CodeActOfGod have a Type of StripeAPIError, and
+// all others have a Type of StripeInvalidRequestError. The Diagnostic's first
+// Path becomes the param, using Stripe's bracketed notation, like
+// `items[0][name]`, for the request body and the name of the parameter for
+// URLParamSteps. Paths that can't be represented that way are left out. If
+// diags is empty, the zero value is returned.
+func StripeErrorFromDiagnostics(diags Diagnostics) StripeErrorResponse {
+	var result StripeErrorResponse
+	if len(diags) < 1 {
+		return result
+	}
+	primary := diags[0]
+	for _, diag := range diags {
+		if diag.Severity == DiagnosticError {
+			primary = diag
+			break
+		}
+	}
+	result.Error = StripeError{
+		Type:    StripeInvalidRequestError,
+		Code:    string(primary.Code),
+		Message: primary.Message(),
+		DocURL:  primary.DocURL,
+	}
+	if primary.Code == CodeActOfGod {
+		result.Error.Type = StripeAPIError
+	}
+	if len(primary.Paths) > 0 {
+		result.Error.Param, _ = stripeParamFromSteps(primary.Paths[0])
+	}
+	return result
+}
+
+// stripeParamFromSteps renders steps using Stripe's bracketed parameter
+// notation, returning false if steps can't be rendered that way.
+func stripeParamFromSteps(steps Steps) (string, bool) {
+	if len(steps) < 1 {
+		return "", false
+	}
+	switch value := steps[0].(type) {
+	case URLParamStep:
+		return string(value), len(steps) == 1
+	case BodyStep:
+	default:
+		return "", false
+	}
+	var buf strings.Builder
+	for pos, step := range steps[1:] {
+		switch value := step.(type) {
+		case ObjectPropertyStep:
+			if strings.ContainsAny(string(value), "[]") || value == "" {
+				return "", false
+			}
+			if pos == 0 {
+				buf.WriteString(string(value))
+			} else {
+				buf.WriteString("[" + string(value) + "]")
+			}
+		case ArrayIndexStep:
+			if pos == 0 {
+				return "", false
+			}
+			buf.WriteString("[" + strconv.FormatInt(int64(value), 10) + "]")
+		default:
+			return "", false
+		}
+	}
+	return buf.String(), buf.Len() > 0
+}
+
+// DiagnosticsFromStripeError converts a Stripe-style error response into
+// Diagnostics, reversing StripeErrorFromDiagnostics. The result is a single
+// Diagnostic with a Severity of DiagnosticError, or no Diagnostics if resp
+// has no Type or Code.
+//
+// Params are assumed to refer to the request body, as Stripe doesn't
+// distinguish between body and URL parameters. Bracketed segments made up
+// only of digits are parsed as ArrayIndexSteps, and all other segments as
+// ObjectPropertySteps.
+func DiagnosticsFromStripeError(resp StripeErrorResponse) (Diagnostics, error) {
+	if resp.Error.Type == "" && resp.Error.Code == "" {
+		return nil, nil
+	}
+	diag := Diagnostic{
+		Severity: DiagnosticError,
+		Code:     Code(resp.Error.Code),
+		DocURL:   resp.Error.DocURL,
+	}
+	if diag.Code == "" {
+		diag.Code = Code(resp.Error.Type)
+	}
+	if resp.Error.Message != string(diag.Code) {
+		diag.Summary = resp.Error.Message
+	}
+	if resp.Error.Param != "" {
+		path, err := stepsFromStripeParam(resp.Error.Param)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing param: %w", err)
+		}
+		diag.Paths = []Steps{path}
+	}
+	return Diagnostics{diag}, nil
+}
+
+func stepsFromStripeParam(param string) (Steps, error) {
+	end := strings.IndexByte(param, '[')
+	if end < 0 {
+		end = len(param)
+	}
+	if end == 0 {
+		return nil, errors.New("param must start with a name")
+	}
+	results := BodyPath().AddStep(ObjectPropertyStep(param[:end]))
+	param = param[end:]
+	for param != "" {
+		if param[0] != '[' {
+			return nil, fmt.Errorf("expected [, got %q", param)
+		}
+		end := strings.IndexByte(param, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated %q", param)
+		}
+		segment := param[1:end]
+		if isJSONPointerIndex(segment) {
+			idx, err := strconv.ParseInt(segment, 10, 64)
+			if err == nil {
+				results = append(results, ArrayIndexStep(idx))
+				param = param[end+1:]
+				continue
+			}
+		}
+		results = append(results, ObjectPropertyStep(segment))
+		param = param[end+1:]
+	}
+	return results, nil
+}
@@ -0,0 +1,144 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nsf/jsondiff"
+)
+
+func TestStripeErrorFromDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags    Diagnostics
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"empty": {
+			expected: `{"error": {"type": ""}}`,
+		},
+		"nested-param": {
+			diags: Diagnostics{
+				{Severity: DiagnosticWarning, Code: CodeDeprecated, Paths: []Steps{HeaderPath("X-Legacy")}},
+				{
+					Severity: DiagnosticError,
+					Code:     CodeMissing,
+					Summary:  "Name is required.",
+					DocURL:   "https://example.com/errors/missing",
+					Paths:    []Steps{BodyPath().AddSteps(ObjectPropertyStep("items"), ArrayIndexStep(0), ObjectPropertyStep("name"))},
+				},
+				{Severity: DiagnosticError, Code: CodeConflict},
+			},
+			expected: `{"error": {"type": "invalid_request_error", "code": "missing", "param": "items[0][name]", "message": "Name is required.", "doc_url": "https://example.com/errors/missing"}}`,
+		},
+		"url-param": {
+			diags: Diagnostics{
+				{Severity: DiagnosticError, Code: CodeNotFound, Paths: []Steps{URLParamPath("id")}},
+			},
+			expected: `{"error": {"type": "invalid_request_error", "code": "not_found", "param": "id", "message": "not_found"}}`,
+		},
+		"unrepresentable-param": {
+			diags: Diagnostics{
+				{Severity: DiagnosticError, Code: CodeInvalidFormat, Paths: []Steps{HeaderPath("Content-Type")}},
+			},
+			expected: `{"error": {"type": "invalid_request_error", "code": "invalid_format", "message": "invalid_format"}}`,
+		},
+		"api-error": {
+			diags: Diagnostics{
+				{Severity: DiagnosticError, Code: CodeActOfGod, Summary: "Try again later."},
+			},
+			expected: `{"error": {"type": "api_error", "code": "act_of_god", "message": "Try again later."}}`,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := json.Marshal(StripeErrorFromDiagnostics(tc.diags))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			opts := jsondiff.DefaultConsoleOptions()
+			match, diff := jsondiff.Compare([]byte(tc.expected), result, &opts)
+			if match != jsondiff.FullMatch {
+				t.Errorf("Unexpected result: %s", diff)
+			}
+			if match > jsondiff.NoMatch {
+				t.Logf("first argument: %s", tc.expected)
+				t.Logf("second argument: %s", result)
+			}
+		})
+	}
+}
+
+func TestDiagnosticsFromStripeError(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    string
+		expected Diagnostics
+		wantErr  bool
+	}
+
+	cases := map[string]testCase{
+		"empty": {
+			input: `{"error": {}}`,
+		},
+		"nested-param": {
+			input: `{"error": {"type": "invalid_request_error", "code": "missing", "param": "items[0][name]", "message": "Name is required.", "doc_url": "https://example.com/errors/missing"}}`,
+			expected: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     CodeMissing,
+					Summary:  "Name is required.",
+					DocURL:   "https://example.com/errors/missing",
+					Paths:    []Steps{BodyPath().AddSteps(ObjectPropertyStep("items"), ArrayIndexStep(0), ObjectPropertyStep("name"))},
+				},
+			},
+		},
+		"type-only": {
+			input: `{"error": {"type": "card_error", "message": "Your card was declined."}}`,
+			expected: Diagnostics{
+				{Severity: DiagnosticError, Code: "card_error", Summary: "Your card was declined."},
+			},
+		},
+		"unterminated-param": {
+			input:   `{"error": {"type": "invalid_request_error", "code": "missing", "param": "items[0"}}`,
+			wantErr: true,
+		},
+		"param-without-name": {
+			input:   `{"error": {"type": "invalid_request_error", "code": "missing", "param": "[0]"}}`,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var resp StripeErrorResponse
+			err := json.Unmarshal([]byte(tc.input), &resp)
+			if err != nil {
+				t.Fatalf("unexpected error decoding input: %s", err)
+			}
+			result, err := DiagnosticsFromStripeError(resp)
+			if err != nil && !tc.wantErr {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && tc.wantErr {
+				t.Fatalf("expected error, got %+v", result)
+			}
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
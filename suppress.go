@@ -0,0 +1,69 @@
+package apidiags
+
+import (
+	"net/http"
+	"strings"
+)
+
+// SuppressWarningsHeader is the request header clients use to opt out of
+// warnings they already handle, listing the Codes of the warnings to leave
+// out of the response, separated by commas, like
+// `X-API-Suppress-Warnings: deprecated, hint`. A Code of `*` suppresses
+// every warning.
+const SuppressWarningsHeader = "X-API-Suppress-Warnings"
+
+// SuppressedWarnings returns the Codes listed in r's
+// SuppressWarningsHeader.
+func SuppressedWarnings(r *http.Request) []Code {
+	var results []Code
+	for _, value := range r.Header.Values(SuppressWarningsHeader) {
+		for _, code := range strings.Split(value, ",") {
+			code = strings.TrimSpace(code)
+			if code != "" {
+				results = append(results, Code(code))
+			}
+		}
+	}
+	return results
+}
+
+// FilterSuppressedWarnings returns diags without the warnings r's
+// SuppressWarningsHeader asks to suppress. Errors are never suppressed. If
+// nothing is suppressed, diags is returned as-is.
+func FilterSuppressedWarnings(r *http.Request, diags Diagnostics) Diagnostics {
+	codes := SuppressedWarnings(r)
+	if len(codes) < 1 {
+		return diags
+	}
+	suppressed := make(map[Code]bool, len(codes))
+	for _, code := range codes {
+		suppressed[code] = true
+	}
+	var results Diagnostics
+	for pos, diag := range diags {
+		if diag.Severity != DiagnosticWarning || !(suppressed[diag.Code] || suppressed["*"]) {
+			if results != nil {
+				results = append(results, diag)
+			}
+			continue
+		}
+		if results == nil {
+			results = make(Diagnostics, pos, len(diags))
+			copy(results, diags[:pos])
+		}
+	}
+	if results == nil {
+		return diags
+	}
+	return results
+}
+
+// WithWarningSuppression makes WriteHTTP and WriteEnvelope leave out the
+// warnings r's SuppressWarningsHeader asks to suppress, using
+// FilterSuppressedWarnings. The headers describing the Diagnostics, like
+// the Deprecation header, are left out along with them.
+func WithWarningSuppression(r *http.Request) HTTPOption {
+	return func(c *httpConfig) {
+		c.request = r
+	}
+}
@@ -0,0 +1,102 @@
+package apidiags
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFilterSuppressedWarnings(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		headers  []string
+		diags    Diagnostics
+		expected Diagnostics
+	}
+
+	diags := Diagnostics{
+		{Severity: DiagnosticWarning, Code: CodeDeprecated, Paths: []Steps{URLParamPath("mode")}},
+		{Severity: DiagnosticError, Code: CodeDeprecated, Paths: []Steps{URLParamPath("legacy")}},
+		{Severity: DiagnosticWarning, Code: "hint"},
+		{Severity: DiagnosticWarning, Code: CodeOverflow, Paths: []Steps{BodyPath().AddStep(ObjectPropertyStep("name"))}},
+	}
+
+	cases := map[string]testCase{
+		"no-header": {
+			diags:    diags,
+			expected: diags,
+		},
+		"one-code": {
+			headers:  []string{"deprecated"},
+			diags:    diags,
+			expected: Diagnostics{diags[1], diags[2], diags[3]},
+		},
+		"list": {
+			headers:  []string{" deprecated , hint,"},
+			diags:    diags,
+			expected: Diagnostics{diags[1], diags[3]},
+		},
+		"multiple-headers": {
+			headers:  []string{"hint", "overflow"},
+			diags:    diags,
+			expected: Diagnostics{diags[0], diags[1]},
+		},
+		"wildcard": {
+			headers:  []string{"*"},
+			diags:    diags,
+			expected: Diagnostics{diags[1]},
+		},
+		"unknown-code": {
+			headers:  []string{"not_a_code"},
+			diags:    diags,
+			expected: diags,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, header := range tc.headers {
+				r.Header.Add(SuppressWarningsHeader, header)
+			}
+			result := FilterSuppressedWarnings(r, tc.diags)
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteHTTPWarningSuppression(t *testing.T) {
+	t.Parallel()
+
+	diags := Diagnostics{
+		{Severity: DiagnosticWarning, Code: CodeDeprecated, Paths: []Steps{URLParamPath("mode")}, DocURL: "https://example.com/deprecations/mode"},
+		{Severity: DiagnosticWarning, Code: "hint"},
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(SuppressWarningsHeader, "deprecated")
+
+	w := httptest.NewRecorder()
+	err := WriteHTTP(w, diags, WithWarningsHeader(), WithWarningSuppression(r))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if link := w.Header().Get("Link"); link != "" {
+		t.Errorf("expected no Link header, got %q", link)
+	}
+	warnings, err := WarningsFromHeader(w.Header())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(diags[1:], warnings); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+}
@@ -0,0 +1,91 @@
+package apidiags
+
+// MessageTemplate describes a Diagnostic's Summary and Detail as templates
+// and the values to fill them in with, so they're only rendered when the
+// Diagnostic is written, and only in the language the caller asked for.
+// Services that build many Diagnostics only to drop or sample most of them
+// don't pay for rendering the messages that are never sent:
+//
+//	diag := apidiags.Diagnostic{
+//		Severity: apidiags.DiagnosticError,
+//		Code:     apidiags.CodeOverflow,
+//		Paths:    []apidiags.Steps{path},
+//	}.WithTemplate(apidiags.MessageTemplate{
+//		Summary: "{path} can be at most {max} characters long.",
+//		Args:    map[string]any{"max": 64},
+//	})
+//
+// Templates have the same placeholders as the messages in a Catalog, which
+// can also use Args. Args are used before the extension members of the same
+// name, and are rendered using fmt.Sprint.
+type MessageTemplate struct {
+	// Summary is the template for the Diagnostic's Summary, in the API's
+	// own language. If it's empty, the Diagnostic's Summary is kept.
+	Summary string
+
+	// Detail is the template for the Diagnostic's Detail, in the API's
+	// own language. If it's empty, the Diagnostic's Detail is kept.
+	Detail string
+
+	// Args holds the values of the templates' placeholders, by name. They
+	// aren't rendered until the templates are, so they must not be changed
+	// once the MessageTemplate is in use.
+	Args map[string]any
+}
+
+// WithTemplate returns a copy of d with tmpl as its Template.
+func (d Diagnostic) WithTemplate(tmpl MessageTemplate) Diagnostic {
+	d.Template = &tmpl
+	return d
+}
+
+// RenderMessage returns a copy of d with its Template rendered into its
+// Summary and Detail, and removed. If catalog has a message for d's Code in
+// the first of langs it can, like Catalog.Localize, that message is
+// rendered into the Summary instead, and the Detail is removed. catalog may
+// be nil, to render the Template in the API's own language. A Diagnostic
+// without a Template is returned unchanged.
+//
+// Templates are rendered when Diagnostics are written by WriteHTTP, using
+// the Catalog passed to WithCatalog, if any, and when they're encoded or
+// logged by this package, so RenderMessage only needs to be called before
+// reading a Diagnostic's Summary or Detail directly.
+func (d Diagnostic) RenderMessage(catalog *Catalog, langs ...string) Diagnostic {
+	if d.Template == nil {
+		return d
+	}
+	if msg, _, ok := catalog.lookup(d.Code, langs); ok {
+		d.Summary = renderTemplate(msg, d)
+		d.Detail = ""
+	} else {
+		if d.Template.Summary != "" {
+			d.Summary = renderTemplate(d.Template.Summary, d)
+		}
+		if d.Template.Detail != "" {
+			d.Detail = renderTemplate(d.Template.Detail, d)
+		}
+	}
+	d.Template = nil
+	return d
+}
+
+// RenderMessages returns diags with Diagnostic.RenderMessage applied to
+// each Diagnostic. diags is returned as-is if none of them have a
+// Template; otherwise, a copy is returned.
+func (diags Diagnostics) RenderMessages(catalog *Catalog, langs ...string) Diagnostics {
+	var results Diagnostics
+	for pos, diag := range diags {
+		if diag.Template == nil {
+			continue
+		}
+		if results == nil {
+			results = make(Diagnostics, len(diags))
+			copy(results, diags)
+		}
+		results[pos] = diag.RenderMessage(catalog, langs...)
+	}
+	if results == nil {
+		return diags
+	}
+	return results
+}
@@ -0,0 +1,244 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+)
+
+// countingArg is a template argument that counts the times it's rendered.
+type countingArg struct {
+	value    string
+	rendered *int
+}
+
+func (a countingArg) String() string {
+	*a.rendered++
+	return a.value
+}
+
+func TestRenderMessage(t *testing.T) {
+	t.Parallel()
+
+	var catalog Catalog
+	catalog.Add("fr", map[Code]string{CodeOverflow: "{max} caractères au maximum pour {path}."})
+
+	type testCase struct {
+		diag     Diagnostic
+		catalog  *Catalog
+		langs    []string
+		expected Diagnostic
+	}
+
+	namePath := BodyPath().AddStep(ObjectPropertyStep("name"))
+	overflow := Diagnostic{Severity: DiagnosticError, Code: CodeOverflow, Paths: []Steps{namePath}}
+	tmpl := MessageTemplate{
+		Summary: "{path} can be at most {max} characters long.",
+		Detail:  "Got {length} characters.",
+		Args:    map[string]any{"max": 64, "length": 80},
+	}
+	expected := overflow
+	expected.Summary = "name can be at most 64 characters long."
+	expected.Detail = "Got 80 characters."
+
+	cases := map[string]testCase{
+		"no-template": {
+			diag:     Diagnostic{Severity: DiagnosticError, Code: CodeMissing, Summary: "Required."},
+			catalog:  &catalog,
+			langs:    []string{"fr"},
+			expected: Diagnostic{Severity: DiagnosticError, Code: CodeMissing, Summary: "Required."},
+		},
+		"no-catalog": {
+			diag:     overflow.WithTemplate(tmpl),
+			expected: expected,
+		},
+		"catalog": {
+			diag:     overflow.WithTemplate(tmpl),
+			catalog:  &catalog,
+			langs:    []string{"de", "fr"},
+			expected: Diagnostic{Severity: DiagnosticError, Code: CodeOverflow, Paths: []Steps{namePath}, Summary: "64 caractères au maximum pour name."},
+		},
+		"unknown-language": {
+			diag:     overflow.WithTemplate(tmpl),
+			catalog:  &catalog,
+			langs:    []string{"de"},
+			expected: expected,
+		},
+		"args-before-extensions": {
+			diag: Diagnostic{Severity: DiagnosticError, Code: CodeOverflow, Extensions: map[string]json.RawMessage{
+				MaxMember: json.RawMessage(`32`),
+			}}.WithTemplate(MessageTemplate{Summary: "At most {max}, not {other}.", Args: map[string]any{"max": 16}}),
+			expected: Diagnostic{Severity: DiagnosticError, Code: CodeOverflow, Extensions: map[string]json.RawMessage{
+				MaxMember: json.RawMessage(`32`),
+			}, Summary: "At most 16, not {other}."},
+		},
+		"extensions": {
+			diag: Diagnostic{Severity: DiagnosticError, Code: CodeOverflow, Extensions: map[string]json.RawMessage{
+				MaxMember: json.RawMessage(`32`),
+			}}.WithTemplate(MessageTemplate{Summary: "At most {max}."}),
+			expected: Diagnostic{Severity: DiagnosticError, Code: CodeOverflow, Extensions: map[string]json.RawMessage{
+				MaxMember: json.RawMessage(`32`),
+			}, Summary: "At most 32."},
+		},
+		"keeps-summary": {
+			diag:     Diagnostic{Severity: DiagnosticError, Code: CodeMissing, Summary: "Required."}.WithTemplate(MessageTemplate{Detail: "Set {field}.", Args: map[string]any{"field": "a name"}}),
+			expected: Diagnostic{Severity: DiagnosticError, Code: CodeMissing, Summary: "Required.", Detail: "Set a name."},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tc.expected, tc.diag.RenderMessage(tc.catalog, tc.langs...)); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestRenderMessagesDoesntCopy(t *testing.T) {
+	t.Parallel()
+
+	diags := Diagnostics{
+		{Severity: DiagnosticError, Code: CodeMissing, Summary: "Required."},
+		{Severity: DiagnosticWarning, Code: CodeDeprecated},
+	}
+	if result := diags.RenderMessages(nil); &result[0] != &diags[0] {
+		t.Error("expected Diagnostics without Templates to be returned as-is")
+	}
+	templated := append(Diagnostics{}, diags...)
+	templated[1] = templated[1].WithTemplate(MessageTemplate{Summary: "Deprecated."})
+	result := templated.RenderMessages(nil)
+	if diff := cmp.Diff(Diagnostics{diags[0], {Severity: DiagnosticWarning, Code: CodeDeprecated, Summary: "Deprecated."}}, result); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	if templated[1].Template == nil {
+		t.Error("expected the original Diagnostics to be unchanged")
+	}
+}
+
+func TestTemplateEncoding(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		marshal func(Diagnostics) ([]byte, error)
+	}
+
+	cases := map[string]testCase{
+		"json": {marshal: func(diags Diagnostics) ([]byte, error) {
+			return json.Marshal(diags)
+		}},
+		"xml": {marshal: func(diags Diagnostics) ([]byte, error) {
+			return xml.Marshal(diags)
+		}},
+		"yaml": {marshal: func(diags Diagnostics) ([]byte, error) {
+			return yaml.Marshal(diags)
+		}},
+	}
+
+	diags := Diagnostics{
+		Diagnostic{Severity: DiagnosticError, Code: CodeOverflow, Paths: []Steps{BodyPath().AddStep(ObjectPropertyStep("name"))}}.WithTemplate(MessageTemplate{
+			Summary: "{path} is too long.",
+			Detail:  "It can be at most {max} characters long.",
+			Args:    map[string]any{"max": 64},
+		}),
+	}
+	rendered := diags.RenderMessages(nil)
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			expected, err := tc.marshal(rendered)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got, err := tc.marshal(diags)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(string(expected), string(got)); diff != "" {
+				t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestTemplateRenderedOnlyWhenWritten(t *testing.T) {
+	t.Parallel()
+
+	var catalog Catalog
+	catalog.Add("fr", map[Code]string{CodeMissing: "{field} est obligatoire."})
+	var errRendered, warningRendered int
+	diags := Diagnostics{
+		Diagnostic{Severity: DiagnosticError, Code: CodeMissing}.WithTemplate(MessageTemplate{
+			Summary: "{field} is required.",
+			Args:    map[string]any{"field": countingArg{value: "name", rendered: &errRendered}},
+		}),
+		Diagnostic{Severity: DiagnosticWarning, Code: CodeDeprecated}.WithTemplate(MessageTemplate{
+			Summary: "{field} is deprecated.",
+			Args:    map[string]any{"field": countingArg{value: "nickname", rendered: &warningRendered}},
+		}),
+	}
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set(SuppressWarningsHeader, string(CodeDeprecated))
+	r.Header.Set("Accept-Language", "fr-CA, en;q=0.5")
+
+	w := httptest.NewRecorder()
+	err := WriteHTTP(w, diags, WithWarningSuppression(r), WithCatalog(&catalog, ParseAcceptLanguage(r.Header.Get("Accept-Language"))...))
+	if err != nil {
+		t.Fatalf("error writing response: %s", err)
+	}
+	var problem Problem
+	err = json.Unmarshal(w.Body.Bytes(), &problem)
+	if err != nil {
+		t.Fatalf("error parsing response: %s", err)
+	}
+	expected := Diagnostics{{Severity: DiagnosticError, Code: CodeMissing, Summary: "name est obligatoire."}}
+	if diff := cmp.Diff(expected, problem.Diagnostics); diff != "" {
+		t.Errorf("unexpected diff (-wanted, +got): %s", diff)
+	}
+	if errRendered != 1 {
+		t.Errorf("expected the error's template to be rendered once, got %d", errRendered)
+	}
+	if warningRendered != 0 {
+		t.Errorf("expected the suppressed warning's template not to be rendered, got %d", warningRendered)
+	}
+}
+
+func BenchmarkTemplate(b *testing.B) {
+	b.ReportAllocs()
+	path := BodyPath().AddStep(ObjectPropertyStep("name"))
+	for i := 0; i < b.N; i++ {
+		_ = Diagnostic{Severity: DiagnosticError, Code: CodeOverflow, Paths: []Steps{path}}.WithTemplate(MessageTemplate{
+			Summary: "{path} can be at most {max} characters long.",
+			Args:    map[string]any{"max": i},
+		})
+	}
+}
+
+// BenchmarkTemplateRendered measures rendering the Summary BenchmarkTemplate
+// defers while building the Diagnostic, for comparison.
+func BenchmarkTemplateRendered(b *testing.B) {
+	b.ReportAllocs()
+	path := BodyPath().AddStep(ObjectPropertyStep("name"))
+	for i := 0; i < b.N; i++ {
+		_ = Diagnostic{
+			Severity: DiagnosticError,
+			Code:     CodeOverflow,
+			Paths:    []Steps{path},
+			Summary:  fmt.Sprintf("%s can be at most %d characters long.", path.FieldPath(), i),
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+const (
+	// TraceIDMember is the extension member of a Diagnostic holding the ID
+	// of the trace of the request that produced it, as a string, so a
+	// Diagnostic reported by a client can be found in a tracing system.
+	TraceIDMember = "trace_id"
+	// SpanIDMember is the extension member of a Diagnostic holding the ID
+	// of the span that produced it, as a string.
+	SpanIDMember = "span_id"
+)
+
+// WithTrace returns a copy of d with traceID and spanID recorded in its
+// Extensions, as TraceIDMember and SpanIDMember. An empty ID leaves the
+// corresponding member out. apidiagsotel records the IDs of OpenTelemetry
+// spans.
+func (d Diagnostic) WithTrace(traceID, spanID string) Diagnostic {
+	if traceID != "" {
+		d = d.withExtension(TraceIDMember, json.RawMessage(strconv.Quote(traceID)))
+	}
+	if spanID != "" {
+		d = d.withExtension(SpanIDMember, json.RawMessage(strconv.Quote(spanID)))
+	}
+	return d
+}
+
+// Trace returns the IDs recorded in d's Extensions by WithTrace. Members
+// that aren't set are returned as empty strings. An error is returned if
+// either member isn't a string.
+func (d Diagnostic) Trace() (traceID, spanID string, err error) {
+	traceID, err = d.extensionString(TraceIDMember)
+	if err != nil {
+		return "", "", err
+	}
+	spanID, err = d.extensionString(SpanIDMember)
+	if err != nil {
+		return "", "", err
+	}
+	return traceID, spanID, nil
+}
+
+func (d Diagnostic) extensionString(member string) (string, error) {
+	value, ok := d.Extensions[member]
+	if !ok {
+		return "", nil
+	}
+	var result string
+	err := json.Unmarshal(value, &result)
+	if err != nil {
+		return "", fmt.Errorf("error parsing %s: %w", member, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,32 @@
+package apidiags
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDiagnosticTrace(t *testing.T) {
+	t.Parallel()
+
+	diag := Diagnostic{Severity: DiagnosticError, Code: CodeActOfGod}.WithTrace("4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7")
+	encoded, err := diag.MarshalJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := `{"severity":"error","code":"act_of_god","span_id":"00f067aa0ba902b7","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"}`
+	if string(encoded) != expected {
+		t.Errorf("expected %s, got %s", expected, encoded)
+	}
+	traceID, spanID, err := diag.Trace()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if traceID != "4bf92f3577b34da6a3ce929d0e0e4736" || spanID != "00f067aa0ba902b7" {
+		t.Errorf("unexpected IDs %q and %q", traceID, spanID)
+	}
+
+	diag.Extensions[SpanIDMember] = json.RawMessage(`123`)
+	if _, _, err := diag.Trace(); err == nil {
+		t.Error("expected an error for a span ID that isn't a string")
+	}
+}
@@ -0,0 +1,123 @@
+package apidiags
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Transport is an http.RoundTripper that retries idempotent requests whose
+// responses have retryable Diagnostics, as decided by a RetryPolicy, so
+// clients of APIs using apidiags get consistent retries without writing
+// their own loops.
+//
+// Requests are idempotent if their method is GET, HEAD, OPTIONS, TRACE,
+// PUT, or DELETE, or if they have an Idempotency-Key header. Requests with
+// a body are only retried if they have a GetBody, like those built by
+// http.NewRequest. Failed responses are parsed with ParseResponse, so
+// Retry-After headers are respected; successful responses are returned
+// without being parsed. Errors from Base are treated like a Diagnostic with
+// a Code of CodeActOfGod.
+//
+// Once the RetryPolicy decides not to retry, the last response is returned
+// with its body intact, so its Diagnostics can be parsed with
+// ParseResponse as usual.
+type Transport struct {
+	// Base is the http.RoundTripper requests are made with. It defaults to
+	// http.DefaultTransport.
+	Base http.RoundTripper
+
+	// Policy decides which responses are retried, and how long to wait
+	// before retrying them.
+	Policy RetryPolicy
+
+	// OnRetry, if set, is called before each retry with the request, the
+	// number of attempts made so far, the Diagnostics of the last attempt,
+	// and how long the Transport will wait before retrying.
+	OnRetry func(req *http.Request, attempts int, diags Diagnostics, delay time.Duration)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if !isIdempotent(req) || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return base.RoundTrip(req)
+	}
+	for attempts := 1; ; attempts++ {
+		attempt := req
+		if attempts > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attempt = req.Clone(req.Context())
+			attempt.Body = body
+		}
+		resp, err := base.RoundTrip(attempt)
+		var diags Diagnostics
+		switch {
+		case err != nil:
+			diags = Diagnostics{{Severity: DiagnosticError, Code: CodeActOfGod}}
+		case resp.StatusCode < 400:
+			return resp, nil
+		default:
+			diags, err = bufferedDiagnostics(resp)
+			if err != nil {
+				return resp, nil
+			}
+		}
+		delay, retry := t.Policy.ShouldRetry(diags, attempts)
+		if !retry || req.Context().Err() != nil {
+			return resp, err
+		}
+		if t.OnRetry != nil {
+			t.OnRetry(req, attempts, diags, delay)
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// bufferedDiagnostics parses the Diagnostics in resp, replacing its body
+// with a copy so it can still be read.
+func bufferedDiagnostics(resp *http.Response) (Diagnostics, error) {
+	original := resp.Body
+	body, err := io.ReadAll(io.LimitReader(original, DefaultMaxResponseBytes+1))
+	resp.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), original), Closer: original}
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > DefaultMaxResponseBytes {
+		return nil, ErrResponseTooLarge
+	}
+	parsed := *resp
+	parsed.Body = io.NopCloser(bytes.NewReader(body))
+	return ParseResponse(&parsed)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// isIdempotent returns true if retrying req can't change its outcome.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
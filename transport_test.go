@@ -0,0 +1,151 @@
+package apidiags
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		method    string
+		body      string
+		failures  int64
+		code      Code
+		header    http.Header
+		status    int
+		requests  int64
+		retries   int
+		finalCode Code
+	}
+
+	cases := map[string]testCase{
+		"success": {
+			method:   http.MethodGet,
+			status:   http.StatusOK,
+			requests: 1,
+		},
+		"retried": {
+			method:   http.MethodGet,
+			failures: 2,
+			code:     CodeActOfGod,
+			status:   http.StatusOK,
+			requests: 3,
+			retries:  2,
+		},
+		"retried-with-body": {
+			method:   http.MethodPut,
+			body:     `{"name":"widget"}`,
+			failures: 1,
+			code:     CodeActOfGod,
+			status:   http.StatusOK,
+			requests: 2,
+			retries:  1,
+		},
+		"exhausted": {
+			method:    http.MethodGet,
+			failures:  10,
+			code:      CodeActOfGod,
+			status:    http.StatusServiceUnavailable,
+			requests:  DefaultRetryAttempts,
+			retries:   DefaultRetryAttempts - 1,
+			finalCode: CodeActOfGod,
+		},
+		"not-retryable": {
+			method:    http.MethodGet,
+			failures:  10,
+			code:      CodeNotFound,
+			status:    http.StatusNotFound,
+			requests:  1,
+			finalCode: CodeNotFound,
+		},
+		"not-idempotent": {
+			method:    http.MethodPost,
+			body:      `{"name":"widget"}`,
+			failures:  10,
+			code:      CodeActOfGod,
+			status:    http.StatusServiceUnavailable,
+			requests:  1,
+			finalCode: CodeActOfGod,
+		},
+		"idempotency-key": {
+			method:   http.MethodPost,
+			body:     `{"name":"widget"}`,
+			header:   http.Header{"Idempotency-Key": []string{"abc123"}},
+			failures: 1,
+			code:     CodeActOfGod,
+			status:   http.StatusOK,
+			requests: 2,
+			retries:  1,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var requests int64
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.body != "" {
+					body, err := io.ReadAll(r.Body)
+					if err != nil || string(body) != tc.body {
+						t.Errorf("expected body %q, got %q (%v)", tc.body, body, err)
+					}
+				}
+				if atomic.AddInt64(&requests, 1) <= tc.failures {
+					_ = WriteHTTP(w, Diagnostics{{Severity: DiagnosticError, Code: tc.code}})
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			var retries int
+			client := &http.Client{Transport: &Transport{
+				Base:   server.Client().Transport,
+				Policy: RetryPolicy{BaseDelay: time.Millisecond},
+				OnRetry: func(_ *http.Request, attempts int, diags Diagnostics, _ time.Duration) {
+					retries++
+					if attempts != retries || !diags.HasErrorCode(tc.code) {
+						t.Errorf("unexpected retry after attempt %d with %+v", attempts, diags)
+					}
+				},
+			}}
+			req, err := http.NewRequest(tc.method, server.URL, strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			for key, values := range tc.header {
+				req.Header[key] = values
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if resp.StatusCode != tc.status {
+				t.Errorf("expected status %d, got %d", tc.status, resp.StatusCode)
+			}
+			if got := atomic.LoadInt64(&requests); got != tc.requests {
+				t.Errorf("expected %d requests, got %d", tc.requests, got)
+			}
+			if retries != tc.retries {
+				t.Errorf("expected %d retries, got %d", tc.retries, retries)
+			}
+			diags, err := ParseResponse(resp)
+			if err != nil {
+				t.Fatalf("unexpected error parsing the final response: %s", err)
+			}
+			if tc.finalCode != "" && !diags.HasErrorCode(tc.finalCode) {
+				t.Errorf("expected the final response to have a %s error, got %+v", tc.finalCode, diags)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package apidiags
+
+import (
+	"strconv"
+	"strings"
+)
+
+// TypeScriptDefinitions returns TypeScript type definitions for the JSON
+// encoding of Diagnostics, so frontends can consume them with type checking.
+//
+// Steps are a discriminated union on their kind, and Codes are a union of
+// the Codes defined by this package and codes. As servers can return Codes
+// the definitions don't know about, the Code union also accepts any other
+// string, without losing editor completion of the known Codes.
+func TypeScriptDefinitions(codes ...Code) string {
+	var buf strings.Builder
+	buf.WriteString("// Code generated by impractical.co/apidiags. DO NOT EDIT.\n\n")
+
+	buf.WriteString("export type Severity = ")
+	buf.WriteString(strconv.Quote(string(DiagnosticError)))
+	buf.WriteString(" | ")
+	buf.WriteString(strconv.Quote(string(DiagnosticWarning)))
+	buf.WriteString(";\n\n")
+
+	buf.WriteString("export type Code =\n")
+	seen := map[Code]bool{}
+	for _, code := range append(append([]Code{}, builtinCodes...), codes...) {
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+		buf.WriteString("  | ")
+		buf.WriteString(strconv.Quote(string(code)))
+		buf.WriteString("\n")
+	}
+	buf.WriteString("  | (string & {});\n\n")
+
+	buf.WriteString("export type Step =\n")
+	for pos, kind := range wireStepKinds {
+		buf.WriteString("  | { kind: ")
+		buf.WriteString(strconv.Quote(kind.kind))
+		switch kind.valueType {
+		case "string":
+			buf.WriteString("; value: string")
+		case "integer":
+			buf.WriteString("; value: number")
+		}
+		buf.WriteString(" }")
+		if pos == len(wireStepKinds)-1 {
+			buf.WriteString(";")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\n")
+
+	buf.WriteString(`export type Steps = Step[];
+
+export interface Diagnostic {
+  severity: Severity;
+  code: Code;
+  path?: Steps[];
+  summary?: string;
+  detail?: string;
+  doc_url?: string;
+}
+
+export type Diagnostics = Diagnostic[];
+`)
+	return buf.String()
+}
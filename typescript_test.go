@@ -0,0 +1,56 @@
+package apidiags
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTypeScriptDefinitions(t *testing.T) {
+	t.Parallel()
+
+	expected := `// Code generated by impractical.co/apidiags. DO NOT EDIT.
+
+export type Severity = "error" | "warning";
+
+export type Code =
+  | "access_denied"
+  | "insufficient"
+  | "overflow"
+  | "invalid_value"
+  | "invalid_format"
+  | "missing"
+  | "not_found"
+  | "conflict"
+  | "act_of_god"
+  | "deprecated"
+  | "quota_exceeded"
+  | (string & {});
+
+export type Step =
+  | { kind: "body" }
+  | { kind: "header"; value: string }
+  | { kind: "url_param"; value: string }
+  | { kind: "array_index"; value: number }
+  | { kind: "object_property"; value: string }
+  | { kind: "string_index"; value: number }
+  | { kind: "rune_index"; value: number };
+
+export type Steps = Step[];
+
+export interface Diagnostic {
+  severity: Severity;
+  code: Code;
+  path?: Steps[];
+  summary?: string;
+  detail?: string;
+  doc_url?: string;
+}
+
+export type Diagnostics = Diagnostic[];
+`
+	result := TypeScriptDefinitions("quota_exceeded", CodeMissing)
+	if diff := cmp.Diff(expected, result); diff != "" {
+		t.Errorf("unexpected definitions (-wanted, +got): %s", diff)
+	}
+}
@@ -0,0 +1,86 @@
+package apidiags
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// WireVersion is the version of the JSON wire format this package produces
+// for Diagnostics. It's incremented whenever the wire format changes in a
+// way older decoders can't handle, like a new step kind or a renamed field.
+const WireVersion = 1
+
+// ErrUnsupportedWireVersion is returned when decoding Diagnostics encoded
+// with a newer version of the wire format than this package knows about.
+var ErrUnsupportedWireVersion = errors.New("unsupported wire format version")
+
+// versionedDiagnostics is the envelope Diagnostics are wrapped in by
+// MarshalVersionedDiagnostics.
+type versionedDiagnostics struct {
+	Version     int             `json:"version"`
+	Diagnostics json.RawMessage `json:"diagnostics"`
+}
+
+// wireMigrations upgrade the encoded Diagnostics of one version of the wire
+// format to the next. The migration at index n upgrades version n to version
+// n+1, so len(wireMigrations) must always equal WireVersion.
+var wireMigrations = []func(json.RawMessage) (json.RawMessage, error){
+	// Version 0 is the unversioned format, a bare array of Diagnostics,
+	// which version 1 wraps in an envelope without changing.
+	func(in json.RawMessage) (json.RawMessage, error) { return in, nil },
+}
+
+// MarshalVersionedDiagnostics encodes diags as JSON, wrapped in an envelope
+// recording the wire format version, like
+// `{"version": 1, "diagnostics": [...]}`. DecodeVersionedDiagnostics can
+// decode the result even after the wire format has changed.
+func MarshalVersionedDiagnostics(diags Diagnostics) ([]byte, error) {
+	if diags == nil {
+		diags = Diagnostics{}
+	}
+	encoded, err := json.Marshal(diags)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(versionedDiagnostics{Version: WireVersion, Diagnostics: encoded})
+}
+
+// DecodeVersionedDiagnostics decodes Diagnostics encoded by
+// MarshalVersionedDiagnostics, upgrading Diagnostics encoded by older
+// versions of the wire format to the current one. A bare JSON array of
+// Diagnostics is treated as version 0, the unversioned format.
+//
+// If in was encoded with a newer version of the wire format than
+// WireVersion, an error wrapping ErrUnsupportedWireVersion is returned.
+func DecodeVersionedDiagnostics(in []byte) (Diagnostics, error) {
+	envelope := versionedDiagnostics{Diagnostics: in}
+	trimmed := bytes.TrimSpace(in)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		err := json.Unmarshal(in, &envelope)
+		if err != nil {
+			return nil, err
+		}
+		if envelope.Version < 1 {
+			return nil, fmt.Errorf("invalid wire format version %d", envelope.Version)
+		}
+	}
+	if envelope.Version > WireVersion {
+		return nil, fmt.Errorf("%w %d, only versions up to %d are supported", ErrUnsupportedWireVersion, envelope.Version, WireVersion)
+	}
+	encoded := envelope.Diagnostics
+	for version := envelope.Version; version < WireVersion; version++ {
+		var err error
+		encoded, err = wireMigrations[version](encoded)
+		if err != nil {
+			return nil, fmt.Errorf("error upgrading from version %d: %w", version, err)
+		}
+	}
+	var results Diagnostics
+	err := json.Unmarshal(encoded, &results)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
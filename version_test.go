@@ -0,0 +1,109 @@
+package apidiags
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWireMigrationsCoverVersions(t *testing.T) {
+	t.Parallel()
+
+	if len(wireMigrations) != WireVersion {
+		t.Fatalf("expected %d migrations, got %d", WireVersion, len(wireMigrations))
+	}
+}
+
+func TestVersionedDiagnosticsRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	diags := Diagnostics{
+		{
+			Severity: DiagnosticError,
+			Code:     CodeMissing,
+			Paths:    []Steps{BodyPath().AddStep(ObjectPropertyStep("name"))},
+		},
+	}
+	encoded, err := MarshalVersionedDiagnostics(diags)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := `{"version":1,"diagnostics":[{"severity":"error","code":"missing","path":[[{"kind":"body"},{"kind":"object_property","value":"name"}]]}]}`
+	if string(encoded) != expected {
+		t.Errorf("expected %s, got %s", expected, encoded)
+	}
+	decoded, err := DecodeVersionedDiagnostics(encoded)
+	if err != nil {
+		t.Fatalf("unexpected error decoding: %s", err)
+	}
+	if diff := cmp.Diff(diags, decoded); diff != "" {
+		t.Errorf("unexpected results (-wanted, +got): %s", diff)
+	}
+}
+
+func TestDecodeVersionedDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    string
+		expected Diagnostics
+		wantErr  error
+	}
+
+	cases := map[string]testCase{
+		"unversioned": {
+			input: ` [{"severity": "warning", "code": "deprecated", "path": [[{"kind": "header", "value": "X-Legacy"}]]}]`,
+			expected: Diagnostics{
+				{Severity: DiagnosticWarning, Code: CodeDeprecated, Paths: []Steps{HeaderPath("X-Legacy")}},
+			},
+		},
+		"current": {
+			input: `{"version": 1, "diagnostics": [{"severity": "error", "code": "not_found"}]}`,
+			expected: Diagnostics{
+				{Severity: DiagnosticError, Code: CodeNotFound},
+			},
+		},
+		"newer": {
+			input:   `{"version": 99, "diagnostics": []}`,
+			wantErr: ErrUnsupportedWireVersion,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := DecodeVersionedDiagnostics([]byte(tc.input))
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Fatalf("unexpected results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestDecodeVersionedDiagnosticsInvalid(t *testing.T) {
+	t.Parallel()
+
+	for name, input := range map[string]string{
+		"zero-version":    `{"version": 0, "diagnostics": []}`,
+		"missing-version": `{"diagnostics": []}`,
+		"not-json":        `nope`,
+	} {
+		name, input := name, input
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := DecodeVersionedDiagnostics([]byte(input))
+			if err == nil {
+				t.Fatalf("expected error, got %+v", result)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package apidiags
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+)
+
+// MarshalXML encodes Diagnostics as an element containing a diagnostic
+// element per Diagnostic, using the same kind and value scheme for Steps as
+// their JSON encoding:
+//
+//	<diagnostics>
+//	  <diagnostic severity="error" code="missing">
+//	    <path>
+//	      <step kind="body"/>
+//	      <step kind="object_property">items</step>
+//	      <step kind="array_index">3</step>
+//	    </path>
+//	    <summary>Name is required.</summary>
+//	    <detail>Every item needs a name.</detail>
+//	    <doc_url>https://example.com/errors/missing</doc_url>
+//	  </diagnostic>
+//	</diagnostics>
+//
+// Each Path of a Diagnostic is a path element, containing a step element per
+// Step. A step's kind attribute is the same as the kind member of its JSON
+// encoding, and its character data is its value, if it has one. The summary,
+// detail, and doc_url elements are omitted when empty, and are rendered from
+// the Diagnostic's Template, if it has one. The name of the outer element is
+// chosen by the caller, as with any other type.
+func (diags Diagnostics) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	err := enc.EncodeToken(start)
+	if err != nil {
+		return err
+	}
+	for pos, diag := range diags {
+		err = enc.EncodeElement(diag.RenderMessage(nil), xml.StartElement{Name: xml.Name{Local: "diagnostic"}})
+		if err != nil {
+			return fmt.Errorf("error encoding diagnostic %d: %w", pos, err)
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// UnmarshalXML decodes an element containing diagnostic elements into
+// Diagnostics.
+func (diags *Diagnostics) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	results := Diagnostics{}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if tok.Name.Local != "diagnostic" {
+				return fmt.Errorf("unexpected element %q", tok.Name.Local)
+			}
+			var diag Diagnostic
+			err = dec.DecodeElement(&diag, &tok)
+			if err != nil {
+				return fmt.Errorf("error parsing diagnostic %d: %w", len(results), err)
+			}
+			results = append(results, diag)
+		case xml.EndElement:
+			*diags = results
+			return nil
+		}
+	}
+}
+
+type xmlStep struct {
+	Kind  string `xml:"kind,attr"`
+	Value string `xml:",chardata"`
+}
+
+// MarshalXML encodes Steps as an element containing a step element per Step.
+func (steps Steps) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	err := enc.EncodeToken(start)
+	if err != nil {
+		return err
+	}
+	for pos, step := range steps {
+		genStep, err := toGenericStep(step)
+		if err != nil {
+			return fmt.Errorf("%w for step %d", err, pos)
+		}
+		encoded := xmlStep{Kind: genStep.Kind}
+		encoded.Value = genStep.Value.String()
+		err = enc.EncodeElement(encoded, xml.StartElement{Name: xml.Name{Local: "step"}})
+		if err != nil {
+			return fmt.Errorf("error encoding step %d: %w", pos, err)
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// UnmarshalXML decodes an element containing step elements into Steps.
+func (steps *Steps) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	results := Steps{}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if tok.Name.Local != "step" {
+				return fmt.Errorf("unexpected element %q", tok.Name.Local)
+			}
+			var encoded xmlStep
+			err = dec.DecodeElement(&encoded, &tok)
+			if err != nil {
+				return fmt.Errorf("error parsing step %d: %w", len(results), err)
+			}
+			step, err := encoded.genericStep().toStep()
+			if err != nil {
+				return fmt.Errorf("error parsing step %d: %w", len(results), err)
+			}
+			results = append(results, step)
+		case xml.EndElement:
+			*steps = results
+			return nil
+		}
+	}
+}
+
+// genericStep converts an xmlStep into a genericStep, using the kind to
+// determine whether the value is a string or a number.
+func (step xmlStep) genericStep() genericStep {
+	result := genericStep{Kind: step.Kind}
+	switch step.Kind {
+	case "body":
+	case "array_index", "string_index", "rune_index":
+		result.Value = numberStepValue(step.Value)
+		if _, err := strconv.ParseInt(step.Value, 10, 64); err != nil {
+			result.Value = stringStepValue(step.Value)
+		}
+	default:
+		result.Value = stringStepValue(step.Value)
+	}
+	return result
+}
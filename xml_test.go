@@ -0,0 +1,116 @@
+package apidiags
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type xmlDiagnosticsDocument struct {
+	XMLName     xml.Name    `xml:"response"`
+	Diagnostics Diagnostics `xml:"diagnostics"`
+}
+
+func TestDiagnosticsXMLRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags    Diagnostics
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"empty": {
+			diags:    Diagnostics{},
+			expected: `<response><diagnostics></diagnostics></response>`,
+		},
+		"all-fields": {
+			diags: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     CodeMissing,
+					Summary:  "Name is required.",
+					Detail:   "Every item needs a name.",
+					DocURL:   "https://example.com/errors/missing",
+					Paths: []Steps{
+						BodyPath().AddSteps(ObjectPropertyStep("items"), ArrayIndexStep(3), ObjectPropertyStep("name")),
+					},
+				},
+			},
+			expected: `<response><diagnostics><diagnostic severity="error" code="missing"><path>` +
+				`<step kind="body"></step><step kind="object_property">items</step>` +
+				`<step kind="array_index">3</step><step kind="object_property">name</step>` +
+				`</path><summary>Name is required.</summary><detail>Every item needs a name.</detail>` +
+				`<doc_url>https://example.com/errors/missing</doc_url></diagnostic></diagnostics></response>`,
+		},
+		"every-step-kind": {
+			diags: Diagnostics{
+				{
+					Severity: DiagnosticWarning,
+					Code:     CodeDeprecated,
+					Paths: []Steps{
+						HeaderPath("X-Legacy"),
+						URLParamPath("id"),
+						BodyPath().AddSteps(ObjectPropertyStep("a<b"), StringIndexStep(2)),
+						BodyPath().AddSteps(ObjectPropertyStep(""), RuneIndexStep(4)),
+					},
+				},
+			},
+			expected: `<response><diagnostics><diagnostic severity="warning" code="deprecated">` +
+				`<path><step kind="header">X-Legacy</step></path>` +
+				`<path><step kind="url_param">id</step></path>` +
+				`<path><step kind="body"></step><step kind="object_property">a&lt;b</step><step kind="string_index">2</step></path>` +
+				`<path><step kind="body"></step><step kind="object_property"></step><step kind="rune_index">4</step></path>` +
+				`</diagnostic></diagnostics></response>`,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			encoded, err := xml.Marshal(xmlDiagnosticsDocument{Diagnostics: tc.diags})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if string(encoded) != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, encoded)
+			}
+			var decoded xmlDiagnosticsDocument
+			err = xml.Unmarshal(encoded, &decoded)
+			if err != nil {
+				t.Fatalf("unexpected error decoding: %s", err)
+			}
+			if diff := cmp.Diff(tc.diags, decoded.Diagnostics); diff != "" {
+				t.Errorf("unexpected round trip results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestStepsUnmarshalXMLErrors(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"unknown-kind":    `<path><step kind="cookie">session</step></path>`,
+		"non-numeric":     `<path><step kind="body"></step><step kind="array_index">three</step></path>`,
+		"unexpected-elem": `<path><body/></path>`,
+	}
+
+	for name, input := range cases {
+		name, input := name, input
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var steps Steps
+			err := xml.Unmarshal([]byte(input), &steps)
+			if err == nil {
+				t.Fatalf("expected error, got %+v", steps)
+			}
+		})
+	}
+}
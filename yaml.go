@@ -0,0 +1,78 @@
+package apidiags
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// yamlStep is the YAML representation of a Step, mirroring genericStep.
+type yamlStep struct {
+	Kind  string `yaml:"kind"`
+	Value any    `yaml:"value,omitempty"`
+}
+
+// MarshalYAML encodes Steps as a sequence of mappings with kind and value
+// keys, using the same scheme as their JSON encoding. It implements the
+// Marshaler interface of both gopkg.in/yaml.v2 and gopkg.in/yaml.v3, without
+// this package depending on either.
+func (steps Steps) MarshalYAML() (any, error) {
+	results := make([]yamlStep, 0, len(steps))
+	for pos, step := range steps {
+		genStep, err := toGenericStep(step)
+		if err != nil {
+			return nil, fmt.Errorf("%w for step %d", err, pos)
+		}
+		encoded := yamlStep{Kind: genStep.Kind}
+		encoded.Value = genStep.Value.boxed()
+		results = append(results, encoded)
+	}
+	return results, nil
+}
+
+// UnmarshalYAML decodes Steps encoded by MarshalYAML. It implements the
+// Unmarshaler interface of gopkg.in/yaml.v2, which gopkg.in/yaml.v3 also
+// supports.
+func (steps *Steps) UnmarshalYAML(unmarshal func(any) error) error {
+	var encoded []yamlStep
+	err := unmarshal(&encoded)
+	if err != nil {
+		return err
+	}
+	results := make(Steps, 0, len(encoded))
+	for pos, yStep := range encoded {
+		step, err := yStep.genericStep().toStep()
+		if err != nil {
+			return fmt.Errorf("error parsing step %d: %w", pos, err)
+		}
+		results = append(results, step)
+	}
+	*steps = results
+	return nil
+}
+
+// genericStep converts a yamlStep into a genericStep, keeping the strings
+// and integers YAML decoders produce without boxing them again.
+func (step yamlStep) genericStep() genericStep {
+	result := genericStep{Kind: step.Kind}
+	switch val := step.Value.(type) {
+	case string:
+		result.Value = stringStepValue(val)
+	case int:
+		result.Value = intStepValue(int64(val))
+	case int64:
+		result.Value = intStepValue(val)
+	case uint64:
+		result.Value = numberStepValue(strconv.FormatUint(val, 10))
+	default:
+		result.Value = otherStepValue(val)
+	}
+	return result
+}
+
+// MarshalYAML encodes a Diagnostic as a mapping of its fields, named by
+// their yaml tags, with its Summary and Detail rendered from its Template,
+// if it has one. Like Steps.MarshalYAML, it implements the Marshaler
+// interface of both gopkg.in/yaml.v2 and gopkg.in/yaml.v3.
+func (d Diagnostic) MarshalYAML() (any, error) {
+	return diagnosticJSON(d.RenderMessage(nil)), nil
+}
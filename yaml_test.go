@@ -0,0 +1,129 @@
+package apidiags
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDiagnosticsYAMLRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		diags    Diagnostics
+		expected string
+	}
+
+	cases := map[string]testCase{
+		"all-fields": {
+			diags: Diagnostics{
+				{
+					Severity: DiagnosticError,
+					Code:     CodeMissing,
+					Summary:  "Name is required.",
+					Detail:   "Every item needs a name.",
+					DocURL:   "https://example.com/errors/missing",
+					Paths: []Steps{
+						BodyPath().AddSteps(ObjectPropertyStep("items"), ArrayIndexStep(3), ObjectPropertyStep("name")),
+					},
+				},
+			},
+			expected: `- severity: error
+  code: missing
+  path:
+    - - kind: body
+      - kind: object_property
+        value: items
+      - kind: array_index
+        value: 3
+      - kind: object_property
+        value: name
+  summary: Name is required.
+  detail: Every item needs a name.
+  doc_url: https://example.com/errors/missing
+`,
+		},
+		"every-step-kind": {
+			diags: Diagnostics{
+				{
+					Severity: DiagnosticWarning,
+					Code:     CodeDeprecated,
+					Paths: []Steps{
+						HeaderPath("X-Legacy"),
+						URLParamPath("true"),
+						BodyPath().AddSteps(ObjectPropertyStep("3"), StringIndexStep(2)),
+						BodyPath().AddSteps(ObjectPropertyStep("name"), RuneIndexStep(4)),
+					},
+				},
+			},
+			expected: `- severity: warning
+  code: deprecated
+  path:
+    - - kind: header
+        value: X-Legacy
+    - - kind: url_param
+        value: "true"
+    - - kind: body
+      - kind: object_property
+        value: "3"
+      - kind: string_index
+        value: 2
+    - - kind: body
+      - kind: object_property
+        value: name
+      - kind: rune_index
+        value: 4
+`,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			encoded, err := yaml.Marshal(tc.diags)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(tc.expected, string(encoded)); diff != "" {
+				t.Errorf("unexpected YAML (-wanted, +got): %s", diff)
+			}
+			var decoded Diagnostics
+			err = yaml.Unmarshal(encoded, &decoded)
+			if err != nil {
+				t.Fatalf("unexpected error decoding: %s", err)
+			}
+			if diff := cmp.Diff(tc.diags, decoded); diff != "" {
+				t.Errorf("unexpected round trip results (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestStepsUnmarshalYAMLErrors(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"unknown-kind": `[{kind: cookie, value: session}]`,
+		"wrong-type":   `[{kind: body}, {kind: array_index, value: three}]`,
+		"no-value":     `[{kind: header}]`,
+		"not-a-list":   `{kind: body}`,
+	}
+
+	for name, input := range cases {
+		name, input := name, input
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var steps Steps
+			err := yaml.Unmarshal([]byte(input), &steps)
+			if err == nil {
+				t.Fatalf("expected error, got %+v", steps)
+			}
+		})
+	}
+}